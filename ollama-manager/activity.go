@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/activity"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/service"
+)
+
+const (
+	// activityLines is how much of the server log is read for traffic.
+	activityLines = 5000
+	// activityMinutes is the span of the requests-per-minute chart.
+	activityMinutes = 30
+	// recentRequests is how many of the latest requests are listed.
+	recentRequests = 10
+)
+
+// errRemoteLog explains the missing traffic for a remote server.
+var errRemoteLog = errors.New("the request log is only read for a local server")
+
+// activityState is the activity monitor: traffic from the server log and
+// which loaded models are in use.
+type activityState struct {
+	reqs    []activity.Request
+	err     error
+	loading bool
+	read    time.Time // when the log was last read
+
+	// expiry and lastUsed tell when each loaded model last served a
+	// request, from its keep-alive expiry moving, as in idleWatch.
+	expiry   map[string]time.Time
+	lastUsed map[string]time.Time
+}
+
+// activityMsg carries the requests read from the server log.
+type activityMsg struct {
+	reqs []activity.Request
+	err  error
+}
+
+func readActivity(name string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		log, err := service.Log(ctx, name, activityLines)
+		if err != nil {
+			return activityMsg{err: err}
+		}
+		return activityMsg{reqs: activity.Parse(log)}
+	}
+}
+
+func (m model) openActivity() (tea.Model, tea.Cmd) {
+	m.mode = modeActivity
+	if m.activity == nil {
+		m.activity = &activityState{expiry: make(map[string]time.Time), lastUsed: make(map[string]time.Time)}
+		m.activity.observe(time.Now(), m.runningList())
+	}
+	return m, m.pollActivity()
+}
+
+// pollActivity re-reads the server log unless a read is in flight.
+func (m *model) pollActivity() tea.Cmd {
+	a := m.activity
+	if !m.client.Local() {
+		a.err = errRemoteLog
+		return nil
+	}
+	if a.loading {
+		return nil
+	}
+	a.loading = true
+	return readActivity(m.serviceName())
+}
+
+func (m *model) finishActivity(msg activityMsg) {
+	a := m.activity
+	a.loading = false
+	a.reqs, a.err, a.read = msg.reqs, msg.err, time.Now()
+}
+
+// observe notes which models served a request since the last refresh.
+// Models seen for the first time are not counted, as it isn't known when
+// they were last used.
+func (a *activityState) observe(now time.Time, running []ollama.RunningModel) {
+	seen := make(map[string]time.Time, len(running))
+	for _, r := range running {
+		seen[r.Name] = r.ExpiresAt
+		if prev, ok := a.expiry[r.Name]; ok && !prev.Equal(r.ExpiresAt) {
+			a.lastUsed[r.Name] = now
+		}
+	}
+	a.expiry = seen
+}
+
+// runningList returns the loaded models by name.
+func (m model) runningList() []ollama.RunningModel {
+	list := make([]ollama.RunningModel, 0, len(m.running))
+	for _, r := range m.running {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// generating guesses which model is running inference. Ollama doesn't
+// report requests in flight, but a busy GPU with a model loaded means
+// one is, most likely the one that last served a request.
+func (m model) generating() (string, int, bool) {
+	if !m.client.Local() || len(m.running) == 0 {
+		return "", 0, false
+	}
+	util := 0
+	for _, d := range m.gpus {
+		util = max(util, d.Utilization)
+	}
+	if util < defaultHogUtil {
+		return "", util, false
+	}
+	name, last := "", time.Time{}
+	for _, r := range m.runningList() {
+		if t := m.activity.lastUsed[r.Name]; name == "" || t.After(last) {
+			name, last = r.Name, t
+		}
+	}
+	return name, util, true
+}
+
+func (m model) updateActivity(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.String() == "esc", key.Matches(msg, m.keys.Quit):
+		m.mode = modeList
+	case key.Matches(msg, m.keys.Refresh):
+		return m, tea.Batch(m.refresh(), m.pollActivity())
+	}
+	return m, nil
+}
+
+func (m model) activityView() string {
+	a := m.activity
+	now := time.Now()
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Activity"))
+	if !a.read.IsZero() {
+		b.WriteString(helpStyle.Render("  from the server log, read " + formatAgo(a.read)))
+	}
+	b.WriteString("\n\n")
+
+	hour := activity.Since(a.reqs, now.Add(-time.Hour))
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	switch {
+	case a.err != nil:
+		fmt.Fprintf(tw, "Requests\t%s\n", errorStyle.Render(a.err.Error()))
+	case a.read.IsZero():
+		fmt.Fprintf(tw, "Requests\tReading the server log...\n")
+	default:
+		errs, inference := 0, 0
+		for _, r := range hour {
+			if r.Status >= 400 {
+				errs++
+			}
+			if r.Inference() {
+				inference++
+			}
+		}
+		perMin := activity.PerMinute(a.reqs, now, activityMinutes)
+		fmt.Fprintf(tw, "Requests\t%d in the last minute, %d in the last hour (%d inference, %d failed)\n",
+			perMin[len(perMin)-1], len(hour), inference, errs)
+		fmt.Fprintf(tw, "\t%s %s\n", sparkline(perMin), helpStyle.Render(fmt.Sprintf("per minute, last %d min", activityMinutes)))
+	}
+	switch name, util, ok := m.generating(); {
+	case ok:
+		fmt.Fprintf(tw, "Generating\t%s %s\n", loadedStyle.Render(name), helpStyle.Render(fmt.Sprintf("(GPU %d%%)", util)))
+	case len(m.gpus) == 0 || !m.client.Local():
+		fmt.Fprintf(tw, "Generating\t%s\n", helpStyle.Render("unknown without GPU readings"))
+	default:
+		fmt.Fprintf(tw, "Generating\tnothing (GPU %d%%)\n", util)
+	}
+	fmt.Fprintf(tw, "Queue\t%s\n", helpStyle.Render("not reported by Ollama; 503s below mean it was full"))
+	tw.Flush()
+
+	b.WriteString("\n")
+	b.WriteString(titleStyle.Render("Loaded models"))
+	b.WriteString("\n")
+	if len(m.running) == 0 {
+		b.WriteString(helpStyle.Render("  None"))
+		b.WriteString("\n")
+	} else {
+		tw = tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  MODEL\tPROCESSOR\tLAST REQUEST\tKEEP-ALIVE")
+		for _, r := range m.runningList() {
+			last := "—"
+			if t, ok := a.lastUsed[r.Name]; ok {
+				last = formatAgo(t)
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", r.Name, r.Processor(), last, strings.TrimSpace(m.expiryLabel(r.Name)))
+		}
+		tw.Flush()
+	}
+
+	if len(hour) > 0 {
+		b.WriteString("\n")
+		b.WriteString(titleStyle.Render("Clients"))
+		b.WriteString(helpStyle.Render("  last hour"))
+		b.WriteString("\n")
+		tw = tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  ADDRESS\tREQUESTS\tFAILED\tLAST")
+		for _, c := range activity.ByClient(hour) {
+			fmt.Fprintf(tw, "  %s\t%d\t%d\t%s\n", c.Addr, c.Requests, c.Errors, formatAgo(c.Last))
+		}
+		tw.Flush()
+	}
+
+	if len(a.reqs) > 0 {
+		b.WriteString("\n")
+		b.WriteString(titleStyle.Render("Recent requests"))
+		b.WriteString("\n")
+		tw = tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		for i := len(a.reqs) - 1; i >= max(len(a.reqs)-recentRequests, 0); i-- {
+			r := a.reqs[i]
+			status := fmt.Sprint(r.Status)
+			if r.Status >= 400 {
+				status = errorStyle.Render(status)
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s %s\t%s\t%s\n", r.Time.Format("15:04:05"), r.Client, r.Method, r.Path,
+				r.Latency.Round(time.Millisecond), status)
+		}
+		tw.Flush()
+	}
+
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render(helpLine(m.keys.Refresh) + "  Esc: Back"))
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("\nStatus: %s", m.status))
+	return b.String()
+}
+
+// sparkBars are the levels of a sparkline, lowest first.
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws counts scaled to the largest.
+func sparkline(counts []int) string {
+	top := 0
+	for _, n := range counts {
+		top = max(top, n)
+	}
+	return sparklineTo(counts, top)
+}
+
+// sparklineTo draws counts scaled to top, such as 100 for percentages.
+func sparklineTo(counts []int, top int) string {
+	var b strings.Builder
+	for _, n := range counts {
+		i := 0
+		if top > 0 {
+			i = min(max(n, 0)*(len(sparkBars)-1)/top, len(sparkBars)-1)
+		}
+		b.WriteRune(sparkBars[i])
+	}
+	return b.String()
+}
+
+// formatAgo is formatAge with seconds for the last minute.
+func formatAgo(t time.Time) string {
+	if d := time.Since(t); d < time.Minute {
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	}
+	return formatAge(t)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"ollama-manager/internal/gpu"
+	"ollama-manager/internal/library"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/quant"
+	"ollama-manager/internal/vram"
+)
+
+// tradeoffWidth is the width of quantTradeoffs, for padding rows without it.
+const tradeoffWidth = 17
+
+// quantTradeoffs renders a level's quality and its speed relative to
+// Q4_K_M, e.g. "★★★★☆ 1.00× speed", or blanks for an unknown level.
+func quantTradeoffs(l quant.Level) string {
+	if l.Quality == 0 {
+		return strings.Repeat(" ", tradeoffWidth)
+	}
+	return fmt.Sprintf("%s %4.2f× speed", l.Stars(), l.Speed())
+}
+
+// suggestedTags marks, for each parameter size among tags, the quantized
+// tag the advisor recommends for the GPUs. Tags without a quant in their
+// name, such as "8b", are left out.
+func (m model) suggestedTags(tags []library.Tag) map[int]bool {
+	total, _ := m.totalVRAM()
+	groups := make(map[float64][]int)
+	advice := make(map[int]quant.Advice)
+	for i, t := range tags {
+		l, ok := quant.Lookup(quant.Of(t.Name))
+		if !ok {
+			continue
+		}
+		params := quant.Params(t.Name)
+		groups[params] = append(groups[params], i)
+		advice[i] = quant.ForSize(l, t.Size, total)
+	}
+	marked := make(map[int]bool, len(groups))
+	for _, idx := range groups {
+		sub := make([]quant.Advice, len(idx))
+		for j, i := range idx {
+			sub[j] = advice[i]
+		}
+		if best := quant.Best(sub); best >= 0 {
+			marked[idx[best]] = true
+		}
+	}
+	return marked
+}
+
+// pullAdvice suggests a quant while a model with a parameter size but no
+// quant, such as "qwen3:32b", is typed into the pull prompt.
+func (m model) pullAdvice(name string) string {
+	name = strings.TrimSpace(name)
+	params := quant.Params(name)
+	if params == 0 || quant.Of(name) != "" {
+		return ""
+	}
+	total, ok := m.totalVRAM()
+	advice, best := quant.Advise(params, total)
+	a := advice[best]
+	on := ""
+	if ok {
+		on = " on " + formatVRAM(total)
+	}
+	return fmt.Sprintf("Suggested for %s%s: %s, %s, %s %s", formatParams(params), on, a.Name,
+		formatBytes(uint64(a.Size)), a.Stars(), a.Note)
+}
+
+// formatParams renders a parameter count, e.g. 32.8 as "32.8B".
+func formatParams(billions float64) string {
+	if billions < 1 {
+		return fmt.Sprintf("%.0fM", billions*1000)
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", billions), ".0") + "B"
+}
+
+func cmdAdvise(c *ollama.Client, args []string) error {
+	fs := flag.NewFlagSet("advise", flag.ContinueOnError)
+	vramGiB := fs.Float64("vram", 0, "GiB of VRAM to plan for (default: all GPUs)")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if fs.NArg() != 1 {
+		return usageError{"usage: advise [--vram GiB] <model|size>, e.g. qwen3:32b or 14b"}
+	}
+	name := fs.Arg(0)
+	params := quant.Params(name)
+	// An installed model knows its exact parameter count.
+	if models, err := getModels(c); err == nil {
+		if mdl, ok := findModel(models, name); ok && quant.Params(mdl.Details.ParameterSize) > 0 {
+			params = quant.Params(mdl.Details.ParameterSize)
+		}
+	}
+	if params == 0 {
+		return fmt.Errorf("no parameter size in %q; name a tag such as qwen3:32b, or a size such as 8b", name)
+	}
+
+	total := uint64(*vramGiB * (1 << 30))
+	if total == 0 {
+		if gpus, err := gpu.Query(); err == nil {
+			for _, d := range gpus {
+				total += d.MemoryTotal
+			}
+		}
+	}
+	advice, best := quant.Advise(params, total)
+	if total > 0 {
+		fmt.Printf("%s on %s of VRAM:\n\n", formatParams(params), formatVRAM(total))
+	} else {
+		fmt.Printf("%s (no GPU found; use --vram to plan for one):\n\n", formatParams(params))
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tQUANT\tDOWNLOAD\tVRAM\tFIT\tQUALITY\tSPEED\t")
+	for i, a := range advice {
+		mark := ""
+		if i == best {
+			mark = "*"
+		}
+		fit := ""
+		if a.Fit != vram.FitUnknown {
+			fit = a.Fit.String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%.2f×\t%s\n", mark, a.Name, formatBytes(uint64(a.Size)),
+			formatVRAM(a.Need), fit, a.Stars(), a.Speed(), a.Note)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\n* suggested. VRAM is estimated at a %d-token context; speed is relative to Q4_K_M\n", vram.DefaultContext)
+	fmt.Println("  and drops sharply for anything that doesn't fit, as layers move to the CPU.")
+	return nil
+}
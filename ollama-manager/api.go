@@ -0,0 +1,436 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"ollama-manager/internal/bench"
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/ollama"
+)
+
+// defaultAPIListen keeps the management API to this machine unless told
+// otherwise.
+const defaultAPIListen = "127.0.0.1:11437"
+
+// apiServer is the management API: the CLI's operations over HTTP, for
+// scripts and CI jobs. Every request acts on the default host, or on the
+// host profile named by ?profile=.
+type apiServer struct {
+	c     *ollama.Client
+	cfg   *config.Config
+	token string
+	port  string // listened on, which a tokenless request's Host must name
+
+	// benching holds the one benchmark allowed at a time, which would
+	// otherwise skew the other's numbers. mu guards history.
+	benching sync.Mutex
+	mu       sync.Mutex
+	history  *bench.History
+}
+
+// apiError is the body of every failed request.
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// errAPI carries the status to answer a failed request with.
+type errAPI struct {
+	status int
+	msg    string
+}
+
+func (e errAPI) Error() string { return e.msg }
+
+func badRequest(format string, args ...any) error {
+	return errAPI{http.StatusBadRequest, fmt.Sprintf(format, args...)}
+}
+
+func (s *apiServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/status", s.get(s.status))
+	mux.HandleFunc("/v1/models", s.get(s.models))
+	mux.HandleFunc("/v1/models/load", s.post(s.load))
+	mux.HandleFunc("/v1/models/unload", s.post(s.unload))
+	mux.HandleFunc("/v1/profiles", s.get(s.profiles))
+	mux.HandleFunc("/v1/warmups", s.get(s.warmups))
+	mux.HandleFunc("/v1/warmups/activate", s.post(s.activate))
+	mux.HandleFunc("/v1/benchmarks", s.get(s.benchmarks))
+	mux.HandleFunc("/v1/benchmarks/run", s.post(s.runBenchmark))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Browsers send an Origin with cross-site requests; without this a
+		// web page could drive an API that needs no token on loopback.
+		if origin := r.Header.Get("Origin"); origin != "" && !sameOrigin(origin, r.Host) {
+			writeAPI(w, nil, errAPI{http.StatusForbidden, "cross-origin requests are not allowed"})
+			return
+		}
+		// Without a token, a page using DNS rebinding could reach the API
+		// under its own name, with a matching Origin; Ollama refuses such
+		// hosts the same way.
+		if s.token == "" && !localHost(r.Host, s.port) {
+			writeAPI(w, nil, errAPI{http.StatusForbidden, fmt.Sprintf("host %q is not this machine", r.Host)})
+			return
+		}
+		if s.token != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+				writeAPI(w, nil, errAPI{http.StatusUnauthorized, "missing or wrong token"})
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// get and post adapt an operation to a handler. Post bodies are JSON, and
+// an operation's error becomes the response, as errAPI or a 502 for the
+// server failing.
+func (s *apiServer) get(fn func(r *http.Request, c *ollama.Client) (any, error)) http.HandlerFunc {
+	return s.handle(http.MethodGet, fn)
+}
+
+func (s *apiServer) post(fn func(r *http.Request, c *ollama.Client) (any, error)) http.HandlerFunc {
+	return s.handle(http.MethodPost, fn)
+}
+
+func (s *apiServer) handle(method string, fn func(r *http.Request, c *ollama.Client) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			writeAPI(w, nil, errAPI{http.StatusMethodNotAllowed, "use " + method})
+			return
+		}
+		// A form or text/plain post needs no preflight, so only JSON is
+		// taken.
+		if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); method == http.MethodPost && mt != "application/json" {
+			writeAPI(w, nil, errAPI{http.StatusUnsupportedMediaType, "send the body as Content-Type: application/json"})
+			return
+		}
+		c, err := s.client(r.URL.Query().Get("profile"))
+		if err != nil {
+			writeAPI(w, nil, err)
+			return
+		}
+		v, err := fn(r, c)
+		writeAPI(w, v, err)
+	}
+}
+
+func writeAPI(w http.ResponseWriter, v any, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		status := http.StatusBadGateway
+		var e errAPI
+		if errors.As(err, &e) {
+			status = e.status
+		}
+		w.WriteHeader(status)
+		v = apiError{err.Error()}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// client returns the client for the named host profile, or the default:
+// the host the API was started for.
+func (s *apiServer) client(profile string) (*ollama.Client, error) {
+	if profile == "" || profile == defaultProfile {
+		return s.c, nil
+	}
+	hosts, _, _ := hostProfiles(s.cfg, "", "")
+	for _, p := range hosts {
+		if p.Name == profile {
+			return clientFor(p)
+		}
+	}
+	return nil, errAPI{http.StatusNotFound, fmt.Sprintf("unknown profile %q", profile)}
+}
+
+// localHost reports whether host, a request's Host header, names this
+// machine at port: localhost or a loopback address.
+func localHost(host, port string) bool {
+	h, p, err := net.SplitHostPort(host)
+	if err != nil || p != port {
+		return false
+	}
+	if strings.EqualFold(h, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(h)
+	return ip != nil && ip.IsLoopback()
+}
+
+// sameOrigin reports whether origin, such as "http://127.0.0.1:11437", is
+// the API itself at host.
+func sameOrigin(origin, host string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && strings.EqualFold(u.Host, host)
+}
+
+// decode reads a JSON request body into v.
+func decode(r *http.Request, v any) error {
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<20)).Decode(v); err != nil {
+		return badRequest("invalid JSON body: %v", err)
+	}
+	return nil
+}
+
+func (s *apiServer) status(r *http.Request, c *ollama.Client) (any, error) {
+	st, _, err := collectStatus(r.Context(), c)
+	return st, err
+}
+
+func (s *apiServer) models(r *http.Request, c *ollama.Client) (any, error) {
+	models, err := getModels(c)
+	if err != nil {
+		return nil, err
+	}
+	running, _ := getRunning(c)
+	return listJSON(models, loadedSet(running), openUsageOrWarn(), c.Host()), nil
+}
+
+// loadRequest mirrors the load command's flags.
+type loadRequest struct {
+	Model     string `json:"model"`
+	KeepAlive string `json:"keep_alive,omitempty"`
+	NumCtx    int    `json:"num_ctx,omitempty"`
+	NumGPU    *int   `json:"num_gpu,omitempty"`
+}
+
+func (s *apiServer) load(r *http.Request, c *ollama.Client) (any, error) {
+	var req loadRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	if req.Model == "" {
+		return nil, badRequest("model is required")
+	}
+	numGPU := -1
+	if req.NumGPU != nil {
+		numGPU = *req.NumGPU
+	}
+	opts, err := explicitLoadOptions(s.cfg, req.Model, req.KeepAlive, req.NumCtx, numGPU)
+	if err != nil {
+		return nil, badRequest("%v", err)
+	}
+	start := time.Now()
+	if err := c.Load(r.Context(), req.Model, opts); err != nil {
+		return nil, fmt.Errorf("load %s: %w", req.Model, err)
+	}
+	return map[string]any{"loaded": req.Model, "seconds": time.Since(start).Seconds()}, nil
+}
+
+// unloadRequest names models to unload, or all of them.
+type unloadRequest struct {
+	Models []string `json:"models,omitempty"`
+	All    bool     `json:"all,omitempty"`
+}
+
+func (s *apiServer) unload(r *http.Request, c *ollama.Client) (any, error) {
+	var req unloadRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	names := req.Models
+	if req.All {
+		running, err := getRunning(c)
+		if err != nil {
+			return nil, err
+		}
+		names = nil
+		for _, m := range running {
+			names = append(names, m.Name)
+		}
+	} else if len(names) == 0 {
+		return nil, badRequest("models or all is required")
+	}
+	unloaded := []string{}
+	for _, name := range names {
+		if err := stopModel(c, name); err != nil {
+			return nil, fmt.Errorf("unload %s: %w", name, err)
+		}
+		unloaded = append(unloaded, name)
+	}
+	return map[string]any{"unloaded": unloaded}, nil
+}
+
+// profileJSON is a host profile without its credentials.
+type profileJSON struct {
+	Name   string `json:"name"`
+	Host   string `json:"host"`
+	Daemon bool   `json:"daemon,omitempty"`
+}
+
+func (s *apiServer) profiles(r *http.Request, c *ollama.Client) (any, error) {
+	hosts, _, _ := hostProfiles(s.cfg, "", "")
+	out := make([]profileJSON, 0, len(hosts))
+	for _, p := range hosts {
+		host, _, err := ollama.ParseHost(p.Host)
+		switch {
+		case p.Name == defaultProfile:
+			host = s.c.Host()
+		case err != nil:
+			host = p.Host
+		}
+		out = append(out, profileJSON{p.Name, host, p.Daemon})
+	}
+	return out, nil
+}
+
+func (s *apiServer) warmups(r *http.Request, c *ollama.Client) (any, error) {
+	return append([]config.Warmup{}, s.cfg.Warmups...), nil
+}
+
+// warmupJSON reports an activated warm-up set.
+type warmupJSON struct {
+	Loaded   []string `json:"loaded"`
+	Unloaded []string `json:"unloaded"`
+	Skipped  []string `json:"skipped"`
+	Error    string   `json:"error,omitempty"`
+}
+
+func (s *apiServer) activate(r *http.Request, c *ollama.Client) (any, error) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	w, ok := s.cfg.Warmup(req.Name)
+	if !ok {
+		return nil, errAPI{http.StatusNotFound, fmt.Sprintf("unknown warm-up set %q", req.Name)}
+	}
+	p, err := planWarmupFor(c, s.cfg, w)
+	if err != nil {
+		return nil, err
+	}
+	msg := runWarmup(c, p)
+	out := warmupJSON{Loaded: []string{}, Unloaded: []string{}, Skipped: append([]string{}, msg.plan.skipped...)}
+	for _, r := range msg.stopped {
+		if r.err == nil {
+			out.Unloaded = append(out.Unloaded, r.name)
+		}
+	}
+	for _, r := range msg.loaded {
+		if r.err == nil {
+			out.Loaded = append(out.Loaded, r.name)
+		}
+	}
+	if err := msg.err(); err != nil {
+		out.Error = err.Error()
+	}
+	return out, nil
+}
+
+// benchmarks lists the recorded results, oldest first, of one model with
+// ?model=.
+func (s *apiServer) benchmarks(r *http.Request, c *ollama.Client) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	model := r.URL.Query().Get("model")
+	out := []*bench.Result{}
+	for _, res := range s.history.Results {
+		if model == "" || res.Model == model {
+			out = append(out, res)
+		}
+	}
+	return out, nil
+}
+
+// runBenchmark benchmarks a model with its saved generation options and
+// answers with the result once done, which takes a minute or so.
+func (s *apiServer) runBenchmark(r *http.Request, c *ollama.Client) (any, error) {
+	var req struct {
+		Model string `json:"model"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	if req.Model == "" {
+		return nil, badRequest("model is required")
+	}
+	if !s.benching.TryLock() {
+		return nil, errAPI{http.StatusConflict, "another benchmark is running"}
+	}
+	defer s.benching.Unlock()
+	res, err := bench.Benchmark(r.Context(), c, req.Model, bench.DefaultPrompts, optionsMap(s.cfg.ModelOptions[req.Model]), func(string) {})
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.history.Add(res); err != nil {
+		return nil, fmt.Errorf("saving the result: %w", err)
+	}
+	return res, nil
+}
+
+func cmdAPI(c *ollama.Client, args []string) error {
+	if len(args) > 1 {
+		return usageError{"usage: api [listen], e.g. api 127.0.0.1:11437"}
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	listen, token := defaultAPIListen, ""
+	if cfg.API != nil {
+		listen, token = firstNonEmpty(cfg.API.Listen, listen), cfg.API.Token
+	}
+	if len(args) == 1 {
+		listen = args[0]
+	}
+	if token == "" && !loopback(listen) {
+		return fmt.Errorf("listening beyond this machine on %s needs api.token set in the config", listen)
+	}
+	history, err := openBenchHistory()
+	if err != nil {
+		return err
+	}
+	s := &apiServer{c: c, cfg: cfg, token: token, history: history}
+
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	_, s.port, _ = net.SplitHostPort(ln.Addr().String())
+	fmt.Printf("Management API for %s on http://%s/v1/\n", c.Host(), ln.Addr())
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	srv := &http.Server{Handler: s.handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// loopback reports whether listen only takes connections from this
+// machine.
+func loopback(listen string) bool {
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
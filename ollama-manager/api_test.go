@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIHostAndOrigin(t *testing.T) {
+	s := &apiServer{port: "11437"}
+	h := s.handler()
+	tests := []struct {
+		name, method, host, origin, contentType string
+		want                                    int
+	}{
+		{name: "rebinding", method: "GET", host: "evil.example:11437", origin: "http://evil.example:11437", want: http.StatusForbidden},
+		{name: "rebinding post", method: "POST", host: "evil.example:11499", origin: "http://evil.example:11499", contentType: "application/json", want: http.StatusForbidden},
+		{name: "foreign origin", method: "POST", host: "127.0.0.1:11437", origin: "http://evil.example", contentType: "application/json", want: http.StatusForbidden},
+		{name: "other port", method: "GET", host: "127.0.0.1:8080", want: http.StatusForbidden},
+		{name: "text/plain", method: "POST", host: "127.0.0.1:11437", contentType: "text/plain", want: http.StatusUnsupportedMediaType},
+		{name: "localhost", method: "POST", host: "localhost:11437", contentType: "application/json", want: http.StatusBadRequest},
+		{name: "ipv6 loopback", method: "POST", host: "[::1]:11437", contentType: "application/json", want: http.StatusBadRequest},
+		{name: "same origin", method: "POST", host: "127.0.0.1:11437", origin: "http://127.0.0.1:11437", contentType: "application/json; charset=utf-8", want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "/v1/warmups/activate", strings.NewReader("not json"))
+		if tt.method == "GET" {
+			r = httptest.NewRequest(tt.method, "/v1/warmups/activate", nil)
+		}
+		r.Host = tt.host
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if tt.contentType != "" {
+			r.Header.Set("Content-Type", tt.contentType)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, w.Code, tt.want, strings.TrimSpace(w.Body.String()))
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/ollama"
+)
+
+// batchWorkers bounds how many operations of a batch run at once, so a
+// multi-model load doesn't hit the server with dozens of requests.
+const batchWorkers = 4
+
+type batchResult struct {
+	name string
+	err  error
+}
+
+// batchDoneMsg reports the per-model outcome of a batch operation.
+type batchDoneMsg struct {
+	running string // e.g. "Loading", reused in failure messages
+	done    string // e.g. "Loaded"
+	results []batchResult
+	load    bool // the batch loaded models
+}
+
+// runBatch applies fn to every name using a bounded worker pool and returns
+// results in input order.
+func runBatch(names []string, fn func(name string) error) []batchResult {
+	results := make([]batchResult, len(names))
+	sem := make(chan struct{}, batchWorkers)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = batchResult{name: name, err: fn(name)}
+		}(i, name)
+	}
+	wg.Wait()
+	return results
+}
+
+// runBatchOp is runOp for several models at once.
+func (m *model) runBatchOp(running, done string, names []string, fn func(name string) error) tea.Cmd {
+	m.status = fmt.Sprintf("%s %d models...", running, len(names))
+	op := func() tea.Msg {
+		return batchDoneMsg{running: running, done: done, results: runBatch(names, fn)}
+	}
+	return tea.Batch(op, m.startBusy())
+}
+
+// startBatchLoad is startLoad for several models at once.
+func (m *model) startBatchLoad(names []string, opts map[string]ollama.LoadOptions) tea.Cmd {
+	c := m.client
+	m.status = fmt.Sprintf("Loading %d models...", len(names))
+	for _, name := range names {
+		m.loading[name] = false
+	}
+	m.syncTable()
+	op := func() tea.Msg {
+		results := runBatch(names, func(name string) error {
+			return loadModel(c, name, opts[name])
+		})
+		return batchDoneMsg{running: "Loading", done: "Loaded", results: results, load: true}
+	}
+	return tea.Batch(op, m.startBusy())
+}
+
+// finishBatch logs each failure separately and summarises the batch.
+func (m *model) finishBatch(msg batchDoneMsg) tea.Cmd {
+	m.busy = max(m.busy-1, 0)
+	failed := 0
+	for _, r := range msg.results {
+		if r.err != nil {
+			failed++
+			m.logError(fmt.Sprintf("%s %s failed: %v", msg.running, r.name, r.err))
+		}
+		if _, ok := m.loading[r.name]; ok && msg.load {
+			if r.err != nil {
+				delete(m.loading, r.name)
+			} else {
+				m.loading[r.name] = true
+			}
+		}
+	}
+	m.syncTable()
+	ok := len(msg.results) - failed
+	if failed == 0 {
+		m.status = fmt.Sprintf("%s %d models", msg.done, ok)
+	} else {
+		m.status = fmt.Sprintf("%s %d of %d models, %d failed (E: show errors)",
+			msg.done, ok, len(msg.results), failed)
+	}
+	return m.refresh()
+}
+
+// targets returns the selected models, or the one under the cursor when
+// nothing is selected.
+func (m model) targets() []ollama.Model {
+	var out []ollama.Model
+	for _, mdl := range m.models {
+		if m.selected[mdl.Name] {
+			out = append(out, mdl)
+		}
+	}
+	if cur, ok := m.current(); ok && len(out) == 0 {
+		out = append(out, cur)
+	}
+	return out
+}
+
+func names(models []ollama.Model) []string {
+	out := make([]string, len(models))
+	for i, mdl := range models {
+		out[i] = mdl.Name
+	}
+	return out
+}
+
+// pruneSelection drops selected names that are no longer installed.
+func (m *model) pruneSelection() {
+	present := make(map[string]bool, len(m.models))
+	for _, mdl := range m.models {
+		present[mdl.Name] = true
+	}
+	for name := range m.selected {
+		if !present[name] {
+			delete(m.selected, name)
+		}
+	}
+}
+
+// loadTargets loads the selected models (or the one under the cursor).
+func (m *model) loadTargets() tea.Cmd {
+	targets := names(m.targets())
+	if len(targets) == 0 {
+		return nil
+	}
+	m.selected = make(map[string]bool)
+	if len(targets) == 1 {
+		name := targets[0]
+		return m.startLoad("Loading "+name, "Loaded "+name, name, m.loadOptions(name))
+	}
+	opts := make(map[string]ollama.LoadOptions, len(targets))
+	for _, name := range targets {
+		opts[name] = m.loadOptions(name)
+	}
+	return m.startBatchLoad(targets, opts)
+}
+
+// stopTargets unloads the selected models (or the one under the cursor).
+func (m *model) stopTargets() tea.Cmd {
+	targets := names(m.targets())
+	if len(targets) == 0 {
+		return nil
+	}
+	m.selected = make(map[string]bool)
+	c := m.client
+	if len(targets) == 1 {
+		name := targets[0]
+		return m.runOp("Stopping "+name, "Stopped "+name, func() error {
+			return stopModel(c, name)
+		})
+	}
+	return m.runBatchOp("Stopping", "Stopped", targets, func(name string) error {
+		return stopModel(c, name)
+	})
+}
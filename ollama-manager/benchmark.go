@@ -0,0 +1,542 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/bench"
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/report"
+	"ollama-manager/internal/service"
+)
+
+const (
+	benchHistoryFile = "benchmarks.json"
+	// benchRegression is the drop in generation speed, in percent, that is
+	// flagged as a regression.
+	benchRegression = 5.0
+)
+
+// benchState tracks a benchmark running in the background.
+type benchState struct {
+	model   string
+	step    string
+	started time.Time
+	updates chan tea.Msg
+	cancel  context.CancelFunc
+	label   string // what runs, if not a benchmark, e.g. "Context sweep of qwen3"
+}
+
+// benchStepMsg reports the step a running benchmark has reached.
+type benchStepMsg string
+
+// benchDoneMsg carries the finished (or failed) benchmark.
+type benchDoneMsg struct {
+	result *bench.Result
+	err    error
+}
+
+func startBench(c *ollama.Client, name string, opts map[string]any) (*benchState, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &benchState{
+		model:   name,
+		step:    "starting",
+		started: time.Now(),
+		updates: make(chan tea.Msg, 16),
+		cancel:  cancel,
+	}
+	go func() {
+		defer close(b.updates)
+		res, err := bench.Benchmark(ctx, c, name, bench.DefaultPrompts, opts, func(step string) {
+			b.updates <- benchStepMsg(step)
+		})
+		b.updates <- benchDoneMsg{result: res, err: err}
+	}()
+	return b, listen(b.updates)
+}
+
+// sweepDoneMsg carries the finished (or failed) context sweep.
+type sweepDoneMsg struct {
+	result *bench.SweepResult
+	err    error
+}
+
+func startSweep(c *ollama.Client, name string, opts map[string]any) (*benchState, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &benchState{
+		model:   name,
+		step:    "starting",
+		started: time.Now(),
+		updates: make(chan tea.Msg, 16),
+		cancel:  cancel,
+		label:   "Context sweep of " + name,
+	}
+	go func() {
+		defer close(b.updates)
+		res, err := bench.Sweep(ctx, c, name, bench.DefaultContexts, opts, func(step string) {
+			b.updates <- benchStepMsg(step)
+		})
+		b.updates <- sweepDoneMsg{result: res, err: err}
+	}()
+	return b, listen(b.updates)
+}
+
+// openSweep shows the benchmark screen, starting a context sweep of name
+// unless a benchmark or sweep is already in progress.
+func (m model) openSweep(name string) (tea.Model, tea.Cmd) {
+	m.mode = modeBench
+	if m.bench != nil {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.bench, cmd = startSweep(m.client, name, m.genOptions(name))
+	m.status = "Sweeping context sizes of " + name
+	return m, tea.Batch(cmd, m.startBusy())
+}
+
+func (m *model) finishSweep(msg sweepDoneMsg) tea.Cmd {
+	name, started := m.bench.model, m.bench.started
+	m.bench = nil
+	m.busy = max(m.busy-1, 0)
+	switch {
+	case errors.Is(msg.err, context.Canceled):
+		m.status = "Context sweep of " + name + " cancelled"
+		return nil
+	case msg.err != nil:
+		m.status = fmt.Sprintf("Context sweep of %s failed: %v", name, msg.err)
+		m.logError(m.status)
+	default:
+		m.sweep = msg.result
+		m.status = sweepSummary(msg.result)
+	}
+	return tea.Batch(m.refresh(), m.notify("bench", started, "%s", m.status))
+}
+
+// parallelDoneMsg carries the finished (or failed) parallelism test.
+type parallelDoneMsg struct {
+	result *bench.ParallelResult
+	err    error
+}
+
+func startParallel(c *ollama.Client, name, svc string, opts map[string]any) (*benchState, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &benchState{
+		model:   name,
+		step:    "starting",
+		started: time.Now(),
+		updates: make(chan tea.Msg, 16),
+		cancel:  cancel,
+		label:   "Parallelism test of " + name,
+	}
+	go func() {
+		defer close(b.updates)
+		res, err := bench.Parallel(ctx, c, name, bench.DefaultParallel, opts, func(step string) {
+			b.updates <- benchStepMsg(step)
+		})
+		if err == nil {
+			res.ServerParallel = serverParallel(ctx, c, svc)
+		}
+		b.updates <- parallelDoneMsg{result: res, err: err}
+	}()
+	return b, listen(b.updates)
+}
+
+// openParallel shows the benchmark screen, starting a parallelism test of
+// name unless a run is already in progress.
+func (m model) openParallel(name string) (tea.Model, tea.Cmd) {
+	m.mode = modeBench
+	if m.bench != nil {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.bench, cmd = startParallel(m.client, name, m.serviceName(), m.genOptions(name))
+	m.status = "Testing parallel requests to " + name
+	return m, tea.Batch(cmd, m.startBusy())
+}
+
+func (m *model) finishParallel(msg parallelDoneMsg) tea.Cmd {
+	name, started := m.bench.model, m.bench.started
+	m.bench = nil
+	m.busy = max(m.busy-1, 0)
+	switch {
+	case errors.Is(msg.err, context.Canceled):
+		m.status = "Parallelism test of " + name + " cancelled"
+		return nil
+	case msg.err != nil:
+		m.status = fmt.Sprintf("Parallelism test of %s failed: %v", name, msg.err)
+		m.logError(m.status)
+	default:
+		m.parallel = msg.result
+		m.status = parallelSummary(msg.result)
+	}
+	return m.notify("bench", started, "%s", m.status)
+}
+
+// openBench shows the benchmark screen, starting a run for name unless one
+// is already in progress.
+func (m model) openBench(name string) (tea.Model, tea.Cmd) {
+	m.mode = modeBench
+	if m.bench != nil {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.bench, cmd = startBench(m.client, name, m.genOptions(name))
+	m.status = "Benchmarking " + name
+	return m, tea.Batch(cmd, m.startBusy())
+}
+
+func (m *model) finishBench(msg benchDoneMsg) tea.Cmd {
+	name, started := m.bench.model, m.bench.started
+	m.bench = nil
+	m.busy = max(m.busy-1, 0)
+	switch {
+	case errors.Is(msg.err, context.Canceled):
+		m.status = "Benchmark of " + name + " cancelled"
+		return nil
+	case msg.err != nil:
+		m.status = fmt.Sprintf("Benchmark of %s failed: %v", name, msg.err)
+		m.logError(m.status)
+	default:
+		m.status = fmt.Sprintf("Benchmarked %s: %.1f tok/s", name, msg.result.GenTPS)
+		if err := m.benchHistory.Add(msg.result); err != nil {
+			m.logError("Saving benchmark history: " + err.Error())
+		}
+	}
+	return m.notify("bench", started, "%s", m.status)
+}
+
+// openBenchHistory loads the results of earlier sessions from the data dir.
+func openBenchHistory() (*bench.History, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	return bench.OpenHistory(filepath.Join(dir, benchHistoryFile))
+}
+
+func (m model) updateBench(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if name := m.kvConfirm; name != "" {
+		m.kvConfirm = ""
+		if msg.String() == "y" {
+			return m.startKVTest(name)
+		}
+		m.status = "Flash attention test cancelled"
+		return m, nil
+	}
+	if m.exportAsk {
+		heading, tables, raw := m.benchExport()
+		return m, m.answerExport(msg.String(), "benchmarks", heading, tables, raw)
+	}
+	switch {
+	case msg.String() == "esc", key.Matches(msg, m.keys.Quit):
+		m.mode = modeList
+	case key.Matches(msg, m.keys.Cancel):
+		if m.bench != nil {
+			m.bench.cancel()
+		}
+	case key.Matches(msg, m.keys.Export):
+		if len(m.benchHistory.Results) == 0 && m.sweep == nil && m.parallel == nil && m.kvTest == nil {
+			m.status = "Nothing to export yet"
+			break
+		}
+		m.exportAsk = true
+	}
+	return m, nil
+}
+
+func (m model) benchView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Benchmark"))
+	b.WriteString("\n\n")
+
+	if m.bench != nil {
+		b.WriteString(fmt.Sprintf("%s %s: %s\n\n", m.spinner.View(), firstNonEmpty(m.bench.label, m.bench.model), m.bench.step))
+	}
+	// The session's sweeps and parallelism tests take room from the history.
+	var tests strings.Builder
+	if m.sweep != nil {
+		t := sweepReport(m.sweep)
+		tests.WriteString(report.Text(t))
+		tests.WriteString(helpStyle.Render(t.Note))
+		tests.WriteString("\n\n")
+	}
+	if m.parallel != nil {
+		t := parallelReport(m.parallel)
+		tests.WriteString(report.Text(t))
+		tests.WriteString(helpStyle.Render(t.Note))
+		tests.WriteString("\n\n")
+	}
+	if m.kvTest != nil {
+		t := kvReport(m.kvTest)
+		tests.WriteString(report.Text(t))
+		tests.WriteString(helpStyle.Render(t.Note))
+		tests.WriteString("\n\n")
+	}
+	if m.kvConfirm != "" {
+		tests.WriteString(m.kvConfirmView())
+		tests.WriteString("\n\n")
+	}
+	b.WriteString(tests.String())
+
+	if len(m.benchHistory.Results) == 0 {
+		b.WriteString(helpStyle.Render("No benchmarks recorded yet. Press b on a model to run one."))
+		b.WriteString("\n")
+	} else {
+		rows := 20
+		if m.height > 0 {
+			rows = max(m.height-12-strings.Count(tests.String(), "\n"), 5)
+		}
+		b.WriteString(benchTable(m.benchHistory, rows))
+	}
+
+	b.WriteString("\n")
+	help := helpLine(m.keys.Export) + "  Esc: Back"
+	if m.bench != nil {
+		help = helpLine(m.keys.Cancel, m.keys.Export) + "  Esc: Back (keeps running)"
+	}
+	if m.exportAsk {
+		b.WriteString(warnStyle.Render(exportPrompt))
+	} else {
+		b.WriteString(helpStyle.Render(help))
+	}
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("\nStatus: %s", m.status))
+	return b.String()
+}
+
+// benchTable lays out the newest rows of the history so quantizations of one
+// model line up. The last column compares generation speed with the previous
+// run of the same model and quantization, highlighting regressions.
+func benchTable(h *bench.History, rows int) string {
+	results := benchRecent(h.Results, rows)
+	t := benchReport(h, results)
+	for i, r := range results {
+		if benchRegressed(r, h.Previous(r)) {
+			last := len(t.Rows[i]) - 1
+			t.Rows[i][last] = errorStyle.Render(t.Rows[i][last])
+		}
+	}
+	return report.Text(t)
+}
+
+// benchRecent returns the newest rows of results, newest first; rows <= 0
+// returns them all.
+func benchRecent(results []*bench.Result, rows int) []*bench.Result {
+	if rows > 0 && len(results) > rows {
+		results = results[len(results)-rows:]
+	}
+	recent := make([]*bench.Result, len(results))
+	for i, r := range results {
+		recent[len(results)-1-i] = r
+	}
+	return recent
+}
+
+// benchReport is the table of results behind benchTable, without styling.
+func benchReport(h *bench.History, results []*bench.Result) report.Table {
+	t := report.Table{
+		Title:  "Benchmarks",
+		Header: []string{"DATE", "MODEL", "QUANT", "GEN t/s", "PROMPT t/s", "TTFT", "VRAM", "GPU", "DRIVER", "OLLAMA", "vs PREV"},
+	}
+	for _, r := range results {
+		t.Rows = append(t.Rows, []string{
+			r.Started.Format("2006-01-02 15:04"), r.Model, r.Quant,
+			fmt.Sprintf("%.1f", r.GenTPS), fmt.Sprintf("%.1f", r.PromptTPS),
+			r.TTFT.Round(time.Millisecond).String(), formatBytes(uint64(r.VRAM)),
+			firstNonEmpty(r.GPU, "-"), firstNonEmpty(r.Driver, "-"), firstNonEmpty(r.OllamaVersion, "-"),
+			benchDelta(r, h.Previous(r)),
+		})
+	}
+	return t
+}
+
+// benchDelta formats the change in generation speed since prev.
+func benchDelta(r, prev *bench.Result) string {
+	if prev == nil || prev.GenTPS == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", (r.GenTPS-prev.GenTPS)/prev.GenTPS*100)
+}
+
+// benchRegressed reports whether generation speed dropped by benchRegression
+// or more since prev.
+func benchRegressed(r, prev *bench.Result) bool {
+	return prev != nil && prev.GenTPS > 0 && (r.GenTPS-prev.GenTPS)/prev.GenTPS*100 <= -benchRegression
+}
+
+// sweepReport lays out a context sweep, one row per context length. USABLE
+// says why a step doesn't count: the model spilled onto the CPU, or ran at
+// less than half the speed of the smallest context.
+func sweepReport(r *bench.SweepResult) report.Table {
+	t := report.Table{
+		Title:  "Context sweep of " + r.Model,
+		Header: []string{"NUM_CTX", "GEN t/s", "PROMPT t/s", "TTFT", "VRAM", "ON GPU", "USABLE"},
+		Note:   sweepSummary(r),
+	}
+	for i, s := range r.Steps {
+		if s.Err != "" {
+			t.Rows = append(t.Rows, []string{strconv.Itoa(s.NumCtx), "-", "-", "-", "-", "-", s.Err})
+			continue
+		}
+		usable := "yes"
+		switch {
+		case !s.OnGPU():
+			usable = "no, spills onto the CPU"
+		case !r.Usable(i):
+			usable = "no, too slow"
+		}
+		onGPU := "-"
+		if s.Size > 0 {
+			onGPU = fmt.Sprintf("%.0f%%", float64(s.VRAM)/float64(s.Size)*100)
+		}
+		t.Rows = append(t.Rows, []string{strconv.Itoa(s.NumCtx), fmt.Sprintf("%.1f", s.GenTPS), fmt.Sprintf("%.1f", s.PromptTPS),
+			s.TTFT.Round(time.Millisecond).String(), formatBytes(uint64(s.VRAM)), onGPU, usable})
+	}
+	return t
+}
+
+// sweepSummary names the largest usable context of a sweep.
+func sweepSummary(r *bench.SweepResult) string {
+	text := fmt.Sprintf("%s: no context ran entirely on the GPU", r.Model)
+	if n := r.Largest(); n > 0 {
+		text = fmt.Sprintf("%s: largest usable context %d", r.Model, n)
+	}
+	if r.MaxContext > 0 {
+		text += fmt.Sprintf(" (trained on %d)", r.MaxContext)
+	}
+	return text
+}
+
+func cmdSweep(c *ollama.Client, args []string) error {
+	fs := flag.NewFlagSet("sweep", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "emit JSON")
+	list := fs.String("contexts", "", "comma-separated num_ctx values to try (default 4096 to 131072, doubling)")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if fs.NArg() != 1 {
+		return usageError{"usage: sweep [--contexts 4096,8192,...] [--json] <model>"}
+	}
+	contexts := bench.DefaultContexts
+	if *list != "" {
+		contexts = nil
+		for _, f := range strings.Split(*list, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(f))
+			if err != nil || n <= 0 {
+				return usageError{fmt.Sprintf("--contexts: %q is not a context length", f)}
+			}
+			contexts = append(contexts, n)
+		}
+		sort.Ints(contexts)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	name := fs.Arg(0)
+	res, err := bench.Sweep(context.Background(), c, name, contexts, optionsMap(cfg.ModelOptions[name]), func(step string) {
+		fmt.Fprintf(os.Stderr, "%s\n", step)
+	})
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(res)
+	}
+	t := sweepReport(res)
+	fmt.Print(report.Text(t))
+	fmt.Println(t.Note)
+	return nil
+}
+
+// parallelReport lays out a parallelism test, one row per number of
+// concurrent requests.
+func parallelReport(r *bench.ParallelResult) report.Table {
+	t := report.Table{
+		Title:  "Parallelism of " + r.Model,
+		Header: []string{"CONCURRENT", "TOTAL t/s", "PER REQUEST t/s", "p50", "p95", "FAILED"},
+		Note:   parallelSummary(r),
+	}
+	for _, s := range r.Steps {
+		failed := "-"
+		if s.Failed > 0 {
+			failed = fmt.Sprintf("%d/%d: %s", s.Failed, s.Requests, s.Err)
+		}
+		t.Rows = append(t.Rows, []string{strconv.Itoa(s.Concurrency), fmt.Sprintf("%.1f", s.AggregateTPS()),
+			fmt.Sprintf("%.1f", s.AggregateTPS()/float64(s.Concurrency)),
+			s.P50.Round(10 * time.Millisecond).String(), s.P95.Round(10 * time.Millisecond).String(), failed})
+	}
+	return t
+}
+
+// serverParallel reads OLLAMA_NUM_PARALLEL from the environment of the
+// named service, when the server runs here.
+func serverParallel(ctx context.Context, c *ollama.Client, name string) string {
+	if !c.Local() {
+		return ""
+	}
+	env, err := service.Env(ctx, name)
+	if err != nil {
+		return ""
+	}
+	return env["OLLAMA_NUM_PARALLEL"]
+}
+
+// parallelSummary suggests an OLLAMA_NUM_PARALLEL from a parallelism test,
+// warning when the server's own setting capped it.
+func parallelSummary(r *bench.ParallelResult) string {
+	n := r.Best()
+	if n == 0 {
+		return r.Model + ": every level had failed requests"
+	}
+	text := fmt.Sprintf("%s: OLLAMA_NUM_PARALLEL=%d comes within 5%% of the best total throughput, using %s of VRAM",
+		r.Model, n, formatBytes(uint64(r.VRAM)))
+	if limit, err := strconv.Atoi(r.ServerParallel); err == nil && limit < bench.DefaultParallel {
+		text += fmt.Sprintf("; the server is set to %d, so more requests than that only queued", limit)
+	}
+	return text
+}
+
+func cmdParallel(c *ollama.Client, args []string) error {
+	fs := flag.NewFlagSet("parallel", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "emit JSON")
+	maxN := fs.Int("max", bench.DefaultParallel, "most concurrent requests to try")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if fs.NArg() != 1 || *maxN < 1 {
+		return usageError{"usage: parallel [--max 8] [--json] <model>"}
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	name := fs.Arg(0)
+	ctx := context.Background()
+	res, err := bench.Parallel(ctx, c, name, *maxN, optionsMap(cfg.ModelOptions[name]), func(step string) {
+		fmt.Fprintf(os.Stderr, "%s\n", step)
+	})
+	if err != nil {
+		return err
+	}
+	res.ServerParallel = serverParallel(ctx, c, firstNonEmpty(cfg.Service, service.DefaultName()))
+	if *asJSON {
+		return writeJSON(res)
+	}
+	t := parallelReport(res)
+	fmt.Print(report.Text(t))
+	fmt.Println(t.Note)
+	return nil
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/library"
+	"ollama-manager/internal/quant"
+	"ollama-manager/internal/vram"
+)
+
+// browseState is the library browser: a search box, the matching models and,
+// once one is opened, its tags.
+type browseState struct {
+	lib       *library.Client
+	query     textinput.Model
+	listFocus bool
+	loading   bool
+	err       error
+	fitsOnly  bool
+
+	results []library.Model
+	cursor  int
+
+	// presets shows the curated sets in place of the results.
+	presets      bool
+	presetCursor int
+
+	// model is the result whose tags are shown; empty while on the results.
+	model     string
+	tags      []library.Tag
+	tagCursor int
+}
+
+// browseResultsMsg carries a finished library search.
+type browseResultsMsg struct {
+	models []library.Model
+	err    error
+}
+
+// browseTagsMsg carries the tags of one library model.
+type browseTagsMsg struct {
+	name string
+	tags []library.Tag
+	err  error
+}
+
+func newBrowseState() *browseState {
+	q := textinput.New()
+	q.Prompt = "Search library: "
+	q.Placeholder = "qwen, coder, vision..."
+	q.CharLimit = 100
+	q.Width = 40
+	return &browseState{lib: library.NewClient(library.BaseURL), query: q}
+}
+
+func searchLibrary(lib *library.Client, query string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		models, err := lib.Search(ctx, query)
+		return browseResultsMsg{models: models, err: err}
+	}
+}
+
+func fetchLibraryTags(lib *library.Client, name string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		tags, err := lib.Tags(ctx, name)
+		return browseTagsMsg{name: name, tags: tags, err: err}
+	}
+}
+
+// openBrowse shows the browser, listing popular models on first use.
+func (m model) openBrowse() (tea.Model, tea.Cmd) {
+	m.mode = modeBrowse
+	if m.browse != nil {
+		return m, nil
+	}
+	m.browse = newBrowseState()
+	m.browse.loading = true
+	return m, tea.Batch(m.browse.query.Focus(), searchLibrary(m.browse.lib, ""))
+}
+
+// totalVRAM sums the memory of all GPUs. The browser compares against the
+// whole card since other models can be unloaded before a pull.
+func (m model) totalVRAM() (total uint64, ok bool) {
+	for _, d := range m.gpus {
+		total += d.MemoryTotal
+	}
+	return total, len(m.gpus) > 0
+}
+
+// downloadFit estimates whether a download of size bytes runs on the GPUs.
+func (m model) downloadFit(size int64) vram.Fit {
+	total, ok := m.totalVRAM()
+	if !ok || size <= 0 {
+		return vram.FitUnknown
+	}
+	est := vram.ForModel(size, vram.Arch{}, vram.DefaultContext)
+	return vram.Check(est.Total(), total)
+}
+
+// fittingSizes returns the parameter sizes of r, keeping only those that fit
+// when the filter is on.
+func (m model) fittingSizes(r library.Model) []string {
+	if !m.browse.fitsOnly {
+		return r.Sizes
+	}
+	var sizes []string
+	for _, s := range r.Sizes {
+		if m.downloadFit(library.Q4Bytes(library.ParamBillions(s))) != vram.WontFit {
+			sizes = append(sizes, s)
+		}
+	}
+	return sizes
+}
+
+// shownResults applies the fits-in-VRAM filter. Models without size labels
+// are kept since nothing is known about them.
+func (m model) shownResults() []library.Model {
+	if !m.browse.fitsOnly {
+		return m.browse.results
+	}
+	var shown []library.Model
+	for _, r := range m.browse.results {
+		if len(r.Sizes) == 0 || len(m.fittingSizes(r)) > 0 {
+			shown = append(shown, r)
+		}
+	}
+	return shown
+}
+
+func (m model) shownTags() []library.Tag {
+	if !m.browse.fitsOnly {
+		return m.browse.tags
+	}
+	var shown []library.Tag
+	for _, t := range m.browse.tags {
+		if m.downloadFit(t.Size) != vram.WontFit {
+			shown = append(shown, t)
+		}
+	}
+	return shown
+}
+
+func (m model) updateBrowse(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	b := m.browse
+	if !b.listFocus {
+		switch msg.String() {
+		case "esc":
+			m.mode = modeList
+			return m, nil
+		case "tab", "down":
+			b.listFocus = true
+			b.query.Blur()
+			return m, nil
+		case "enter":
+			b.model, b.tags, b.err, b.presets = "", nil, nil, false
+			b.loading, b.listFocus, b.cursor = true, true, 0
+			b.query.Blur()
+			return m, searchLibrary(b.lib, strings.TrimSpace(b.query.Value()))
+		}
+		var cmd tea.Cmd
+		b.query, cmd = b.query.Update(msg)
+		return m, cmd
+	}
+
+	switch {
+	case msg.String() == "esc":
+		if b.presets {
+			b.presets = false
+			return m, nil
+		}
+		if b.model != "" {
+			b.model, b.tags, b.err = "", nil, nil
+			return m, nil
+		}
+		m.mode = modeList
+	case msg.String() == "tab", key.Matches(msg, m.keys.Filter):
+		b.listFocus = false
+		return m, b.query.Focus()
+	case msg.String() == "t" && b.model == "":
+		b.presets = !b.presets
+	case msg.String() == "f":
+		if _, ok := m.totalVRAM(); !ok {
+			m.status = "No GPU readings to filter by"
+			break
+		}
+		b.fitsOnly = !b.fitsOnly
+		b.cursor, b.tagCursor = 0, 0
+	case key.Matches(msg, m.keys.Up):
+		if b.presets {
+			b.presetCursor = max(b.presetCursor-1, 0)
+		} else if b.model != "" {
+			b.tagCursor = max(b.tagCursor-1, 0)
+		} else {
+			b.cursor = max(b.cursor-1, 0)
+		}
+	case key.Matches(msg, m.keys.Down):
+		if b.presets {
+			b.presetCursor = min(b.presetCursor+1, len(presets)-1)
+		} else if b.model != "" {
+			b.tagCursor = min(b.tagCursor+1, max(len(m.shownTags())-1, 0))
+		} else {
+			b.cursor = min(b.cursor+1, max(len(m.shownResults())-1, 0))
+		}
+	case msg.String() == "enter", key.Matches(msg, m.keys.Pull):
+		if b.presets {
+			m.queuePreset(presets[b.presetCursor])
+			m.mode = modeList
+			return m, nil
+		}
+		if b.loading {
+			break
+		}
+		if b.model == "" {
+			results := m.shownResults()
+			if b.cursor >= len(results) {
+				break
+			}
+			b.model, b.tags, b.err = results[b.cursor].Name, nil, nil
+			b.loading, b.tagCursor = true, 0
+			return m, fetchLibraryTags(b.lib, b.model)
+		}
+		tags := m.shownTags()
+		if b.tagCursor >= len(tags) {
+			break
+		}
+		m.queuePull(tags[b.tagCursor].Name)
+		m.mode = modeList
+		return m, nil
+	}
+	return m, nil
+}
+
+func (m model) browseView() string {
+	b := m.browse
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Browse Library"))
+	switch {
+	case b.presets:
+		s.WriteString(helpStyle.Render("  Presets"))
+	case b.model != "":
+		s.WriteString(helpStyle.Render("  " + b.model))
+	}
+	if b.fitsOnly {
+		s.WriteString(loadedStyle.Render("  [fits VRAM]"))
+	}
+	s.WriteString("\n\n")
+	s.WriteString(b.query.View())
+	s.WriteString("\n\n")
+
+	rows := 15
+	if m.height > 0 {
+		rows = max(m.height-10, 5)
+	}
+	switch {
+	case b.presets:
+		s.WriteString(m.presetsList())
+	case b.loading:
+		s.WriteString("Loading...\n")
+	case b.err != nil:
+		s.WriteString(errorStyle.Render(fmt.Sprintf("Could not reach %s: %v", library.BaseURL, b.err)))
+		s.WriteString("\n")
+	case b.model != "":
+		s.WriteString(m.tagsList(rows))
+	default:
+		s.WriteString(m.resultsList(rows))
+	}
+
+	s.WriteString("\n")
+	help := "Enter: Search  Tab: Results  Esc: Back"
+	if b.listFocus {
+		help = "Enter: Open  " + helpLine(m.keys.Filter) + "  f: Fits VRAM  t: Presets  Esc: Back"
+		switch {
+		case b.presets:
+			help = "Enter/" + helpLine(m.keys.Pull) + " all  t/Esc: Results"
+		case b.model != "":
+			help = "Enter/" + helpLine(m.keys.Pull) + "  f: Fits VRAM  Esc: Results"
+		}
+	}
+	s.WriteString(helpStyle.Render(help))
+	s.WriteString("\n")
+	s.WriteString(fmt.Sprintf("\nStatus: %s", m.status))
+	return s.String()
+}
+
+// window returns the range of n rows to draw so cursor stays visible.
+func window(cursor, n, rows int) (int, int) {
+	start := max(min(cursor-rows/2, n-rows), 0)
+	return start, min(start+rows, n)
+}
+
+func (m model) resultsList(rows int) string {
+	b := m.browse
+	results := m.shownResults()
+	if len(results) == 0 {
+		return helpStyle.Render("No models found.") + "\n"
+	}
+	var s strings.Builder
+	start, end := window(b.cursor, len(results), rows/2)
+	for i := start; i < end; i++ {
+		r := results[i]
+		prefix := "  "
+		if i == b.cursor && b.listFocus {
+			prefix = cursorStyle.Render("> ")
+		}
+		line := fmt.Sprintf("%s%-28s %-24s", prefix, r.Name, strings.Join(m.fittingSizes(r), " "))
+		if r.Pulls != "" {
+			line += helpStyle.Render(" " + r.Pulls + " pulls")
+		}
+		if len(r.Capabilities) > 0 {
+			line += helpStyle.Render("  " + strings.Join(r.Capabilities, ", "))
+		}
+		s.WriteString(line)
+		s.WriteString("\n")
+		if r.Description != "" {
+			desc := r.Description
+			if m.width > 8 && len(desc) > m.width-4 {
+				desc = desc[:m.width-7] + "..."
+			}
+			s.WriteString(helpStyle.Render("    " + desc))
+			s.WriteString("\n")
+		}
+	}
+	return s.String()
+}
+
+// fitMarks label a download's fit in the browsers.
+var fitMarks = map[vram.Fit]string{
+	vram.Fits:    loadedStyle.Render("✓ fits"),
+	vram.Tight:   warnStyle.Render("! tight"),
+	vram.WontFit: errorStyle.Render("✗ too big"),
+}
+
+func (m model) tagsList(rows int) string {
+	b := m.browse
+	tags := m.shownTags()
+	if len(tags) == 0 {
+		return helpStyle.Render("No tags match.") + "\n"
+	}
+	suggested := m.suggestedTags(tags)
+	var s strings.Builder
+	start, end := window(b.tagCursor, len(tags), rows)
+	for i := start; i < end; i++ {
+		t := tags[i]
+		prefix := "  "
+		if i == b.tagCursor {
+			prefix = cursorStyle.Render("> ")
+		}
+		size := "?"
+		if t.Size > 0 {
+			size = formatBytes(uint64(t.Size))
+		}
+		l, _ := quant.Lookup(quant.Of(t.Name))
+		s.WriteString(fmt.Sprintf("%s%-48s %9s  %s  %s", prefix, t.Name, size, quantTradeoffs(l), fitMarks[m.downloadFit(t.Size)]))
+		if suggested[i] {
+			s.WriteString(helpStyle.Render("  suggested"))
+		}
+		s.WriteString("\n")
+	}
+	return s.String()
+}
+
+// presetsList shows each preset as it would be pulled for the GPUs.
+func (m model) presetsList() string {
+	total, ok := m.totalVRAM()
+	var s strings.Builder
+	if ok {
+		s.WriteString(helpStyle.Render("Sized for " + formatVRAM(total) + " of VRAM"))
+	} else {
+		s.WriteString(helpStyle.Render("No GPU readings: the smallest sizes are used"))
+	}
+	s.WriteString("\n\n")
+	for i, p := range presets {
+		prefix := "  "
+		if i == m.browse.presetCursor {
+			prefix = cursorStyle.Render("> ")
+		}
+		models := p.expand(total)
+		var largest int64
+		for _, pm := range models {
+			largest = max(largest, pm.size)
+		}
+		s.WriteString(fmt.Sprintf("%s%-20s %s  %s\n", prefix, p.name, presetSummary(models), fitMarks[m.downloadFit(largest)]))
+		s.WriteString(helpStyle.Render("    " + p.about))
+		s.WriteString("\n")
+	}
+	return s.String()
+}
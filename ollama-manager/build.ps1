@@ -2,7 +2,8 @@
 # Builds Windows exe using Docker or Podman (no Go required locally)
 
 param(
-    [switch]$Local  # Use local Go instead of container
+    [switch]$Local,  # Use local Go instead of container
+    [switch]$NVML    # Read GPU stats via NVML (requires -Local and cgo)
 )
 
 $ErrorActionPreference = "Stop"
@@ -21,7 +22,11 @@ if ($Local) {
         exit 1
     }
     go mod tidy
-    go build -ldflags="-s -w" -o ollama-manager.exe .
+    if ($NVML) {
+        go build -tags nvml -ldflags="-s -w" -o ollama-manager.exe .
+    } else {
+        go build -ldflags="-s -w" -o ollama-manager.exe .
+    }
 } else {
     $engine = Find-ContainerEngine
     if (-not $engine) {
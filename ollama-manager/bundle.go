@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/store"
+)
+
+// cmdExportBundle writes models with their blobs to a tar file, for
+// importing on a machine without internet access.
+func cmdExportBundle(c *ollama.Client, args []string) error {
+	if len(args) < 2 {
+		return usageError{"usage: export-bundle <file.tar|-> <model...>"}
+	}
+	if !c.Local() {
+		return errors.New("bundles are read from the models directory, so export-bundle only works for a local server")
+	}
+	dir, err := store.Dir()
+	if err != nil {
+		return err
+	}
+	path, models := args[0], args[1:]
+	if path == "-" {
+		return store.Export(os.Stdout, dir, models, nil)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = store.Export(f, dir, models, func(done, total int64) {
+		fmt.Printf("\r  %3.0f%% of %s   ", float64(done)/float64(max(total, 1))*100, formatBytes(uint64(total)))
+	})
+	fmt.Print("\r\033[K")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Exported %s to %s (%s)\n", strings.Join(models, ", "), path, formatBytes(uint64(info.Size())))
+	return nil
+}
+
+// cmdImportBundle adds the models of a tar file written by export-bundle to
+// the models directory. Ollama lists them right away.
+func cmdImportBundle(c *ollama.Client, args []string) error {
+	if len(args) != 1 {
+		return usageError{"usage: import-bundle <file.tar|->"}
+	}
+	if !c.Local() {
+		return errors.New("bundles are unpacked into the models directory, so import-bundle only works for a local server")
+	}
+	dir, err := store.Dir()
+	if err != nil {
+		return err
+	}
+	var r io.Reader = os.Stdin
+	var size int64
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if info, err := f.Stat(); err == nil {
+			size = info.Size()
+		}
+		r = f
+	}
+	added, skipped, err := store.Import(r, dir, func(done int64) {
+		if size > 0 {
+			fmt.Printf("\r  %3.0f%% of %s   ", float64(done)/float64(size)*100, formatBytes(uint64(size)))
+		}
+	})
+	fmt.Print("\r\033[K")
+	for _, name := range skipped {
+		fmt.Printf("%s: already installed, skipped\n", name)
+	}
+	for _, name := range added {
+		fmt.Printf("Imported %s\n", name)
+	}
+	return err
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/session"
+)
+
+var chatPaneStyle = lipgloss.NewStyle().Border(lipgloss.NormalBorder(), true, false)
+
+// chatState is an open conversation with one model.
+type chatState struct {
+	model    string
+	vision   bool // the model accepts images
+	history  []ollama.Message
+	partial  strings.Builder // assistant reply being streamed
+	input    textinput.Model
+	view     viewport.Model
+	updates  chan tea.Msg
+	cancel   context.CancelFunc
+	started  time.Time
+	lastStat string
+	err      error
+	session  *session.Session // where the conversation is saved
+
+	// attaching is set while the input holds an image path instead of the
+	// message, which is kept in draft. image is the base64 of the image sent
+	// with the next message.
+	attaching bool
+	draft     string
+	image     string
+	imageName string
+}
+
+// chatChunkMsg is a piece of the streamed assistant reply.
+type chatChunkMsg string
+
+// chatDoneMsg ends a streamed reply.
+type chatDoneMsg struct {
+	metrics ollama.Metrics
+	err     error
+}
+
+func newChat(name string, vision bool, width, height int) *chatState {
+	ti := textinput.New()
+	ti.Prompt = "> "
+	ti.Placeholder = "Send a message"
+	ti.Width = max(width-4, 20)
+
+	c := &chatState{
+		model:   name,
+		vision:  vision,
+		input:   ti,
+		view:    viewport.New(width, chatViewHeight(height)),
+		session: session.New(name),
+	}
+	c.render()
+	return c
+}
+
+// chatViewHeight leaves room for the tab bar, title, input line and help.
+func chatViewHeight(height int) int {
+	return max(height-7-tabBarLines, 5)
+}
+
+func (c *chatState) streaming() bool {
+	return c.updates != nil
+}
+
+func (c *chatState) resize(width, height int) {
+	c.view.Width = width
+	c.view.Height = chatViewHeight(height)
+	c.input.Width = max(width-4, 20)
+	c.render()
+}
+
+// send appends the prompt, and the attached image, to the history and starts
+// streaming the reply with the model's saved options.
+func (c *chatState) send(client *ollama.Client, prompt string, opts map[string]any) tea.Cmd {
+	msg := ollama.Message{Role: "user", Content: prompt}
+	if c.image != "" {
+		msg.Images = []string{c.image}
+		c.image, c.imageName = "", ""
+	}
+	c.history = append(c.history, msg)
+	c.partial.Reset()
+	c.err = nil
+	c.started = time.Now()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.updates = make(chan tea.Msg, 64)
+	req := ollama.ChatRequest{
+		Model:    c.model,
+		Messages: append([]ollama.Message(nil), c.history...),
+		Options:  opts,
+	}
+
+	updates := c.updates
+	go func() {
+		defer close(updates)
+		var final ollama.Metrics
+		err := client.Chat(ctx, req, func(r ollama.ChatResponse) {
+			if r.Message.Content != "" {
+				updates <- chatChunkMsg(r.Message.Content)
+			}
+			if r.Done {
+				final = r.Metrics
+			}
+		})
+		updates <- chatDoneMsg{metrics: final, err: err}
+	}()
+
+	c.render()
+	return listen(updates)
+}
+
+// finish moves the streamed reply into the history.
+func (c *chatState) finish(msg chatDoneMsg) {
+	c.updates = nil
+	c.cancel = nil
+	if reply := c.partial.String(); reply != "" {
+		c.history = append(c.history, ollama.Message{Role: "assistant", Content: reply})
+	}
+	c.partial.Reset()
+	c.err = msg.err
+	if errors.Is(msg.err, context.Canceled) {
+		c.err = nil
+		c.lastStat = "stopped"
+	} else if msg.err == nil {
+		c.lastStat = fmt.Sprintf("%d tokens  %.1f tok/s  %s",
+			msg.metrics.EvalCount, msg.metrics.TokensPerSecond(),
+			time.Since(c.started).Round(100*time.Millisecond))
+	}
+	c.render()
+}
+
+func (c *chatState) stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+// render rebuilds the transcript and keeps it scrolled to the newest text.
+func (c *chatState) render() {
+	wrap := lipgloss.NewStyle().Width(max(c.view.Width-2, 20))
+	var b strings.Builder
+	turn := func(role, content string) {
+		if role == "user" {
+			b.WriteString(userStyle.Render("You"))
+		} else {
+			b.WriteString(assistantStyle.Render(c.model))
+		}
+		b.WriteString("\n")
+		b.WriteString(wrap.Render(content))
+		b.WriteString("\n\n")
+	}
+	for _, msg := range c.history {
+		content := msg.Content
+		if len(msg.Images) > 0 {
+			content = helpStyle.Render("[image]") + " " + content
+		}
+		turn(msg.Role, content)
+	}
+	if c.streaming() {
+		turn("assistant", c.partial.String()+"▌")
+	}
+	if c.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", c.err)))
+		b.WriteString("\n")
+	}
+	c.view.SetContent(b.String())
+	c.view.GotoBottom()
+}
+
+func (m model) openChat(name string) (tea.Model, tea.Cmd) {
+	if m.chat == nil || m.chat.model != name {
+		if m.chat != nil {
+			m.chat.stop()
+		}
+		m.chat = newChat(name, m.archOf(name).Vision, m.width, m.height)
+	}
+	m.mode = modeChat
+	return m, m.chat.input.Focus()
+}
+
+// maxChatImage bounds an attached image; vision models scale images down to
+// well under a megapixel anyway.
+const maxChatImage = 20 << 20
+
+// attachImage reads the image at path for the next message.
+func (c *chatState) attachImage(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Size() > maxChatImage {
+		return fmt.Errorf("%s is larger than %s", filepath.Base(path), formatBytes(maxChatImage))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch http.DetectContentType(data) {
+	case "image/png", "image/jpeg":
+	default:
+		return fmt.Errorf("%s is not a PNG or JPEG image", filepath.Base(path))
+	}
+	c.image, c.imageName = base64.StdEncoding.EncodeToString(data), filepath.Base(path)
+	return nil
+}
+
+// updateAttach handles keys while the input holds an image path.
+func (m model) updateAttach(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := m.chat
+	switch msg.String() {
+	case "esc":
+	case "enter":
+		path := strings.Trim(strings.TrimSpace(c.input.Value()), `"`)
+		if path == "" {
+			break
+		}
+		if err := c.attachImage(path); err != nil {
+			c.err = err
+			c.render()
+			return m, nil
+		}
+		c.err = nil
+		c.render()
+	default:
+		var cmd tea.Cmd
+		c.input, cmd = c.input.Update(msg)
+		return m, cmd
+	}
+	c.attaching = false
+	c.input.Prompt, c.input.Placeholder = "> ", "Send a message"
+	c.input.SetValue(c.draft)
+	c.input.CursorEnd()
+	return m, nil
+}
+
+func (m model) updateChat(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := m.chat
+	if c.attaching {
+		return m.updateAttach(msg)
+	}
+	switch {
+	case key.Matches(msg, m.keys.Template):
+		return m.openTemplate()
+	case key.Matches(msg, m.keys.Sessions):
+		return m.openSessions()
+	case key.Matches(msg, m.keys.Attach):
+		c.attaching, c.draft = true, c.input.Value()
+		c.input.Prompt, c.input.Placeholder = "Image: ", "path to a PNG or JPEG file"
+		c.input.SetValue("")
+		return m, nil
+	case key.Matches(msg, m.keys.ChatStop):
+		// Abort the reply in flight; the partial text is kept.
+		c.stop()
+		return m, nil
+	case key.Matches(msg, m.keys.ChatClear):
+		if !c.streaming() {
+			// The cleared chat stays saved; the next message starts a new one.
+			c.history = nil
+			c.session = session.New(c.model)
+			c.lastStat = ""
+			c.image, c.imageName = "", ""
+			c.render()
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		c.input.Blur()
+		m.mode = modeList
+		return m, nil
+	case "pgup", "pgdown":
+		var cmd tea.Cmd
+		c.view, cmd = c.view.Update(msg)
+		return m, cmd
+	case "enter":
+		prompt := strings.TrimSpace(c.input.Value())
+		if c.streaming() || prompt == "" && c.image == "" {
+			return m, nil
+		}
+		if prompt == "" {
+			prompt = "Describe this image."
+		}
+		c.input.Reset()
+		return m, c.send(m.client, prompt, m.genOptions(c.model))
+	}
+
+	var cmd tea.Cmd
+	c.input, cmd = c.input.Update(msg)
+	return m, cmd
+}
+
+func (m model) chatView() string {
+	c := m.chat
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Chat: " + c.model))
+	if c.vision {
+		b.WriteString(helpStyle.Render("  vision"))
+	}
+	if c.lastStat != "" {
+		b.WriteString(helpStyle.Render("  " + c.lastStat))
+	}
+	b.WriteString("\n")
+	b.WriteString(chatPaneStyle.Render(c.view.View()))
+	b.WriteString("\n")
+	b.WriteString(c.input.View())
+	b.WriteString("\n")
+	help := "Enter: Send  PgUp/PgDn: Scroll  " + helpLine(m.keys.Attach, m.keys.Template, m.keys.Sessions, m.keys.ChatClear) + "  Esc: Back"
+	switch {
+	case c.attaching:
+		help = "Enter: Attach  Esc: Cancel"
+	case c.streaming():
+		help = helpLine(m.keys.ChatStop) + "  PgUp/PgDn: Scroll  Esc: Back"
+	case c.imageName != "":
+		help = "Enter: Send with " + c.imageName + "  " + helpLine(m.keys.ChatClear) + "  Esc: Back"
+	}
+	b.WriteString(helpStyle.Render(help))
+	return b.String()
+}
@@ -0,0 +1,479 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/diag"
+	"ollama-manager/internal/gpu"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/pullqueue"
+	"ollama-manager/internal/service"
+	"ollama-manager/internal/usage"
+)
+
+// command is a non-interactive subcommand that bypasses the TUI.
+type command struct {
+	summary string
+	run     func(c *ollama.Client, args []string) error
+}
+
+var commands = map[string]command{
+	"list":          {"List installed models and when each was last used [--unused] [--json]", cmdList},
+	"status":        {"Show server, loaded models and GPUs [--json]", cmdStatus},
+	"load":          {"Load one or more models into memory [--keep-alive 10m] [--num-ctx N] [--num-gpu N]", cmdLoad},
+	"unload":        {"Unload one or more models", cmdUnload},
+	"unload-all":    {"Unload every loaded model", cmdUnloadAll},
+	"warmup":        {"Load a warm-up set from the config, unloading everything else; list the sets without one", cmdWarmup},
+	"update":        {"Check installed models for newer versions in their registries [--pull] [--concurrency N] [--limit 20MB/s] [model...]", cmdUpdate},
+	"export":        {"Write the installed models to a manifest (YAML, JSON for .json, - for stdout)", cmdExport},
+	"import":        {"Pull the models of a manifest that aren't installed [--dry-run] [--concurrency N] [--limit 20MB/s]", cmdImport},
+	"import-gguf":   {"Create a model from a local GGUF file [--name NAME]", cmdImportGGUF},
+	"export-bundle": {"Write models with their blobs to a tar file (- for stdout) to import on a machine without internet", cmdExportBundle},
+	"import-bundle": {"Add the models of a tar file written by export-bundle to the models directory", cmdImportBundle},
+	"sweep":         {"Load a model at growing context lengths and time each, to find the largest that runs well on the GPU [--contexts 4096,8192,...] [--json]", cmdSweep},
+	"parallel":      {"Time 1 to 8 concurrent requests to a model, to pick OLLAMA_NUM_PARALLEL [--max N] [--json]", cmdParallel},
+	"usage":         {"Rank the installed models by requests, generated tokens and GPU time through the proxy, with GPU energy by day [--format csv|md] [--json]", cmdUsage},
+	"report":        {"Print the benchmark history as a table, CSV or Markdown to paste into a thread, or JSON [--format csv|md|json] [--model name] [--last N]", cmdReport},
+	"suite":         {"Run a benchmark suite of prompts and expected keywords against models, exiting 1 on failures; list the suites without one [--json]", cmdSuite},
+	"verify":        {"Re-hash the blobs of models (all without names) against their digests to find disk corruption [--repair] [model...]", cmdVerify},
+	"advise":        {"Suggest a quantization for a model size and the GPUs' VRAM [--vram GiB]", cmdAdvise},
+	"proxy":         {"Forward API traffic from :11435 (or [listen [upstream]]) and record per-client stats", cmdProxy},
+	"daemon":        {"Run the schedule, watchers and GPU history (and with --proxy the proxy) in the foreground for TUIs to attach to [--listen addr]", cmdDaemon},
+	"api":           {"Serve the management API on 127.0.0.1:11437 (or [listen]) for scripts and CI", cmdAPI},
+	"web":           {"Serve a browser dashboard on :8080 (or [listen]) to list, load, unload and pull models", cmdWeb},
+	"check":         {"Check driver, CUDA and Ollama compatibility and GPU use [--json]", cmdCheck},
+	"doctor":        {"Print a redacted diagnostic report for bug reports [--json]", cmdDoctor},
+	"setup":         {"Check the GPU, install Ollama, pull a first model and write the config", cmdSetup},
+}
+
+// usageError is reported with exit status 2 instead of 1.
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+func runCommand(c *ollama.Client, cmd command, args []string) int {
+	if err := cmd.run(c, args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if errors.As(err, new(usageError)) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+// printUsage lists the subcommands after the TUI's own flags.
+func printUsage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags]                    start the TUI\n", os.Args[0])
+	fmt.Fprintf(out, "       %s [flags] attach host:port   start the TUI on a daemon on another machine [--token T]\n", os.Args[0])
+	fmt.Fprintf(out, "       %s [flags] <command> [args]   run a command\n\nCommands:\n", os.Args[0])
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-14s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+// parseJSONFlag parses the common --json flag and returns remaining args.
+func parseJSONFlag(name string, args []string) (asJSON bool, rest []string, err error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.BoolVar(&asJSON, "json", false, "emit JSON")
+	if err := fs.Parse(args); err != nil {
+		return false, nil, usageError{err.Error()}
+	}
+	return asJSON, fs.Args(), nil
+}
+
+func writeJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+type modelJSON struct {
+	Name          string     `json:"name"`
+	Size          int64      `json:"size"`
+	Digest        string     `json:"digest,omitempty"`
+	ModifiedAt    time.Time  `json:"modified_at"`
+	Family        string     `json:"family,omitempty"`
+	ParameterSize string     `json:"parameter_size,omitempty"`
+	Quantization  string     `json:"quantization,omitempty"`
+	Loaded        bool       `json:"loaded"`
+	LastUsed      *time.Time `json:"last_used,omitempty"`
+}
+
+func cmdList(c *ollama.Client, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "emit JSON")
+	onlyUnused := fs.Bool("unused", false, "only models unused for unused_days (default 30)")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	models, err := getModels(c)
+	if err != nil {
+		return err
+	}
+	running, _ := getRunning(c)
+	loaded := loadedSet(running)
+	used := openUsageOrWarn()
+	if *onlyUnused {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		now, days := time.Now(), unusedDays(cfg)
+		kept := models[:0]
+		for _, m := range models {
+			if unused(used, c.Host(), m, days, now) {
+				kept = append(kept, m)
+			}
+		}
+		models = kept
+	}
+
+	if *asJSON {
+		return writeJSON(listJSON(models, loaded, used, c.Host()))
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSIZE\tQUANT\tLAST USED\tLOADED")
+	for _, m := range models {
+		mark := ""
+		if loaded[m.Name] {
+			mark = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", m.Name, formatBytes(uint64(m.Size)), m.Details.QuantizationLevel,
+			lastUsedText(used.Last(c.Host(), m.Name)), mark)
+	}
+	return tw.Flush()
+}
+
+func listJSON(models []ollama.Model, loaded map[string]bool, used *usage.Log, host string) []modelJSON {
+	out := make([]modelJSON, 0, len(models))
+	for _, m := range models {
+		var last *time.Time
+		if t := used.Last(host, m.Name); !t.IsZero() {
+			last = &t
+		}
+		out = append(out, modelJSON{
+			Name:          m.Name,
+			Size:          m.Size,
+			Digest:        m.Digest,
+			ModifiedAt:    m.ModifiedAt,
+			Family:        m.Details.Family,
+			ParameterSize: m.Details.ParameterSize,
+			Quantization:  m.Details.QuantizationLevel,
+			Loaded:        loaded[m.Name],
+			LastUsed:      last,
+		})
+	}
+	return out
+}
+
+type runningJSON struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	SizeVRAM  int64     `json:"size_vram"`
+	Processor string    `json:"processor"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type gpuJSON struct {
+	Index       int    `json:"index"`
+	Name        string `json:"name"`
+	MemoryTotal uint64 `json:"memory_total"`
+	MemoryUsed  uint64 `json:"memory_used"`
+	Utilization int    `json:"utilization"`
+	Temperature int    `json:"temperature"`
+	Power       int    `json:"power,omitempty"`
+}
+
+type statusJSON struct {
+	Host      string        `json:"host"`
+	Reachable bool          `json:"reachable"`
+	Version   string        `json:"version,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	Loaded    []runningJSON `json:"loaded"`
+	GPUs      []gpuJSON     `json:"gpus"`
+}
+
+func cmdStatus(c *ollama.Client, args []string) error {
+	asJSON, _, err := parseJSONFlag("status", args)
+	if err != nil {
+		return err
+	}
+
+	st, verErr, err := collectStatus(context.Background(), c)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		if err := writeJSON(st); err != nil {
+			return err
+		}
+	} else {
+		printStatus(st)
+	}
+	if verErr != nil {
+		return fmt.Errorf("ollama not reachable at %s: %w", c.Host(), verErr)
+	}
+	return nil
+}
+
+// collectStatus reports on the server and this machine's GPUs. verErr
+// says why the server is unreachable, which still makes a report; err is
+// for a server that answered but failed.
+func collectStatus(ctx context.Context, c *ollama.Client) (st statusJSON, verErr, err error) {
+	st = statusJSON{Host: c.Host(), Loaded: []runningJSON{}, GPUs: []gpuJSON{}}
+	ctx, cancel := context.WithTimeout(ctx, apiTimeout)
+	defer cancel()
+	version, verErr := c.Version(ctx)
+	if verErr == nil {
+		st.Reachable = true
+		st.Version = version
+		running, err := c.Running(ctx)
+		if err != nil {
+			return st, nil, err
+		}
+		for _, r := range running {
+			st.Loaded = append(st.Loaded, runningJSON{r.Name, r.Size, r.SizeVRAM, r.Processor(), r.ExpiresAt})
+		}
+	} else {
+		st.Error = verErr.Error()
+	}
+	if gpus, err := gpu.Query(); err == nil {
+		for _, d := range gpus {
+			st.GPUs = append(st.GPUs, gpuJSON{d.Index, d.Name, d.MemoryTotal, d.MemoryUsed, d.Utilization, d.Temperature, d.Power})
+		}
+	}
+	return st, verErr, nil
+}
+
+func printStatus(st statusJSON) {
+	if st.Reachable {
+		fmt.Printf("Ollama %s at %s\n", st.Version, st.Host)
+	} else {
+		fmt.Printf("Ollama not reachable at %s\n", st.Host)
+	}
+
+	fmt.Printf("\nLoaded models: %d\n", len(st.Loaded))
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, r := range st.Loaded {
+		fmt.Fprintf(tw, "  %s\t%s\t%s VRAM\t%s\tuntil %s\n", r.Name, formatBytes(uint64(r.Size)),
+			formatBytes(uint64(r.SizeVRAM)), r.Processor, r.ExpiresAt.Local().Format(time.Kitchen))
+	}
+	tw.Flush()
+
+	fmt.Printf("\nGPUs: %d\n", len(st.GPUs))
+	for _, d := range st.GPUs {
+		fmt.Printf("  %d %s  %s / %s  util %d%%  %d°C%s\n", d.Index, d.Name,
+			formatVRAM(d.MemoryUsed), formatVRAM(d.MemoryTotal), d.Utilization, d.Temperature, formatPower(d.Power))
+	}
+}
+
+func cmdCheck(c *ollama.Client, args []string) error {
+	asJSON, _, err := parseJSONFlag("check", args)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+	r := diag.Run(ctx, c, firstNonEmpty(cfg.Service, service.DefaultName()))
+
+	if asJSON {
+		if err := writeJSON(r); err != nil {
+			return err
+		}
+	} else {
+		printFindings(r.Findings)
+	}
+	if r.Worst() == diag.Fail {
+		return errors.New("problems found")
+	}
+	return nil
+}
+
+// printFindings lists check results, one per line, marked by status.
+func printFindings(findings []diag.Finding) {
+	marks := map[diag.Status]string{diag.OK: "✓", diag.Warn: "!", diag.Fail: "✗"}
+	for _, f := range findings {
+		fmt.Printf("  %s %s\n", marks[f.Status], f.Detail)
+	}
+}
+
+func cmdLoad(c *ollama.Client, args []string) error {
+	fs := flag.NewFlagSet("load", flag.ContinueOnError)
+	keepAlive := fs.String("keep-alive", "", "keep-alive for the loaded models (10m, 1h, -1 = forever)")
+	numCtx := fs.Int("num-ctx", 0, "context length in tokens (default: the model's)")
+	numGPU := fs.Int("num-gpu", -1, "layers to offload to the GPU (default: as many as fit)")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if fs.NArg() == 0 {
+		return usageError{"usage: load [--keep-alive 10m] [--num-ctx N] [--num-gpu N] <model> [model...]"}
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range fs.Args() {
+		opts, err := explicitLoadOptions(cfg, name, *keepAlive, *numCtx, *numGPU)
+		if err != nil {
+			return usageError{err.Error()}
+		}
+		if err := c.Load(context.Background(), name, opts); err != nil {
+			return fmt.Errorf("load %s: %w", name, err)
+		}
+		fmt.Printf("Loaded %s\n", name)
+	}
+	return nil
+}
+
+// explicitLoadOptions combines options given for a load with the model's
+// configured keep-alive. A negative numGPU leaves the layers to the server.
+func explicitLoadOptions(cfg *config.Config, name, keepAlive string, numCtx, numGPU int) (ollama.LoadOptions, error) {
+	opts := ollama.LoadOptions{NumCtx: numCtx}
+	if numGPU >= 0 {
+		opts.NumGPU = &numGPU
+	}
+	if v := firstNonEmpty(keepAlive, cfg.KeepAliveFor(name)); v != "" {
+		var err error
+		if opts.KeepAlive, err = ollama.ParseKeepAlive(v); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func cmdUnload(c *ollama.Client, args []string) error {
+	if len(args) == 0 {
+		return usageError{"usage: unload <model> [model...]"}
+	}
+	for _, name := range args {
+		if err := unloadModel(c, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func cmdUnloadAll(c *ollama.Client, args []string) error {
+	running, err := getRunning(c)
+	if err != nil {
+		return err
+	}
+	for _, r := range running {
+		if err := unloadModel(c, r.Name); err != nil {
+			return err
+		}
+	}
+	if len(running) == 0 {
+		fmt.Println("No models loaded")
+	}
+	return nil
+}
+
+func unloadModel(c *ollama.Client, name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+	if err := c.Unload(ctx, name); err != nil {
+		return fmt.Errorf("unload %s: %w", name, err)
+	}
+	fmt.Printf("Unloaded %s\n", name)
+	return nil
+}
+
+// pullQueueFlags adds the flags of the commands that queue pulls.
+func pullQueueFlags(fs *flag.FlagSet) (workers *int, limit *string) {
+	workers = fs.Int("concurrency", 0, "pulls to run at once (default from the config, else 2)")
+	limit = fs.String("limit", "", "cap on the combined download rate, e.g. 20MB/s (default from the config)")
+	return workers, limit
+}
+
+// cliPullQueue sets up a pull queue from the config, with the flags taking
+// precedence.
+func cliPullQueue(workers int, limit string) (*pullqueue.Queue, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if workers > 0 {
+		cfg.PullConcurrency = workers
+	}
+	if limit != "" {
+		cfg.PullRateLimit = limit
+	}
+	q, err := newPullQueue(cfg)
+	if err != nil {
+		return nil, usageError{err.Error()}
+	}
+	return q, nil
+}
+
+// runPullQueue pulls names through q, printing each as it finishes and the
+// overall progress on one line, and returns the outcomes.
+func runPullQueue(q *pullqueue.Queue, c *ollama.Client, names []string) []pullqueue.Item {
+	for _, name := range names {
+		q.Add(c, name)
+	}
+	var finished []pullqueue.Item
+	for q.Busy() {
+		<-q.Changed()
+		for _, it := range q.TakeFinished() {
+			finished = append(finished, it)
+			switch it.State {
+			case pullqueue.Done:
+				fmt.Printf("\r\033[K  ✓ %s\n", it.Name)
+			default:
+				fmt.Printf("\r\033[K  ✗ %s: %s\n", it.Name, it.Status)
+			}
+		}
+		pulling, queued := 0, 0
+		var total, done int64
+		var rate float64
+		for _, it := range q.Items() {
+			switch it.State {
+			case pullqueue.Queued:
+				queued++
+			case pullqueue.Pulling, pullqueue.Throttled:
+				pulling++
+				total, done, rate = total+it.Total, done+it.Done, rate+it.Rate
+			}
+		}
+		if pulling > 0 {
+			fmt.Printf("\r\033[K  %d pulling, %d queued: %s of %s at %s/s", pulling, queued,
+				formatBytes(uint64(done)), formatBytes(uint64(total)), formatBytes(uint64(max(rate, 0))))
+		}
+	}
+	fmt.Print("\r\033[K")
+	return append(finished, q.TakeFinished()...)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/ollama"
+)
+
+// snippetLangs are the languages of the API snippets, with the key that
+// picks each.
+var snippetLangs = []struct{ key, name string }{
+	{"c", "curl"},
+	{"p", "Python"},
+	{"j", "JavaScript"},
+}
+
+// copyText puts text on the clipboard, reporting it as what.
+func (m *model) copyText(text, what string) {
+	if err := clipboard.WriteAll(text); err != nil {
+		m.status = fmt.Sprintf("Could not copy to the clipboard: %v", err)
+		return
+	}
+	m.status = "Copied " + what + " to the clipboard"
+}
+
+// openSnippet asks which language of API snippet to copy for a model.
+func (m model) openSnippet(name string) (tea.Model, tea.Cmd) {
+	m.mode = modeSnippet
+	m.snippetModel = name
+	return m, nil
+}
+
+func (m model) updateSnippet(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.mode = modeList
+	for _, l := range snippetLangs {
+		if msg.String() == l.key {
+			auth := m.client.Auth()
+			m.copyText(apiSnippet(l.name, m.client.Host(), m.snippetModel, auth), "a "+l.name+" snippet for "+m.snippetModel)
+			if env := snippetSecret(auth); env != "" {
+				m.status += "; it reads the credential from " + env
+			}
+			return m, nil
+		}
+	}
+	return m, nil
+}
+
+func (m model) snippetView() string {
+	keys := make([]string, len(snippetLangs))
+	for i, l := range snippetLangs {
+		keys[i] = l.key + ": " + l.name
+	}
+	return warnStyle.Render(fmt.Sprintf("Copy a snippet calling %s on %s", m.snippetModel, m.client.Host())) + "\n" +
+		helpStyle.Render(strings.Join(keys, "  ")+"  any other key: Cancel")
+}
+
+// The snippets take a host's secret from an environment variable rather
+// than putting it on the clipboard.
+const (
+	snippetTokenEnv    = "OLLAMA_API_KEY"
+	snippetPasswordEnv = "OLLAMA_PASSWORD"
+)
+
+// snippetSecret is the variable the snippets read auth's secret from, or ""
+// for a host without credentials.
+func snippetSecret(auth ollama.Auth) string {
+	switch {
+	case auth.Token != "":
+		return snippetTokenEnv
+	case auth.Username != "":
+		return snippetPasswordEnv
+	}
+	return ""
+}
+
+// apiSnippet is a chat request to model on host in lang, through the
+// OpenAI-compatible endpoint so the same code works against other servers.
+// It sends the kind of credentials auth has, with the secret read from the
+// environment.
+func apiSnippet(lang, host, model string, auth ollama.Auth) string {
+	q, _ := json.Marshal(model)
+	user, _ := json.Marshal(auth.Username + ":")
+	switch lang {
+	case "Python":
+		// The client sends its API key as a bearer token, and needs one even
+		// for a server that takes none.
+		key, imports, extra := `"ollama"`, "", ""
+		switch {
+		case auth.Token != "":
+			key, imports = fmt.Sprintf("os.environ[%q]", snippetTokenEnv), "import os\n"
+		case auth.Username != "":
+			imports = "import base64\nimport os\n"
+			extra = fmt.Sprintf(`
+basic = base64.b64encode((%s + os.environ[%q]).encode()).decode()`, user, snippetPasswordEnv)
+		}
+		client := fmt.Sprintf(`OpenAI(base_url="%s/v1", api_key=%s)`, host, key)
+		if extra != "" {
+			client = fmt.Sprintf(`OpenAI(
+    base_url="%s/v1",
+    api_key=%s,
+    default_headers={"Authorization": "Basic " + basic},
+)`, host, key)
+		}
+		return fmt.Sprintf(`%sfrom openai import OpenAI
+%s
+client = %s
+reply = client.chat.completions.create(
+    model=%s,
+    messages=[{"role": "user", "content": "Hello!"}],
+)
+print(reply.choices[0].message.content)
+`, imports, extra, client, q)
+	case "JavaScript":
+		header := ""
+		switch {
+		case auth.Token != "":
+			header = fmt.Sprintf("\n    Authorization: `Bearer ${process.env.%s}`,", snippetTokenEnv)
+		case auth.Username != "":
+			header = fmt.Sprintf("\n    Authorization: \"Basic \" + btoa(%s + process.env.%s),", user, snippetPasswordEnv)
+		}
+		return fmt.Sprintf(`const res = await fetch("%s%s", {
+  method: "POST",
+  headers: {
+    "Content-Type": "application/json",%s
+  },
+  body: JSON.stringify({
+    model: %s,
+    messages: [{ role: "user", content: "Hello!" }],
+  }),
+});
+const reply = await res.json();
+console.log(reply.choices[0].message.content);
+`, host, ollama.ChatCompletionsPath, header, q)
+	}
+	header := ""
+	switch {
+	case auth.Token != "":
+		header = fmt.Sprintf("\n  -H \"Authorization: Bearer $%s\" \\", snippetTokenEnv)
+	case auth.Username != "":
+		header = fmt.Sprintf("\n  -u \"%s:$%s\" \\", shellQuoted(auth.Username), snippetPasswordEnv)
+	}
+	return fmt.Sprintf(`curl %s%s \%s
+  -H "Content-Type: application/json" \
+  -d '{"model": %s, "messages": [{"role": "user", "content": "Hello!"}]}'
+`, host, ollama.ChatCompletionsPath, header, q)
+}
+
+// shellQuoted escapes s for use inside double quotes in a POSIX shell.
+func shellQuoted(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(s)
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"ollama-manager/internal/ollama"
+)
+
+var comparePaneStyle = lipgloss.NewStyle().Border(lipgloss.NormalBorder()).Padding(0, 1)
+
+// compareState sends every prompt to two models at once and shows their
+// replies side by side. Each side keeps its own conversation.
+type compareState struct {
+	panes [2]*comparePane
+	input textinput.Model
+}
+
+// comparePane is one model's side of a comparison.
+type comparePane struct {
+	model   string
+	history []ollama.Message
+	partial strings.Builder
+	view    viewport.Model
+	updates chan tea.Msg
+	cancel  context.CancelFunc
+	started time.Time
+	ttft    time.Duration
+	stats   string
+	err     error
+}
+
+// compareChunkMsg is a piece of a streamed reply in pane.
+type compareChunkMsg struct {
+	pane *comparePane
+	text string
+}
+
+// compareDoneMsg ends the reply in pane.
+type compareDoneMsg struct {
+	pane    *comparePane
+	metrics ollama.Metrics
+	err     error
+}
+
+// compareViewHeight leaves room for the title, pane borders, stats, input
+// and help.
+func compareViewHeight(height int) int {
+	return max(height-9, 5)
+}
+
+// comparePaneWidth is the text width of each of the two panes, inside their
+// border and padding.
+func comparePaneWidth(width int) int {
+	return max(width/2-4, 20)
+}
+
+func newCompareState(models [2]string, width, height int) *compareState {
+	ti := textinput.New()
+	ti.Prompt = "> "
+	ti.Placeholder = "Send a message to both models"
+	ti.Width = max(width-4, 20)
+
+	s := &compareState{input: ti}
+	for i, name := range models {
+		s.panes[i] = &comparePane{model: name, view: viewport.New(comparePaneWidth(width), compareViewHeight(height))}
+		s.panes[i].render()
+	}
+	return s
+}
+
+func (s *compareState) resize(width, height int) {
+	s.input.Width = max(width-4, 20)
+	for _, p := range s.panes {
+		p.view.Width = comparePaneWidth(width)
+		p.view.Height = compareViewHeight(height)
+		p.render()
+	}
+}
+
+func (s *compareState) streaming() bool {
+	return s.panes[0].streaming() || s.panes[1].streaming()
+}
+
+func (s *compareState) stop() {
+	for _, p := range s.panes {
+		if p.cancel != nil {
+			p.cancel()
+		}
+	}
+}
+
+func (p *comparePane) streaming() bool {
+	return p.updates != nil
+}
+
+// send appends the prompt and streams the model's reply, with its saved
+// options, into the pane.
+func (p *comparePane) send(client *ollama.Client, prompt string, opts map[string]any) tea.Cmd {
+	p.history = append(p.history, ollama.Message{Role: "user", Content: prompt})
+	p.partial.Reset()
+	p.ttft, p.stats, p.err = 0, "", nil
+	p.started = time.Now()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.updates = make(chan tea.Msg, 64)
+	req := ollama.ChatRequest{
+		Model:    p.model,
+		Messages: append([]ollama.Message(nil), p.history...),
+		Options:  opts,
+	}
+
+	updates := p.updates
+	go func() {
+		defer close(updates)
+		var final ollama.Metrics
+		err := client.Chat(ctx, req, func(r ollama.ChatResponse) {
+			if r.Message.Content != "" {
+				updates <- compareChunkMsg{pane: p, text: r.Message.Content}
+			}
+			if r.Done {
+				final = r.Metrics
+			}
+		})
+		updates <- compareDoneMsg{pane: p, metrics: final, err: err}
+	}()
+
+	p.render()
+	return listen(updates)
+}
+
+func (p *comparePane) add(text string) {
+	if p.ttft == 0 {
+		p.ttft = time.Since(p.started)
+	}
+	p.partial.WriteString(text)
+	p.render()
+}
+
+// finish moves the streamed reply into the history and sums up its timing.
+// When both models don't fit in VRAM together, Ollama runs them one after
+// the other, which shows up as a long time to first token and a load time.
+func (p *comparePane) finish(msg compareDoneMsg) {
+	p.updates, p.cancel = nil, nil
+	if reply := p.partial.String(); reply != "" {
+		p.history = append(p.history, ollama.Message{Role: "assistant", Content: reply})
+	}
+	p.partial.Reset()
+	switch {
+	case errors.Is(msg.err, context.Canceled):
+		p.stats = "stopped"
+	case msg.err != nil:
+		p.err = msg.err
+	default:
+		mt := msg.metrics
+		p.stats = fmt.Sprintf("%d tokens  %.1f tok/s  first token %s  total %s",
+			mt.EvalCount, mt.TokensPerSecond(), formatLatency(p.ttft), formatLatency(time.Since(p.started)))
+		if mt.LoadDuration >= 100*time.Millisecond {
+			p.stats += "  load " + formatLatency(mt.LoadDuration)
+		}
+	}
+	p.render()
+}
+
+func (p *comparePane) render() {
+	wrap := lipgloss.NewStyle().Width(p.view.Width)
+	var b strings.Builder
+	turn := func(role, content string) {
+		if role == "user" {
+			b.WriteString(userStyle.Render("You"))
+		} else {
+			b.WriteString(assistantStyle.Render(p.model))
+		}
+		b.WriteString("\n")
+		b.WriteString(wrap.Render(content))
+		b.WriteString("\n\n")
+	}
+	for _, msg := range p.history {
+		turn(msg.Role, msg.Content)
+	}
+	if p.streaming() {
+		turn("assistant", p.partial.String()+"▌")
+	}
+	if p.err != nil {
+		b.WriteString(wrap.Render(errorStyle.Render(fmt.Sprintf("Error: %v", p.err))))
+		b.WriteString("\n")
+	}
+	p.view.SetContent(b.String())
+	p.view.GotoBottom()
+}
+
+// openCompare starts a comparison of the two marked models, or returns to
+// the last one if it was of the same two.
+func (m model) openCompare() (tea.Model, tea.Cmd) {
+	targets := m.targets()
+	if len(targets) != 2 {
+		m.status = "Mark two models with Space to compare them"
+		return m, nil
+	}
+	for _, mdl := range targets {
+		if m.arch[archKey(mdl)].Embedding {
+			m.status = mdl.Name + " is an embedding model and can't chat"
+			return m, nil
+		}
+	}
+	models := [2]string{targets[0].Name, targets[1].Name}
+	if s := m.compare; s == nil || s.panes[0].model != models[0] || s.panes[1].model != models[1] {
+		if s != nil {
+			s.stop()
+		}
+		m.compare = newCompareState(models, m.width, m.height)
+	}
+	m.mode = modeCompare
+	return m, m.compare.input.Focus()
+}
+
+func (m model) updateCompare(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := m.compare
+	switch {
+	case key.Matches(msg, m.keys.ChatStop):
+		s.stop()
+		return m, nil
+	case key.Matches(msg, m.keys.ChatClear):
+		if !s.streaming() {
+			for _, p := range s.panes {
+				p.history, p.stats, p.err = nil, "", nil
+				p.render()
+			}
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		// Replies in flight are stopped; the conversations are kept for
+		// when the same two models are compared again.
+		s.stop()
+		s.input.Blur()
+		m.mode = modeList
+		return m, nil
+	case "pgup", "pgdown":
+		var cmds []tea.Cmd
+		for _, p := range s.panes {
+			var cmd tea.Cmd
+			p.view, cmd = p.view.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+		return m, tea.Batch(cmds...)
+	case "enter":
+		prompt := strings.TrimSpace(s.input.Value())
+		if s.streaming() || prompt == "" {
+			return m, nil
+		}
+		s.input.Reset()
+		var cmds []tea.Cmd
+		for _, p := range s.panes {
+			cmds = append(cmds, p.send(m.client, prompt, m.genOptions(p.model)), m.startBusy())
+		}
+		return m, tea.Batch(cmds...)
+	}
+
+	var cmd tea.Cmd
+	s.input, cmd = s.input.Update(msg)
+	return m, cmd
+}
+
+func (m model) compareView() string {
+	s := m.compare
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Compare: %s vs %s", s.panes[0].model, s.panes[1].model)))
+	b.WriteString("\n")
+	cols := make([]string, len(s.panes))
+	for i, p := range s.panes {
+		stats := p.stats
+		if p.streaming() {
+			stats = m.spinner.View() + " generating"
+			if p.ttft > 0 {
+				stats += "  first token " + formatLatency(p.ttft)
+			}
+		}
+		cols[i] = comparePaneStyle.Render(p.view.View() + "\n" + helpStyle.Render(stats))
+	}
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, cols...))
+	b.WriteString("\n")
+	b.WriteString(s.input.View())
+	b.WriteString("\n")
+	help := "Enter: Send to both  PgUp/PgDn: Scroll  " + helpLine(m.keys.ChatClear) + "  Esc: Back"
+	if s.streaming() {
+		help = helpLine(m.keys.ChatStop) + "  PgUp/PgDn: Scroll  Esc: Back"
+	}
+	b.WriteString(helpStyle.Render(help))
+	return b.String()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openCopyInput prompts for the name to copy a model to, prefilled with the
+// source so only the tag needs editing.
+func (m model) openCopyInput(name string) (tea.Model, tea.Cmd) {
+	m.mode = modeCopyInput
+	m.copySource = name
+	m.input.Reset()
+	m.input.Prompt = fmt.Sprintf("Copy %s to: ", name)
+	m.input.Placeholder = ""
+	m.input.CharLimit = 256
+	m.input.Width = 40
+	m.input.SetValue(name)
+	m.input.CursorEnd()
+	return m, m.input.Focus()
+}
+
+// updateCopyInput copies the model under the entered name. Existing models
+// are never overwritten.
+func (m model) updateCopyInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = modeList
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		src := m.copySource
+		dst := strings.TrimSpace(m.input.Value())
+		if dst == "" || dst == src {
+			m.status = "Enter a new name for the copy"
+			return m, nil
+		}
+		if m.modelExists(dst) {
+			m.status = dst + " already exists"
+			return m, nil
+		}
+		m.mode = modeList
+		m.input.Blur()
+		c := m.client
+		return m, m.runOp(fmt.Sprintf("Copying %s to %s", src, dst), "Copied "+src+" to "+dst, func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+			defer cancel()
+			return c.Copy(ctx, src, dst)
+		})
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
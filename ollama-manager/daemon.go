@@ -0,0 +1,485 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/daemon"
+	"ollama-manager/internal/gpu"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/resume"
+	"ollama-manager/internal/schedule"
+	"ollama-manager/internal/service"
+	"ollama-manager/internal/usage"
+)
+
+// attachTimeout bounds the TUI's look for a daemon at startup, so a stale
+// socket doesn't hold it up.
+const attachTimeout = 2 * time.Second
+
+// daemonState is what the daemon serves to the TUI. Its GPU history is
+// recorded here rather than in each TUI, so it survives closing them.
+type daemonState struct {
+	mu      sync.Mutex
+	status  daemon.Status
+	jobs    []schedule.Job
+	last    map[string]daemon.Job // by job name, once it has run
+	history *gpu.History
+	usage   *usage.Log
+	meter   energyMeter
+}
+
+func (d *daemonState) Status() daemon.Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	st := d.status
+	now := time.Now()
+	for _, j := range d.jobs {
+		dj := d.last[j.Name]
+		dj.Name, dj.Spec, dj.Next = j.Name, j.Spec.String(), j.Spec.Next(now)
+		st.Jobs = append(st.Jobs, dj)
+	}
+	return st
+}
+
+func (d *daemonState) Samples() []gpu.Sample {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.history.All()
+}
+
+func (d *daemonState) GPUs() ([]gpu.Device, error) {
+	return gpu.Query()
+}
+
+// ran records the outcome of a scheduled job.
+func (d *daemonState) ran(j schedule.Job, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dj := daemon.Job{Last: time.Now()}
+	if err != nil {
+		dj.Err = err.Error()
+	}
+	d.last[j.Name] = dj
+}
+
+// recordGPUs samples the GPUs on the TUI's default refresh interval until
+// ctx is done, opening NVML again when the machine wakes from sleep.
+func (d *daemonState) recordGPUs(ctx context.Context) {
+	ticker := time.NewTicker(defaultRefresh)
+	defer ticker.Stop()
+	wake := resume.NewDetector(defaultRefresh)
+	for {
+		if slept, ok := wake.Check(time.Now()); ok {
+			fmt.Printf("%s resumed after %s asleep; reopening the GPUs\n", time.Now().Format("2006-01-02 15:04"), slept.Round(time.Minute))
+			gpu.Reinit()
+		}
+		if devices, err := gpu.Query(); err == nil {
+			d.mu.Lock()
+			err = d.history.Add(time.Now(), devices)
+			d.mu.Unlock()
+			if err != nil {
+				fmt.Printf("%s saving GPU history: %v\n", time.Now().Format("2006-01-02 15:04"), err)
+			}
+			if err := recordEnergy(d.usage, &d.meter, d.status.Host, devices, 2*defaultRefresh); err != nil {
+				fmt.Printf("%s saving energy: %v\n", time.Now().Format("2006-01-02 15:04"), err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func cmdDaemon(c *ollama.Client, args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	withProxy := fs.Bool("proxy", false, "also run the reverse proxy")
+	listen := fs.String("listen", "", "also accept TUIs on this TCP address (default from the config)")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if fs.NArg() > 0 {
+		return usageError{"usage: daemon [--proxy] [--listen addr]"}
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	*listen = firstNonEmpty(*listen, daemonListen(cfg))
+	remote, err := daemonRemote(cfg, *listen, c)
+	if err != nil {
+		return err
+	}
+	jobs, err := scheduleJobs(c, cfg)
+	if err != nil {
+		return err
+	}
+	idle, err := newIdleWatch(cfg.AutoUnload)
+	if err != nil {
+		return err
+	}
+	if idle != nil && !c.Local() {
+		return fmt.Errorf("auto_unload needs the Ollama server on this machine, not %s", c.Host())
+	}
+	thermal, err := newThermalWatch(cfg.Thermal)
+	if err != nil {
+		return err
+	}
+	if thermal != nil && !c.Local() {
+		return fmt.Errorf("thermal needs the Ollama server on this machine, not %s", c.Host())
+	}
+	watch, err := newWatchdog(cfg.Watchdog)
+	if err != nil {
+		return err
+	}
+	if watch != nil && !c.Local() {
+		return fmt.Errorf("watchdog needs the Ollama server on this machine, not %s", c.Host())
+	}
+	if err := checkWebhooks(cfg); err != nil {
+		return err
+	}
+	if err := checkNotify(cfg); err != nil {
+		return err
+	}
+	alerts := newAlerts(cfg)
+	upstream := c.Host()
+	if *withProxy && cfg.Proxy != nil && cfg.Proxy.Upstream != "" {
+		if upstream, err = proxyUpstream(cfg.Proxy.Upstream); err != nil {
+			return err
+		}
+	}
+	history, err := openGPUHistory(cfg)
+	if err != nil {
+		return err
+	}
+	sock, err := daemon.SocketPath()
+	if err != nil {
+		return err
+	}
+	ln, err := daemon.Listen(sock)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(sock)
+	var tcp net.Listener
+	if remote != nil {
+		if tcp, err = net.Listen("tcp", *listen); err != nil {
+			ln.Close()
+			return err
+		}
+	}
+
+	state := &daemonState{
+		status:  daemon.Status{PID: os.Getpid(), Started: time.Now(), Host: c.Host(), Webhooks: len(cfg.Webhooks)},
+		jobs:    jobs,
+		last:    make(map[string]daemon.Job),
+		history: history,
+		usage:   openUsageOrWarn(),
+	}
+	now := time.Now()
+	for _, j := range jobs {
+		next := "never"
+		if t := j.Spec.Next(now); !t.IsZero() {
+			next = t.Format("Mon Jan 2 15:04")
+		}
+		fmt.Printf("%-18s %-32s next %s\n", j.Spec, j.Name, next)
+	}
+	if idle != nil {
+		state.status.AutoUnload = fmt.Sprintf("after %s idle", idle.idle)
+		fmt.Printf("Unloading models after %s idle when another program needs the GPU\n", idle.idle)
+	}
+	if thermal != nil {
+		state.status.Thermal = thermal.String()
+		fmt.Printf("Watching GPUs for %s\n", thermal)
+	}
+	if watch != nil {
+		state.status.Watchdog = watch.String()
+		fmt.Printf("Checking the server %s\n", watch)
+	}
+	if alerts != nil {
+		fmt.Printf("Sending events to %d webhooks\n", len(alerts.hooks))
+	}
+	if *withProxy {
+		state.status.Proxy = proxyListen(cfg)
+	}
+	fmt.Printf("Serving the TUI on %s\n", sock)
+	if remote != nil {
+		state.status.Listen = *listen
+		fmt.Printf("Accepting TUIs with the token on %s\n", *listen)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		if err := daemon.Serve(ctx, ln, state, nil); err != nil {
+			fmt.Printf("Serving the TUI: %v\n", err)
+		}
+	}()
+	if remote != nil {
+		go func() {
+			if err := daemon.Serve(ctx, tcp, state, remote); err != nil {
+				fmt.Printf("Serving remote TUIs: %v\n", err)
+			}
+		}()
+	}
+	go state.recordGPUs(ctx)
+	if *withProxy {
+		go func() {
+			if err := serveProxy(ctx, cfg, proxyListen(cfg), upstream); err != nil {
+				fmt.Printf("Proxy: %v\n", err)
+			}
+		}()
+	}
+	if idle != nil {
+		go runIdleWatch(ctx, c, idle)
+	}
+	if thermal != nil {
+		go runThermalWatch(ctx, c, thermal, firstNonEmpty(cfg.Service, service.DefaultName()), alerts)
+	}
+	if watch != nil {
+		go runWatchdog(ctx, c, watch, firstNonEmpty(cfg.Service, service.DefaultName()), cfg, alerts)
+	}
+	if alerts != nil {
+		go runAlerts(ctx, c, alerts)
+	}
+	runSchedule(ctx, jobs, func(j schedule.Job, err error) {
+		state.ran(j, err)
+		at := time.Now().Format("2006-01-02 15:04")
+		if err != nil {
+			fmt.Printf("%s %s failed: %v\n", at, j.Name, err)
+			return
+		}
+		fmt.Printf("%s %s\n", at, j.Name)
+	})
+	return nil
+}
+
+// daemonListen returns the configured TCP address, empty when the daemon
+// is local only.
+func daemonListen(cfg *config.Config) string {
+	if cfg.Daemon == nil {
+		return ""
+	}
+	return cfg.Daemon.Listen
+}
+
+// daemonRemote sets up remote TUIs on listen, which is empty when there are
+// none. They reach the server c through the daemon.
+func daemonRemote(cfg *config.Config, listen string, c *ollama.Client) (*daemon.Remote, error) {
+	if listen == "" {
+		return nil, nil
+	}
+	if cfg.Daemon == nil || cfg.Daemon.Token == "" {
+		return nil, errors.New("listening on TCP needs daemon.token set in the config")
+	}
+	if len(cfg.Daemon.Token) < 16 {
+		return nil, errors.New("daemon.token must be at least 16 characters")
+	}
+	upstream, err := url.Parse(c.Host())
+	if err != nil {
+		return nil, err
+	}
+	return &daemon.Remote{Token: cfg.Daemon.Token, Upstream: upstream}, nil
+}
+
+// attachDaemon looks for a daemon on this machine. The TUI then leaves
+// schedules and watchers to it and charts the GPU history it records.
+func attachDaemon() (*daemon.Client, *daemon.Status, error) {
+	sock, err := daemon.SocketPath()
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := os.Stat(sock); err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), attachTimeout)
+	defer cancel()
+	dc := daemon.Dial(sock)
+	st, err := dc.Status(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dc, &st, nil
+}
+
+// daemonFor returns the daemon behind profile p, reached through c: the
+// one a daemon profile names, or for this machine's server the local
+// daemon attached at startup. It is nil without one.
+func (m model) daemonFor(p config.Profile, c *ollama.Client) *daemon.Client {
+	switch {
+	case p.Daemon:
+		return daemon.Connect(c.Host(), p.Token)
+	case c.Local():
+		return m.localDaemon
+	}
+	return nil
+}
+
+// remoteGPUs returns the daemon to read the active host's GPUs from, nil
+// when they are this machine's or unknown.
+func (m model) remoteGPUs() *daemon.Client {
+	if !m.hosts[m.host].Daemon {
+		return nil
+	}
+	return m.daemonConn
+}
+
+// useGPUHistory starts the GPU charts over for the active host: empty when
+// a daemon records them, which syncDaemon then fills in, otherwise from
+// this machine's own record.
+func (m *model) useGPUHistory() {
+	if m.daemonConn != nil {
+		m.gpuHistory = gpu.NewHistory(gpuHistorySize)
+		return
+	}
+	h, err := openGPUHistory(m.cfg)
+	if err != nil {
+		m.logError("GPU history: " + err.Error())
+		h = gpu.NewHistory(gpuHistorySize)
+	}
+	m.gpuHistory = h
+}
+
+// syncDaemon asks the daemon behind the active host for its status and GPU
+// history; nil without one.
+func (m model) syncDaemon() tea.Cmd {
+	if m.daemonConn == nil {
+		return nil
+	}
+	return tea.Batch(fetchDaemon(m.daemonConn), fetchSamples(m.daemonConn))
+}
+
+// daemonMsg carries a fresh status from the daemon conn.
+type daemonMsg struct {
+	conn   *daemon.Client
+	status daemon.Status
+	err    error
+}
+
+func fetchDaemon(dc *daemon.Client) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), attachTimeout)
+		defer cancel()
+		st, err := dc.Status(ctx)
+		return daemonMsg{conn: dc, status: st, err: err}
+	}
+}
+
+func (m *model) finishDaemon(msg daemonMsg) {
+	if msg.conn != m.daemonConn {
+		return // from the host before a switch
+	}
+	if msg.err != nil {
+		// Schedules and watchers stay with a local daemon until restarting
+		// the TUI, so one that went away is worth an entry.
+		if m.daemon != nil && m.daemon.PID != 0 {
+			m.logError("Daemon stopped answering: " + msg.err.Error())
+		}
+		m.daemon = &daemon.Status{}
+		return
+	}
+	m.daemon = &msg.status
+}
+
+// samplesMsg carries the GPU history recorded by the daemon conn.
+type samplesMsg struct {
+	conn    *daemon.Client
+	samples []gpu.Sample
+	err     error
+}
+
+func fetchSamples(dc *daemon.Client) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), attachTimeout)
+		defer cancel()
+		samples, err := dc.Samples(ctx)
+		return samplesMsg{conn: dc, samples: samples, err: err}
+	}
+}
+
+func (m *model) finishSamples(msg samplesMsg) {
+	if msg.conn != m.daemonConn {
+		return
+	}
+	if msg.err != nil {
+		m.logError("GPU history from the daemon: " + msg.err.Error())
+		return
+	}
+	m.gpuHistory.Seed(msg.samples)
+}
+
+// daemonView describes the daemon behind the active host for the server
+// panel.
+func (m model) daemonView() string {
+	d := m.daemon
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Daemon"))
+	b.WriteString("\n\n")
+	switch {
+	case d == nil:
+		b.WriteString(helpStyle.Render("Connecting…"))
+		b.WriteString("\n")
+		return b.String()
+	case d.PID == 0 && m.daemonConn == m.localDaemon:
+		b.WriteString(errorStyle.Render("Not answering; restart the TUI to run the schedule and watchers here"))
+		b.WriteString("\n")
+		return b.String()
+	case d.PID == 0:
+		b.WriteString(errorStyle.Render("Not answering"))
+		b.WriteString("\n")
+		return b.String()
+	}
+	b.WriteString(labelStyle.Render("Process") + fmt.Sprintf("pid %d, up %s\n", d.PID, formatUptime(time.Since(d.Started))))
+	b.WriteString(labelStyle.Render("Host") + d.Host + "\n")
+	if d.Listen != "" {
+		b.WriteString(labelStyle.Render("Remote TUIs") + "on " + d.Listen + "\n")
+	}
+	var watching []string
+	if d.AutoUnload != "" {
+		watching = append(watching, "auto-unload "+d.AutoUnload)
+	}
+	if d.Thermal != "" {
+		watching = append(watching, "thermal "+d.Thermal)
+	}
+	if d.Watchdog != "" {
+		watching = append(watching, "watchdog "+d.Watchdog)
+	}
+	if d.Webhooks > 0 {
+		watching = append(watching, fmt.Sprintf("%d webhooks", d.Webhooks))
+	}
+	if d.Proxy != "" {
+		watching = append(watching, "proxy on "+d.Proxy)
+	}
+	if len(watching) > 0 {
+		b.WriteString(labelStyle.Render("Running") + strings.Join(watching, ", ") + "\n")
+	}
+	for _, j := range d.Jobs {
+		line := fmt.Sprintf("%-18s %-32s", j.Spec, j.Name)
+		if !j.Next.IsZero() {
+			line += " next " + j.Next.Format("Mon Jan 2 15:04")
+		}
+		switch {
+		case j.Err != "":
+			line += errorStyle.Render(fmt.Sprintf("  failed %s: %s", formatAgo(j.Last), j.Err))
+		case !j.Last.IsZero():
+			line += helpStyle.Render("  ran " + formatAgo(j.Last))
+		}
+		b.WriteString("  " + line + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/service"
+)
+
+const (
+	// modelsFile keeps the last model list seen on each server, under the
+	// data dir, to show while the server is down.
+	modelsFile = "models.json"
+
+	// retryWait is how long after a failed refresh the next one is tried
+	// while the server can't be reached.
+	retryWait = 5 * time.Second
+)
+
+// cachedModels is the model list last seen on one server.
+type cachedModels struct {
+	Models []ollama.Model `json:"models"`
+	Seen   time.Time      `json:"seen"`
+}
+
+// retryMsg ticks the retry countdown once a second.
+type retryMsg struct{}
+
+// readModelsCache returns the cached lists by server URL; a missing file
+// has none.
+func readModelsCache() (map[string]cachedModels, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, modelsFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]cachedModels{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cache := make(map[string]cachedModels)
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cache, nil
+}
+
+// writeModelsCache records models as the list of host.
+func writeModelsCache(host string, models []ollama.Model) error {
+	cache, err := readModelsCache()
+	if err != nil {
+		cache = make(map[string]cachedModels) // start over from a bad file
+	}
+	cache[host] = cachedModels{Models: models, Seen: time.Now()}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir, err := config.DataDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, modelsFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// cachedList returns the last model list seen on host, if any.
+func cachedList(host string) (cachedModels, bool) {
+	cache, err := readModelsCache()
+	if err != nil {
+		slog.Warn("models cache", "err", err)
+		return cachedModels{}, false
+	}
+	c, ok := cache[host]
+	return c, ok
+}
+
+// cacheModels writes the model list of the active host when it changed
+// since the last write, so the file isn't rewritten on every refresh.
+func (m *model) cacheModels(models []ollama.Model) {
+	var key strings.Builder
+	key.WriteString(m.client.Host())
+	for _, mdl := range models {
+		key.WriteString(" " + mdl.Name + "@" + mdl.Digest)
+	}
+	if key.String() == m.cachedKey {
+		return
+	}
+	if err := writeModelsCache(m.client.Host(), models); err != nil {
+		slog.Warn("models cache", "err", err)
+		return
+	}
+	m.cachedKey = key.String()
+}
+
+// noteDown switches to the degraded view when a refresh failed because the
+// server can't be reached, and counts down to the next try. An error the
+// server answered with leaves the list as it is.
+func (m *model) noteDown(err error) tea.Cmd {
+	if !ollama.IsUnreachable(err) {
+		m.down = false
+		return nil
+	}
+	if !m.down {
+		m.status = "Ollama not reachable at " + m.hostAddr() + " (" + m.keys.Errors.Help().Key + " shows the error)"
+	}
+	m.down = true
+	m.retryAt = time.Now().Add(retryWait)
+	if m.retrying {
+		return nil
+	}
+	m.retrying = true
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return retryMsg{} })
+}
+
+// retry refreshes once the countdown has run out, and stops ticking once
+// the server is back.
+func (m *model) retry() tea.Cmd {
+	if !m.down {
+		m.retrying = false
+		return nil
+	}
+	next := tea.Tick(time.Second, func(time.Time) tea.Msg { return retryMsg{} })
+	if !m.retryAt.IsZero() && !time.Now().Before(m.retryAt) {
+		m.retryAt = time.Time{}
+		return tea.Batch(m.refresh(), next)
+	}
+	return next
+}
+
+// startServer starts the local Ollama service from the degraded view.
+func (m model) startServer() (tea.Model, tea.Cmd) {
+	switch {
+	case !m.client.Local():
+		m.status = "Start Ollama on " + m.hostAddr() + " itself; only a local server can be started from here"
+		return m, nil
+	case m.server.loading:
+		return m, nil
+	}
+	return m.startService(service.Start)
+}
+
+// downHelp is the list's short help while the server can't be reached.
+func (m model) downHelp() []key.Binding {
+	k := m.keys
+	help := []key.Binding{relabel(k.Refresh, "Retry now"), k.Hosts, k.Server, k.Help, k.Quit}
+	if m.client.Local() {
+		help = append([]key.Binding{relabel(k.Run, "Start server")}, help...)
+	}
+	return help
+}
+
+// hostAddr is the host:port of the active server.
+func (m model) hostAddr() string {
+	if u, err := url.Parse(m.client.Host()); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return m.client.Host()
+}
+
+// downView replaces the model list while the server can't be reached: what
+// is wrong, when the next try is, and the last list seen, greyed out since
+// nothing can be done with it until the server is back.
+func (m model) downView() string {
+	var b strings.Builder
+	b.WriteString(errorStyle.Render("  Ollama not reachable at " + m.hostAddr()))
+	b.WriteString("\n")
+	switch left := time.Until(m.retryAt); {
+	case m.server.loading:
+		b.WriteString(helpStyle.Render("  Starting the server..."))
+	case m.retryAt.IsZero() || left <= 0:
+		b.WriteString(helpStyle.Render("  Retrying..."))
+	default:
+		b.WriteString(helpStyle.Render(fmt.Sprintf("  Retrying in %ds", int(left.Seconds()+0.999))))
+	}
+	b.WriteString("\n\n")
+
+	if len(m.models) == 0 {
+		return b.String()
+	}
+	seen := "Last seen"
+	if !m.refreshed.IsZero() {
+		seen += " " + m.refreshed.Format("2006-01-02 15:04")
+	}
+	b.WriteString(helpStyle.Render(fmt.Sprintf("  %s (%d models):", seen, len(m.models))))
+	b.WriteString("\n")
+	rows := max(m.listRows()-3, 1)
+	for i, mdl := range m.models {
+		if i == rows && len(m.models) > rows+1 {
+			b.WriteString(helpStyle.Render(fmt.Sprintf("  ... and %d more", len(m.models)-i)))
+			b.WriteString("\n")
+			break
+		}
+		b.WriteString(helpStyle.Render(fmt.Sprintf("  %-48s %8s", m.displayName(mdl.Name), formatBytes(uint64(mdl.Size)))))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
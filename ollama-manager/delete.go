@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/ollama"
+)
+
+// deleteConfirm is the two-step "are you sure" state for deleting one or
+// more models.
+type deleteConfirm struct {
+	names []string
+	size  int64 // combined size on disk
+	step  int   // 1 = first prompt, 2 = final confirmation
+}
+
+func newDeleteConfirm(models []ollama.Model) *deleteConfirm {
+	c := &deleteConfirm{step: 1}
+	for _, mdl := range models {
+		c.names = append(c.names, mdl.Name)
+		c.size += mdl.Size
+	}
+	return c
+}
+
+func deleteModel(c *ollama.Client, name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+	return c.Delete(ctx, name)
+}
+
+// updateDeleteConfirm advances or cancels the confirmation. Only "y" moves
+// forward; any other key backs out.
+func (m model) updateDeleteConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() != "y" {
+		m.mode = modeList
+		m.confirm = nil
+		m.status = "Delete cancelled"
+		return m, nil
+	}
+	if m.confirm.step == 1 {
+		m.confirm.step = 2
+		return m, nil
+	}
+
+	targets := m.confirm.names
+	m.mode = modeList
+	m.confirm = nil
+	m.selected = make(map[string]bool)
+	if len(targets) == 1 {
+		name := targets[0]
+		return m, m.runOp("Deleting "+name, "Deleted "+name, func() error {
+			return deleteModel(m.client, name)
+		})
+	}
+	return m, m.runBatchOp("Deleting", "Deleted", targets, func(name string) error {
+		return deleteModel(m.client, name)
+	})
+}
+
+func (c *deleteConfirm) view() string {
+	var b strings.Builder
+	title := "Delete model"
+	if len(c.names) > 1 {
+		title = fmt.Sprintf("Delete %d models", len(c.names))
+	}
+	b.WriteString(errorStyle.Bold(true).Render(title))
+	b.WriteString("\n\n")
+	for _, name := range c.names {
+		b.WriteString(name)
+		b.WriteString("\n")
+	}
+	size := "size unknown"
+	if c.size > 0 {
+		size = formatBytes(uint64(c.size)) + " on disk"
+	}
+	b.WriteString(helpStyle.Render(size))
+	b.WriteString("\n\n")
+	if c.step == 1 {
+		b.WriteString("Remove from disk? [y/N]")
+	} else {
+		b.WriteString(warnStyle.Render("This cannot be undone. Press y again to delete."))
+	}
+	return modalStyle.Render(b.String())
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"ollama-manager/internal/ollama"
+)
+
+var labelStyle = lipgloss.NewStyle().Bold(true).Width(16)
+
+// showMsg carries the /api/show result for the details screen.
+type showMsg struct {
+	name string
+	info *ollama.ShowResponse
+	err  error
+}
+
+func fetchShow(c *ollama.Client, name string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		info, err := c.Show(ctx, name)
+		return showMsg{name: name, info: info, err: err}
+	}
+}
+
+// openDetails switches to the details screen and starts loading metadata.
+func (m model) openDetails(name string) (tea.Model, tea.Cmd) {
+	m.mode = modeDetails
+	m.details = viewport.New(m.width, max(m.height-4, 5))
+	m.details.SetContent(fmt.Sprintf("Loading %s...", name))
+	m.detailsName = name
+	return m, fetchShow(m.client, name)
+}
+
+func (m model) updateDetails(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "esc" || key.Matches(msg, m.keys.Quit, m.keys.Details) {
+		m.mode = modeList
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.details, cmd = m.details.Update(msg)
+	return m, cmd
+}
+
+func (m model) detailsView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(m.detailsName))
+	b.WriteString("\n\n")
+	b.WriteString(m.details.View())
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render(fmt.Sprintf("↑/↓: Scroll  Esc: Back  %3.0f%%", m.details.ScrollPercent()*100)))
+	return b.String()
+}
+
+// renderDetails formats /api/show output, and the note on the model, for
+// the details viewport.
+func renderDetails(info *ollama.ShowResponse, note modelNote) string {
+	var b strings.Builder
+	row := func(label, value string) {
+		if value == "" {
+			value = helpStyle.Render("(none)")
+		}
+		b.WriteString(labelStyle.Render(label))
+		b.WriteString(value)
+		b.WriteString("\n")
+	}
+	section := func(label, body string) {
+		b.WriteString("\n")
+		b.WriteString(titleStyle.Render(label))
+		b.WriteString("\n")
+		body = strings.TrimSpace(body)
+		if body == "" {
+			body = helpStyle.Render("(none)")
+		}
+		b.WriteString(body)
+		b.WriteString("\n")
+	}
+
+	params := info.Details.ParameterSize
+	if n := info.ParameterCount(); n > 0 {
+		params = fmt.Sprintf("%s (%d)", params, n)
+	}
+	ctx := ""
+	if n := info.ContextLength(); n > 0 {
+		ctx = fmt.Sprintf("%d tokens", n)
+	}
+
+	row("Architecture", info.Architecture())
+	row("Family", info.Details.Family)
+	row("Parameters", params)
+	row("Quantization", info.Details.QuantizationLevel)
+	row("Format", info.Details.Format)
+	row("Context length", ctx)
+	row("Capabilities", strings.Join(info.Capabilities, ", "))
+	row("Tags", note.tagList())
+
+	section("Notes", note.Text)
+	section("Parameters", info.Parameters)
+	section("System prompt", info.System)
+	section("Template", info.Template)
+	section("License", info.License)
+	return b.String()
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/store"
+)
+
+// diskState is the disk usage screen.
+type diskState struct {
+	usage   *store.Usage
+	err     error
+	loading bool
+	confirm bool // waiting for y to prune orphans
+	cursor  int  // selected model row
+	layers  bool // showing the selected model's layers
+	// verify is the last check of a model's blobs; repairAsk waits for y
+	// to repair the damage it found.
+	verify    *verifyState
+	repairAsk bool
+}
+
+// diskMsg carries a finished scan of the models directory.
+type diskMsg struct {
+	usage *store.Usage
+	err   error
+}
+
+// pruneMsg reports the result of deleting orphaned blobs.
+type pruneMsg struct {
+	freed int64
+	err   error
+}
+
+// scanDisk scans the models directory and, when one is set, the cold store.
+func (m model) scanDisk() tea.Cmd {
+	cold := m.cfg.ColdStore
+	return func() tea.Msg {
+		dir, err := store.Dir()
+		if err != nil {
+			return diskMsg{err: err}
+		}
+		u, err := scanStore(dir, cold)
+		return diskMsg{usage: u, err: err}
+	}
+}
+
+// scanStore scans dir, counting blobs left behind in the cold store as
+// orphans too.
+func scanStore(dir, cold string) (*store.Usage, error) {
+	u, err := store.Scan(dir)
+	if err != nil || cold == "" {
+		return u, err
+	}
+	orphans, err := store.ColdOrphans(dir, cold)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range orphans {
+		u.Orphans = append(u.Orphans, b)
+		u.OrphanBytes += b.Size
+	}
+	sort.Slice(u.Orphans, func(i, j int) bool { return u.Orphans[i].Size > u.Orphans[j].Size })
+	return u, nil
+}
+
+func (m model) openDisk() (tea.Model, tea.Cmd) {
+	m.mode = modeDisk
+	if !m.client.Local() {
+		m.disk = &diskState{err: fmt.Errorf("disk usage is only available for a local server")}
+		return m, nil
+	}
+	d := &diskState{loading: true}
+	if m.disk != nil && m.disk.verify != nil && m.disk.verify.running {
+		d.verify = m.disk.verify // still hashing in the background
+	}
+	m.disk = d
+	return m, m.scanDisk()
+}
+
+// partialGrace is how long after its last write a partial download is
+// taken for abandoned. An `ollama pull` in another terminal writes its
+// partials too, which the pull queue knows nothing of.
+const partialGrace = time.Hour
+
+// prunable returns the orphans of u safe to delete. Partial downloads are
+// left alone while a pull might still be writing them.
+func prunable(u *store.Usage, pulling bool) []store.Blob {
+	var blobs []store.Blob
+	for _, b := range u.Orphans {
+		if b.Partial && (pulling || time.Since(b.Modified) < partialGrace) {
+			continue
+		}
+		blobs = append(blobs, b)
+	}
+	return blobs
+}
+
+// pruneOrphans rescans before deleting so a model created since the screen
+// was drawn can't lose its layers.
+func pruneOrphans(dir, cold string, pulling bool) tea.Cmd {
+	return func() tea.Msg {
+		u, err := scanStore(dir, cold)
+		if err != nil {
+			return pruneMsg{err: err}
+		}
+		freed, err := store.Prune(prunable(u, pulling))
+		return pruneMsg{freed: freed, err: err}
+	}
+}
+
+func (m model) updateDisk(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	d := m.disk
+	if d.repairAsk {
+		d.repairAsk = false
+		if msg.String() != "y" {
+			m.status = "Repair skipped"
+			return m, nil
+		}
+		return m, repairBlobs(d.verify.model, d.verify.checks)
+	}
+	if d.confirm {
+		d.confirm = false
+		if msg.String() != "y" {
+			m.status = "Cleanup cancelled"
+			return m, nil
+		}
+		d.loading = true
+		return m, pruneOrphans(d.usage.Dir, m.cfg.ColdStore, m.pulls.Busy())
+	}
+
+	switch {
+	case msg.String() == "esc", key.Matches(msg, m.keys.Quit, m.keys.Disk):
+		m.mode = modeList
+	case key.Matches(msg, m.keys.Refresh):
+		if d.usage != nil {
+			d.loading = true
+			return m, m.scanDisk()
+		}
+	case key.Matches(msg, m.keys.Prune):
+		if d.usage == nil || d.loading {
+			break
+		}
+		if len(prunable(d.usage, m.pulls.Busy())) == 0 {
+			m.status = "Nothing to clean up"
+			break
+		}
+		d.confirm = true
+	case key.Matches(msg, m.keys.Up):
+		d.cursor = max(d.cursor-1, 0)
+	case key.Matches(msg, m.keys.Down):
+		if d.usage != nil {
+			d.cursor = min(d.cursor+1, max(len(d.usage.Models)-1, 0))
+		}
+	case msg.String() == "enter":
+		if d.usage != nil && len(d.usage.Models) > 0 {
+			d.layers = !d.layers
+		}
+	case key.Matches(msg, m.keys.Verify):
+		return m.startVerify()
+	case key.Matches(msg, m.keys.MoveStore):
+		return m.openMoveStore()
+	case key.Matches(msg, m.keys.Cancel):
+		if d.verify != nil && d.verify.running {
+			d.verify.cancel()
+		}
+	}
+	return m, nil
+}
+
+func (m model) diskView() string {
+	d := m.disk
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Disk Usage"))
+	b.WriteString("\n\n")
+
+	switch {
+	case d.err != nil:
+		b.WriteString(errorStyle.Render(d.err.Error()))
+		b.WriteString("\n")
+	case d.usage == nil:
+		b.WriteString("Scanning...\n")
+	default:
+		u := d.usage
+		b.WriteString(fmt.Sprintf("%s\n", helpStyle.Render(u.Dir)))
+		b.WriteString(fmt.Sprintf("Blob store: %s in %d models", formatBytes(uint64(u.Total)), len(u.Models)))
+		if u.Archived > 0 {
+			b.WriteString(fmt.Sprintf(", %s of it in the cold store", formatBytes(uint64(u.Archived))))
+		}
+		b.WriteString("\n")
+		orphans := fmt.Sprintf("Orphaned blobs: %d (%s)", len(u.Orphans), formatBytes(uint64(u.OrphanBytes)))
+		if u.OrphanBytes > 0 {
+			orphans = warnStyle.Render(orphans)
+		}
+		b.WriteString(orphans)
+		b.WriteString("\n\n")
+
+		rows := 15
+		if m.height > 0 {
+			rows = max(m.height-14, 3)
+		}
+		if d.layers && d.cursor < len(u.Models) {
+			mu := u.Models[d.cursor]
+			rows = max(rows-len(mu.Layers)-3, 3)
+			b.WriteString(diskTable(u.Models, d.cursor, rows))
+			b.WriteString("\n")
+			b.WriteString(layerTable(mu))
+		} else {
+			b.WriteString(diskTable(u.Models, d.cursor, rows))
+		}
+		if d.verify != nil {
+			b.WriteString("\n")
+			b.WriteString(m.verifyView())
+		}
+		if d.loading {
+			b.WriteString("\nWorking...\n")
+		}
+	}
+
+	b.WriteString("\n")
+	if d.repairAsk {
+		b.WriteString(m.repairPrompt())
+		b.WriteString("\n")
+	} else if d.confirm {
+		blobs := prunable(d.usage, m.pulls.Busy())
+		var size int64
+		for _, bl := range blobs {
+			size += bl.Size
+		}
+		b.WriteString(modalStyle.Render(fmt.Sprintf(
+			"Delete %d orphaned blobs (%s)? No installed model uses them.\n\ny: Delete  any other key: Cancel",
+			len(blobs), formatBytes(uint64(size)))))
+		b.WriteString("\n")
+	} else {
+		b.WriteString(helpStyle.Render(helpLine(m.keys.Up, m.keys.Down) + "  Enter: Layers  " + helpLine(m.keys.Verify, m.keys.Prune, m.keys.MoveStore, m.keys.Refresh) + "  Esc: Back"))
+		b.WriteString("\n")
+	}
+	b.WriteString(fmt.Sprintf("\nStatus: %s", m.status))
+	return b.String()
+}
+
+// diskTable lists models by size, as many rows as fit around cursor.
+// EXCLUSIVE is what deleting the model would free; SHARED stays behind for
+// the models it is shared with.
+func diskTable(models []store.ModelUsage, cursor, rows int) string {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  MODEL\tSIZE\tEXCLUSIVE\tSHARED\tSHARED WITH")
+	start := max(cursor-rows+1, 0)
+	end := min(start+rows, len(models))
+	for i, mu := range models[start:end] {
+		marker := "  "
+		if start+i == cursor {
+			marker = "> "
+		}
+		fmt.Fprintf(tw, "%s%s\t%s\t%s\t%s\t%s\n", marker, mu.Name, formatBytes(uint64(mu.Size)),
+			formatBytes(uint64(mu.Unique)), formatBytes(uint64(mu.Shared)), sharedWith(mu.SharesWith()))
+	}
+	tw.Flush()
+	if end < len(models) {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("… %d more", len(models)-end)))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// sharedWith names the first two models sharing layers and counts the rest.
+func sharedWith(names []string) string {
+	switch {
+	case len(names) == 0:
+		return "-"
+	case len(names) <= 2:
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s +%d", strings.Join(names[:2], ", "), len(names)-2)
+}
+
+// layerTable lists the blobs of mu and the other models using each.
+func layerTable(mu store.ModelUsage) string {
+	var b strings.Builder
+	b.WriteString(helpStyle.Render("Layers of " + mu.Name))
+	b.WriteString("\n")
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LAYER\tKIND\tSIZE\tALSO USED BY")
+	for _, l := range mu.Layers {
+		digest := strings.TrimPrefix(l.Digest, "sha256:")
+		others := "-"
+		if len(l.Others) > 0 {
+			others = strings.Join(l.Others, ", ")
+		}
+		kind := l.Kind
+		if l.Archived {
+			kind += " (cold)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", digest[:min(12, len(digest))], kind, formatBytes(uint64(l.Size)), others)
+	}
+	tw.Flush()
+	return b.String()
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"ollama-manager/internal/applog"
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/diag"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/service"
+)
+
+// doctorErrors is how many recent warnings and errors the report includes.
+const doctorErrors = 20
+
+// doctorReport is everything `doctor` collects for a bug report.
+type doctorReport struct {
+	Generated time.Time         `json:"generated"`
+	OS        string            `json:"os"`
+	Host      string            `json:"host"`
+	Checks    *diag.Report      `json:"checks"`
+	Loaded    []runningJSON     `json:"loaded"`
+	ServerEnv map[string]string `json:"server_env"` // what the Ollama service starts with
+	ShellEnv  map[string]string `json:"shell_env"`  // OLLAMA_* and CUDA_* seen by the manager
+	Errors    []string          `json:"recent_errors"`
+}
+
+func cmdDoctor(c *ollama.Client, args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "emit JSON instead of markdown")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	name := firstNonEmpty(cfg.Service, service.DefaultName())
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+
+	// Errors are read first so the report doesn't list its own failed probes.
+	errs := []string{}
+	if dir, err := config.DataDir(); err == nil {
+		if lines, err := applog.Recent(filepath.Join(dir, logFile), doctorErrors, slog.LevelWarn); err == nil && lines != nil {
+			errs = lines
+		}
+	}
+	r := doctorReport{
+		Generated: time.Now().UTC().Truncate(time.Second),
+		OS:        osVersion(ctx),
+		Host:      c.Host(),
+		Checks:    diag.Run(ctx, c, name),
+		Loaded:    []runningJSON{},
+		ServerEnv: map[string]string{},
+		ShellEnv:  map[string]string{},
+		Errors:    errs,
+	}
+	if r.Checks.Ollama != "" {
+		if running, err := c.Running(ctx); err == nil {
+			for _, m := range running {
+				r.Loaded = append(r.Loaded, runningJSON{m.Name, m.Size, m.SizeVRAM, m.Processor(), m.ExpiresAt})
+			}
+		}
+	}
+	if c.Local() {
+		if env, err := service.Env(ctx, name); err == nil {
+			r.ServerEnv = env
+		}
+	}
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(k, "OLLAMA_") || strings.HasPrefix(k, "CUDA_") {
+			r.ShellEnv[k] = v
+		}
+	}
+	r.redact(newRedactor())
+
+	if *asJSON {
+		return writeJSON(r)
+	}
+	fmt.Print(r.markdown())
+	return nil
+}
+
+// osVersion names the operating system release, e.g. "Ubuntu 24.04.1 LTS
+// (linux/amd64)".
+func osVersion(ctx context.Context) string {
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+	var name string
+	switch runtime.GOOS {
+	case "linux":
+		if data, err := os.ReadFile("/etc/os-release"); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if v, ok := strings.CutPrefix(line, "PRETTY_NAME="); ok {
+					name = strings.Trim(v, `"`)
+				}
+			}
+		}
+	case "darwin":
+		if out, err := exec.CommandContext(ctx, "sw_vers", "-productVersion").Output(); err == nil {
+			name = "macOS " + strings.TrimSpace(string(out))
+		}
+	case "windows":
+		if out, err := exec.CommandContext(ctx, "cmd", "/c", "ver").Output(); err == nil {
+			name = strings.TrimSpace(string(out))
+		}
+	}
+	if name == "" {
+		return platform
+	}
+	return name + " (" + platform + ")"
+}
+
+// redactor strips what shouldn't end up in a public bug report: credentials
+// and the user's home directory, which usually contains their name.
+type redactor struct {
+	home string
+}
+
+var (
+	reURLCredentials = regexp.MustCompile(`(\w+://)[^/\s@]+@`)
+	reBearer         = regexp.MustCompile(`(?i)(bearer\s+)\S+`)
+	reSecretName     = regexp.MustCompile(`(?i)token|key|secret|password|auth`)
+)
+
+func newRedactor() redactor {
+	home, _ := os.UserHomeDir()
+	return redactor{home: home}
+}
+
+func (rd redactor) str(s string) string {
+	s = reURLCredentials.ReplaceAllString(s, "${1}REDACTED@")
+	s = reBearer.ReplaceAllString(s, "${1}REDACTED")
+	if len(rd.home) > 1 {
+		s = strings.ReplaceAll(s, rd.home, "~")
+	}
+	return s
+}
+
+func (rd redactor) env(env map[string]string) {
+	for k, v := range env {
+		if reSecretName.MatchString(k) {
+			env[k] = "REDACTED"
+		} else {
+			env[k] = rd.str(v)
+		}
+	}
+}
+
+func (r *doctorReport) redact(rd redactor) {
+	r.Host = rd.str(r.Host)
+	for i := range r.Checks.Findings {
+		r.Checks.Findings[i].Detail = rd.str(r.Checks.Findings[i].Detail)
+	}
+	rd.env(r.ServerEnv)
+	rd.env(r.ShellEnv)
+	for i := range r.Errors {
+		r.Errors[i] = rd.str(r.Errors[i])
+	}
+}
+
+func (r doctorReport) markdown() string {
+	var b strings.Builder
+	ch := r.Checks
+	fmt.Fprintf(&b, "# Ollama Manager diagnostic report\n\nGenerated %s\n\n", r.Generated.Format(time.RFC3339))
+
+	b.WriteString("## System\n\n")
+	fmt.Fprintf(&b, "- OS: %s\n", r.OS)
+	if ch.Driver != "" {
+		fmt.Fprintf(&b, "- NVIDIA driver: %s (CUDA %s)\n", ch.Driver, firstNonEmpty(ch.CUDA, "unknown"))
+	}
+	for _, g := range ch.GPUs {
+		fmt.Fprintf(&b, "- GPU %d: %s, %s, compute %s\n", g.Index, g.Name, formatVRAM(g.VRAM), firstNonEmpty(g.Compute, "unknown"))
+	}
+
+	b.WriteString("\n## Ollama\n\n")
+	fmt.Fprintf(&b, "- Host: %s\n", r.Host)
+	fmt.Fprintf(&b, "- Version: %s\n", firstNonEmpty(ch.Ollama, "not reachable"))
+	if len(r.Loaded) == 0 {
+		b.WriteString("- Loaded models: none\n")
+	} else {
+		b.WriteString("\n| Model | Size | VRAM | Processor |\n|-------|------|------|-----------|\n")
+		for _, m := range r.Loaded {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", m.Name, formatBytes(uint64(m.Size)), formatBytes(uint64(m.SizeVRAM)), m.Processor)
+		}
+	}
+
+	b.WriteString("\n## Checks\n\n")
+	marks := map[diag.Status]string{diag.OK: "ok", diag.Warn: "WARN", diag.Fail: "FAIL"}
+	for _, f := range ch.Findings {
+		fmt.Fprintf(&b, "- **%s** %s\n", marks[f.Status], f.Detail)
+	}
+
+	b.WriteString("\n## Environment\n\n")
+	writeEnvBlock(&b, "Ollama service", r.ServerEnv)
+	writeEnvBlock(&b, "Shell", r.ShellEnv)
+
+	b.WriteString("## Recent errors\n\n")
+	if len(r.Errors) == 0 {
+		b.WriteString("None logged.\n")
+	} else {
+		b.WriteString("```\n" + strings.Join(r.Errors, "\n") + "\n```\n")
+	}
+	return b.String()
+}
+
+func writeEnvBlock(b *strings.Builder, title string, env map[string]string) {
+	if len(env) == 0 {
+		fmt.Fprintf(b, "%s: nothing set\n\n", title)
+		return
+	}
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(b, "%s:\n\n```\n", title)
+	for _, k := range keys {
+		fmt.Fprintf(b, "%s=%s\n", k, env[k])
+	}
+	b.WriteString("```\n\n")
+}
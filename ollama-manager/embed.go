@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/bench"
+	"ollama-manager/internal/ollama"
+)
+
+// embedState is the tester that replaces the chat for embedding models: it
+// embeds two texts and compares them.
+type embedState struct {
+	model string
+	texts [2]textinput.Model
+	focus int
+
+	// step is set while a comparison or benchmark runs.
+	step    string
+	updates chan tea.Msg
+	cancel  context.CancelFunc
+
+	dims       int
+	similarity float64
+	preview    [2]string
+	took       time.Duration
+	result     *bench.EmbedResult
+	err        error
+}
+
+// embedMsg carries the embeddings of the two texts.
+type embedMsg struct {
+	model string
+	resp  *ollama.EmbedResponse
+	took  time.Duration
+	err   error
+}
+
+// embedStepMsg reports the step a running embedding benchmark has reached.
+type embedStepMsg string
+
+// embedBenchMsg carries the finished (or failed) embedding benchmark.
+type embedBenchMsg struct {
+	model  string
+	result *bench.EmbedResult
+	err    error
+}
+
+func newEmbedState(name string, width int) *embedState {
+	s := &embedState{model: name}
+	for i, v := range []string{"The cat sat on the mat.", "A kitten is resting on a rug."} {
+		ti := textinput.New()
+		ti.Prompt = fmt.Sprintf("Text %d: ", i+1)
+		ti.CharLimit = 0
+		ti.Width = max(width-12, 20)
+		ti.SetValue(v)
+		s.texts[i] = ti
+	}
+	return s
+}
+
+func (s *embedState) running() bool {
+	return s.step != ""
+}
+
+func (s *embedState) stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// compare embeds both texts in one request.
+func (s *embedState) compare(c *ollama.Client) tea.Cmd {
+	inputs := []string{strings.TrimSpace(s.texts[0].Value()), strings.TrimSpace(s.texts[1].Value())}
+	if inputs[0] == "" || inputs[1] == "" {
+		s.err = errors.New("enter two texts to compare")
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.step, s.cancel, s.err = "embedding", cancel, nil
+	model := s.model
+	return func() tea.Msg {
+		start := time.Now()
+		resp, err := c.Embed(ctx, ollama.EmbedRequest{Model: model, Input: inputs})
+		if err == nil && len(resp.Embeddings) != len(inputs) {
+			err = fmt.Errorf("expected %d embeddings, got %d", len(inputs), len(resp.Embeddings))
+		}
+		return embedMsg{model: model, resp: resp, took: time.Since(start), err: err}
+	}
+}
+
+func (s *embedState) finishCompare(msg embedMsg) {
+	s.step, s.cancel = "", nil
+	if msg.err != nil {
+		s.err = msg.err
+		return
+	}
+	a, b := msg.resp.Embeddings[0], msg.resp.Embeddings[1]
+	s.dims, s.similarity, s.took = len(a), bench.Cosine(a, b), msg.took
+	s.preview = [2]string{vectorPreview(a), vectorPreview(b)}
+}
+
+// benchmark times batches of the first text in the background.
+func (s *embedState) benchmark(c *ollama.Client) tea.Cmd {
+	text := strings.TrimSpace(s.texts[0].Value())
+	if text == "" {
+		s.err = errors.New("enter a text to embed")
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.step, s.cancel, s.err = "starting", cancel, nil
+	s.updates = make(chan tea.Msg, 16)
+	updates, model := s.updates, s.model
+	go func() {
+		defer close(updates)
+		res, err := bench.BenchmarkEmbed(ctx, c, model, text, func(step string) {
+			updates <- embedStepMsg(step)
+		})
+		updates <- embedBenchMsg{model: model, result: res, err: err}
+	}()
+	return listen(updates)
+}
+
+func (s *embedState) finishBenchmark(msg embedBenchMsg) {
+	s.step, s.cancel, s.updates = "", nil, nil
+	s.err = msg.err
+	if msg.err == nil {
+		s.result = msg.result
+	}
+}
+
+// vectorPreview shows the first values of a vector, e.g. "[0.0123, -0.0456,
+// ...]".
+func vectorPreview(v []float64) string {
+	const shown = 4
+	parts := make([]string, 0, shown+1)
+	for i, x := range v {
+		if i == shown {
+			parts = append(parts, "...")
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%.4f", x))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// similarityText puts a cosine similarity in words. Embedding models differ
+// in how they spread scores, so the bands are only a rough guide.
+func similarityText(sim float64) string {
+	switch {
+	case sim >= 0.8:
+		return "very similar"
+	case sim >= 0.6:
+		return "related"
+	case sim >= 0.4:
+		return "loosely related"
+	}
+	return "unrelated"
+}
+
+// openEmbed shows the embeddings tester for name, keeping the texts and
+// results when it was last open for the same model.
+func (m model) openEmbed(name string) (tea.Model, tea.Cmd) {
+	if m.embed == nil || m.embed.model != name {
+		if m.embed != nil {
+			m.embed.stop()
+		}
+		m.embed = newEmbedState(name, m.width)
+	}
+	m.mode = modeEmbed
+	s := m.embed
+	return m, s.texts[s.focus].Focus()
+}
+
+// startEmbed spins the spinner while cmd, a comparison or benchmark, runs.
+func (m *model) startEmbed(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return tea.Batch(cmd, m.startBusy())
+}
+
+// finishEmbed applies msg, an embedMsg or embedBenchMsg, unless the tester
+// has moved on to another model since.
+func (m *model) finishEmbed(model string, apply func(*embedState)) {
+	m.busy = max(m.busy-1, 0)
+	if s := m.embed; s != nil && s.model == model {
+		apply(s)
+	}
+}
+
+func (m model) updateEmbed(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := m.embed
+	switch {
+	case key.Matches(msg, m.keys.ChatStop):
+		s.stop()
+		return m, nil
+	case key.Matches(msg, m.keys.EmbedBench):
+		if s.running() {
+			return m, nil
+		}
+		return m, m.startEmbed(s.benchmark(m.client))
+	}
+
+	switch msg.String() {
+	case "esc":
+		// A comparison or benchmark in flight finishes in the background.
+		s.texts[s.focus].Blur()
+		m.mode = modeList
+		return m, nil
+	case "up", "down":
+		s.texts[s.focus].Blur()
+		s.focus = 1 - s.focus
+		return m, s.texts[s.focus].Focus()
+	case "enter":
+		if s.running() {
+			return m, nil
+		}
+		return m, m.startEmbed(s.compare(m.client))
+	}
+
+	var cmd tea.Cmd
+	s.texts[s.focus], cmd = s.texts[s.focus].Update(msg)
+	return m, cmd
+}
+
+func (m model) embedView() string {
+	s := m.embed
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Embeddings: " + s.model))
+	b.WriteString(helpStyle.Render("  an embedding model, which can't chat"))
+	b.WriteString("\n\n")
+	for _, t := range s.texts {
+		b.WriteString(t.View())
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	switch {
+	case s.running():
+		b.WriteString(fmt.Sprintf("%s %s\n", m.spinner.View(), s.step))
+	case s.err != nil && !errors.Is(s.err, context.Canceled):
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", s.err)))
+		b.WriteString("\n")
+	}
+	if s.dims > 0 {
+		b.WriteString(fmt.Sprintf("Dimensions:         %d\n", s.dims))
+		b.WriteString(fmt.Sprintf("Cosine similarity:  %.4f", s.similarity))
+		b.WriteString(helpStyle.Render("  " + similarityText(s.similarity)))
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf("Took:               %s\n", formatLatency(s.took)))
+		for i, p := range s.preview {
+			b.WriteString(helpStyle.Render(fmt.Sprintf("Text %d:             %s", i+1, p)))
+			b.WriteString("\n")
+		}
+	}
+	if r := s.result; r != nil {
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf("Benchmark:          %.1f embeddings/s, %.0f tokens/s", r.PerSecond(), r.TokensPerSecond()))
+		b.WriteString(helpStyle.Render(fmt.Sprintf("  %d embeddings of text 1 in %s", r.Embeddings, formatLatency(r.Elapsed))))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	help := "Enter: Compare  ↑/↓: Switch text  " + helpLine(m.keys.EmbedBench)
+	if s.running() {
+		help = helpLine(relabel(m.keys.ChatStop, "Stop"))
+	}
+	b.WriteString(helpStyle.Render(help + "  Esc: Back"))
+	return b.String()
+}
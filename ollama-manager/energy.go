@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/gpu"
+	"ollama-manager/internal/report"
+	"ollama-manager/internal/usage"
+)
+
+// energyDays is how many days of energy the usage screen lists.
+const energyDays = 7
+
+// energyMeter turns power readings into energy, counting each reading's
+// draw over the time since the one before.
+type energyMeter struct {
+	last time.Time
+}
+
+// sample returns what devices drew since the previous sample, as busy energy
+// when any of them was at least defaultHogUtil busy. The first sample, and
+// one after a gap longer than maxGap such as sleep, only start the clock.
+func (e *energyMeter) sample(now time.Time, devices []gpu.Device, maxGap time.Duration) (usage.Energy, bool) {
+	last := e.last
+	e.last = now
+	dt := now.Sub(last)
+	if last.IsZero() || dt <= 0 || dt > maxGap {
+		return usage.Energy{}, false
+	}
+	watts, util := 0, 0
+	for _, d := range devices {
+		watts += d.Power
+		util = max(util, d.Utilization)
+	}
+	if watts == 0 {
+		return usage.Energy{}, false
+	}
+	en := usage.Energy{Wh: float64(watts) * dt.Hours()}
+	if util >= defaultHogUtil {
+		en.BusyWh, en.BusyTime = en.Wh, dt
+	}
+	return en, true
+}
+
+// recordEnergy adds what devices drew on host since the last sample to log.
+func recordEnergy(log *usage.Log, meter *energyMeter, host string, devices []gpu.Device, maxGap time.Duration) error {
+	now := time.Now()
+	if en, ok := meter.sample(now, devices, maxGap); ok {
+		log.AddEnergy(host, now, en)
+	}
+	return log.Save()
+}
+
+// dayEnergy is one day's row of the energy table.
+type dayEnergy struct {
+	Date string `json:"date"`
+	usage.Energy
+}
+
+// recentEnergy returns the newest n days of energy recorded on host, newest
+// first.
+func recentEnergy(log *usage.Log, host string, n int) []dayEnergy {
+	var out []dayEnergy
+	for date, e := range log.Days(host) {
+		out = append(out, dayEnergy{Date: date, Energy: e})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Date > out[j].Date })
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// busyWatts is the mean draw while busy over all the days recorded on host,
+// which estimates the energy of a model's GPU time.
+func busyWatts(log *usage.Log, host string) float64 {
+	var all usage.Energy
+	for _, e := range log.Days(host) {
+		all.BusyWh += e.BusyWh
+		all.BusyTime += e.BusyTime
+	}
+	return all.BusyWatts()
+}
+
+// energyText is wh for a cell, in kWh once there is one.
+func energyText(wh float64) string {
+	switch {
+	case wh <= 0:
+		return "-"
+	case wh < 10:
+		return fmt.Sprintf("%.1f Wh", wh)
+	case wh < 1000:
+		return fmt.Sprintf("%.0f Wh", wh)
+	}
+	return fmt.Sprintf("%.2f kWh", wh/1000)
+}
+
+// costText is what wh costs at price, or "-" without a price.
+func costText(wh float64, price *config.Electricity) string {
+	if price == nil || price.PricePerKWh <= 0 || wh <= 0 {
+		return "-"
+	}
+	cost := fmt.Sprintf("%.2f", wh/1000*price.PricePerKWh)
+	if cost == "0.00" {
+		cost = "<0.01"
+	}
+	if price.Currency != "" {
+		cost += " " + price.Currency
+	}
+	return cost
+}
+
+// energyReport lays out the energy by day. BUSY is the part drawn while
+// the GPUs were working; the rest is what the rig costs just being on.
+func energyReport(days []dayEnergy, price *config.Electricity) report.Table {
+	t := report.Table{
+		Title:  "GPU energy by day",
+		Header: []string{"DATE", "ENERGY", "BUSY", "BUSY TIME", "COST"},
+	}
+	var total float64
+	for _, d := range days {
+		total += d.Wh
+		t.Rows = append(t.Rows, []string{d.Date, energyText(d.Wh), energyText(d.BusyWh),
+			d.BusyTime.Round(time.Minute).String(), costText(d.Wh, price)})
+	}
+	if len(days) > 0 {
+		t.Note = fmt.Sprintf("%s over %d days, about %s a day", energyText(total), len(days), energyText(total/float64(len(days))))
+		if c := costText(total/float64(len(days)), price); c != "-" {
+			t.Note += ", costing " + c
+		}
+	}
+	return t
+}
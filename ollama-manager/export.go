@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/bench"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/report"
+	"ollama-manager/internal/suite"
+)
+
+// exportKeys maps the keys of the export prompt to report formats.
+var exportKeys = map[string]string{"c": "csv", "m": "md", "j": "json"}
+
+// exportPrompt is shown while the benchmark or suites screen awaits a format.
+const exportPrompt = "Export as  c: CSV  m: Markdown (Reddit, GitHub)  j: JSON  any other key: Cancel"
+
+// benchExportJSON is the JSON form of the benchmark screen's results.
+type benchExportJSON struct {
+	Benchmarks     []*bench.Result       `json:"benchmarks"`
+	Sweep          *bench.SweepResult    `json:"sweep,omitempty"`
+	Parallel       *bench.ParallelResult `json:"parallel,omitempty"`
+	FlashAttention []kvRunJSON           `json:"flash_attention,omitempty"`
+}
+
+type kvRunJSON struct {
+	Variant string        `json:"variant"`
+	Result  *bench.Result `json:"result,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// suiteExportJSON is the JSON form of the last suite run.
+type suiteExportJSON struct {
+	Results   []*suite.Result `json:"results"`
+	NotTested []string        `json:"not_tested,omitempty"`
+}
+
+// benchExport gathers the benchmark history, oldest first in JSON and newest
+// first in tables, and the tests run this session.
+func (m model) benchExport() (heading string, tables []report.Table, raw any) {
+	h := m.benchHistory
+	j := benchExportJSON{Benchmarks: h.Results, Sweep: m.sweep, Parallel: m.parallel}
+	heading = "Ollama benchmarks"
+	if n := len(h.Results); n > 0 {
+		tables = append(tables, benchReport(h, benchRecent(h.Results, 0)))
+		if gpu := h.Results[n-1].GPU; gpu != "" {
+			heading += " on " + gpu
+		}
+	}
+	if m.sweep != nil {
+		tables = append(tables, sweepReport(m.sweep))
+	}
+	if m.parallel != nil {
+		tables = append(tables, parallelReport(m.parallel))
+	}
+	if m.kvTest != nil {
+		tables = append(tables, kvReport(m.kvTest))
+		for _, r := range m.kvTest.runs {
+			run := kvRunJSON{Variant: r.variant, Result: r.result}
+			if r.err != nil {
+				run.Error = r.err.Error()
+			}
+			j.FlashAttention = append(j.FlashAttention, run)
+		}
+	}
+	return heading, tables, j
+}
+
+// suiteExport gathers the last suite run: the summary and every prompt.
+func suiteExport(st *suitesState) (heading string, tables []report.Table, raw any) {
+	summary := suiteReport(st.results, st.failed)
+	prompts := report.Table{
+		Title:  "Prompts",
+		Header: []string{"MODEL", "PROMPT", "PASSED", "MISSING", "GEN t/s", "TTFT"},
+	}
+	for _, r := range st.results {
+		for _, c := range r.Cases {
+			passed, missing := "yes", strings.Join(c.Missing, ", ")
+			switch {
+			case c.Err != "":
+				passed, missing = "no", c.Err
+			case !c.Passed:
+				passed = "no"
+			}
+			prompts.Rows = append(prompts.Rows, []string{r.Model, c.Prompt, passed, firstNonEmpty(missing, "-"),
+				fmt.Sprintf("%.1f", c.GenTPS), c.TTFT.Round(time.Millisecond).String()})
+		}
+	}
+	return "Ollama " + strings.ToLower(summary.Title), []report.Table{summary, prompts},
+		suiteExportJSON{Results: st.results, NotTested: st.failed}
+}
+
+// writeExport writes tables as CSV or Markdown, or raw as JSON, to a
+// timestamped file in the working directory.
+func writeExport(prefix, format, heading string, tables []report.Table, raw any) (string, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "csv":
+		err = report.CSV(&buf, tables)
+	case "md":
+		err = report.Markdown(&buf, heading, tables)
+	case "json":
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(raw)
+	default:
+		return "", fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return "", err
+	}
+	path, err := filepath.Abs(fmt.Sprintf("%s-%s.%s", prefix, time.Now().Format("20060102-150405"), format))
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// answerExport writes the export the prompt asked for, given the key pressed.
+func (m *model) answerExport(pressed, prefix, heading string, tables []report.Table, raw any) tea.Cmd {
+	m.exportAsk = false
+	format, ok := exportKeys[pressed]
+	if !ok {
+		m.status = "Export cancelled"
+		return nil
+	}
+	path, err := writeExport(prefix, format, heading, tables, raw)
+	if err != nil {
+		m.status = "Export failed: " + err.Error()
+		m.logError(m.status)
+		return nil
+	}
+	m.status = fmt.Sprintf("Exported %s to %s", report.Formats[format], path)
+	return nil
+}
+
+func cmdReport(c *ollama.Client, args []string) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	format := fs.String("format", "", "csv, md or json (default a table for the terminal)")
+	name := fs.String("model", "", "only this model's results")
+	last := fs.Int("last", 0, "only the newest N results")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if fs.NArg() != 0 {
+		return usageError{"usage: report [--format csv|md|json] [--model name] [--last N]"}
+	}
+	if _, ok := report.Formats[*format]; *format != "" && !ok {
+		return usageError{fmt.Sprintf("--format: %q is not csv, md or json", *format)}
+	}
+	h, err := openBenchHistory()
+	if err != nil {
+		return err
+	}
+	var results []*bench.Result
+	for _, r := range h.Results {
+		if *name == "" || r.Model == *name {
+			results = append(results, r)
+		}
+	}
+	if *last > 0 && len(results) > *last {
+		results = results[len(results)-*last:]
+	}
+	t := benchReport(h, benchRecent(results, 0))
+	heading := "Ollama benchmarks"
+	if n := len(results); n > 0 && results[n-1].GPU != "" {
+		heading += " on " + results[n-1].GPU
+	}
+	switch *format {
+	case "csv":
+		return report.CSV(os.Stdout, []report.Table{t})
+	case "md":
+		return report.Markdown(os.Stdout, heading, []report.Table{t})
+	case "json":
+		return writeJSON(results)
+	}
+	if len(results) == 0 {
+		fmt.Println("No benchmarks recorded")
+		return nil
+	}
+	fmt.Print(report.Text(t))
+	return nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ollama-manager/internal/ollama"
+)
+
+// listRow is one row of the model table: a visible model, or while grouping
+// the header of a family of tags.
+type listRow struct {
+	model  int          // index into m.visible; -1 for a family header
+	family *modelFamily // set on headers and the tags under them
+}
+
+// modelFamily is the visible tags of one base model, such as llama3.1:8b
+// and llama3.1:8b-instruct-q8_0.
+type modelFamily struct {
+	base   string
+	models []ollama.Model
+}
+
+// familyBase is the model a tag belongs to: its name without the tag.
+func familyBase(name string) string {
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		return name[:i]
+	}
+	return name
+}
+
+// size is the disk space the family takes. Tags with the same digest are
+// one model under several names, so each digest is counted once.
+func (f *modelFamily) size() int64 {
+	var total int64
+	seen := make(map[string]bool)
+	for _, mdl := range f.models {
+		if mdl.Digest != "" {
+			if seen[mdl.Digest] {
+				continue
+			}
+			seen[mdl.Digest] = true
+		}
+		total += mdl.Size
+	}
+	return total
+}
+
+// buildRows lays out the table rows for the visible models. While grouping,
+// families of two or more tags get a header at the position of their first
+// tag in the sort order, with the tags under it unless it is collapsed; a
+// filter opens every family so matches aren't hidden.
+func (m *model) buildRows() {
+	m.rows = m.rows[:0]
+	if !m.cfg.GroupFamilies {
+		for i := range m.visible {
+			m.rows = append(m.rows, listRow{model: i})
+		}
+		return
+	}
+	families := make(map[string]*modelFamily)
+	var order []*modelFamily
+	for _, mdl := range m.visible {
+		base := familyBase(mdl.Name)
+		f := families[base]
+		if f == nil {
+			f = &modelFamily{base: base}
+			families[base] = f
+			order = append(order, f)
+		}
+		f.models = append(f.models, mdl)
+	}
+	index := make(map[string]int, len(m.visible))
+	for i, mdl := range m.visible {
+		index[mdl.Name] = i
+	}
+	for _, f := range order {
+		if len(f.models) == 1 {
+			m.rows = append(m.rows, listRow{model: index[f.models[0].Name]})
+			continue
+		}
+		m.rows = append(m.rows, listRow{model: -1, family: f})
+		if !m.expanded[f.base] && !m.filtering() {
+			continue
+		}
+		for _, mdl := range f.models {
+			m.rows = append(m.rows, listRow{model: index[mdl.Name], family: f})
+		}
+	}
+}
+
+// rowOf finds the row showing the named model, or its family's header when
+// the family is collapsed.
+func (m model) rowOf(name string) (int, bool) {
+	header := -1
+	for i, r := range m.rows {
+		switch {
+		case r.model >= 0 && m.visible[r.model].Name == name:
+			return i, true
+		case r.model < 0 && familyBase(name) == r.family.base:
+			header = i
+		}
+	}
+	return header, header >= 0
+}
+
+// currentFamily returns the family whose header is under the cursor.
+func (m model) currentFamily() (*modelFamily, bool) {
+	if c := m.table.Cursor(); c >= 0 && c < len(m.rows) && m.rows[c].model < 0 {
+		return m.rows[c].family, true
+	}
+	return nil, false
+}
+
+// toggleFamily opens or collapses a family, keeping the cursor on its header.
+func (m *model) toggleFamily(f *modelFamily) {
+	m.expanded[f.base] = !m.expanded[f.base]
+	m.syncTable()
+	if i, ok := m.rowOf(f.models[0].Name); ok && !m.expanded[f.base] {
+		m.setCursor(i)
+	}
+}
+
+// selectFamily selects every tag of a family, or clears them when all
+// already are.
+func (m *model) selectFamily(f *modelFamily) {
+	all := true
+	for _, mdl := range f.models {
+		all = all && m.selected[mdl.Name]
+	}
+	for _, mdl := range f.models {
+		if all {
+			delete(m.selected, mdl.Name)
+		} else {
+			m.selected[mdl.Name] = true
+		}
+	}
+	m.syncTable()
+}
+
+// toggleGrouping switches family grouping on or off and saves the choice.
+func (m *model) toggleGrouping() {
+	m.cfg.GroupFamilies = !m.cfg.GroupFamilies
+	m.relist()
+	m.status = "Grouping tags by model"
+	if !m.cfg.GroupFamilies {
+		m.status = "Listing every tag"
+	}
+	if err := m.cfg.Save(); err != nil {
+		m.status = fmt.Sprintf("%s; could not save config: %v", m.status, err)
+	}
+}
+
+// familyRow is the table row of a family header: its tag count, the disk
+// space of its distinct models, when any of them was last used and how
+// many are loaded.
+func (m model) familyRow(f *modelFamily) []string {
+	check := "[ ]"
+	switch n := m.countSelected(f); {
+	case n == len(f.models):
+		check = "[x]"
+	case n > 0:
+		check = "[-]"
+	}
+	arrow := "▸"
+	if m.expanded[f.base] || m.filtering() {
+		arrow = "▾"
+	}
+	loaded := 0
+	for _, mdl := range f.models {
+		if m.loaded[mdl.Name] {
+			loaded++
+		}
+	}
+	var last time.Time
+	for _, mdl := range f.models {
+		if t := m.usage.Last(m.client.Host(), mdl.Name); t.After(last) {
+			last = t
+		}
+	}
+	loadedText := ""
+	if loaded > 0 {
+		loadedText = fmt.Sprintf("● %d of %d", loaded, len(f.models))
+	}
+	return []string{
+		check,
+		fmt.Sprintf("%s %s (%d tags)", arrow, f.base, len(f.models)),
+		formatBytes(uint64(f.size())),
+		"",
+		f.models[0].Details.Family,
+		"",
+		lastUsedText(last),
+		"",
+		loadedText,
+		"",
+	}
+}
+
+// countSelected is how many tags of f are selected.
+func (m model) countSelected(f *modelFamily) int {
+	n := 0
+	for _, mdl := range f.models {
+		if m.selected[mdl.Name] {
+			n++
+		}
+	}
+	return n
+}
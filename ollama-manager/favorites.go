@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// displayName is how a model appears in the list: its alias followed by the
+// full name, and a star for favorites.
+func (m model) displayName(name string) string {
+	s := name
+	if alias := m.cfg.Aliases[name]; alias != "" {
+		s = alias + " (" + name + ")"
+	}
+	if m.cfg.IsFavorite(name) {
+		s = "★ " + s
+	}
+	if m.showHidden && m.cfg.IsHidden(name) {
+		s += " [hidden]"
+	}
+	s += m.tierLabel(name)
+	if m.hasUpdate(name) {
+		s += " [update]"
+	}
+	return s
+}
+
+// toggleFavorite pins or unpins a model and saves the change.
+func (m *model) toggleFavorite(name string) {
+	fav := m.cfg.ToggleFavorite(name)
+	if err := m.cfg.Save(); err != nil {
+		m.status = fmt.Sprintf("Could not save config: %v", err)
+		return
+	}
+	m.relist()
+	if fav {
+		m.status = "Pinned " + name
+	} else {
+		m.status = "Unpinned " + name
+	}
+}
+
+// openAliasInput prompts for a model's short name, prefilled with the
+// current one.
+func (m model) openAliasInput(name string) (tea.Model, tea.Cmd) {
+	m.mode = modeAliasInput
+	m.aliasModel = name
+	m.input.Reset()
+	m.input.Prompt = fmt.Sprintf("Alias for %s (empty = none): ", name)
+	m.input.Placeholder = ""
+	m.input.CharLimit = 32
+	m.input.Width = 32
+	m.input.SetValue(m.cfg.Aliases[name])
+	m.input.CursorEnd()
+	return m, m.input.Focus()
+}
+
+func (m model) updateAliasInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = modeList
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		name := m.aliasModel
+		alias := strings.TrimSpace(m.input.Value())
+		m.mode = modeList
+		m.input.Blur()
+
+		m.cfg.SetAlias(name, alias)
+		if err := m.cfg.Save(); err != nil {
+			m.status = fmt.Sprintf("Could not save config: %v", err)
+			return m, nil
+		}
+		m.relist()
+		if alias == "" {
+			m.status = "Removed the alias of " + name
+		} else {
+			m.status = fmt.Sprintf("%s is now shown as %s", name, alias)
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/fuzzy"
+	"ollama-manager/internal/ollama"
+)
+
+func newFilterInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = "/"
+	ti.Placeholder = "filter models"
+	ti.CharLimit = 64
+	ti.Width = 30
+	return ti
+}
+
+// applyFilter recomputes the visible rows from the model list and the
+// current filter text, keeping the cursor in range. Models whose note
+// matches come after those whose name does; "#tag" only matches tags.
+func (m *model) applyFilter() {
+	pattern := strings.TrimSpace(m.filter.Value())
+	var idx []int
+	if !strings.HasPrefix(pattern, "#") {
+		idx = fuzzy.Filter(pattern, len(m.models), func(i int) string {
+			name := m.models[i].Name
+			if alias := m.cfg.Aliases[name]; alias != "" {
+				return alias + " " + name
+			}
+			return name
+		})
+	}
+	for i, mdl := range m.models {
+		if m.noteMatches(mdl.Name, pattern) && !slices.Contains(idx, i) {
+			idx = append(idx, i)
+		}
+	}
+	m.visible = make([]ollama.Model, 0, len(idx))
+	now, days := time.Now(), unusedDays(m.cfg)
+	for _, j := range idx {
+		mdl := m.models[j]
+		if !m.showHidden && m.cfg.IsHidden(mdl.Name) {
+			continue
+		}
+		if m.onlyUnused && !unused(m.usage, m.client.Host(), mdl, days, now) {
+			continue
+		}
+		m.visible = append(m.visible, mdl)
+	}
+	m.sortVisible()
+	m.syncTable()
+	if m.table.Cursor() >= len(m.rows) {
+		m.setCursor(max(len(m.rows)-1, 0))
+	}
+}
+
+// current returns the model under the cursor; a family header is none.
+func (m model) current() (ollama.Model, bool) {
+	if c := m.table.Cursor(); c >= 0 && c < len(m.rows) && m.rows[c].model >= 0 {
+		return m.visible[m.rows[c].model], true
+	}
+	return ollama.Model{}, false
+}
+
+func (m model) openFilter() (tea.Model, tea.Cmd) {
+	m.mode = modeFilter
+	m.filter.CursorEnd()
+	return m, m.filter.Focus()
+}
+
+// updateFilter narrows the list as the user types. Enter keeps the filter and
+// returns to the list; Esc clears it.
+func (m model) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.filter.Reset()
+		m.filter.Blur()
+		m.mode = modeList
+		m.applyFilter()
+		return m, nil
+	case "enter":
+		m.filter.Blur()
+		m.mode = modeList
+		return m, nil
+	case "up":
+		// Let the arrows move through the narrowed list while typing.
+		m.moveCursor(-1)
+		return m, nil
+	case "down":
+		m.moveCursor(1)
+		return m, nil
+	}
+
+	before := m.filter.Value()
+	var cmd tea.Cmd
+	m.filter, cmd = m.filter.Update(msg)
+	if m.filter.Value() != before {
+		m.setCursor(0)
+		m.applyFilter()
+	}
+	return m, cmd
+}
+
+// filtering reports whether a filter narrows the list.
+func (m model) filtering() bool {
+	return m.filter.Value() != ""
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/vram"
+)
+
+// archMsg delivers the architecture dimensions of one model.
+type archMsg struct {
+	key  string
+	arch vram.Arch
+}
+
+// archKey identifies a model build; a re-pulled tag gets a new digest and is
+// looked up again.
+func archKey(m ollama.Model) string {
+	if m.Digest != "" {
+		return m.Digest
+	}
+	return m.Name
+}
+
+func fetchArch(c *ollama.Client, name, key string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		info, err := c.Show(ctx, name)
+		if err != nil {
+			return archMsg{key: key}
+		}
+		return archMsg{key: key, arch: vram.ArchFromShow(info)}
+	}
+}
+
+// archOf returns the cached metadata of the installed model name.
+func (m model) archOf(name string) vram.Arch {
+	for _, mdl := range m.models {
+		if mdl.Name == name {
+			return m.arch[archKey(mdl)]
+		}
+	}
+	return vram.Arch{}
+}
+
+// fetchMissingArch requests metadata for models not yet in the cache. Entries
+// are reserved up front so a slow /api/show isn't requested twice.
+func (m *model) fetchMissingArch() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, mdl := range m.models {
+		key := archKey(mdl)
+		if _, ok := m.arch[key]; ok {
+			continue
+		}
+		m.arch[key] = vram.Arch{}
+		cmds = append(cmds, fetchArch(m.client, mdl.Name, key))
+	}
+	return tea.Batch(cmds...)
+}
+
+// freeVRAM sums free memory across all GPUs; ok is false without readings.
+func (m model) freeVRAM() (free uint64, ok bool) {
+	for _, d := range m.gpus {
+		free += d.MemoryFree()
+	}
+	return free, len(m.gpus) > 0
+}
+
+// fitText renders the estimated footprint and fit verdict for a table row.
+func (m model) fitText(mdl ollama.Model) string {
+	if mdl.Size == 0 {
+		return ""
+	}
+	arch := m.arch[archKey(mdl)]
+	ctx := arch.Context
+	if ctx == 0 {
+		ctx = vram.DefaultContext
+	}
+	est := vram.ForModel(mdl.Size, arch, ctx)
+	label := "~" + formatBytes(est.Total())
+
+	free, ok := m.freeVRAM()
+	if !ok || m.loaded[mdl.Name] {
+		return label
+	}
+	fit := vram.Check(est.Total(), free)
+	mark := map[vram.Fit]string{vram.Fits: "✓", vram.Tight: "!", vram.WontFit: "✗"}[fit]
+	return fmt.Sprintf("%s %s %s", label, mark, fit)
+}
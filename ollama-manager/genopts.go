@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+)
+
+// genOptions returns the generation parameters saved for name as runner
+// options, or nil when none are.
+func (m model) genOptions(name string) map[string]any {
+	return optionsMap(m.cfg.ModelOptions[name])
+}
+
+func optionsMap(o config.GenOptions) map[string]any {
+	if o.IsZero() {
+		return nil
+	}
+	opts := make(map[string]any)
+	if o.Temperature != nil {
+		opts["temperature"] = *o.Temperature
+	}
+	if o.TopP != nil {
+		opts["top_p"] = *o.TopP
+	}
+	if o.NumCtx > 0 {
+		opts["num_ctx"] = o.NumCtx
+	}
+	if o.NumPredict != 0 {
+		opts["num_predict"] = o.NumPredict
+	}
+	if o.Seed != nil {
+		opts["seed"] = *o.Seed
+	}
+	return opts
+}
+
+// genOptionFields are the parameters the dialog edits, in order.
+var genOptionFields = []struct{ name, hint string }{
+	{"temperature", "0–2, lower is more deterministic"},
+	{"top_p", "0–1"},
+	{"num_ctx", "context length in tokens"},
+	{"num_predict", "max tokens per reply, -1 = unlimited"},
+	{"seed", "fixed for reproducible replies"},
+}
+
+// genOptionsDialog edits the generation parameters saved for a model. An
+// empty field keeps the model's default.
+type genOptionsDialog struct {
+	name   string
+	fields []textinput.Model
+	focus  int
+}
+
+func (m model) openGenOptions(name string) (tea.Model, tea.Cmd) {
+	o := m.cfg.ModelOptions[name]
+	values := []string{formatFloatOpt(o.Temperature), formatFloatOpt(o.TopP), formatIntOpt(o.NumCtx), formatIntOpt(o.NumPredict), ""}
+	if o.Seed != nil {
+		values[4] = strconv.Itoa(*o.Seed)
+	}
+
+	d := &genOptionsDialog{name: name}
+	for i, f := range genOptionFields {
+		ti := textinput.New()
+		ti.Prompt = fmt.Sprintf("%-12s ", f.name+":")
+		ti.Placeholder = "default"
+		ti.CharLimit = 12
+		ti.Width = 12
+		ti.SetValue(values[i])
+		ti.CursorEnd()
+		d.fields = append(d.fields, ti)
+	}
+	m.mode = modeGenOptions
+	m.genDialog = d
+	return m, d.fields[0].Focus()
+}
+
+func formatFloatOpt(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+func formatIntOpt(v int) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.Itoa(v)
+}
+
+// values parses the fields, naming the first one that is out of range.
+func (d *genOptionsDialog) values() (config.GenOptions, error) {
+	var o config.GenOptions
+	field := func(i int) string { return strings.TrimSpace(d.fields[i].Value()) }
+	parseFloat := func(i int, lo, hi float64) (*float64, error) {
+		v := field(i)
+		if v == "" {
+			return nil, nil
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < lo || f > hi {
+			return nil, fmt.Errorf("%s must be a number from %g to %g", genOptionFields[i].name, lo, hi)
+		}
+		return &f, nil
+	}
+	parseInt := func(i, lo int) (int, error) {
+		v := field(i)
+		if v == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < lo {
+			return 0, fmt.Errorf("%s must be a whole number of at least %d", genOptionFields[i].name, lo)
+		}
+		return n, nil
+	}
+
+	var err error
+	if o.Temperature, err = parseFloat(0, 0, 2); err != nil {
+		return o, err
+	}
+	if o.TopP, err = parseFloat(1, 0, 1); err != nil {
+		return o, err
+	}
+	if o.NumCtx, err = parseInt(2, 1); err != nil {
+		return o, err
+	}
+	if o.NumPredict, err = parseInt(3, -1); err != nil {
+		return o, err
+	}
+	if field(4) != "" {
+		seed, err := parseInt(4, 0)
+		if err != nil {
+			return o, err
+		}
+		o.Seed = &seed
+	}
+	return o, nil
+}
+
+func (m model) updateGenOptions(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	d := m.genDialog
+	switch msg.String() {
+	case "esc":
+		m.mode = modeList
+		return m, nil
+	case "tab", "down", "shift+tab", "up":
+		d.fields[d.focus].Blur()
+		step := 1
+		if s := msg.String(); s == "shift+tab" || s == "up" {
+			step = len(d.fields) - 1
+		}
+		d.focus = (d.focus + step) % len(d.fields)
+		return m, d.fields[d.focus].Focus()
+	case "enter":
+		o, err := d.values()
+		if err != nil {
+			m.status = err.Error()
+			return m, nil
+		}
+		m.mode = modeList
+		m.cfg.SetModelOptions(d.name, o)
+		if err := m.cfg.Save(); err != nil {
+			m.status = fmt.Sprintf("Could not save config: %v", err)
+			return m, nil
+		}
+		if o.IsZero() {
+			m.status = "Cleared the generation defaults of " + d.name
+		} else {
+			m.status = "Saved the generation defaults of " + d.name
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	d.fields[d.focus], cmd = d.fields[d.focus].Update(msg)
+	return m, cmd
+}
+
+func (m model) genOptionsView() string {
+	d := m.genDialog
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Generation defaults: " + d.name))
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("Sent with every chat, benchmark and console request; empty keeps the model's default"))
+	b.WriteString("\n\n")
+	for i, f := range d.fields {
+		b.WriteString(f.View())
+		b.WriteString(helpStyle.Render("  " + genOptionFields[i].hint))
+		b.WriteString("\n")
+	}
+	if _, err := d.values(); err != nil {
+		b.WriteString("\n")
+		b.WriteString(errorStyle.Render(err.Error()))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("Tab/↑/↓: Switch field  Enter: Save  Esc: Cancel"))
+	return modalStyle.Render(b.String())
+}
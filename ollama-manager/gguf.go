@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/ollama"
+)
+
+// unsafeNameChars are the characters Ollama rejects in a model name.
+var unsafeNameChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// ggufModelName suggests a model name for a GGUF file, e.g.
+// "Qwen2.5-7B-Instruct-Q4_K_M.gguf" becomes "qwen2.5-7b-instruct-q4_k_m".
+func ggufModelName(path string) string {
+	base := filepath.Base(path)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	return strings.Trim(unsafeNameChars.ReplaceAllString(strings.ToLower(base), "-"), "-._")
+}
+
+// openImportInput prompts for the path of a GGUF file to import.
+func (m model) openImportInput() (tea.Model, tea.Cmd) {
+	m.mode = modeImportInput
+	m.input.Reset()
+	m.input.Prompt = "Import GGUF: "
+	m.input.Placeholder = `C:\models\model-Q4_K_M.gguf`
+	m.input.CharLimit = 1024
+	m.input.Width = 60
+	return m, m.input.Focus()
+}
+
+// updateImportInput opens the Modelfile editor for the entered file. The
+// file is read by the manager and uploaded, so the path is on this machine
+// even when the server is remote.
+func (m model) updateImportInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = modeList
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		path := strings.Trim(strings.TrimSpace(m.input.Value()), `"`)
+		if path == "" {
+			return m, nil
+		}
+		if !ollama.IsGGUFPath(path) {
+			m.status = "Enter the path of a .gguf file"
+			return m, nil
+		}
+		if err := ollama.CheckGGUF(path); err != nil {
+			m.status = err.Error()
+			return m, nil
+		}
+		m.input.Blur()
+		return m.openGGUFModelfile(path)
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// openGGUFModelfile shows the Modelfile editor with a minimal Modelfile for
+// path. A build still in progress is returned to instead of being replaced.
+func (m model) openGGUFModelfile(path string) (tea.Model, tea.Cmd) {
+	m.mode = modeModelfile
+	if m.modelfile != nil && m.modelfile.creating {
+		m.status = "Wait for the current build to finish"
+		return m, nil
+	}
+	s := newModelfileState(path, m.width, m.height)
+	s.loading = false
+	s.name.SetValue(ggufModelName(path))
+	s.editor.SetValue(ollama.GGUFModelfile(path))
+	m.modelfile = s
+	return m, s.editor.Focus()
+}
+
+func cmdImportGGUF(c *ollama.Client, args []string) error {
+	fs := flag.NewFlagSet("import-gguf", flag.ContinueOnError)
+	name := fs.String("name", "", "model name (default: derived from the file name)")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if fs.NArg() != 1 || !ollama.IsGGUFPath(fs.Arg(0)) {
+		return usageError{"usage: import-gguf [--name NAME] <file.gguf>"}
+	}
+	path := fs.Arg(0)
+	if err := ollama.CheckGGUF(path); err != nil {
+		return err
+	}
+	if *name == "" {
+		*name = ggufModelName(path)
+	}
+	models, err := getModels(c)
+	if err != nil {
+		return err
+	}
+	if _, ok := findModel(models, *name); ok {
+		return fmt.Errorf("%s already exists", *name)
+	}
+
+	req, err := ollama.ParseModelfile(ollama.GGUFModelfile(path))
+	if err != nil {
+		return err
+	}
+	req.Model = *name
+	err = c.Create(context.Background(), *req, func(p ollama.PullProgress) {
+		fmt.Printf("\r  %-50s", progressText(p))
+	})
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created %s from %s\n", *name, filepath.Base(path))
+	return nil
+}
+
+// progressText renders a create or upload status, with its percentage when
+// it has one.
+func progressText(p ollama.PullProgress) string {
+	if p.Total > 0 {
+		return fmt.Sprintf("%s %3.0f%% of %s", p.Status, float64(p.Completed)/float64(p.Total)*100, formatBytes(uint64(p.Total)))
+	}
+	return p.Status
+}
@@ -3,6 +3,12 @@ module ollama-manager
 go 1.21
 
 require (
+	github.com/NVIDIA/go-nvml v0.12.4-1
+	github.com/atotto/clipboard v0.1.4
+	github.com/charmbracelet/bubbles v0.20.0
 	github.com/charmbracelet/bubbletea v1.2.4
 	github.com/charmbracelet/lipgloss v1.0.0
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+require github.com/charmbracelet/harmonica v0.2.0 // indirect
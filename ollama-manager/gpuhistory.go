@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/gpu"
+)
+
+const (
+	// gpuHistoryFile holds the recorded GPU readings, under the data dir.
+	gpuHistoryFile = "gpu-history.jsonl"
+	// gpuHistorySize is how many readings are kept per GPU: half an hour
+	// at the default refresh interval.
+	gpuHistorySize = 360
+	// chartLabel is the width of the chart name before the sparkline.
+	chartLabel = 10
+)
+
+// openGPUHistory keeps GPU readings in memory, and in the data dir when
+// gpu_history is set.
+func openGPUHistory(cfg *config.Config) (*gpu.History, error) {
+	if !cfg.GPUHistory {
+		return gpu.NewHistory(gpuHistorySize), nil
+	}
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	return gpu.OpenHistory(filepath.Join(dir, gpuHistoryFile), gpuHistorySize)
+}
+
+// recordGPUs adds a refresh's readings to the history, and their energy to
+// the usage log unless a daemon is recording it.
+func (m *model) recordGPUs(devices []gpu.Device) {
+	if err := m.gpuHistory.Add(time.Now(), devices); err != nil {
+		m.logError("Saving GPU history: " + err.Error())
+	}
+	if m.daemonConn == nil {
+		if err := recordEnergy(m.usage, &m.energy, m.client.Host(), devices, 2*m.refreshEvery); err != nil {
+			m.logError("Saving GPU energy: " + err.Error())
+		}
+	}
+}
+
+// gpuCharts draws a sparkline per reading of d over its recorded samples,
+// as many of the newest as fit in width.
+func (m model) gpuCharts(d gpu.Device, width int) string {
+	samples := m.gpuHistory.Samples(d.Index)
+	if len(samples) < 2 {
+		return ""
+	}
+	samples = samples[max(len(samples)-max(width-4-chartLabel-16, 10), 0):]
+
+	util := make([]int, len(samples))
+	mem := make([]int, len(samples))
+	temp := make([]int, len(samples))
+	power := make([]int, len(samples))
+	var peakUtil, peakTemp, peakPower int
+	var peakMem uint64
+	for i, s := range samples {
+		util[i], temp[i], power[i] = s.Utilization, s.Temperature, s.Power
+		mem[i] = int(s.MemoryUsed >> 20)
+		peakUtil, peakTemp, peakPower = max(peakUtil, s.Utilization), max(peakTemp, s.Temperature), max(peakPower, s.Power)
+		peakMem = max(peakMem, s.MemoryUsed)
+	}
+
+	var b strings.Builder
+	line := func(name, chart, peak string) {
+		b.WriteString(fmt.Sprintf("    %-*s%s  %s\n", chartLabel, name, chart, helpStyle.Render("peak "+peak)))
+	}
+	line("util", sparklineTo(util, 100), fmt.Sprintf("%d%%", peakUtil))
+	line("vram", sparklineTo(mem, int(d.MemoryTotal>>20)), formatVRAM(peakMem))
+	line("temp", sparkline(temp), fmt.Sprintf("%d°C", peakTemp))
+	if peakPower > 0 {
+		line("power", sparkline(power), fmt.Sprintf("%dW", peakPower))
+	}
+	span := samples[len(samples)-1].Time.Sub(samples[0].Time).Round(time.Second)
+	b.WriteString(helpStyle.Render(fmt.Sprintf("    %-*slast %s", chartLabel, "", span)))
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/gpu"
+)
+
+// procsState is the list of processes holding VRAM.
+type procsState struct {
+	procs   []gpu.Process
+	err     error
+	loading bool
+	cursor  int
+	kill    *gpu.Process // the process named in the kill prompt, waiting for y
+}
+
+// procsMsg carries a fresh process list.
+type procsMsg struct {
+	procs []gpu.Process
+	err   error
+}
+
+// killMsg reports the outcome of killing a process.
+type killMsg struct {
+	proc gpu.Process
+	err  error
+}
+
+func queryProcs() tea.Msg {
+	procs, err := gpu.Processes()
+	return procsMsg{procs: procs, err: err}
+}
+
+// setProcs takes a fresh process list, keeping the cursor on the process
+// it was on while that still runs.
+func (s *procsState) setProcs(procs []gpu.Process, err error) {
+	cursor := min(s.cursor, max(len(procs)-1, 0))
+	if s.cursor < len(s.procs) {
+		pid := s.procs[s.cursor].PID
+		for i, p := range procs {
+			if p.PID == pid {
+				cursor = i
+				break
+			}
+		}
+	}
+	s.loading = false
+	s.procs, s.err, s.cursor = procs, err, cursor
+}
+
+func (m model) openProcs() (tea.Model, tea.Cmd) {
+	m.mode = modeProcs
+	if !m.client.Local() {
+		m.procs = &procsState{err: errRemoteGPU}
+		return m, nil
+	}
+	m.procs = &procsState{loading: true}
+	return m, queryProcs
+}
+
+// isOllama reports whether p is the server or one of its model runners,
+// which are managed through the model list rather than killed.
+func isOllama(p gpu.Process) bool {
+	return strings.Contains(strings.ToLower(p.Name), "ollama")
+}
+
+// killProcess asks the process to exit. The OS refuses for processes owned
+// by another user unless the manager runs elevated.
+func killProcess(p gpu.Process) tea.Cmd {
+	return func() tea.Msg {
+		proc, err := os.FindProcess(p.PID)
+		if err == nil {
+			if runtime.GOOS == "windows" {
+				err = proc.Kill()
+			} else {
+				err = proc.Signal(syscall.SIGTERM)
+			}
+		}
+		if errors.Is(err, os.ErrPermission) {
+			err = fmt.Errorf("%w (not your process)", err)
+		}
+		return killMsg{proc: p, err: err}
+	}
+}
+
+func (m *model) finishKill(msg killMsg) tea.Cmd {
+	if msg.err != nil {
+		m.status = fmt.Sprintf("Killing %s (%d) failed: %v", msg.proc.Name, msg.proc.PID, msg.err)
+		m.logError(m.status)
+	} else {
+		m.status = fmt.Sprintf("Killed %s (%d)", msg.proc.Name, msg.proc.PID)
+	}
+	return tea.Batch(queryProcs, m.refresh())
+}
+
+func (m model) updateProcs(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := m.procs
+	if p := s.kill; p != nil {
+		s.kill = nil
+		if msg.String() != "y" {
+			m.status = "Kill cancelled"
+			return m, nil
+		}
+		return m, killProcess(*p)
+	}
+
+	switch {
+	case msg.String() == "esc", key.Matches(msg, m.keys.Quit, m.keys.Processes):
+		m.mode = modeList
+	case key.Matches(msg, m.keys.Refresh):
+		if s.err != errRemoteGPU {
+			s.loading = true
+			return m, queryProcs
+		}
+	case key.Matches(msg, m.keys.Up):
+		s.cursor = max(s.cursor-1, 0)
+	case key.Matches(msg, m.keys.Down):
+		s.cursor = min(s.cursor+1, max(len(s.procs)-1, 0))
+	case key.Matches(msg, m.keys.Kill):
+		if s.cursor >= len(s.procs) {
+			break
+		}
+		p := s.procs[s.cursor]
+		if isOllama(p) {
+			m.status = "That's Ollama; unload models from the list or use the server panel"
+			break
+		}
+		s.kill = &p
+	}
+	return m, nil
+}
+
+func (m model) procsView() string {
+	s := m.procs
+	var b strings.Builder
+	b.WriteString(m.gpuView())
+	b.WriteString("\n")
+	if v := m.placementView(); v != "" {
+		b.WriteString(v)
+		b.WriteString("\n")
+	}
+	b.WriteString(titleStyle.Render("Processes"))
+	b.WriteString("\n\n")
+
+	switch {
+	case s.err != nil:
+		b.WriteString(errorStyle.Render(s.err.Error()))
+		b.WriteString("\n")
+	case s.procs == nil && s.loading:
+		b.WriteString("Reading processes...\n")
+	case len(s.procs) == 0:
+		b.WriteString("No processes are using the GPU.\n")
+	default:
+		var others uint64
+		tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  PID\tGPU\tTYPE\tVRAM\tNAME")
+		for i, p := range s.procs {
+			cursor := "  "
+			if i == s.cursor {
+				cursor = "> "
+			}
+			vram := "n/a"
+			if p.Memory > 0 {
+				vram = formatBytes(p.Memory)
+			}
+			name := p.Name
+			if isOllama(p) {
+				name = loadedStyle.Render(name)
+			} else {
+				others += p.Memory
+			}
+			fmt.Fprintf(tw, "%s%d\t%d\t%s\t%s\t%s\n", cursor, p.PID, p.GPU, p.Type, vram, name)
+		}
+		tw.Flush()
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf("Other programs hold %s of VRAM", formatBytes(others)))
+		b.WriteString("\n")
+		if s.loading {
+			b.WriteString("\nWorking...\n")
+		}
+	}
+
+	b.WriteString("\n")
+	if p := s.kill; p != nil {
+		b.WriteString(modalStyle.Render(fmt.Sprintf(
+			"Kill %s (pid %d)? Unsaved work in it is lost.\n\ny: Kill  any other key: Cancel", p.Name, p.PID)))
+	} else {
+		b.WriteString(helpStyle.Render(helpLine(m.keys.Kill, m.keys.Refresh) + "  Esc: Back"))
+	}
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("\nStatus: %s", m.status))
+	return b.String()
+}
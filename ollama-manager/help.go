@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// helpGroup is one column of the help overlay.
+type helpGroup struct {
+	title    string
+	bindings []key.Binding
+}
+
+// fixedKey describes a key that can't be remapped so the overlay can list
+// it next to the configurable ones.
+func fixedKey(label, desc string) key.Binding {
+	return key.NewBinding(key.WithKeys(label), key.WithHelp(label, desc))
+}
+
+// helpGroups lists every binding by category. It reads from the active
+// keymap, so remapped keys show up here without further changes.
+func (k keyMap) helpGroups() []helpGroup {
+	return []helpGroup{
+		{"Navigation", []key.Binding{
+			k.Up, k.Down, k.PageUp, k.PageDown, k.Top, k.Bottom, k.Filter, k.Sort, k.Reverse, k.Select,
+			fixedKey("Esc", "Clear filter, then selection"),
+			k.ShowHidden, k.Group, k.Unused, k.Usage, k.Details, k.Hosts,
+		}},
+		{"Models", []key.Binding{
+			k.Run, k.LoadWith, k.GenOptions, k.Stop, k.UnloadAll, k.KeepAlive, k.Extend, k.Warmup, k.Favorite, k.Alias, k.Note, k.Hide, k.Pull, k.PullQueue, k.MoveUp, k.MoveDown, k.Pause, k.Updates, k.Browse, k.HuggingFace, k.Copy, k.CopyToHost, k.CopyName, k.Snippet, k.Delete,
+			k.Refresh, k.Modelfile, k.ImportGGUF, k.Save, k.Versions, k.Disk, k.Prune, k.Verify, k.MoveStore, k.Archive,
+		}},
+		{"GPU", []key.Binding{
+			k.Bench, k.BenchHistory, k.Sweep, k.Parallel, k.KVTest, k.Suites, k.Cancel, k.Processes, k.Kill,
+		}},
+		{"Traffic", []key.Binding{
+			k.NewKey, fixedKey("Enter", "Key's models"), relabel(k.Limits, "Key's limits"), relabel(k.Delete, "Revoke key"), k.Abort,
+		}},
+		{"Chat", []key.Binding{
+			k.Chat, fixedKey("Enter", "Send"), k.ChatStop, k.ChatClear, k.Attach, k.Template, k.Sessions, k.Export,
+			fixedKey("PgUp/PgDn", "Scroll"), k.Compare, k.EmbedBench, k.OpenAI,
+		}},
+		{"General", []key.Binding{
+			k.NextTab, k.PrevTab, fixedKey("1–7", "Go to tab"), k.Server, k.Restart, k.Recreate, k.Upgrade, k.Settings, k.Errors, k.Logs, k.Verbose, k.Theme, k.Help, k.Quit, fixedKey("Ctrl+C", "Quit"),
+		}},
+	}
+}
+
+func (m model) updateHelp(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "esc" || key.Matches(msg, m.keys.Help, m.keys.Quit) {
+		m.mode = modeList
+	}
+	return m, nil
+}
+
+// renderHelpGroup lays out one category as an aligned key/description list.
+func renderHelpGroup(g helpGroup) string {
+	width := 0
+	for _, b := range g.bindings {
+		if b.Enabled() {
+			width = max(width, lipgloss.Width(b.Help().Key))
+		}
+	}
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(g.title))
+	for _, kb := range g.bindings {
+		if !kb.Enabled() {
+			continue
+		}
+		h := kb.Help()
+		pad := strings.Repeat(" ", width-lipgloss.Width(h.Key))
+		b.WriteString(fmt.Sprintf("\n%s%s  %s", cursorStyle.Render(h.Key), pad, h.Desc))
+	}
+	return b.String()
+}
+
+// helpView shows all groups side by side, wrapping onto further rows when
+// the terminal is too narrow.
+func (m model) helpView() string {
+	const gap = 4
+	var rows []string
+	var row []string
+	rowWidth := 0
+	for _, g := range m.keys.helpGroups() {
+		block := lipgloss.NewStyle().MarginRight(gap).Render(renderHelpGroup(g))
+		w := lipgloss.Width(block)
+		if len(row) > 0 && m.width > 0 && rowWidth+w > m.width {
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+			row, rowWidth = nil, 0
+		}
+		row = append(row, block)
+		rowWidth += w
+	}
+	rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, row...))
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Keyboard Shortcuts"))
+	b.WriteString("\n\n")
+	b.WriteString(strings.Join(rows, "\n\n"))
+	b.WriteString("\n\n")
+	b.WriteString(helpStyle.Render(m.keys.Help.Help().Key + "/Esc: Close"))
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,36 @@
+package main
+
+import "fmt"
+
+// hiddenCount is how many installed models the list leaves out.
+func (m model) hiddenCount() int {
+	n := 0
+	for _, mdl := range m.models {
+		if m.cfg.IsHidden(mdl.Name) {
+			n++
+		}
+	}
+	return n
+}
+
+// toggleHidden hides a model from the list, or shows it again, and saves
+// the change. A hidden model is also dropped from the selection so batch
+// operations don't act on rows that aren't shown.
+func (m *model) toggleHidden(name string) {
+	if p := m.cfg.IgnoredBy(name); p != "" {
+		m.status = fmt.Sprintf("%s is hidden by the ignore pattern %q in the config", name, p)
+		return
+	}
+	hidden := m.cfg.ToggleHidden(name)
+	if err := m.cfg.Save(); err != nil {
+		m.status = fmt.Sprintf("Could not save config: %v", err)
+		return
+	}
+	if hidden {
+		delete(m.selected, name)
+		m.status = fmt.Sprintf("Hid %s (%s shows hidden models)", name, m.keys.ShowHidden.Help().Key)
+	} else {
+		m.status = "Unhid " + name
+	}
+	m.relist()
+}
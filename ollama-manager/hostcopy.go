@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/store"
+)
+
+// hostCopyState is a model being sent to another host.
+type hostCopyState struct {
+	model   string
+	host    string // the profile's name
+	updates chan tea.Msg
+	cancel  context.CancelFunc
+}
+
+// hostCopyProgressMsg reports an upload or the create on the other host.
+type hostCopyProgressMsg ollama.PullProgress
+
+// hostCopyDoneMsg ends a copy to another host.
+type hostCopyDoneMsg struct {
+	model, host string
+	err         error
+}
+
+// openCopyToHost picks the host to send a model to. Ollama has no way to
+// read blobs back out, so the model has to come from the local server,
+// whose files the manager reads.
+func (m model) openCopyToHost(name string) (tea.Model, tea.Cmd) {
+	switch {
+	case m.hostCopy != nil:
+		m.status = fmt.Sprintf("Already copying %s to %s", m.hostCopy.model, m.hostCopy.host)
+		return m, nil
+	case len(m.hosts) < 2:
+		m.status = "Add the other host under \"profiles\" in " + m.cfg.Path()
+		return m, nil
+	case !m.client.Local():
+		m.status = "Models are copied from the local server; switch to it to send one"
+		return m, nil
+	}
+	m.copyTo = name
+	m.mode = modeHosts
+	m.hostCursor = 0
+	if m.host == 0 {
+		m.hostCursor = 1
+	}
+	return m, nil
+}
+
+// startCopyToHost sends the model picked in openCopyToHost to profile p.
+func (m model) startCopyToHost(p config.Profile) (tea.Model, tea.Cmd) {
+	name := m.copyTo
+	m.copyTo = ""
+	m.mode = modeList
+	if p.Daemon {
+		m.status = p.Name + " is a daemon; copy to its Ollama server's profile instead"
+		return m, nil
+	}
+	dst, err := clientFor(p)
+	if err != nil {
+		m.status = fmt.Sprintf("Cannot copy to %s: %v", p.Name, err)
+		return m, nil
+	}
+	dir, err := store.Dir()
+	if err != nil {
+		m.status = err.Error()
+		return m, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &hostCopyState{model: name, host: p.Name, updates: make(chan tea.Msg, 1), cancel: cancel}
+	m.hostCopy = s
+	go func() {
+		defer close(s.updates)
+		err := copyModel(ctx, dir, dst, name, func(p ollama.PullProgress) {
+			select {
+			case s.updates <- hostCopyProgressMsg(p):
+			default:
+			}
+		})
+		s.updates <- hostCopyDoneMsg{model: name, host: p.Name, err: err}
+	}()
+	m.status = fmt.Sprintf("Copying %s to %s", name, p.Name)
+	return m, tea.Batch(listen(s.updates), m.startBusy())
+}
+
+// copyModel sends name from the models directory dir to dst: it uploads
+// the blobs dst lacks and has dst create the model from them, with the
+// same template, system prompt, parameters and license. The weights keep
+// their digest, so later pulls and copies share them.
+func copyModel(ctx context.Context, dir string, dst *ollama.Client, name string, fn func(ollama.PullProgress)) error {
+	files, err := store.Files(dir, name)
+	if err != nil {
+		return err
+	}
+	listCtx, cancel := context.WithTimeout(ctx, apiTimeout)
+	installed, err := dst.List(listCtx)
+	cancel()
+	if err != nil {
+		return err
+	}
+	if _, ok := findModel(installed, name); ok {
+		return fmt.Errorf("%s is already installed there", name)
+	}
+
+	req := ollama.CreateRequest{Model: name, Files: map[string]string{}}
+	for _, f := range files {
+		switch f.Kind {
+		case "config":
+			// Rebuilt by the create.
+		case "model", "projector", "adapter":
+			if err := pushLayer(ctx, dst, f, fn); err != nil {
+				return err
+			}
+			if f.Kind == "adapter" {
+				req.Adapters = map[string]string{"adapter.gguf": f.Digest}
+			} else {
+				req.Files[f.Kind+".gguf"] = f.Digest
+			}
+		case "template", "system", "license", "params", "messages":
+			data, err := os.ReadFile(f.Path)
+			if err != nil {
+				return err
+			}
+			switch f.Kind {
+			case "template":
+				req.Template = string(data)
+			case "system":
+				req.System = string(data)
+			case "license":
+				req.License = append(req.License, string(data))
+			case "params":
+				err = json.Unmarshal(data, &req.Parameters)
+			case "messages":
+				err = json.Unmarshal(data, &req.Messages)
+			}
+			if err != nil {
+				return fmt.Errorf("%s layer of %s: %w", f.Kind, name, err)
+			}
+		default:
+			return fmt.Errorf("%s has a %s layer, which can't be copied this way", name, f.Kind)
+		}
+	}
+	if len(req.Files) == 0 {
+		return errors.New(name + " has no weights to copy")
+	}
+	return dst.Create(ctx, req, fn)
+}
+
+// pushLayer uploads the blob of f unless dst has it already.
+func pushLayer(ctx context.Context, dst *ollama.Client, f store.File, fn func(ollama.PullProgress)) error {
+	hasCtx, cancel := context.WithTimeout(ctx, apiTimeout)
+	ok, err := dst.HasBlob(hasCtx, f.Digest)
+	cancel()
+	if err != nil || ok {
+		return err
+	}
+	r, err := os.Open(f.Path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return dst.PushBlob(ctx, f.Digest, r, f.Size, "sending "+f.Kind+" layer", fn)
+}
+
+// finishCopyToHost reports the end of a copy.
+func (m *model) finishCopyToHost(msg hostCopyDoneMsg) tea.Cmd {
+	m.hostCopy = nil
+	m.busy = max(m.busy-1, 0)
+	switch {
+	case errors.Is(msg.err, context.Canceled):
+		m.status = fmt.Sprintf("Copy of %s to %s cancelled", msg.model, msg.host)
+		return nil
+	case msg.err != nil:
+		m.status = fmt.Sprintf("Copying %s to %s failed: %v", msg.model, msg.host, msg.err)
+		m.logError(m.status)
+		return nil
+	}
+	m.status = fmt.Sprintf("Copied %s to %s", msg.model, msg.host)
+	return nil
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/ollama"
+)
+
+// defaultProfile names the host given by --host, OLLAMA_HOST or the
+// top-level config fields.
+const defaultProfile = "default"
+
+// hostSnapshot is the last model list seen on a host, shown straight away
+// when switching back to it while a fresh one loads.
+type hostSnapshot struct {
+	models  []ollama.Model
+	running []ollama.RunningModel
+	at      time.Time
+}
+
+// hostProfiles returns the default host followed by the configured
+// profiles, and the index to start on. An explicit --host or OLLAMA_HOST
+// wins over the profile saved in the config; --profile wins over both.
+func hostProfiles(cfg *config.Config, flagHost, flagProfile string) ([]config.Profile, int, error) {
+	override := firstNonEmpty(flagHost, os.Getenv("OLLAMA_HOST"))
+	profiles := []config.Profile{{
+		Name:     defaultProfile,
+		Host:     firstNonEmpty(override, cfg.Host),
+		Username: cfg.Username,
+		Password: cfg.Password,
+		Token:    cfg.Token,
+	}}
+	profiles = append(profiles, cfg.Profiles...)
+
+	want := flagProfile
+	if want == "" && override == "" {
+		want = cfg.Profile
+	}
+	if want == "" {
+		return profiles, 0, nil
+	}
+	for i, p := range profiles {
+		if p.Name == want {
+			return profiles, i, nil
+		}
+	}
+	if flagProfile == "" {
+		// A profile removed from the config since it was last selected.
+		return profiles, 0, nil
+	}
+	return nil, 0, fmt.Errorf("unknown profile %q", want)
+}
+
+// daemonTokenEnv holds the token for `attach` when --token is not given,
+// which keeps it out of the process list.
+const daemonTokenEnv = "OLLAMA_MANAGER_TOKEN"
+
+// attachProfile picks the profile `attach [--token T] host:port` starts
+// the TUI on: a daemon profile of that host from the config, or one added
+// for the session. The other profiles stay a switch away.
+func attachProfile(profiles []config.Profile, args []string) ([]config.Profile, int, error) {
+	fs := flag.NewFlagSet("attach", flag.ContinueOnError)
+	token := fs.String("token", "", "the daemon's token (default $"+daemonTokenEnv+")")
+	if err := fs.Parse(args); err != nil {
+		return nil, 0, err
+	}
+	if fs.NArg() != 1 {
+		return nil, 0, errors.New("usage: attach [--token T] host:port")
+	}
+	host, _, err := ollama.ParseHost(fs.Arg(0))
+	if err != nil {
+		return nil, 0, err
+	}
+	for i, p := range profiles {
+		if h, _, err := ollama.ParseHost(p.Host); err == nil && h == host && p.Daemon {
+			if *token != "" {
+				profiles[i].Token = *token
+			}
+			return profiles, i, nil
+		}
+	}
+	p := config.Profile{Name: fs.Arg(0), Host: host, Token: firstNonEmpty(*token, os.Getenv(daemonTokenEnv)), Daemon: true}
+	if p.Token == "" {
+		return nil, 0, fmt.Errorf("the daemon's token is needed: pass --token or set %s", daemonTokenEnv)
+	}
+	return append(profiles, p), len(profiles), nil
+}
+
+// localPort is the port of the default host, which a native server, WSL's
+// forwarding and a container compete for on this machine.
+func (m model) localPort() string {
+	host, _, err := ollama.ParseHost(m.hosts[0].Host)
+	if err != nil {
+		return "11434"
+	}
+	u, err := url.Parse(host)
+	if err != nil || u.Port() == "" {
+		return "11434"
+	}
+	return u.Port()
+}
+
+// clientFor connects to a profile's host. Credentials embedded in the host
+// URL win over those set on the profile.
+func clientFor(p config.Profile) (*ollama.Client, error) {
+	host, auth, err := ollama.ParseHost(p.Host)
+	if err != nil {
+		return nil, err
+	}
+	if auth == (ollama.Auth{}) {
+		auth = ollama.Auth{Username: p.Username, Password: p.Password, Token: p.Token}
+	}
+	c := ollama.NewClient(host)
+	c.SetAuth(auth)
+	if p.Daemon {
+		// The daemon may well be reached through a tunnel on localhost.
+		c.SetRemote()
+	}
+	return c, nil
+}
+
+func (m model) openHosts() (tea.Model, tea.Cmd) {
+	m.mode = modeHosts
+	m.hostCursor = m.host
+	return m, nil
+}
+
+// switchHost makes profile i the active endpoint, showing its cached model
+// list until the refresh it starts comes back.
+func (m model) switchHost(i int) (tea.Model, tea.Cmd) {
+	p := m.hosts[i]
+	c, err := clientFor(p)
+	if err != nil {
+		m.status = fmt.Sprintf("Cannot switch to %s: %v", p.Name, err)
+		m.logError(m.status)
+		return m, nil
+	}
+
+	m.host = i
+	m.client = c
+	var daemonCmd tea.Cmd
+	if dc := m.daemonFor(p, c); dc != m.daemonConn {
+		m.daemonConn, m.daemon = dc, nil
+		m.useGPUHistory()
+		daemonCmd = m.syncDaemon()
+	}
+	m.selected = make(map[string]bool)
+	m.loading = make(map[string]bool)
+	m.lastRefreshErr, m.cachedKey = "", ""
+	m.server = serverInfo{}
+	m.activity = nil
+	m.reload = nil
+	m.previous, m.restore = nil, nil
+	snap, ok := m.hostCache[p.Name]
+	if !ok {
+		cached, _ := cachedList(c.Host())
+		snap = hostSnapshot{models: cached.Models, at: cached.Seen}
+	}
+	m.applyRefresh(refreshMsg{host: c.Host(), models: snap.models, running: snap.running})
+	m.refreshed = snap.at
+	m.down = false
+	m.status = "Switched to " + p.Name
+
+	m.cfg.Profile = p.Name
+	if p.Name == defaultProfile {
+		m.cfg.Profile = ""
+	}
+	if err := m.cfg.Save(); err != nil {
+		m.logError(fmt.Sprintf("Could not save config: %v", err))
+	}
+	return m, tea.Batch(m.refresh(), fetchServer(c, m.serviceName()), daemonCmd)
+}
+
+func (m model) updateHosts(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.String() == "esc", key.Matches(msg, m.keys.Quit, m.keys.Hosts):
+		m.mode = modeList
+		m.copyTo = ""
+	case key.Matches(msg, m.keys.Up):
+		m.hostCursor = max(m.hostCursor-1, 0)
+	case key.Matches(msg, m.keys.Down):
+		m.hostCursor = min(m.hostCursor+1, len(m.hosts)-1)
+	case msg.String() == "enter" && m.copyTo != "":
+		if m.hostCursor == m.host {
+			m.status = "Pick another host to copy to"
+			break
+		}
+		return m.startCopyToHost(m.hosts[m.hostCursor])
+	case msg.String() == "enter":
+		m.mode = modeList
+		if m.hostCursor != m.host {
+			return m.switchHost(m.hostCursor)
+		}
+	}
+	return m, nil
+}
+
+func (m model) hostsView() string {
+	var b strings.Builder
+	if m.copyTo != "" {
+		b.WriteString(titleStyle.Render("Copy " + m.copyTo + " to"))
+	} else {
+		b.WriteString(titleStyle.Render("Hosts"))
+	}
+	b.WriteString("\n\n")
+
+	for i, p := range m.hosts {
+		line := fmt.Sprintf("%-12s %s", p.Name, firstNonEmpty(p.Host, ollama.DefaultHost))
+		if p.Daemon {
+			line += helpStyle.Render("  daemon")
+		}
+		if snap, ok := m.hostCache[p.Name]; ok {
+			line += helpStyle.Render(fmt.Sprintf("  %d models, %s", len(snap.models), formatAge(snap.at)))
+		}
+		if i == m.host {
+			line = loadedStyle.Render("● ") + line
+		} else {
+			line = "  " + line
+		}
+		if i == m.hostCursor {
+			b.WriteString(cursorStyle.Render("> "))
+		} else {
+			b.WriteString("  ")
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	if len(m.hosts) == 1 {
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render("Add named hosts under \"profiles\" in " + m.cfg.Path()))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	if m.copyTo != "" {
+		b.WriteString(helpStyle.Render(helpLine(m.keys.Up, m.keys.Down) + "  Enter: Copy there  Esc: Back"))
+	} else {
+		b.WriteString(helpStyle.Render(helpLine(m.keys.Up, m.keys.Down) + "  Enter: Switch  Esc: Back"))
+	}
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("\nStatus: %s", m.status))
+	return b.String()
+}
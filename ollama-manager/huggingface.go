@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/hf"
+	"ollama-manager/internal/quant"
+)
+
+// hfState is the Hugging Face search: a search box, the matching GGUF
+// repositories and, once one is opened, its quantizations.
+type hfState struct {
+	hub       *hf.Client
+	query     textinput.Model
+	listFocus bool
+	loading   bool
+	err       error
+
+	results []hf.Repo
+	cursor  int
+
+	// repo is the result whose quants are shown; empty while on the results.
+	repo        string
+	quants      []hf.Quant
+	advice      []quant.Advice // of quants, against the VRAM free when listed
+	quantCursor int
+	suggested   int // index of the quant preselected for the free VRAM
+}
+
+// hfResultsMsg carries a finished Hugging Face search.
+type hfResultsMsg struct {
+	repos []hf.Repo
+	err   error
+}
+
+// hfQuantsMsg carries the quantizations of one repository.
+type hfQuantsMsg struct {
+	repo   string
+	quants []hf.Quant
+	err    error
+}
+
+func newHFState() *hfState {
+	q := textinput.New()
+	q.Prompt = "Search Hugging Face: "
+	q.Placeholder = "qwen2.5 7b instruct..."
+	q.CharLimit = 100
+	q.Width = 40
+	return &hfState{hub: hf.NewClient(hf.BaseURL), query: q}
+}
+
+func searchHF(hub *hf.Client, query string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		repos, err := hub.Search(ctx, query)
+		return hfResultsMsg{repos: repos, err: err}
+	}
+}
+
+func fetchHFQuants(hub *hf.Client, repo string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		quants, err := hub.Quants(ctx, repo)
+		return hfQuantsMsg{repo: repo, quants: quants, err: err}
+	}
+}
+
+// openHF shows the Hugging Face search, listing the most downloaded GGUF
+// repositories on first use.
+func (m model) openHF() (tea.Model, tea.Cmd) {
+	m.mode = modeHF
+	if m.hf != nil {
+		return m, nil
+	}
+	m.hf = newHFState()
+	m.hf.loading = true
+	return m, tea.Batch(m.hf.query.Focus(), searchHF(m.hf.hub, ""))
+}
+
+// quantAdvice estimates each of a repository's quants against the VRAM
+// free right now.
+func (m model) quantAdvice(quants []hf.Quant) []quant.Advice {
+	free, _ := m.freeVRAM()
+	advice := make([]quant.Advice, len(quants))
+	for i, q := range quants {
+		l, ok := quant.Lookup(q.Name)
+		if !ok {
+			l = quant.Level{Name: q.Name}
+		}
+		advice[i] = quant.ForSize(l, q.Size, free)
+	}
+	return advice
+}
+
+func (m *model) finishHFQuants(msg hfQuantsMsg) {
+	s := m.hf
+	if s == nil || s.repo != msg.repo {
+		return
+	}
+	s.loading, s.err, s.quants = false, msg.err, msg.quants
+	s.advice = m.quantAdvice(msg.quants)
+	s.suggested = max(quant.Best(s.advice), 0)
+	s.quantCursor = s.suggested
+}
+
+func (m model) updateHF(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := m.hf
+	if !s.listFocus {
+		switch msg.String() {
+		case "esc":
+			m.mode = modeList
+			return m, nil
+		case "tab", "down":
+			s.listFocus = true
+			s.query.Blur()
+			return m, nil
+		case "enter":
+			s.repo, s.quants, s.err = "", nil, nil
+			s.loading, s.listFocus, s.cursor = true, true, 0
+			s.query.Blur()
+			return m, searchHF(s.hub, strings.TrimSpace(s.query.Value()))
+		}
+		var cmd tea.Cmd
+		s.query, cmd = s.query.Update(msg)
+		return m, cmd
+	}
+
+	switch {
+	case msg.String() == "esc":
+		if s.repo != "" {
+			s.repo, s.quants, s.err = "", nil, nil
+			return m, nil
+		}
+		m.mode = modeList
+	case msg.String() == "tab", key.Matches(msg, m.keys.Filter):
+		s.listFocus = false
+		return m, s.query.Focus()
+	case key.Matches(msg, m.keys.Up):
+		if s.repo != "" {
+			s.quantCursor = max(s.quantCursor-1, 0)
+		} else {
+			s.cursor = max(s.cursor-1, 0)
+		}
+	case key.Matches(msg, m.keys.Down):
+		if s.repo != "" {
+			s.quantCursor = min(s.quantCursor+1, max(len(s.quants)-1, 0))
+		} else {
+			s.cursor = min(s.cursor+1, max(len(s.results)-1, 0))
+		}
+	case msg.String() == "enter", key.Matches(msg, m.keys.Pull):
+		if s.loading {
+			break
+		}
+		if s.repo == "" {
+			if s.cursor >= len(s.results) {
+				break
+			}
+			s.repo, s.quants, s.err = s.results[s.cursor].ID, nil, nil
+			s.loading = true
+			return m, fetchHFQuants(s.hub, s.repo)
+		}
+		if s.quantCursor >= len(s.quants) {
+			break
+		}
+		m.queuePull(hf.PullName(s.repo, s.quants[s.quantCursor].Name))
+		m.mode = modeList
+		return m, nil
+	}
+	return m, nil
+}
+
+func (m model) hfView() string {
+	s := m.hf
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Hugging Face"))
+	if s.repo != "" {
+		b.WriteString(helpStyle.Render("  " + s.repo))
+	}
+	if free, ok := m.freeVRAM(); ok {
+		b.WriteString(helpStyle.Render("  " + formatVRAM(free) + " VRAM free"))
+	}
+	b.WriteString("\n\n")
+	b.WriteString(s.query.View())
+	b.WriteString("\n\n")
+
+	rows := 15
+	if m.height > 0 {
+		rows = max(m.height-10, 5)
+	}
+	switch {
+	case s.loading:
+		b.WriteString("Loading...\n")
+	case s.err != nil:
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Could not reach %s: %v", hf.BaseURL, s.err)))
+		b.WriteString("\n")
+	case s.repo != "":
+		b.WriteString(m.quantsList(rows))
+	default:
+		b.WriteString(m.reposList(rows))
+	}
+
+	b.WriteString("\n")
+	help := "Enter: Search  Tab: Results  Esc: Back"
+	if s.listFocus {
+		help = "Enter: Open  " + helpLine(m.keys.Filter) + "  Esc: Back"
+		if s.repo != "" {
+			help = "Enter/" + helpLine(m.keys.Pull) + "  Esc: Results"
+		}
+	}
+	b.WriteString(helpStyle.Render(help))
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("\nStatus: %s", m.status))
+	return b.String()
+}
+
+func (m model) reposList(rows int) string {
+	s := m.hf
+	if len(s.results) == 0 {
+		return helpStyle.Render("No GGUF repositories found.") + "\n"
+	}
+	var b strings.Builder
+	start, end := window(s.cursor, len(s.results), rows)
+	for i := start; i < end; i++ {
+		r := s.results[i]
+		prefix := "  "
+		if i == s.cursor && s.listFocus {
+			prefix = cursorStyle.Render("> ")
+		}
+		b.WriteString(fmt.Sprintf("%s%-60s", prefix, r.ID))
+		b.WriteString(helpStyle.Render(fmt.Sprintf(" %7s downloads  %6s likes", formatCount(r.Downloads), formatCount(r.Likes))))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (m model) quantsList(rows int) string {
+	s := m.hf
+	if len(s.quants) == 0 {
+		return helpStyle.Render("No single-file GGUF quants in this repository.") + "\n"
+	}
+	var b strings.Builder
+	start, end := window(s.quantCursor, len(s.quants), rows)
+	for i := start; i < end; i++ {
+		a := s.advice[i]
+		prefix := "  "
+		if i == s.quantCursor {
+			prefix = cursorStyle.Render("> ")
+		}
+		b.WriteString(fmt.Sprintf("%s%-10s %9s  %s  %s", prefix, a.Name, formatBytes(uint64(a.Size)), quantTradeoffs(a.Level), fitMarks[a.Fit]))
+		if i == s.suggested {
+			b.WriteString(helpStyle.Render("  suggested"))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// formatCount shortens a download or like count, e.g. 1234567 to "1.2M".
+func formatCount(n int) string {
+	switch {
+	case n >= 1e6:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1e3:
+		return fmt.Sprintf("%.1fK", float64(n)/1e3)
+	}
+	return fmt.Sprint(n)
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/gpu"
+	"ollama-manager/internal/ollama"
+)
+
+const (
+	defaultHogVRAM = 2048 // MiB
+	defaultHogUtil = 30   // percent
+
+	// idlePoll is how often the daemon looks for activity; the TUI uses its
+	// refresh interval.
+	idlePoll = 15 * time.Second
+)
+
+// compositors draw the desktop and always hold some VRAM; making room for
+// them would unload models for no one.
+var compositors = []string{
+	"dwm.exe", "explorer.exe", "xorg", "xwayland", "gnome-shell", "kwin_x11", "kwin_wayland", "windowserver",
+}
+
+// idleWatch unloads models for other GPU programs. Ollama doesn't expose
+// when it last served a request, but every request pushes a loaded model's
+// expiry back by its keep-alive, so a changed expiry between two polls
+// counts as activity.
+type idleWatch struct {
+	idle    time.Duration
+	minVRAM uint64
+	minUtil int
+	ignore  []string // lower-case process names
+
+	expiry     map[string]time.Time
+	lastActive time.Time
+	checking   bool // a check for GPU programs is in flight
+}
+
+// idleUnloadMsg reports an auto-unload check. hog is nil when no other
+// program needed the GPU.
+type idleUnloadMsg struct {
+	hog     *gpu.Process
+	results []batchResult
+	err     error
+}
+
+// newIdleWatch parses the auto_unload config; it returns nil when the
+// feature is off.
+func newIdleWatch(cfg *config.AutoUnload) (*idleWatch, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	idle, err := time.ParseDuration(cfg.Idle)
+	if err != nil || idle <= 0 {
+		return nil, fmt.Errorf("auto_unload: idle must be a duration such as 15m, not %q", cfg.Idle)
+	}
+	w := &idleWatch{
+		idle:    idle,
+		minVRAM: uint64(orDefault(cfg.MinVRAMMiB, defaultHogVRAM)) << 20,
+		minUtil: orDefault(cfg.MinUtilization, defaultHogUtil),
+		ignore:  compositors,
+	}
+	for _, name := range cfg.Ignore {
+		w.ignore = append(w.ignore, strings.ToLower(name))
+	}
+	return w, nil
+}
+
+// orDefault returns v, or def when v isn't set.
+func orDefault(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+// observe notes requests to the loaded models and reports whether none
+// came in for the idle time. Newly loaded models count as activity.
+func (w *idleWatch) observe(now time.Time, running []ollama.RunningModel) bool {
+	seen := make(map[string]time.Time, len(running))
+	for _, r := range running {
+		seen[r.Name] = r.ExpiresAt
+		if prev, ok := w.expiry[r.Name]; !ok || !prev.Equal(r.ExpiresAt) {
+			w.lastActive = now
+		}
+	}
+	w.expiry = seen
+	return len(running) > 0 && now.Sub(w.lastActive) >= w.idle
+}
+
+// hog finds a program other than Ollama using the GPU heavily. Windows
+// doesn't report VRAM per graphics app, so for those the GPU's load is used
+// instead; with Ollama idle, it comes from someone else.
+func (w *idleWatch) hog(procs []gpu.Process, devices []gpu.Device) (gpu.Process, bool) {
+	util := make(map[int]int, len(devices))
+	for _, d := range devices {
+		util[d.Index] = d.Utilization
+	}
+	for _, p := range procs {
+		if isOllama(p) || w.ignored(p.Name) {
+			continue
+		}
+		if p.Memory >= w.minVRAM || (p.Memory == 0 && util[p.GPU] >= w.minUtil) {
+			return p, true
+		}
+	}
+	return gpu.Process{}, false
+}
+
+func (w *idleWatch) ignored(name string) bool {
+	base := strings.ToLower(name[strings.LastIndexAny(name, `/\`)+1:])
+	for _, n := range w.ignore {
+		if base == n {
+			return true
+		}
+	}
+	return false
+}
+
+// unloadForHog looks for a GPU-heavy program and, if one is running,
+// unloads the given models to make room for it.
+func (w *idleWatch) unloadForHog(c *ollama.Client, loaded []string) idleUnloadMsg {
+	procs, err := gpu.Processes()
+	if err != nil {
+		return idleUnloadMsg{err: err}
+	}
+	devices, _ := gpu.Query()
+	p, ok := w.hog(procs, devices)
+	if !ok {
+		return idleUnloadMsg{}
+	}
+	slog.Info("auto-unload", "process", p.Name, "pid", p.PID, "models", loaded)
+	results := runBatch(loaded, func(name string) error {
+		return stopModel(c, name)
+	})
+	return idleUnloadMsg{hog: &p, results: results}
+}
+
+// watchIdle feeds a refresh to the watcher and starts a check once Ollama
+// has been idle long enough. Only a local server shares this machine's GPU.
+func (m *model) watchIdle(running []ollama.RunningModel) tea.Cmd {
+	w := m.idle
+	if w == nil || !m.client.Local() || w.checking || !w.observe(time.Now(), running) {
+		return nil
+	}
+	w.checking = true
+	c := m.client
+	loaded := make([]string, len(running))
+	for i, r := range running {
+		loaded[i] = r.Name
+	}
+	return func() tea.Msg {
+		return w.unloadForHog(c, loaded)
+	}
+}
+
+func (m *model) finishIdleUnload(msg idleUnloadMsg) tea.Cmd {
+	m.idle.checking = false
+	if msg.err != nil {
+		slog.Debug("auto-unload", "err", msg.err)
+		return nil
+	}
+	if msg.hog == nil {
+		return nil
+	}
+	failed := 0
+	for _, r := range msg.results {
+		if r.err != nil {
+			failed++
+			m.logError(fmt.Sprintf("Auto-unload of %s failed: %v", r.name, r.err))
+		}
+	}
+	m.status = fmt.Sprintf("Unloaded %d models for %s after %s idle", len(msg.results)-failed, msg.hog.Name, m.idle.idle)
+	return tea.Batch(m.refresh(), m.notify("auto_unload", time.Time{}, "%s", m.status))
+}
+
+// runIdleWatch polls for idleness until ctx is done, for the daemon.
+func runIdleWatch(ctx context.Context, c *ollama.Client, w *idleWatch) {
+	ticker := time.NewTicker(idlePoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		running, err := getRunning(c)
+		if err != nil || !w.observe(time.Now(), running) {
+			continue
+		}
+		loaded := make([]string, len(running))
+		for i, r := range running {
+			loaded[i] = r.Name
+		}
+		msg := w.unloadForHog(c, loaded)
+		if msg.hog == nil {
+			continue
+		}
+		at := time.Now().Format("2006-01-02 15:04")
+		for _, r := range msg.results {
+			if r.err != nil {
+				fmt.Printf("%s auto-unload %s failed: %v\n", at, r.name, r.err)
+			} else {
+				fmt.Printf("%s unloaded %s for %s (pid %d)\n", at, r.name, msg.hog.Name, msg.hog.PID)
+			}
+		}
+	}
+}
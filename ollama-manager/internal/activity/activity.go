@@ -0,0 +1,112 @@
+// Package activity reconstructs the request traffic of an Ollama server
+// from the line its HTTP router logs for every finished request, e.g.
+//
+//	[GIN] 2025/06/01 - 12:34:56 | 200 |  4.123456s |  192.168.1.20 | POST     "/api/chat"
+package activity
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request is one finished request.
+type Request struct {
+	Time    time.Time
+	Status  int
+	Latency time.Duration
+	Client  string
+	Method  string
+	Path    string
+}
+
+// Inference reports whether the request ran a model rather than managed
+// one, such as a chat, completion or embedding.
+func (r Request) Inference() bool {
+	return IsInference(r.Path)
+}
+
+// IsInference reports whether a request to path runs a model.
+func IsInference(path string) bool {
+	switch path {
+	case "/api/chat", "/api/generate", "/api/embed", "/api/embeddings",
+		"/v1/chat/completions", "/v1/completions", "/v1/embeddings":
+		return true
+	}
+	return false
+}
+
+var reGin = regexp.MustCompile(`\[GIN\] (\d{4}/\d{2}/\d{2} - \d{2}:\d{2}:\d{2}) \|\s*(\d{3}) \|\s*(\S+)\s*\|\s*(\S+)\s*\|\s*(\w+)\s+"([^"]*)"`)
+
+// Parse reads the requests out of server log text, oldest first. Other
+// lines are skipped. The log has no time zone, so times are taken as local.
+func Parse(log string) []Request {
+	var reqs []Request
+	for _, line := range strings.Split(log, "\n") {
+		m := reGin.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		t, err := time.ParseInLocation("2006/01/02 - 15:04:05", m[1], time.Local)
+		if err != nil {
+			continue
+		}
+		status, _ := strconv.Atoi(m[2])
+		latency, _ := time.ParseDuration(m[3])
+		reqs = append(reqs, Request{Time: t, Status: status, Latency: latency, Client: m[4], Method: m[5], Path: m[6]})
+	}
+	return reqs
+}
+
+// PerMinute counts the requests in each of the last n minutes before now,
+// oldest first.
+func PerMinute(reqs []Request, now time.Time, n int) []int {
+	counts := make([]int, n)
+	for _, r := range reqs {
+		ago := int(now.Sub(r.Time) / time.Minute)
+		if ago >= 0 && ago < n {
+			counts[n-1-ago]++
+		}
+	}
+	return counts
+}
+
+// Since returns the requests at or after t.
+func Since(reqs []Request, t time.Time) []Request {
+	i := sort.Search(len(reqs), func(i int) bool { return !reqs[i].Time.Before(t) })
+	return reqs[i:]
+}
+
+// Client is the traffic of one address.
+type Client struct {
+	Addr     string
+	Requests int
+	Errors   int
+	Last     time.Time
+}
+
+// ByClient groups requests by address, busiest first.
+func ByClient(reqs []Request) []Client {
+	idx := make(map[string]int)
+	var clients []Client
+	for _, r := range reqs {
+		i, ok := idx[r.Client]
+		if !ok {
+			i = len(clients)
+			idx[r.Client] = i
+			clients = append(clients, Client{Addr: r.Client})
+		}
+		c := &clients[i]
+		c.Requests++
+		if r.Status >= 400 {
+			c.Errors++
+		}
+		if r.Time.After(c.Last) {
+			c.Last = r.Time
+		}
+	}
+	sort.SliceStable(clients, func(i, j int) bool { return clients[i].Requests > clients[j].Requests })
+	return clients
+}
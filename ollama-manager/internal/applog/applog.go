@@ -0,0 +1,234 @@
+// Package applog records what the manager does (API calls, external
+// commands and errors) to a log file and to an in-memory ring buffer that
+// the TUI's log viewer reads.
+package applog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxFileSize is the size past which the log file is moved aside to
+// path+".1", replacing the previous one.
+const maxFileSize = 5 << 20
+
+// Entry is one log record as the viewer shows it.
+type Entry struct {
+	Time  time.Time
+	Level slog.Level
+	Msg   string
+	Attrs string // space-separated key=value pairs
+}
+
+// Ring keeps the most recent entries in memory.
+type Ring struct {
+	mu    sync.Mutex
+	buf   []Entry
+	start int
+	n     int
+}
+
+// NewRing returns a ring holding up to size entries.
+func NewRing(size int) *Ring {
+	return &Ring{buf: make([]Entry, size)}
+}
+
+func (r *Ring) add(e Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.n < len(r.buf) {
+		r.buf[(r.start+r.n)%len(r.buf)] = e
+		r.n++
+		return
+	}
+	r.buf[r.start] = e
+	r.start = (r.start + 1) % len(r.buf)
+}
+
+// Entries returns a copy of the buffered entries, oldest first.
+func (r *Ring) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Entry, r.n)
+	for i := range out {
+		out[i] = r.buf[(r.start+i)%len(r.buf)]
+	}
+	return out
+}
+
+// handler sends every record to the ring and, if set, the file handler.
+type handler struct {
+	ring  *Ring
+	file  slog.Handler
+	attrs string // pre-rendered attributes from WithAttrs
+	group string // key prefix from WithGroup
+}
+
+func (h *handler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	var attrs []string
+	if h.attrs != "" {
+		attrs = append(attrs, h.attrs)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, h.render(a))
+		return true
+	})
+	h.ring.add(Entry{Time: r.Time, Level: r.Level, Msg: r.Message, Attrs: strings.Join(attrs, " ")})
+	if h.file != nil {
+		return h.file.Handle(ctx, r)
+	}
+	return nil
+}
+
+func (h *handler) render(a slog.Attr) string {
+	v := a.Value.Resolve().String()
+	if strings.ContainsAny(v, " \"=") {
+		v = fmt.Sprintf("%q", v)
+	}
+	return h.group + a.Key + "=" + v
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	parts := []string{}
+	if h.attrs != "" {
+		parts = append(parts, h.attrs)
+	}
+	for _, a := range attrs {
+		parts = append(parts, h.render(a))
+	}
+	h2.attrs = strings.Join(parts, " ")
+	if h.file != nil {
+		h2.file = h.file.WithAttrs(attrs)
+	}
+	return &h2
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.group = h.group + name + "."
+	if h.file != nil {
+		h2.file = h.file.WithGroup(name)
+	}
+	return &h2
+}
+
+// Open returns a logger writing to a ring of size entries and, unless path
+// is empty, appending to the file at path. If the file can't be opened the
+// logger still writes to the ring and the error is returned alongside it.
+func Open(path string, size int) (*slog.Logger, *Ring, func() error, error) {
+	ring := NewRing(size)
+	h := &handler{ring: ring}
+	closeFn := func() error { return nil }
+	if path == "" {
+		return slog.New(h), ring, closeFn, nil
+	}
+
+	f := &file{path: path}
+	if err := f.open(); err != nil {
+		return slog.New(h), ring, closeFn, err
+	}
+	h.file = slog.NewTextHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return slog.New(h), ring, f.Close, nil
+}
+
+// file is the log file, rotated once it grows past maxFileSize.
+type file struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	size int64
+}
+
+func (f *file) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return err
+	}
+	fh, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := fh.Stat()
+	if err != nil {
+		fh.Close()
+		return err
+	}
+	f.f, f.size = fh, info.Size()
+	return nil
+}
+
+func (f *file) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.f == nil {
+		return 0, os.ErrClosed
+	}
+	if f.size+int64(len(p)) > maxFileSize {
+		f.f.Close()
+		f.f = nil
+		// If the rename fails keep appending rather than lose the log.
+		os.Rename(f.path, f.path+".1")
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.f.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *file) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.f == nil {
+		return nil
+	}
+	err := f.f.Close()
+	f.f = nil
+	return err
+}
+
+// Command records an external command that has finished. Failures are
+// logged as warnings whatever the level.
+func Command(level slog.Level, start time.Time, args []string, err error) {
+	attrs := []any{"cmd", strings.Join(args, " "), "took", time.Since(start).Round(time.Millisecond)}
+	if err != nil {
+		slog.Warn("exec", append(attrs, "err", err)...)
+		return
+	}
+	slog.Log(context.Background(), level, "exec", attrs...)
+}
+
+// Recent returns up to n of the last lines in the log file at path logged
+// at min or above, oldest first.
+func Recent(path string, n int, min slog.Level) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		_, rest, ok := strings.Cut(line, " level=")
+		if !ok {
+			continue
+		}
+		name, _, _ := strings.Cut(rest, " ")
+		var level slog.Level
+		if level.UnmarshalText([]byte(name)) != nil || level < min {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
@@ -0,0 +1,189 @@
+// Package bench measures model throughput on the local GPU.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"ollama-manager/internal/gpu"
+	"ollama-manager/internal/ollama"
+)
+
+// Prompt is one request in a benchmark run.
+type Prompt struct {
+	Name      string
+	Text      string
+	MaxTokens int
+}
+
+// DefaultPrompts is the standard set: a short chat turn, a code task and a
+// long-form answer, so both prompt processing and generation get exercised.
+var DefaultPrompts = []Prompt{
+	{
+		Name:      "short",
+		Text:      "Explain in two sentences why the sky is blue.",
+		MaxTokens: 128,
+	},
+	{
+		Name:      "code",
+		Text:      "Write a Python function that returns the n-th Fibonacci number using memoization, with a docstring and type hints.",
+		MaxTokens: 256,
+	},
+	{
+		Name:      "long",
+		Text:      "Write a detailed, well-structured essay about the history of the GPU, from fixed-function graphics pipelines to modern tensor cores, covering at least five milestones.",
+		MaxTokens: 512,
+	},
+}
+
+// options pin sampling so runs are comparable across models and sessions.
+// extra, the model's saved options, override them, except num_predict: each
+// prompt always generates up to its own MaxTokens.
+func options(maxTokens int, extra map[string]any) map[string]any {
+	opts := map[string]any{
+		"temperature": 0,
+		"seed":        42,
+	}
+	for k, v := range extra {
+		opts[k] = v
+	}
+	opts["num_predict"] = maxTokens
+	return opts
+}
+
+// Run holds the measurements for a single prompt.
+type Run struct {
+	Prompt       string        `json:"prompt"`
+	PromptTokens int           `json:"prompt_tokens"`
+	GenTokens    int           `json:"gen_tokens"`
+	PromptTPS    float64       `json:"prompt_tps"`
+	GenTPS       float64       `json:"gen_tps"`
+	TTFT         time.Duration `json:"ttft"`
+	Total        time.Duration `json:"total"`
+}
+
+// Result summarises a benchmark of one model, along with the environment it
+// ran in so later runs can be compared across driver and Ollama upgrades.
+type Result struct {
+	Model         string         `json:"model"`
+	Quant         string         `json:"quant"`
+	Started       time.Time      `json:"started"`
+	LoadTime      time.Duration  `json:"load_time"`
+	PromptTPS     float64        `json:"prompt_tps"` // aggregate prompt processing tokens/s
+	GenTPS        float64        `json:"gen_tps"`    // aggregate generation tokens/s
+	TTFT          time.Duration  `json:"ttft"`       // mean time to first token
+	VRAM          int64          `json:"vram"`       // bytes resident on the GPU after the run
+	GPU           string         `json:"gpu,omitempty"`
+	Driver        string         `json:"driver,omitempty"`
+	OllamaVersion string         `json:"ollama_version,omitempty"`
+	Options       map[string]any `json:"options,omitempty"` // the model's saved options, if any
+	Runs          []Run          `json:"runs"`
+}
+
+// Progress receives a human-readable description of each step.
+type Progress func(step string)
+
+// Benchmark loads model, runs prompts against it and reports throughput.
+// opts are extra runner options, such as num_ctx, applied to every prompt.
+func Benchmark(ctx context.Context, c *ollama.Client, model string, prompts []Prompt, opts map[string]any, progress Progress) (*Result, error) {
+	res := &Result{Model: model, Started: time.Now(), Options: opts}
+	res.describeEnv(ctx, c)
+
+	// Load with the context length the prompts use, or the first one would
+	// reload the model and count that in its time to first token.
+	numCtx, _ := opts["num_ctx"].(int)
+	progress("loading " + model)
+	start := time.Now()
+	if err := c.Load(ctx, model, ollama.LoadOptions{NumCtx: numCtx}); err != nil {
+		return nil, fmt.Errorf("load: %w", err)
+	}
+	res.LoadTime = time.Since(start)
+
+	var promptTokens, genTokens int
+	var promptDur, genDur, ttft time.Duration
+	for i, p := range prompts {
+		progress(fmt.Sprintf("prompt %d/%d: %s", i+1, len(prompts), p.Name))
+		run, metrics, err := runPrompt(ctx, c, model, p, opts)
+		if err != nil {
+			return nil, fmt.Errorf("prompt %q: %w", p.Name, err)
+		}
+		res.Runs = append(res.Runs, run)
+		promptTokens += metrics.PromptEvalCount
+		promptDur += metrics.PromptEvalDuration
+		genTokens += metrics.EvalCount
+		genDur += metrics.EvalDuration
+		ttft += run.TTFT
+	}
+
+	if promptDur > 0 {
+		res.PromptTPS = float64(promptTokens) / promptDur.Seconds()
+	}
+	if genDur > 0 {
+		res.GenTPS = float64(genTokens) / genDur.Seconds()
+	}
+	if len(prompts) > 0 {
+		res.TTFT = ttft / time.Duration(len(prompts))
+	}
+
+	progress("reading VRAM usage")
+	if running, err := c.Running(ctx); err == nil {
+		for _, r := range running {
+			if r.Name == model {
+				res.VRAM = r.SizeVRAM
+				res.Quant = r.Details.QuantizationLevel
+			}
+		}
+	}
+	return res, nil
+}
+
+func runPrompt(ctx context.Context, c *ollama.Client, model string, p Prompt, opts map[string]any) (Run, ollama.Metrics, error) {
+	run := Run{Prompt: p.Name}
+	var final ollama.Metrics
+	start := time.Now()
+	err := c.GenerateStream(ctx, ollama.GenerateRequest{
+		Model:   model,
+		Prompt:  p.Text,
+		Options: options(p.MaxTokens, opts),
+	}, func(r ollama.GenerateResponse) {
+		if run.TTFT == 0 && r.Response != "" {
+			run.TTFT = time.Since(start)
+		}
+		if r.Done {
+			final = r.Metrics
+		}
+	})
+	if err != nil {
+		return run, final, err
+	}
+	run.Total = time.Since(start)
+	run.PromptTokens = final.PromptEvalCount
+	run.GenTokens = final.EvalCount
+	run.PromptTPS = final.PromptTokensPerSecond()
+	run.GenTPS = final.TokensPerSecond()
+	return run, final, nil
+}
+
+// describeEnv records the GPU, driver and server version. Failures leave the
+// fields empty rather than aborting the benchmark, and GPU details are only
+// taken when the server runs on this machine.
+func (r *Result) describeEnv(ctx context.Context, c *ollama.Client) {
+	if v, err := c.Version(ctx); err == nil {
+		r.OllamaVersion = v
+	}
+	if !c.Local() {
+		return
+	}
+	devices, err := gpu.Query()
+	if err != nil || len(devices) == 0 {
+		return
+	}
+	names := make([]string, len(devices))
+	for i, d := range devices {
+		names[i] = d.Name
+	}
+	r.GPU = strings.Join(names, " + ")
+	r.Driver = devices[0].Driver
+}
@@ -0,0 +1,88 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"ollama-manager/internal/ollama"
+)
+
+const (
+	// embedBatch is how many inputs go in one /api/embed request, about what
+	// a RAG indexer sends.
+	embedBatch = 32
+	// embedRounds is how many batches are timed.
+	embedRounds = 4
+)
+
+// EmbedResult is the throughput of an embedding model.
+type EmbedResult struct {
+	Embeddings int
+	Tokens     int
+	Elapsed    time.Duration
+}
+
+// PerSecond returns embeddings per second.
+func (r EmbedResult) PerSecond() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Embeddings) / r.Elapsed.Seconds()
+}
+
+// TokensPerSecond returns input tokens embedded per second.
+func (r EmbedResult) TokensPerSecond() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Tokens) / r.Elapsed.Seconds()
+}
+
+// BenchmarkEmbed times batches of variations of text through model. A first
+// request loads the model and isn't counted.
+func BenchmarkEmbed(ctx context.Context, c *ollama.Client, model, text string, progress Progress) (*EmbedResult, error) {
+	progress("loading " + model)
+	if _, err := c.Embed(ctx, ollama.EmbedRequest{Model: model, Input: []string{text}}); err != nil {
+		return nil, fmt.Errorf("load: %w", err)
+	}
+
+	// Numbering the inputs keeps the server from answering from a cache.
+	inputs := make([]string, embedBatch)
+	res := &EmbedResult{}
+	for round := 0; round < embedRounds; round++ {
+		progress(fmt.Sprintf("batch %d/%d", round+1, embedRounds))
+		for i := range inputs {
+			inputs[i] = fmt.Sprintf("%d. %s", round*embedBatch+i+1, text)
+		}
+		start := time.Now()
+		resp, err := c.Embed(ctx, ollama.EmbedRequest{Model: model, Input: inputs})
+		if err != nil {
+			return nil, err
+		}
+		res.Elapsed += time.Since(start)
+		res.Embeddings += len(resp.Embeddings)
+		res.Tokens += resp.PromptEvalCount
+	}
+	return res, nil
+}
+
+// Cosine returns the cosine similarity of two vectors: 1 for the same
+// direction, 0 for unrelated. It is 0 when they differ in length or either
+// is zero.
+func Cosine(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
@@ -0,0 +1,72 @@
+package bench
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// History is the persisted list of benchmark results, oldest first. The zero
+// value keeps results in memory only.
+type History struct {
+	path    string
+	Results []*Result
+}
+
+// OpenHistory loads the history file at path; a missing file is empty.
+func OpenHistory(path string) (*History, error) {
+	h := &History{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &h.Results); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return h, nil
+}
+
+// Add appends r and writes the history back to disk.
+func (h *History) Add(r *Result) error {
+	h.Results = append(h.Results, r)
+	return h.save()
+}
+
+func (h *History) save() error {
+	if h.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(h.Results, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return err
+	}
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, h.path)
+}
+
+// Previous returns the most recent result for the same model and
+// quantization recorded before r, or nil.
+func (h *History) Previous(r *Result) *Result {
+	var prev *Result
+	for _, other := range h.Results {
+		if other == r || other.Model != r.Model || other.Quant != r.Quant {
+			continue
+		}
+		if other.Started.Before(r.Started) && (prev == nil || other.Started.After(prev.Started)) {
+			prev = other
+		}
+	}
+	return prev
+}
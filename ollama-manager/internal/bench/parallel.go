@@ -0,0 +1,168 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"ollama-manager/internal/ollama"
+)
+
+const (
+	// DefaultParallel is the highest number of concurrent requests tried.
+	DefaultParallel = 8
+	// parallelRounds is how many requests each concurrent client sends in
+	// turn, so latencies have a spread to take a percentile of.
+	parallelRounds = 3
+	// bestShare is how close to the peak aggregate throughput a level must
+	// come to be suggested: more parallelism than that buys little.
+	bestShare = 0.95
+)
+
+// parallelPrompt is what every request asks: the short answer, so a level
+// of eight finishes quickly.
+var parallelPrompt = DefaultPrompts[0]
+
+// ParallelStep is the measurement at one number of concurrent requests.
+type ParallelStep struct {
+	Concurrency int           `json:"concurrency"`
+	Requests    int           `json:"requests"`
+	Failed      int           `json:"failed"`
+	GenTokens   int           `json:"gen_tokens"`
+	Elapsed     time.Duration `json:"elapsed"`
+	P50         time.Duration `json:"p50"` // request latency, start to last token
+	P95         time.Duration `json:"p95"`
+	Err         string        `json:"error,omitempty"` // the first failure
+}
+
+// AggregateTPS is the tokens generated per second across all requests.
+func (s ParallelStep) AggregateTPS() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.GenTokens) / s.Elapsed.Seconds()
+}
+
+// ParallelResult is a parallelism test of one model.
+type ParallelResult struct {
+	Model         string         `json:"model"`
+	Quant         string         `json:"quant"`
+	Started       time.Time      `json:"started"`
+	VRAM          int64          `json:"vram"` // bytes on the GPU, which the slots' KV caches are part of
+	GPU           string         `json:"gpu,omitempty"`
+	OllamaVersion string         `json:"ollama_version,omitempty"`
+	Steps         []ParallelStep `json:"steps"`
+
+	// ServerParallel is OLLAMA_NUM_PARALLEL as the server's service sets
+	// it, filled in by the caller; empty when unset or unknown.
+	ServerParallel string `json:"server_parallel,omitempty"`
+}
+
+// Best returns the fewest concurrent requests that reach bestShare of the
+// highest aggregate throughput measured without failures, or 0.
+func (r *ParallelResult) Best() int {
+	peak := 0.0
+	for _, s := range r.Steps {
+		if s.Failed == 0 {
+			peak = max(peak, s.AggregateTPS())
+		}
+	}
+	for _, s := range r.Steps {
+		if s.Failed == 0 && peak > 0 && s.AggregateTPS() >= peak*bestShare {
+			return s.Concurrency
+		}
+	}
+	return 0
+}
+
+// Parallel loads model and, for each number of concurrent requests from 1
+// to maxN, has that many clients send parallelRounds requests each at
+// once, timing every one. The server only runs as many at once as its
+// OLLAMA_NUM_PARALLEL allows and queues the rest, which shows as flat
+// throughput and growing latency.
+func Parallel(ctx context.Context, c *ollama.Client, model string, maxN int, opts map[string]any, progress Progress) (*ParallelResult, error) {
+	env := &Result{}
+	env.describeEnv(ctx, c)
+	res := &ParallelResult{Model: model, Started: time.Now(), GPU: env.GPU, OllamaVersion: env.OllamaVersion}
+
+	numCtx, _ := opts["num_ctx"].(int)
+	progress("loading " + model)
+	if err := c.Load(ctx, model, ollama.LoadOptions{NumCtx: numCtx}); err != nil {
+		return nil, fmt.Errorf("load: %w", err)
+	}
+
+	for n := 1; n <= maxN; n++ {
+		progress(fmt.Sprintf("%d concurrent requests", n))
+		step := runParallel(ctx, c, model, n, opts)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		res.Steps = append(res.Steps, step)
+	}
+
+	if running, err := c.Running(ctx); err == nil {
+		for _, r := range running {
+			if r.Name == model {
+				res.VRAM = r.SizeVRAM
+				res.Quant = r.Details.QuantizationLevel
+			}
+		}
+	}
+	return res, nil
+}
+
+// runParallel measures one level: n clients, each sending parallelRounds
+// requests one after another.
+func runParallel(ctx context.Context, c *ollama.Client, model string, n int, opts map[string]any) ParallelStep {
+	step := ParallelStep{Concurrency: n}
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		wg        sync.WaitGroup
+	)
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := 0; round < parallelRounds; round++ {
+				began := time.Now()
+				run, _, err := runPrompt(ctx, c, model, parallelPrompt, opts)
+				took := time.Since(began)
+				mu.Lock()
+				step.Requests++
+				if err != nil {
+					step.Failed++
+					if step.Err == "" {
+						step.Err = err.Error()
+					}
+				} else {
+					step.GenTokens += run.GenTokens
+					latencies = append(latencies, took)
+				}
+				mu.Unlock()
+				if ctx.Err() != nil {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	step.Elapsed = time.Since(start)
+	step.P50, step.P95 = percentile(latencies, 50), percentile(latencies, 95)
+	return step
+}
+
+// percentile returns the p-th percentile of ds by the nearest-rank method,
+// or 0 for none.
+func percentile(ds []time.Duration, p int) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,125 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ollama-manager/internal/ollama"
+)
+
+// DefaultContexts are the num_ctx values a sweep steps through, doubling
+// from Ollama's default.
+var DefaultContexts = []int{4096, 8192, 16384, 32768, 65536, 131072}
+
+// usableSpeed is the share of the smallest context's generation speed a
+// larger one must keep to count as usable.
+const usableSpeed = 0.5
+
+// sweepPrompt is timed at each context length: the code task, long enough
+// to measure generation without making the sweep slow.
+var sweepPrompt = DefaultPrompts[1]
+
+// SweepStep is the measurement at one context length.
+type SweepStep struct {
+	NumCtx    int           `json:"num_ctx"`
+	PromptTPS float64       `json:"prompt_tps"`
+	GenTPS    float64       `json:"gen_tps"`
+	TTFT      time.Duration `json:"ttft"`
+	Size      int64         `json:"size"` // bytes the loaded model takes in all
+	VRAM      int64         `json:"vram"` // bytes of it on the GPU
+	Err       string        `json:"error,omitempty"`
+}
+
+// OnGPU reports whether the model ran entirely in VRAM at this context.
+func (s SweepStep) OnGPU() bool {
+	return s.Err == "" && s.Size > 0 && s.VRAM >= s.Size
+}
+
+// SweepResult is a context-size sweep of one model.
+type SweepResult struct {
+	Model         string      `json:"model"`
+	Quant         string      `json:"quant"`
+	Started       time.Time   `json:"started"`
+	MaxContext    int         `json:"max_context,omitempty"` // the model's trained context length
+	GPU           string      `json:"gpu,omitempty"`
+	Driver        string      `json:"driver,omitempty"`
+	OllamaVersion string      `json:"ollama_version,omitempty"`
+	Steps         []SweepStep `json:"steps"`
+}
+
+// Usable reports whether step i ran entirely on the GPU at no less than
+// usableSpeed of the first step's generation speed.
+func (r *SweepResult) Usable(i int) bool {
+	s := r.Steps[i]
+	return s.OnGPU() && s.GenTPS >= r.Steps[0].GenTPS*usableSpeed
+}
+
+// Largest returns the largest usable context, or 0 if none was.
+func (r *SweepResult) Largest() int {
+	largest := 0
+	for i, s := range r.Steps {
+		if r.Usable(i) {
+			largest = max(largest, s.NumCtx)
+		}
+	}
+	return largest
+}
+
+// Sweep loads model at each of contexts in turn, leaving out those beyond
+// its trained length, and times a prompt at each. opts are the model's
+// saved options; num_ctx among them is overridden. The sweep stops after
+// the first context that fails or no longer fits in VRAM, since larger
+// ones only spill further onto the CPU.
+func Sweep(ctx context.Context, c *ollama.Client, model string, contexts []int, opts map[string]any, progress Progress) (*SweepResult, error) {
+	env := &Result{}
+	env.describeEnv(ctx, c)
+	res := &SweepResult{Model: model, Started: time.Now(), GPU: env.GPU, Driver: env.Driver, OllamaVersion: env.OllamaVersion}
+	if info, err := c.Show(ctx, model); err == nil {
+		res.MaxContext = info.ContextLength()
+	}
+
+	for _, n := range contexts {
+		if res.MaxContext > 0 && n > res.MaxContext {
+			break
+		}
+		step := SweepStep{NumCtx: n}
+		progress(fmt.Sprintf("loading at num_ctx %d", n))
+		err := c.Load(ctx, model, ollama.LoadOptions{NumCtx: n})
+		if err == nil {
+			progress(fmt.Sprintf("timing at num_ctx %d", n))
+			stepOpts := map[string]any{}
+			for k, v := range opts {
+				stepOpts[k] = v
+			}
+			stepOpts["num_ctx"] = n
+			var run Run
+			run, _, err = runPrompt(ctx, c, model, sweepPrompt, stepOpts)
+			step.PromptTPS, step.GenTPS, step.TTFT = run.PromptTPS, run.GenTPS, run.TTFT
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			step.Err = err.Error()
+			res.Steps = append(res.Steps, step)
+			break
+		}
+		if running, err := c.Running(ctx); err == nil {
+			for _, r := range running {
+				if r.Name == model {
+					step.Size, step.VRAM = r.Size, r.SizeVRAM
+					res.Quant = r.Details.QuantizationLevel
+				}
+			}
+		}
+		res.Steps = append(res.Steps, step)
+		if !step.OnGPU() {
+			break
+		}
+	}
+	if len(res.Steps) == 0 {
+		return nil, fmt.Errorf("%s was trained on %d tokens, less than the smallest context to try", model, res.MaxContext)
+	}
+	return res, nil
+}
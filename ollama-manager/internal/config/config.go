@@ -0,0 +1,585 @@
+// Package config loads and saves the manager's settings file.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"time"
+)
+
+// Config is the on-disk settings file. Zero values mean "use the default".
+type Config struct {
+	// Host is the Ollama server to manage, e.g. "gpu-box:11434". The
+	// --host flag and OLLAMA_HOST take precedence.
+	Host string `json:"host,omitempty"`
+
+	// Username and Password enable basic auth; Token sends a bearer token
+	// instead. Both are for servers behind an authenticating proxy.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+
+	// Profiles names additional servers to switch between; Profile is the
+	// one selected last.
+	Profiles []Profile `json:"profiles,omitempty"`
+	Profile  string    `json:"profile,omitempty"`
+
+	// Keys rebinds actions, e.g. {"run": ["enter"], "details": ["i"]}. An
+	// empty list disables the action.
+	Keys map[string][]string `json:"keys,omitempty"`
+
+	// KeepAlive is the default keep_alive sent when loading a model,
+	// e.g. "10m", "1h" or "-1" to keep models loaded indefinitely.
+	KeepAlive string `json:"keep_alive,omitempty"`
+
+	// ModelKeepAlive overrides KeepAlive for individual models.
+	ModelKeepAlive map[string]string `json:"model_keep_alive,omitempty"`
+
+	// ModelOptions are generation parameters sent with every request the
+	// manager makes to a model, e.g. {"qwen3:14b": {"temperature": 0.6,
+	// "num_ctx": 16384}}.
+	ModelOptions map[string]GenOptions `json:"model_options,omitempty"`
+
+	// Favorites are pinned to the top of the model list. Aliases give
+	// models short names to show there, e.g. {"hf.co/bartowski/Qwen2.5-
+	// Coder-32B-Instruct-GGUF:Q4_K_M": "coder"}.
+	Favorites []string          `json:"favorites,omitempty"`
+	Aliases   map[string]string `json:"aliases,omitempty"`
+
+	// Hidden models are left out of the list, as are models matching one of
+	// the Ignore patterns, e.g. "*embed*" or "test-*" (path.Match syntax).
+	Hidden []string `json:"hidden,omitempty"`
+	Ignore []string `json:"ignore,omitempty"`
+
+	// Warmups are named sets of models to have loaded together, each with
+	// an optional keep-alive, e.g. {"name": "coding", "models": [{"name":
+	// "qwen2.5-coder:14b", "keep_alive": "-1"}, {"name": "nomic-embed-text"}]}.
+	// Activating one unloads every other model.
+	Warmups []Warmup `json:"warmups,omitempty"`
+
+	// Schedule loads and unloads models at set times while the manager or
+	// `ollama-manager daemon` runs, e.g. {"cron": "30 8 * * mon-fri",
+	// "action": "warmup", "warmup": "coding"}.
+	Schedule []ScheduleEntry `json:"schedule,omitempty"`
+
+	// AutoUnload, when set, unloads every model once Ollama has had no
+	// requests for a while and another program, such as a game, is using
+	// the GPU heavily.
+	AutoUnload *AutoUnload `json:"auto_unload,omitempty"`
+
+	// Thermal guards a GPU against running hot, as in a small case: past
+	// TempC or PowerW it alerts and, with an Action, acts on it, e.g.
+	// {"temp_c": 83, "power_w": 320, "action": "unload"}.
+	Thermal *Thermal `json:"thermal,omitempty"`
+
+	// Watchdog has `ollama-manager daemon` restart the Ollama service when
+	// it stops answering, e.g. {"interval": "30s", "failures": 3}.
+	Watchdog *Watchdog `json:"watchdog,omitempty"`
+
+	// ReloadOnResume has the manager load the models that were loaded
+	// before the machine slept again after it wakes, if the server dropped
+	// them meanwhile.
+	ReloadOnResume bool `json:"reload_on_resume,omitempty"`
+
+	// Restore is what happens on start to the models that were loaded when
+	// the manager last ran against the same server: "ask" (the default)
+	// offers to load them again, "auto" loads them and "off" leaves them.
+	Restore string `json:"restore,omitempty"`
+
+	// GPUHistory keeps the GPU readings charted in the GPU tab on disk, so
+	// they survive a restart; otherwise only the current session's are.
+	GPUHistory bool `json:"gpu_history,omitempty"`
+
+	// Notify picks how the end of a long operation is announced, by event
+	// ("pull", "bench", "create", "verify", "schedule", "auto_unload",
+	// "thermal" or "watchdog"):
+	// "desktop" (the default), "bell" or "off", e.g. {"bench": "bell"}.
+	Notify map[string]string `json:"notify,omitempty"`
+
+	// Webhooks are POSTed to on events for outside alerting, e.g. {"url":
+	// "https://discord.com/api/webhooks/...", "format": "discord",
+	// "events": ["server_down", "gpu_temp"]}. GPUTempAlert is the
+	// temperature in °C that sends gpu_temp; zero means 85.
+	Webhooks     []Webhook `json:"webhooks,omitempty"`
+	GPUTempAlert int       `json:"gpu_temp_alert,omitempty"`
+
+	// PullConcurrency is how many pulls run at once; zero means 2.
+	// PullRateLimit caps their combined download rate on average, e.g.
+	// "20MB/s"; empty means no cap.
+	PullConcurrency int    `json:"pull_concurrency,omitempty"`
+	PullRateLimit   string `json:"pull_rate_limit,omitempty"`
+
+	// Proxy configures `ollama-manager proxy`, which forwards API traffic to
+	// the server and records who sends it for the Traffic tab.
+	Proxy *Proxy `json:"proxy,omitempty"`
+
+	// Daemon lets TUIs on other machines attach to `ollama-manager daemon`
+	// over TCP, e.g. {"listen": ":11436", "token": "..."}.
+	Daemon *Daemon `json:"daemon,omitempty"`
+
+	// Web configures `ollama-manager web`, the browser dashboard, e.g.
+	// {"listen": ":8080", "token": "..."}. Without a Token, each run makes
+	// one up and prints it in the link to open.
+	Web *Web `json:"web,omitempty"`
+
+	// API configures `ollama-manager api`, the management API for scripts,
+	// e.g. {"listen": "127.0.0.1:11437"}. Token, sent as a bearer token,
+	// is required to listen beyond this machine.
+	API *API `json:"api,omitempty"`
+
+	// Service names the Ollama service for the server panel; empty uses the
+	// platform default ("ollama", "homebrew.mxcl.ollama" or "Ollama"), or a
+	// container found publishing the API port. "docker:NAME" or
+	// "podman:NAME" manages that container.
+	Service string `json:"service,omitempty"`
+
+	// Container is what the server panel recreates the Ollama container
+	// from, e.g. {"volumes": ["/srv/ollama:/root/.ollama"], "env":
+	// {"OLLAMA_FLASH_ATTENTION": "1"}}. It always gets every GPU.
+	Container *Container `json:"container,omitempty"`
+
+	// Theme is "dark", "light" or "high-contrast"; empty or "auto" picks
+	// dark or light from the terminal background. Colors overrides single
+	// colors of it, e.g. {"accent": "#ff8700", "muted": "245"}.
+	Theme  string            `json:"theme,omitempty"`
+	Colors map[string]string `json:"colors,omitempty"`
+
+	// GroupFamilies lists the tags of one model, such as llama3.1:8b and
+	// llama3.1:70b, under a collapsible row with their total size.
+	GroupFamilies bool `json:"group_families,omitempty"`
+
+	// UnusedDays is how long a model goes unused before the unused view
+	// lists it as a candidate for cleanup; 0 means 30.
+	UnusedDays int `json:"unused_days,omitempty"`
+
+	// ColdStore is a folder on a slower drive that models are archived to,
+	// e.g. "E:\\ollama-cold", freeing the models directory while Ollama
+	// still runs them through links.
+	ColdStore string `json:"cold_store,omitempty"`
+
+	// Electricity prices the energy the GPUs draw on the usage screen, e.g.
+	// {"price_per_kwh": 0.32, "currency": "EUR"}.
+	Electricity *Electricity `json:"electricity,omitempty"`
+
+	// DisableMouse leaves the mouse to the terminal, e.g. for selecting
+	// text without holding Shift.
+	DisableMouse bool `json:"disable_mouse,omitempty"`
+
+	path string
+}
+
+// Profile is a named Ollama server and its credentials. With Daemon set,
+// Host is an `ollama-manager daemon` listening on TCP instead, and Token
+// its token.
+type Profile struct {
+	Name     string `json:"name"`
+	Host     string `json:"host"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+	Daemon   bool   `json:"daemon,omitempty"`
+}
+
+// GenOptions are generation parameters, named as Ollama's options. Unset
+// fields keep the model's defaults; pointers tell 0 apart from unset.
+type GenOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	NumCtx      int      `json:"num_ctx,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+}
+
+// IsZero reports whether no parameter is set.
+func (o GenOptions) IsZero() bool {
+	return o == GenOptions{}
+}
+
+// Warmup is a named set of models loaded together.
+type Warmup struct {
+	Name   string        `json:"name"`
+	Models []WarmupModel `json:"models"`
+}
+
+// WarmupModel is one model of a Warmup. KeepAlive overrides the model's
+// usual keep-alive while the set is active.
+type WarmupModel struct {
+	Name      string `json:"name"`
+	KeepAlive string `json:"keep_alive,omitempty"`
+}
+
+// ScheduleEntry is one scheduled action. Action is "load" or "unload" for
+// Models, "unload_all", or "warmup" for the Warmup set of that name.
+type ScheduleEntry struct {
+	Cron   string   `json:"cron"`
+	Action string   `json:"action"`
+	Models []string `json:"models,omitempty"`
+	Warmup string   `json:"warmup,omitempty"`
+}
+
+// AutoUnload configures unloading models for other GPU programs. Zero
+// values pick the defaults: 2048 MiB and 30%.
+type AutoUnload struct {
+	// Idle is how long without requests counts as idle, e.g. "15m".
+	Idle string `json:"idle"`
+
+	// A process other than Ollama counts as GPU-heavy when it holds
+	// MinVRAMMiB of VRAM or, where the driver doesn't report its memory,
+	// keeps its GPU at MinUtilization percent.
+	MinVRAMMiB     int `json:"min_vram_mib,omitempty"`
+	MinUtilization int `json:"min_utilization,omitempty"`
+
+	// Ignore lists further process names never to make room for, besides
+	// desktop compositors such as dwm.exe and Xorg.
+	Ignore []string `json:"ignore,omitempty"`
+}
+
+// Thermal sets the limits a GPU is held to. A zero limit isn't checked.
+// Action is "" to only alert, "unload" to unload the model using the most
+// VRAM, or "reduce_parallel" to halve OLLAMA_NUM_PARALLEL and restart the
+// service.
+type Thermal struct {
+	TempC  int    `json:"temp_c,omitempty"`
+	PowerW int    `json:"power_w,omitempty"`
+	Action string `json:"action,omitempty"`
+}
+
+// Watchdog is how the server's health is checked. Every Interval ("30s"
+// when empty) the daemon asks for the server's version, allowing Timeout
+// ("10s"); after Failures (3) checks in a row fail it restarts the service.
+type Watchdog struct {
+	Interval string `json:"interval,omitempty"`
+	Timeout  string `json:"timeout,omitempty"`
+	Failures int    `json:"failures,omitempty"`
+}
+
+// Proxy configures the reverse proxy. Listen is its address, ":11435" when
+// empty; Upstream is the Ollama server it forwards to, the managed one when
+// empty. Once any Keys exist, every request must carry one as a bearer
+// token. The limits apply to all clients together.
+type Proxy struct {
+	Listen   string     `json:"listen,omitempty"`
+	Upstream string     `json:"upstream,omitempty"`
+	Keys     []ProxyKey `json:"keys,omitempty"`
+	ProxyLimits
+}
+
+// Daemon configures the daemon's TCP listener. Listen is its address; the
+// daemon only listens on TCP when it is set, and Token, which every TUI
+// attaching has to send, must be set with it.
+type Daemon struct {
+	Listen string `json:"listen,omitempty"`
+	Token  string `json:"token,omitempty"`
+}
+
+// Container is the template for `docker run` or `podman run`. Empty fields
+// take the defaults of Ollama's instructions: the ollama/ollama image, port
+// 11434 and a named volume for models.
+type Container struct {
+	Image   string            `json:"image,omitempty"`
+	Ports   []string          `json:"ports,omitempty"`   // as for -p, e.g. "11434:11434"
+	Volumes []string          `json:"volumes,omitempty"` // as for -v, e.g. "ollama:/root/.ollama"
+	Env     map[string]string `json:"env,omitempty"`
+	Args    []string          `json:"args,omitempty"` // further flags for run
+}
+
+// Electricity is what a kWh costs. Currency is only a label, printed after
+// the amount.
+type Electricity struct {
+	PricePerKWh float64 `json:"price_per_kwh"`
+	Currency    string  `json:"currency,omitempty"`
+}
+
+// Web configures the browser dashboard. Listen is its address, ":8080"
+// when empty.
+type Web struct {
+	Listen string `json:"listen,omitempty"`
+	Token  string `json:"token,omitempty"`
+}
+
+// API configures the management API. Listen is its address,
+// "127.0.0.1:11437" when empty.
+type API struct {
+	Listen string `json:"listen,omitempty"`
+	Token  string `json:"token,omitempty"`
+}
+
+// ProxyLimits caps the requests through the proxy. RequestsPerMinute counts
+// every request; MaxConcurrent only generations (chat, completion and
+// embedding requests) in flight. Zero means no limit.
+type ProxyLimits struct {
+	RequestsPerMinute int `json:"requests_per_minute,omitempty"`
+	MaxConcurrent     int `json:"max_concurrent,omitempty"`
+}
+
+// ProxyKey is an API key for the proxy. Only a hash of the token is kept,
+// and Hint, its last characters, to tell keys apart. Models limits the key
+// to models matching one of the patterns, e.g. "qwen3:*"; empty allows all.
+type ProxyKey struct {
+	Name    string    `json:"name"`
+	Hash    string    `json:"hash"`
+	Hint    string    `json:"hint,omitempty"`
+	Models  []string  `json:"models,omitempty"`
+	Created time.Time `json:"created"`
+	ProxyLimits
+}
+
+// Webhook is an endpoint told about events. Format is "json" (the
+// default), "discord" or "slack"; Events picks which of "load", "pull",
+// "gpu_temp", "thermal", "server_down", "server_up" and "watchdog" are
+// sent, all when empty.
+type Webhook struct {
+	URL    string   `json:"url"`
+	Format string   `json:"format,omitempty"`
+	Events []string `json:"events,omitempty"`
+}
+
+// Wants reports whether event is sent to the webhook.
+func (w Webhook) Wants(event string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, event)
+}
+
+// Dir returns the directory holding the config file.
+func Dir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "ollama-manager"), nil
+}
+
+// DataDir returns the directory for state the manager records, such as
+// benchmark history: %LOCALAPPDATA% on Windows, ~/Library/Application Support
+// on macOS and $XDG_DATA_HOME (default ~/.local/share) elsewhere.
+func DataDir() (string, error) {
+	var base string
+	switch runtime.GOOS {
+	case "windows":
+		base = os.Getenv("LOCALAPPDATA")
+	case "darwin":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		base = dir
+	default:
+		base = os.Getenv("XDG_DATA_HOME")
+		if base == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			base = filepath.Join(home, ".local", "share")
+		}
+	}
+	if base == "" {
+		return Dir()
+	}
+	return filepath.Join(base, "ollama-manager"), nil
+}
+
+// Load reads the config file, returning an empty config if none exists yet.
+func Load() (*Config, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	return LoadFile(filepath.Join(dir, "config.json"))
+}
+
+// LoadFile reads the config at path, returning an empty config if the file
+// doesn't exist. Save writes back to the same path.
+func LoadFile(path string) (*Config, error) {
+	cfg := &Config{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Path returns the file the config was loaded from.
+func (c *Config) Path() string {
+	return c.path
+}
+
+// Save writes the config atomically, creating its directory if needed.
+func (c *Config) Save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// KeepAliveFor returns the keep-alive setting for a model, or "" to let the
+// server apply its default.
+func (c *Config) KeepAliveFor(model string) string {
+	if v, ok := c.ModelKeepAlive[model]; ok {
+		return v
+	}
+	return c.KeepAlive
+}
+
+// SetModelKeepAlive sets or, with an empty value, clears a model override.
+func (c *Config) SetModelKeepAlive(model, value string) {
+	if value == "" {
+		delete(c.ModelKeepAlive, model)
+		return
+	}
+	if c.ModelKeepAlive == nil {
+		c.ModelKeepAlive = make(map[string]string)
+	}
+	c.ModelKeepAlive[model] = value
+}
+
+// SetModelOptions sets or, when none are set, clears a model's generation
+// parameters.
+func (c *Config) SetModelOptions(model string, o GenOptions) {
+	if o.IsZero() {
+		delete(c.ModelOptions, model)
+		return
+	}
+	if c.ModelOptions == nil {
+		c.ModelOptions = make(map[string]GenOptions)
+	}
+	c.ModelOptions[model] = o
+}
+
+// IsFavorite reports whether model is pinned to the top of the list.
+func (c *Config) IsFavorite(model string) bool {
+	return slices.Contains(c.Favorites, model)
+}
+
+// ToggleFavorite pins or unpins model and reports whether it is now a
+// favorite.
+func (c *Config) ToggleFavorite(model string) bool {
+	if i := slices.Index(c.Favorites, model); i >= 0 {
+		c.Favorites = slices.Delete(c.Favorites, i, i+1)
+		return false
+	}
+	c.Favorites = append(c.Favorites, model)
+	return true
+}
+
+// SetAlias sets or, with an empty alias, clears the short name of a model.
+func (c *Config) SetAlias(model, alias string) {
+	if alias == "" {
+		delete(c.Aliases, model)
+		return
+	}
+	if c.Aliases == nil {
+		c.Aliases = make(map[string]string)
+	}
+	c.Aliases[model] = alias
+}
+
+// Warmup returns the warm-up set called name.
+func (c *Config) Warmup(name string) (Warmup, bool) {
+	for _, w := range c.Warmups {
+		if w.Name == name {
+			return w, true
+		}
+	}
+	return Warmup{}, false
+}
+
+// NotifyFor returns how event is announced.
+func (c *Config) NotifyFor(event string) string {
+	if v, ok := c.Notify[event]; ok {
+		return v
+	}
+	return "desktop"
+}
+
+// IgnoredBy returns the first Ignore pattern matching model, or "". Since *
+// doesn't match "/", patterns are also tried on the last part of names such
+// as "hf.co/user/repo:tag".
+func (c *Config) IgnoredBy(model string) string {
+	base := path.Base(model)
+	for _, p := range c.Ignore {
+		if ok, _ := path.Match(p, model); ok {
+			return p
+		}
+		if ok, _ := path.Match(p, base); ok {
+			return p
+		}
+	}
+	return ""
+}
+
+// IsHidden reports whether model is left out of the list.
+func (c *Config) IsHidden(model string) bool {
+	return slices.Contains(c.Hidden, model) || c.IgnoredBy(model) != ""
+}
+
+// ToggleHidden hides or unhides model and reports whether it is now in
+// Hidden. Models matched by an Ignore pattern stay hidden either way.
+func (c *Config) ToggleHidden(model string) bool {
+	if i := slices.Index(c.Hidden, model); i >= 0 {
+		c.Hidden = slices.Delete(c.Hidden, i, i+1)
+		return false
+	}
+	c.Hidden = append(c.Hidden, model)
+	return true
+}
+
+// CheckIgnore reports the first malformed Ignore pattern.
+func (c *Config) CheckIgnore() error {
+	for _, p := range c.Ignore {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("ignore: bad pattern %q", p)
+		}
+	}
+	return nil
+}
+
+// ProxyKeys returns the proxy's API keys.
+func (c *Config) ProxyKeys() []ProxyKey {
+	if c.Proxy == nil {
+		return nil
+	}
+	return c.Proxy.Keys
+}
+
+// AddProxyKey adds k, reporting an error if its name is taken.
+func (c *Config) AddProxyKey(k ProxyKey) error {
+	for _, have := range c.ProxyKeys() {
+		if have.Name == k.Name {
+			return fmt.Errorf("an API key named %q already exists", k.Name)
+		}
+	}
+	if c.Proxy == nil {
+		c.Proxy = &Proxy{}
+	}
+	c.Proxy.Keys = append(c.Proxy.Keys, k)
+	return nil
+}
+
+// RemoveProxyKey revokes the key called name.
+func (c *Config) RemoveProxyKey(name string) {
+	if c.Proxy == nil {
+		return
+	}
+	c.Proxy.Keys = slices.DeleteFunc(c.Proxy.Keys, func(k ProxyKey) bool { return k.Name == name })
+}
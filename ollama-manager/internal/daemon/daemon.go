@@ -0,0 +1,233 @@
+// Package daemon connects the TUI to a running `ollama-manager daemon`. The
+// daemon serves its state over HTTP on a unix socket in the data directory,
+// which Windows 10 and later support as well, and the TUI reads it there
+// rather than keeping state of its own that closing the terminal would
+// lose. A daemon may also listen on TCP for TUIs on other machines, which
+// then reach its Ollama server through it too.
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/gpu"
+)
+
+// socketFile is the daemon's socket, under the data dir.
+const socketFile = "daemon.sock"
+
+// The daemon's own paths sit apart from Ollama's /api, which a daemon on TCP
+// forwards.
+const (
+	statusPath  = "/ollama-manager/status"
+	historyPath = "/ollama-manager/gpu-history"
+	gpusPath    = "/ollama-manager/gpus"
+)
+
+// SocketPath returns where the daemon listens.
+func SocketPath() (string, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, socketFile), nil
+}
+
+// Job is a scheduled action and how it last went.
+type Job struct {
+	Name string    `json:"name"`
+	Spec string    `json:"spec"`
+	Next time.Time `json:"next,omitempty"`
+	Last time.Time `json:"last,omitempty"`
+	Err  string    `json:"err,omitempty"` // of the last run
+}
+
+// Status is what the daemon is doing. The watcher fields describe a
+// watcher that is on, and are empty when it is off.
+type Status struct {
+	PID        int       `json:"pid"`
+	Started    time.Time `json:"started"`
+	Host       string    `json:"host"`
+	Jobs       []Job     `json:"jobs,omitempty"`
+	AutoUnload string    `json:"auto_unload,omitempty"`
+	Thermal    string    `json:"thermal,omitempty"`
+	Watchdog   string    `json:"watchdog,omitempty"`
+	Webhooks   int       `json:"webhooks,omitempty"`
+	Proxy      string    `json:"proxy,omitempty"`  // listen address
+	Listen     string    `json:"listen,omitempty"` // TCP address for remote TUIs
+}
+
+// Source is the state the daemon serves.
+type Source interface {
+	Status() Status
+	Samples() []gpu.Sample
+	GPUs() ([]gpu.Device, error)
+}
+
+// Remote lets TUIs on other machines in. Every request must carry Token as
+// a bearer token; those outside the daemon's own paths go to Upstream, the
+// Ollama server the daemon manages.
+type Remote struct {
+	Token    string
+	Upstream *url.URL
+}
+
+// Listen opens the socket at path. A socket left behind by a daemon that
+// didn't exit cleanly is replaced; one that still answers means a daemon is
+// running.
+func Listen(path string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a daemon is already running on %s", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return net.Listen("unix", path)
+}
+
+// Serve answers requests on ln until ctx is done. remote is nil for the
+// local socket.
+func Serve(ctx context.Context, ln net.Listener, src Source, remote *Remote) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc(statusPath, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, src.Status())
+	})
+	mux.HandleFunc(historyPath, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, src.Samples())
+	})
+	mux.HandleFunc(gpusPath, func(w http.ResponseWriter, r *http.Request) {
+		devices, err := src.GPUs()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, devices)
+	})
+	var h http.Handler = mux
+	if remote != nil {
+		mux.Handle("/", &httputil.ReverseProxy{
+			// SetURL also sends the upstream's own Host, which Ollama
+			// checks when it listens on localhost.
+			Rewrite: func(pr *httputil.ProxyRequest) { pr.SetURL(remote.Upstream) },
+			// Stream generations and pull progress as they come.
+			FlushInterval: -1,
+		})
+		h = remote.authorize(mux)
+	}
+	srv := &http.Server{Handler: h, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// authorize turns away requests without the token, and keeps it from the
+// server behind.
+func (rm *Remote) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(rm.Token)) != 1 {
+			http.Error(w, "missing or wrong daemon token", http.StatusUnauthorized)
+			return
+		}
+		r.Header.Del("Authorization")
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// Client talks to the daemon.
+type Client struct {
+	http  *http.Client
+	base  string
+	token string
+}
+
+// Dial returns a client for the daemon listening on the socket at path.
+// It doesn't connect until asked for something.
+func Dial(path string) *Client {
+	return &Client{base: "http://daemon", http: &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}}
+}
+
+// Connect returns a client for a daemon listening on TCP, at a base URL as
+// returned by ollama.ParseHost.
+func Connect(base, token string) *Client {
+	return &Client{base: strings.TrimSuffix(base, "/"), token: token, http: http.DefaultClient}
+}
+
+// Status asks the daemon what it is doing.
+func (c *Client) Status(ctx context.Context) (Status, error) {
+	var st Status
+	err := c.get(ctx, statusPath, &st)
+	return st, err
+}
+
+// Samples returns the GPU readings the daemon has recorded, oldest first
+// per GPU.
+func (c *Client) Samples(ctx context.Context) ([]gpu.Sample, error) {
+	var samples []gpu.Sample
+	err := c.get(ctx, historyPath, &samples)
+	return samples, err
+}
+
+// GPUs reads the GPUs of the daemon's machine.
+func (c *Client) GPUs(ctx context.Context) ([]gpu.Device, error) {
+	var devices []gpu.Device
+	err := c.get(ctx, gpusPath, &devices)
+	return devices, err
+}
+
+func (c *Client) get(ctx context.Context, path string, v any) error {
+	// Over the socket the host is ignored; the transport always dials it.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base+path, nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if len(bytes.TrimSpace(msg)) == 0 {
+			return fmt.Errorf("daemon: %s", resp.Status)
+		}
+		return fmt.Errorf("daemon: %s", bytes.TrimSpace(msg))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
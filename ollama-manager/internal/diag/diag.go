@@ -0,0 +1,342 @@
+// Package diag checks that the NVIDIA driver, CUDA and Ollama fit together
+// and that Ollama is actually using the GPU.
+package diag
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ollama-manager/internal/gpu"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/service"
+	"ollama-manager/internal/wsl"
+)
+
+const (
+	// MinDriver is the oldest NVIDIA driver branch Ollama's CUDA runtime
+	// supports, and MinCompute the oldest GPU architecture (Maxwell).
+	MinDriver  = 531
+	MinCompute = 5.0
+
+	// logLines is how much of the server log is searched for GPU discovery.
+	logLines = 2000
+)
+
+// Status grades a finding.
+type Status int
+
+const (
+	OK Status = iota
+	Warn
+	Fail
+)
+
+func (s Status) String() string {
+	switch s {
+	case Warn:
+		return "warn"
+	case Fail:
+		return "fail"
+	}
+	return "ok"
+}
+
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Finding is the outcome of one check.
+type Finding struct {
+	Status Status `json:"status"`
+	Check  string `json:"check"`
+	Detail string `json:"detail"`
+}
+
+// GPU is one card as the driver reports it.
+type GPU struct {
+	Index   int    `json:"index"`
+	Name    string `json:"name"`
+	Compute string `json:"compute_capability,omitempty"`
+	VRAM    uint64 `json:"vram"`
+}
+
+// Report collects what was found and how it was judged.
+type Report struct {
+	Driver   string    `json:"driver,omitempty"`
+	CUDA     string    `json:"cuda,omitempty"` // newest CUDA version the driver supports
+	GPUs     []GPU     `json:"gpus"`
+	Ollama   string    `json:"ollama_version,omitempty"`
+	Seen     []string  `json:"ollama_gpus,omitempty"` // GPUs Ollama reported discovering
+	Findings []Finding `json:"findings"`
+}
+
+func (r *Report) add(s Status, check, format string, args ...any) {
+	r.Findings = append(r.Findings, Finding{s, check, fmt.Sprintf(format, args...)})
+}
+
+// Worst is the most severe status among the findings.
+func (r *Report) Worst() Status {
+	worst := OK
+	for _, f := range r.Findings {
+		worst = max(worst, f.Status)
+	}
+	return worst
+}
+
+// Run checks the local GPUs and the Ollama server behind c. serviceName is
+// used to find the server log when c is local.
+func Run(ctx context.Context, c *ollama.Client, serviceName string) *Report {
+	r := &Report{GPUs: []GPU{}}
+	r.CheckSystem()
+	r.CheckOllama(ctx, c, serviceName)
+	if c.Local() {
+		r.CheckWSL(ctx, c)
+	}
+	return r
+}
+
+// CheckWSL warns when Ollama runs both natively on Windows and inside
+// WSL2, where only one of them can have the local port.
+func (r *Report) CheckWSL(ctx context.Context, c *ollama.Client) {
+	port := "11434"
+	if u, err := url.Parse(c.Host()); err == nil && u.Port() != "" {
+		port = u.Port()
+	}
+	w, err := wsl.Detect(ctx, port)
+	if err != nil || w == nil || len(w.Running()) == 0 {
+		return
+	}
+	var names []string
+	for _, d := range w.Running() {
+		names = append(names, d.Name)
+	}
+	if w.Conflict() {
+		r.add(Warn, "wsl", "Ollama runs both natively (pid %d) and under WSL (%s); port %s reaches only the native one", w.NativePID, strings.Join(names, ", "), port)
+		return
+	}
+	r.add(OK, "wsl", "Ollama runs under WSL (%s)", strings.Join(names, ", "))
+}
+
+// CheckSystem reads the driver, its CUDA version and each GPU's compute
+// capability.
+func (r *Report) CheckSystem() {
+	devices, err := gpu.Query()
+	if err != nil || len(devices) == 0 {
+		detail := "no NVIDIA GPU found; Ollama will run models on the CPU"
+		if err != nil {
+			detail += " (" + err.Error() + ")"
+		}
+		r.add(Fail, "gpu", "%s", detail)
+		return
+	}
+	caps, _ := gpu.ComputeCaps()
+	r.CUDA, _ = gpu.CUDAVersion()
+	r.Driver = devices[0].Driver
+
+	for _, d := range devices {
+		g := GPU{Index: d.Index, Name: d.Name, VRAM: d.MemoryTotal}
+		if d.Index < len(caps) {
+			g.Compute = caps[d.Index]
+		}
+		r.GPUs = append(r.GPUs, g)
+		switch v, err := strconv.ParseFloat(g.Compute, 64); {
+		case err != nil:
+			r.add(Warn, "gpu", "GPU %d %s: compute capability unknown", d.Index, d.Name)
+		case v < MinCompute:
+			r.add(Fail, "gpu", "GPU %d %s: compute capability %s is below %.1f; Ollama can't use it", d.Index, d.Name, g.Compute, MinCompute)
+		default:
+			r.add(OK, "gpu", "GPU %d %s: compute capability %s", d.Index, d.Name, g.Compute)
+		}
+	}
+
+	major := driverMajor(r.Driver)
+	switch {
+	case major == 0:
+		r.add(Warn, "driver", "driver version unknown")
+	case major < MinDriver:
+		r.add(Fail, "driver", "driver %s is older than %d, the oldest Ollama supports; update it from nvidia.com/drivers", r.Driver, MinDriver)
+	default:
+		r.add(OK, "driver", "driver %s, CUDA %s", r.Driver, firstNonEmpty(r.CUDA, "unknown"))
+	}
+	for _, iss := range issues {
+		if major > 0 && major < iss.driver && r.affects(iss) {
+			r.add(Fail, "driver", "%s need driver %d or newer; this one is %s", iss.gpus, iss.driver, r.Driver)
+		}
+	}
+}
+
+// issue is a known-bad combination: GPUs of an architecture that need a
+// newer driver or Ollama than the general minimum.
+type issue struct {
+	compute float64 // first affected compute capability
+	gpus    string  // how to name the affected cards
+	driver  int     // oldest working driver branch, or 0
+	ollama  string  // oldest working Ollama release, or ""
+}
+
+var issues = []issue{
+	// Blackwell needs CUDA 12.8, which Ollama started bundling in 0.5.13.
+	{compute: 12.0, gpus: "RTX 50-series (Blackwell) cards", driver: 570, ollama: "0.5.13"},
+}
+
+// affects reports whether any GPU falls under iss.
+func (r *Report) affects(iss issue) bool {
+	for _, g := range r.GPUs {
+		if v, err := strconv.ParseFloat(g.Compute, 64); err == nil && v >= iss.compute {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOllama asks the server for its version and whether it found the GPU:
+// from its log when it runs on this machine, or else from where loaded
+// models ended up.
+func (r *Report) CheckOllama(ctx context.Context, c *ollama.Client, serviceName string) {
+	version, err := c.Version(ctx)
+	if err != nil {
+		r.add(Fail, "ollama", "not reachable at %s: %v", c.Host(), err)
+		return
+	}
+	r.Ollama = version
+	r.add(OK, "ollama", "Ollama %s at %s", version, c.Host())
+	for _, iss := range issues {
+		if iss.ollama != "" && r.affects(iss) && VersionLess(version, iss.ollama) {
+			r.add(Fail, "ollama", "%s need Ollama %s or newer; update Ollama", iss.gpus, iss.ollama)
+		}
+	}
+
+	if c.Local() {
+		if log, err := service.Log(ctx, serviceName, logLines); err == nil {
+			if seen, ok := discovered(log); ok {
+				r.Seen = seen
+				if len(seen) == 0 {
+					r.add(Fail, "ollama-gpu", "Ollama found no usable GPU at startup and runs models on the CPU")
+				} else {
+					r.add(OK, "ollama-gpu", "Ollama is using %s", strings.Join(seen, ", "))
+				}
+				return
+			}
+		}
+	}
+
+	running, err := c.Running(ctx)
+	if err != nil {
+		r.add(Warn, "ollama-gpu", "could not list loaded models: %v", err)
+		return
+	}
+	if len(running) == 0 {
+		if len(r.GPUs) > 0 {
+			r.add(Warn, "ollama-gpu", "unknown: no server log available and no model loaded; load one and check again")
+		}
+		return
+	}
+	for _, m := range running {
+		if m.SizeVRAM == 0 {
+			r.add(Fail, "ollama-gpu", "%s is loaded entirely on the CPU", m.Name)
+			continue
+		}
+		r.add(OK, "ollama-gpu", "%s is loaded %s", m.Name, m.Processor())
+	}
+}
+
+var reLogField = regexp.MustCompile(`(\w+)=("(?:[^"\\]|\\.)*"|\S+)`)
+
+// discovered scans a server log for the GPUs found at the last startup,
+// from lines such as
+//
+//	msg="inference compute" id=GPU-1a2b library=cuda compute=8.9 driver=12.4 name="NVIDIA GeForce RTX 4090" total="23.6 GiB"
+//
+// It reports false if the log doesn't cover a startup.
+func discovered(log string) ([]string, bool) {
+	lines := strings.Split(log, "\n")
+	// Only the lines after the last startup describe the running server.
+	end := len(lines)
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.Contains(lines[i], `msg="inference compute"`) || strings.Contains(lines[i], "no compatible GPUs were discovered") {
+			end = i
+			break
+		}
+	}
+	if end == len(lines) {
+		return nil, false
+	}
+	start := end
+	for start > 0 && strings.Contains(lines[start-1], `msg="inference compute"`) {
+		start--
+	}
+
+	seen := []string{}
+	for _, line := range lines[start : end+1] {
+		f := logFields(line)
+		if f["library"] == "" || f["library"] == "cpu" {
+			continue
+		}
+		name := firstNonEmpty(f["name"], f["id"])
+		if f["compute"] != "" {
+			name += " (" + f["library"] + ", compute " + f["compute"] + ")"
+		}
+		seen = append(seen, name)
+	}
+	return seen, true
+}
+
+func logFields(line string) map[string]string {
+	f := make(map[string]string)
+	for _, m := range reLogField.FindAllStringSubmatch(line, -1) {
+		if v, err := strconv.Unquote(m[2]); err == nil {
+			m[2] = v
+		}
+		f[m[1]] = m[2]
+	}
+	return f
+}
+
+func driverMajor(v string) int {
+	major, _, _ := strings.Cut(v, ".")
+	n, _ := strconv.Atoi(major)
+	return n
+}
+
+// VersionLess compares dotted release versions such as "0.5.7", ignoring
+// suffixes like "-rc1".
+func VersionLess(a, b string) bool {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			return x < y
+		}
+	}
+	return false
+}
+
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "-")
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		n, _ := strconv.Atoi(s)
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+// Package fuzzy implements fzf-style subsequence matching for filtering
+// short strings such as model names.
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	scoreMatch       = 16
+	bonusConsecutive = 24
+	bonusBoundary    = 32
+	bonusFirstChar   = 16
+	penaltyGap       = 1
+)
+
+// Match reports whether every rune of pattern appears in text in order
+// (case-insensitively) and scores the match. Higher scores mean tighter
+// matches: consecutive runs and matches at word boundaries ("llama3:8b"
+// matching "l3" on "llama" and "3") rank above scattered ones. An empty
+// pattern matches everything with score 0.
+func Match(pattern, text string) (score int, ok bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(text)
+
+	pi := 0
+	prev := -2
+	for ti := 0; ti < len(t) && pi < len(p); ti++ {
+		if unicode.ToLower(t[ti]) != p[pi] {
+			continue
+		}
+		score += scoreMatch
+		switch {
+		case ti == 0:
+			score += bonusFirstChar + bonusBoundary
+		case isBoundary(t[ti-1], t[ti]):
+			score += bonusBoundary
+		}
+		if ti == prev+1 {
+			score += bonusConsecutive
+		} else if prev >= 0 {
+			score -= penaltyGap * (ti - prev - 1)
+		}
+		prev = ti
+		pi++
+	}
+	if pi < len(p) {
+		return 0, false
+	}
+	return score, true
+}
+
+// isBoundary reports whether cur starts a new "word" after prev.
+func isBoundary(prev, cur rune) bool {
+	switch {
+	case !unicode.IsLetter(prev) && !unicode.IsDigit(prev):
+		return true
+	case unicode.IsLower(prev) && unicode.IsUpper(cur):
+		return true
+	case unicode.IsLetter(prev) && unicode.IsDigit(cur):
+		return true
+	}
+	return false
+}
+
+// Filter returns the indexes of items whose text (as returned by key)
+// matches pattern, best match first. Ties keep their original order.
+func Filter(pattern string, n int, key func(i int) string) []int {
+	type hit struct{ idx, score int }
+	var hits []hit
+	for i := 0; i < n; i++ {
+		if score, ok := Match(pattern, key(i)); ok {
+			hits = append(hits, hit{i, score})
+		}
+	}
+	if pattern != "" {
+		sort.SliceStable(hits, func(a, b int) bool { return hits[a].score > hits[b].score })
+	}
+	out := make([]int, len(hits))
+	for i, h := range hits {
+		out[i] = h.idx
+	}
+	return out
+}
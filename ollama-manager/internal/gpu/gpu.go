@@ -0,0 +1,60 @@
+// Package gpu reports NVIDIA GPU memory, utilization and temperature, and the
+// processes using each GPU.
+//
+// Readings come from NVML when the binary is built with the "nvml" tag (which
+// requires cgo) and from parsing nvidia-smi otherwise, or when NVML fails.
+package gpu
+
+// Device is a point-in-time reading for one GPU.
+type Device struct {
+	Index       int
+	Name        string
+	UUID        string
+	MemoryTotal uint64 // bytes
+	MemoryUsed  uint64 // bytes
+	Utilization int    // percent
+	Temperature int    // degrees Celsius
+	Power       int    // watts drawn; 0 if the card doesn't report it
+	Driver      string // NVIDIA driver version
+}
+
+// MemoryFree returns the unused VRAM in bytes.
+func (d Device) MemoryFree() uint64 {
+	if d.MemoryUsed > d.MemoryTotal {
+		return 0
+	}
+	return d.MemoryTotal - d.MemoryUsed
+}
+
+// Query reads every visible GPU, preferring NVML over nvidia-smi.
+func Query() ([]Device, error) {
+	if devices, err := queryNVML(); err == nil {
+		return devices, nil
+	}
+	return querySMI()
+}
+
+// Reinit drops the NVML handles so the next reading opens them again, as
+// needed after the machine wakes from sleep, when the old ones may be stale.
+func Reinit() {
+	resetNVML()
+}
+
+// ComputeCaps returns the CUDA compute capability of every visible GPU, such
+// as "8.9", in index order. It is queried separately from Query because
+// nvidia-smi only reports it from driver 510 on.
+func ComputeCaps() ([]string, error) {
+	if caps, err := computeCapsNVML(); err == nil {
+		return caps, nil
+	}
+	return computeCapsSMI()
+}
+
+// CUDAVersion returns the newest CUDA version the installed driver supports,
+// such as "12.4".
+func CUDAVersion() (string, error) {
+	if v, err := cudaVersionNVML(); err == nil {
+		return v, nil
+	}
+	return cudaVersionSMI()
+}
@@ -0,0 +1,152 @@
+package gpu
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Sample is one reading of a GPU, kept to chart it over time.
+type Sample struct {
+	Time        time.Time `json:"time"`
+	GPU         int       `json:"gpu"`
+	Utilization int       `json:"util"`
+	MemoryUsed  uint64    `json:"mem"`
+	Temperature int       `json:"temp"`
+	Power       int       `json:"power,omitempty"`
+}
+
+// History keeps the latest samples of each GPU in memory, oldest first.
+// When opened with a path it also appends them to that file as JSON lines,
+// so the record outlives the process.
+type History struct {
+	size    int
+	path    string
+	samples map[int][]Sample // by GPU index
+}
+
+// NewHistory keeps up to size samples per GPU in memory only.
+func NewHistory(size int) *History {
+	return &History{size: size, samples: make(map[int][]Sample)}
+}
+
+// OpenHistory loads the newest size samples per GPU from the file at path
+// and rewrites it with only those, which keeps it from growing without
+// bound. A missing file is empty.
+func OpenHistory(path string, size int) (*History, error) {
+	h := NewHistory(size)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		var s Sample
+		if err := json.Unmarshal(sc.Bytes(), &s); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		h.add(s)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, samples := range h.samples {
+		for _, s := range samples {
+			if err := enc.Encode(s); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, err
+	}
+	h.path = path
+	return h, nil
+}
+
+func (h *History) add(s Sample) {
+	samples := append(h.samples[s.GPU], s)
+	if len(samples) > h.size {
+		samples = samples[len(samples)-h.size:]
+	}
+	h.samples[s.GPU] = samples
+}
+
+// Add records a reading of every device taken at t. A failed write to the
+// file stops further writes, so it is reported once; the samples are kept
+// in memory either way.
+func (h *History) Add(t time.Time, devices []Device) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, d := range devices {
+		s := Sample{Time: t, GPU: d.Index, Utilization: d.Utilization, MemoryUsed: d.MemoryUsed, Temperature: d.Temperature, Power: d.Power}
+		h.add(s)
+		if err := enc.Encode(s); err != nil {
+			return err
+		}
+	}
+	if h.path == "" || len(devices) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err == nil {
+		_, err = f.Write(buf.Bytes())
+		err = errors.Join(err, f.Close())
+	}
+	if err != nil {
+		h.path = ""
+	}
+	return err
+}
+
+// Samples returns the recorded samples of the GPU with the given index,
+// oldest first.
+func (h *History) Samples(index int) []Sample {
+	return h.samples[index]
+}
+
+// Seed puts samples recorded elsewhere, such as by the daemon, before the
+// ones in memory, dropping those of these that they overlap.
+func (h *History) Seed(samples []Sample) {
+	own := h.samples
+	h.samples = make(map[int][]Sample)
+	last := make(map[int]time.Time) // by GPU index
+	for _, s := range samples {
+		h.add(s)
+		last[s.GPU] = s.Time
+	}
+	for i, ss := range own {
+		for _, s := range ss {
+			if s.Time.After(last[i]) {
+				h.add(s)
+			}
+		}
+	}
+}
+
+// All returns every recorded sample, by GPU index and oldest first per GPU.
+func (h *History) All() []Sample {
+	indexes := make([]int, 0, len(h.samples))
+	for i := range h.samples {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	var all []Sample
+	for _, i := range indexes {
+		all = append(all, h.samples[i]...)
+	}
+	return all
+}
@@ -0,0 +1,164 @@
+//go:build nvml
+
+package gpu
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+// NVML is initialised on first use and again after resetNVML.
+var (
+	nvmlMu     sync.Mutex
+	nvmlInited bool
+	nvmlErr    error
+)
+
+func initNVML() error {
+	nvmlMu.Lock()
+	defer nvmlMu.Unlock()
+	if !nvmlInited {
+		nvmlInited = true
+		nvmlErr = nil
+		if ret := nvml.Init(); ret != nvml.SUCCESS {
+			nvmlErr = fmt.Errorf("nvml init: %s", nvml.ErrorString(ret))
+		}
+	}
+	return nvmlErr
+}
+
+func resetNVML() {
+	nvmlMu.Lock()
+	defer nvmlMu.Unlock()
+	if nvmlInited && nvmlErr == nil {
+		nvml.Shutdown()
+	}
+	nvmlInited = false
+}
+
+func queryNVML() ([]Device, error) {
+	if err := initNVML(); err != nil {
+		return nil, err
+	}
+
+	count, ret := nvml.DeviceGetCount()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("nvml device count: %s", nvml.ErrorString(ret))
+	}
+
+	driver, _ := nvml.SystemGetDriverVersion()
+
+	devices := make([]Device, 0, count)
+	for i := 0; i < count; i++ {
+		h, ret := nvml.DeviceGetHandleByIndex(i)
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("nvml device %d: %s", i, nvml.ErrorString(ret))
+		}
+
+		// Individual readings are best-effort; consumer cards don't
+		// support every query.
+		d := Device{Index: i, Driver: driver}
+		d.Name, _ = h.GetName()
+		d.UUID, _ = h.GetUUID()
+		if mem, ret := h.GetMemoryInfo(); ret == nvml.SUCCESS {
+			d.MemoryTotal = mem.Total
+			d.MemoryUsed = mem.Used
+		}
+		if util, ret := h.GetUtilizationRates(); ret == nvml.SUCCESS {
+			d.Utilization = int(util.Gpu)
+		}
+		if temp, ret := h.GetTemperature(nvml.TEMPERATURE_GPU); ret == nvml.SUCCESS {
+			d.Temperature = int(temp)
+		}
+		if mw, ret := h.GetPowerUsage(); ret == nvml.SUCCESS {
+			d.Power = int(mw / 1000)
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+func computeCapsNVML() ([]string, error) {
+	if err := initNVML(); err != nil {
+		return nil, err
+	}
+	count, ret := nvml.DeviceGetCount()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("nvml device count: %s", nvml.ErrorString(ret))
+	}
+	caps := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		h, ret := nvml.DeviceGetHandleByIndex(i)
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("nvml device %d: %s", i, nvml.ErrorString(ret))
+		}
+		major, minor, ret := h.GetCudaComputeCapability()
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("nvml compute capability %d: %s", i, nvml.ErrorString(ret))
+		}
+		caps = append(caps, fmt.Sprintf("%d.%d", major, minor))
+	}
+	return caps, nil
+}
+
+func cudaVersionNVML() (string, error) {
+	if err := initNVML(); err != nil {
+		return "", err
+	}
+	v, ret := nvml.SystemGetCudaDriverVersion()
+	if ret != nvml.SUCCESS {
+		return "", fmt.Errorf("nvml cuda version: %s", nvml.ErrorString(ret))
+	}
+	return fmt.Sprintf("%d.%d", v/1000, v%1000/10), nil
+}
+
+func processesNVML() ([]Process, error) {
+	if err := initNVML(); err != nil {
+		return nil, err
+	}
+	count, ret := nvml.DeviceGetCount()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("nvml device count: %s", nvml.ErrorString(ret))
+	}
+
+	var procs []Process
+	for i := 0; i < count; i++ {
+		h, ret := nvml.DeviceGetHandleByIndex(i)
+		if ret != nvml.SUCCESS {
+			return nil, fmt.Errorf("nvml device %d: %s", i, nvml.ErrorString(ret))
+		}
+		// A process using both APIs shows up in both lists.
+		seen := make(map[uint32]int)
+		lists := []struct {
+			typ string
+			get func() ([]nvml.ProcessInfo, nvml.Return)
+		}{
+			{"C", h.GetComputeRunningProcesses},
+			{"G", h.GetGraphicsRunningProcesses},
+		}
+		for _, l := range lists {
+			infos, ret := l.get()
+			if ret != nvml.SUCCESS {
+				continue
+			}
+			for _, info := range infos {
+				mem := info.UsedGpuMemory
+				if mem == math.MaxUint64 {
+					mem = 0 // NVML_VALUE_NOT_AVAILABLE
+				}
+				if j, ok := seen[info.Pid]; ok {
+					procs[j].Type = "C+G"
+					procs[j].Memory = max(procs[j].Memory, mem)
+					continue
+				}
+				name, _ := nvml.SystemGetProcessName(int(info.Pid))
+				seen[info.Pid] = len(procs)
+				procs = append(procs, Process{PID: int(info.Pid), Name: name, GPU: i, Type: l.typ, Memory: mem})
+			}
+		}
+	}
+	return procs, nil
+}
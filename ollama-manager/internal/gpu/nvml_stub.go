@@ -0,0 +1,23 @@
+//go:build !nvml
+
+package gpu
+
+import "errors"
+
+func resetNVML() {}
+
+func queryNVML() ([]Device, error) {
+	return nil, errors.New("built without nvml support")
+}
+
+func processesNVML() ([]Process, error) {
+	return nil, errors.New("built without nvml support")
+}
+
+func computeCapsNVML() ([]string, error) {
+	return nil, errors.New("built without nvml support")
+}
+
+func cudaVersionNVML() (string, error) {
+	return "", errors.New("built without nvml support")
+}
@@ -0,0 +1,89 @@
+package gpu
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Process is a program holding memory on a GPU.
+type Process struct {
+	PID    int
+	Name   string
+	GPU    int    // device index
+	Type   string // "C" compute, "G" graphics or "C+G"
+	Memory uint64 // bytes; 0 when the driver doesn't report it, as under Windows WDDM
+}
+
+// Processes lists what is running on every GPU, largest VRAM user first.
+func Processes() ([]Process, error) {
+	procs, err := processesNVML()
+	if err != nil {
+		procs, err = processesSMI()
+	}
+	sort.SliceStable(procs, func(i, j int) bool { return procs[i].Memory > procs[j].Memory })
+	return procs, err
+}
+
+// processesSMI parses the process table of plain `nvidia-smi`, since
+// --query-compute-apps leaves out graphics processes such as games and
+// browsers.
+func processesSMI() ([]Process, error) {
+	start := time.Now()
+	out, err := exec.Command("nvidia-smi").Output()
+	logCommand(start, []string{"nvidia-smi"}, err)
+	if err != nil {
+		return nil, fmt.Errorf("nvidia-smi: %w", err)
+	}
+	return parseSMIProcesses(string(out)), nil
+}
+
+var reProcType = regexp.MustCompile(`^(C|G|M|C\+G|M\+C)$`)
+
+// parseSMIProcesses reads rows such as
+//
+//	|    0   N/A  N/A      1234      G   /usr/lib/xorg/Xorg          256MiB |
+//
+// where older drivers omit the GI and CI columns and Windows reports N/A for
+// the memory of graphics processes.
+func parseSMIProcesses(out string) []Process {
+	_, table, ok := strings.Cut(out, "Processes:")
+	if !ok {
+		return nil
+	}
+	var procs []Process
+	for _, line := range strings.Split(table, "\n") {
+		f := strings.Fields(strings.Trim(strings.TrimSpace(line), "|"))
+		if len(f) < 4 {
+			continue
+		}
+		gpu, err := strconv.Atoi(f[0])
+		if err != nil {
+			continue
+		}
+		t := -1
+		for i := 2; i < len(f)-1; i++ {
+			if reProcType.MatchString(f[i]) {
+				t = i
+				break
+			}
+		}
+		if t < 0 {
+			continue
+		}
+		pid, err := strconv.Atoi(f[t-1])
+		if err != nil {
+			continue
+		}
+		p := Process{PID: pid, GPU: gpu, Type: f[t], Name: strings.Join(f[t+1:len(f)-1], " ")}
+		if mem, ok := strings.CutSuffix(f[len(f)-1], "MiB"); ok {
+			p.Memory = uint64(atoi(mem)) * mib
+		}
+		procs = append(procs, p)
+	}
+	return procs
+}
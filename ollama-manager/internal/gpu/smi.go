@@ -0,0 +1,111 @@
+package gpu
+
+import (
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const mib = 1 << 20
+
+var smiFields = []string{
+	"index", "name", "uuid", "memory.total", "memory.used",
+	"utilization.gpu", "temperature.gpu", "driver_version", "power.draw",
+}
+
+// logCommand records an nvidia-smi call. Failures stay at debug level since
+// without an NVIDIA driver every refresh fails the same way.
+func logCommand(start time.Time, args []string, err error) {
+	attrs := []any{"cmd", strings.Join(args, " "), "took", time.Since(start).Round(time.Millisecond)}
+	if err != nil {
+		attrs = append(attrs, "err", err)
+	}
+	slog.Debug("exec", attrs...)
+}
+
+// querySMI parses `nvidia-smi --query-gpu` CSV output.
+func querySMI() ([]Device, error) {
+	start := time.Now()
+	args := []string{"nvidia-smi", "--query-gpu=" + strings.Join(smiFields, ","), "--format=csv,noheader,nounits"}
+	out, err := exec.Command(args[0], args[1:]...).Output()
+	logCommand(start, args, err)
+	if err != nil {
+		return nil, fmt.Errorf("nvidia-smi: %w", err)
+	}
+	return parseSMI(string(out))
+}
+
+func parseSMI(out string) ([]Device, error) {
+	var devices []Device
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		f := strings.Split(line, ",")
+		if len(f) != len(smiFields) {
+			return nil, fmt.Errorf("nvidia-smi: unexpected line %q", line)
+		}
+		for i := range f {
+			f[i] = strings.TrimSpace(f[i])
+		}
+		devices = append(devices, Device{
+			Index:       atoi(f[0]),
+			Name:        f[1],
+			UUID:        f[2],
+			MemoryTotal: uint64(atoi(f[3])) * mib,
+			MemoryUsed:  uint64(atoi(f[4])) * mib,
+			Utilization: atoi(f[5]),
+			Temperature: atoi(f[6]),
+			Power:       atoi(f[8]),
+			Driver:      f[7],
+		})
+	}
+	return devices, nil
+}
+
+// atoi parses a numeric nvidia-smi field, treating "[N/A]" and friends as 0.
+func atoi(s string) int {
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+func computeCapsSMI() ([]string, error) {
+	start := time.Now()
+	args := []string{"nvidia-smi", "--query-gpu=compute_cap", "--format=csv,noheader"}
+	out, err := exec.Command(args[0], args[1:]...).Output()
+	logCommand(start, args, err)
+	if err != nil {
+		return nil, fmt.Errorf("nvidia-smi: %w", err)
+	}
+	var caps []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			caps = append(caps, line)
+		}
+	}
+	return caps, nil
+}
+
+var reCUDAVersion = regexp.MustCompile(`CUDA Version:\s*([0-9.]+)`)
+
+// cudaVersionSMI reads the version from the banner of plain `nvidia-smi`.
+func cudaVersionSMI() (string, error) {
+	start := time.Now()
+	out, err := exec.Command("nvidia-smi").Output()
+	logCommand(start, []string{"nvidia-smi"}, err)
+	if err != nil {
+		return "", fmt.Errorf("nvidia-smi: %w", err)
+	}
+	m := reCUDAVersion.FindSubmatch(out)
+	if m == nil {
+		return "", fmt.Errorf("nvidia-smi: no CUDA version in output")
+	}
+	return string(m[1]), nil
+}
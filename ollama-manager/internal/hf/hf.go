@@ -0,0 +1,124 @@
+// Package hf searches Hugging Face for GGUF repositories, which Ollama pulls
+// directly as "hf.co/<user>/<repo>:<quant>".
+package hf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"ollama-manager/internal/quant"
+)
+
+// BaseURL is the public Hugging Face hub.
+const BaseURL = "https://huggingface.co"
+
+// searchLimit caps the repositories returned by a search.
+const searchLimit = 40
+
+// Repo is one search result.
+type Repo struct {
+	ID        string `json:"id"` // e.g. "bartowski/Qwen2.5-7B-Instruct-GGUF"
+	Downloads int    `json:"downloads"`
+	Likes     int    `json:"likes"`
+}
+
+// Quant is one quantization offered by a repository.
+type Quant struct {
+	Name string // e.g. "Q4_K_M"
+	File string
+	Size int64 // bytes
+}
+
+// Client talks to the Hugging Face API.
+type Client struct {
+	base string
+	http *http.Client
+}
+
+// NewClient returns a client for the hub at base, usually BaseURL.
+func NewClient(base string) *Client {
+	return &Client{base: strings.TrimRight(base, "/"), http: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// PullName is the name Ollama pulls quant of repo by.
+func PullName(repo, quant string) string {
+	return "hf.co/" + repo + ":" + quant
+}
+
+// Search returns GGUF repositories matching query, most downloaded first.
+func (c *Client) Search(ctx context.Context, query string) ([]Repo, error) {
+	q := url.Values{
+		"search":    {query},
+		"filter":    {"gguf"},
+		"sort":      {"downloads"},
+		"direction": {"-1"},
+		"limit":     {fmt.Sprint(searchLimit)},
+	}
+	var repos []Repo
+	if err := c.get(ctx, "/api/models?"+q.Encode(), &repos); err != nil {
+		return nil, err
+	}
+	return repos, nil
+}
+
+// Quants lists the single-file GGUF builds of repo, smallest first. Split
+// files and vision projectors are left out; Ollama can't pull the former by
+// quant and fetches the latter along with the model.
+func (c *Client) Quants(ctx context.Context, repo string) ([]Quant, error) {
+	var files []struct {
+		Type string `json:"type"`
+		Path string `json:"path"`
+		Size int64  `json:"size"`
+	}
+	if err := c.get(ctx, "/api/models/"+repo+"/tree/main?recursive=true", &files); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var quants []Quant
+	for _, f := range files {
+		name := path.Base(f.Path)
+		if f.Type != "file" || !strings.EqualFold(path.Ext(name), ".gguf") ||
+			reSplit.MatchString(name) || strings.Contains(strings.ToLower(name), "mmproj") {
+			continue
+		}
+		q := quant.Of(name)
+		if q == "" || seen[q] {
+			continue
+		}
+		seen[q] = true
+		quants = append(quants, Quant{Name: q, File: f.Path, Size: f.Size})
+	}
+	sort.Slice(quants, func(i, j int) bool { return quants[i].Size < quants[j].Size })
+	return quants, nil
+}
+
+var reSplit = regexp.MustCompile(`-\d{5}-of-\d{5}\.gguf$`)
+
+func (c *Client) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	if err != nil {
+		slog.Warn("huggingface", "path", path, "err", err)
+		return err
+	}
+	defer resp.Body.Close()
+	slog.Debug("huggingface", "path", path, "status", resp.StatusCode, "took", time.Since(start).Round(time.Millisecond))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("huggingface: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
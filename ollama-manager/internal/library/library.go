@@ -0,0 +1,215 @@
+// Package library searches the public model library on ollama.com. The site
+// has no JSON API, so results are scraped from its search and tags pages.
+package library
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BaseURL is the public Ollama library.
+const BaseURL = "https://ollama.com"
+
+// maxPage caps how much of a page is read.
+const maxPage = 4 << 20
+
+// ErrNoResults is returned when a tags page parsed to nothing, which usually
+// means the site's markup changed.
+var ErrNoResults = errors.New("library: no results found on page")
+
+// Model is one search result.
+type Model struct {
+	Name         string
+	Description  string
+	Sizes        []string // parameter sizes such as "8b" or "70b"
+	Capabilities []string // e.g. "tools", "vision"
+	Pulls        string   // as displayed, e.g. "93.1M"
+}
+
+// Tag is one pullable variant of a model.
+type Tag struct {
+	Name string // full name, e.g. "llama3.1:8b-instruct-q4_K_M"
+	Size int64  // download size in bytes, 0 if unknown
+}
+
+// Client fetches library pages.
+type Client struct {
+	base string
+	http *http.Client
+}
+
+// NewClient returns a client for the library at base, usually BaseURL.
+func NewClient(base string) *Client {
+	return &Client{base: strings.TrimRight(base, "/"), http: &http.Client{}}
+}
+
+// Search returns models matching query; an empty query lists popular ones.
+func (c *Client) Search(ctx context.Context, query string) ([]Model, error) {
+	page, err := c.get(ctx, "/search?q="+url.QueryEscape(query))
+	if err != nil {
+		return nil, err
+	}
+	return parseSearch(page), nil
+}
+
+// Tags lists the variants of a library model.
+func (c *Client) Tags(ctx context.Context, name string) ([]Tag, error) {
+	page, err := c.get(ctx, "/library/"+url.PathEscape(name)+"/tags")
+	if err != nil {
+		return nil, err
+	}
+	tags := parseTags(page, name)
+	if len(tags) == 0 {
+		return nil, ErrNoResults
+	}
+	return tags, nil
+}
+
+func (c *Client) get(ctx context.Context, path string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/html")
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	if err != nil {
+		slog.Warn("library", "path", path, "err", err)
+		return "", err
+	}
+	defer resp.Body.Close()
+	slog.Debug("library", "path", path, "status", resp.StatusCode, "took", time.Since(start).Round(time.Millisecond))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("library: %s %s", path, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPage))
+	return string(body), err
+}
+
+var (
+	reHref       = regexp.MustCompile(`href="/library/([^"/:]+)"`)
+	reTitle      = regexp.MustCompile(`x-test-search-response-title[^>]*>([^<]+)<`)
+	reDesc       = regexp.MustCompile(`(?s)<p[^>]*>(.*?)</p>`)
+	reSize       = regexp.MustCompile(`x-test-size[^>]*>([^<]+)<`)
+	reCapability = regexp.MustCompile(`x-test-capability[^>]*>([^<]+)<`)
+	rePulls      = regexp.MustCompile(`x-test-pull-count[^>]*>([^<]+)<`)
+	reBytes      = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*([KMGT]B)\b`)
+	reMarkup     = regexp.MustCompile(`<[^>]+>`)
+)
+
+// parseSearch splits the results page on its per-model marker and pulls the
+// fields out of each chunk.
+func parseSearch(page string) []Model {
+	var models []Model
+	chunks := strings.Split(page, "x-test-model")
+	for _, chunk := range chunks[1:] {
+		var m Model
+		if s := reTitle.FindStringSubmatch(chunk); s != nil {
+			m.Name = text(s[1])
+		} else if s := reHref.FindStringSubmatch(chunk); s != nil {
+			m.Name = s[1]
+		}
+		if m.Name == "" {
+			continue
+		}
+		if s := reDesc.FindStringSubmatch(chunk); s != nil {
+			m.Description = text(reMarkup.ReplaceAllString(s[1], ""))
+		}
+		for _, s := range reSize.FindAllStringSubmatch(chunk, -1) {
+			m.Sizes = append(m.Sizes, text(s[1]))
+		}
+		for _, s := range reCapability.FindAllStringSubmatch(chunk, -1) {
+			m.Capabilities = append(m.Capabilities, text(s[1]))
+		}
+		if s := rePulls.FindStringSubmatch(chunk); s != nil {
+			m.Pulls = text(s[1])
+		}
+		models = append(models, m)
+	}
+	return models
+}
+
+// parseTags finds links to name:tag and the first size shown after each.
+func parseTags(page, name string) []Tag {
+	re := regexp.MustCompile(`href="/library/` + regexp.QuoteMeta(name) + `:([^"]+)"`)
+	matches := re.FindAllStringSubmatchIndex(page, -1)
+	seen := make(map[string]bool)
+	var tags []Tag
+	for i, loc := range matches {
+		tag := page[loc[2]:loc[3]]
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+
+		end := len(page)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		t := Tag{Name: name + ":" + html.UnescapeString(tag)}
+		if s := reBytes.FindStringSubmatch(page[loc[1]:end]); s != nil {
+			t.Size = parseBytes(s[1], s[2])
+		}
+		tags = append(tags, t)
+	}
+	return tags
+}
+
+func text(s string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
+
+// parseBytes converts a displayed size such as "4.9 GB" (decimal units, as
+// the site uses) to bytes.
+func parseBytes(num, unit string) int64 {
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0
+	}
+	mult := map[string]float64{"KB": 1e3, "MB": 1e6, "GB": 1e9, "TB": 1e12}[unit]
+	return int64(f * mult)
+}
+
+// ParamBillions reads a parameter size label ("8b", "1.5b", "270m", "8x7b")
+// as billions of parameters, or 0 if it isn't one.
+func ParamBillions(size string) float64 {
+	size = strings.ToLower(strings.TrimSpace(size))
+	mult := 1.0
+	if n, rest, ok := strings.Cut(size, "x"); ok {
+		experts, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return 0
+		}
+		mult, size = experts, rest
+	}
+	scale := 1.0
+	switch {
+	case strings.HasSuffix(size, "b"):
+		size = strings.TrimSuffix(size, "b")
+	case strings.HasSuffix(size, "m"):
+		size, scale = strings.TrimSuffix(size, "m"), 1e-3
+	default:
+		return 0
+	}
+	f, err := strconv.ParseFloat(size, 64)
+	if err != nil {
+		return 0
+	}
+	return f * scale * mult
+}
+
+// Q4Bytes approximates the download size of a Q4_K_M build, the default tag
+// for most library models, at about 4.8 bits per parameter.
+func Q4Bytes(billions float64) int64 {
+	return int64(billions * 1e9 * 0.6)
+}
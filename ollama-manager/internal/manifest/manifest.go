@@ -0,0 +1,137 @@
+// Package manifest reads and writes model manifests, the list of models
+// installed on one machine for pulling onto another. Manifests are written
+// as YAML, and read as YAML or JSON.
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the manifest format written.
+const Version = 1
+
+// Manifest is a model library.
+type Manifest struct {
+	Version  int       `json:"version" yaml:"version"`
+	Host     string    `json:"host,omitempty" yaml:"host"`
+	Exported time.Time `json:"exported" yaml:"exported"`
+	Models   []Model   `json:"models" yaml:"models"`
+}
+
+// Model is one installed model. Digest and Size are informational; the
+// name is what gets pulled.
+type Model struct {
+	Name   string `json:"name" yaml:"name"`
+	Digest string `json:"digest,omitempty" yaml:"digest"`
+	Size   int64  `json:"size,omitempty" yaml:"size"`
+}
+
+// UnmarshalYAML reads a models entry, which may also be a bare name
+// ("- llama3.1:8b") so a manifest can be written by hand.
+func (m *Model) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*m = Model{Name: node.Value}
+		return nil
+	}
+	type model Model // without this method
+	return node.Decode((*model)(m))
+}
+
+// Load reads the manifest at path, as JSON if it starts with "{" and as
+// YAML otherwise.
+func Load(path string) (Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var m Manifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			return Manifest{}, fmt.Errorf("%s: %w", path, err)
+		}
+		return m, nil
+	}
+	m, err := Parse(data)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Save writes m to path, as JSON for a .json file and YAML otherwise.
+func Save(path string, m Manifest) error {
+	var b bytes.Buffer
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		enc := json.NewEncoder(&b)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(m); err != nil {
+			return err
+		}
+	} else if err := Write(&b, m); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b.Bytes(), 0o644)
+}
+
+// Write writes m as YAML.
+func Write(w io.Writer, m Manifest) error {
+	var b strings.Builder
+	b.WriteString("# Ollama models exported by ollama-manager.\n")
+	b.WriteString("# Pull the missing ones elsewhere with: ollama-manager import <file>\n")
+	fmt.Fprintf(&b, "version: %d\n", m.Version)
+	if m.Host != "" {
+		fmt.Fprintf(&b, "host: %s\n", strconv.Quote(m.Host))
+	}
+	fmt.Fprintf(&b, "exported: %s\n", m.Exported.UTC().Format(time.RFC3339))
+	if len(m.Models) == 0 {
+		b.WriteString("models: []\n")
+	} else {
+		b.WriteString("models:\n")
+	}
+	for _, mdl := range m.Models {
+		fmt.Fprintf(&b, "  - name: %s\n", strconv.Quote(mdl.Name))
+		if mdl.Digest != "" {
+			fmt.Fprintf(&b, "    digest: %s\n", strconv.Quote(mdl.Digest))
+		}
+		if mdl.Size > 0 {
+			fmt.Fprintf(&b, "    size: %d\n", mdl.Size)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Parse reads a YAML manifest. A manifest without a models list, rather
+// than an empty one, is refused, as that is more likely a mistake.
+func Parse(data []byte) (Manifest, error) {
+	var raw struct {
+		Version  int       `yaml:"version"`
+		Host     string    `yaml:"host"`
+		Exported time.Time `yaml:"exported"`
+		Models   *[]Model  `yaml:"models"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return Manifest{}, err
+	}
+	if raw.Models == nil {
+		return Manifest{}, errors.New("no models list")
+	}
+	m := Manifest{Version: raw.Version, Host: raw.Host, Exported: raw.Exported, Models: *raw.Models}
+	for i, mdl := range m.Models {
+		if mdl.Name == "" {
+			return Manifest{}, fmt.Errorf("models[%d]: no name", i)
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,73 @@
+// Package notify shows desktop notifications: notify-send on Linux,
+// Notification Center on macOS and a toast on Windows.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"ollama-manager/internal/applog"
+)
+
+// timeout bounds the helper program; a notification that can't be shown in
+// this time isn't worth waiting for.
+const timeout = 10 * time.Second
+
+// toastScript shows a Windows toast under PowerShell's app ID, which is
+// registered on every install. Title and body come from the environment so
+// they need no quoting.
+const toastScript = `
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $xml.GetElementsByTagName('text')
+$text.Item(0).AppendChild($xml.CreateTextNode($env:NOTIFY_TITLE)) > $null
+$text.Item(1).AppendChild($xml.CreateTextNode($env:NOTIFY_BODY)) > $null
+$id = '{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe'
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($id).Show([Windows.UI.Notifications.ToastNotification]::new($xml))
+`
+
+// Send shows a notification.
+func Send(title, body string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=Ollama Manager", title, body)
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleString(body), appleString(title))
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	case "windows":
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", toastScript)
+		cmd.Env = append(os.Environ(), "NOTIFY_TITLE="+title, "NOTIFY_BODY="+body)
+	default:
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+	start := time.Now()
+	out, err := cmd.CombinedOutput()
+	applog.Command(slog.LevelDebug, start, cmd.Args[:1], err)
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s: %s", cmd.Args[0], msg)
+		}
+		return fmt.Errorf("%s: %w", cmd.Args[0], err)
+	}
+	return nil
+}
+
+// appleString quotes s as an AppleScript string literal.
+func appleString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// Bell rings the terminal bell. It goes to stderr, which is the terminal
+// too but stays out of the way of the TUI drawing on stdout.
+func Bell() {
+	os.Stderr.WriteString("\a")
+}
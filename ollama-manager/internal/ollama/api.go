@@ -0,0 +1,209 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+)
+
+// List returns the models installed on the server.
+func (c *Client) List(ctx context.Context) ([]Model, error) {
+	var resp struct {
+		Models []Model `json:"models"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/tags", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Models, nil
+}
+
+// Running returns the models currently loaded into memory.
+func (c *Client) Running(ctx context.Context) ([]RunningModel, error) {
+	var resp struct {
+		Models []RunningModel `json:"models"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/ps", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Models, nil
+}
+
+// Generate runs a non-streaming completion.
+func (c *Client) Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	stream := false
+	req.Stream = &stream
+
+	var resp GenerateResponse
+	if err := c.do(ctx, http.MethodPost, "/api/generate", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// LoadOptions tune how a model is loaded.
+type LoadOptions struct {
+	// KeepAlive overrides the server's default unload timer; nil keeps it.
+	KeepAlive *Duration
+	// NumCtx sets the context length; 0 keeps the model's default.
+	NumCtx int
+	// NumGPU sets how many layers are offloaded to the GPU; nil lets Ollama
+	// decide.
+	NumGPU *int
+}
+
+// options returns the runner options for a load, or nil if none are set.
+func (o LoadOptions) options() map[string]any {
+	opts := make(map[string]any)
+	if o.NumCtx > 0 {
+		opts["num_ctx"] = o.NumCtx
+	}
+	if o.NumGPU != nil {
+		opts["num_gpu"] = *o.NumGPU
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+	return opts
+}
+
+// Load asks the server to load a model into memory. An empty prompt makes
+// Ollama load the weights and return without generating. Loading a model
+// that is already in memory resets its keep-alive timer; loading it with
+// different options reloads it.
+func (c *Client) Load(ctx context.Context, name string, opts LoadOptions) error {
+	_, err := c.Generate(ctx, GenerateRequest{Model: name, KeepAlive: opts.KeepAlive, Options: opts.options()})
+	return err
+}
+
+// Unload evicts a model from memory by setting its keep-alive to zero.
+func (c *Client) Unload(ctx context.Context, name string) error {
+	_, err := c.Generate(ctx, GenerateRequest{Model: name, KeepAlive: KeepAlive(0)})
+	return err
+}
+
+// Pull downloads a model from the registry, calling fn for every progress
+// update until the pull succeeds, fails, or ctx is cancelled.
+func (c *Client) Pull(ctx context.Context, name string, fn func(PullProgress)) error {
+	req := struct {
+		Model  string `json:"model"`
+		Stream bool   `json:"stream"`
+	}{Model: name, Stream: true}
+
+	return c.stream(ctx, http.MethodPost, "/api/pull", req, func(line []byte) error {
+		var p PullProgress
+		if err := json.Unmarshal(line, &p); err != nil {
+			return err
+		}
+		fn(p)
+		return nil
+	})
+}
+
+// Delete removes a model and any layers no other model references.
+func (c *Client) Delete(ctx context.Context, name string) error {
+	req := struct {
+		Model string `json:"model"`
+	}{Model: name}
+	return c.do(ctx, http.MethodDelete, "/api/delete", req, nil)
+}
+
+// Create builds a new model from req, calling fn with each status update
+// (e.g. "creating new layer", "success") until it finishes or ctx is
+// cancelled. A From naming a local GGUF file is uploaded first, like
+// `ollama create` does.
+func (c *Client) Create(ctx context.Context, req CreateRequest, fn func(PullProgress)) error {
+	if IsGGUFPath(req.From) {
+		digest, err := c.pushFile(ctx, req.From, fn)
+		if err != nil {
+			return err
+		}
+		req.Files = map[string]string{filepath.Base(req.From): digest}
+		req.From = ""
+	}
+	stream := true
+	req.Stream = &stream
+	return c.stream(ctx, http.MethodPost, "/api/create", req, func(line []byte) error {
+		var p PullProgress
+		if err := json.Unmarshal(line, &p); err != nil {
+			return err
+		}
+		fn(p)
+		return nil
+	})
+}
+
+// Copy creates destination as another name for source, like `ollama cp`.
+// Layers are shared, so it costs no extra disk space.
+func (c *Client) Copy(ctx context.Context, source, destination string) error {
+	req := struct {
+		Source      string `json:"source"`
+		Destination string `json:"destination"`
+	}{Source: source, Destination: destination}
+	return c.do(ctx, http.MethodPost, "/api/copy", req, nil)
+}
+
+// Show returns a model's metadata, template, parameters and license.
+func (c *Client) Show(ctx context.Context, name string) (*ShowResponse, error) {
+	req := struct {
+		Model string `json:"model"`
+	}{Model: name}
+
+	var resp ShowResponse
+	if err := c.do(ctx, http.MethodPost, "/api/show", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Chat streams a chat completion, calling fn with each chunk. The final chunk
+// has Done set and carries the timing metrics.
+func (c *Client) Chat(ctx context.Context, req ChatRequest, fn func(ChatResponse)) error {
+	stream := true
+	req.Stream = &stream
+
+	return c.stream(ctx, http.MethodPost, "/api/chat", req, func(line []byte) error {
+		var r ChatResponse
+		if err := json.Unmarshal(line, &r); err != nil {
+			return err
+		}
+		fn(r)
+		return nil
+	})
+}
+
+// Embed returns the embeddings of req's inputs, in order.
+func (c *Client) Embed(ctx context.Context, req EmbedRequest) (*EmbedResponse, error) {
+	var resp EmbedResponse
+	if err := c.do(ctx, http.MethodPost, "/api/embed", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Version returns the server's version string.
+func (c *Client) Version(ctx context.Context) (string, error) {
+	var resp struct {
+		Version string `json:"version"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/version", nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Version, nil
+}
+
+// GenerateStream runs a streaming completion, calling fn with each chunk.
+// The final chunk has Done set and carries the timing metrics.
+func (c *Client) GenerateStream(ctx context.Context, req GenerateRequest, fn func(GenerateResponse)) error {
+	stream := true
+	req.Stream = &stream
+
+	return c.stream(ctx, http.MethodPost, "/api/generate", req, func(line []byte) error {
+		var r GenerateResponse
+		if err := json.Unmarshal(line, &r); err != nil {
+			return err
+		}
+		fn(r)
+		return nil
+	})
+}
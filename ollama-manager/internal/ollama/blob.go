@@ -0,0 +1,129 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ggufMagic starts every GGUF file.
+var ggufMagic = []byte("GGUF")
+
+// IsGGUFPath reports whether a Modelfile FROM names a local GGUF file rather
+// than a model.
+func IsGGUFPath(from string) bool {
+	return strings.EqualFold(filepath.Ext(from), ".gguf")
+}
+
+// CheckGGUF returns an error unless path is a readable GGUF file.
+func CheckGGUF(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	magic := make([]byte, len(ggufMagic))
+	if _, err := io.ReadFull(f, magic); err != nil || !bytes.Equal(magic, ggufMagic) {
+		return fmt.Errorf("%s is not a GGUF file", filepath.Base(path))
+	}
+	return nil
+}
+
+// HasBlob reports whether the server already stores the blob with digest.
+func (c *Client) HasBlob(ctx context.Context, digest string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.base+"/api/blobs/"+digest, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.roundTrip(req)
+	var se *StatusError
+	if errors.As(err, &se) && se.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// pushFile uploads a local file as a blob unless the server has it already,
+// and returns its digest. Hashing and uploading a multi-gigabyte file take a
+// while, so both report progress to fn.
+func (c *Client) pushFile(ctx context.Context, path string, fn func(PullProgress)) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	name := filepath.Base(path)
+
+	h := sha256.New()
+	hashing := &progressReader{ctx: ctx, r: f, total: st.Size(), status: "hashing " + name, fn: fn}
+	if _, err := io.Copy(h, hashing); err != nil {
+		return "", err
+	}
+	digest := fmt.Sprintf("sha256:%x", h.Sum(nil))
+	if ok, err := c.HasBlob(ctx, digest); err != nil || ok {
+		return digest, err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return digest, c.PushBlob(ctx, digest, f, st.Size(), "uploading "+name, fn)
+}
+
+// PushBlob uploads the size bytes read from r as the blob with digest,
+// reporting progress to fn with status. The server checks the content
+// against the digest.
+func (c *Client) PushBlob(ctx context.Context, digest string, r io.Reader, size int64, status string, fn func(PullProgress)) error {
+	uploading := &progressReader{ctx: ctx, r: r, total: size, status: status, fn: fn}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.base+"/api/blobs/"+digest, uploading)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := c.roundTrip(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// progressReader reports how much of r has been read, once per percent.
+type progressReader struct {
+	ctx    context.Context
+	r      io.Reader
+	total  int64
+	done   int64
+	last   int64
+	status string
+	fn     func(PullProgress)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	if err := p.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	if pct := p.done * 100 / max(p.total, 1); pct != p.last || p.done == int64(n) {
+		p.last = pct
+		p.fn(PullProgress{Status: p.status, Total: p.total, Completed: p.done})
+	}
+	return n, err
+}
@@ -0,0 +1,154 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"ollama-manager/internal/applog"
+)
+
+// The functions below shell out to the ollama binary. They are only used as
+// a fallback when the HTTP API cannot be reached, since they depend on
+// finding the binary and on the column layout of its table output.
+
+// Binary returns the ollama executable: the one on PATH or, on Windows,
+// where terminals opened before the install don't see it on PATH, the one
+// Ollama's installer put in place. It is plain "ollama" when there is
+// none, so errors name the command.
+func Binary() string {
+	if path, err := exec.LookPath("ollama"); err == nil {
+		return path
+	}
+	if runtime.GOOS == "windows" {
+		for _, dir := range windowsInstallDirs() {
+			path := filepath.Join(dir, "ollama.exe")
+			if _, err := os.Stat(path); err == nil {
+				return path
+			}
+		}
+	}
+	return "ollama"
+}
+
+// windowsInstallDirs lists where Ollama may be installed: the locations
+// registered for uninstalling it, then the installer's per-user default
+// and Program Files.
+func windowsInstallDirs() []string {
+	var dirs []string
+	for _, root := range []string{"HKCU", "HKLM"} {
+		dirs = append(dirs, registeredInstalls(root+`\Software\Microsoft\Windows\CurrentVersion\Uninstall`)...)
+	}
+	if d := os.Getenv("LOCALAPPDATA"); d != "" {
+		dirs = append(dirs, filepath.Join(d, "Programs", "Ollama"))
+	}
+	if d := os.Getenv("ProgramFiles"); d != "" {
+		dirs = append(dirs, filepath.Join(d, "Ollama"))
+	}
+	return dirs
+}
+
+// registeredInstalls returns the InstallLocation of the uninstall entries
+// under key that mention Ollama.
+func registeredInstalls(key string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	args := []string{"reg", "query", key, "/s", "/f", "Ollama", "/d"}
+	start := time.Now()
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+	applog.Command(slog.LevelDebug, start, args, err)
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, line := range strings.Split(string(out), "\n") {
+		// "    InstallLocation    REG_SZ    C:\Users\me\AppData\Local\Programs\Ollama\"
+		f := strings.Fields(line)
+		if len(f) < 3 || f[0] != "InstallLocation" || !strings.HasPrefix(f[1], "REG_") {
+			continue
+		}
+		_, value, _ := strings.Cut(line, f[1])
+		dirs = append(dirs, strings.TrimSpace(value))
+	}
+	return dirs
+}
+
+// ListCLI returns installed models by parsing `ollama list`. Only Name is set.
+func ListCLI(ctx context.Context) ([]Model, error) {
+	names, err := firstColumn(ctx, "list")
+	if err != nil {
+		return nil, err
+	}
+	models := make([]Model, len(names))
+	for i, name := range names {
+		models[i] = Model{Name: name, Model: name}
+	}
+	return models, nil
+}
+
+// RunningCLI returns loaded models by parsing `ollama ps`. Only Name is set.
+func RunningCLI(ctx context.Context) ([]RunningModel, error) {
+	names, err := firstColumn(ctx, "ps")
+	if err != nil {
+		return nil, err
+	}
+	models := make([]RunningModel, len(names))
+	for i, name := range names {
+		models[i] = RunningModel{Name: name, Model: name}
+	}
+	return models, nil
+}
+
+// StopCLI runs `ollama stop`.
+func StopCLI(ctx context.Context, name string) error {
+	start := time.Now()
+	args := []string{Binary(), "stop", name}
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	applog.Command(slog.LevelInfo, start, args, err)
+	if err != nil {
+		return cliError("stop", err, out)
+	}
+	return nil
+}
+
+// cliError folds the command's output into err, since ollama reports the
+// reason for a failure on stderr rather than in its exit code.
+func cliError(subcommand string, err error, out []byte) error {
+	if msg := strings.TrimSpace(string(out)); msg != "" {
+		return fmt.Errorf("ollama %s: %s", subcommand, msg)
+	}
+	return fmt.Errorf("ollama %s: %w", subcommand, err)
+}
+
+func firstColumn(ctx context.Context, subcommand string) ([]string, error) {
+	start := time.Now()
+	args := []string{Binary(), subcommand}
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+	applog.Command(slog.LevelInfo, start, args, err)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, cliError(subcommand, err, exitErr.Stderr)
+		}
+		return nil, cliError(subcommand, err, nil)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	var names []string
+	for i, line := range lines {
+		if i == 0 {
+			continue // skip header
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			names = append(names, fields[0])
+		}
+	}
+	return names, nil
+}
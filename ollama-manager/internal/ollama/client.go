@@ -0,0 +1,146 @@
+// Package ollama is a small client for the Ollama REST API.
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultHost is the address a stock Ollama install listens on.
+const DefaultHost = "http://127.0.0.1:11434"
+
+// Client talks to a single Ollama server.
+type Client struct {
+	base   string
+	auth   Auth
+	http   *http.Client
+	remote bool
+}
+
+// NewClient returns a client for the server at host, e.g. "http://127.0.0.1:11434".
+func NewClient(host string) *Client {
+	return &Client{
+		base: strings.TrimRight(host, "/"),
+		http: &http.Client{},
+	}
+}
+
+// SetAuth sets the credentials sent with every request.
+func (c *Client) SetAuth(a Auth) {
+	c.auth = a
+}
+
+// Auth returns the credentials sent with every request.
+func (c *Client) Auth() Auth {
+	return c.auth
+}
+
+// Host returns the base URL the client sends requests to.
+func (c *Client) Host() string {
+	return c.base
+}
+
+// StatusError is returned when the server answers with a non-2xx status.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("ollama: %s", http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("ollama: %s (%d)", e.Message, e.StatusCode)
+}
+
+// do sends a JSON request and decodes a JSON response into out (if non-nil).
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	resp, err := c.send(ctx, method, path, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send issues the request and converts error statuses into *StatusError.
+// The caller owns the returned body.
+func (c *Client) send(ctx context.Context, method, path string, in any) (*http.Response, error) {
+	var body io.Reader
+	if in != nil {
+		buf, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(buf)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.roundTrip(req)
+}
+
+// roundTrip sends a prepared request with the client's credentials and
+// converts error statuses into *StatusError. The caller owns the returned
+// body.
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	method, path := req.Method, req.URL.Path
+	req.Header.Set("Accept", "application/json")
+	c.auth.apply(req)
+
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	if err != nil {
+		slog.Warn("api", "method", method, "path", path, "err", err)
+		return nil, err
+	}
+	took := time.Since(start).Round(time.Millisecond)
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		err := statusError(resp)
+		slog.Warn("api", "method", method, "path", path, "status", resp.StatusCode, "took", took, "err", err)
+		return nil, err
+	}
+	slog.Debug("api", "method", method, "path", path, "status", resp.StatusCode, "took", took)
+	return resp, nil
+}
+
+// IsUnreachable reports whether err means the server couldn't be contacted,
+// as opposed to the server rejecting the request.
+func IsUnreachable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var se *StatusError
+	return !errors.As(err, &se)
+}
+
+func statusError(resp *http.Response) error {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	msg := strings.TrimSpace(string(raw))
+	if json.Unmarshal(raw, &apiErr) == nil && apiErr.Error != "" {
+		msg = apiErr.Error
+	}
+	return &StatusError{StatusCode: resp.StatusCode, Message: msg}
+}
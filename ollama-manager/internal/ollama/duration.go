@@ -0,0 +1,45 @@
+package ollama
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is a keep_alive value. Negative durations keep a model loaded
+// indefinitely and zero unloads it immediately.
+type Duration struct {
+	time.Duration
+}
+
+// KeepAlive wraps d for use in request bodies.
+func KeepAlive(d time.Duration) *Duration {
+	return &Duration{d}
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	if d.Duration < 0 {
+		return []byte("-1"), nil
+	}
+	return json.Marshal(d.Duration.String())
+}
+
+// ParseKeepAlive parses a keep-alive the way Ollama does: a Go duration such
+// as "10m" or "1h", a bare number of seconds, or any negative value ("-1")
+// for "never unload".
+func ParseKeepAlive(s string) (*Duration, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "-") {
+		return KeepAlive(-1), nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return KeepAlive(time.Duration(n) * time.Second), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keep-alive %q: use e.g. 10m, 1h or -1", s)
+	}
+	return KeepAlive(d), nil
+}
@@ -0,0 +1,168 @@
+package ollama
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CreateRequest is the body of /api/create for deriving a model from an
+// existing one or building one from a GGUF file.
+type CreateRequest struct {
+	Model string `json:"model"`
+	From  string `json:"from,omitempty"`
+	// Files maps file names to the digests of uploaded blobs, for models
+	// built from local weights instead of From; Adapters does the same for
+	// LoRA adapters.
+	Files      map[string]string `json:"files,omitempty"`
+	Adapters   map[string]string `json:"adapters,omitempty"`
+	Parameters map[string]any    `json:"parameters,omitempty"`
+	System     string            `json:"system,omitempty"`
+	Template   string            `json:"template,omitempty"`
+	License    []string          `json:"license,omitempty"`
+	Messages   []Message         `json:"messages,omitempty"`
+	Stream     *bool             `json:"stream,omitempty"`
+}
+
+// parameterHints are the tuning parameters offered as comments in a
+// generated Modelfile.
+var parameterHints = []string{"temperature 0.7", "num_ctx 8192", "top_p 0.9"}
+
+// Modelfile renders an editable Modelfile deriving from base, carrying over
+// its parameters and system prompt. Common tuning parameters that aren't set
+// are included as comments to uncomment.
+func Modelfile(base string, info *ShowResponse) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "FROM %s\n\n", base)
+
+	set := make(map[string]bool)
+	for _, line := range strings.Split(info.Parameters, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		set[fields[0]] = true
+		fmt.Fprintf(&b, "PARAMETER %s %s\n", fields[0], strings.Join(fields[1:], " "))
+	}
+	for _, hint := range parameterHints {
+		if name, _, _ := strings.Cut(hint, " "); !set[name] {
+			fmt.Fprintf(&b, "# PARAMETER %s\n", hint)
+		}
+	}
+
+	b.WriteString("\n")
+	if info.System != "" {
+		fmt.Fprintf(&b, "SYSTEM \"\"\"%s\"\"\"\n", info.System)
+	} else {
+		b.WriteString("# SYSTEM \"\"\"You are a helpful assistant.\"\"\"\n")
+	}
+	return b.String()
+}
+
+// GGUFModelfile renders a minimal Modelfile for the weights in a local GGUF
+// file. Ollama takes the chat template from the file's metadata, so only the
+// tuning parameters are offered, as comments.
+func GGUFModelfile(path string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "FROM %s\n\n", path)
+	for _, hint := range parameterHints {
+		fmt.Fprintf(&b, "# PARAMETER %s\n", hint)
+	}
+	b.WriteString("\n# SYSTEM \"\"\"You are a helpful assistant.\"\"\"\n")
+	return b.String()
+}
+
+// ParseModelfile reads the subset of the Modelfile format the editor offers:
+// FROM, PARAMETER, SYSTEM and TEMPLATE, with """ for multi-line values and #
+// comments. Parameter values are typed the way the API expects.
+func ParseModelfile(text string) (*CreateRequest, error) {
+	req := &CreateRequest{Parameters: make(map[string]any)}
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		instr, rest, _ := strings.Cut(line, " ")
+		rest = strings.TrimSpace(rest)
+		lineNo := i + 1
+
+		switch strings.ToUpper(instr) {
+		case "FROM":
+			req.From = rest
+		case "PARAMETER":
+			name, value, ok := strings.Cut(rest, " ")
+			if !ok {
+				return nil, fmt.Errorf("line %d: PARAMETER needs a name and a value", lineNo)
+			}
+			addParameter(req.Parameters, name, strings.TrimSpace(value))
+		case "SYSTEM", "TEMPLATE":
+			value, next, err := quoted(rest, lines, i)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			i = next
+			if strings.EqualFold(instr, "SYSTEM") {
+				req.System = value
+			} else {
+				req.Template = value
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unsupported instruction %s", lineNo, instr)
+		}
+	}
+	if req.From == "" {
+		return nil, fmt.Errorf("missing FROM")
+	}
+	if len(req.Parameters) == 0 {
+		req.Parameters = nil
+	}
+	return req, nil
+}
+
+// quoted reads a value that is either on one line, in "double quotes", or
+// spans lines between """ markers. It returns the index of the last line
+// consumed.
+func quoted(rest string, lines []string, i int) (string, int, error) {
+	if !strings.HasPrefix(rest, `"""`) {
+		if s, err := strconv.Unquote(rest); err == nil {
+			return s, i, nil
+		}
+		return rest, i, nil
+	}
+	body := strings.TrimPrefix(rest, `"""`)
+	if end := strings.Index(body, `"""`); end >= 0 {
+		return body[:end], i, nil
+	}
+	parts := []string{body}
+	for j := i + 1; j < len(lines); j++ {
+		if end := strings.Index(lines[j], `"""`); end >= 0 {
+			parts = append(parts, lines[j][:end])
+			return strings.TrimPrefix(strings.Join(parts, "\n"), "\n"), j, nil
+		}
+		parts = append(parts, lines[j])
+	}
+	return "", i, fmt.Errorf(`unterminated """`)
+}
+
+// addParameter stores a value as a number or bool when it parses as one;
+// stop may repeat and collects into a list.
+func addParameter(params map[string]any, name, value string) {
+	if name == "stop" {
+		if s, err := strconv.Unquote(value); err == nil {
+			value = s
+		}
+		list, _ := params[name].([]string)
+		params[name] = append(list, value)
+		return
+	}
+	if n, err := strconv.Atoi(value); err == nil {
+		params[name] = n
+	} else if f, err := strconv.ParseFloat(value, 64); err == nil {
+		params[name] = f
+	} else if b, err := strconv.ParseBool(value); err == nil {
+		params[name] = b
+	} else {
+		params[name] = value
+	}
+}
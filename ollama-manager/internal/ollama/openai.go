@@ -0,0 +1,37 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+)
+
+// ChatCompletionsPath is the server's OpenAI-compatible chat endpoint.
+const ChatCompletionsPath = "/v1/chat/completions"
+
+// ChatCompletions posts body, a request in OpenAI's chat.completions schema,
+// as is and calls fn with each line of the response: the JSON object, or the
+// "data: " events of a streamed response. An error status is returned as a
+// *StatusError whose Message is the response body.
+func (c *Client) ChatCompletions(ctx context.Context, body []byte, fn func(line string)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.base+ChatCompletionsPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.roundTrip(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 0, 64<<10), maxLine)
+	for sc.Scan() {
+		if line := sc.Text(); line != "" {
+			fn(line)
+		}
+	}
+	return sc.Err()
+}
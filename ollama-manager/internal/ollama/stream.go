@@ -0,0 +1,41 @@
+package ollama
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// maxLine bounds a single streamed JSON object; chat chunks and progress
+// updates are tiny, but a final response can carry a large context array.
+const maxLine = 8 << 20
+
+// stream posts in and calls fn with each newline-delimited JSON object of the
+// response. A mid-stream {"error": ...} object is returned as an error.
+func (c *Client) stream(ctx context.Context, method, path string, in any, fn func(line []byte) error) error {
+	resp, err := c.send(ctx, method, path, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 0, 64<<10), maxLine)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(line, &apiErr) == nil && apiErr.Error != "" {
+			return errors.New(apiErr.Error)
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
@@ -0,0 +1,117 @@
+package ollama
+
+import (
+	"encoding/json"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// responseMark stands in for the reply when rendering the last turn; the
+// server stops the prompt where the reply would begin.
+const responseMark = "\x00response\x00"
+
+// templateMessage has the fields templates read from each message.
+type templateMessage struct {
+	Role      string
+	Content   string
+	Thinking  string
+	Images    []string
+	ToolCalls []any
+	ToolName  string
+}
+
+// templateValues has the fields templates read at the top level.
+type templateValues struct {
+	Messages   []templateMessage
+	Tools      []any
+	System     string
+	Prompt     string
+	Response   string
+	Suffix     string
+	Think      bool
+	ThinkLevel string
+	IsThinkSet bool
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v any) string {
+		b, _ := json.Marshal(v)
+		return string(b)
+	},
+	"currentDate": func(...string) string {
+		return time.Now().Format("2006-01-02")
+	},
+}
+
+// RenderPrompt renders msgs through tmpl, a model's prompt template, the way
+// the server does before generating, so the special tokens around each turn
+// can be inspected. system is the model's default system prompt, used when
+// msgs has none.
+//
+// Templates that range over .Messages see the whole conversation. Older ones
+// only know .System, .Prompt and .Response and are rendered once per
+// exchange, the last one up to where the reply would begin.
+func RenderPrompt(tmpl, system string, msgs []Message) (string, error) {
+	t, err := template.New("prompt").Funcs(templateFuncs).Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	if system != "" && (len(msgs) == 0 || msgs[0].Role != "system") {
+		msgs = append([]Message{{Role: "system", Content: system}}, msgs...)
+	}
+
+	var b strings.Builder
+	if strings.Contains(tmpl, ".Messages") {
+		v := templateValues{}
+		for _, m := range msgs {
+			v.Messages = append(v.Messages, templateMessage{Role: m.Role, Content: m.Content, Images: m.Images})
+			if m.Role == "system" {
+				v.System = m.Content
+			}
+		}
+		if err := t.Execute(&b, v); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	}
+
+	var v templateValues
+	execute := func() error {
+		err := t.Execute(&b, v)
+		v = templateValues{}
+		return err
+	}
+	for _, m := range msgs {
+		switch m.Role {
+		case "system":
+			if v.Prompt != "" || v.Response != "" {
+				if err := execute(); err != nil {
+					return "", err
+				}
+			}
+			v.System = m.Content
+		case "user":
+			if v.Response != "" {
+				if err := execute(); err != nil {
+					return "", err
+				}
+			}
+			v.Prompt = m.Content
+		case "assistant":
+			v.Response = m.Content
+		}
+	}
+	if v.Response != "" {
+		if err := execute(); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	}
+	v.Response = responseMark
+	if err := execute(); err != nil {
+		return "", err
+	}
+	out, _, _ := strings.Cut(b.String(), responseMark)
+	return out, nil
+}
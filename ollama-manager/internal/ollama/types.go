@@ -0,0 +1,265 @@
+package ollama
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Details describes the format and architecture of a model.
+type Details struct {
+	ParentModel       string   `json:"parent_model"`
+	Format            string   `json:"format"`
+	Family            string   `json:"family"`
+	Families          []string `json:"families"`
+	ParameterSize     string   `json:"parameter_size"`
+	QuantizationLevel string   `json:"quantization_level"`
+}
+
+// Model is an installed model as reported by /api/tags.
+type Model struct {
+	Name       string    `json:"name"`
+	Model      string    `json:"model"`
+	ModifiedAt time.Time `json:"modified_at"`
+	Size       int64     `json:"size"`
+	Digest     string    `json:"digest"`
+	Details    Details   `json:"details"`
+}
+
+// RunningModel is a model currently held in memory, as reported by /api/ps.
+type RunningModel struct {
+	Name      string    `json:"name"`
+	Model     string    `json:"model"`
+	Size      int64     `json:"size"`
+	Digest    string    `json:"digest"`
+	Details   Details   `json:"details"`
+	ExpiresAt time.Time `json:"expires_at"`
+	SizeVRAM  int64     `json:"size_vram"`
+	// ContextLength is the num_ctx the model was loaded with; older servers
+	// don't report it.
+	ContextLength int `json:"context_length,omitempty"`
+}
+
+// GPUFraction is the share of the model held in VRAM, from 0 to 1.
+func (r RunningModel) GPUFraction() float64 {
+	if r.Size <= 0 {
+		return 0
+	}
+	return min(float64(r.SizeVRAM)/float64(r.Size), 1)
+}
+
+// Offloaded reports whether part of the model runs on the CPU.
+func (r RunningModel) Offloaded() bool {
+	return r.SizeVRAM < r.Size
+}
+
+// Processor describes the CPU/GPU split the way `ollama ps` does:
+// "100% GPU", "100% CPU" or "48%/52% CPU/GPU".
+func (r RunningModel) Processor() string {
+	gpu := int(r.GPUFraction()*100 + 0.5)
+	switch {
+	case !r.Offloaded():
+		return "100% GPU"
+	case r.SizeVRAM == 0:
+		return "100% CPU"
+	}
+	return fmt.Sprintf("%d%%/%d%% CPU/GPU", 100-gpu, gpu)
+}
+
+// GenerateRequest is the body of /api/generate.
+type GenerateRequest struct {
+	Model     string         `json:"model"`
+	Prompt    string         `json:"prompt"`
+	System    string         `json:"system,omitempty"`
+	Stream    *bool          `json:"stream,omitempty"`
+	KeepAlive *Duration      `json:"keep_alive,omitempty"`
+	Options   map[string]any `json:"options,omitempty"`
+}
+
+// GenerateResponse is a (final or streamed) reply from /api/generate.
+type GenerateResponse struct {
+	Model      string    `json:"model"`
+	CreatedAt  time.Time `json:"created_at"`
+	Response   string    `json:"response"`
+	Done       bool      `json:"done"`
+	DoneReason string    `json:"done_reason,omitempty"`
+	Metrics
+}
+
+// Metrics are the timing counters Ollama attaches to completed requests.
+type Metrics struct {
+	TotalDuration      time.Duration `json:"total_duration,omitempty"`
+	LoadDuration       time.Duration `json:"load_duration,omitempty"`
+	PromptEvalCount    int           `json:"prompt_eval_count,omitempty"`
+	PromptEvalDuration time.Duration `json:"prompt_eval_duration,omitempty"`
+	EvalCount          int           `json:"eval_count,omitempty"`
+	EvalDuration       time.Duration `json:"eval_duration,omitempty"`
+}
+
+// PullProgress is one streamed status update from /api/pull.
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+}
+
+// ShowResponse is the model metadata returned by /api/show.
+type ShowResponse struct {
+	License       string         `json:"license"`
+	Modelfile     string         `json:"modelfile"`
+	Parameters    string         `json:"parameters"`
+	Template      string         `json:"template"`
+	System        string         `json:"system"`
+	Details       Details        `json:"details"`
+	ModelInfo     map[string]any `json:"model_info"`
+	ProjectorInfo map[string]any `json:"projector_info"`
+	Capabilities  []string       `json:"capabilities"`
+	ModifiedAt    time.Time      `json:"modified_at"`
+}
+
+// Architecture returns the GGUF architecture name, e.g. "llama" or "qwen2".
+func (s *ShowResponse) Architecture() string {
+	arch, _ := s.ModelInfo["general.architecture"].(string)
+	return arch
+}
+
+// ParameterCount returns the exact parameter count, or 0 if unknown.
+func (s *ShowResponse) ParameterCount() int64 {
+	n, _ := s.ModelInfo["general.parameter_count"].(float64)
+	return int64(n)
+}
+
+// ContextLength returns the model's trained context length, or 0 if unknown.
+func (s *ShowResponse) ContextLength() int {
+	return s.archInt("context_length")
+}
+
+// Embedding reports whether the model only produces embeddings. Servers that
+// predate capabilities are answered from the pooling type embedding models
+// declare.
+func (s *ShowResponse) Embedding() bool {
+	if len(s.Capabilities) > 0 {
+		return slices.Contains(s.Capabilities, "embedding") && !slices.Contains(s.Capabilities, "completion")
+	}
+	_, ok := s.ModelInfo[s.Architecture()+".pooling_type"]
+	return ok
+}
+
+// Vision reports whether the model accepts images. Servers that predate
+// capabilities are answered from the vision projector of models such as
+// llava, or the vision tower built into ones such as llama3.2-vision.
+func (s *ShowResponse) Vision() bool {
+	if len(s.Capabilities) > 0 {
+		return slices.Contains(s.Capabilities, "vision")
+	}
+	if len(s.ProjectorInfo) > 0 {
+		return true
+	}
+	_, ok := s.ModelInfo[s.Architecture()+".vision.block_count"]
+	return ok
+}
+
+// archInt reads an architecture-scoped integer such as "llama.block_count".
+func (s *ShowResponse) archInt(key string) int {
+	n, _ := s.ModelInfo[s.Architecture()+"."+key].(float64)
+	return int(n)
+}
+
+// BlockCount returns the number of transformer layers, or 0 if unknown.
+func (s *ShowResponse) BlockCount() int {
+	return s.archInt("block_count")
+}
+
+// HeadCountKV returns the number of key/value attention heads, falling back
+// to the query head count for models without grouped-query attention.
+func (s *ShowResponse) HeadCountKV() int {
+	if n := s.archInt("attention.head_count_kv"); n > 0 {
+		return n
+	}
+	return s.archInt("attention.head_count")
+}
+
+// HeadDim returns the per-head key dimension, or 0 if unknown.
+func (s *ShowResponse) HeadDim() int {
+	if n := s.archInt("attention.key_length"); n > 0 {
+		return n
+	}
+	heads := s.archInt("attention.head_count")
+	if heads == 0 {
+		return 0
+	}
+	return s.archInt("embedding_length") / heads
+}
+
+// Parameter returns the values of a Modelfile PARAMETER, e.g. "num_ctx".
+// Parameters such as "stop" may appear more than once.
+func (s *ShowResponse) Parameter(name string) []string {
+	var values []string
+	for _, line := range strings.Split(s.Parameters, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == name {
+			values = append(values, strings.Join(fields[1:], " "))
+		}
+	}
+	return values
+}
+
+// Message is one turn of a chat conversation.
+type Message struct {
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Images  []string `json:"images,omitempty"`
+}
+
+// ChatRequest is the body of /api/chat.
+type ChatRequest struct {
+	Model     string         `json:"model"`
+	Messages  []Message      `json:"messages"`
+	Stream    *bool          `json:"stream,omitempty"`
+	KeepAlive *Duration      `json:"keep_alive,omitempty"`
+	Options   map[string]any `json:"options,omitempty"`
+}
+
+// ChatResponse is one streamed chunk (or the final reply) from /api/chat.
+type ChatResponse struct {
+	Model      string    `json:"model"`
+	CreatedAt  time.Time `json:"created_at"`
+	Message    Message   `json:"message"`
+	Done       bool      `json:"done"`
+	DoneReason string    `json:"done_reason,omitempty"`
+	Metrics
+}
+
+// EmbedRequest is the body of /api/embed.
+type EmbedRequest struct {
+	Model     string    `json:"model"`
+	Input     []string  `json:"input"`
+	KeepAlive *Duration `json:"keep_alive,omitempty"`
+}
+
+// EmbedResponse holds one vector per input of an EmbedRequest.
+type EmbedResponse struct {
+	Model           string        `json:"model"`
+	Embeddings      [][]float64   `json:"embeddings"`
+	TotalDuration   time.Duration `json:"total_duration,omitempty"`
+	LoadDuration    time.Duration `json:"load_duration,omitempty"`
+	PromptEvalCount int           `json:"prompt_eval_count,omitempty"`
+}
+
+// TokensPerSecond returns the generation speed reported by the server.
+func (m Metrics) TokensPerSecond() float64 {
+	if m.EvalDuration <= 0 {
+		return 0
+	}
+	return float64(m.EvalCount) / m.EvalDuration.Seconds()
+}
+
+// PromptTokensPerSecond returns the prompt processing speed.
+func (m Metrics) PromptTokensPerSecond() float64 {
+	if m.PromptEvalDuration <= 0 {
+		return 0
+	}
+	return float64(m.PromptEvalCount) / m.PromptEvalDuration.Seconds()
+}
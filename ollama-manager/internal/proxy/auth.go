@@ -0,0 +1,149 @@
+package proxy
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// tokenPrefix marks the proxy's tokens, so they are recognizable in configs
+// and secret scanners.
+const tokenPrefix = "om-"
+
+// Key is an API key the proxy accepts.
+type Key struct {
+	Name   string
+	Hash   string   // HashToken of the token
+	Models []string // patterns of the models it may use; empty for all
+	Limits Limits
+}
+
+// NewToken generates a random API token.
+func NewToken() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return tokenPrefix + hex.EncodeToString(b), nil
+}
+
+// HashToken is what is stored of a token: its hex SHA-256.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Hint is the end of a token, shown to tell keys apart.
+func Hint(token string) string {
+	if len(token) <= 8 {
+		return "…"
+	}
+	return "…" + token[len(token)-4:]
+}
+
+// Allows reports whether the key may use model. A pattern without a tag,
+// such as "qwen3", matches every tag of the model. A key limited to some
+// models allows no unnamed one.
+func (k Key) Allows(model string) bool {
+	if len(k.Models) == 0 {
+		return true
+	}
+	base, _, _ := strings.Cut(model, ":")
+	for _, p := range k.Models {
+		if ok, _ := path.Match(p, model); ok {
+			return true
+		}
+		if !strings.Contains(p, ":") {
+			if ok, _ := path.Match(p, base); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// permits reports whether the key may make the request of ex. A key limited
+// to some models may only read without naming one; any other request, such
+// as a delete or one whose body couldn't be read, must name models it allows.
+func (k Key) permits(ex *exchange) (string, bool) {
+	if len(k.Models) == 0 {
+		return "", true
+	}
+	if len(ex.models) == 0 {
+		return "", ex.method == http.MethodGet || ex.method == http.MethodHead
+	}
+	for _, m := range ex.models {
+		if !k.Allows(m) {
+			return m, false
+		}
+	}
+	return "", true
+}
+
+// SetKeys replaces the API keys the proxy accepts. With none, requests need
+// no key.
+func (p *Proxy) SetKeys(keys []Key) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	byHash := make(map[string]Key, len(keys))
+	limiters := make(map[string]*limiter, len(keys))
+	for _, k := range keys {
+		byHash[k.Hash] = k
+		if l, ok := p.limiters[k.Name]; ok {
+			l.set(k.Limits)
+			limiters[k.Name] = l
+		} else {
+			limiters[k.Name] = newLimiter(k.Limits)
+		}
+	}
+	p.keys, p.limiters = byHash, limiters
+}
+
+// authorize checks the request's key against the model it names. A refused
+// request is answered here, and the status sent returned; 0 lets it through.
+// The token is removed so it doesn't reach the server.
+func (p *Proxy) authorize(w http.ResponseWriter, r *http.Request, ex *exchange) int {
+	p.mu.Lock()
+	keys := p.keys
+	p.mu.Unlock()
+	// CORS preflights carry no credentials.
+	if len(keys) == 0 || r.Method == http.MethodOptions {
+		return 0
+	}
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	r.Header.Del("Authorization")
+	k, ok := keys[HashToken(token)]
+	denied, permitted := k.permits(ex)
+	switch {
+	case !ok:
+		if token != "" {
+			ex.caller.Key = "(invalid)"
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="ollama-manager"`)
+		return refuse(w, http.StatusUnauthorized, "missing or invalid API key")
+	case ex.modelfile && len(k.Models) > 0:
+		ex.caller.Key = k.Name
+		return refuse(w, http.StatusForbidden, fmt.Sprintf("API key %q is limited to some models and may not create from a Modelfile; send \"from\" instead", k.Name))
+	case !permitted && denied == "":
+		ex.caller.Key = k.Name
+		return refuse(w, http.StatusForbidden, fmt.Sprintf("API key %q is limited to some models and the request names none", k.Name))
+	case !permitted:
+		ex.caller.Key = k.Name
+		return refuse(w, http.StatusForbidden, fmt.Sprintf("API key %q may not use model %q", k.Name, denied))
+	}
+	ex.caller.Key, ex.keyed = k.Name, true
+	return 0
+}
+
+// refuse answers with an error in the form Ollama uses and returns status.
+func refuse(w http.ResponseWriter, status int, msg string) int {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+	return status
+}
@@ -0,0 +1,46 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestModelAllowlist(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	defer upstream.Close()
+	p, err := New(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetKeys([]Key{{Name: "qwen", Hash: HashToken("om-test"), Models: []string{"qwen3"}}})
+
+	tests := []struct {
+		name, method, path, body string
+		want                     int
+	}{
+		{"list", "GET", "/api/tags", "", http.StatusOK},
+		{"allowed chat", "POST", "/api/chat", `{"model": "qwen3:8b"}`, http.StatusOK},
+		{"other model", "POST", "/api/chat", `{"model": "llama3.1"}`, http.StatusForbidden},
+		{"malformed body", "POST", "/api/chat", `{"model": "qwen3"`, http.StatusForbidden},
+		{"delete other", "DELETE", "/api/delete", `{"model": "llama3.1"}`, http.StatusForbidden},
+		{"delete unnamed", "DELETE", "/api/delete", ``, http.StatusForbidden},
+		{"copy to allowed", "POST", "/api/copy", `{"source": "qwen3", "destination": "qwen3:x"}`, http.StatusOK},
+		{"copy from other", "POST", "/api/copy", `{"source": "llama3.1", "destination": "qwen3:x"}`, http.StatusForbidden},
+		{"create from allowed", "POST", "/api/create", `{"model": "qwen3:x", "from": "qwen3:8b"}`, http.StatusOK},
+		{"create from other", "POST", "/api/create", `{"model": "qwen3:x", "from": "llama3.1"}`, http.StatusForbidden},
+		{"create from modelfile", "POST", "/api/create", `{"model": "qwen3:x", "modelfile": "FROM llama3.1"}`, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		r.Header.Set("Authorization", "Bearer om-test")
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, w.Code, tt.want, strings.TrimSpace(w.Body.String()))
+		}
+	}
+}
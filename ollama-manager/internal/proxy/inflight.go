@@ -0,0 +1,117 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// AbortPath cancels a generation in flight, for callers on the proxy's own
+// machine: POST it with ?id= set to the Active.ID.
+const AbortPath = "/ollama-manager/abort"
+
+// Active is a generation still in flight.
+type Active struct {
+	Caller
+	ID      int64     `json:"id"`
+	Started time.Time `json:"started"`
+	Method  string    `json:"method"`
+	Path    string    `json:"path"`
+	Model   string    `json:"model,omitempty"`
+	// Chunks counts the response lines streamed so far, about one per
+	// token for a streaming request.
+	Chunks int64 `json:"chunks"`
+}
+
+// track lists a generation as in flight until finish.
+func (p *Proxy) track(ex *exchange) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nextID++
+	ex.id = p.nextID
+	p.inflight[ex.id] = ex
+}
+
+func (p *Proxy) untrack(ex *exchange) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.inflight, ex.id)
+}
+
+// active lists the generations in flight, oldest first. The caller holds
+// p.mu.
+func (p *Proxy) active() []Active {
+	list := make([]Active, 0, len(p.inflight))
+	for _, ex := range p.inflight {
+		list = append(list, Active{Caller: ex.caller, ID: ex.id, Started: ex.start, Method: ex.method,
+			Path: ex.path, Model: ex.model, Chunks: ex.chunks.Load()})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Abort cancels the generation with the given ID, closing its connection
+// to the server, which stops generating. The client sees its response cut
+// short. It reports whether the generation was still in flight.
+func (p *Proxy) Abort(id int64) bool {
+	p.mu.Lock()
+	ex, ok := p.inflight[id]
+	p.mu.Unlock()
+	if !ok {
+		return false
+	}
+	ex.aborted.Store(true)
+	ex.cancel()
+	return true
+}
+
+// serveAbort answers local callers only, like serveStats.
+func (p *Proxy) serveAbort(w http.ResponseWriter, r *http.Request) {
+	if ip := net.ParseIP(clientAddr(r)); ip == nil || !ip.IsLoopback() {
+		http.Error(w, "aborting is only allowed from this machine", http.StatusForbidden)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "id must be a request number", http.StatusBadRequest)
+		return
+	}
+	if !p.Abort(id) {
+		http.Error(w, fmt.Sprintf("request %d is not in flight", id), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RequestAbort asks the proxy listening on listen, e.g. ":11435", to abort
+// the generation with the given ID.
+func RequestAbort(ctx context.Context, listen string, id int64) error {
+	u, err := localURL(listen, AbortPath+"?id="+strconv.FormatInt(id, 10))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("request %d is no longer in flight", id)
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("%s: %s", listen, resp.Status)
+	}
+	return nil
+}
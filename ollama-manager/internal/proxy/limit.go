@@ -0,0 +1,162 @@
+package proxy
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits caps requests. Zero fields mean no limit.
+type Limits struct {
+	// RequestsPerMinute allows bursts of up to that many requests, refilled
+	// evenly over the minute.
+	RequestsPerMinute int `json:"requests_per_minute,omitempty"`
+	// MaxConcurrent bounds generations in flight: chat, completion and
+	// embedding requests, whose responses may stream for minutes.
+	MaxConcurrent int `json:"max_concurrent,omitempty"`
+}
+
+func (l Limits) String() string {
+	var parts []string
+	if l.RequestsPerMinute > 0 {
+		parts = append(parts, fmt.Sprintf("%d/min", l.RequestsPerMinute))
+	}
+	if l.MaxConcurrent > 0 {
+		parts = append(parts, fmt.Sprintf("%d at once", l.MaxConcurrent))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Limit hit reasons, as counted in Usage.Limited and Snapshot.LimitHits.
+const (
+	LimitRate        = "rate"
+	LimitConcurrency = "concurrency"
+)
+
+// limiter enforces Limits with a token bucket for the rate and a count of
+// generations in flight. The proxy's mutex guards it.
+type limiter struct {
+	limits Limits
+	tokens float64
+	last   time.Time
+	active int
+}
+
+func newLimiter(l Limits) *limiter {
+	return &limiter{limits: l, tokens: float64(l.RequestsPerMinute)}
+}
+
+// set changes the limits, keeping the budget and generations in flight.
+func (l *limiter) set(limits Limits) {
+	if l.limits.RequestsPerMinute <= 0 {
+		l.tokens, l.last = float64(limits.RequestsPerMinute), time.Time{}
+	}
+	l.limits = limits
+	l.tokens = min(l.tokens, float64(limits.RequestsPerMinute))
+}
+
+// refill adds the tokens earned since the last call.
+func (l *limiter) refill(now time.Time) {
+	if l.limits.RequestsPerMinute <= 0 {
+		return
+	}
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Minutes() * float64(l.limits.RequestsPerMinute)
+		l.tokens = min(l.tokens, float64(l.limits.RequestsPerMinute))
+	}
+	l.last = now
+}
+
+// check reports why a request can't start now, and when to retry, without
+// taking anything; "" means it can.
+func (l *limiter) check(now time.Time, generation bool) (string, time.Duration) {
+	l.refill(now)
+	if generation && l.limits.MaxConcurrent > 0 && l.active >= l.limits.MaxConcurrent {
+		return LimitConcurrency, time.Second
+	}
+	if l.limits.RequestsPerMinute > 0 && l.tokens < 1 {
+		wait := time.Duration((1 - l.tokens) / float64(l.limits.RequestsPerMinute) * float64(time.Minute))
+		return LimitRate, wait
+	}
+	return "", 0
+}
+
+// take starts a request that passed check.
+func (l *limiter) take(generation bool) {
+	if l.limits.RequestsPerMinute > 0 {
+		l.tokens--
+	}
+	if generation {
+		l.active++
+	}
+}
+
+func (l *limiter) release() {
+	l.active = max(l.active-1, 0)
+}
+
+// SetLimits replaces the limits shared by all clients.
+func (p *Proxy) SetLimits(l Limits) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.global == nil {
+		p.global = newLimiter(l)
+		return
+	}
+	p.global.set(l)
+}
+
+// admit applies the key's limits and the global ones. A refused request is
+// answered with 429 and the status returned; 0 lets it through, holding a
+// generation slot until finish when it is one.
+func (p *Proxy) admit(w http.ResponseWriter, ex *exchange) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	limiters := []*limiter{p.global}
+	if l, ok := p.limiters[ex.caller.Key]; ok && ex.keyed {
+		limiters = append(limiters, l)
+	}
+	now := time.Now()
+	for _, l := range limiters {
+		if l == nil {
+			continue
+		}
+		if reason, wait := l.check(now, ex.generation); reason != "" {
+			ex.limited = reason
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			msg := "too many requests; try again shortly"
+			if reason == LimitConcurrency {
+				msg = "too many generations in flight; try again shortly"
+			}
+			return refuse(w, http.StatusTooManyRequests, msg)
+		}
+	}
+	for _, l := range limiters {
+		if l != nil {
+			l.take(ex.generation)
+			if ex.generation {
+				ex.held = append(ex.held, l)
+			}
+		}
+	}
+	return 0
+}
+
+// releaseSlots frees the generation slots ex holds.
+func (p *Proxy) releaseSlots(ex *exchange) {
+	if len(ex.held) == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, l := range ex.held {
+		l.release()
+	}
+	ex.held = nil
+}
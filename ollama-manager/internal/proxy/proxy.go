@@ -0,0 +1,290 @@
+// Package proxy is a reverse proxy in front of an Ollama server that
+// records who sends it requests: per API key and client address, how many,
+// the tokens they used and how long they took.
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ollama-manager/internal/activity"
+)
+
+// DefaultListen is the address the proxy listens on unless told otherwise,
+// next to Ollama's own 11434.
+const DefaultListen = ":11435"
+
+// StatsPath serves a Snapshot to callers on the proxy's own machine.
+const StatsPath = "/ollama-manager/stats"
+
+// maxModelBody bounds how much of a request body is read for its model.
+const maxModelBody = 32 << 20
+
+// Proxy forwards requests to an Ollama server. It implements http.Handler.
+type Proxy struct {
+	rp    *httputil.ReverseProxy
+	stats *Stats
+
+	mu       sync.Mutex
+	keys     map[string]Key      // by hash
+	limiters map[string]*limiter // by key name
+	global   *limiter
+	inflight map[int64]*exchange // generations, by ID
+	nextID   int64
+
+	// Log, if set, is called after every request.
+	Log func(Record)
+}
+
+// exchange is what the proxy knows about a request in flight.
+type exchange struct {
+	start        time.Time
+	caller       Caller
+	keyed        bool // caller.Key names an API key
+	method, path string
+	model        string     // the first of models, as recorded
+	models       []string   // every model the request names
+	modelfile    bool       // a create from a Modelfile, whose FROM isn't read
+	generation   bool       // the request runs a model
+	limited      string     // the limit that refused it
+	held         []*limiter // generation slots taken
+
+	id      int64 // set for generations, which can be aborted
+	cancel  context.CancelFunc
+	chunks  atomic.Int64 // response lines so far
+	aborted atomic.Bool
+}
+
+type exchangeKey struct{}
+
+// New returns a proxy to the server at upstream, a base URL such as
+// "http://127.0.0.1:11434".
+func New(upstream string) (*Proxy, error) {
+	u, err := url.Parse(upstream)
+	if err != nil {
+		return nil, err
+	}
+	p := &Proxy{stats: newStats(upstream), inflight: make(map[int64]*exchange)}
+	p.rp = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(u)
+			pr.SetXForwarded()
+		},
+		// Stream generated tokens as they come.
+		FlushInterval:  -1,
+		ModifyResponse: p.meter,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, "ollama-manager proxy: "+err.Error(), http.StatusBadGateway)
+			if ex, ok := r.Context().Value(exchangeKey{}).(*exchange); ok {
+				p.finish(ex, http.StatusBadGateway, usage{})
+			}
+		},
+	}
+	return p, nil
+}
+
+// Stats returns the traffic recorded so far.
+func (p *Proxy) Stats() Snapshot {
+	return p.snapshot()
+}
+
+func (p *Proxy) snapshot() Snapshot {
+	snap := p.stats.Snapshot()
+	p.mu.Lock()
+	if p.global != nil {
+		snap.Limits = p.global.limits
+	}
+	snap.Active = p.active()
+	p.mu.Unlock()
+	return snap
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case StatsPath:
+		p.serveStats(w, r)
+		return
+	case AbortPath:
+		p.serveAbort(w, r)
+		return
+	}
+	ex := &exchange{
+		start:  time.Now(),
+		caller: Caller{Key: tokenHint(r), Addr: clientAddr(r)},
+		method: r.Method,
+		path:   r.URL.Path,
+	}
+	ex.models, ex.modelfile = requestModels(r)
+	if len(ex.models) > 0 {
+		ex.model = ex.models[0]
+	}
+	ex.generation = activity.IsInference(ex.path)
+	if status := p.authorize(w, r, ex); status != 0 {
+		p.finish(ex, status, usage{})
+		return
+	}
+	if status := p.admit(w, ex); status != 0 {
+		p.finish(ex, status, usage{})
+		return
+	}
+	ctx, cancel := context.WithCancel(context.WithValue(r.Context(), exchangeKey{}, ex))
+	defer cancel()
+	ex.cancel = cancel
+	if ex.generation {
+		p.track(ex)
+	}
+	p.rp.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// serveStats answers local callers only, as the stats name every client.
+func (p *Proxy) serveStats(w http.ResponseWriter, r *http.Request) {
+	if ip := net.ParseIP(clientAddr(r)); ip == nil || !ip.IsLoopback() {
+		http.Error(w, "stats are only served to this machine", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p.snapshot())
+}
+
+// meter wraps the response body to read token usage from it as it streams
+// past, recording the request once it is closed.
+func (p *Proxy) meter(resp *http.Response) error {
+	ex, ok := resp.Request.Context().Value(exchangeKey{}).(*exchange)
+	if !ok {
+		return nil
+	}
+	resp.Body = &meteredBody{ReadCloser: resp.Body, lines: &ex.chunks, done: func(u usage) {
+		p.finish(ex, resp.StatusCode, u)
+	}}
+	return nil
+}
+
+func (p *Proxy) finish(ex *exchange, status int, u usage) {
+	p.releaseSlots(ex)
+	if ex.id != 0 {
+		p.untrack(ex)
+	}
+	rec := Record{
+		Time:         ex.start,
+		Caller:       ex.caller,
+		Method:       ex.method,
+		Path:         ex.path,
+		Model:        ex.model,
+		Status:       status,
+		Latency:      time.Since(ex.start),
+		InputTokens:  u.input,
+		OutputTokens: u.output,
+		GPUTime:      u.gpu,
+		Limited:      ex.limited,
+		Aborted:      ex.aborted.Load(),
+	}
+	p.stats.add(rec)
+	if p.Log != nil {
+		p.Log(rec)
+	}
+}
+
+// clientAddr returns the IP a request came from.
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// tokenHint identifies the bearer token a request carries without revealing
+// it, e.g. "…3f9a", or returns "" for none. Once the proxy has API keys,
+// authorize names the key instead.
+func tokenHint(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return ""
+	}
+	return Hint(token)
+}
+
+// requestModels reads the models a JSON request names, leaving the body to
+// be forwarded as it came: the model of most requests, the source and
+// destination of a copy, and the base of a create. A request whose body
+// can't be read for them, being too large or not JSON, names none. A create
+// from a legacy Modelfile is reported, as its base is in the FROM line.
+func requestModels(r *http.Request) ([]string, bool) {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions ||
+		r.Body == nil || strings.HasPrefix(r.URL.Path, "/api/blobs/") || r.ContentLength > maxModelBody {
+		return nil, false
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxModelBody+1))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	if err != nil || len(body) > maxModelBody {
+		return nil, false
+	}
+	var req struct {
+		Model       string `json:"model"`
+		Name        string `json:"name"`
+		Source      string `json:"source"`
+		Destination string `json:"destination"`
+		From        string `json:"from"`
+		Modelfile   string `json:"modelfile"`
+	}
+	if json.Unmarshal(body, &req) != nil {
+		return nil, false
+	}
+	var models []string
+	for _, m := range []string{req.Model, req.Source, req.Destination, req.From} {
+		if m != "" {
+			models = append(models, m)
+		}
+	}
+	if req.Model == "" && req.Name != "" {
+		models = append([]string{req.Name}, models...)
+	}
+	return models, req.Modelfile != ""
+}
+
+// localURL addresses path on the proxy listening on listen from this
+// machine.
+func localURL(listen, path string) (string, error) {
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "", err
+	}
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port) + path, nil
+}
+
+// Fetch asks the proxy listening on listen, e.g. ":11435", for its stats.
+func Fetch(ctx context.Context, listen string) (Snapshot, error) {
+	u, err := localURL(listen, StatsPath)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Snapshot{}, fmt.Errorf("%s: %s", listen, resp.Status)
+	}
+	var snap Snapshot
+	err = json.NewDecoder(resp.Body).Decode(&snap)
+	return snap, err
+}
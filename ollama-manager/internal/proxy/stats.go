@@ -0,0 +1,219 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// recentRecords is how many of the latest requests a Snapshot lists.
+const recentRecords = 50
+
+// maxLine bounds the response line buffered to read usage from; longer
+// lines, such as a large batch of embeddings, go uncounted.
+const maxLine = 8 << 20
+
+// Caller is who sent a request: the API key it carried, if any, and the
+// address it came from.
+type Caller struct {
+	Key  string `json:"key,omitempty"`
+	Addr string `json:"addr"`
+}
+
+// Record is one finished request.
+type Record struct {
+	Caller
+	Time         time.Time     `json:"time"`
+	Method       string        `json:"method"`
+	Path         string        `json:"path"`
+	Model        string        `json:"model,omitempty"`
+	Status       int           `json:"status"`
+	Latency      time.Duration `json:"latency"`
+	InputTokens  int           `json:"input_tokens,omitempty"`
+	OutputTokens int           `json:"output_tokens,omitempty"`
+	GPUTime      time.Duration `json:"gpu_time,omitempty"` // spent on the prompt and reply, as Ollama reports it
+	Limited      string        `json:"limited,omitempty"`  // the limit that refused it
+	Aborted      bool          `json:"aborted,omitempty"`  // cut short from the manager
+}
+
+// Usage totals the requests of one caller.
+type Usage struct {
+	Caller
+	Requests     int           `json:"requests"`
+	Errors       int           `json:"errors"`  // including Limited
+	Limited      int           `json:"limited"` // refused by a limit
+	InputTokens  int           `json:"input_tokens"`
+	OutputTokens int           `json:"output_tokens"`
+	Latency      time.Duration `json:"latency"` // summed over requests
+	Last         time.Time     `json:"last"`
+}
+
+// AvgLatency is the mean time a request took, to its last byte.
+func (u Usage) AvgLatency() time.Duration {
+	if u.Requests == 0 {
+		return 0
+	}
+	return u.Latency / time.Duration(u.Requests)
+}
+
+// Snapshot is the traffic a proxy has seen since it started.
+type Snapshot struct {
+	Upstream  string         `json:"upstream"`
+	Started   time.Time      `json:"started"`
+	Callers   []Usage        `json:"callers"` // busiest first
+	Recent    []Record       `json:"recent"`  // newest first
+	Active    []Active       `json:"active"`  // generations in flight, oldest first
+	Limits    Limits         `json:"limits"`  // shared by all clients
+	LimitHits map[string]int `json:"limit_hits"`
+}
+
+// Stats accumulates records. It is safe for concurrent use.
+type Stats struct {
+	mu       sync.Mutex
+	upstream string
+	started  time.Time
+	callers  map[Caller]*Usage
+	recent   []Record
+	hits     map[string]int // by limit
+}
+
+func newStats(upstream string) *Stats {
+	return &Stats{upstream: upstream, started: time.Now(), callers: make(map[Caller]*Usage), hits: make(map[string]int)}
+}
+
+func (s *Stats) add(r Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.callers[r.Caller]
+	if !ok {
+		u = &Usage{Caller: r.Caller}
+		s.callers[r.Caller] = u
+	}
+	u.Requests++
+	if r.Status >= 400 {
+		u.Errors++
+	}
+	if r.Limited != "" {
+		u.Limited++
+		s.hits[r.Limited]++
+	}
+	u.InputTokens += r.InputTokens
+	u.OutputTokens += r.OutputTokens
+	u.Latency += r.Latency
+	u.Last = r.Time
+	s.recent = append(s.recent, r)
+	if len(s.recent) > recentRecords {
+		s.recent = s.recent[len(s.recent)-recentRecords:]
+	}
+}
+
+// Snapshot copies the current totals.
+func (s *Stats) Snapshot() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap := Snapshot{Upstream: s.upstream, Started: s.started, Callers: make([]Usage, 0, len(s.callers)),
+		LimitHits: make(map[string]int, len(s.hits))}
+	for reason, n := range s.hits {
+		snap.LimitHits[reason] = n
+	}
+	for _, u := range s.callers {
+		snap.Callers = append(snap.Callers, *u)
+	}
+	sort.Slice(snap.Callers, func(i, j int) bool {
+		a, b := snap.Callers[i], snap.Callers[j]
+		if a.Requests != b.Requests {
+			return a.Requests > b.Requests
+		}
+		return a.Addr+a.Key < b.Addr+b.Key
+	})
+	for i := len(s.recent) - 1; i >= 0; i-- {
+		snap.Recent = append(snap.Recent, s.recent[i])
+	}
+	return snap
+}
+
+// usage is the token count of one response.
+type usage struct {
+	input, output int
+	gpu           time.Duration
+}
+
+// usageLine holds the token counts a response reports: Ollama's API in its
+// final object, the OpenAI-compatible one in "usage", which streams only
+// when the request asks for it with stream_options.include_usage.
+type usageLine struct {
+	PromptEvalCount    int   `json:"prompt_eval_count"`
+	EvalCount          int   `json:"eval_count"`
+	PromptEvalDuration int64 `json:"prompt_eval_duration"`
+	EvalDuration       int64 `json:"eval_duration"`
+	Usage              *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// meteredBody reads token usage from the lines of a response, JSON objects
+// or "data: " server-sent events, and reports it on Close.
+type meteredBody struct {
+	io.ReadCloser
+	line     []byte
+	overflow bool
+	usage    usage
+	lines    *atomic.Int64 // counts the lines read
+	done     func(usage)
+	once     sync.Once
+}
+
+func (b *meteredBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	for chunk := p[:n]; len(chunk) > 0; {
+		i := bytes.IndexByte(chunk, '\n')
+		if i < 0 {
+			b.buffer(chunk)
+			break
+		}
+		b.buffer(chunk[:i])
+		b.lines.Add(1)
+		b.scan()
+		chunk = chunk[i+1:]
+	}
+	return n, err
+}
+
+func (b *meteredBody) buffer(p []byte) {
+	if b.overflow || len(b.line)+len(p) > maxLine {
+		b.overflow, b.line = true, b.line[:0]
+		return
+	}
+	b.line = append(b.line, p...)
+}
+
+// scan takes the usage from the buffered line if it reports any.
+func (b *meteredBody) scan() {
+	line := bytes.TrimPrefix(bytes.TrimSpace(b.line), []byte("data: "))
+	if !b.overflow && (bytes.Contains(line, []byte(`eval_count"`)) || bytes.Contains(line, []byte(`"usage"`))) {
+		var u usageLine
+		if json.Unmarshal(line, &u) == nil {
+			switch {
+			case u.Usage != nil:
+				b.usage = usage{input: u.Usage.PromptTokens, output: u.Usage.CompletionTokens}
+			case u.EvalCount > 0 || u.PromptEvalCount > 0:
+				b.usage = usage{u.PromptEvalCount, u.EvalCount, time.Duration(u.PromptEvalDuration + u.EvalDuration)}
+			}
+		}
+	}
+	b.line, b.overflow = b.line[:0], false
+}
+
+func (b *meteredBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.scan() // a response without a trailing newline
+		b.done(b.usage)
+	})
+	return err
+}
@@ -0,0 +1,457 @@
+// Package pullqueue runs model pulls from a queue, a few at a time and
+// optionally under a bandwidth cap.
+//
+// Ollama downloads on the server and has no rate limit of its own, so the
+// cap is kept on average: once the pulls get ahead of it they are stopped,
+// and started again when the time has caught up. Ollama keeps partly
+// downloaded layers and carries on from them, so nothing is fetched twice.
+package pullqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ollama-manager/internal/ollama"
+)
+
+// burst is how far ahead of the cap pulls may get, in seconds of the cap,
+// before they are stopped; it keeps short pulls from stopping at all.
+const burst = 5
+
+// rateWindow is how often an item's download speed is resampled.
+const rateWindow = 500 * time.Millisecond
+
+// State is where an item is in the queue.
+type State int
+
+const (
+	Queued    State = iota
+	Pulling         // downloading
+	Throttled       // stopped to stay under the bandwidth cap, resumed later
+	Paused          // stopped by the user until resumed
+	Done
+	Failed
+	Cancelled
+)
+
+func (s State) String() string {
+	return [...]string{"queued", "pulling", "throttled", "paused", "done", "failed", "cancelled"}[s]
+}
+
+// Finished reports whether the item has left the queue for good.
+func (s State) Finished() bool {
+	return s >= Done
+}
+
+// Puller pulls a model; *ollama.Client is one.
+type Puller interface {
+	Pull(ctx context.Context, name string, fn func(ollama.PullProgress)) error
+}
+
+// Item is a snapshot of one queued pull.
+type Item struct {
+	ID     int
+	Name   string
+	State  State
+	Status string // Ollama's latest status line, e.g. "pulling 6a0746a1ec1a"
+	Total  int64  // bytes over the layers seen so far
+	Done   int64
+	Rate   float64 // bytes/s
+	Err    error
+
+	Started  time.Time // zero while queued
+	Finished time.Time
+}
+
+// Progress is the downloaded share, from 0 to 1.
+func (it Item) Progress() float64 {
+	if it.Total == 0 {
+		return 0
+	}
+	return float64(it.Done) / float64(it.Total)
+}
+
+type entry struct {
+	Item
+	p      Puller
+	layers map[string]*ollama.PullProgress
+	cancel context.CancelFunc
+
+	throttled bool // cancelled by the cap rather than by the user
+	pausing   bool // cancelled to be paused
+	reported  bool // handed out by TakeFinished
+
+	sampleAt    time.Time
+	sampleBytes int64
+}
+
+// Queue holds pulls waiting, running and finished.
+type Queue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	workers int
+	limit   float64 // bytes/s, 0 for none
+	entries []*entry
+	nextID  int
+
+	// The cap is kept from the start of a run of pulls: by now no more
+	// than limit per second since windowStart may have been downloaded.
+	windowStart time.Time
+	windowBytes int64
+	resumeAt    time.Time
+
+	changed chan struct{}
+}
+
+// New returns a queue running up to workers pulls at once, together
+// downloading no more than limit bytes per second on average (0 for no
+// limit).
+func New(workers int, limit float64) *Queue {
+	q := &Queue{workers: max(workers, 1), limit: limit, changed: make(chan struct{}, 1)}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// Add queues a pull of name through p. It reports false if name is
+// already waiting or being pulled.
+func (q *Queue) Add(p Puller, name string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, e := range q.entries {
+		if e.Name == name && !e.State.Finished() {
+			return false
+		}
+	}
+	q.nextID++
+	q.entries = append(q.entries, &entry{
+		Item:   Item{ID: q.nextID, Name: name, State: Queued, Status: "queued"},
+		p:      p,
+		layers: make(map[string]*ollama.PullProgress),
+	})
+	q.schedule()
+	return true
+}
+
+// Items returns a snapshot of the queue in order.
+func (q *Queue) Items() []Item {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	items := make([]Item, len(q.entries))
+	for i, e := range q.entries {
+		items[i] = e.Item
+	}
+	return items
+}
+
+// Limits returns how many pulls run at once and the bandwidth cap.
+func (q *Queue) Limits() (workers int, limit float64) {
+	return q.workers, q.limit
+}
+
+// Busy reports whether any pull is waiting or running.
+func (q *Queue) Busy() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.busy()
+}
+
+func (q *Queue) busy() bool {
+	for _, e := range q.entries {
+		if !e.State.Finished() {
+			return true
+		}
+	}
+	return false
+}
+
+// Changed delivers a value after the queue has changed. Changes are
+// coalesced, so a reader that falls behind sees one.
+func (q *Queue) Changed() <-chan struct{} {
+	return q.changed
+}
+
+// TakeFinished returns the items that finished since it was last called.
+func (q *Queue) TakeFinished() []Item {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var out []Item
+	for _, e := range q.entries {
+		if e.State.Finished() && !e.reported {
+			e.reported = true
+			out = append(out, e.Item)
+		}
+	}
+	return out
+}
+
+// Wait blocks until no pull is waiting or running.
+func (q *Queue) Wait() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.busy() {
+		q.cond.Wait()
+	}
+}
+
+// Move shifts the item with id by delta places, e.g. -1 to pull it
+// sooner. Moving a running pull changes nothing until it is throttled.
+func (q *Queue) Move(id, delta int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := q.index(id)
+	j := i + delta
+	if i < 0 || j < 0 || j >= len(q.entries) {
+		return
+	}
+	step := 1
+	if delta < 0 {
+		step = -1
+	}
+	for ; i != j; i += step {
+		q.entries[i], q.entries[i+step] = q.entries[i+step], q.entries[i]
+	}
+	q.notify()
+}
+
+// Cancel stops the item with id, or removes it if it has finished.
+func (q *Queue) Cancel(id int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := q.index(id)
+	if i < 0 {
+		return
+	}
+	e := q.entries[i]
+	switch e.State {
+	case Queued, Throttled, Paused:
+		q.finish(e, Cancelled, context.Canceled)
+	case Pulling:
+		e.throttled, e.pausing = false, false
+		e.cancel()
+	default:
+		q.entries = append(q.entries[:i], q.entries[i+1:]...)
+		q.notify()
+	}
+}
+
+// Pause stops the item with id until it is resumed. Ollama keeps what it
+// has downloaded so far.
+func (q *Queue) Pause(id int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := q.index(id)
+	if i < 0 {
+		return
+	}
+	e := q.entries[i]
+	switch e.State {
+	case Queued, Throttled:
+		e.State, e.Status = Paused, "paused"
+		q.notify()
+	case Pulling:
+		e.throttled, e.pausing = false, true
+		e.cancel()
+	}
+}
+
+// Resume puts a paused item back in line; it continues from the layers
+// downloaded before.
+func (q *Queue) Resume(id int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if i := q.index(id); i >= 0 && q.entries[i].State == Paused {
+		q.entries[i].State, q.entries[i].Status = Queued, "queued"
+		q.schedule()
+	}
+}
+
+// CancelAll stops every pull.
+func (q *Queue) CancelAll() {
+	q.mu.Lock()
+	ids := make([]int, 0, len(q.entries))
+	for _, e := range q.entries {
+		if !e.State.Finished() {
+			ids = append(ids, e.ID)
+		}
+	}
+	q.mu.Unlock()
+	for _, id := range ids {
+		q.Cancel(id)
+	}
+}
+
+func (q *Queue) index(id int) int {
+	for i, e := range q.entries {
+		if e.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// schedule starts waiting pulls while there are free workers and the cap
+// allows. The caller holds q.mu.
+func (q *Queue) schedule() {
+	defer q.notify()
+	now := time.Now()
+	if now.Before(q.resumeAt) {
+		return
+	}
+	running, waiting := 0, false
+	for _, e := range q.entries {
+		switch e.State {
+		case Pulling:
+			running++
+		case Throttled:
+			waiting = true
+		}
+	}
+	if running == 0 && !waiting {
+		q.windowStart, q.windowBytes = now, 0
+	}
+	for _, e := range q.entries {
+		if running >= q.workers {
+			return
+		}
+		if e.State == Queued || e.State == Throttled {
+			q.start(e)
+			running++
+		}
+	}
+}
+
+func (q *Queue) start(e *entry) {
+	ctx, cancel := context.WithCancel(context.Background())
+	e.State, e.Status, e.cancel = Pulling, "starting", cancel
+	if e.Started.IsZero() {
+		e.Started = time.Now()
+	}
+	e.sampleAt, e.sampleBytes = time.Now(), e.Done
+	go func() {
+		err := e.p.Pull(ctx, e.Name, func(pr ollama.PullProgress) {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			q.apply(e, pr)
+		})
+		cancel()
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		switch {
+		case e.pausing:
+			e.pausing = false
+			e.State, e.Status, e.Rate = Paused, "paused", 0
+		case e.throttled:
+			e.throttled = false
+			e.State, e.Status, e.Rate = Throttled, "waiting for the bandwidth cap", 0
+		case errors.Is(err, context.Canceled):
+			q.finish(e, Cancelled, err)
+		case err != nil:
+			q.finish(e, Failed, err)
+		default:
+			q.finish(e, Done, nil)
+		}
+		q.schedule()
+	}()
+}
+
+func (q *Queue) finish(e *entry, s State, err error) {
+	e.State, e.Err, e.Rate, e.Finished = s, err, 0, time.Now()
+	e.Status = s.String()
+	if err != nil && s == Failed {
+		e.Status = err.Error()
+	}
+	q.notify()
+}
+
+// apply folds a progress update into the item's totals and stops every
+// pull if they got ahead of the cap. The caller holds q.mu.
+func (q *Queue) apply(e *entry, pr ollama.PullProgress) {
+	if e.State != Pulling || e.throttled || e.pausing {
+		return
+	}
+	e.Status = pr.Status
+	if pr.Digest != "" {
+		// After a resume Ollama reports the layer from what it already had.
+		prev := int64(0)
+		if l, ok := e.layers[pr.Digest]; ok {
+			prev = l.Completed
+		}
+		layer := pr
+		e.layers[pr.Digest] = &layer
+		if delta := pr.Completed - prev; delta > 0 {
+			q.windowBytes += delta
+		}
+		e.Total, e.Done = 0, 0
+		for _, l := range e.layers {
+			e.Total += l.Total
+			e.Done += l.Completed
+		}
+		if elapsed := time.Since(e.sampleAt); elapsed >= rateWindow {
+			e.Rate = float64(e.Done-e.sampleBytes) / elapsed.Seconds()
+			e.sampleAt, e.sampleBytes = time.Now(), e.Done
+		}
+	}
+	q.notify()
+
+	if q.limit <= 0 {
+		return
+	}
+	allowed := q.limit * (time.Since(q.windowStart).Seconds() + burst)
+	if float64(q.windowBytes) <= allowed {
+		return
+	}
+	q.resumeAt = q.windowStart.Add(time.Duration(float64(q.windowBytes) / q.limit * float64(time.Second)))
+	for _, o := range q.entries {
+		if o.State == Pulling {
+			o.throttled = true
+			o.cancel()
+		}
+	}
+	time.AfterFunc(time.Until(q.resumeAt), func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.schedule()
+	})
+}
+
+// notify wakes Changed and Wait. The caller holds q.mu.
+func (q *Queue) notify() {
+	q.cond.Broadcast()
+	select {
+	case q.changed <- struct{}{}:
+	default:
+	}
+}
+
+// ParseRate reads a bandwidth such as "20MB/s", "500KiB/s" or "1.5GB" into
+// bytes per second. Empty and "0" mean no limit.
+func ParseRate(s string) (float64, error) {
+	t := strings.TrimSuffix(strings.TrimSpace(s), "/s")
+	if t == "" || t == "0" {
+		return 0, nil
+	}
+	i := strings.IndexFunc(t, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(t)
+	}
+	n, err := strconv.ParseFloat(t[:i], 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("bad rate %q (e.g. 20MB/s)", s)
+	}
+	units := map[string]float64{
+		"": 1, "B": 1,
+		"KB": 1e3, "MB": 1e6, "GB": 1e9,
+		"KIB": 1 << 10, "MIB": 1 << 20, "GIB": 1 << 30,
+		"K": 1e3, "M": 1e6, "G": 1e9,
+	}
+	mult, ok := units[strings.ToUpper(strings.TrimSpace(t[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("bad rate %q (e.g. 20MB/s)", s)
+	}
+	return n * mult, nil
+}
@@ -0,0 +1,171 @@
+// Package quant describes GGUF quantization types and recommends which one
+// to pull for a model size and amount of VRAM.
+package quant
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"ollama-manager/internal/library"
+	"ollama-manager/internal/vram"
+)
+
+// Level is one quantization type.
+type Level struct {
+	Name    string
+	Bits    float64 // average bits per weight, including scales
+	Quality int     // 1 (poor) to 5 (lossless)
+	Note    string
+}
+
+// Levels lists the common quantization types, smallest first. Bits are the
+// averages llama.cpp reports for a 7B model; the notes summarize its
+// perplexity measurements.
+var Levels = []Level{
+	{"Q2_K", 3.00, 1, "large quality loss"},
+	{"IQ3_XXS", 3.06, 2, "noticeable loss"},
+	{"Q3_K_S", 3.50, 2, "noticeable loss"},
+	{"Q3_K_M", 3.91, 2, "noticeable loss"},
+	{"IQ4_XS", 4.25, 3, "small loss"},
+	{"Q3_K_L", 4.27, 3, "some loss"},
+	{"Q4_0", 4.55, 3, "small loss, older format"},
+	{"Q4_K_S", 4.58, 3, "small loss"},
+	{"Q4_K_M", 4.89, 4, "small loss, Ollama's default"},
+	{"Q5_0", 5.54, 4, "very small loss, older format"},
+	{"Q5_K_S", 5.54, 4, "very small loss"},
+	{"Q5_K_M", 5.69, 4, "very small loss"},
+	{"Q6_K", 6.56, 5, "near lossless"},
+	{"Q8_0", 8.50, 5, "practically lossless"},
+	{"F16", 16, 5, "lossless, twice the size of Q8_0"},
+	{"BF16", 16, 5, "lossless, twice the size of Q8_0"},
+}
+
+// reference is the level speeds are relative to.
+const reference = "Q4_K_M"
+
+// Lookup finds a level by name, ignoring case.
+func Lookup(name string) (Level, bool) {
+	for _, l := range Levels {
+		if strings.EqualFold(l.Name, name) {
+			return l, true
+		}
+	}
+	return Level{}, false
+}
+
+// Bytes estimates the weights of a model with billions of parameters.
+func (l Level) Bytes(billions float64) int64 {
+	return int64(billions * 1e9 * l.Bits / 8)
+}
+
+// Speed estimates generation speed relative to Q4_K_M. Generating a token
+// reads every weight once, so on the GPU speed scales with their size.
+func (l Level) Speed() float64 {
+	ref, _ := Lookup(reference)
+	return ref.Bits / l.Bits
+}
+
+// Stars renders the quality rating, e.g. "★★★★☆".
+func (l Level) Stars() string {
+	return strings.Repeat("★", l.Quality) + strings.Repeat("☆", 5-l.Quality)
+}
+
+// suggestable reports whether a level is worth recommending: a known one,
+// and not F16, which is never better than Q8_0 in practice, only bigger and
+// slower.
+func (l Level) suggestable() bool {
+	return l.Quality > 0 && l.Bits < 16
+}
+
+// Advice is one level's estimate for a model size and amount of VRAM.
+type Advice struct {
+	Level
+	Size int64    // download size
+	Need uint64   // estimated VRAM at the default context
+	Fit  vram.Fit // against the VRAM given
+}
+
+// Advise estimates every level for a model with billions of parameters and
+// returns the index of the recommended one: the best quality that fits vram
+// with room to spare, or failing that the smallest. With vram 0 the fit is
+// unknown and Q4_K_M is recommended.
+func Advise(billions float64, vramBytes uint64) ([]Advice, int) {
+	advice := make([]Advice, len(Levels))
+	for i, l := range Levels {
+		advice[i] = ForSize(l, l.Bytes(billions), vramBytes)
+	}
+	return advice, Best(advice)
+}
+
+// Best returns the index of the recommended entry of advice, which may be
+// any subset of levels, such as the quants one repository offers.
+func Best(advice []Advice) int {
+	best := -1
+	for i, a := range advice {
+		if !a.suggestable() {
+			continue
+		}
+		switch {
+		case a.Fit == vram.FitUnknown && a.Name == reference:
+			return i
+		case a.Fit == vram.Fits && (best < 0 || a.Quality > advice[best].Quality ||
+			a.Quality == advice[best].Quality && a.Bits > advice[best].Bits):
+			best = i
+		}
+	}
+	if best < 0 && len(advice) > 0 {
+		// Nothing fits; the smallest spills least into system memory.
+		best = 0
+		for i, a := range advice {
+			if a.Size < advice[best].Size {
+				best = i
+			}
+		}
+	}
+	return best
+}
+
+// ForSize estimates a level whose download size is known, such as a file in
+// a repository, against vramBytes.
+func ForSize(l Level, size int64, vramBytes uint64) Advice {
+	a := Advice{Level: l, Size: size, Need: vram.ForModel(size, vram.Arch{}, vram.DefaultContext).Total()}
+	if vramBytes > 0 && size > 0 {
+		a.Fit = vram.Check(a.Need, vramBytes)
+	}
+	return a
+}
+
+var (
+	reQuant  = regexp.MustCompile(`(?i)(?:^|[-_.])(I?Q[1-8](?:_[A-Z0-9]+)+|BF16|F16|F32|FP16)(?:[-.]|$)`)
+	reParams = regexp.MustCompile(`(?i)(?:^|[-_:/.])((?:\d+x)?\d+(?:\.\d+)?[bm])(?:[-_.]|$)`)
+)
+
+// Of reads the quantization from a GGUF file name or model tag such as
+// "Qwen2.5-7B-Instruct-Q4_K_M.gguf" or "8b-instruct-q4_K_M", or returns ""
+// if it names none.
+func Of(name string) string {
+	if strings.EqualFold(path.Ext(name), ".gguf") {
+		name = strings.TrimSuffix(name, path.Ext(name))
+	}
+	m := reQuant.FindAllStringSubmatch(name, -1)
+	if m == nil {
+		return ""
+	}
+	q := strings.ToUpper(m[len(m)-1][1])
+	if q == "FP16" {
+		q = "F16"
+	}
+	return q
+}
+
+// Params reads the parameter count from a model name, tag or file name such
+// as "qwen3:32b", "32b" or "Qwen2.5-7B-Instruct-GGUF", in billions, or
+// returns 0 if it names none.
+func Params(name string) float64 {
+	m := reParams.FindStringSubmatch(name)
+	if m == nil {
+		return 0
+	}
+	return library.ParamBillions(m[1])
+}
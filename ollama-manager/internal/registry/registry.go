@@ -0,0 +1,87 @@
+// Package registry asks model registries which version of a model they
+// serve, so installed models can be checked for updates without pulling.
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultHost is the registry of models named without a host, such as
+// "llama3.1:8b".
+const DefaultHost = "registry.ollama.ai"
+
+// maxManifest caps how much of a manifest is read; real ones are a few KB.
+const maxManifest = 1 << 20
+
+// ErrNotFound is returned for models the registry doesn't have, usually
+// because they were created locally.
+var ErrNotFound = errors.New("registry: model not found")
+
+// Client fetches manifests.
+type Client struct {
+	scheme string
+	http   *http.Client
+}
+
+// NewClient returns a client for registries served over HTTPS.
+func NewClient() *Client {
+	return &Client{scheme: "https", http: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Ref splits a model name the way Ollama does: "host/namespace/model:tag",
+// with the host defaulting to DefaultHost, the namespace to "library" and
+// the tag to "latest".
+func Ref(name string) (host, repo, tag string) {
+	tag = "latest"
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, tag = name[:i], name[i+1:]
+	}
+	parts := strings.Split(name, "/")
+	switch len(parts) {
+	case 1:
+		return DefaultHost, "library/" + name, tag
+	case 2:
+		return DefaultHost, name, tag
+	}
+	return parts[0], strings.Join(parts[1:], "/"), tag
+}
+
+// Digest returns the digest Ollama would list for name if it were pulled
+// now, which is the SHA-256 of the model's manifest.
+func (c *Client) Digest(ctx context.Context, name string) (string, error) {
+	host, repo, tag := Ref(name)
+	url := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", c.scheme, host, repo, tag)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.docker.distribution.manifest.v2+json")
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	if err != nil {
+		slog.Warn("registry", "model", name, "err", err)
+		return "", err
+	}
+	defer resp.Body.Close()
+	slog.Debug("registry", "model", name, "status", resp.StatusCode, "took", time.Since(start).Round(time.Millisecond))
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("registry: %s: %s", host, resp.Status)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, io.LimitReader(resp.Body, maxManifest)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,63 @@
+// Package release asks GitHub for the newest Ollama release, so the
+// installed server can be compared against it.
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// latestURL is GitHub's API for the newest release that is neither a draft
+// nor a pre-release.
+const latestURL = "https://api.github.com/repos/ollama/ollama/releases/latest"
+
+// Release is one Ollama release.
+type Release struct {
+	Version   string // without the leading "v", as the server reports it
+	URL       string // release notes
+	Published time.Time
+}
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// Latest returns the newest Ollama release.
+func Latest(ctx context.Context) (*Release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, latestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		slog.Warn("release", "err", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	slog.Debug("release", "status", resp.StatusCode, "took", time.Since(start).Round(time.Millisecond))
+	if resp.StatusCode != http.StatusOK {
+		// 403 usually means the hourly limit for unauthenticated calls.
+		return nil, fmt.Errorf("GitHub releases: %s", resp.Status)
+	}
+	var body struct {
+		TagName     string    `json:"tag_name"`
+		HTMLURL     string    `json:"html_url"`
+		PublishedAt time.Time `json:"published_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("GitHub releases: %w", err)
+	}
+	if body.TagName == "" {
+		return nil, fmt.Errorf("GitHub releases: no tag in the latest release")
+	}
+	return &Release{
+		Version:   strings.TrimPrefix(body.TagName, "v"),
+		URL:       body.HTMLURL,
+		Published: body.PublishedAt,
+	}, nil
+}
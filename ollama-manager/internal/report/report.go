@@ -0,0 +1,96 @@
+// Package report writes tables of results, such as benchmarks, as CSV or
+// Markdown for sharing.
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Table is one titled table of a report.
+type Table struct {
+	Title  string
+	Header []string
+	Rows   [][]string
+	Note   string // a line below the table, such as a conclusion
+}
+
+// Formats are the report formats, by file extension.
+var Formats = map[string]string{"csv": "CSV", "md": "Markdown", "json": "JSON"}
+
+// Text lays t out in aligned columns for the terminal, without its title.
+func Text(t Table) string {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.Header, "\t"))
+	for _, row := range t.Rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	tw.Flush()
+	return b.String()
+}
+
+// CSV writes the tables one after another, each headed by its title on a
+// row of its own and followed by an empty row, so a spreadsheet shows them
+// as separate blocks.
+func CSV(w io.Writer, tables []Table) error {
+	cw := csv.NewWriter(w)
+	for i, t := range tables {
+		if i > 0 {
+			cw.Write(nil)
+		}
+		if t.Title != "" {
+			cw.Write([]string{t.Title})
+		}
+		cw.Write(t.Header)
+		for _, row := range t.Rows {
+			cw.Write(row)
+		}
+		if t.Note != "" {
+			cw.Write([]string{t.Note})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Markdown writes the tables as GitHub-flavored Markdown, which Reddit
+// renders too, under an optional heading.
+func Markdown(w io.Writer, heading string, tables []Table) error {
+	var b strings.Builder
+	if heading != "" {
+		fmt.Fprintf(&b, "## %s\n\n", heading)
+	}
+	for _, t := range tables {
+		if t.Title != "" {
+			fmt.Fprintf(&b, "### %s\n\n", t.Title)
+		}
+		b.WriteString(mdRow(t.Header))
+		sep := make([]string, len(t.Header))
+		for i := range sep {
+			sep[i] = "---"
+		}
+		b.WriteString(mdRow(sep))
+		for _, row := range t.Rows {
+			b.WriteString(mdRow(row))
+		}
+		if t.Note != "" {
+			fmt.Fprintf(&b, "\n%s\n", t.Note)
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mdRow is one table row, with pipes in cells escaped.
+func mdRow(cells []string) string {
+	escaped := make([]string, len(cells))
+	for i, c := range cells {
+		escaped[i] = strings.ReplaceAll(strings.ReplaceAll(c, "|", `\|`), "\n", " ")
+	}
+	return "| " + strings.Join(escaped, " | ") + " |\n"
+}
@@ -0,0 +1,44 @@
+// Package resume notices that the machine slept and woke up again, after
+// which GPU handles and what a server had loaded can no longer be trusted.
+package resume
+
+import "time"
+
+// slack is how much longer than expected a gap between checks must be to
+// count as sleep rather than a busy machine.
+const slack = 30 * time.Second
+
+// Detector is checked on a regular interval and reports the checks that
+// came after a sleep. A nil Detector never reports one.
+type Detector struct {
+	every time.Duration
+	last  time.Time
+}
+
+// NewDetector returns a Detector checked every interval.
+func NewDetector(every time.Duration) *Detector {
+	return &Detector{every: every}
+}
+
+// Check records a check at now, which must come from time.Now, and reports
+// roughly how long the machine slept since the previous one, if it did.
+//
+// The monotonic clock stops during sleep on Linux and macOS, so the wall
+// clock runs ahead of it; on Windows it doesn't stop, and only the check
+// coming far later than due gives the sleep away.
+func (d *Detector) Check(now time.Time) (slept time.Duration, ok bool) {
+	if d == nil {
+		return 0, false
+	}
+	last := d.last
+	d.last = now
+	if last.IsZero() {
+		return 0, false
+	}
+	wall := now.Round(0).Sub(last.Round(0))
+	slept = max(wall-now.Sub(last), wall-d.every)
+	if slept < slack {
+		return 0, false
+	}
+	return slept, true
+}
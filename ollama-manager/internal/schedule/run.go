@@ -0,0 +1,36 @@
+package schedule
+
+import (
+	"context"
+	"time"
+)
+
+// Job is an action run whenever its spec matches.
+type Job struct {
+	Name string
+	Spec Spec
+	Run  func() error
+}
+
+// Run fires each job at the start of every minute its spec matches until
+// ctx is done, passing the outcome to done. Jobs due in the same minute run
+// one after another in the order given; minutes spent running them, or
+// asleep, are not caught up on.
+func Run(ctx context.Context, jobs []Job, done func(Job, error)) {
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		for _, j := range jobs {
+			if ctx.Err() == nil && j.Spec.Matches(next) {
+				done(j, j.Run())
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Package schedule parses cron expressions and runs jobs on them.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Spec is a parsed five-field cron expression: minute, hour, day of month,
+// month and day of week, e.g. "30 8 * * mon-fri".
+type Spec struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64 // bit i set when value i matches
+	domAny, dowAny                bool
+}
+
+// field describes the range and names of one cron field.
+type field struct {
+	name     string
+	min, max int
+	names    []string // names[i] stands for min+i
+}
+
+var fields = [5]field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	// 7 is accepted as Sunday too and folded onto 0.
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// Parse reads a cron expression. Each field is "*", a value, a range such
+// as "1-5" or a comma-separated list of those, optionally with a step as in
+// "*/15". Months and weekdays may be given by their first three letters.
+func Parse(expr string) (Spec, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return Spec{}, fmt.Errorf("cron %q: want 5 fields (minute hour day month weekday), got %d", expr, len(parts))
+	}
+	s := Spec{expr: expr}
+	sets := [5]*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for i, p := range parts {
+		bits, err := fields[i].parse(p)
+		if err != nil {
+			return Spec{}, fmt.Errorf("cron %q: %w", expr, err)
+		}
+		*sets[i] = bits
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny, s.dowAny = parts[2] == "*", parts[4] == "*"
+	return s, nil
+}
+
+func (f field) parse(s string) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("%s: bad step %q", f.name, stepStr)
+			}
+			step = n
+		}
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(a); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(b); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max // "5/15" means from 5 on
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("%s: empty range %q", f.name, rng)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func (f field) value(s string) (int, error) {
+	for i, n := range f.names {
+		if strings.EqualFold(s, n) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: %q is not between %d and %d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+func (s Spec) String() string {
+	return s.expr
+}
+
+// Matches reports whether the spec fires in the minute of t. As in cron,
+// when both the day of month and the day of week are restricted, either
+// one matching is enough.
+func (s Spec) Matches(t time.Time) bool {
+	if s.minute&(1<<t.Minute()) == 0 || s.hour&(1<<t.Hour()) == 0 || s.month&(1<<int(t.Month())) == 0 {
+		return false
+	}
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<int(t.Weekday())) != 0
+	switch {
+	case s.domAny || s.dowAny:
+		return dom && dow
+	default:
+		return dom || dow
+	}
+}
+
+// Next returns the first minute after t the spec fires in, or the zero time
+// if it doesn't within a year (e.g. "0 0 30 2 *").
+func (s Spec) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute)
+	for end := t.AddDate(1, 0, 1); t.Before(end); {
+		t = t.Add(time.Minute)
+		if s.Matches(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Runtimes are the container engines a service name may start with, as in
+// "docker:ollama" or "podman:ollama".
+var Runtimes = []string{"docker", "podman"}
+
+// DefaultImage is Ollama's official image.
+const DefaultImage = "ollama/ollama"
+
+// errContainerEnv explains why a container's settings can't be edited in
+// place.
+var errContainerEnv = errors.New(`a container gets its environment when it is created: set "env" under "container" in the config and recreate it`)
+
+// ContainerName returns the name of a container service, "docker:ollama",
+// split into its runtime and container, or false for any other service.
+func ContainerName(name string) (rt, container string, ok bool) {
+	rt, container, ok = strings.Cut(name, ":")
+	if !ok || container == "" {
+		return "", "", false
+	}
+	for _, r := range Runtimes {
+		if rt == r {
+			return rt, container, true
+		}
+	}
+	return "", "", false
+}
+
+// FindContainer looks for a running container that publishes port, the way
+// the Ollama image is usually run, and returns its service name.
+func FindContainer(ctx context.Context, port string) (string, error) {
+	for _, rt := range Runtimes {
+		if _, err := exec.LookPath(rt); err != nil {
+			continue
+		}
+		out, err := run(ctx, rt, "ps", "--format", "{{.Names}}\t{{.Ports}}")
+		if err != nil {
+			// Usually the daemon isn't running or needs root.
+			continue
+		}
+		for _, line := range strings.Split(out, "\n") {
+			// "ollama	0.0.0.0:11434->11434/tcp, [::]:11434->11434/tcp"
+			name, ports, ok := strings.Cut(strings.TrimSpace(line), "\t")
+			if ok && strings.Contains(ports, ":"+port+"->") {
+				return rt + ":" + strings.Split(name, ",")[0], nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// containerState is how `inspect` reports a container's state.
+const containerState = "{{.State.Status}}\t{{.State.Pid}}\t{{.State.StartedAt}}\t{{.State.FinishedAt}}"
+
+func queryContainer(ctx context.Context, rt, name string) (*Status, error) {
+	out, err := run(ctx, rt, "inspect", "--format", containerState, name)
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "no such") {
+			return nil, ErrNotInstalled
+		}
+		return nil, err
+	}
+	f := strings.Split(strings.TrimSpace(out), "\t")
+	if len(f) < 4 {
+		return nil, fmt.Errorf("%s inspect: unexpected output %q", rt, out)
+	}
+	st := &Status{Manager: rt, Name: name}
+	since := f[3]
+	switch f[0] {
+	case "running":
+		st.State = Running
+		st.PID, _ = strconv.Atoi(f[1])
+		since = f[2]
+	case "restarting":
+		st.State = Starting
+	case "removing", "stopping":
+		st.State = Stopping
+	case "exited", "created", "configured", "stopped", "paused":
+		st.State = Stopped
+	case "dead":
+		st.State = Failed
+	}
+	// Docker prints RFC 3339, Podman Go's default time format.
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999 -0700 MST"} {
+		if t, err := time.Parse(layout, since); err == nil && t.Year() > 1 {
+			st.Since = t
+			break
+		}
+	}
+	return st, nil
+}
+
+func controlContainer(ctx context.Context, rt, name string, a Action) error {
+	_, err := run(ctx, rt, string(a), name)
+	return err
+}
+
+// containerLog returns the container's output, where the server logs to.
+func containerLog(ctx context.Context, rt, name string, lines int) (string, error) {
+	return run(ctx, rt, "logs", "--tail", strconv.Itoa(lines), name)
+}
+
+// envContainer reads the Settings the container was created with.
+func envContainer(ctx context.Context, rt, name string) (map[string]string, error) {
+	out, err := run(ctx, rt, "inspect", "--format", "{{range .Config.Env}}{{println .}}{{end}}", name)
+	if err != nil {
+		return nil, err
+	}
+	env := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(line), "="); ok && known(k) {
+			env[k] = v
+		}
+	}
+	return env, nil
+}
+
+// ContainerSpec is what a container is recreated from.
+type ContainerSpec struct {
+	Image   string            // default DefaultImage
+	Ports   []string          // as for -p, default the API port
+	Volumes []string          // as for -v, default a named volume for models
+	Env     map[string]string // as for -e
+	Args    []string          // further flags for run
+}
+
+// runArgs turns spec into `run` arguments for the container, giving it
+// every GPU through the NVIDIA container toolkit: --gpus for Docker, the
+// CDI device for Podman.
+func (spec ContainerSpec) runArgs(rt, name string) []string {
+	args := []string{"run", "--detach", "--name", name, "--restart", "unless-stopped"}
+	if rt == "podman" {
+		args = append(args, "--device", "nvidia.com/gpu=all")
+	} else {
+		args = append(args, "--gpus", "all")
+	}
+	ports := spec.Ports
+	if len(ports) == 0 {
+		ports = []string{"11434:11434"}
+	}
+	for _, p := range ports {
+		args = append(args, "--publish", p)
+	}
+	volumes := spec.Volumes
+	if len(volumes) == 0 {
+		volumes = []string{"ollama:/root/.ollama"}
+	}
+	for _, v := range volumes {
+		args = append(args, "--volume", v)
+	}
+	keys := make([]string, 0, len(spec.Env))
+	for k := range spec.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "--env", k+"="+spec.Env[k])
+	}
+	args = append(args, spec.Args...)
+	image := spec.Image
+	if image == "" {
+		image = DefaultImage
+	}
+	return append(args, image)
+}
+
+// Recreate replaces a container service with one made from spec, pulling
+// the image first so recreating also updates Ollama. Models survive in
+// the volume they are stored in.
+func Recreate(ctx context.Context, name string, spec ContainerSpec) error {
+	rt, container, ok := ContainerName(name)
+	if !ok {
+		return fmt.Errorf("%s is not a container; name one as docker:NAME or podman:NAME", name)
+	}
+	args := spec.runArgs(rt, container)
+	if _, err := run(ctx, rt, "pull", args[len(args)-1]); err != nil {
+		return err
+	}
+	if _, err := run(ctx, rt, "rm", "--force", container); err != nil && !strings.Contains(strings.ToLower(err.Error()), "no such") {
+		return err
+	}
+	_, err := run(ctx, rt, args...)
+	return err
+}
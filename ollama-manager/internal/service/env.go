@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Setting is an environment variable the Ollama server reads at startup.
+type Setting struct {
+	Name string
+	Help string
+}
+
+// Settings are the variables offered for editing, most useful for tuning
+// first.
+var Settings = []Setting{
+	{"OLLAMA_NUM_PARALLEL", "Requests each model serves at once; each one multiplies the KV cache"},
+	{"OLLAMA_MAX_LOADED_MODELS", "Models kept in memory at the same time"},
+	{"OLLAMA_KV_CACHE_TYPE", "KV cache quantization: f16, q8_0 (half the VRAM) or q4_0; needs flash attention"},
+	{"OLLAMA_FLASH_ATTENTION", "1 enables flash attention, cutting KV cache memory on supported GPUs"},
+	{"OLLAMA_CONTEXT_LENGTH", "Default num_ctx for models that don't set one"},
+	{"OLLAMA_KEEP_ALIVE", "How long idle models stay loaded, e.g. 5m, 1h or -1"},
+	{"OLLAMA_MAX_QUEUE", "Requests queued before the server answers busy"},
+	{"OLLAMA_GPU_OVERHEAD", "Bytes of VRAM per GPU to leave free for other programs"},
+	{"OLLAMA_SCHED_SPREAD", "1 spreads every model across all GPUs"},
+	{"CUDA_VISIBLE_DEVICES", "GPUs Ollama may use, e.g. 0 or 0,1"},
+	{"OLLAMA_HOST", "Address the server listens on, e.g. 0.0.0.0:11434"},
+	{"OLLAMA_ORIGINS", "Extra origins allowed to call the API from a browser"},
+	{"OLLAMA_MODELS", "Directory models are stored in"},
+}
+
+// Env returns the values of Settings the service starts with; unset
+// variables are left out.
+func Env(ctx context.Context, name string) (map[string]string, error) {
+	if rt, container, ok := ContainerName(name); ok {
+		return envContainer(ctx, rt, container)
+	}
+	switch runtime.GOOS {
+	case "linux":
+		return envSystemd(ctx, name)
+	case "darwin":
+		return envLaunchd(ctx)
+	case "windows":
+		return envWindows(ctx)
+	}
+	return nil, fmt.Errorf("editing the Ollama environment is not supported on %s", runtime.GOOS)
+}
+
+// SetEnv applies changes to the service's environment; an empty value
+// removes the variable. The service must be restarted to pick them up.
+func SetEnv(ctx context.Context, name string, changes map[string]string) error {
+	if _, _, ok := ContainerName(name); ok {
+		return errContainerEnv
+	}
+	switch runtime.GOOS {
+	case "linux":
+		return setEnvSystemd(ctx, name, changes)
+	case "darwin":
+		return setEnvLaunchd(ctx, changes)
+	case "windows":
+		return setEnvWindows(ctx, changes)
+	}
+	return fmt.Errorf("editing the Ollama environment is not supported on %s", runtime.GOOS)
+}
+
+// EnvLocation describes where SetEnv writes on this platform, for display.
+func EnvLocation(name string) string {
+	if _, _, ok := ContainerName(name); ok {
+		return ""
+	}
+	switch runtime.GOOS {
+	case "linux":
+		return dropInPath(name)
+	case "darwin":
+		return "launchctl setenv (until logout)"
+	case "windows":
+		return `HKCU\Environment`
+	}
+	return ""
+}
+
+func known(key string) bool {
+	for _, s := range Settings {
+		if s.Name == key {
+			return true
+		}
+	}
+	return false
+}
+
+// systemd keeps the manager's settings in a drop-in of its own so the unit
+// file the installer wrote is never touched.
+func dropInPath(name string) string {
+	return filepath.Join("/etc/systemd/system", name+".service.d", "ollama-manager.conf")
+}
+
+func envSystemd(ctx context.Context, name string) (map[string]string, error) {
+	out, err := run(ctx, "systemctl", "show", name, "--no-pager", "--property=LoadState,Environment")
+	if err != nil {
+		return nil, err
+	}
+	kv := keyValues(out, "=")
+	if kv["LoadState"] == "not-found" {
+		return nil, ErrNotInstalled
+	}
+	env := make(map[string]string)
+	for _, assign := range splitQuoted(kv["Environment"]) {
+		if k, v, ok := strings.Cut(assign, "="); ok && known(k) {
+			env[k] = v
+		}
+	}
+	return env, nil
+}
+
+func setEnvSystemd(ctx context.Context, name string, changes map[string]string) error {
+	path := dropInPath(name)
+	env, err := readDropIn(path)
+	if err != nil {
+		return err
+	}
+	for k, v := range changes {
+		if v == "" {
+			delete(env, k)
+		} else {
+			env[k] = v
+		}
+	}
+
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString("# Written by ollama-manager. Edit with its settings screen or by hand.\n[Service]\n")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "Environment=%s\n", strconv.Quote(k+"="+env[k]))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return permissionHint(err)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return permissionHint(err)
+	}
+	_, err = run(ctx, "systemctl", "--no-ask-password", "daemon-reload")
+	return err
+}
+
+// readDropIn parses the Environment= lines of the manager's drop-in; a
+// missing file is empty.
+func readDropIn(path string) (map[string]string, error) {
+	env := make(map[string]string)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return env, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), "Environment=")
+		if !ok {
+			continue
+		}
+		for _, assign := range splitQuoted(value) {
+			if k, v, ok := strings.Cut(assign, "="); ok {
+				env[k] = v
+			}
+		}
+	}
+	return env, nil
+}
+
+func permissionHint(err error) error {
+	if errors.Is(err, fs.ErrPermission) {
+		return fmt.Errorf("%w (run the manager as root to change the service environment)", err)
+	}
+	return err
+}
+
+// splitQuoted splits systemd's space-separated assignments, where an
+// assignment containing spaces is wrapped in double quotes.
+func splitQuoted(s string) []string {
+	var fields []string
+	var cur strings.Builder
+	inQuote, escaped := false, false
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && inQuote:
+			escaped = true
+		case r == '"':
+			inQuote = !inQuote
+		case r == ' ' && !inQuote:
+			if cur.Len() > 0 {
+				fields = append(fields, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		fields = append(fields, cur.String())
+	}
+	return fields
+}
+
+// launchd variables are what the Ollama app and Homebrew service see when
+// started from the user's session.
+func envLaunchd(ctx context.Context) (map[string]string, error) {
+	env := make(map[string]string)
+	for _, s := range Settings {
+		out, err := run(ctx, "launchctl", "getenv", s.Name)
+		if err != nil {
+			return nil, err
+		}
+		if v := strings.TrimSpace(out); v != "" {
+			env[s.Name] = v
+		}
+	}
+	return env, nil
+}
+
+func setEnvLaunchd(ctx context.Context, changes map[string]string) error {
+	for k, v := range changes {
+		var err error
+		if v == "" {
+			_, err = run(ctx, "launchctl", "unsetenv", k)
+		} else {
+			_, err = run(ctx, "launchctl", "setenv", k, v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// On Windows Ollama reads user environment variables, which live in the
+// registry; setx and reg write them there without needing elevation.
+func envWindows(ctx context.Context) (map[string]string, error) {
+	out, err := run(ctx, "reg", "query", `HKCU\Environment`)
+	if err != nil {
+		return nil, err
+	}
+	env := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		// "    OLLAMA_HOST    REG_SZ    0.0.0.0"
+		f := strings.Fields(line)
+		if len(f) < 3 || !strings.HasPrefix(f[1], "REG_") || !known(f[0]) {
+			continue
+		}
+		_, value, _ := strings.Cut(line, f[1])
+		env[f[0]] = strings.TrimSpace(value)
+	}
+	return env, nil
+}
+
+func setEnvWindows(ctx context.Context, changes map[string]string) error {
+	for k, v := range changes {
+		var err error
+		if v == "" {
+			_, err = run(ctx, "reg", "delete", `HKCU\Environment`, "/v", k, "/f")
+		} else {
+			_, err = run(ctx, "setx", k, v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"ollama-manager/internal/applog"
+)
+
+// launchdDomain is the per-user domain Homebrew services run in.
+func launchdDomain() string {
+	return fmt.Sprintf("gui/%d", os.Getuid())
+}
+
+// launchdPlist is where `brew services` installs the agent's definition.
+func launchdPlist(name string) string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "Library", "LaunchAgents", name+".plist")
+}
+
+func queryLaunchd(ctx context.Context, name string) (*Status, error) {
+	st := &Status{Manager: "launchd", Name: name}
+	start := time.Now()
+	args := []string{"launchctl", "print", launchdDomain() + "/" + name}
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+	applog.Command(slog.LevelDebug, start, args, err)
+	if err != nil {
+		// Not bootstrapped: stopped if the agent is installed at all.
+		if _, statErr := os.Stat(launchdPlist(name)); statErr == nil {
+			st.State = Stopped
+			return st, nil
+		}
+		return nil, ErrNotInstalled
+	}
+
+	kv := keyValues(string(out), " = ")
+	st.State = Stopped
+	if kv["state"] == "running" {
+		st.State = Running
+	}
+	st.PID, _ = strconv.Atoi(kv["pid"])
+	if st.PID > 0 {
+		st.Since = processStart(ctx, st.PID)
+	}
+	return st, nil
+}
+
+// controlLaunchd bootstraps the agent to start it and boots it out to stop
+// it, since Homebrew's agent has KeepAlive set and launchd would restart a
+// process that was merely killed.
+func controlLaunchd(ctx context.Context, name string, a Action) error {
+	target := launchdDomain() + "/" + name
+	switch a {
+	case Start:
+		if _, err := run(ctx, "launchctl", "print", target); err != nil {
+			_, err := run(ctx, "launchctl", "bootstrap", launchdDomain(), launchdPlist(name))
+			return err
+		}
+		_, err := run(ctx, "launchctl", "kickstart", target)
+		return err
+	case Stop:
+		_, err := run(ctx, "launchctl", "bootout", target)
+		return err
+	case Restart:
+		_, err := run(ctx, "launchctl", "kickstart", "-k", target)
+		return err
+	}
+	return fmt.Errorf("unknown action %q", a)
+}
+
+// processStart asks ps how long pid has been running. The zero time is
+// returned if it can't tell.
+func processStart(ctx context.Context, pid int) time.Time {
+	start := time.Now()
+	args := []string{"ps", "-o", "etime=", "-p", strconv.Itoa(pid)}
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+	applog.Command(slog.LevelDebug, start, args, err)
+	if err != nil {
+		return time.Time{}
+	}
+	d, ok := parseElapsed(strings.TrimSpace(string(out)))
+	if !ok {
+		return time.Time{}
+	}
+	return time.Now().Add(-d)
+}
+
+// parseElapsed reads ps's etime format, [[dd-]hh:]mm:ss.
+func parseElapsed(s string) (time.Duration, bool) {
+	var days int
+	if d, rest, ok := strings.Cut(s, "-"); ok {
+		n, err := strconv.Atoi(d)
+		if err != nil {
+			return 0, false
+		}
+		days, s = n, rest
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+	var secs int
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return 0, false
+		}
+		secs = secs*60 + n
+	}
+	return time.Duration(days)*24*time.Hour + time.Duration(secs)*time.Second, true
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// maxLogRead caps how much of the end of a log file is read.
+const maxLogRead = 1 << 20
+
+// Log returns the last lines of the Ollama server's log: the journal of the
+// systemd unit on Linux, and the file the Ollama app or Homebrew service
+// writes elsewhere, or a container's output.
+func Log(ctx context.Context, name string, lines int) (string, error) {
+	if rt, container, ok := ContainerName(name); ok {
+		return containerLog(ctx, rt, container, lines)
+	}
+	if runtime.GOOS == "linux" {
+		return run(ctx, "journalctl", "-u", name, "--no-pager", "-o", "cat", "-n", strconv.Itoa(lines))
+	}
+	var lastErr error
+	for _, path := range logPaths() {
+		out, err := tailFile(path, lines)
+		if err == nil {
+			return out, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("reading the Ollama log is not supported on %s", runtime.GOOS)
+	}
+	return "", lastErr
+}
+
+// logPaths lists where the server log may be, most likely first.
+func logPaths() []string {
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		return []string{filepath.Join(os.Getenv("LOCALAPPDATA"), "Ollama", "server.log")}
+	case "darwin":
+		return []string{
+			filepath.Join(home, ".ollama", "logs", "server.log"),
+			"/opt/homebrew/var/log/ollama.log",
+			"/usr/local/var/log/ollama.log",
+		}
+	}
+	return nil
+}
+
+// tailFile returns the last n lines of the file at path.
+func tailFile(path string, n int) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if fi.Size() > maxLogRead {
+		if _, err := f.Seek(-maxLogRead, io.SeekEnd); err != nil {
+			return "", err
+		}
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"ollama-manager/internal/applog"
+)
+
+// scmNoService is the exit code sc.exe returns for an unknown service
+// (ERROR_SERVICE_DOES_NOT_EXIST).
+const scmNoService = 1060
+
+func querySCM(ctx context.Context, name string) (*Status, error) {
+	start := time.Now()
+	out, err := exec.CommandContext(ctx, "sc.exe", "queryex", name).CombinedOutput()
+	applog.Command(slog.LevelDebug, start, []string{"sc.exe", "queryex", name}, err)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == scmNoService {
+			return nil, ErrNotInstalled
+		}
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return nil, fmt.Errorf("sc.exe queryex: %s", msg)
+		}
+		return nil, fmt.Errorf("sc.exe queryex: %w", err)
+	}
+
+	// STATE              : 4  RUNNING
+	kv := keyValues(string(out), ":")
+	st := &Status{Manager: "windows", Name: name}
+	if f := strings.Fields(kv["STATE"]); len(f) > 0 {
+		switch f[0] {
+		case "1":
+			st.State = Stopped
+		case "2":
+			st.State = Starting
+		case "3":
+			st.State = Stopping
+		case "4":
+			st.State = Running
+		}
+	}
+	st.PID, _ = strconv.Atoi(kv["PID"])
+	if st.PID > 0 {
+		st.Since = windowsProcessStart(ctx, st.PID)
+	}
+	return st, nil
+}
+
+// controlSCM drives sc.exe, which doesn't wait for a state change; a restart
+// polls until the service has stopped before starting it again.
+func controlSCM(ctx context.Context, name string, a Action) error {
+	switch a {
+	case Start, Stop:
+		_, err := run(ctx, "sc.exe", string(a), name)
+		return err
+	case Restart:
+		if _, err := run(ctx, "sc.exe", "stop", name); err != nil {
+			return err
+		}
+		for {
+			st, err := querySCM(ctx, name)
+			if err != nil {
+				return err
+			}
+			if st.State == Stopped {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(500 * time.Millisecond):
+			}
+		}
+		_, err := run(ctx, "sc.exe", "start", name)
+		return err
+	}
+	return fmt.Errorf("unknown action %q", a)
+}
+
+// windowsProcessStart reads a process's start time through PowerShell, as
+// sc.exe doesn't report one.
+func windowsProcessStart(ctx context.Context, pid int) time.Time {
+	script := fmt.Sprintf("(Get-Process -Id %d).StartTime.ToUniversalTime().ToString('o')", pid)
+	start := time.Now()
+	args := []string{PowerShell(), "-NoProfile", "-NonInteractive", "-Command", script}
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+	applog.Command(slog.LevelDebug, start, args, err)
+	if err != nil {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(out)))
+	return t
+}
+
+// PowerShell returns the PowerShell to run scripts with: Windows
+// PowerShell, which every Windows since 7 has, or else PowerShell 7.
+func PowerShell() string {
+	if _, err := exec.LookPath("powershell"); err == nil {
+		return "powershell"
+	}
+	if _, err := exec.LookPath("pwsh"); err == nil {
+		return "pwsh"
+	}
+	return "powershell"
+}
@@ -0,0 +1,172 @@
+// Package service inspects and controls the Ollama daemon through the
+// platform's service manager: systemd on Linux, launchd on macOS and the
+// Service Control Manager on Windows, or there Ollama's tray app when it
+// isn't installed as a service. A name such as "docker:ollama" manages a
+// Docker or Podman container instead.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"ollama-manager/internal/applog"
+)
+
+// ErrNotInstalled is returned when Ollama isn't registered with the service
+// manager, e.g. when it runs as the Windows tray app or from `ollama serve`.
+var ErrNotInstalled = errors.New("Ollama is not installed as a service")
+
+// State is the service's run state.
+type State int
+
+const (
+	Unknown State = iota
+	Stopped
+	Starting
+	Running
+	Stopping
+	Failed
+)
+
+func (s State) String() string {
+	switch s {
+	case Stopped:
+		return "stopped"
+	case Starting:
+		return "starting"
+	case Running:
+		return "running"
+	case Stopping:
+		return "stopping"
+	case Failed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// Status describes the Ollama service.
+type Status struct {
+	Manager string // "systemd", "launchd", "windows", "tray app", "docker" or "podman"
+	Name    string // unit, label, service or container name
+	State   State
+	PID     int
+	Since   time.Time // when the service entered its state; zero if unknown
+}
+
+// Uptime is how long a running service has been up, or 0.
+func (s *Status) Uptime() time.Duration {
+	if s == nil || s.State != Running || s.Since.IsZero() {
+		return 0
+	}
+	return time.Since(s.Since)
+}
+
+// Action is something the service manager can be asked to do.
+type Action string
+
+const (
+	Start   Action = "start"
+	Stop    Action = "stop"
+	Restart Action = "restart"
+)
+
+// DefaultName returns the service name Ollama's installer (or Homebrew on
+// macOS) registers on this platform.
+func DefaultName() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "homebrew.mxcl.ollama"
+	case "windows":
+		return "Ollama"
+	}
+	return "ollama"
+}
+
+// Query reports the state of the named service.
+func Query(ctx context.Context, name string) (*Status, error) {
+	if rt, container, ok := ContainerName(name); ok {
+		return queryContainer(ctx, rt, container)
+	}
+	switch runtime.GOOS {
+	case "linux":
+		return querySystemd(ctx, name)
+	case "darwin":
+		return queryLaunchd(ctx, name)
+	case "windows":
+		st, err := querySCM(ctx, name)
+		if errors.Is(err, ErrNotInstalled) {
+			return queryTray(ctx)
+		}
+		return st, err
+	}
+	return nil, fmt.Errorf("service management is not supported on %s", runtime.GOOS)
+}
+
+// Control asks the service manager to start, stop or restart the named
+// service. It returns once the manager has acted, which may be before the
+// API is listening again.
+func Control(ctx context.Context, name string, a Action) error {
+	var err error
+	rt, container, isContainer := ContainerName(name)
+	switch {
+	case isContainer:
+		err = controlContainer(ctx, rt, container, a)
+	case runtime.GOOS == "linux":
+		err = controlSystemd(ctx, name, a)
+	case runtime.GOOS == "darwin":
+		err = controlLaunchd(ctx, name, a)
+	case runtime.GOOS == "windows":
+		if _, err = querySCM(ctx, name); errors.Is(err, ErrNotInstalled) {
+			err = controlTray(ctx, a)
+		} else {
+			err = controlSCM(ctx, name, a)
+		}
+	default:
+		err = fmt.Errorf("service management is not supported on %s", runtime.GOOS)
+	}
+	if err != nil {
+		slog.Error("service", "action", a, "name", name, "err", err)
+	} else {
+		slog.Info("service", "action", a, "name", name)
+	}
+	return err
+}
+
+// run executes a service manager command, folding its output into the error
+// since that is where the reason for a failure (usually permissions) is.
+func run(ctx context.Context, name string, args ...string) (string, error) {
+	start := time.Now()
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	applog.Command(slog.LevelDebug, start, append([]string{name}, args...), err)
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			msg = strings.ReplaceAll(msg, "\n", "; ")
+			return string(out), fmt.Errorf("%s %s: %s", name, args[0], msg)
+		}
+		return string(out), fmt.Errorf("%s %s: %w", name, args[0], err)
+	}
+	return string(out), nil
+}
+
+// keyValues parses "key=value" or "key : value" lines, keeping the first
+// occurrence of each key.
+func keyValues(out, sep string) map[string]string {
+	kv := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		k, v, ok := strings.Cut(line, sep)
+		if !ok {
+			continue
+		}
+		k = strings.TrimSpace(k)
+		if _, seen := kv[k]; !seen {
+			kv[k] = strings.TrimSpace(v)
+		}
+	}
+	return kv
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// systemdTime is how `systemctl show` prints timestamps.
+const systemdTime = "Mon 2006-01-02 15:04:05 MST"
+
+func querySystemd(ctx context.Context, name string) (*Status, error) {
+	out, err := run(ctx, "systemctl", "show", name, "--no-pager",
+		"--property=LoadState,ActiveState,MainPID,StateChangeTimestamp")
+	if err != nil {
+		return nil, err
+	}
+	kv := keyValues(out, "=")
+	if kv["LoadState"] == "not-found" {
+		return nil, ErrNotInstalled
+	}
+
+	st := &Status{Manager: "systemd", Name: name}
+	switch kv["ActiveState"] {
+	case "active", "reloading":
+		st.State = Running
+	case "activating":
+		st.State = Starting
+	case "deactivating":
+		st.State = Stopping
+	case "inactive":
+		st.State = Stopped
+	case "failed":
+		st.State = Failed
+	}
+	st.PID, _ = strconv.Atoi(kv["MainPID"])
+	if ts := kv["StateChangeTimestamp"]; ts != "" {
+		st.Since, _ = time.ParseInLocation(systemdTime, ts, time.Local)
+	}
+	return st, nil
+}
+
+// controlSystemd never prompts for a password: polkit's terminal agent would
+// fight the TUI for the screen, so an unprivileged user gets an error instead.
+func controlSystemd(ctx context.Context, name string, a Action) error {
+	_, err := run(ctx, "systemctl", "--no-ask-password", string(a), name)
+	if err != nil && strings.Contains(err.Error(), "authentication required") {
+		return fmt.Errorf("%w (run as root, or use sudo systemctl %s %s)", err, a, name)
+	}
+	return err
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"ollama-manager/internal/applog"
+	"ollama-manager/internal/ollama"
+)
+
+// trayImage is the process of Ollama's Windows app, which runs the server
+// from the notification area. Ollama's installer sets it up to start at
+// logon rather than registering a service.
+const trayImage = "ollama app.exe"
+
+// trayPath is where the app sits, next to ollama.exe.
+func trayPath() string {
+	return filepath.Join(filepath.Dir(ollama.Binary()), trayImage)
+}
+
+// queryTray reports the tray app as the service when there is no real one.
+func queryTray(ctx context.Context) (*Status, error) {
+	pid, err := WindowsPID(ctx, trayImage)
+	if err != nil {
+		return nil, err
+	}
+	st := &Status{Manager: "tray app", Name: "Ollama"}
+	if pid == 0 {
+		if _, err := os.Stat(trayPath()); err != nil {
+			return nil, ErrNotInstalled
+		}
+		st.State = Stopped
+		return st, nil
+	}
+	st.State, st.PID = Running, pid
+	st.Since = windowsProcessStart(ctx, pid)
+	return st, nil
+}
+
+// controlTray quits and starts the tray app along with the server it runs.
+func controlTray(ctx context.Context, a Action) error {
+	switch a {
+	case Start:
+		return startTray(ctx)
+	case Stop:
+		return stopTray(ctx)
+	case Restart:
+		if err := stopTray(ctx); err != nil {
+			return err
+		}
+		return startTray(ctx)
+	}
+	return fmt.Errorf("unknown action %q", a)
+}
+
+// startTray launches the app detached. It gets the user environment as it
+// is now rather than this process's, which predates any changes SetEnv
+// made, so a restart picks them up as a service restart would.
+func startTray(ctx context.Context) error {
+	path := trayPath()
+	env, err := envWindows(ctx)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(path)
+	cmd.Env = freshEnv(os.Environ(), env)
+	start := time.Now()
+	err = cmd.Start()
+	applog.Command(slog.LevelInfo, start, []string{path}, err)
+	if err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// freshEnv replaces the Settings in environ with the values in env,
+// dropping those env no longer has.
+func freshEnv(environ []string, env map[string]string) []string {
+	var out []string
+	for _, kv := range environ {
+		if k, _, _ := strings.Cut(kv, "="); !known(k) {
+			out = append(out, kv)
+		}
+	}
+	for k, v := range env {
+		out = append(out, k+"="+v)
+	}
+	return out
+}
+
+// stopTray quits the app, then the server and model runners it leaves
+// behind, and waits until the app is gone.
+func stopTray(ctx context.Context) error {
+	for _, image := range []string{trayImage, "ollama.exe"} {
+		pid, err := WindowsPID(ctx, image)
+		if err != nil {
+			return err
+		}
+		if pid == 0 {
+			continue
+		}
+		if _, err := run(ctx, "taskkill", "/IM", image, "/T", "/F"); err != nil {
+			return err
+		}
+	}
+	for {
+		pid, err := WindowsPID(ctx, trayImage)
+		if err != nil || pid == 0 {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// WindowsPID returns the PID of a process running image, or 0 if none is.
+func WindowsPID(ctx context.Context, image string) (int, error) {
+	out, err := run(ctx, "tasklist", "/FI", "IMAGENAME eq "+image, "/FO", "CSV", "/NH")
+	if err != nil {
+		return 0, err
+	}
+	// "ollama app.exe","9876","Console","1","61,220 K", or an INFO line
+	// when nothing matches.
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		return 0, nil
+	}
+	for _, r := range records {
+		if len(r) > 1 && strings.EqualFold(r[0], image) {
+			pid, err := strconv.Atoi(r[1])
+			if err != nil {
+				return 0, errors.New("tasklist: unexpected PID " + r[1])
+			}
+			return pid, nil
+		}
+	}
+	return 0, nil
+}
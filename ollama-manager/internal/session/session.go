@@ -0,0 +1,155 @@
+// Package session keeps chat conversations on disk, one JSON file each, so
+// they survive restarts and can be exported.
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ollama-manager/internal/ollama"
+)
+
+// titleLen bounds a session's title, taken from its first message.
+const titleLen = 60
+
+// Session is one conversation with a model.
+type Session struct {
+	ID       string           `json:"id"`
+	Model    string           `json:"model"`
+	Created  time.Time        `json:"created"`
+	Updated  time.Time        `json:"updated"`
+	Messages []ollama.Message `json:"messages"`
+}
+
+// New starts an empty session with model. IDs sort by creation time; the
+// random suffix keeps sessions started together apart.
+func New(model string) *Session {
+	now := time.Now()
+	b := make([]byte, 3)
+	rand.Read(b)
+	id := now.Format("20060102-150405") + "-" + hex.EncodeToString(b)
+	return &Session{ID: id, Model: model, Created: now, Updated: now}
+}
+
+// Title is the start of the first message, on one line.
+func (s *Session) Title() string {
+	for _, m := range s.Messages {
+		if m.Role != "user" {
+			continue
+		}
+		t := strings.Join(strings.Fields(m.Content), " ")
+		if utf8.RuneCountInString(t) > titleLen {
+			t = string([]rune(t)[:titleLen-1]) + "…"
+		}
+		return t
+	}
+	return "(empty)"
+}
+
+// Markdown renders the conversation for sharing, one heading per turn.
+// Images are noted but not included.
+func (s *Session) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Chat with %s\n\n", s.Model)
+	fmt.Fprintf(&b, "_%s_\n", s.Created.Format("2006-01-02 15:04"))
+	for _, m := range s.Messages {
+		who := "You"
+		switch m.Role {
+		case "assistant":
+			who = s.Model
+		case "system":
+			who = "System"
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", who)
+		for range m.Images {
+			b.WriteString("_[image]_\n\n")
+		}
+		b.WriteString(strings.TrimSpace(m.Content))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Store is a directory of sessions. The zero value keeps nothing.
+type Store struct {
+	dir string
+}
+
+// Open returns the store in dir, which is created on the first save.
+func Open(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+func (st *Store) path(id string) string {
+	return filepath.Join(st.dir, id+".json")
+}
+
+// List returns the sessions with model, most recently updated first.
+// Unreadable files are skipped.
+func (st *Store) List(model string) ([]*Session, error) {
+	if st.dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(st.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sessions []*Session
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(st.dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		var s Session
+		if json.Unmarshal(data, &s) != nil || s.Model != model {
+			continue
+		}
+		sessions = append(sessions, &s)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Updated.After(sessions[j].Updated) })
+	return sessions, nil
+}
+
+// Save writes s, replacing its earlier version.
+func (st *Store) Save(s *Session) error {
+	s.Updated = time.Now()
+	if st.dir == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(st.dir, 0o755); err != nil {
+		return err
+	}
+	tmp := st.path(s.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, st.path(s.ID))
+}
+
+// Delete removes the session with id.
+func (st *Store) Delete(id string) error {
+	if st.dir == "" {
+		return nil
+	}
+	return os.Remove(st.path(id))
+}
@@ -0,0 +1,284 @@
+package store
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// A bundle is a tar of models laid out like the models directory: their
+// manifests under manifests/, then the blobs they use under blobs/. It can
+// be unpacked into a models directory by hand too.
+
+// maxManifest bounds a manifest read from a bundle; real ones are a few KB.
+const maxManifest = 1 << 20
+
+var blobFile = regexp.MustCompile(`^sha256-[0-9a-f]{64}$`)
+
+// Export writes the models under dir, by name, to w as a bundle, calling
+// progress, if not nil, with the bytes of blobs written so far and their
+// total. Blobs shared between the models are written once.
+func Export(w io.Writer, dir string, models []string, progress func(done, total int64)) error {
+	root := filepath.Join(dir, "manifests")
+	refs, err := readManifests(root)
+	if err != nil {
+		return err
+	}
+	files, err := manifestFiles(root)
+	if err != nil {
+		return err
+	}
+
+	var manifests []string
+	digests := make(map[string]bool)
+	for _, name := range models {
+		name, ds, ok := lookup(refs, name)
+		if !ok {
+			return fmt.Errorf("no manifest for %s in %s", name, dir)
+		}
+		manifests = append(manifests, files[name])
+		for d := range ds {
+			digests[d] = true
+		}
+	}
+	type blob struct {
+		name string
+		size int64
+	}
+	var blobs []blob
+	var total int64
+	for d := range digests {
+		info, err := os.Stat(filepath.Join(dir, "blobs", blobName(d)))
+		if err != nil {
+			return err
+		}
+		blobs = append(blobs, blob{blobName(d), info.Size()})
+		total += info.Size()
+	}
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].name < blobs[j].name })
+
+	tw := tar.NewWriter(w)
+	for _, rel := range manifests {
+		if err := addFile(tw, filepath.Join(root, rel), "manifests/"+filepath.ToSlash(rel), nil); err != nil {
+			return err
+		}
+	}
+	var done int64
+	for _, b := range blobs {
+		err := addFile(tw, filepath.Join(dir, "blobs", b.name), "blobs/"+b.name, func(n int64) {
+			if progress != nil {
+				progress(done+n, total)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		done += b.size
+	}
+	return tw.Close()
+}
+
+// addFile writes the file at path to tw as name, following a link into the
+// cold store, and calling written, if not nil, with the bytes written so far.
+func addFile(tw *tar.Writer, path, name string, written func(n int64)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{Name: name, Mode: 0o644, Size: info.Size(), ModTime: info.ModTime(), Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	var out io.Writer = tw
+	if written != nil {
+		out = &countWriter{w: tw, written: written}
+	}
+	_, err = io.Copy(out, f)
+	return err
+}
+
+// countWriter reports the running total of bytes written through it.
+type countWriter struct {
+	w       io.Writer
+	n       int64
+	written func(n int64)
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.written(c.n)
+	return n, err
+}
+
+// Import adds the models of the bundle read from r to the models directory
+// dir, calling progress, if not nil, with the bytes read so far. Each blob is
+// checked against its digest as it is written, and the manifests go in last,
+// so a failed import leaves no model half there. Models dir already has are
+// skipped, as are blobs it has.
+func Import(r io.Reader, dir string, progress func(done int64)) (added, skipped []string, err error) {
+	blobs := filepath.Join(dir, "blobs")
+	if err := os.MkdirAll(blobs, 0o755); err != nil {
+		return nil, nil, err
+	}
+	manifests := make(map[string][]byte)
+	var done int64
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		name := path.Clean(hdr.Name)
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+		// A backslash is a separator on Windows, where a name such as
+		// "manifests/..\..\x" would leave the models directory.
+		if hdr.Typeflag != tar.TypeReg || strings.Contains(hdr.Name, `\`) || !filepath.IsLocal(filepath.FromSlash(name)) {
+			return nil, nil, fmt.Errorf("%s: not a file of a model bundle", hdr.Name)
+		}
+		switch dirName, rest, _ := strings.Cut(name, "/"); {
+		case dirName == "manifests" && rest != "":
+			data, err := io.ReadAll(io.LimitReader(tr, maxManifest+1))
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(data) > maxManifest {
+				return nil, nil, fmt.Errorf("%s: manifest too large", hdr.Name)
+			}
+			manifests[rest] = data
+		case dirName == "blobs" && blobFile.MatchString(rest):
+			dst := filepath.Join(blobs, rest)
+			if _, err := os.Lstat(dst); err == nil {
+				done += hdr.Size
+				break
+			}
+			if err := importBlob(tr, dst, func(n int64) {
+				if progress != nil {
+					progress(done + n)
+				}
+			}); err != nil {
+				return nil, nil, err
+			}
+			done += hdr.Size
+		default:
+			return nil, nil, fmt.Errorf("%s: not a file of a model bundle", hdr.Name)
+		}
+		if progress != nil {
+			progress(done)
+		}
+	}
+	if len(manifests) == 0 {
+		return nil, nil, errors.New("no models in the bundle")
+	}
+
+	rels := make([]string, 0, len(manifests))
+	for rel := range manifests {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	for _, rel := range rels {
+		var m manifest
+		if err := json.Unmarshal(manifests[rel], &m); err != nil {
+			return added, skipped, fmt.Errorf("manifests/%s: %w", rel, err)
+		}
+		if m.Config.Digest == "" {
+			return added, skipped, fmt.Errorf("manifests/%s: not a model manifest", rel)
+		}
+		digests := []string{m.Config.Digest}
+		for _, l := range m.Layers {
+			digests = append(digests, l.Digest)
+		}
+		for _, d := range digests {
+			if _, err := os.Stat(filepath.Join(blobs, blobName(d))); err != nil {
+				return added, skipped, fmt.Errorf("%s: the bundle lacks layer %s", modelName(rel), d)
+			}
+		}
+		dst := filepath.Join(dir, "manifests", filepath.FromSlash(rel))
+		if _, err := os.Stat(dst); err == nil {
+			skipped = append(skipped, modelName(rel))
+			continue
+		}
+		if err := writeAtomic(dst, manifests[rel]); err != nil {
+			return added, skipped, err
+		}
+		added = append(added, modelName(rel))
+	}
+	return added, skipped, nil
+}
+
+// importBlob writes the blob read from r to dst, refusing it unless it
+// hashes to the digest in the file name.
+func importBlob(r io.Reader, dst string, written func(n int64)) error {
+	tmp := filepath.Join(filepath.Dir(dst), ".import-"+filepath.Base(dst))
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	_, err = io.Copy(&countWriter{w: io.MultiWriter(out, h), written: written}, r)
+	if err == nil {
+		err = out.Sync()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if name := filepath.Base(dst); err == nil && "sha256-"+hex.EncodeToString(h.Sum(nil)) != name {
+		err = fmt.Errorf("blob %s is damaged: its content doesn't match the digest", name)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// writeAtomic writes data to path through a temporary file, creating its
+// directory.
+func writeAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// manifestFiles maps each model name to its manifest's path under root.
+func manifestFiles(root string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files[modelName(rel)] = rel
+		return nil
+	})
+	return files, err
+}
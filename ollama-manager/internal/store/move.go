@@ -0,0 +1,174 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// storeDirs are what the models directory holds; RemoveStore deletes only
+// these.
+var storeDirs = []string{"blobs", "manifests"}
+
+// CheckMove reports why the models directory src can't move to dst: dst
+// is inside src or the other way round, or already holds files.
+func CheckMove(src, dst string) error {
+	src, dst = filepath.Clean(src), filepath.Clean(dst)
+	if !filepath.IsAbs(dst) {
+		return fmt.Errorf("%s is not an absolute path", dst)
+	}
+	if within(dst, src) || within(src, dst) {
+		return fmt.Errorf("%s and %s overlap", src, dst)
+	}
+	entries, err := os.ReadDir(dst)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("%s is not empty", dst)
+	}
+	return nil
+}
+
+// within reports whether path is dir or below it.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Move moves the models directory src to dst, which CheckMove has passed.
+// On the same volume it renames src, so nothing is left behind; otherwise it
+// copies the store, calling progress with the bytes copied so far and the
+// total, and leaves src for RemoveStore once the copy is known to work.
+// Links into the cold store are copied as links. A failed copy is removed
+// again. copied reports which happened.
+func Move(ctx context.Context, src, dst string, progress func(done, total int64)) (copied bool, err error) {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return false, err
+	}
+	os.Remove(dst) // an empty directory would stop the rename
+	if err := os.Rename(src, dst); err == nil {
+		return false, nil
+	}
+
+	type file struct {
+		rel  string
+		size int64
+		link string // the target, for a link
+	}
+	var files []file
+	var total int64
+	for _, d := range storeDirs {
+		err := filepath.WalkDir(filepath.Join(src, d), func(path string, e fs.DirEntry, err error) error {
+			if err != nil || e.IsDir() {
+				return err
+			}
+			rel, _ := filepath.Rel(src, path)
+			if e.Type()&fs.ModeSymlink != 0 {
+				target, err := os.Readlink(path)
+				files = append(files, file{rel: rel, link: target})
+				return err
+			}
+			info, err := e.Info()
+			if err != nil {
+				return err
+			}
+			files = append(files, file{rel: rel, size: info.Size()})
+			total += info.Size()
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return true, err
+		}
+	}
+
+	var done int64
+	for _, f := range files {
+		if f.link != "" {
+			if err := os.MkdirAll(filepath.Dir(filepath.Join(dst, f.rel)), 0o755); err != nil {
+				os.RemoveAll(dst)
+				return true, err
+			}
+			if err := os.Symlink(f.link, filepath.Join(dst, f.rel)); err != nil {
+				os.RemoveAll(dst)
+				return true, err
+			}
+			continue
+		}
+		err := copyFile(ctx, filepath.Join(src, f.rel), filepath.Join(dst, f.rel), func(n int64) {
+			if progress != nil {
+				progress(done+n, total)
+			}
+		})
+		if err != nil {
+			os.RemoveAll(dst)
+			return true, err
+		}
+		done += f.size
+	}
+	return true, nil
+}
+
+// copyFile copies src to dst, calling written with the bytes copied so far,
+// and syncs dst before returning so a crash can't leave it short.
+func copyFile(ctx context.Context, src, dst string, written func(n int64)) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 4<<20)
+	var n int64
+	for {
+		if err := ctx.Err(); err != nil {
+			out.Close()
+			return err
+		}
+		k, rerr := in.Read(buf)
+		if _, err := out.Write(buf[:k]); err != nil {
+			out.Close()
+			return err
+		}
+		n += int64(k)
+		written(n)
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			out.Close()
+			return rerr
+		}
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// RemoveStore deletes the blobs and manifests under dir, and dir itself if
+// nothing else is left in it.
+func RemoveStore(dir string) error {
+	for _, d := range storeDirs {
+		if err := os.RemoveAll(filepath.Join(dir, d)); err != nil {
+			return err
+		}
+	}
+	os.Remove(dir) // fails, harmlessly, if anything else is there
+	return nil
+}
@@ -0,0 +1,302 @@
+// Package store inspects the Ollama model directory on disk: which blobs each
+// model uses and which blobs no manifest references any more.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Dir returns the models directory: $OLLAMA_MODELS, or the first of the
+// per-user and Linux service locations that exists.
+func Dir() (string, error) {
+	if dir := os.Getenv("OLLAMA_MODELS"); dir != "" {
+		return dir, nil
+	}
+	var candidates []string
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, ".ollama", "models"))
+	}
+	if runtime.GOOS == "linux" {
+		candidates = append(candidates, "/usr/share/ollama/.ollama/models")
+	}
+	for _, dir := range candidates {
+		if _, err := os.Stat(filepath.Join(dir, "manifests")); err == nil {
+			return dir, nil
+		}
+	}
+	return "", errors.New("no Ollama models directory found; set OLLAMA_MODELS")
+}
+
+// Blob is one file in the blob store.
+type Blob struct {
+	Path     string
+	Digest   string // "sha256:<hex>"
+	Size     int64
+	Partial  bool // an unfinished download
+	Archived bool // a link to the cold store; Size is the target's
+	Modified time.Time
+}
+
+// ModelUsage is the disk footprint of one model.
+type ModelUsage struct {
+	Name     string
+	Size     int64   // all layers the model uses
+	Unique   int64   // layers no other model shares, i.e. what deleting it frees
+	Shared   int64   // layers other models use too
+	Archived int64   // layers in the cold store
+	Layers   []Layer // largest first
+}
+
+// Layer is one blob a model uses.
+type Layer struct {
+	Digest   string
+	Kind     string // from the media type: "model" for weights, "template", "params", ...
+	Size     int64
+	Archived bool
+	Others   []string // the other models using it, by name
+}
+
+// SharesWith returns the models sharing layers with mu, most bytes shared
+// first.
+func (mu ModelUsage) SharesWith() []string {
+	bytes := make(map[string]int64)
+	for _, l := range mu.Layers {
+		for _, o := range l.Others {
+			bytes[o] += l.Size
+		}
+	}
+	names := make([]string, 0, len(bytes))
+	for name := range bytes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if bytes[names[i]] != bytes[names[j]] {
+			return bytes[names[i]] > bytes[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// Usage summarises the model directory.
+type Usage struct {
+	Dir         string
+	Total       int64 // every file in the blob store
+	Archived    int64 // the part of Total in the cold store
+	Models      []ModelUsage
+	Orphans     []Blob // blobs no manifest references, largest first
+	OrphanBytes int64
+}
+
+type manifest struct {
+	Config struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Layers []struct {
+		Digest    string `json:"digest"`
+		MediaType string `json:"mediaType"`
+	} `json:"layers"`
+}
+
+// Scan reads every manifest and blob under dir.
+func Scan(dir string) (*Usage, error) {
+	blobs, err := readBlobs(filepath.Join(dir, "blobs"))
+	if err != nil {
+		return nil, err
+	}
+	refs, err := readManifests(filepath.Join(dir, "manifests"))
+	if err != nil {
+		return nil, err
+	}
+
+	u := &Usage{Dir: dir}
+	users := make(map[string][]string)
+	for name, digests := range refs {
+		for d := range digests {
+			users[d] = append(users[d], name)
+		}
+	}
+	for _, names := range users {
+		sort.Strings(names)
+	}
+	for _, b := range blobs {
+		u.Total += b.Size
+		if b.Archived {
+			u.Archived += b.Size
+		}
+		if len(users[b.Digest]) == 0 {
+			u.Orphans = append(u.Orphans, b)
+			u.OrphanBytes += b.Size
+		}
+	}
+	for name, digests := range refs {
+		mu := ModelUsage{Name: name}
+		for d, kind := range digests {
+			b, ok := blobs[d]
+			if !ok {
+				continue
+			}
+			l := Layer{Digest: d, Kind: kind, Size: b.Size, Archived: b.Archived}
+			for _, other := range users[d] {
+				if other != name {
+					l.Others = append(l.Others, other)
+				}
+			}
+			mu.Size += b.Size
+			if b.Archived {
+				mu.Archived += b.Size
+			}
+			if len(l.Others) == 0 {
+				mu.Unique += b.Size
+			} else {
+				mu.Shared += b.Size
+			}
+			mu.Layers = append(mu.Layers, l)
+		}
+		sort.Slice(mu.Layers, func(i, j int) bool { return mu.Layers[i].Size > mu.Layers[j].Size })
+		u.Models = append(u.Models, mu)
+	}
+
+	sort.Slice(u.Models, func(i, j int) bool { return u.Models[i].Size > u.Models[j].Size })
+	sort.Slice(u.Orphans, func(i, j int) bool { return u.Orphans[i].Size > u.Orphans[j].Size })
+	return u, nil
+}
+
+// readBlobs indexes the blob store by digest. Partial downloads get a digest
+// of their own so they never match a manifest. Links to the cold store are
+// followed for their size; a broken one, e.g. with the drive unplugged, is
+// left out like a missing blob.
+func readBlobs(dir string) (map[string]Blob, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	blobs := make(map[string]Blob, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), "sha256-") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		b := Blob{
+			Path:     path,
+			Digest:   strings.Replace(e.Name(), "-", ":", 1),
+			Size:     info.Size(),
+			Partial:  strings.Contains(e.Name(), "-partial"),
+			Archived: e.Type()&fs.ModeSymlink != 0,
+			Modified: info.ModTime(),
+		}
+		blobs[b.Digest] = b
+	}
+	return blobs, nil
+}
+
+// readManifests maps each model name to the digests it references and
+// their kinds.
+func readManifests(root string) (map[string]map[string]string, error) {
+	refs := make(map[string]map[string]string)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var m manifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		digests := map[string]string{m.Config.Digest: "config"}
+		for _, l := range m.Layers {
+			// e.g. "application/vnd.ollama.image.model"
+			digests[l.Digest] = l.MediaType[strings.LastIndex(l.MediaType, ".")+1:]
+		}
+		refs[modelName(rel)] = digests
+		return nil
+	})
+	return refs, err
+}
+
+// modelName turns a manifest path such as
+// "registry.ollama.ai/library/llama3.1/8b" into the name Ollama shows,
+// "llama3.1:8b".
+func modelName(rel string) string {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if len(parts) < 2 {
+		return rel
+	}
+	tag := parts[len(parts)-1]
+	repo := parts[:len(parts)-1]
+	if len(repo) == 3 && repo[0] == "registry.ollama.ai" {
+		repo = repo[1:]
+		if repo[0] == "library" {
+			repo = repo[1:]
+		}
+	}
+	return strings.Join(repo, "/") + ":" + tag
+}
+
+// Prune deletes the given blobs, returning the bytes freed. An archived
+// blob takes its copy in the cold store with it.
+func Prune(blobs []Blob) (int64, error) {
+	var freed int64
+	var errs []error
+	for _, b := range blobs {
+		if target, err := os.Readlink(b.Path); err == nil {
+			os.Remove(target)
+		}
+		if err := os.Remove(b.Path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		freed += b.Size
+	}
+	return freed, errors.Join(errs...)
+}
+
+// File is a blob of one model and where it is on disk.
+type File struct {
+	Layer
+	Path string
+}
+
+// Files returns the blobs of model under dir, largest first. Others is left
+// empty.
+func Files(dir, model string) ([]File, error) {
+	refs, err := readManifests(filepath.Join(dir, "manifests"))
+	if err != nil {
+		return nil, err
+	}
+	model, digests, ok := lookup(refs, model)
+	if !ok {
+		return nil, fmt.Errorf("no manifest for %s in %s", model, dir)
+	}
+	var files []File
+	for d, kind := range digests {
+		path := filepath.Join(dir, "blobs", blobName(d))
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Layer: Layer{Digest: d, Kind: kind, Size: info.Size()}, Path: path})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Size > files[j].Size })
+	return files, nil
+}
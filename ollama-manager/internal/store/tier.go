@@ -0,0 +1,216 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// An archived blob lives in the cold store, a folder on a slower drive, and
+// a symbolic link in the blob store points at it, so Ollama still lists and
+// runs the model, only loading it more slowly.
+
+// Tier says where the blobs of a model are kept.
+type Tier int
+
+const (
+	Hot   Tier = iota // all in the models directory
+	Cold              // all in the cold store
+	Mixed             // some of each, e.g. layers shared with an archived model
+)
+
+func (t Tier) String() string {
+	return [...]string{"hot", "cold", "mixed"}[t]
+}
+
+// Tier reports where the layers of mu are.
+func (mu ModelUsage) Tier() Tier {
+	switch {
+	case mu.Archived == 0:
+		return Hot
+	case mu.Archived == mu.Size:
+		return Cold
+	}
+	return Mixed
+}
+
+// Tiers returns the tier of every model under dir.
+func Tiers(dir string) (map[string]Tier, error) {
+	u, err := Scan(dir)
+	if err != nil {
+		return nil, err
+	}
+	tiers := make(map[string]Tier, len(u.Models))
+	for _, mu := range u.Models {
+		tiers[mu.Name] = mu.Tier()
+	}
+	return tiers, nil
+}
+
+// Archive moves the blobs of model under dir to the cold store and links
+// them back, returning the bytes moved. Layers already archived, such as
+// ones shared with an archived model, are skipped.
+func Archive(dir, cold, model string) (int64, error) {
+	if within(cold, dir) || within(dir, cold) {
+		return 0, fmt.Errorf("the cold store %s and the models directory %s overlap", cold, dir)
+	}
+	digests, err := modelLayers(dir, model)
+	if err != nil {
+		return 0, err
+	}
+	blobs, coldBlobs := filepath.Join(dir, "blobs"), filepath.Join(cold, "blobs")
+	if err := os.MkdirAll(coldBlobs, 0o755); err != nil {
+		return 0, err
+	}
+	var moved int64
+	for _, d := range digests {
+		name := blobName(d)
+		path, target := filepath.Join(blobs, name), filepath.Join(coldBlobs, name)
+		info, err := os.Lstat(path)
+		if err != nil {
+			return moved, err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			continue
+		}
+		if err := os.Rename(path, target); err == nil {
+			if err := os.Symlink(target, path); err != nil {
+				os.Rename(target, path)
+				return moved, err
+			}
+			moved += info.Size()
+			continue
+		}
+		// Across drives: copy, then swap the link in for the file in one
+		// rename so the blob is never missing.
+		if err := copyFile(context.Background(), path, target, func(int64) {}); err != nil {
+			os.Remove(target)
+			return moved, err
+		}
+		link := filepath.Join(blobs, ".archive-"+name)
+		os.Remove(link)
+		if err := os.Symlink(target, link); err != nil {
+			os.Remove(target)
+			return moved, err
+		}
+		if err := os.Rename(link, path); err != nil {
+			os.Remove(link)
+			os.Remove(target)
+			return moved, err
+		}
+		moved += info.Size()
+	}
+	return moved, nil
+}
+
+// Restore copies the archived blobs of model under dir back from the cold
+// store, returning the bytes moved. Other models sharing a layer are
+// restored along with it.
+func Restore(dir, model string) (int64, error) {
+	digests, err := modelLayers(dir, model)
+	if err != nil {
+		return 0, err
+	}
+	blobs := filepath.Join(dir, "blobs")
+	var moved int64
+	for _, d := range digests {
+		name := blobName(d)
+		path := filepath.Join(blobs, name)
+		link, err := os.Lstat(path)
+		if err != nil {
+			return moved, err
+		}
+		if link.Mode()&fs.ModeSymlink == 0 {
+			continue
+		}
+		target, err := os.Readlink(path)
+		if err != nil {
+			return moved, err
+		}
+		info, err := os.Stat(target)
+		if err != nil {
+			return moved, fmt.Errorf("%s is archived to %s: %w", d, target, err)
+		}
+		tmp := filepath.Join(blobs, ".restore-"+name)
+		if err := copyFile(context.Background(), target, tmp, func(int64) {}); err != nil {
+			os.Remove(tmp)
+			return moved, err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			os.Remove(tmp)
+			return moved, err
+		}
+		os.Remove(target)
+		moved += info.Size()
+	}
+	return moved, nil
+}
+
+// modelLayers returns the digests of the blobs model uses under dir.
+func modelLayers(dir, model string) ([]string, error) {
+	refs, err := readManifests(filepath.Join(dir, "manifests"))
+	if err != nil {
+		return nil, err
+	}
+	_, digests, ok := lookup(refs, model)
+	if !ok {
+		return nil, fmt.Errorf("no manifest for %s in %s", model, dir)
+	}
+	layers := make([]string, 0, len(digests))
+	for d := range digests {
+		layers = append(layers, d)
+	}
+	return layers, nil
+}
+
+// lookup finds model in refs, trying the "latest" tag when none is given,
+// and returns the name it is listed under.
+func lookup(refs map[string]map[string]string, model string) (string, map[string]string, bool) {
+	digests, ok := refs[model]
+	if !ok && !strings.Contains(model[strings.LastIndex(model, "/")+1:], ":") {
+		model += ":latest"
+		digests, ok = refs[model]
+	}
+	return model, digests, ok
+}
+
+// blobName is the file in the blob store holding digest.
+func blobName(digest string) string {
+	return strings.Replace(digest, ":", "-", 1)
+}
+
+// ColdOrphans returns the blobs in the cold store no link in the blob store
+// under dir points at any more, such as those of an archived model Ollama
+// deleted: it removes only the link.
+func ColdOrphans(dir, cold string) ([]Blob, error) {
+	entries, err := os.ReadDir(filepath.Join(cold, "blobs"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var orphans []Blob
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), "sha256-") {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(dir, "blobs", e.Name())); err == nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		orphans = append(orphans, Blob{
+			Path:   filepath.Join(cold, "blobs", e.Name()),
+			Digest: strings.Replace(e.Name(), "-", ":", 1),
+			Size:   info.Size(),
+		})
+	}
+	return orphans, nil
+}
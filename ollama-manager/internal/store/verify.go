@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Check is the verdict on one layer of a model.
+type Check struct {
+	Layer
+	Path    string
+	Problem string // "" when intact, else "missing" or "corrupt"
+}
+
+// Verify re-hashes the blobs of model under dir against the digests its
+// manifest names, calling progress, if not nil, with the bytes hashed so far
+// and the total. Layers come back largest first.
+func Verify(ctx context.Context, dir, model string, progress func(done, total int64)) ([]Check, error) {
+	refs, err := readManifests(filepath.Join(dir, "manifests"))
+	if err != nil {
+		return nil, err
+	}
+	model, digests, ok := lookup(refs, model)
+	if !ok {
+		return nil, fmt.Errorf("no manifest for %s in %s", model, dir)
+	}
+	blobs, err := readBlobs(filepath.Join(dir, "blobs"))
+	if err != nil {
+		return nil, err
+	}
+
+	var checks []Check
+	var total int64
+	for d, kind := range digests {
+		c := Check{Layer: Layer{Digest: d, Kind: kind}, Path: filepath.Join(dir, "blobs", blobName(d))}
+		if b, ok := blobs[d]; ok {
+			c.Size = b.Size
+			total += b.Size
+		} else {
+			c.Problem = "missing"
+		}
+		for other, ds := range refs {
+			if _, ok := ds[d]; ok && other != model {
+				c.Others = append(c.Others, other)
+			}
+		}
+		sort.Strings(c.Others)
+		checks = append(checks, c)
+	}
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Size > checks[j].Size })
+
+	var done int64
+	for i := range checks {
+		c := &checks[i]
+		if c.Problem != "" {
+			continue
+		}
+		sum, err := hashFile(ctx, c.Path, func(n int64) {
+			if progress != nil {
+				progress(done+n, total)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		done += c.Size
+		if "sha256:"+sum != c.Digest {
+			c.Problem = "corrupt"
+		}
+	}
+	return checks, nil
+}
+
+// hashFile returns the hex SHA-256 of the file at path, calling read with
+// the bytes read so far. It stops early when ctx is done.
+func hashFile(ctx context.Context, path string, read func(n int64)) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	buf := make([]byte, 1<<20)
+	var n int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		k, err := f.Read(buf)
+		h.Write(buf[:k])
+		n += int64(k)
+		read(n)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Damaged returns the checks that found a problem.
+func Damaged(checks []Check) []Check {
+	var bad []Check
+	for _, c := range checks {
+		if c.Problem != "" {
+			bad = append(bad, c)
+		}
+	}
+	return bad
+}
+
+// Corrupt returns the blobs of the checks that failed to match their digest,
+// for Prune to delete before pulling them again. Missing ones are already
+// gone.
+func Corrupt(checks []Check) []Blob {
+	var blobs []Blob
+	for _, c := range checks {
+		if c.Problem == "corrupt" {
+			blobs = append(blobs, Blob{Path: c.Path, Digest: c.Digest, Size: c.Size})
+		}
+	}
+	return blobs
+}
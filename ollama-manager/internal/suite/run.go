@@ -0,0 +1,138 @@
+package suite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"ollama-manager/internal/ollama"
+)
+
+// Case is the outcome of one prompt.
+type Case struct {
+	Prompt    string        `json:"prompt"`
+	Passed    bool          `json:"passed"`
+	Missing   []string      `json:"missing,omitempty"` // expected keywords not in the reply
+	Reply     string        `json:"reply"`
+	GenTokens int           `json:"gen_tokens"`
+	GenTPS    float64       `json:"gen_tps"`
+	TTFT      time.Duration `json:"ttft"`
+	Total     time.Duration `json:"total"`
+	Err       string        `json:"error,omitempty"`
+}
+
+// Result is a suite run against one model.
+type Result struct {
+	Suite   string        `json:"suite"`
+	Model   string        `json:"model"`
+	Started time.Time     `json:"started"`
+	Elapsed time.Duration `json:"elapsed"`
+	Cases   []Case        `json:"cases"`
+}
+
+// Passed returns how many prompts passed.
+func (r *Result) Passed() int {
+	n := 0
+	for _, c := range r.Cases {
+		if c.Passed {
+			n++
+		}
+	}
+	return n
+}
+
+// GenTPS returns the mean generation speed of the prompts that ran.
+func (r *Result) GenTPS() float64 {
+	var sum float64
+	n := 0
+	for _, c := range r.Cases {
+		if c.Err == "" {
+			sum += c.GenTPS
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// TTFT returns the mean time to first token of the prompts that ran.
+func (r *Result) TTFT() time.Duration {
+	var sum time.Duration
+	n := 0
+	for _, c := range r.Cases {
+		if c.Err == "" {
+			sum += c.TTFT
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / time.Duration(n)
+}
+
+// Run loads model and sends it every prompt of s in turn. Sampling is
+// pinned as for a benchmark so runs can be compared; opts, the model's
+// saved options, override that, but not the reply length. A prompt that
+// fails is recorded as failed and the run goes on.
+func Run(ctx context.Context, c *ollama.Client, model string, s *Suite, opts map[string]any, progress func(step string)) (*Result, error) {
+	res := &Result{Suite: s.Name, Model: model, Started: time.Now()}
+	numCtx, _ := opts["num_ctx"].(int)
+	progress("loading " + model)
+	if err := c.Load(ctx, model, ollama.LoadOptions{NumCtx: numCtx}); err != nil {
+		return nil, fmt.Errorf("load %s: %w", model, err)
+	}
+	for i, p := range s.Prompts {
+		progress(fmt.Sprintf("%s %d/%d: %s", model, i+1, len(s.Prompts), p.Name))
+		cs := runCase(ctx, c, model, p, s.maxTokens(p), opts)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		res.Cases = append(res.Cases, cs)
+	}
+	res.Elapsed = time.Since(res.Started)
+	return res, nil
+}
+
+func runCase(ctx context.Context, c *ollama.Client, model string, p Prompt, maxTokens int, opts map[string]any) Case {
+	cs := Case{Prompt: p.Name}
+	options := map[string]any{"temperature": 0, "seed": 42}
+	for k, v := range opts {
+		options[k] = v
+	}
+	options["num_predict"] = maxTokens
+
+	var reply strings.Builder
+	var final ollama.Metrics
+	start := time.Now()
+	err := c.GenerateStream(ctx, ollama.GenerateRequest{Model: model, Prompt: p.Text, System: p.System, Options: options},
+		func(r ollama.GenerateResponse) {
+			if cs.TTFT == 0 && r.Response != "" {
+				cs.TTFT = time.Since(start)
+			}
+			reply.WriteString(r.Response)
+			if r.Done {
+				final = r.Metrics
+			}
+		})
+	cs.Total = time.Since(start)
+	cs.Reply = reply.String()
+	if err != nil {
+		cs.Err = err.Error()
+		return cs
+	}
+	cs.GenTokens = final.EvalCount
+	cs.GenTPS = final.TokensPerSecond()
+
+	lower := strings.ToLower(cs.Reply)
+	for _, kw := range p.Expect {
+		if !strings.Contains(lower, strings.ToLower(kw)) {
+			cs.Missing = append(cs.Missing, kw)
+		}
+	}
+	cs.Passed = len(cs.Missing) == 0
+	return cs
+}
@@ -0,0 +1,211 @@
+// Package suite reads benchmark suites, sets of prompts with the keywords
+// each reply should contain, and runs them against a model as a small
+// evaluation.
+//
+// Suites are YAML, or JSON when the file starts with "{":
+//
+//	name: basics
+//	max_tokens: 256
+//	prompts:
+//	  - name: capital
+//	    prompt: What is the capital of France?
+//	    expect: [Paris]
+//	  - name: reverse
+//	    system: You write Go.
+//	    prompt: |
+//	      Write a function that reverses a string.
+//	    expect:
+//	      - func
+//	      - rune
+package suite
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultMaxTokens caps a reply when neither the prompt nor the suite does.
+const DefaultMaxTokens = 512
+
+// Suite is a named set of prompts.
+type Suite struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" yaml:"description"`
+	MaxTokens   int      `json:"max_tokens,omitempty" yaml:"max_tokens"` // for prompts that don't set their own
+	Prompts     []Prompt `json:"prompts" yaml:"prompts"`
+
+	File string `json:"-" yaml:"-"` // where the suite was read from
+}
+
+// Prompt is one request of a suite. The reply passes when it contains
+// every keyword of Expect, ignoring case.
+type Prompt struct {
+	Name      string   `json:"name,omitempty"`
+	Text      string   `json:"prompt"`
+	System    string   `json:"system,omitempty"`
+	Expect    []string `json:"expect,omitempty"`
+	MaxTokens int      `json:"max_tokens,omitempty"`
+}
+
+// Checks returns how many keyword checks the suite makes.
+func (s *Suite) Checks() int {
+	n := 0
+	for _, p := range s.Prompts {
+		n += len(p.Expect)
+	}
+	return n
+}
+
+// maxTokens is the reply length p is capped at.
+func (s *Suite) maxTokens(p Prompt) int {
+	switch {
+	case p.MaxTokens > 0:
+		return p.MaxTokens
+	case s.MaxTokens > 0:
+		return s.MaxTokens
+	}
+	return DefaultMaxTokens
+}
+
+// Load reads the suite at path. A suite without a name is named after the
+// file.
+func Load(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s *Suite
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		s = &Suite{}
+		err = json.Unmarshal(data, s)
+	} else {
+		s, err = Parse(data)
+	}
+	if err == nil {
+		err = s.check()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	s.File = path
+	if s.Name == "" {
+		s.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return s, nil
+}
+
+// LoadDir reads every .yaml, .yml and .json file in dir, sorted by name. A
+// missing dir has no suites; files that fail to load are left out and
+// reported together in the error.
+func LoadDir(dir string) ([]*Suite, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var suites []*Suite
+	var errs []error
+	for _, e := range entries {
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		s, err := Load(filepath.Join(dir, e.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		suites = append(suites, s)
+	}
+	sort.Slice(suites, func(i, j int) bool { return suites[i].Name < suites[j].Name })
+	return suites, errors.Join(errs...)
+}
+
+// check names unnamed prompts and rejects empty ones.
+func (s *Suite) check() error {
+	if len(s.Prompts) == 0 {
+		return errors.New("no prompts")
+	}
+	if s.MaxTokens < 0 {
+		return errors.New("max_tokens must be a positive number")
+	}
+	for i := range s.Prompts {
+		p := &s.Prompts[i]
+		if strings.TrimSpace(p.Text) == "" {
+			return fmt.Errorf("prompts[%d]: no prompt", i)
+		}
+		if p.MaxTokens < 0 {
+			return fmt.Errorf("prompts[%d]: max_tokens must be a positive number", i)
+		}
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("prompt %d", i+1)
+		}
+	}
+	return nil
+}
+
+// Parse reads a suite from YAML. Unknown keys are refused, so a misspelt
+// one isn't silently ignored.
+func Parse(data []byte) (*Suite, error) {
+	s := &Suite{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(s); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return s, nil
+}
+
+// promptKeys are the keys of a prompts entry.
+var promptKeys = []string{"name", "prompt", "system", "expect", "max_tokens"}
+
+// UnmarshalYAML reads a prompts entry, which may also be a bare string that
+// is the prompt itself, and whose expect may be a single keyword.
+func (p *Prompt) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*p = Prompt{Text: node.Value}
+		return nil
+	}
+	// The decoder's KnownFields doesn't reach through UnmarshalYAML.
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if k := node.Content[i]; !slices.Contains(promptKeys, k.Value) {
+				return fmt.Errorf("line %d: unknown key %q", k.Line, k.Value)
+			}
+		}
+	}
+	var raw struct {
+		Name      string    `yaml:"name"`
+		Text      string    `yaml:"prompt"`
+		System    string    `yaml:"system"`
+		Expect    yaml.Node `yaml:"expect"`
+		MaxTokens int       `yaml:"max_tokens"`
+	}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	*p = Prompt{Name: raw.Name, Text: raw.Text, System: raw.System, MaxTokens: raw.MaxTokens}
+	switch {
+	case raw.Expect.Kind == yaml.ScalarNode && raw.Expect.Tag != "!!null":
+		p.Expect = []string{raw.Expect.Value}
+	case raw.Expect.Kind == yaml.SequenceNode:
+		return raw.Expect.Decode(&p.Expect)
+	case raw.Expect.Kind != 0 && raw.Expect.Tag != "!!null":
+		return fmt.Errorf("line %d: expect must be a keyword or a list of them", raw.Expect.Line)
+	}
+	return nil
+}
@@ -0,0 +1,308 @@
+// Package usage records when each model on a server was last used and how
+// much work it did, so models that don't earn their disk space can be
+// found and cleaned up.
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Resolution is how far a model's time must move before the log is worth
+// writing again; a model in use every refresh writes at most this often.
+const Resolution = time.Minute
+
+// server is the record of one server: when tracking began, each model's
+// last use and the totals of its requests.
+type server struct {
+	Since  time.Time            `json:"since"`
+	Models map[string]time.Time `json:"models"`
+	Totals map[string]Totals    `json:"totals,omitempty"`
+	Days   map[string]Energy    `json:"days,omitempty"` // by date, 2006-01-02
+}
+
+// Energy is what the GPUs drew over a day, and the part of it while busy.
+type Energy struct {
+	Wh       float64       `json:"wh"`
+	BusyWh   float64       `json:"busy_wh"`
+	BusyTime time.Duration `json:"busy_time"`
+}
+
+func (e *Energy) add(o Energy) {
+	e.Wh += o.Wh
+	e.BusyWh += o.BusyWh
+	e.BusyTime += o.BusyTime
+}
+
+// BusyWatts is the mean draw while busy, 0 if the GPUs never were.
+func (e Energy) BusyWatts() float64 {
+	if e.BusyTime <= 0 {
+		return 0
+	}
+	return e.BusyWh / e.BusyTime.Hours()
+}
+
+// Totals adds up the requests that used a model.
+type Totals struct {
+	Requests     int           `json:"requests"`
+	InputTokens  int           `json:"input_tokens"`
+	OutputTokens int           `json:"output_tokens"`
+	GPUTime      time.Duration `json:"gpu_time"` // spent on prompts and replies
+}
+
+func (t *Totals) add(o Totals) {
+	t.Requests += o.Requests
+	t.InputTokens += o.InputTokens
+	t.OutputTokens += o.OutputTokens
+	t.GPUTime += o.GPUTime
+}
+
+// Log is the usage of the models on every server, by URL. It is safe for
+// concurrent use, and Save merges with the file so the TUI and the daemon
+// can share it.
+type Log struct {
+	mu      sync.Mutex
+	path    string
+	servers map[string]*server
+	dirty   bool
+	// pending are the totals and energy added since the last save, by
+	// server and model or date; Save adds them to the file's rather than
+	// overwriting it.
+	pending       map[string]map[string]Totals
+	pendingEnergy map[string]map[string]Energy
+	saved         time.Time
+}
+
+// Open reads the log at path; a missing file is empty, and an empty path
+// keeps the log in memory only.
+func Open(path string) (*Log, error) {
+	servers, err := read(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Log{path: path, servers: servers, pending: make(map[string]map[string]Totals),
+		pendingEnergy: make(map[string]map[string]Energy)}, nil
+}
+
+func read(path string) (map[string]*server, error) {
+	if path == "" {
+		return make(map[string]*server), nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return make(map[string]*server), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	servers := make(map[string]*server)
+	if err := json.Unmarshal(data, &servers); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, s := range servers {
+		if s.Models == nil {
+			s.Models = make(map[string]time.Time)
+		}
+		if s.Totals == nil {
+			s.Totals = make(map[string]Totals)
+		}
+		if s.Days == nil {
+			s.Days = make(map[string]Energy)
+		}
+	}
+	return servers, nil
+}
+
+// Touch notes that model was used on host at t. Earlier times than the one
+// recorded are ignored.
+func (l *Log) Touch(host, model string, t time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.touch(host, model, t)
+}
+
+func (l *Log) touch(host, model string, t time.Time) *server {
+	s := l.servers[host]
+	if s == nil {
+		s = newServer(t)
+		l.servers[host] = s
+		l.dirty = true
+	}
+	if model == "" {
+		return s
+	}
+	if last := s.Models[model]; t.Sub(last) >= Resolution {
+		s.Models[model] = t
+		l.dirty = true
+	}
+	return s
+}
+
+func newServer(since time.Time) *server {
+	return &server{Since: since, Models: make(map[string]time.Time), Totals: make(map[string]Totals),
+		Days: make(map[string]Energy)}
+}
+
+// AddEnergy counts what the GPUs of host drew in the sample ending at t.
+// Samples come every few seconds, so the log becomes worth saving only once
+// per Resolution.
+func (l *Log) AddEnergy(host string, t time.Time, e Energy) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := l.touch(host, "", t)
+	day := t.Format(time.DateOnly)
+	total := s.Days[day]
+	total.add(e)
+	s.Days[day] = total
+	if l.pendingEnergy[host] == nil {
+		l.pendingEnergy[host] = make(map[string]Energy)
+	}
+	p := l.pendingEnergy[host][day]
+	p.add(e)
+	l.pendingEnergy[host][day] = p
+	if t.Sub(l.saved) >= Resolution {
+		l.dirty = true
+	}
+}
+
+// Days returns the energy recorded on host by date.
+func (l *Log) Days(host string) map[string]Energy {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make(map[string]Energy)
+	if s := l.servers[host]; s != nil {
+		for day, e := range s.Days {
+			out[day] = e
+		}
+	}
+	return out
+}
+
+// Add counts a request to model on host at t towards its totals.
+func (l *Log) Add(host, model string, t time.Time, req Totals) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := l.touch(host, model, t)
+	total := s.Totals[model]
+	total.add(req)
+	s.Totals[model] = total
+	if l.pending[host] == nil {
+		l.pending[host] = make(map[string]Totals)
+	}
+	p := l.pending[host][model]
+	p.add(req)
+	l.pending[host][model] = p
+	l.dirty = true
+}
+
+// Totals returns the totals of every model with requests on host.
+func (l *Log) Totals(host string) map[string]Totals {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make(map[string]Totals)
+	if s := l.servers[host]; s != nil {
+		for model, t := range s.Totals {
+			out[model] = t
+		}
+	}
+	return out
+}
+
+// Last returns when model was last used on host, zero if it never was
+// while the log was kept.
+func (l *Log) Last(host, model string) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s := l.servers[host]; s != nil {
+		return s.Models[model]
+	}
+	return time.Time{}
+}
+
+// Since returns when tracking began on host, zero if it hasn't.
+func (l *Log) Since(host string) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s := l.servers[host]; s != nil {
+		return s.Since
+	}
+	return time.Time{}
+}
+
+// Save writes the log if anything changed, keeping the later of its own
+// and the file's time for each model, and adding the totals and energy
+// counted since the last save to the file's.
+func (l *Log) Save() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.dirty || l.path == "" {
+		return nil
+	}
+	return l.save()
+}
+
+// Sync saves the log whether or not anything changed, taking in what other
+// processes, such as the daemon, have saved since it was read.
+func (l *Log) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.path == "" {
+		return nil
+	}
+	return l.save()
+}
+
+func (l *Log) save() error {
+	disk, err := read(l.path)
+	if err != nil {
+		disk = make(map[string]*server) // start over from a bad file
+	}
+	for host, s := range l.servers {
+		d := disk[host]
+		if d == nil {
+			d = newServer(s.Since)
+			disk[host] = d
+		}
+		if d.Since.IsZero() || (!s.Since.IsZero() && s.Since.Before(d.Since)) {
+			d.Since = s.Since
+		}
+		for model, t := range s.Models {
+			if t.After(d.Models[model]) {
+				d.Models[model] = t
+			}
+		}
+		for model, p := range l.pending[host] {
+			total := d.Totals[model]
+			total.add(p)
+			d.Totals[model] = total
+		}
+		for day, p := range l.pendingEnergy[host] {
+			total := d.Days[day]
+			total.add(p)
+			d.Days[day] = total
+		}
+	}
+	data, err := json.MarshalIndent(disk, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return err
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		return err
+	}
+	l.servers, l.pending, l.pendingEnergy = disk, make(map[string]map[string]Totals), make(map[string]map[string]Energy)
+	l.dirty, l.saved = false, time.Now()
+	return nil
+}
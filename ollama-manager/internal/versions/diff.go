@@ -0,0 +1,65 @@
+package versions
+
+import "strings"
+
+// Line is one line of a diff. Op is ' ' for a line both sides share, '-'
+// for one only in the old text and '+' for one only in the new.
+type Line struct {
+	Op   byte
+	Text string
+}
+
+// Diff compares old and new line by line, keeping the longest run of
+// shared lines. Modelfiles are short, so the quadratic table is fine.
+func Diff(old, new string) []Line {
+	a := strings.Split(strings.TrimRight(old, "\n"), "\n")
+	b := strings.Split(strings.TrimRight(new, "\n"), "\n")
+	// common[i][j] is the length of the longest common subsequence of
+	// a[i:] and b[j:].
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+	var out []Line
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, Line{' ', a[i]})
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			out = append(out, Line{'-', a[i]})
+			i++
+		default:
+			out = append(out, Line{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, Line{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		out = append(out, Line{'+', b[j]})
+	}
+	return out
+}
+
+// Changed reports whether the diff has any added or removed lines.
+func Changed(lines []Line) bool {
+	for _, l := range lines {
+		if l.Op != ' ' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+// Package versions keeps the Modelfiles models were built from, per server
+// and model, so a model can be rebuilt from an earlier one.
+package versions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Keep is how many versions of a model are kept; older ones are dropped.
+const Keep = 20
+
+// Version is a Modelfile a model was built from. Numbers count up per model
+// and stay put when old versions are dropped.
+type Version struct {
+	Number  int       `json:"number"`
+	Created time.Time `json:"created"`
+	Text    string    `json:"text"`
+	Note    string    `json:"note,omitempty"` // e.g. "rolled back to version 2"
+}
+
+// Store is the versions file.
+type Store struct {
+	path    string
+	servers map[string]map[string][]Version // by host, then model
+}
+
+// Open reads the store at path. A missing file is an empty store; an empty
+// path keeps the store in memory only.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, servers: make(map[string]map[string][]Version)}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.servers); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Name is model as Ollama lists it, with ":latest" for a missing tag.
+func Name(model string) string {
+	if i := strings.LastIndex(model, "/"); !strings.Contains(model[i+1:], ":") {
+		return model + ":latest"
+	}
+	return model
+}
+
+// Versions returns the kept versions of model on host, oldest first.
+func (s *Store) Versions(host, model string) []Version {
+	return s.servers[host][Name(model)]
+}
+
+// Latest returns the newest version of model on host.
+func (s *Store) Latest(host, model string) (Version, bool) {
+	vs := s.Versions(host, model)
+	if len(vs) == 0 {
+		return Version{}, false
+	}
+	return vs[len(vs)-1], true
+}
+
+// Add records text as the newest version of model on host and saves the
+// store. A build from the same text as the newest version only updates its
+// time and note.
+func (s *Store) Add(host, model, text, note string) error {
+	model = Name(model)
+	if s.servers[host] == nil {
+		s.servers[host] = make(map[string][]Version)
+	}
+	vs := s.servers[host][model]
+	now := time.Now()
+	if n := len(vs); n > 0 && vs[n-1].Text == text {
+		vs[n-1].Created, vs[n-1].Note = now, note
+	} else {
+		v := Version{Number: 1, Created: now, Text: text, Note: note}
+		if n > 0 {
+			v.Number = vs[n-1].Number + 1
+		}
+		vs = append(vs, v)
+		if len(vs) > Keep {
+			vs = append([]Version(nil), vs[len(vs)-Keep:]...)
+		}
+	}
+	s.servers[host][model] = vs
+	return s.save()
+}
+
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.servers, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,136 @@
+// Package vram estimates how much GPU memory a model needs and whether it
+// fits in what is currently free.
+package vram
+
+import (
+	"strconv"
+
+	"ollama-manager/internal/ollama"
+)
+
+// DefaultContext is the num_ctx Ollama uses when a model doesn't set one.
+const DefaultContext = 4096
+
+// overhead approximates the CUDA context and compute graph buffers that
+// Ollama allocates on top of weights and KV cache.
+const overhead = 512 << 20
+
+// Arch holds the architecture dimensions that drive KV cache size.
+type Arch struct {
+	Layers  int
+	HeadsKV int
+	HeadDim int
+	Context int // num_ctx from the Modelfile, or DefaultContext
+
+	// Embedding is set for embedding models, which can't chat, and Vision
+	// for models that accept images.
+	Embedding bool
+	Vision    bool
+}
+
+// ArchFromShow extracts the dimensions from /api/show output.
+func ArchFromShow(info *ollama.ShowResponse) Arch {
+	a := Arch{
+		Layers:  info.BlockCount(),
+		HeadsKV: info.HeadCountKV(),
+		HeadDim: info.HeadDim(),
+		Context: DefaultContext,
+
+		Embedding: info.Embedding(),
+		Vision:    info.Vision(),
+	}
+	if v := info.Parameter("num_ctx"); len(v) > 0 {
+		if n, err := strconv.Atoi(v[0]); err == nil && n > 0 {
+			a.Context = n
+		}
+	}
+	return a
+}
+
+// Known reports whether enough metadata was found to size the KV cache.
+func (a Arch) Known() bool {
+	return a.Layers > 0 && a.HeadsKV > 0 && a.HeadDim > 0
+}
+
+// KVCache returns the bytes needed for the K and V caches at ctx tokens with
+// an f16 cache.
+func (a Arch) KVCache(ctx int) uint64 {
+	const bytesPerElem = 2 // f16
+	return 2 * uint64(a.Layers) * uint64(ctx) * uint64(a.HeadsKV) * uint64(a.HeadDim) * bytesPerElem
+}
+
+// Estimate is a breakdown of the VRAM a model is expected to use.
+type Estimate struct {
+	Weights  uint64
+	KVCache  uint64
+	Overhead uint64
+}
+
+// Total is the full expected footprint.
+func (e Estimate) Total() uint64 {
+	return e.Weights + e.KVCache + e.Overhead
+}
+
+// ForModel estimates the footprint of a model whose GGUF weights are size
+// bytes, at context length ctx. When arch is unknown the KV cache is
+// approximated as a tenth of the weights.
+func ForModel(size int64, arch Arch, ctx int) Estimate {
+	e := Estimate{Weights: uint64(size), Overhead: overhead}
+	if arch.Known() {
+		e.KVCache = arch.KVCache(ctx)
+	} else {
+		e.KVCache = uint64(size) / 10
+	}
+	return e
+}
+
+// OnGPU scales e to n of the model's layers offloaded to the GPU, as with
+// num_gpu. The output layer counts as one more, and the overhead stays.
+func (e Estimate) OnGPU(arch Arch, n int) Estimate {
+	total := arch.Layers + 1
+	if !arch.Known() || n >= total {
+		return e
+	}
+	share := float64(max(n, 0)) / float64(total)
+	e.Weights = uint64(float64(e.Weights) * share)
+	e.KVCache = uint64(float64(e.KVCache) * share)
+	return e
+}
+
+// Fit classifies an estimate against free VRAM.
+type Fit int
+
+const (
+	FitUnknown Fit = iota
+	Fits
+	Tight
+	WontFit
+)
+
+func (f Fit) String() string {
+	switch f {
+	case Fits:
+		return "fits"
+	case Tight:
+		return "tight"
+	case WontFit:
+		return "won't fit"
+	}
+	return "unknown"
+}
+
+// tightMargin is the share of free VRAM above which a fit is called tight;
+// fragmentation and other processes make the last few percent unreliable.
+const tightMargin = 0.9
+
+// Check compares a needed footprint with the free VRAM.
+func Check(need, free uint64) Fit {
+	switch {
+	case float64(need) <= float64(free)*tightMargin:
+		return Fits
+	case need <= free:
+		return Tight
+	default:
+		return WontFit
+	}
+}
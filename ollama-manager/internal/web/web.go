@@ -0,0 +1,165 @@
+// Package web serves a small dashboard for managing the server from a
+// browser, such as on a phone: the models with load and unload, the GPUs
+// and pulls. The page is embedded and polls a JSON API; what it shows and
+// does comes from a Backend, the same operations the TUI uses.
+package web
+
+import (
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"ollama-manager/internal/gpu"
+)
+
+// DefaultListen is the address the dashboard listens on unless told
+// otherwise.
+const DefaultListen = ":8080"
+
+// cookieName keeps the token once the page was opened with ?token=.
+const cookieName = "ollama-manager-token"
+
+//go:embed index.html
+var index []byte
+
+// Model is an installed model and, when loaded, how it is loaded.
+type Model struct {
+	Name          string    `json:"name"`
+	Size          int64     `json:"size"`
+	ParameterSize string    `json:"parameter_size,omitempty"`
+	Quantization  string    `json:"quantization,omitempty"`
+	Loaded        bool      `json:"loaded"`
+	SizeVRAM      int64     `json:"size_vram,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// Pull is a queued, running or finished pull.
+type Pull struct {
+	Name     string  `json:"name"`
+	State    string  `json:"state"`
+	Status   string  `json:"status,omitempty"`
+	Progress float64 `json:"progress"` // 0 to 1
+	Rate     float64 `json:"rate,omitempty"`
+}
+
+// State is everything the page shows. GPUError says why GPUs is empty.
+type State struct {
+	Host     string       `json:"host"`
+	Models   []Model      `json:"models"`
+	GPUs     []gpu.Device `json:"gpus"`
+	GPUError string       `json:"gpu_error,omitempty"`
+	Pulls    []Pull       `json:"pulls"`
+}
+
+// Backend does the work behind the dashboard.
+type Backend interface {
+	State(ctx context.Context) (State, error)
+	Load(ctx context.Context, name string) error
+	Unload(ctx context.Context, name string) error
+	Pull(name string) error
+}
+
+// Handler serves the dashboard and its API to callers with token. Opening
+// the page as /?token=... keeps it in a cookie, so the link only has to be
+// typed once per browser.
+func Handler(b Backend, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		if t := r.URL.Query().Get("token"); t != "" {
+			if !equal(t, token) {
+				http.Error(w, "wrong token", http.StatusUnauthorized)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: cookieName, Value: t, Path: "/", HttpOnly: true,
+				SameSite: http.SameSiteStrictMode, MaxAge: int((365 * 24 * time.Hour).Seconds())})
+			// Drop the token from the address bar and history.
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
+		if !authorized(r, token) {
+			http.Error(w, "open the link with ?token= printed by `ollama-manager web`", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(index)
+	})
+	mux.HandleFunc("/api/state", func(w http.ResponseWriter, r *http.Request) {
+		st, err := b.State(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(st)
+	})
+	mux.HandleFunc("/api/load", action(b.Load))
+	mux.HandleFunc("/api/unload", action(b.Unload))
+	mux.HandleFunc("/api/pull", action(func(_ context.Context, name string) error { return b.Pull(name) }))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" && !authorized(r, token) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// action runs fn on the model named in a JSON body. Requiring JSON keeps
+// other sites from posting forms to the API with the cookie.
+func action(fn func(ctx context.Context, name string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "use POST", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "send JSON", http.StatusUnsupportedMediaType)
+			return
+		}
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil || req.Name == "" {
+			http.Error(w, "the body must be {\"name\": \"model\"}", http.StatusBadRequest)
+			return
+		}
+		if err := fn(r.Context(), req.Name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func authorized(r *http.Request, token string) bool {
+	if c, err := r.Cookie(cookieName); err == nil && equal(c.Value, token) {
+		return true
+	}
+	return false
+}
+
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// Serve runs the dashboard on addr until ctx is done.
+func Serve(ctx context.Context, addr string, h http.Handler) error {
+	srv := &http.Server{Addr: addr, Handler: h, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Package webhook posts events to HTTP endpoints, as plain JSON or as chat
+// messages for Discord and Slack incoming webhooks.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// timeout bounds one delivery; alerting must not hold anything up.
+const timeout = 10 * time.Second
+
+// Formats are the payload formats Post can send.
+var Formats = []string{"json", "discord", "slack"}
+
+// Event is something worth telling an alerting system about. It is the
+// payload of the "json" format.
+type Event struct {
+	Event string    `json:"event"` // e.g. "server_down"
+	Host  string    `json:"host"`  // the Ollama server it concerns
+	Text  string    `json:"text"`  // a one-line description
+	Time  time.Time `json:"time"`
+}
+
+// Post sends ev to the webhook at rawURL in format, "json" (or empty),
+// "discord" or "slack".
+func Post(ctx context.Context, rawURL, format string, ev Event) error {
+	var payload any = ev
+	msg := fmt.Sprintf("[%s] %s", ev.Host, ev.Text)
+	switch format {
+	case "", "json":
+	case "discord":
+		payload = map[string]string{"content": msg}
+	case "slack":
+		payload = map[string]string{"text": msg}
+	default:
+		return fmt.Errorf("webhook: unknown format %q", format)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return redact(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return redact(err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook: %s", resp.Status)
+	}
+	return nil
+}
+
+// redact drops the URL from transport errors: Discord and Slack webhook
+// URLs carry their secret in the path.
+func redact(err error) error {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return fmt.Errorf("webhook: %w", uerr.Err)
+	}
+	return err
+}
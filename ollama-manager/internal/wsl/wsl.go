@@ -0,0 +1,179 @@
+// Package wsl finds the Ollama servers a Windows machine runs: the native
+// one and those inside running WSL2 distributions, and which of them
+// answers on localhost.
+package wsl
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"ollama-manager/internal/applog"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/service"
+)
+
+// Owners of the localhost port.
+const (
+	Windows = "windows"
+	WSL     = "wsl"
+)
+
+// Distro is a running WSL distribution with Ollama installed.
+type Distro struct {
+	Name    string
+	Running bool   // an ollama process runs in it
+	Addr    string // the distribution's address, as seen from Windows
+}
+
+// Report is what Detect found.
+type Report struct {
+	Native    bool // ollama.exe is installed
+	NativePID int  // and runs as this process, or 0
+	Distros   []Distro
+	Owner     string // Windows or WSL for whichever listens on the port, or ""
+}
+
+// Conflict reports whether a native and a WSL server both run. Only one of
+// them gets localhost: WSL forwards a port to Windows only while nothing
+// on Windows listens on it.
+func (r *Report) Conflict() bool {
+	return r.NativePID > 0 && len(r.Running()) > 0
+}
+
+// Running returns the distributions an ollama process runs in.
+func (r *Report) Running() []Distro {
+	var out []Distro
+	for _, d := range r.Distros {
+		if d.Running {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Detect looks for both kinds of server and for who listens on port. It
+// only looks inside distributions that are already running, since asking a
+// stopped one would boot it. Elsewhere than Windows it returns nil.
+func Detect(ctx context.Context, port string) (*Report, error) {
+	if runtime.GOOS != "windows" {
+		return nil, nil
+	}
+	r := &Report{Native: filepath.IsAbs(ollama.Binary())}
+	var err error
+	if r.NativePID, err = service.WindowsPID(ctx, "ollama.exe"); err != nil {
+		return nil, err
+	}
+	names, err := running(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if d, ok := probe(ctx, name); ok {
+			r.Distros = append(r.Distros, d)
+		}
+	}
+	switch pid := listener(ctx, port); {
+	case pid == 0:
+	case pid == r.NativePID:
+		r.Owner = Windows
+	case len(r.Running()) > 0:
+		// WSL's relay process holds the port on Windows' side.
+		r.Owner = WSL
+	}
+	return r, nil
+}
+
+// running lists the running distributions.
+func running(ctx context.Context) ([]string, error) {
+	out, err := wsl(ctx, "--list", "--running", "--quiet")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) || errors.Is(err, exec.ErrNotFound) {
+			// No distribution is running, or WSL isn't installed.
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, line := range strings.Split(out, "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// probeScript exits 3 without ollama and 4 when it isn't running, and
+// otherwise prints the distribution's addresses.
+const probeScript = `command -v ollama >/dev/null || exit 3; pgrep -x ollama >/dev/null || exit 4; hostname -I`
+
+// probe checks a distribution for Ollama, reporting false when it has none.
+func probe(ctx context.Context, name string) (Distro, bool) {
+	out, err := wsl(ctx, "--distribution", name, "--exec", "sh", "-c", probeScript)
+	d := Distro{Name: name}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 4:
+		return d, true
+	case err != nil:
+		return d, false
+	}
+	d.Running = true
+	if f := strings.Fields(out); len(f) > 0 {
+		d.Addr = f[0]
+	}
+	return d, true
+}
+
+// listener returns the PID listening on port, or 0.
+func listener(ctx context.Context, port string) int {
+	args := []string{"netstat", "-ano", "-p", "TCP"}
+	start := time.Now()
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+	applog.Command(slog.LevelDebug, start, args, err)
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		// "  TCP    127.0.0.1:11434    0.0.0.0:0    LISTENING    4242"
+		f := strings.Fields(line)
+		if len(f) != 5 || f[3] != "LISTENING" || !strings.HasSuffix(f[1], ":"+port) {
+			continue
+		}
+		if pid, err := strconv.Atoi(f[4]); err == nil {
+			return pid
+		}
+	}
+	return 0
+}
+
+// wsl runs wsl.exe, whose own messages are UTF-16 while the commands it
+// runs print UTF-8.
+func wsl(ctx context.Context, args ...string) (string, error) {
+	args = append([]string{"wsl.exe"}, args...)
+	start := time.Now()
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+	applog.Command(slog.LevelDebug, start, args, err)
+	return decode(out), err
+}
+
+// decode reads UTF-16LE output, recognised by its NUL high bytes, and
+// passes anything else through.
+func decode(b []byte) string {
+	if len(b) < 2 || len(b)%2 != 0 || b[1] != 0 {
+		return string(b)
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = uint16(b[2*i]) | uint16(b[2*i+1])<<8
+	}
+	return string(utf16.Decode(u))
+}
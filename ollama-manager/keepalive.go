@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/ollama"
+)
+
+// foreverThreshold separates real expiry times from the far-future timestamp
+// Ollama reports for models loaded with a negative keep-alive.
+const foreverThreshold = 100 * 365 * 24 * time.Hour
+
+// loadOptions returns the load settings configured for a model.
+func (m model) loadOptions(name string) ollama.LoadOptions {
+	return configLoadOptions(m.cfg, name)
+}
+
+// configLoadOptions reads a model's load settings from cfg. Invalid values
+// are ignored so the server default applies.
+func configLoadOptions(cfg *config.Config, name string) ollama.LoadOptions {
+	var opts ollama.LoadOptions
+	if v := cfg.KeepAliveFor(name); v != "" {
+		opts.KeepAlive, _ = ollama.ParseKeepAlive(v)
+	}
+	return opts
+}
+
+// expiryLabel renders the remaining keep-alive of a loaded model, e.g. " 4m12s".
+func (m model) expiryLabel(name string) string {
+	r, ok := m.running[name]
+	if !ok || r.ExpiresAt.IsZero() {
+		return ""
+	}
+	left := time.Until(r.ExpiresAt)
+	switch {
+	case left > foreverThreshold:
+		return " ∞"
+	case left <= 0:
+		return " expiring"
+	}
+	return " " + left.Round(time.Second).String()
+}
+
+// countdownMsg redraws the keep-alive countdowns.
+type countdownMsg struct{}
+
+// startCountdown ticks once a second while a loaded model has a keep-alive
+// running out; the list refresh is usually slower than that.
+func (m *model) startCountdown() tea.Cmd {
+	if m.counting {
+		return nil
+	}
+	for _, r := range m.running {
+		if left := time.Until(r.ExpiresAt); !r.ExpiresAt.IsZero() && left < foreverThreshold {
+			m.counting = true
+			return tea.Tick(time.Second, func(time.Time) tea.Msg { return countdownMsg{} })
+		}
+	}
+	return nil
+}
+
+// extendTargets restarts the keep-alive of the selected loaded models (or
+// the one under the cursor) without generating anything.
+func (m *model) extendTargets() tea.Cmd {
+	var targets []string
+	for _, mdl := range m.targets() {
+		if m.loaded[mdl.Name] {
+			targets = append(targets, mdl.Name)
+		}
+	}
+	if len(targets) == 0 {
+		m.status = "No loaded model to extend"
+		return nil
+	}
+	m.selected = make(map[string]bool)
+	c := m.client
+	opts := make(map[string]ollama.LoadOptions, len(targets))
+	for _, name := range targets {
+		opts[name] = m.extendOptions(name)
+	}
+	extend := func(name string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		return c.Load(ctx, name, opts[name])
+	}
+	if len(targets) == 1 {
+		name := targets[0]
+		return m.runOp("Extending keep-alive of "+name, "Extended keep-alive of "+name, func() error {
+			return extend(name)
+		})
+	}
+	return m.runBatchOp("Extending keep-alive of", "Extended keep-alive of", targets, extend)
+}
+
+// extendOptions loads a model again with its configured keep-alive. The
+// context length it runs with is kept, as a different one would reload it.
+func (m model) extendOptions(name string) ollama.LoadOptions {
+	opts := m.loadOptions(name)
+	opts.NumCtx = m.running[name].ContextLength
+	return opts
+}
+
+// openKeepAliveInput prompts for a model's keep-alive, prefilled with the
+// current setting.
+func (m model) openKeepAliveInput(name string) (tea.Model, tea.Cmd) {
+	m.mode = modeKeepAliveInput
+	m.keepAliveModel = name
+	m.input.Reset()
+	m.input.Prompt = fmt.Sprintf("Keep-alive for %s (10m, 1h, -1 = forever, empty = default): ", name)
+	m.input.Placeholder = ""
+	m.input.CharLimit = 16
+	m.input.Width = 16
+	m.input.SetValue(m.cfg.ModelKeepAlive[name])
+	m.input.CursorEnd()
+	return m, m.input.Focus()
+}
+
+// updateKeepAliveInput saves the entered keep-alive to the config and, if the
+// model is loaded, re-applies it right away.
+func (m model) updateKeepAliveInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = modeList
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		name := m.keepAliveModel
+		value := strings.TrimSpace(m.input.Value())
+		if value != "" {
+			if _, err := ollama.ParseKeepAlive(value); err != nil {
+				m.status = err.Error()
+				return m, nil
+			}
+		}
+		m.mode = modeList
+		m.input.Blur()
+
+		m.cfg.SetModelKeepAlive(name, value)
+		if err := m.cfg.Save(); err != nil {
+			m.status = fmt.Sprintf("Could not save config: %v", err)
+			return m, nil
+		}
+		set := fmt.Sprintf("Keep-alive for %s set to %s", name, displayKeepAlive(m.cfg.KeepAliveFor(name)))
+		if m.loaded[name] {
+			return m, m.startLoad("Applying keep-alive to "+name, set, name, m.loadOptions(name))
+		}
+		m.status = set
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func displayKeepAlive(v string) string {
+	if v == "" {
+		return "server default"
+	}
+	return v
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// keyMap holds every rebindable action. Enter and Esc stay fixed because
+// text inputs rely on them, and Ctrl+C always quits.
+type keyMap struct {
+	Up, Down     key.Binding
+	PageUp       key.Binding
+	PageDown     key.Binding
+	Top, Bottom  key.Binding
+	Details      key.Binding
+	Select       key.Binding
+	Filter       key.Binding
+	Sort         key.Binding
+	Reverse      key.Binding
+	Run          key.Binding
+	LoadWith     key.Binding
+	GenOptions   key.Binding
+	Stop         key.Binding
+	UnloadAll    key.Binding
+	Chat         key.Binding
+	Compare      key.Binding
+	OpenAI       key.Binding
+	Bench        key.Binding
+	BenchHistory key.Binding
+	Sweep        key.Binding
+	Parallel     key.Binding
+	KVTest       key.Binding
+	Suites       key.Binding
+	KeepAlive    key.Binding
+	Extend       key.Binding
+	Warmup       key.Binding
+	Favorite     key.Binding
+	Alias        key.Binding
+	Note         key.Binding
+	Hide         key.Binding
+	ShowHidden   key.Binding
+	Group        key.Binding
+	Unused       key.Binding
+	Usage        key.Binding
+	Hosts        key.Binding
+	Server       key.Binding
+	Restart      key.Binding
+	Recreate     key.Binding
+	Upgrade      key.Binding
+	Settings     key.Binding
+	Processes    key.Binding
+	Pull         key.Binding
+	Updates      key.Binding
+	PullQueue    key.Binding
+	Browse       key.Binding
+	HuggingFace  key.Binding
+	Copy         key.Binding
+	CopyName     key.Binding
+	Snippet      key.Binding
+	CopyToHost   key.Binding
+	Modelfile    key.Binding
+	ImportGGUF   key.Binding
+	Delete       key.Binding
+	Refresh      key.Binding
+	Disk         key.Binding
+	Prune        key.Binding
+	Verify       key.Binding
+	MoveStore    key.Binding
+	Archive      key.Binding
+	Errors       key.Binding
+	Logs         key.Binding
+	Theme        key.Binding
+	Help         key.Binding
+	Quit         key.Binding
+
+	ChatStop   key.Binding
+	ChatClear  key.Binding
+	Attach     key.Binding
+	Template   key.Binding
+	Sessions   key.Binding
+	Export     key.Binding
+	EmbedBench key.Binding
+	Cancel     key.Binding
+	Save       key.Binding
+	Versions   key.Binding
+	Kill       key.Binding
+	MoveUp     key.Binding
+	MoveDown   key.Binding
+	Pause      key.Binding
+	Verbose    key.Binding
+	NewKey     key.Binding
+	Limits     key.Binding
+	Abort      key.Binding
+
+	NextTab key.Binding
+	PrevTab key.Binding
+}
+
+func binding(desc string, keys ...string) key.Binding {
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(keyLabel(keys), desc))
+}
+
+func defaultKeyMap() keyMap {
+	return keyMap{
+		Up:           binding("Up", "up", "k"),
+		Down:         binding("Down", "down", "j"),
+		PageUp:       binding("Page up", "pgup"),
+		PageDown:     binding("Page down", "pgdown"),
+		Top:          binding("First model", "home"),
+		Bottom:       binding("Last model", "end"),
+		Details:      binding("Info", "i", "enter"),
+		Select:       binding("Select", " "),
+		Filter:       binding("Filter", "/"),
+		Sort:         binding("Sort", "o"),
+		Reverse:      binding("Reverse sort", "O"),
+		Run:          binding("Run", "r"),
+		LoadWith:     binding("Load with options", "n"),
+		GenOptions:   binding("Generation defaults", "g"),
+		Stop:         binding("Stop", "s"),
+		UnloadAll:    binding("Unload All", "u"),
+		Chat:         binding("Chat", "c"),
+		Compare:      binding("Compare two models", "V"),
+		OpenAI:       binding("OpenAI API console", "D"),
+		Bench:        binding("Bench", "b"),
+		BenchHistory: binding("Bench history", "B"),
+		Sweep:        binding("Context sweep", "Z"),
+		Parallel:     binding("Parallelism test", "N"),
+		KVTest:       binding("Flash attention/KV cache test", "J"),
+		Suites:       binding("Benchmark suites", "ctrl+e"),
+		KeepAlive:    binding("Keep-alive", "a"),
+		Extend:       binding("Extend keep-alive", "e"),
+		Warmup:       binding("Warm-up sets", "w"),
+		Favorite:     binding("Favorite", "f"),
+		Alias:        binding("Alias", "A"),
+		Note:         binding("Note and tags", "#"),
+		Hide:         binding("Hide", "H"),
+		ShowHidden:   binding("Show hidden", "."),
+		Group:        binding("Group tags by model", "z"),
+		Unused:       binding("Only unused models", "W"),
+		Usage:        binding("Model usage", "ctrl+u"),
+		Hosts:        binding("Hosts", "h"),
+		Server:       binding("Server", "S"),
+		Restart:      binding("Restart server", "T"),
+		Recreate:     binding("Recreate container", "X"),
+		Upgrade:      binding("Upgrade Ollama", "u"),
+		Settings:     binding("Ollama settings", "="),
+		Processes:    binding("GPU processes", "G"),
+		Pull:         binding("Pull", "p"),
+		Updates:      binding("Check for updates", "C"),
+		PullQueue:    binding("Pull queue", "Q"),
+		Browse:       binding("Browse library", "L"),
+		HuggingFace:  binding("Hugging Face", "F"),
+		Copy:         binding("Copy", "y"),
+		CopyName:     binding("Copy name", "ctrl+n"),
+		Snippet:      binding("Copy API snippet", "ctrl+y"),
+		CopyToHost:   binding("Copy to host", "Y"),
+		Modelfile:    binding("Modelfile", "m"),
+		ImportGGUF:   binding("Import GGUF", "I"),
+		Delete:       binding("Delete", "d"),
+		Refresh:      binding("Refresh", "R"),
+		Disk:         binding("Disk usage", "U"),
+		Prune:        binding("Clean up orphans", "P"),
+		Verify:       binding("Verify model files", "v"),
+		MoveStore:    binding("Move models directory", "M"),
+		Archive:      binding("Archive/restore", "ctrl+a"),
+		Errors:       binding("Errors", "E"),
+		Logs:         binding("Log", "l"),
+		Theme:        binding("Switch theme", "t"),
+		Help:         binding("Help", "?"),
+		Quit:         binding("Quit", "q"),
+
+		ChatStop:   binding("Stop reply", "ctrl+x"),
+		ChatClear:  binding("Clear", "ctrl+l"),
+		Attach:     binding("Attach image", "ctrl+o"),
+		Template:   binding("Prompt template", "ctrl+t"),
+		Sessions:   binding("Saved chats", "ctrl+r"),
+		Export:     binding("Export", "X"),
+		EmbedBench: binding("Benchmark embeddings", "ctrl+b"),
+		Cancel:     binding("Cancel", "x"),
+		Save:       binding("Create model", "ctrl+s"),
+		Versions:   binding("Modelfile versions", "ctrl+r"),
+		Kill:       binding("Kill process", "K"),
+		MoveUp:     binding("Move up", "shift+up", "["),
+		MoveDown:   binding("Move down", "shift+down", "]"),
+		Pause:      binding("Pause/resume", " "),
+		Verbose:    binding("Show debug entries", "v"),
+		NewKey:     binding("New API key", "N"),
+		Limits:     binding("Limits", "L"),
+		Abort:      binding("Abort request", "K"),
+
+		NextTab: binding("Next tab", "tab"),
+		PrevTab: binding("Previous tab", "shift+tab"),
+	}
+}
+
+// actions names each binding as it appears under "keys" in the config file.
+func (k *keyMap) actions() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up":            &k.Up,
+		"down":          &k.Down,
+		"page_up":       &k.PageUp,
+		"page_down":     &k.PageDown,
+		"top":           &k.Top,
+		"bottom":        &k.Bottom,
+		"details":       &k.Details,
+		"select":        &k.Select,
+		"filter":        &k.Filter,
+		"sort":          &k.Sort,
+		"reverse":       &k.Reverse,
+		"run":           &k.Run,
+		"load_with":     &k.LoadWith,
+		"gen_options":   &k.GenOptions,
+		"stop":          &k.Stop,
+		"unload_all":    &k.UnloadAll,
+		"chat":          &k.Chat,
+		"compare":       &k.Compare,
+		"openai":        &k.OpenAI,
+		"bench":         &k.Bench,
+		"bench_history": &k.BenchHistory,
+		"sweep":         &k.Sweep,
+		"parallel":      &k.Parallel,
+		"kv_test":       &k.KVTest,
+		"suites":        &k.Suites,
+		"keep_alive":    &k.KeepAlive,
+		"extend":        &k.Extend,
+		"warmup":        &k.Warmup,
+		"favorite":      &k.Favorite,
+		"alias":         &k.Alias,
+		"note":          &k.Note,
+		"hide":          &k.Hide,
+		"show_hidden":   &k.ShowHidden,
+		"group":         &k.Group,
+		"unused":        &k.Unused,
+		"usage":         &k.Usage,
+		"hosts":         &k.Hosts,
+		"server":        &k.Server,
+		"restart":       &k.Restart,
+		"recreate":      &k.Recreate,
+		"upgrade":       &k.Upgrade,
+		"settings":      &k.Settings,
+		"processes":     &k.Processes,
+		"pull":          &k.Pull,
+		"updates":       &k.Updates,
+		"pull_queue":    &k.PullQueue,
+		"browse":        &k.Browse,
+		"huggingface":   &k.HuggingFace,
+		"copy":          &k.Copy,
+		"copy_name":     &k.CopyName,
+		"snippet":       &k.Snippet,
+		"copy_to_host":  &k.CopyToHost,
+		"modelfile":     &k.Modelfile,
+		"import_gguf":   &k.ImportGGUF,
+		"delete":        &k.Delete,
+		"refresh":       &k.Refresh,
+		"disk":          &k.Disk,
+		"prune":         &k.Prune,
+		"verify":        &k.Verify,
+		"move_store":    &k.MoveStore,
+		"archive":       &k.Archive,
+		"errors":        &k.Errors,
+		"logs":          &k.Logs,
+		"theme":         &k.Theme,
+		"help":          &k.Help,
+		"quit":          &k.Quit,
+		"chat_stop":     &k.ChatStop,
+		"chat_clear":    &k.ChatClear,
+		"attach":        &k.Attach,
+		"template":      &k.Template,
+		"sessions":      &k.Sessions,
+		"export":        &k.Export,
+		"embed_bench":   &k.EmbedBench,
+		"cancel":        &k.Cancel,
+		"save":          &k.Save,
+		"versions":      &k.Versions,
+		"kill":          &k.Kill,
+		"move_up":       &k.MoveUp,
+		"move_down":     &k.MoveDown,
+		"pause":         &k.Pause,
+		"verbose":       &k.Verbose,
+		"new_key":       &k.NewKey,
+		"limits":        &k.Limits,
+		"abort":         &k.Abort,
+		"next_tab":      &k.NextTab,
+		"prev_tab":      &k.PrevTab,
+	}
+}
+
+// newKeyMap applies the overrides from the config file to the defaults. An
+// empty key list unbinds the action.
+func newKeyMap(overrides map[string][]string) (keyMap, error) {
+	k := defaultKeyMap()
+	actions := k.actions()
+	for name, keys := range overrides {
+		b, ok := actions[name]
+		if !ok {
+			return k, fmt.Errorf("keys: unknown action %q (valid: %s)", name, strings.Join(actionNames(actions), ", "))
+		}
+		if len(keys) == 0 {
+			b.Unbind()
+			continue
+		}
+		b.SetKeys(keys...)
+		b.SetHelp(keyLabel(keys), b.Help().Desc)
+	}
+	return k, nil
+}
+
+func actionNames(actions map[string]*key.Binding) []string {
+	names := make([]string, 0, len(actions))
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// keyLabel renders keys the way the help bar shows them, e.g. "i/Enter".
+func keyLabel(keys []string) string {
+	labels := make([]string, len(keys))
+	for i, k := range keys {
+		switch k {
+		case " ":
+			k = "Space"
+		case "up":
+			k = "↑"
+		case "down":
+			k = "↓"
+		case "pgup":
+			k = "PgUp"
+		case "pgdown":
+			k = "PgDn"
+		case "home", "end":
+			k = strings.ToUpper(k[:1]) + k[1:]
+		case "enter", "esc", "tab":
+			k = strings.ToUpper(k[:1]) + k[1:]
+		case "shift+tab":
+			k = "Shift+Tab"
+		case "shift+up":
+			k = "Shift+↑"
+		case "shift+down":
+			k = "Shift+↓"
+		default:
+			if rest, ok := strings.CutPrefix(k, "ctrl+"); ok {
+				k = "Ctrl+" + strings.ToUpper(rest)
+			}
+		}
+		labels[i] = k
+	}
+	return strings.Join(labels, "/")
+}
+
+// listHelp is the set of bindings shown in the model list's help bar; the
+// rest are listed in the help overlay.
+func (k keyMap) listHelp() []key.Binding {
+	return []key.Binding{
+		k.Filter, k.Select, k.Run, k.Stop, k.Details, k.Chat, k.Pull,
+		k.Delete, k.Help, k.Quit,
+	}
+}
+
+// relabel describes b differently on a screen that gives it another meaning,
+// such as r starting the Ollama service on the server panel.
+func relabel(b key.Binding, desc string) key.Binding {
+	b.SetHelp(b.Help().Key, desc)
+	return b
+}
+
+// helpLine renders bindings as "key: Desc" pairs, skipping unbound ones.
+func helpLine(bindings ...key.Binding) string {
+	parts := make([]string, 0, len(bindings))
+	for _, b := range bindings {
+		if !b.Enabled() {
+			continue
+		}
+		parts = append(parts, b.Help().Key+": "+b.Help().Desc)
+	}
+	return strings.Join(parts, "  ")
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/bench"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/report"
+	"ollama-manager/internal/service"
+)
+
+const (
+	envFlash   = "OLLAMA_FLASH_ATTENTION"
+	envKVCache = "OLLAMA_KV_CACHE_TYPE"
+
+	// kvContext is the num_ctx the A/B test runs at unless the model has
+	// its own: the KV cache is what the variants change, and at Ollama's
+	// default context it is too small to tell apart.
+	kvContext = 16384
+)
+
+// kvVariant is one server configuration the A/B test benchmarks under.
+type kvVariant struct {
+	name string
+	env  map[string]string // an empty value unsets the variable
+}
+
+// kvVariants go from the server's defaults to the smallest cache. A
+// quantized cache needs flash attention.
+var kvVariants = []kvVariant{
+	{"server default", map[string]string{envFlash: "", envKVCache: ""}},
+	{"flash attention", map[string]string{envFlash: "1", envKVCache: ""}},
+	{"flash + q8_0 cache", map[string]string{envFlash: "1", envKVCache: "q8_0"}},
+	{"flash + q4_0 cache", map[string]string{envFlash: "1", envKVCache: "q4_0"}},
+}
+
+// kvRun is the benchmark under one variant; err is set when the model
+// failed under it, as some do with a quantized cache.
+type kvRun struct {
+	variant string
+	result  *bench.Result
+	err     error
+}
+
+// kvTest is a finished A/B test of one model.
+type kvTest struct {
+	model string
+	runs  []kvRun
+}
+
+// kvTestDoneMsg carries the finished (or failed) A/B test.
+type kvTestDoneMsg struct {
+	test *kvTest
+	err  error
+}
+
+// restartWith applies env to the service and restarts it, waiting until the
+// API answers again.
+func restartWith(ctx context.Context, c *ollama.Client, svc string, env map[string]string) error {
+	if err := service.SetEnv(ctx, svc, env); err != nil {
+		return err
+	}
+	if err := service.Control(ctx, svc, service.Restart); err != nil {
+		return err
+	}
+	return waitForAPI(ctx, c)
+}
+
+// runKVTest benchmarks model under each of kvVariants, restarting the
+// service between them, and puts the service's own settings back after,
+// even when cancelled or failing.
+func runKVTest(ctx context.Context, c *ollama.Client, svc, model string, opts map[string]any, progress bench.Progress) (test *kvTest, err error) {
+	before, err := service.Env(ctx, svc)
+	if err != nil {
+		return nil, fmt.Errorf("reading the server's settings: %w", err)
+	}
+	defer func() {
+		progress("restoring the server's settings")
+		rctx, cancel := context.WithTimeout(context.Background(), serviceTimeout)
+		defer cancel()
+		if rerr := restartWith(rctx, c, svc, map[string]string{envFlash: before[envFlash], envKVCache: before[envKVCache]}); rerr != nil {
+			err = errors.Join(err, fmt.Errorf("restoring the server's settings: %w", rerr))
+		}
+	}()
+
+	runOpts := map[string]any{"num_ctx": kvContext}
+	for k, v := range opts {
+		runOpts[k] = v
+	}
+	test = &kvTest{model: model}
+	for _, v := range kvVariants {
+		progress("restarting with " + v.name)
+		rctx, cancel := context.WithTimeout(ctx, serviceTimeout)
+		err := restartWith(rctx, c, svc, v.env)
+		cancel()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("restarting with %s: %w", v.name, err)
+		}
+		res, err := bench.Benchmark(ctx, c, model, bench.DefaultPrompts, runOpts, func(step string) {
+			progress(v.name + ": " + step)
+		})
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		test.runs = append(test.runs, kvRun{variant: v.name, result: res, err: err})
+	}
+	return test, nil
+}
+
+// openKVTest asks before an A/B test of name, since it restarts the
+// server several times.
+func (m model) openKVTest(name string) (tea.Model, tea.Cmd) {
+	switch _, _, container := service.ContainerName(m.serviceName()); {
+	case !m.client.Local():
+		m.status = "The flash attention test restarts the server, so it only runs on this machine's"
+		return m, nil
+	case container:
+		m.status = "The flash attention test can't change a container's settings; set them under \"container\" in the config"
+		return m, nil
+	}
+	m.mode = modeBench
+	if m.bench == nil {
+		m.kvConfirm = name
+	}
+	return m, nil
+}
+
+// startKVTest runs the confirmed A/B test in the background.
+func (m model) startKVTest(name string) (tea.Model, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &benchState{
+		model:   name,
+		step:    "starting",
+		started: time.Now(),
+		updates: make(chan tea.Msg, 16),
+		cancel:  cancel,
+		label:   "Flash attention test of " + name,
+	}
+	c, svc, opts := m.client, m.serviceName(), m.genOptions(name)
+	go func() {
+		defer close(b.updates)
+		test, err := runKVTest(ctx, c, svc, name, opts, func(step string) {
+			b.updates <- benchStepMsg(step)
+		})
+		b.updates <- kvTestDoneMsg{test: test, err: err}
+	}()
+	m.bench = b
+	m.status = "Testing flash attention and KV cache types with " + name
+	return m, tea.Batch(listen(b.updates), m.startBusy())
+}
+
+func (m *model) finishKVTest(msg kvTestDoneMsg) tea.Cmd {
+	name, started := m.bench.model, m.bench.started
+	m.bench = nil
+	m.busy = max(m.busy-1, 0)
+	switch {
+	case errors.Is(msg.err, context.Canceled):
+		m.status = "Flash attention test of " + name + " cancelled"
+		if msg.err != context.Canceled { // restoring the settings failed too
+			m.logError(msg.err.Error())
+		}
+	case msg.err != nil:
+		m.status = fmt.Sprintf("Flash attention test of %s failed: %v", name, msg.err)
+		m.logError(m.status)
+	default:
+		m.kvTest = msg.test
+		m.status = kvSummary(msg.test)
+		for _, r := range msg.test.runs {
+			if r.err != nil {
+				m.logError(fmt.Sprintf("%s with %s: %v", name, r.variant, r.err))
+			}
+		}
+	}
+	return tea.Batch(m.refresh(), fetchServer(m.client, m.serviceName()), m.notify("bench", started, "%s", m.status))
+}
+
+// kvConfirmView is the prompt before an A/B test.
+func (m model) kvConfirmView() string {
+	names := make([]string, len(kvVariants))
+	for i, v := range kvVariants {
+		names[i] = v.name
+	}
+	return modalStyle.Render(fmt.Sprintf("Benchmark %s under %s?\n\nThe Ollama service restarts before each run, unloading every model, and\nits %s and %s are put back at the end.\n\ny: Start  any other key: Cancel",
+		m.kvConfirm, strings.Join(names, ", "), envFlash, envKVCache))
+}
+
+// kvReport lays out an A/B test, comparing each variant with the first.
+func kvReport(kt *kvTest) report.Table {
+	t := report.Table{
+		Title:  "Flash attention and KV cache test of " + kt.model,
+		Header: []string{"VARIANT", "GEN t/s", "PROMPT t/s", "VRAM", "vs DEFAULT"},
+		Note:   kvSummary(kt),
+	}
+	base := kt.runs[0].result
+	for _, r := range kt.runs {
+		if r.err != nil {
+			t.Rows = append(t.Rows, []string{r.variant, "-", "-", "-", r.err.Error()})
+			continue
+		}
+		vs := "-"
+		if base != nil && r.result != base && base.GenTPS > 0 && base.VRAM > 0 {
+			vs = fmt.Sprintf("%+.1f%% speed, %+.1f%% VRAM",
+				(r.result.GenTPS-base.GenTPS)/base.GenTPS*100, float64(r.result.VRAM-base.VRAM)/float64(base.VRAM)*100)
+		}
+		t.Rows = append(t.Rows, []string{r.variant, fmt.Sprintf("%.1f", r.result.GenTPS), fmt.Sprintf("%.1f", r.result.PromptTPS),
+			formatBytes(uint64(r.result.VRAM)), vs})
+	}
+	return t
+}
+
+// kvSummary names the fastest variant and the one using the least VRAM.
+func kvSummary(t *kvTest) string {
+	var fastest, smallest *kvRun
+	for i := range t.runs {
+		r := &t.runs[i]
+		if r.err != nil {
+			continue
+		}
+		if fastest == nil || r.result.GenTPS > fastest.result.GenTPS {
+			fastest = r
+		}
+		if smallest == nil || r.result.VRAM < smallest.result.VRAM {
+			smallest = r
+		}
+	}
+	if fastest == nil {
+		return t.model + ": failed under every variant"
+	}
+	return fmt.Sprintf("%s: fastest with %s (%.1f tok/s), least VRAM with %s (%s)",
+		t.model, fastest.variant, fastest.result.GenTPS, smallest.variant, formatBytes(uint64(smallest.result.VRAM)))
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/report"
+	"ollama-manager/internal/usage"
+)
+
+// leaderRow is one installed model's share of the work on the leaderboard.
+type leaderRow struct {
+	Name     string       `json:"name"`
+	Size     int64        `json:"size"`
+	LastUsed *time.Time   `json:"last_used,omitempty"`
+	Totals   usage.Totals `json:"totals"`
+	EnergyWh float64      `json:"energy_wh,omitempty"` // estimated from GPU time
+}
+
+// leaderboardState is the usage leaderboard screen.
+type leaderboardState struct {
+	rows   []leaderRow
+	since  time.Time
+	days   []dayEnergy
+	price  *config.Electricity
+	offset int // first row shown
+}
+
+// leaderboard ranks the installed models by requests, then GPU time. Models
+// without any come last, biggest first, as they are the ones to weigh up.
+// A model's energy is its GPU time at the GPUs' mean draw while busy.
+func leaderboard(log *usage.Log, host string, models []ollama.Model) []leaderRow {
+	totals := log.Totals(host)
+	watts := busyWatts(log, host)
+	rows := make([]leaderRow, 0, len(models))
+	for _, mdl := range models {
+		r := leaderRow{Name: mdl.Name, Size: mdl.Size, Totals: totals[mdl.Name]}
+		r.EnergyWh = r.Totals.GPUTime.Hours() * watts
+		if t := log.Last(host, mdl.Name); !t.IsZero() {
+			r.LastUsed = &t
+		}
+		rows = append(rows, r)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i].Totals, rows[j].Totals
+		switch {
+		case a.Requests != b.Requests:
+			return a.Requests > b.Requests
+		case a.GPUTime != b.GPUTime:
+			return a.GPUTime > b.GPUTime
+		}
+		return rows[i].Size > rows[j].Size
+	})
+	return rows
+}
+
+// leaderboardReport lays out the leaderboard. SHARE is each model's part of
+// all requests; the note totals the disk space of models without any.
+func leaderboardReport(rows []leaderRow, since time.Time, price *config.Electricity) report.Table {
+	t := report.Table{
+		Title:  "Model usage",
+		Header: []string{"MODEL", "SIZE", "REQUESTS", "SHARE", "GENERATED", "GPU TIME", "ENERGY", "COST", "LAST USED"},
+	}
+	all := 0
+	for _, r := range rows {
+		all += r.Totals.Requests
+	}
+	idle, idleSize := 0, int64(0)
+	for _, r := range rows {
+		share, last := "-", time.Time{}
+		if all > 0 {
+			share = fmt.Sprintf("%.0f%%", float64(r.Totals.Requests)/float64(all)*100)
+		}
+		if r.LastUsed != nil {
+			last = *r.LastUsed
+		}
+		if r.Totals.Requests == 0 {
+			idle++
+			idleSize += r.Size
+			t.Rows = append(t.Rows, []string{r.Name, formatBytes(uint64(r.Size)), "0", "-", "-", "-", "-", "-", lastUsedText(last)})
+			continue
+		}
+		t.Rows = append(t.Rows, []string{r.Name, formatBytes(uint64(r.Size)), fmt.Sprint(r.Totals.Requests), share,
+			fmt.Sprintf("%d tokens", r.Totals.OutputTokens), r.Totals.GPUTime.Round(time.Second).String(),
+			energyText(r.EnergyWh), costText(r.EnergyWh, price), lastUsedText(last)})
+	}
+	if idle > 0 && !since.IsZero() {
+		t.Note = fmt.Sprintf("No requests through the proxy since %s: %d of %d models, taking %s",
+			since.Format("2006-01-02"), idle, len(rows), formatBytes(uint64(idleSize)))
+	}
+	return t
+}
+
+// leaderboardTables are the leaderboard and, once any was recorded, the
+// energy by day.
+func leaderboardTables(rows []leaderRow, since time.Time, days []dayEnergy, price *config.Electricity) []report.Table {
+	tables := []report.Table{leaderboardReport(rows, since, price)}
+	if len(days) > 0 {
+		tables = append(tables, energyReport(days, price))
+	}
+	return tables
+}
+
+func (m model) openLeaderboard() (tea.Model, tea.Cmd) {
+	host := m.client.Host()
+	if err := m.usage.Sync(); err != nil {
+		m.logError("Reading model usage: " + err.Error())
+	}
+	m.leaderboard = &leaderboardState{
+		rows:  leaderboard(m.usage, host, m.models),
+		since: m.usage.Since(host),
+		days:  recentEnergy(m.usage, host, energyDays),
+		price: m.cfg.Electricity,
+	}
+	m.mode = modeLeaderboard
+	return m, nil
+}
+
+func (m model) updateLeaderboard(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	lb := m.leaderboard
+	if m.exportAsk {
+		tables := leaderboardTables(lb.rows, lb.since, lb.days, lb.price)
+		raw := struct {
+			Models []leaderRow `json:"models"`
+			Days   []dayEnergy `json:"days,omitempty"`
+		}{lb.rows, lb.days}
+		return m, m.answerExport(msg.String(), "model-usage", "Ollama model usage", tables, raw)
+	}
+	switch {
+	case msg.String() == "esc", key.Matches(msg, m.keys.Quit, m.keys.Usage):
+		m.mode = modeList
+	case key.Matches(msg, m.keys.Up):
+		lb.offset = max(lb.offset-1, 0)
+	case key.Matches(msg, m.keys.Down):
+		lb.offset = min(lb.offset+1, max(len(lb.rows)-1, 0))
+	case key.Matches(msg, m.keys.Export):
+		m.exportAsk = true
+	}
+	return m, nil
+}
+
+func (m model) leaderboardView() string {
+	lb := m.leaderboard
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Model Usage"))
+	b.WriteString("\n\n")
+
+	requests := 0
+	for _, r := range lb.rows {
+		requests += r.Totals.Requests
+	}
+	switch {
+	case lb.since.IsZero():
+		b.WriteString(helpStyle.Render("No usage recorded yet. Requests are counted as they pass through the proxy (ollama-manager proxy, or daemon --proxy)."))
+		b.WriteString("\n\n")
+	case requests == 0:
+		b.WriteString(helpStyle.Render(fmt.Sprintf("No requests through the proxy since %s.", lb.since.Format("2006-01-02"))))
+		b.WriteString("\n\n")
+	default:
+		b.WriteString(helpStyle.Render(fmt.Sprintf("%d requests through the proxy since %s", requests, lb.since.Format("2006-01-02"))))
+		b.WriteString("\n\n")
+	}
+
+	t := leaderboardReport(lb.rows, lb.since, lb.price)
+	rows := 15
+	if m.height > 0 {
+		rows = max(m.height-12, 3)
+		if len(lb.days) > 0 {
+			rows = max(rows-len(lb.days)-3, 3)
+		}
+	}
+	end := min(lb.offset+rows, len(t.Rows))
+	note := t.Note
+	t.Rows = t.Rows[lb.offset:end]
+	b.WriteString(report.Text(t))
+	if end < len(lb.rows) {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("… %d more", len(lb.rows)-end)))
+		b.WriteString("\n")
+	}
+	if note != "" {
+		b.WriteString(warnStyle.Render(note))
+		b.WriteString("\n")
+	}
+	if len(lb.days) > 0 {
+		e := energyReport(lb.days, lb.price)
+		b.WriteString("\n")
+		b.WriteString(report.Text(e))
+		b.WriteString(helpStyle.Render(e.Note))
+		if lb.price == nil {
+			b.WriteString(helpStyle.Render(`; set "electricity" in the config to see what it costs`))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	if m.exportAsk {
+		b.WriteString(warnStyle.Render(exportPrompt))
+	} else {
+		b.WriteString(helpStyle.Render(helpLine(m.keys.Up, m.keys.Down, m.keys.Export) + "  Esc: Back"))
+	}
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("\nStatus: %s", m.status))
+	return b.String()
+}
+
+func cmdUsage(c *ollama.Client, args []string) error {
+	fs := flag.NewFlagSet("usage", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "emit JSON")
+	format := fs.String("format", "", "csv or md instead of a table for the terminal")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if fs.NArg() != 0 {
+		return usageError{"usage: usage [--format csv|md] [--json]"}
+	}
+	models, err := getModels(c)
+	if err != nil {
+		return err
+	}
+	log, err := openUsage()
+	if err != nil {
+		return err
+	}
+	rows := leaderboard(log, c.Host(), models)
+	if *asJSON {
+		return writeJSON(rows)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	tables := leaderboardTables(rows, log.Since(c.Host()), recentEnergy(log, c.Host(), energyDays), cfg.Electricity)
+	switch *format {
+	case "":
+	case "csv":
+		return report.CSV(os.Stdout, tables)
+	case "md":
+		return report.Markdown(os.Stdout, "", tables)
+	default:
+		return usageError{fmt.Sprintf("--format: %q is not csv or md", *format)}
+	}
+	for i, t := range tables {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Print(report.Text(t))
+		if t.Note != "" {
+			fmt.Println(t.Note)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/service"
+	"ollama-manager/internal/vram"
+)
+
+// loadDialog asks for num_ctx and num_gpu before loading a model and shows
+// what the chosen context will cost in VRAM. For a local server it also
+// sets which GPUs Ollama uses and whether it spreads models across them;
+// those are server-wide, so changing them restarts the service first.
+type loadDialog struct {
+	name    string
+	size    int64
+	arch    vram.Arch
+	ctx     textinput.Model
+	gpu     textinput.Model
+	devices textinput.Model
+	spread  bool
+	focus   int
+
+	env    map[string]string // the service's, nil until read
+	envErr error
+}
+
+// The load dialog's fields, in Tab order.
+const (
+	loadFieldCtx = iota
+	loadFieldGPU
+	loadFieldDevices
+	loadFieldSpread
+)
+
+// loadEnvMsg carries the service environment for the load dialog.
+type loadEnvMsg envMsg
+
+// placedMsg reports the service restarted with a new GPU placement, after
+// which the load it was changed for goes ahead.
+type placedMsg struct {
+	name string
+	opts ollama.LoadOptions
+	err  error
+}
+
+func (m model) openLoadDialog(mdl ollama.Model) (tea.Model, tea.Cmd) {
+	arch := m.arch[archKey(mdl)]
+	numCtx := arch.Context
+	if numCtx == 0 {
+		numCtx = vram.DefaultContext
+	}
+
+	ctx := textinput.New()
+	ctx.Prompt = "num_ctx: "
+	ctx.CharLimit = 8
+	ctx.Width = 10
+	ctx.SetValue(strconv.Itoa(numCtx))
+	ctx.CursorEnd()
+
+	gpu := textinput.New()
+	gpu.Prompt = "num_gpu: "
+	gpu.Placeholder = "auto"
+	gpu.CharLimit = 4
+	gpu.Width = 10
+
+	devices := textinput.New()
+	devices.Prompt = "GPUs:    "
+	devices.Placeholder = "all"
+	devices.CharLimit = 64
+	devices.Width = 20
+
+	m.mode = modeLoadOptions
+	m.loadDialog = &loadDialog{name: mdl.Name, size: mdl.Size, arch: arch, ctx: ctx, gpu: gpu, devices: devices}
+	if !m.client.Local() {
+		m.loadDialog.envErr = errRemoteService
+		return m, m.loadDialog.ctx.Focus()
+	}
+	name := m.serviceName()
+	readLoadEnv := func() tea.Msg {
+		return loadEnvMsg(readEnv(name)().(envMsg))
+	}
+	return m, tea.Batch(m.loadDialog.ctx.Focus(), readLoadEnv)
+}
+
+// setEnv takes in the service environment, starting the placement fields
+// at its values.
+func (d *loadDialog) setEnv(msg loadEnvMsg) {
+	d.env, d.envErr = msg.values, msg.err
+	if d.env != nil {
+		d.devices.SetValue(d.env["CUDA_VISIBLE_DEVICES"])
+		d.spread = isOn(d.env["OLLAMA_SCHED_SPREAD"])
+	}
+}
+
+// isOn reads a boolean environment variable the way Ollama does.
+func isOn(v string) bool {
+	b, err := strconv.ParseBool(v)
+	return err == nil && b
+}
+
+// fields is how many fields Tab cycles through; the placement ones only
+// once the environment is known.
+func (d *loadDialog) fields() int {
+	if d.env == nil {
+		return loadFieldDevices
+	}
+	return loadFieldSpread + 1
+}
+
+// input is the focused text field, or nil on the spread toggle.
+func (d *loadDialog) input() *textinput.Model {
+	switch d.focus {
+	case loadFieldCtx:
+		return &d.ctx
+	case loadFieldGPU:
+		return &d.gpu
+	case loadFieldDevices:
+		return &d.devices
+	}
+	return nil
+}
+
+// values parses the inputs. An empty num_gpu leaves the split to Ollama.
+func (d *loadDialog) values() (numCtx int, numGPU *int, err error) {
+	numCtx, err = strconv.Atoi(strings.TrimSpace(d.ctx.Value()))
+	if err != nil || numCtx < 1 {
+		return 0, nil, fmt.Errorf("num_ctx must be a positive number of tokens")
+	}
+	if v := strings.TrimSpace(d.gpu.Value()); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, nil, fmt.Errorf("num_gpu must be a layer count, or empty for auto")
+		}
+		numGPU = &n
+	}
+	if err := checkDevices(d.devices.Value()); err != nil {
+		return 0, nil, err
+	}
+	return numCtx, numGPU, nil
+}
+
+// checkDevices validates a CUDA_VISIBLE_DEVICES list: GPU indexes or UUIDs
+// separated by commas.
+func checkDevices(v string) error {
+	if strings.TrimSpace(v) == "" {
+		return nil
+	}
+	for _, dev := range strings.Split(v, ",") {
+		dev = strings.TrimSpace(dev)
+		if n, err := strconv.Atoi(dev); (err != nil || n < 0) && !strings.HasPrefix(dev, "GPU-") {
+			return fmt.Errorf("GPUs must be indexes or UUIDs separated by commas, e.g. 0 or 0,1")
+		}
+	}
+	return nil
+}
+
+// placement returns the environment changes the chosen GPUs and spread
+// need, if any.
+func (d *loadDialog) placement() map[string]string {
+	if d.env == nil {
+		return nil
+	}
+	changes := make(map[string]string)
+	if v := strings.ReplaceAll(d.devices.Value(), " ", ""); v != d.env["CUDA_VISIBLE_DEVICES"] {
+		changes["CUDA_VISIBLE_DEVICES"] = v
+	}
+	if d.spread != isOn(d.env["OLLAMA_SCHED_SPREAD"]) {
+		changes["OLLAMA_SCHED_SPREAD"] = ""
+		if d.spread {
+			changes["OLLAMA_SCHED_SPREAD"] = "1"
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// place writes the new placement to the service environment and restarts
+// it, waiting for the API so the load finds the server up.
+func place(c *ollama.Client, serviceName string, changes map[string]string, name string, opts ollama.LoadOptions) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), serviceTimeout)
+		defer cancel()
+		err := service.SetEnv(ctx, serviceName, changes)
+		if err == nil {
+			err = service.Control(ctx, serviceName, service.Restart)
+		}
+		if err == nil {
+			err = waitForAPI(ctx, c)
+		}
+		return placedMsg{name: name, opts: opts, err: err}
+	}
+}
+
+func (m *model) finishPlace(msg placedMsg) tea.Cmd {
+	m.busy = max(m.busy-1, 0)
+	if msg.err != nil {
+		m.status = fmt.Sprintf("Changing the GPUs for %s failed: %v", msg.name, msg.err)
+		m.logError(m.status)
+		return m.refresh()
+	}
+	running := fmt.Sprintf("Loading %s with num_ctx %d", msg.name, msg.opts.NumCtx)
+	return tea.Batch(m.startLoad(running, "Loaded "+msg.name, msg.name, msg.opts), fetchServer(m.client, m.serviceName()))
+}
+
+func (m model) updateLoadDialog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	d := m.loadDialog
+	switch msg.String() {
+	case "esc":
+		m.mode = modeList
+		return m, nil
+	case "tab", "shift+tab":
+		if in := d.input(); in != nil {
+			in.Blur()
+		}
+		step := 1
+		if msg.String() == "shift+tab" {
+			step = d.fields() - 1
+		}
+		d.focus = (d.focus + step) % d.fields()
+		if in := d.input(); in != nil {
+			return m, in.Focus()
+		}
+		return m, nil
+	case " ":
+		if d.focus == loadFieldSpread {
+			d.spread = !d.spread
+			return m, nil
+		}
+	case "enter":
+		numCtx, numGPU, err := d.values()
+		if err != nil {
+			m.status = err.Error()
+			return m, nil
+		}
+		m.mode = modeList
+		opts := m.loadOptions(d.name)
+		opts.NumCtx, opts.NumGPU = numCtx, numGPU
+		if changes := d.placement(); changes != nil {
+			m.status = fmt.Sprintf("Restarting Ollama with the new GPUs for %s...", d.name)
+			slog.Info("op", "action", "place", "model", d.name, "env", changes)
+			return m, tea.Batch(place(m.client, m.serviceName(), changes, d.name, opts), m.startBusy())
+		}
+		running := fmt.Sprintf("Loading %s with num_ctx %d", d.name, numCtx)
+		return m, m.startLoad(running, "Loaded "+d.name, d.name, opts)
+	}
+
+	var cmd tea.Cmd
+	if in := d.input(); in != nil {
+		*in, cmd = in.Update(msg)
+	}
+	return m, cmd
+}
+
+// loadFreeVRAM is the VRAM available to the model: what is free now plus
+// what it already holds, since a reload releases that first.
+func (m model) loadFreeVRAM(name string) (uint64, bool) {
+	free, ok := m.freeVRAM()
+	if r, loaded := m.running[name]; ok && loaded {
+		free += uint64(r.SizeVRAM)
+	}
+	return free, ok
+}
+
+func (m model) loadDialogView() string {
+	d := m.loadDialog
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Load " + d.name))
+	b.WriteString("\n\n")
+	b.WriteString(d.ctx.View())
+	b.WriteString("\n")
+	b.WriteString(d.gpu.View())
+	b.WriteString(helpStyle.Render("  layers on the GPU"))
+	if d.arch.Known() {
+		b.WriteString(helpStyle.Render(fmt.Sprintf(", %d in total", d.arch.Layers+1)))
+	}
+	b.WriteString("\n")
+	switch {
+	case d.env != nil:
+		b.WriteString(d.devices.View())
+		b.WriteString(helpStyle.Render("  CUDA_VISIBLE_DEVICES"))
+		b.WriteString("\n")
+		spread := "[ ]"
+		if d.spread {
+			spread = "[x]"
+		}
+		if d.focus == loadFieldSpread {
+			spread = cursorStyle.Render(spread)
+		}
+		b.WriteString("Spread:   " + spread + helpStyle.Render(" across all GPUs (OLLAMA_SCHED_SPREAD)"))
+		b.WriteString("\n")
+		if d.placement() != nil {
+			b.WriteString(warnStyle.Render("⚠ Restarts Ollama first, unloading every model"))
+			b.WriteString("\n")
+		}
+	case d.envErr != nil:
+		b.WriteString(helpStyle.Render("GPUs: " + d.envErr.Error()))
+		b.WriteString("\n")
+	default:
+		b.WriteString(helpStyle.Render("GPUs: reading the service environment..."))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	numCtx, numGPU, err := d.values()
+	if err != nil {
+		b.WriteString(errorStyle.Render(err.Error()))
+	} else {
+		est := vram.ForModel(d.size, d.arch, numCtx)
+		kv := fmt.Sprintf("KV cache at %d tokens: %s", numCtx, formatBytes(est.KVCache))
+		if numGPU != nil {
+			est = est.OnGPU(d.arch, *numGPU)
+		}
+		if !d.arch.Known() {
+			kv += helpStyle.Render(" (rough, architecture unknown)")
+		}
+		b.WriteString(kv)
+		b.WriteString("\n")
+		total := fmt.Sprintf("Estimated VRAM: ~%s", formatBytes(est.Total()))
+		free, ok := m.loadFreeVRAM(d.name)
+		if ok {
+			total += fmt.Sprintf(" of %s free", formatBytes(free))
+		}
+		b.WriteString(total)
+		if ok {
+			switch vram.Check(est.Total(), free) {
+			case vram.WontFit:
+				b.WriteString("\n" + warnStyle.Render("⚠ Exceeds free VRAM; lower num_ctx or expect part of the model on the CPU"))
+			case vram.Tight:
+				b.WriteString("\n" + warnStyle.Render("⚠ Barely fits; other GPU programs may push it onto the CPU"))
+			}
+		}
+	}
+	b.WriteString("\n\n")
+	help := "Tab: Switch field  Enter: Load  Esc: Cancel"
+	if d.focus == loadFieldSpread {
+		help = "Space: Toggle  " + help
+	}
+	b.WriteString(helpStyle.Render(help))
+	return modalStyle.Render(b.String())
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"ollama-manager/internal/applog"
+	"ollama-manager/internal/config"
+)
+
+const (
+	logFile = "ollama-manager.log"
+	// logSize is how many entries the log viewer keeps in memory.
+	logSize = 2000
+)
+
+// logState is the log viewer: every API call, command and error recorded
+// since startup.
+type logState struct {
+	ring    *applog.Ring
+	path    string // log file, or "" if it couldn't be opened
+	view    viewport.Model
+	verbose bool // show debug entries such as routine refreshes
+}
+
+// openLog sets up the default slog logger for both the TUI and the CLI
+// subcommands. The log file lives next to the benchmark history; if it can't
+// be opened, entries are only kept in memory.
+func openLog() (*logState, func() error, error) {
+	path := ""
+	dir, err := config.DataDir()
+	if err == nil {
+		path = filepath.Join(dir, logFile)
+	}
+	logger, ring, closeFn, openErr := applog.Open(path, logSize)
+	slog.SetDefault(logger)
+	if err == nil {
+		err = openErr
+	}
+	if err != nil {
+		path = ""
+	}
+	return &logState{ring: ring, path: path}, closeFn, err
+}
+
+func (m model) openLogView() (tea.Model, tea.Cmd) {
+	m.mode = modeLog
+	m.logs.view = viewport.New(m.width, max(m.height-4-tabBarLines, 5))
+	m.syncLog()
+	m.logs.view.GotoBottom()
+	return m, nil
+}
+
+// syncLog re-renders the buffered entries, following new ones if the view
+// was already scrolled to the end.
+func (m *model) syncLog() {
+	s := m.logs
+	follow := s.view.AtBottom()
+	var b strings.Builder
+	for _, e := range s.ring.Entries() {
+		if e.Level < slog.LevelInfo && !s.verbose {
+			continue
+		}
+		b.WriteString(formatLogEntry(e))
+		b.WriteString("\n")
+	}
+	content := strings.TrimSuffix(b.String(), "\n")
+	if content == "" {
+		content = "Nothing logged yet."
+	}
+	if m.width > 0 {
+		content = lipgloss.NewStyle().Width(m.width).Render(content)
+	}
+	s.view.SetContent(content)
+	if follow {
+		s.view.GotoBottom()
+	}
+}
+
+func formatLogEntry(e applog.Entry) string {
+	level := fmt.Sprintf("%-5s", e.Level)
+	switch {
+	case e.Level >= slog.LevelError:
+		level = errorStyle.Render(level)
+	case e.Level >= slog.LevelWarn:
+		level = warnStyle.Render(level)
+	case e.Level < slog.LevelInfo:
+		level = helpStyle.Render(level)
+	}
+	line := e.Time.Format("15:04:05") + " " + level + " " + e.Msg
+	if e.Attrs != "" {
+		line += " " + helpStyle.Render(e.Attrs)
+	}
+	return line
+}
+
+func (m model) updateLogView(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.String() == "esc", key.Matches(msg, m.keys.Quit, m.keys.Logs):
+		m.mode = modeList
+		return m, nil
+	case key.Matches(msg, m.keys.Verbose):
+		m.logs.verbose = !m.logs.verbose
+		m.syncLog()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.logs.view, cmd = m.logs.view.Update(msg)
+	return m, cmd
+}
+
+func (m model) logView() string {
+	s := m.logs
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Log"))
+	if s.path != "" {
+		b.WriteString(helpStyle.Render("  " + s.path))
+	}
+	b.WriteString("\n\n")
+	b.WriteString(s.view.View())
+	b.WriteString("\n")
+	verbose := m.keys.Verbose
+	if s.verbose {
+		verbose = relabel(verbose, "Hide debug entries")
+	}
+	b.WriteString(helpStyle.Render(fmt.Sprintf("↑/↓: Scroll  %s  Esc: Back  %3.0f%%",
+		helpLine(verbose), s.view.ScrollPercent()*100)))
+	return b.String()
+}
@@ -1,122 +1,1230 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
 	"os"
-	"os/exec"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
+
+	"ollama-manager/internal/applog"
+	"ollama-manager/internal/bench"
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/daemon"
+	"ollama-manager/internal/gpu"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/pullqueue"
+	"ollama-manager/internal/release"
+	"ollama-manager/internal/resume"
+	"ollama-manager/internal/schedule"
+	"ollama-manager/internal/service"
+	"ollama-manager/internal/session"
+	"ollama-manager/internal/store"
+	"ollama-manager/internal/usage"
+	"ollama-manager/internal/versions"
+	"ollama-manager/internal/vram"
+	"ollama-manager/internal/wsl"
 )
 
-var (
-	titleStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
-	loadedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
-	helpStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
-	cursorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+// apiTimeout bounds quick API calls such as listing or unloading models.
+const apiTimeout = 10 * time.Second
+
+// errRemoteGPU explains the empty GPU pane: readings come from this
+// machine, not the server being managed.
+var errRemoteGPU = errors.New("readings are only taken for a local server")
+
+// defaultRefresh is how often the model list and loaded state are re-queried.
+const defaultRefresh = 5 * time.Second
+
+// mode selects which component receives key presses.
+type mode int
+
+const (
+	modeList mode = iota
+	modePullInput
+	modeConfirmDelete
+	modeDetails
+	modeChat
+	modeKeepAliveInput
+	modeFilter
+	modeBench
+	modeHosts
+	modeHelp
+	modeCopyInput
+	modeModelfile
+	modeBrowse
+	modeDisk
+	modeLoadOptions
+	modeServer
+	modeSettings
+	modeProcs
+	modeLog
+	modeAliasInput
+	modeNoteInput
+	modeSnippet
+	modeWarmup
+	modeUpdates
+	modePullQueue
+	modeImportInput
+	modeHF
+	modeActivity
+	modeTraffic
+	modeOpenAI
+	modeEmbed
+	modeTemplate
+	modeSessions
+	modeGenOptions
+	modeCompare
+	modeConfirmRestore
+	modeSuites
+	modeLeaderboard
+	modeMoveStore
 )
 
 type model struct {
-	models  []string
+	client  *ollama.Client
+	cfg     *config.Config
+	keys    keyMap
+	models  []ollama.Model
+	visible []ollama.Model // models after filtering; the cursor indexes this
 	loaded  map[string]bool
-	cursor  int
+	running map[string]ollama.RunningModel
+	gpus    []gpu.Device
+	gpuErr  error
 	status  string
 	quiting bool
+	mode    mode
+
+	input   textinput.Model
+	bar     progress.Model
+	confirm *deleteConfirm
+
+	pulls       *pullqueue.Queue
+	queueCursor int
+
+	details     viewport.Model
+	detailsName string
+
+	chat     *chatState
+	compare  *compareState
+	embed    *embedState
+	template *templateState
+	openai   *openaiState
+
+	// sessions keeps chats on disk; sessionList is the picker over them.
+	sessions    *session.Store
+	sessionList *sessionsState
+
+	// showHidden lists hidden and ignored models too.
+	showHidden bool
+	// rows are the table's rows over visible; expanded holds the families
+	// opened while grouping tags by model.
+	rows     []listRow
+	expanded map[string]bool
+	// usage records when models were last used and, with energy, what the
+	// GPUs drew; onlyUnused narrows the list to those unused for the
+	// configured days.
+	usage       *usage.Log
+	energy      energyMeter
+	onlyUnused  bool
+	leaderboard *leaderboardState
+	moveStore   *moveState
+	// tiers says which models are archived to the cold store; tiersFailed
+	// is set while scanning for them fails.
+	tiers       map[string]store.Tier
+	tiersFailed bool
+
+	keepAliveModel string
+	copySource     string
+	aliasModel     string
+	noteModel      string
+	snippetModel   string
+	// notes are the notes and tags on models, by name.
+	notes map[string]modelNote
+	// counting is set while the keep-alive countdown ticks.
+	counting bool
+
+	modelfile *modelfileState
+	// modelfiles keeps the Modelfiles of the models built in the editor.
+	modelfiles *versions.Store
+	browse     *browseState
+	hf         *hfState
+	disk       *diskState
+	activity   *activityState
+	traffic    *trafficState
+
+	// loadDialog is the load-with-options prompt; genDialog edits a model's
+	// generation defaults.
+	loadDialog *loadDialog
+	genDialog  *genOptionsDialog
+
+	// server is the daemon's version and service state.
+	server   serverInfo
+	settings *settingsState
+	procs    *procsState
+
+	// selected holds names marked with space for batch operations.
+	selected map[string]bool
+
+	filter textinput.Model
+
+	table       table.Model
+	sortBy      sortKey
+	sortReverse bool
+
+	// listTop is the first row shown; lastClick is the row clicked last,
+	// for telling double-clicks apart.
+	listTop   int
+	lastClick click
+
+	bench        *benchState
+	benchHistory *bench.History
+	sweep        *bench.SweepResult    // the last context sweep this session
+	parallel     *bench.ParallelResult // the last parallelism test this session
+	kvTest       *kvTest               // the last flash attention test this session
+	kvConfirm    string                // the model a flash attention test awaits confirming for
+	suites       *suitesState          // nil until the suites are first opened
+	exportAsk    bool                  // the benchmark or suites screen awaits an export format
+	gpuHistory   *gpu.History
+
+	// localDaemon is the `ollama-manager daemon` on this machine the TUI
+	// attached to at startup, nil without one. It runs the schedule and
+	// watchers instead. daemonConn is the daemon behind the active host,
+	// which may be another machine's, and daemon its last status, nil
+	// until one arrives.
+	localDaemon *daemon.Client
+	daemonConn  *daemon.Client
+	daemon      *daemon.Status
+
+	// busy counts background operations in flight; the spinner runs while
+	// it is non-zero.
+	busy    int
+	spinner spinner.Model
+
+	// loading holds models a load was started for that /api/ps doesn't
+	// list yet; the value turns true once the load request has returned.
+	loading map[string]bool
+
+	errs           []errorEntry
+	showErrors     bool
+	logs           *logState
+	lastRefreshErr string
+	// refreshed is when the list last came back from the server.
+	refreshed time.Time
+	// down is set while the server can't be reached; the list then shows
+	// the last models seen and counts down to retryAt, with retrying set
+	// while that countdown ticks. cachedKey is the list last written to
+	// the models cache.
+	down      bool
+	retrying  bool
+	retryAt   time.Time
+	cachedKey string
+
+	width, height int
+
+	// arch caches model dimensions for the VRAM estimator, keyed by digest.
+	arch map[string]vram.Arch
+
+	refreshEvery time.Duration
+
+	// hosts lists the servers that can be managed; host indexes the active
+	// one. hostCache keeps the last model list seen on each, by name.
+	hosts      []config.Profile
+	host       int
+	hostCursor int
+	hostCache  map[string]hostSnapshot
+	// copyTo is the model the host list picks a destination for;
+	// hostCopy is the copy to another host in flight.
+	copyTo   string
+	hostCopy *hostCopyState
+
+	// wslTargets is where Ollama runs on a Windows machine, natively and
+	// under WSL2; nil until detected and elsewhere. wslWarned is set once
+	// the conflict between the two has been reported.
+	wslTargets *wsl.Report
+	wslWarned  bool
+
+	// container is the Ollama container found publishing the local API
+	// port, managed in place of the platform service; empty without one
+	// or when the config names the service.
+	container string
+
+	// latest is the newest Ollama release, nil until GitHub has answered;
+	// latestErr is why it couldn't be found.
+	latest    *release.Release
+	latestErr error
+
+	warmupCursor int // highlighted warm-up set
+
+	updates *updatesState // nil until updates are checked for
+
+	// wake notices the machine waking from sleep between ticks; nil
+	// without auto-refresh. reload holds the models to load again after
+	// it, with reload_on_resume.
+	wake   *resume.Detector
+	reload []string
+
+	// previous is the models loaded when the manager last ran, until the
+	// first refresh; restore is the plan to load them again while it is
+	// being confirmed. savedLoaded is the loaded set last recorded.
+	previous    []string
+	restore     *warmupPlan
+	savedLoaded string
+
+	idle    *idleWatch    // nil unless auto_unload is configured
+	thermal *thermalWatch // nil unless thermal is configured
+	alerts  *alerts       // nil without webhooks
+
+	// focused is set while the terminal has focus, for terminals that
+	// report it; notifications are held back then.
+	focused bool
 }
 
-func getModels() []string {
-	out, err := exec.Command("ollama", "list").Output()
-	if err != nil {
-		return nil
-	}
-	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
-	var models []string
-	for i, line := range lines {
-		if i == 0 {
-			continue // skip header
-		}
-		fields := strings.Fields(line)
-		if len(fields) > 0 {
-			models = append(models, fields[0])
+// tickMsg fires on every auto-refresh interval.
+type tickMsg time.Time
+
+// refreshMsg carries a fresh snapshot of installed and loaded models and GPU
+// readings.
+type refreshMsg struct {
+	host    string // server the snapshot came from
+	models  []ollama.Model
+	running []ollama.RunningModel
+	gpus    []gpu.Device
+	gpuErr  error
+	err     error
+}
+
+// listen waits for the next message from a background stream such as a pull
+// or chat. Handlers re-issue it after each message until the channel closes.
+func listen(ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-ch
+		if !ok {
+			return nil
 		}
+		return msg
 	}
-	return models
 }
 
-func getLoaded() map[string]bool {
-	loaded := make(map[string]bool)
-	out, err := exec.Command("ollama", "ps").Output()
-	if err != nil {
-		return loaded
+func tick(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+// refresh queries the active host off the Update loop.
+func (m model) refresh() tea.Cmd {
+	return refresh(m.client, m.remoteGPUs())
+}
+
+// refresh queries the server off the Update loop, reading the GPUs from
+// remote, a daemon on the server's machine, when it is set.
+func refresh(c *ollama.Client, remote *daemon.Client) tea.Cmd {
+	return func() tea.Msg {
+		return snapshot(c, remote)
 	}
-	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
-	for i, line := range lines {
-		if i == 0 {
-			continue // skip header
-		}
-		fields := strings.Fields(line)
-		if len(fields) > 0 {
-			loaded[fields[0]] = true
-		}
+}
+
+func snapshot(c *ollama.Client, remote *daemon.Client) refreshMsg {
+	models, err := getModels(c)
+	running, runErr := getRunning(c)
+	var gpus []gpu.Device
+	var gpuErr error
+	switch {
+	case remote != nil:
+		ctx, cancel := context.WithTimeout(context.Background(), attachTimeout)
+		gpus, gpuErr = remote.GPUs(ctx)
+		cancel()
+	case c.Local():
+		gpus, gpuErr = gpu.Query()
+	default:
+		gpuErr = errRemoteGPU
+	}
+	return refreshMsg{
+		host:    c.Host(),
+		models:  models,
+		running: running,
+		gpus:    gpus,
+		gpuErr:  gpuErr,
+		err:     errors.Join(err, runErr),
 	}
-	return loaded
 }
 
-func initialModel() model {
-	return model{
-		models: getModels(),
-		loaded: getLoaded(),
-		status: "Ready",
+func initialModel(c *ollama.Client, cfg *config.Config, keys keyMap, hosts []config.Profile, host int, refreshEvery time.Duration) model {
+	m := model{
+		client:       c,
+		cfg:          cfg,
+		keys:         keys,
+		hosts:        hosts,
+		host:         host,
+		hostCache:    make(map[string]hostSnapshot),
+		status:       "Ready",
+		input:        textinput.New(),
+		bar:          newProgressBar(),
+		pulls:        pullqueue.New(defaultPullWorkers, 0),
+		arch:         make(map[string]vram.Arch),
+		selected:     make(map[string]bool),
+		expanded:     make(map[string]bool),
+		loading:      make(map[string]bool),
+		filter:       newFilterInput(),
+		table:        newModelTable(),
+		refreshEvery: refreshEvery,
+		logs:         &logState{ring: applog.NewRing(logSize)},
+	}
+	m.spinner = spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(cursorStyle))
+	if refreshEvery > 0 {
+		m.wake = resume.NewDetector(refreshEvery)
+	}
+	m.previous = previousLoaded(cfg, c.Host())
+
+	h, err := openBenchHistory()
+	if err != nil {
+		m.logError("Benchmark history: " + err.Error())
+		h = &bench.History{}
+	}
+	m.benchHistory = h
+
+	used, err := openUsage()
+	if err != nil {
+		m.logError("Model usage: " + err.Error())
+		used, _ = usage.Open("")
+	}
+	m.usage = used
+
+	st, err := openSessionStore()
+	if err != nil {
+		m.logError("Saved chats: " + err.Error())
+		st = &session.Store{}
+	}
+	m.sessions = st
+
+	vs, err := openVersions()
+	if err != nil {
+		m.logError("Modelfile versions: " + err.Error())
+		vs, _ = versions.Open("")
+	}
+	m.modelfiles = vs
+
+	// Show the last list seen until the first refresh, and while the
+	// server is down.
+	if cached, ok := cachedList(c.Host()); ok {
+		m.models, m.refreshed = cached.Models, cached.Seen
+		m.applyFilter()
+	}
+
+	notes, err := readNotes()
+	if err != nil {
+		m.logError("Model notes: " + err.Error())
+		notes = map[string]modelNote{}
 	}
+	m.notes = notes
+	return m
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	cmds := []tea.Cmd{m.refresh(), fetchServer(m.client, m.serviceName()), listenQueue(m.pulls), m.syncDaemon(), m.fetchWSL(), m.findContainer()}
+	if m.refreshEvery > 0 {
+		cmds = append(cmds, tick(m.refreshEvery))
+	}
+	return tea.Batch(cmds...)
+}
+
+// applyRefresh swaps in a new snapshot, keeping the cursor on the same model
+// when it still exists.
+func (m *model) applyRefresh(msg refreshMsg) {
+	selected := ""
+	if cur, ok := m.current(); ok {
+		selected = cur.Name
+	}
+	m.models = msg.models
+	m.loaded = loadedSet(msg.running)
+	m.running = make(map[string]ollama.RunningModel, len(msg.running))
+	for _, r := range msg.running {
+		m.running[r.Name] = r
+	}
+	for name, done := range m.loading {
+		if !done {
+			continue
+		}
+		delete(m.loading, name)
+		if !m.loaded[name] {
+			m.logError(fmt.Sprintf("%s loaded but is no longer in memory; loading another model may have evicted it", name))
+		}
+	}
+	m.gpus = msg.gpus
+	m.gpuErr = msg.gpuErr
+	m.recordGPUs(msg.gpus)
+	if m.activity != nil {
+		m.activity.observe(time.Now(), msg.running)
+	}
+	m.pruneSelection()
+	m.applyFilter()
+	if i, ok := m.rowOf(selected); ok {
+		m.setCursor(i)
+	}
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tickMsg:
+		cmds := []tea.Cmd{m.refresh(), tick(m.refreshEvery)}
+		if slept, ok := m.wake.Check(time.Time(msg)); ok {
+			cmds = append(cmds, m.resumed(slept))
+		}
+		switch m.mode {
+		case modeLog:
+			m.syncLog()
+		case modeProcs:
+			// The list holds still while a kill waits for its y.
+			if m.procs.err != errRemoteGPU && !m.procs.loading && m.procs.kill == nil {
+				m.procs.loading = true
+				cmds = append(cmds, queryProcs)
+			}
+		case modeActivity:
+			cmds = append(cmds, m.pollActivity())
+		case modeTraffic:
+			cmds = append(cmds, m.pollTraffic())
+		}
+		return m, tea.Batch(cmds...)
+	case retryMsg:
+		return m, m.retry()
+	case countdownMsg:
+		m.counting = false
+		m.syncTable()
+		return m, m.startCountdown()
+	case refreshMsg:
+		if msg.host != m.client.Host() {
+			break // started before switching hosts
+		}
+		recovered := m.lastRefreshErr != "" && msg.err == nil
+		m.noteRefreshErr(msg.err)
+		hooks := m.alerts.send(m.alerts.observe(msg)...)
+		if msg.err != nil {
+			m.gpus, m.gpuErr = msg.gpus, msg.gpuErr
+			return m, tea.Batch(hooks, m.noteDown(msg.err))
+		}
+		m.down = false
+		m.applyRefresh(msg)
+		m.refreshed = time.Now()
+		m.cacheModels(msg.models)
+		m.hostCache[m.hosts[m.host].Name] = hostSnapshot{models: msg.models, running: msg.running, at: m.refreshed}
+		restore := m.offerRestore()
+		m.recordLoaded()
+		m.recordUsage()
+		if m.status == "Refreshing..." {
+			m.status = "Refreshed"
+		}
+		if recovered {
+			// The server came back, perhaps restarted or upgraded.
+			return m, tea.Batch(m.fetchMissingArch(), fetchServer(m.client, m.serviceName()), m.reloadAfterResume(), restore, hooks, m.scanTiers())
+		}
+		return m, tea.Batch(m.fetchMissingArch(), m.watchIdle(msg.running), m.watchThermal(msg.gpus), m.startCountdown(), m.reloadAfterResume(), restore, hooks, m.scanTiers())
+	case serverMsg:
+		if msg.host == m.client.Host() {
+			m.server.version, m.server.status, m.server.err = msg.version, msg.status, msg.err
+		}
+	case tiersMsg:
+		m.finishTiers(msg)
+	case wslMsg:
+		m.finishWSL(msg)
+	case containerMsg:
+		if msg.name != "" && m.container == "" {
+			m.container = msg.name
+			return m, fetchServer(m.client, m.serviceName())
+		}
+	case releaseMsg:
+		m.latest, m.latestErr = msg.rel, msg.err
+	case upgradeDoneMsg:
+		return m, m.finishUpgrade(msg)
+	case upgradeCheckedMsg:
+		return m, m.finishUpgradeCheck(msg)
+	case serverLogMsg:
+		m.server.log, m.server.logErr = msg.text, msg.err
+	case daemonMsg:
+		m.finishDaemon(msg)
+	case samplesMsg:
+		m.finishSamples(msg)
+	case serviceDoneMsg:
+		return m, m.finishService(msg)
+	case envMsg:
+		if m.settings != nil {
+			m.settings.loading = false
+			m.settings.values, m.settings.err = msg.values, msg.err
+		}
+	case procsMsg:
+		if m.procs != nil {
+			m.procs.setProcs(msg.procs, msg.err)
+		}
+	case killMsg:
+		return m, m.finishKill(msg)
+	case warmupDoneMsg:
+		return m, m.finishWarmup(msg)
+	case updatesCheckedMsg:
+		m.finishUpdateCheck(msg)
+	case updatesPulledMsg:
+		m.finishUpdatePulls(msg)
+	case idleUnloadMsg:
+		return m, m.finishIdleUnload(msg)
+	case thermalMsg:
+		return m, m.finishThermal(msg)
+	case scheduledMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Scheduled %s failed: %v", msg.name, msg.err)
+			m.logError(m.status)
+		} else {
+			m.status = "Scheduled " + msg.name + " done"
+		}
+		return m, tea.Batch(m.refresh(), m.notify("schedule", time.Time{}, "%s", m.status))
+	case loadEnvMsg:
+		if m.loadDialog != nil {
+			m.loadDialog.setEnv(msg)
+		}
+	case placedMsg:
+		return m, m.finishPlace(msg)
+	case envSavedMsg:
+		if m.settings != nil {
+			return m, m.finishSaveEnv(msg)
+		}
+	case archMsg:
+		m.arch[msg.key] = msg.arch
+		m.syncTable()
+	case pullQueueMsg:
+		return m, m.finishPulls()
+	case webhookFailedMsg:
+		m.logError("Webhook failed: " + msg.err.Error())
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.syncTable()
+		m.details.Width = msg.Width
+		m.details.Height = max(msg.Height-4, 5)
+		if m.chat != nil {
+			m.chat.resize(msg.Width, msg.Height)
+		}
+		if m.compare != nil {
+			m.compare.resize(msg.Width, msg.Height)
+		}
+		if m.modelfile != nil {
+			m.modelfile.resize(msg.Width, msg.Height)
+		}
+		if m.openai != nil {
+			m.openai.resize(msg.Width, msg.Height)
+		}
+		if m.template != nil {
+			m.template.resize(msg.Width, msg.Height)
+		}
+		m.logs.view.Width = msg.Width
+		m.logs.view.Height = max(msg.Height-4-tabBarLines, 5)
+		if m.mode == modeLog {
+			m.syncLog()
+		}
+	case chatChunkMsg:
+		if m.chat != nil && m.chat.streaming() {
+			m.chat.partial.WriteString(string(msg))
+			m.chat.render()
+			return m, listen(m.chat.updates)
+		}
+	case compareChunkMsg:
+		msg.pane.add(msg.text)
+		return m, listen(msg.pane.updates)
+	case compareDoneMsg:
+		m.busy = max(m.busy-1, 0)
+		msg.pane.finish(msg)
+	case chatDoneMsg:
+		if m.chat != nil {
+			m.chat.finish(msg)
+			m.saveChat()
+		}
+	case embedMsg:
+		m.finishEmbed(msg.model, func(s *embedState) { s.finishCompare(msg) })
+	case embedStepMsg:
+		if s := m.embed; s != nil && s.updates != nil {
+			s.step = string(msg)
+			return m, listen(s.updates)
+		}
+	case embedBenchMsg:
+		m.finishEmbed(msg.model, func(s *embedState) { s.finishBenchmark(msg) })
+	case templateMsg:
+		if s := m.template; s != nil && s.model == msg.model {
+			s.info, s.err = msg.info, msg.err
+			s.render()
+		}
+	case openaiLineMsg:
+		if s := m.openai; s != nil && s.sending() {
+			s.add(string(msg))
+			s.render()
+			return m, listen(s.updates)
+		}
+	case openaiDoneMsg:
+		if m.openai != nil {
+			m.openai.done(msg)
+		}
+	case showMsg:
+		if m.mode != modeDetails || msg.name != m.detailsName {
+			break
+		}
+		if msg.err != nil {
+			m.details.SetContent(errorStyle.Render(fmt.Sprintf("Could not load details: %v", msg.err)))
+			break
+		}
+		m.details.SetContent(renderDetails(msg.info, m.notes[msg.name]))
+	case modelfileMsg:
+		if s := m.modelfile; s != nil && s.loading && s.source == msg.source {
+			s.loading = false
+			if msg.err != nil {
+				s.status = fmt.Sprintf("Could not load %s: %v", msg.source, msg.err)
+				break
+			}
+			s.editor.SetValue(msg.text)
+		}
+	case createProgressMsg:
+		if s := m.modelfile; s != nil && s.creating {
+			s.status = string(msg)
+			return m, listen(s.updates)
+		}
+	case createDoneMsg:
+		if m.modelfile != nil {
+			return m, m.finishCreate(msg)
+		}
+	case browseResultsMsg:
+		if b := m.browse; b != nil {
+			b.loading, b.err, b.results, b.cursor = false, msg.err, msg.models, 0
+		}
+	case hfResultsMsg:
+		if s := m.hf; s != nil {
+			s.loading, s.err, s.results, s.cursor = false, msg.err, msg.repos, 0
+		}
+	case hfQuantsMsg:
+		m.finishHFQuants(msg)
+	case activityMsg:
+		if m.activity != nil {
+			m.finishActivity(msg)
+		}
+	case trafficMsg:
+		m.finishTraffic(msg)
+	case abortedMsg:
+		return m, m.finishAbort(msg)
+	case browseTagsMsg:
+		if b := m.browse; b != nil && b.model == msg.name {
+			b.loading, b.err, b.tags = false, msg.err, msg.tags
+		}
+	case diskMsg:
+		if d := m.disk; d != nil {
+			d.loading, d.usage, d.err = false, msg.usage, msg.err
+			if d.usage != nil {
+				d.cursor = min(d.cursor, max(len(d.usage.Models)-1, 0))
+			}
+		}
+	case hostCopyProgressMsg:
+		if s := m.hostCopy; s != nil {
+			m.status = fmt.Sprintf("Copying %s to %s: %s", s.model, s.host, progressText(ollama.PullProgress(msg)))
+			return m, listen(s.updates)
+		}
+	case hostCopyDoneMsg:
+		return m, m.finishCopyToHost(msg)
+	case moveStepMsg:
+		if s := m.moveStore; s != nil && s.running {
+			s.step = string(msg)
+			return m, listen(s.updates)
+		}
+	case moveProgressMsg:
+		if s := m.moveStore; s != nil && s.running {
+			s.done, s.total = msg.done, msg.total
+			return m, listen(s.updates)
+		}
+	case moveDoneMsg:
+		if m.moveStore != nil {
+			return m, m.finishMoveStore(msg)
+		}
+	case verifyProgressMsg:
+		if d := m.disk; d != nil && d.verify != nil && d.verify.running {
+			d.verify.done, d.verify.total = msg.done, msg.total
+			return m, listen(d.verify.updates)
+		}
+	case verifyDoneMsg:
+		if d := m.disk; d != nil && d.verify != nil {
+			return m, m.finishVerify(msg)
+		}
+	case pullableMsg:
+		if m.disk != nil {
+			m.finishPullable(msg)
+		}
+	case repairMsg:
+		if m.disk != nil {
+			return m, m.finishRepair(msg)
+		}
+	case pruneMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Cleanup freed %s with errors: %v", formatBytes(uint64(msg.freed)), msg.err)
+			m.logError(m.status)
+		} else {
+			m.status = "Cleanup freed " + formatBytes(uint64(msg.freed))
+		}
+		if m.disk != nil {
+			return m, m.scanDisk()
+		}
+	case opDoneMsg:
+		return m, m.finishOp(msg)
+	case batchDoneMsg:
+		return m, m.finishBatch(msg)
+	case benchStepMsg:
+		if m.bench != nil {
+			m.bench.step = string(msg)
+			return m, listen(m.bench.updates)
+		}
+	case benchDoneMsg:
+		if m.bench != nil {
+			return m, m.finishBench(msg)
+		}
+	case sweepDoneMsg:
+		if m.bench != nil {
+			return m, m.finishSweep(msg)
+		}
+	case parallelDoneMsg:
+		if m.bench != nil {
+			return m, m.finishParallel(msg)
+		}
+	case kvTestDoneMsg:
+		if m.bench != nil {
+			return m, m.finishKVTest(msg)
+		}
+	case suiteStepMsg:
+		if m.suites != nil && m.suites.run != nil {
+			m.suites.run.step = string(msg)
+			return m, listen(m.suites.run.updates)
+		}
+	case suiteDoneMsg:
+		if m.suites != nil && m.suites.run != nil {
+			return m, m.finishSuite(msg)
+		}
+	case spinner.TickMsg:
+		if m.spinning() {
+			var cmd tea.Cmd
+			m.spinner, cmd = m.spinner.Update(msg)
+			if len(m.loading) > 0 {
+				m.syncTable()
+			}
+			return m, cmd
+		}
+	case tea.FocusMsg:
+		m.focused = true
+	case tea.BlurMsg:
+		m.focused = false
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "q", "ctrl+c":
+		if t, ok := m.tabKey(msg); ok {
+			return m.switchTab(t)
+		}
+		switch m.mode {
+		case modePullInput:
+			return m.updatePullInput(msg)
+		case modeConfirmDelete:
+			return m.updateDeleteConfirm(msg)
+		case modeConfirmRestore:
+			return m.updateRestoreConfirm(msg)
+		case modeSnippet:
+			return m.updateSnippet(msg)
+		case modeDetails:
+			return m.updateDetails(msg)
+		case modeChat:
+			return m.updateChat(msg)
+		case modeKeepAliveInput:
+			return m.updateKeepAliveInput(msg)
+		case modeFilter:
+			return m.updateFilter(msg)
+		case modeBench:
+			return m.updateBench(msg)
+		case modeSuites:
+			return m.updateSuites(msg)
+		case modeHosts:
+			return m.updateHosts(msg)
+		case modeHelp:
+			return m.updateHelp(msg)
+		case modeCopyInput:
+			return m.updateCopyInput(msg)
+		case modeModelfile:
+			return m.updateModelfile(msg)
+		case modeOpenAI:
+			return m.updateOpenAI(msg)
+		case modeEmbed:
+			return m.updateEmbed(msg)
+		case modeTemplate:
+			return m.updateTemplate(msg)
+		case modeCompare:
+			return m.updateCompare(msg)
+		case modeSessions:
+			return m.updateSessions(msg)
+		case modeBrowse:
+			return m.updateBrowse(msg)
+		case modeHF:
+			return m.updateHF(msg)
+		case modeActivity:
+			return m.updateActivity(msg)
+		case modeTraffic:
+			return m.updateTraffic(msg)
+		case modeDisk:
+			return m.updateDisk(msg)
+		case modeMoveStore:
+			return m.updateMoveStore(msg)
+		case modeLeaderboard:
+			return m.updateLeaderboard(msg)
+		case modeLoadOptions:
+			return m.updateLoadDialog(msg)
+		case modeGenOptions:
+			return m.updateGenOptions(msg)
+		case modeServer:
+			return m.updateServer(msg)
+		case modeSettings:
+			return m.updateSettings(msg)
+		case modeProcs:
+			return m.updateProcs(msg)
+		case modeLog:
+			return m.updateLogView(msg)
+		case modeAliasInput:
+			return m.updateAliasInput(msg)
+		case modeNoteInput:
+			return m.updateNoteInput(msg)
+		case modeImportInput:
+			return m.updateImportInput(msg)
+		case modeWarmup:
+			return m.updateWarmups(msg)
+		case modeUpdates:
+			return m.updateUpdates(msg)
+		case modePullQueue:
+			return m.updatePullQueue(msg)
+		}
+		if msg.String() == "esc" {
+			// Clear the filter first, then the selection.
+			if m.filtering() {
+				m.filter.Reset()
+				m.relist()
+				break
+			}
+			m.selected = make(map[string]bool)
+			m.syncTable()
+			break
+		}
+		k := m.keys
+		switch {
+		case m.down && key.Matches(msg, k.Run):
+			return m.startServer()
+		case m.down && msg.String() != "ctrl+c" &&
+			!key.Matches(msg, k.Refresh, k.Hosts, k.Server, k.Errors, k.Logs, k.Theme, k.Help, k.Quit):
+			// The greyed list is only to look at until the server is back.
+			return m, nil
+		case msg.String() == "ctrl+c", key.Matches(msg, k.Quit):
+			m.pulls.CancelAll()
+			if m.chat != nil {
+				m.chat.stop()
+			}
+			if m.compare != nil {
+				m.compare.stop()
+			}
+			if m.embed != nil {
+				m.embed.stop()
+			}
+			if m.openai != nil {
+				m.openai.stop()
+			}
+			if m.bench != nil {
+				m.bench.cancel()
+			}
+			if m.suites != nil && m.suites.run != nil {
+				m.suites.run.cancel()
+			}
+			if m.modelfile != nil && m.modelfile.creating {
+				m.modelfile.cancel()
+			}
 			m.quiting = true
 			return m, tea.Quit
-		case "up", "k":
-			if m.cursor > 0 {
-				m.cursor--
-			}
-		case "down", "j":
-			if m.cursor < len(m.models)-1 {
-				m.cursor++
-			}
-		case "r", "enter":
-			if len(m.models) > 0 {
-				name := m.models[m.cursor]
-				m.status = fmt.Sprintf("Loading %s...", name)
-				go exec.Command("ollama", "run", name).Start()
-				m.loaded[name] = true
-				m.status = fmt.Sprintf("Started %s", name)
-			}
-		case "s":
-			if len(m.models) > 0 {
-				name := m.models[m.cursor]
-				m.status = fmt.Sprintf("Stopping %s...", name)
-				exec.Command("ollama", "stop", name).Run()
-				delete(m.loaded, name)
-				m.status = fmt.Sprintf("Stopped %s", name)
-			}
-		case "u":
-			m.status = "Unloading all models..."
+		case key.Matches(msg, k.Up):
+			m.moveCursor(-1)
+		case key.Matches(msg, k.Down):
+			m.moveCursor(1)
+		case key.Matches(msg, k.PageUp):
+			m.moveCursor(-m.listRows())
+		case key.Matches(msg, k.PageDown):
+			m.moveCursor(m.listRows())
+		case key.Matches(msg, k.Top):
+			m.setCursor(0)
+		case key.Matches(msg, k.Bottom):
+			m.setCursor(max(len(m.rows)-1, 0))
+		case key.Matches(msg, k.Sort):
+			m.cycleSort()
+		case key.Matches(msg, k.Reverse):
+			m.sortReverse = !m.sortReverse
+			m.relist()
+			m.status = "Sorted by " + m.sortHint()
+		case key.Matches(msg, k.Details):
+			if f, ok := m.currentFamily(); ok {
+				m.toggleFamily(f)
+			} else if cur, ok := m.current(); ok {
+				return m.openDetails(cur.Name)
+			}
+		case key.Matches(msg, k.Select):
+			if f, ok := m.currentFamily(); ok {
+				m.selectFamily(f)
+				m.moveCursor(1)
+			} else if cur, ok := m.current(); ok {
+				if m.selected[cur.Name] {
+					delete(m.selected, cur.Name)
+				} else {
+					m.selected[cur.Name] = true
+				}
+				m.syncTable()
+				m.moveCursor(1)
+			}
+		case key.Matches(msg, k.Filter):
+			return m.openFilter()
+		case key.Matches(msg, k.Run):
+			return m, m.loadTargets()
+		case key.Matches(msg, k.LoadWith):
+			if cur, ok := m.current(); ok {
+				return m.openLoadDialog(cur)
+			}
+		case key.Matches(msg, k.GenOptions):
+			if cur, ok := m.current(); ok {
+				return m.openGenOptions(cur.Name)
+			}
+		case key.Matches(msg, k.Stop):
+			return m, m.stopTargets()
+		case key.Matches(msg, k.UnloadAll):
+			var loaded []string
 			for name := range m.loaded {
-				exec.Command("ollama", "stop", name).Run()
+				loaded = append(loaded, name)
+			}
+			if len(loaded) == 0 {
+				m.status = "No models loaded"
+				break
+			}
+			return m, m.runBatchOp("Unloading", "Unloaded", loaded, func(name string) error {
+				return stopModel(m.client, name)
+			})
+		case key.Matches(msg, k.Refresh):
+			m.status = "Refreshing..."
+			return m, m.refresh()
+		case key.Matches(msg, k.Errors):
+			m.showErrors = !m.showErrors
+		case key.Matches(msg, k.Logs):
+			return m.openLogView()
+		case key.Matches(msg, k.Theme):
+			m.cycleTheme()
+		case key.Matches(msg, k.Pull):
+			return m.openPullInput()
+		case key.Matches(msg, k.PullQueue):
+			return m.openPullQueue()
+		case key.Matches(msg, k.Extend):
+			return m, m.extendTargets()
+		case key.Matches(msg, k.KeepAlive):
+			if cur, ok := m.current(); ok {
+				return m.openKeepAliveInput(cur.Name)
+			}
+		case key.Matches(msg, k.Warmup):
+			return m.openWarmups()
+		case key.Matches(msg, k.Updates):
+			return m.openUpdates()
+		case key.Matches(msg, k.Favorite):
+			if cur, ok := m.current(); ok {
+				m.toggleFavorite(cur.Name)
+			}
+		case key.Matches(msg, k.Alias):
+			if cur, ok := m.current(); ok {
+				return m.openAliasInput(cur.Name)
+			}
+		case key.Matches(msg, k.Note):
+			if cur, ok := m.current(); ok {
+				return m.openNoteInput(cur.Name)
+			}
+		case key.Matches(msg, k.Hide):
+			if cur, ok := m.current(); ok {
+				m.toggleHidden(cur.Name)
+			}
+		case key.Matches(msg, k.Archive):
+			if cur, ok := m.current(); ok {
+				return m, m.toggleArchive(cur.Name)
+			}
+		case key.Matches(msg, k.Group):
+			m.toggleGrouping()
+		case key.Matches(msg, k.Unused):
+			m.toggleUnused()
+		case key.Matches(msg, k.Usage):
+			return m.openLeaderboard()
+		case key.Matches(msg, k.ShowHidden):
+			m.showHidden = !m.showHidden
+			m.relist()
+			if m.showHidden {
+				m.status = "Showing hidden models"
+			} else {
+				m.status = "Hiding hidden models"
+			}
+		case key.Matches(msg, k.Chat):
+			if cur, ok := m.current(); ok {
+				if m.arch[archKey(cur)].Embedding {
+					return m.openEmbed(cur.Name)
+				}
+				return m.openChat(cur.Name)
+			}
+		case key.Matches(msg, k.Compare):
+			return m.openCompare()
+		case key.Matches(msg, k.OpenAI):
+			if cur, ok := m.current(); ok {
+				return m.openOpenAI(cur.Name)
+			}
+		case key.Matches(msg, k.Bench):
+			if cur, ok := m.current(); ok {
+				return m.openBench(cur.Name)
+			}
+		case key.Matches(msg, k.BenchHistory):
+			m.mode = modeBench
+		case key.Matches(msg, k.Sweep):
+			if cur, ok := m.current(); ok {
+				return m.openSweep(cur.Name)
+			}
+		case key.Matches(msg, k.Parallel):
+			if cur, ok := m.current(); ok {
+				return m.openParallel(cur.Name)
+			}
+		case key.Matches(msg, k.KVTest):
+			if cur, ok := m.current(); ok {
+				return m.openKVTest(cur.Name)
+			}
+		case key.Matches(msg, k.Suites):
+			return m.openSuites()
+		case key.Matches(msg, k.Hosts):
+			return m.openHosts()
+		case key.Matches(msg, k.Server):
+			return m.openServer()
+		case key.Matches(msg, k.Settings):
+			return m.openSettings()
+		case key.Matches(msg, k.Processes):
+			return m.openProcs()
+		case key.Matches(msg, k.Restart):
+			if m.server.status == nil {
+				m.status = "No Ollama service to restart"
+				break
+			}
+			m.mode = modeServer
+			m.server.confirm = service.Restart
+		case key.Matches(msg, k.Help):
+			m.mode = modeHelp
+		case key.Matches(msg, k.Copy):
+			if cur, ok := m.current(); ok {
+				return m.openCopyInput(cur.Name)
+			}
+		case key.Matches(msg, k.CopyName):
+			if cur, ok := m.current(); ok {
+				m.copyText(cur.Name, cur.Name)
+			}
+		case key.Matches(msg, k.Snippet):
+			if cur, ok := m.current(); ok {
+				return m.openSnippet(cur.Name)
+			}
+		case key.Matches(msg, k.CopyToHost):
+			if cur, ok := m.current(); ok {
+				return m.openCopyToHost(cur.Name)
+			}
+		case key.Matches(msg, k.Cancel):
+			if m.hostCopy != nil {
+				m.hostCopy.cancel()
+			}
+		case key.Matches(msg, k.Disk):
+			return m.openDisk()
+		case key.Matches(msg, k.Browse):
+			return m.openBrowse()
+		case key.Matches(msg, k.HuggingFace):
+			return m.openHF()
+		case key.Matches(msg, k.Modelfile):
+			if cur, ok := m.current(); ok {
+				return m.openModelfile(cur.Name)
+			}
+		case key.Matches(msg, k.ImportGGUF):
+			return m.openImportInput()
+		case key.Matches(msg, k.Delete):
+			if targets := m.targets(); len(targets) > 0 {
+				m.confirm = newDeleteConfirm(targets)
+				m.mode = modeConfirmDelete
 			}
-			m.loaded = make(map[string]bool)
-			m.status = "All models unloaded"
-		case "R":
-			m.models = getModels()
-			m.loaded = getLoaded()
-			m.status = "Refreshed"
+		}
+	default:
+		switch m.mode {
+		case modePullInput, modeKeepAliveInput, modeCopyInput, modeAliasInput, modeNoteInput, modeImportInput:
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		case modeFilter:
+			var cmd tea.Cmd
+			m.filter, cmd = m.filter.Update(msg)
+			return m, cmd
+		case modeChat:
+			var cmd tea.Cmd
+			m.chat.input, cmd = m.chat.input.Update(msg)
+			return m, cmd
+		case modeModelfile:
+			var cmd tea.Cmd
+			m.modelfile.editor, cmd = m.modelfile.editor.Update(msg)
+			return m, cmd
+		case modeOpenAI:
+			var cmd tea.Cmd
+			m.openai.editor, cmd = m.openai.editor.Update(msg)
+			return m, cmd
+		case modeTemplate:
+			var cmd tea.Cmd
+			m.template.system, cmd = m.template.system.Update(msg)
+			return m, cmd
+		case modeCompare:
+			var cmd tea.Cmd
+			m.compare.input, cmd = m.compare.input.Update(msg)
+			return m, cmd
+		case modeEmbed:
+			var cmd tea.Cmd
+			s := m.embed
+			s.texts[s.focus], cmd = s.texts[s.focus].Update(msg)
+			return m, cmd
+		case modeBrowse:
+			var cmd tea.Cmd
+			m.browse.query, cmd = m.browse.query.Update(msg)
+			return m, cmd
+		case modeHF:
+			var cmd tea.Cmd
+			m.hf.query, cmd = m.hf.query.Update(msg)
+			return m, cmd
+		case modeTraffic:
+			if t := m.traffic; t.aborting {
+				var cmd tea.Cmd
+				t.abort, cmd = t.abort.Update(msg)
+				return m, cmd
+			}
+			if s := &m.traffic.keys; s.editing != "" {
+				var cmd tea.Cmd
+				s.input, cmd = s.input.Update(msg)
+				return m, cmd
+			}
+		case modeSettings:
+			if m.settings.editing {
+				var cmd tea.Cmd
+				m.settings.input, cmd = m.settings.input.Update(msg)
+				return m, cmd
+			}
+		case modeLoadOptions:
+			var cmd tea.Cmd
+			if in := m.loadDialog.input(); in != nil {
+				*in, cmd = in.Update(msg)
+			}
+			return m, cmd
+		case modeGenOptions:
+			d := m.genDialog
+			var cmd tea.Cmd
+			d.fields[d.focus], cmd = d.fields[d.focus].Update(msg)
+			return m, cmd
 		}
 	}
 	return m, nil
@@ -126,40 +1234,374 @@ func (m model) View() string {
 	if m.quiting {
 		return ""
 	}
+	if _, ok := m.currentTab(); ok {
+		return m.tabBar() + "\n" + m.screenView()
+	}
+	return m.screenView()
+}
+
+func (m model) screenView() string {
+	switch m.mode {
+	case modeDetails:
+		return m.detailsView()
+	case modeChat:
+		return m.chatView()
+	case modeBench:
+		return m.benchView()
+	case modeSuites:
+		return m.suitesView()
+	case modeHosts:
+		return m.hostsView()
+	case modeHelp:
+		return m.helpView()
+	case modeModelfile:
+		return m.modelfileView()
+	case modeOpenAI:
+		return m.openaiView()
+	case modeEmbed:
+		return m.embedView()
+	case modeTemplate:
+		return m.templateView()
+	case modeCompare:
+		return m.compareView()
+	case modeSessions:
+		return m.sessionsView()
+	case modeBrowse:
+		return m.browseView()
+	case modeHF:
+		return m.hfView()
+	case modeActivity:
+		return m.activityView()
+	case modeTraffic:
+		return m.trafficView()
+	case modeDisk:
+		return m.diskView()
+	case modeMoveStore:
+		return m.moveStoreView()
+	case modeLeaderboard:
+		return m.leaderboardView()
+	case modeServer:
+		return m.serverView()
+	case modeSettings:
+		return m.settingsView()
+	case modeProcs:
+		return m.procsView()
+	case modeLog:
+		return m.logView()
+	case modeWarmup:
+		return m.warmupsView()
+	case modeUpdates:
+		return m.updatesView()
+	case modePullQueue:
+		return m.pullQueueView()
+	}
 
 	var b strings.Builder
 
 	b.WriteString(titleStyle.Render("Ollama Model Manager"))
-	b.WriteString("\n\n")
+	if n := m.hiddenCount(); n > 0 && !m.showHidden {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("  %d hidden", n)))
+	}
+	if m.onlyUnused {
+		b.WriteString(warnStyle.Render(fmt.Sprintf("  unused for %d days", unusedDays(m.cfg))))
+	}
+	b.WriteString("\n")
+	if m.mode == modeFilter || m.filtering() {
+		b.WriteString(m.filter.View())
+		b.WriteString(helpStyle.Render(fmt.Sprintf("  %d/%d", len(m.visible), len(m.models))))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
 
-	if len(m.models) == 0 {
+	switch {
+	case m.down:
+		b.WriteString(m.downView())
+	case len(m.models) == 0:
 		b.WriteString("  No models found. Run 'ollama pull <model>' first.\n")
-	} else {
-		for i, name := range m.models {
-			cursor := "  "
-			if i == m.cursor {
-				cursor = cursorStyle.Render("> ")
-			}
+	case len(m.visible) == 0 && !m.filtering():
+		b.WriteString(fmt.Sprintf("  All models are hidden. Press %s to show them.\n", m.keys.ShowHidden.Help().Key))
+	case len(m.visible) == 0:
+		b.WriteString("  No models match the filter.\n")
+	default:
+		b.WriteString(m.tableView())
+		b.WriteString("\n")
+	}
+	b.WriteString(m.listFooter())
 
-			status := ""
-			if m.loaded[name] {
-				status = loadedStyle.Render(" [LOADED]")
-			}
+	return b.String()
+}
+
+// listFooter is everything below the model table. listRows measures it to
+// give the table the rest of the screen.
+func (m model) listFooter() string {
+	var b strings.Builder
+	b.WriteString(m.offloadView())
 
-			b.WriteString(fmt.Sprintf("%s%s%s\n", cursor, name, status))
+	if pulls := m.pullsView(); pulls != "" {
+		b.WriteString("\n")
+		b.WriteString(pulls)
+	}
+	if m.mode == modeConfirmDelete {
+		b.WriteString("\n")
+		b.WriteString(m.confirm.view())
+		b.WriteString("\n")
+	}
+	if m.mode == modeConfirmRestore {
+		b.WriteString("\n")
+		b.WriteString(m.restoreView())
+		b.WriteString("\n")
+	}
+	if m.mode == modeSnippet {
+		b.WriteString("\n")
+		b.WriteString(m.snippetView())
+		b.WriteString("\n")
+	}
+	if m.mode == modeLoadOptions {
+		b.WriteString("\n")
+		b.WriteString(m.loadDialogView())
+		b.WriteString("\n")
+	}
+	if m.mode == modeGenOptions {
+		b.WriteString("\n")
+		b.WriteString(m.genOptionsView())
+		b.WriteString("\n")
+	}
+	if m.mode == modePullInput || m.mode == modeKeepAliveInput || m.mode == modeCopyInput || m.mode == modeAliasInput ||
+		m.mode == modeNoteInput || m.mode == modeImportInput {
+		b.WriteString("\n")
+		b.WriteString(m.input.View())
+		b.WriteString("\n")
+		if advice := m.pullAdvice(m.input.Value()); m.mode == modePullInput && advice != "" {
+			b.WriteString(helpStyle.Render(advice))
+			b.WriteString("\n")
 		}
+		b.WriteString(helpStyle.Render("Enter: Confirm  Esc: Cancel"))
+		b.WriteString("\n")
 	}
 
 	b.WriteString("\n")
-	b.WriteString(helpStyle.Render("r/Enter: Run  s: Stop  u: Unload All  R: Refresh  q: Quit"))
+	if m.down {
+		b.WriteString(helpStyle.Render(helpLine(m.downHelp()...)))
+	} else {
+		b.WriteString(helpStyle.Render(helpLine(m.keys.listHelp()...)))
+	}
+	b.WriteString("\n")
+	b.WriteString("\n")
+	b.WriteString(m.statusBar())
+	if m.showErrors {
+		b.WriteString("\n\n")
+		b.WriteString(m.errorLogView())
+	}
+
+	return b.String()
+}
+
+// gpuView renders one line per GPU with VRAM, utilization and temperature,
+// followed by charts of their recent history.
+func (m model) gpuView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("GPU"))
 	b.WriteString("\n")
-	b.WriteString(fmt.Sprintf("\nStatus: %s", m.status))
 
+	if m.gpuErr != nil {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("  Not available: %v", m.gpuErr)))
+		b.WriteString("\n")
+		return b.String()
+	}
+	if len(m.gpus) == 0 {
+		b.WriteString(helpStyle.Render("  No NVIDIA GPUs found"))
+		b.WriteString("\n")
+		return b.String()
+	}
+
+	for _, d := range m.gpus {
+		pct := 0.0
+		if d.MemoryTotal > 0 {
+			pct = float64(d.MemoryUsed) / float64(d.MemoryTotal)
+		}
+		bar := usageBar(pct, 20)
+		switch {
+		case pct >= 0.95:
+			bar = errorStyle.Render(bar)
+		case pct >= 0.8:
+			bar = warnStyle.Render(bar)
+		default:
+			bar = loadedStyle.Render(bar)
+		}
+		b.WriteString(fmt.Sprintf("  %d %-24s %s %s / %s  util %3d%%  %d°C%s\n",
+			d.Index, d.Name, bar, formatVRAM(d.MemoryUsed), formatVRAM(d.MemoryTotal),
+			d.Utilization, d.Temperature, formatPower(d.Power)))
+		b.WriteString(m.gpuCharts(d, m.width))
+	}
 	return b.String()
 }
 
+// usageBar draws a fixed-width bar filled to fraction pct.
+func usageBar(pct float64, width int) string {
+	filled := int(pct*float64(width) + 0.5)
+	if filled > width {
+		filled = width
+	}
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + "]"
+}
+
+// formatBytes renders a byte count the way `ollama list` does (decimal units).
+func formatBytes(n uint64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatVRAM renders GPU memory in GiB, matching how cards are marketed.
+func formatVRAM(n uint64) string {
+	return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+}
+
+// formatPower renders a GPU's power draw after its other readings, or
+// nothing when the card doesn't report one.
+func formatPower(w int) string {
+	if w == 0 {
+		return ""
+	}
+	return fmt.Sprintf("  %dW", w)
+}
+
 func main() {
-	p := tea.NewProgram(initialModel())
+	host := flag.String("host", "", "Ollama server URL (default $OLLAMA_HOST or "+ollama.DefaultHost+")")
+	profile := flag.String("profile", "", "host profile from the config file")
+	refreshEvery := flag.Duration("refresh", defaultRefresh, "auto-refresh interval (0 disables)")
+	flag.Usage = printUsage
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	hosts, active, err := hostProfiles(cfg, *host, *profile)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+	c, err := clientFor(hosts[active])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+	logs, closeLog, logErr := openLog()
+	defer closeLog()
+	slog.Info("start", "args", strings.Join(os.Args[1:], " "), "host", c.Host())
+
+	if flag.Arg(0) == "attach" {
+		if hosts, active, err = attachProfile(hosts, flag.Args()[1:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(2)
+		}
+		if c, err = clientFor(hosts[active]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	if cmd, ok := commands[flag.Arg(0)]; ok {
+		os.Exit(runCommand(c, cmd, flag.Args()[1:]))
+	}
+	if flag.NArg() == 0 && firstRun(cfg) && isTerminal(os.Stdin) {
+		if err := offerSetup(cfg, c.Host()); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		// The wizard may have changed the host.
+		hosts, active, _ = hostProfiles(cfg, *host, *profile)
+		if c, err = clientFor(hosts[active]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	keys, err := newKeyMap(cfg.Keys)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+	if err := cfg.CheckIgnore(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+	if err := checkNotify(cfg); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+	if err := checkWebhooks(cfg); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+	jobs, err := scheduleJobs(c, cfg)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+	idle, err := newIdleWatch(cfg.AutoUnload)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+	thermal, err := newThermalWatch(cfg.Thermal)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+	pulls, err := newPullQueue(cfg)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+	th, err := newTheme(cfg.Theme, cfg.Colors)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+	applyTheme(th)
+
+	m := initialModel(c, cfg, keys, hosts, active, *refreshEvery)
+	m.logs = logs
+	m.pulls = pulls
+	m.alerts = newAlerts(cfg)
+	if logErr != nil {
+		m.logError("Log file: " + logErr.Error())
+	}
+	if dc, st, err := attachDaemon(); err == nil {
+		slog.Info("daemon", "pid", st.PID)
+		m.localDaemon = dc
+		m.alerts.leaveToDaemon()
+		jobs = nil
+	} else {
+		m.idle, m.thermal = idle, thermal
+	}
+	m.daemonConn = m.daemonFor(hosts[active], c)
+	m.useGPUHistory()
+	// The alternate screen keeps the view at the top of the terminal, where
+	// mouse coordinates line up with it.
+	opts := []tea.ProgramOption{tea.WithAltScreen(), tea.WithReportFocus()}
+	if !cfg.DisableMouse {
+		opts = append(opts, tea.WithMouseCellMotion())
+	}
+	p := tea.NewProgram(m, opts...)
+	if len(jobs) > 0 {
+		// Scheduled actions keep acting on the starting host when another
+		// one is selected.
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go runSchedule(ctx, jobs, func(j schedule.Job, err error) {
+			p.Send(scheduledMsg{name: j.Name, err: err})
+		})
+	}
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"ollama-manager/internal/manifest"
+	"ollama-manager/internal/ollama"
+)
+
+func cmdExport(c *ollama.Client, args []string) error {
+	if len(args) != 1 {
+		return usageError{"usage: export <manifest.yaml|manifest.json|->"}
+	}
+	models, err := getModels(c)
+	if err != nil {
+		return err
+	}
+	m := manifest.Manifest{Version: manifest.Version, Host: c.Host(), Exported: time.Now()}
+	for _, mdl := range models {
+		m.Models = append(m.Models, manifest.Model{Name: mdl.Name, Digest: mdl.Digest, Size: mdl.Size})
+	}
+	if args[0] == "-" {
+		return manifest.Write(os.Stdout, m)
+	}
+	if err := manifest.Save(args[0], m); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d models to %s\n", len(m.Models), args[0])
+	return nil
+}
+
+func cmdImport(c *ollama.Client, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "list what would be pulled without pulling")
+	workers, limit := pullQueueFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	q, err := cliPullQueue(*workers, *limit)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError{"usage: import [--dry-run] [--concurrency N] [--limit 20MB/s] <manifest>"}
+	}
+	m, err := manifest.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if m.Version > manifest.Version {
+		return fmt.Errorf("%s is manifest version %d; this build reads up to %d", fs.Arg(0), m.Version, manifest.Version)
+	}
+	installed, err := getModels(c)
+	if err != nil {
+		return err
+	}
+
+	var missing []manifest.Model
+	var size int64
+	for _, want := range m.Models {
+		have, ok := findModel(installed, want.Name)
+		switch {
+		case !ok:
+			missing = append(missing, want)
+			size += want.Size
+		case want.Digest != "" && have.Digest != want.Digest:
+			fmt.Printf("%s is installed, but not the version exported\n", have.Name)
+		}
+	}
+	if len(missing) == 0 {
+		fmt.Printf("All %d models are installed\n", len(m.Models))
+		return nil
+	}
+	fmt.Printf("%d of %d models missing", len(missing), len(m.Models))
+	if size > 0 {
+		fmt.Printf(", %s to download", formatBytes(uint64(size)))
+	}
+	fmt.Println()
+	if *dryRun {
+		for _, mdl := range missing {
+			fmt.Printf("  %s\n", mdl.Name)
+		}
+		return nil
+	}
+
+	want := make(map[string]string, len(missing))
+	names := make([]string, len(missing))
+	for i, mdl := range missing {
+		want[mdl.Name], names[i] = mdl.Digest, mdl.Name
+	}
+	var failed []error
+	pulled := runPullQueue(q, c, names)
+	for _, it := range pulled {
+		if err := pullErr(it); err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", it.Name, err))
+		}
+	}
+	// Pulls fetch the registry's current version, which may have moved on
+	// since the export.
+	if now, err := getModels(c); err == nil {
+		for _, it := range pulled {
+			got, ok := findModel(now, it.Name)
+			if ok && want[it.Name] != "" && got.Digest != want[it.Name] {
+				fmt.Printf("%s: pulled a newer version than exported\n", it.Name)
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d pulls failed: %w", len(failed), len(missing), errors.Join(failed...))
+	}
+	return nil
+}
+
+// pullPrinting pulls name, showing progress on one line.
+func pullPrinting(c *ollama.Client, name string) error {
+	err := c.Pull(context.Background(), name, func(pr ollama.PullProgress) {
+		if pr.Total > 0 {
+			fmt.Printf("\r  %s %3.0f%% of %s   ", pr.Status, float64(pr.Completed)/float64(pr.Total)*100, formatBytes(uint64(pr.Total)))
+		} else {
+			fmt.Printf("\r  %-40s", pr.Status)
+		}
+	})
+	fmt.Println()
+	return err
+}
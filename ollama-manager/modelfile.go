@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/versions"
+)
+
+// versionsFile keeps the Modelfiles models were built from, under the data
+// dir.
+const versionsFile = "modelfiles.json"
+
+// modelfileState is the Modelfile editor for deriving a new model from an
+// installed one.
+type modelfileState struct {
+	source    string
+	name      textinput.Model
+	editor    textarea.Model
+	focusName bool
+	loading   bool
+
+	// creating is set while /api/create streams; status is its last update.
+	creating bool
+	started  time.Time
+	status   string
+	updates  chan tea.Msg
+	cancel   context.CancelFunc
+	// built is the Modelfile being built and note what to record with it.
+	built string
+	note  string
+
+	// versions lists the earlier builds of the model named, when open.
+	versions *versionsView
+}
+
+// versionsView lists the versions of a model's Modelfile, newest first.
+type versionsView struct {
+	model  string
+	list   []versions.Version
+	cursor int
+}
+
+// modelfileMsg carries the generated Modelfile of the source model.
+type modelfileMsg struct {
+	source string
+	text   string
+	err    error
+}
+
+// createProgressMsg is one status line streamed by /api/create.
+type createProgressMsg string
+
+// createDoneMsg is sent once the new model is built or the build failed.
+type createDoneMsg struct {
+	host string
+	name string
+	err  error
+}
+
+func newModelfileState(source string, width, height int) *modelfileState {
+	name := textinput.New()
+	name.Prompt = "New model: "
+	name.CharLimit = 256
+	name.Width = 40
+	name.SetValue(source + "-custom")
+
+	editor := textarea.New()
+	editor.ShowLineNumbers = true
+	editor.CharLimit = 0
+	editor.MaxHeight = 0
+
+	s := &modelfileState{source: source, name: name, editor: editor, loading: true}
+	s.resize(width, height)
+	return s
+}
+
+func (s *modelfileState) resize(width, height int) {
+	s.editor.SetWidth(max(width, 40))
+	s.editor.SetHeight(max(height-9, 5))
+}
+
+func fetchModelfile(c *ollama.Client, name string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		info, err := c.Show(ctx, name)
+		if err != nil {
+			return modelfileMsg{source: name, err: err}
+		}
+		return modelfileMsg{source: name, text: ollama.Modelfile(name, info)}
+	}
+}
+
+func openVersions() (*versions.Store, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	return versions.Open(filepath.Join(dir, versionsFile))
+}
+
+// openModelfile shows the editor for name. A build still in progress is
+// returned to instead of being replaced. A model built here before opens
+// with the Modelfile of its latest build, under its own name, so saving
+// rebuilds it.
+func (m model) openModelfile(name string) (tea.Model, tea.Cmd) {
+	m.mode = modeModelfile
+	if m.modelfile != nil && m.modelfile.creating {
+		return m, nil
+	}
+	m.modelfile = newModelfileState(name, m.width, m.height)
+	if v, ok := m.modelfiles.Latest(m.client.Host(), name); ok {
+		s := m.modelfile
+		s.loading = false
+		s.name.SetValue(name)
+		s.editor.SetValue(v.Text)
+		s.status = fmt.Sprintf("Version %d, from %s. %s rebuilds %s; %s lists the earlier ones.",
+			v.Number, v.Created.Format("2006-01-02 15:04"), m.keys.Save.Help().Key, name, m.keys.Versions.Help().Key)
+		return m, s.editor.Focus()
+	}
+	return m, tea.Batch(fetchModelfile(m.client, name), m.modelfile.editor.Focus())
+}
+
+// startCreate parses the edited Modelfile and streams the build.
+func (m model) startCreate() (tea.Model, tea.Cmd) {
+	s := m.modelfile
+	name := strings.TrimSpace(s.name.Value())
+	if name == "" {
+		s.status = "Enter a name for the new model"
+		return m, nil
+	}
+	host := m.client.Host()
+	if m.modelExists(name) && len(m.modelfiles.Versions(host, name)) == 0 {
+		s.status = name + " already exists; only models built here can be rebuilt"
+		return m, nil
+	}
+	req, err := ollama.ParseModelfile(s.editor.Value())
+	if err != nil {
+		s.status = "Modelfile: " + err.Error()
+		return m, nil
+	}
+	req.Model = name
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.creating = true
+	s.started = time.Now()
+	s.status = "starting"
+	s.updates = make(chan tea.Msg, 16)
+	s.cancel = cancel
+	s.built = s.editor.Value()
+	c := m.client
+	go func() {
+		defer close(s.updates)
+		err := c.Create(ctx, *req, func(p ollama.PullProgress) {
+			s.updates <- createProgressMsg(progressText(p))
+		})
+		s.updates <- createDoneMsg{host: host, name: name, err: err}
+	}()
+	m.status = "Creating " + name
+	return m, tea.Batch(listen(s.updates), m.startBusy())
+}
+
+func (m *model) finishCreate(msg createDoneMsg) tea.Cmd {
+	s := m.modelfile
+	s.creating = false
+	m.busy = max(m.busy-1, 0)
+	note := s.note
+	s.note = ""
+	switch {
+	case errors.Is(msg.err, context.Canceled):
+		s.status = "cancelled"
+		m.status = "Creation of " + msg.name + " cancelled"
+	case msg.err != nil:
+		s.status = msg.err.Error()
+		m.status = fmt.Sprintf("Creating %s failed: %v", msg.name, msg.err)
+		m.logError(m.status)
+		return m.notify("create", s.started, "%s", m.status)
+	default:
+		s.status = "created"
+		m.status = "Created " + msg.name
+		if err := m.modelfiles.Add(msg.host, msg.name, s.built, note); err != nil {
+			m.logError("Saving the Modelfile version: " + err.Error())
+		}
+		return tea.Batch(m.refresh(), m.notify("create", s.started, "%s", m.status))
+	}
+	return nil
+}
+
+func (m model) updateModelfile(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := m.modelfile
+	if s.versions != nil {
+		return m.updateVersions(msg)
+	}
+	switch {
+	case key.Matches(msg, m.keys.Save):
+		if s.creating || s.loading {
+			return m, nil
+		}
+		return m.startCreate()
+	case key.Matches(msg, m.keys.Versions):
+		if s.creating || s.loading {
+			return m, nil
+		}
+		m.openVersions()
+		return m, nil
+	case s.creating && key.Matches(msg, m.keys.Cancel):
+		s.cancel()
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		// A running build carries on in the background.
+		m.mode = modeList
+		return m, nil
+	case "tab":
+		s.focusName = !s.focusName
+		if s.focusName {
+			s.editor.Blur()
+			return m, s.name.Focus()
+		}
+		s.name.Blur()
+		return m, s.editor.Focus()
+	}
+	if s.creating {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	if s.focusName {
+		s.name, cmd = s.name.Update(msg)
+	} else {
+		s.editor, cmd = s.editor.Update(msg)
+	}
+	return m, cmd
+}
+
+func (m model) modelfileView() string {
+	s := m.modelfile
+	if s.versions != nil {
+		return m.versionsView()
+	}
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Modelfile"))
+	if ollama.IsGGUFPath(s.source) {
+		b.WriteString(helpStyle.Render("  from " + filepath.Base(s.source)))
+	} else {
+		b.WriteString(helpStyle.Render("  derived from " + s.source))
+	}
+	b.WriteString("\n\n")
+	b.WriteString(s.name.View())
+	b.WriteString("\n\n")
+	if s.loading {
+		b.WriteString(fmt.Sprintf("Loading %s...\n", s.source))
+	} else {
+		b.WriteString(s.editor.View())
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	switch {
+	case s.creating:
+		b.WriteString(fmt.Sprintf("%s %s\n", m.spinner.View(), s.status))
+		b.WriteString(helpStyle.Render(helpLine(m.keys.Cancel) + "  Esc: Back (keeps running)"))
+	default:
+		if s.status != "" {
+			b.WriteString(s.status + "\n")
+		}
+		b.WriteString(helpStyle.Render("Tab: Switch field  " + helpLine(m.keys.Save, m.keys.Versions) + "  Esc: Back"))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// modelExists reports whether name is installed, treating a missing tag as
+// ":latest" the way Ollama does.
+func (m model) modelExists(name string) bool {
+	for _, mdl := range m.models {
+		if mdl.Name == name || mdl.Name == name+":latest" {
+			return true
+		}
+	}
+	return false
+}
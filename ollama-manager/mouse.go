@@ -0,0 +1,172 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// doubleClick is the longest gap between two clicks on a row that
+	// still counts as a double-click.
+	doubleClick = 400 * time.Millisecond
+	// wheelRows is how far one notch of the scroll wheel moves the list.
+	wheelRows = 3
+	// checkWidth is the [ ] column plus its cell padding.
+	checkWidth = 5
+)
+
+// click remembers the last row clicked in the model list.
+type click struct {
+	name string
+	at   time.Time
+}
+
+func (m model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if _, ok := m.currentTab(); ok && msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft && m.viewLine(msg.Y) == 0 {
+		if t, ok := tabAt(msg.X); ok {
+			return m.switchTab(t)
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	switch m.mode {
+	case modeList:
+		return m.listMouse(msg)
+	case modeDetails:
+		m.details, cmd = m.details.Update(msg)
+	case modeLog:
+		m.logs.view, cmd = m.logs.view.Update(msg)
+	case modeChat:
+		m.chat.view, cmd = m.chat.view.Update(msg)
+	}
+	return m, cmd
+}
+
+// listMouse scrolls the list with the wheel, moves the cursor to a clicked
+// row (or toggles its selection when the checkbox is clicked), loads or
+// unloads a double-clicked model and treats the help bar as buttons.
+func (m model) listMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if msg.Action != tea.MouseActionPress {
+		return m, nil
+	}
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		m.scrollBy(-wheelRows)
+	case tea.MouseButtonWheelDown:
+		m.scrollBy(wheelRows)
+	case tea.MouseButtonLeft:
+		line := m.viewLine(msg.Y)
+		if i, ok := m.rowAt(line); ok {
+			return m.clickRow(i, msg.X)
+		}
+		if b, ok := m.helpButtonAt(line, msg.X); ok {
+			return m.Update(keyPress(b))
+		}
+	}
+	return m, nil
+}
+
+// viewLine converts a screen row into a line of View. The renderer drops
+// the top of a view taller than the terminal, shifting everything up.
+func (m model) viewLine(y int) int {
+	if m.height == 0 {
+		return y
+	}
+	lines := strings.Count(m.View(), "\n") + 1
+	return y + max(lines-m.height, 0)
+}
+
+// rowAt maps a view line to an index into m.rows.
+func (m model) rowAt(line int) (int, bool) {
+	first := m.listTitleLines() + tableHeaderLines
+	i := m.windowTop() + line - first
+	if line < first || line-first >= m.listRows() || i >= len(m.rows) {
+		return 0, false
+	}
+	return i, true
+}
+
+// scrollBy moves the list window n rows, dragging the cursor along when it
+// would leave the window.
+func (m *model) scrollBy(n int) {
+	rows := m.listRows()
+	m.listTop = max(min(m.windowTop()+n, len(m.rows)-rows), 0)
+	c := m.table.Cursor()
+	switch {
+	case c < m.listTop:
+		m.setCursor(m.listTop)
+	case c >= m.listTop+rows:
+		m.setCursor(m.listTop + rows - 1)
+	}
+}
+
+func (m model) clickRow(i, x int) (tea.Model, tea.Cmd) {
+	m.setCursor(i)
+	if f, ok := m.currentFamily(); ok {
+		if x < checkWidth {
+			m.selectFamily(f)
+		} else {
+			m.toggleFamily(f)
+		}
+		return m, nil
+	}
+	mdl := m.visible[m.rows[i].model]
+	if x < checkWidth {
+		if m.selected[mdl.Name] {
+			delete(m.selected, mdl.Name)
+		} else {
+			m.selected[mdl.Name] = true
+		}
+		m.syncTable()
+		return m, nil
+	}
+
+	if m.lastClick.name != mdl.Name || time.Since(m.lastClick.at) > doubleClick {
+		m.lastClick = click{name: mdl.Name, at: time.Now()}
+		return m, nil
+	}
+	m.lastClick = click{}
+	name := mdl.Name
+	if _, loading := m.loading[name]; loading {
+		return m, nil
+	}
+	if m.loaded[name] {
+		c := m.client
+		return m, m.runOp("Stopping "+name, "Stopped "+name, func() error {
+			return stopModel(c, name)
+		})
+	}
+	return m, m.startLoad("Loading "+name, "Loaded "+name, name, m.loadOptions(name))
+}
+
+// helpButtonAt finds the help bar entry under a click on the list screen.
+func (m model) helpButtonAt(line, x int) (key.Binding, bool) {
+	lines := strings.Split(m.View(), "\n")
+	bindings := m.keys.listHelp()
+	if line < 0 || line >= len(lines) || lines[line] != helpStyle.Render(helpLine(bindings...)) {
+		return key.Binding{}, false
+	}
+	pos := 0
+	for _, b := range bindings {
+		if !b.Enabled() {
+			continue
+		}
+		w := lipgloss.Width(b.Help().Key + ": " + b.Help().Desc)
+		if x >= pos && x < pos+w {
+			return b, true
+		}
+		pos += w + 2 // helpLine's separator
+	}
+	return key.Binding{}, false
+}
+
+// keyPress fakes pressing b's first key. key.Matches compares
+// KeyMsg.String(), which for runes is the runes themselves, so this works
+// for named keys such as "enter" too.
+func keyPress(b key.Binding) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(b.Keys()[0])}
+}
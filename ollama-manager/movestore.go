@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/service"
+	"ollama-manager/internal/store"
+)
+
+// moveState is the guided move of the models directory to another drive.
+type moveState struct {
+	src     string
+	size    int64 // of the blob store
+	models  []string
+	input   textinput.Model
+	dst     string
+	confirm bool // waiting for y to start
+	running bool
+	started time.Time
+	step    string
+	done    int64 // bytes copied
+	total   int64
+	err     error
+	result  string
+	updates chan tea.Msg
+	cancel  context.CancelFunc
+}
+
+// moveStepMsg starts a step of the move.
+type moveStepMsg string
+
+// moveProgressMsg reports the bytes copied so far.
+type moveProgressMsg struct{ done, total int64 }
+
+// moveDoneMsg ends the move; result says where the models are on success.
+type moveDoneMsg struct {
+	result string
+	err    error
+}
+
+// openMoveStore asks where to move the models directory the disk screen
+// scanned.
+func (m model) openMoveStore() (tea.Model, tea.Cmd) {
+	d := m.disk
+	if d.usage == nil || d.loading {
+		return m, nil
+	}
+	if m.moveStore != nil && m.moveStore.running {
+		m.mode = modeMoveStore
+		return m, nil
+	}
+	if _, _, ok := service.ContainerName(m.serviceName()); ok {
+		m.status = "The models live in the container's volume; change it in the container settings instead"
+		return m, nil
+	}
+	input := textinput.New()
+	input.Prompt = "Move to: "
+	input.Placeholder = `D:\ollama\models`
+	if runtime.GOOS != "windows" {
+		input.Placeholder = "/mnt/data/ollama/models"
+	}
+	input.CharLimit = 512
+	input.Width = 60
+	s := &moveState{src: d.usage.Dir, size: d.usage.Total, input: input}
+	for _, mu := range d.usage.Models {
+		s.models = append(s.models, mu.Name)
+	}
+	m.moveStore = s
+	m.mode = modeMoveStore
+	return m, s.input.Focus()
+}
+
+func (m model) updateMoveStore(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := m.moveStore
+	switch {
+	case s.running:
+		switch {
+		case key.Matches(msg, m.keys.Cancel):
+			s.cancel()
+		case msg.String() == "esc":
+			m.mode = modeDisk // the move carries on
+		}
+		return m, nil
+	case s.confirm:
+		s.confirm = false
+		if msg.String() != "y" {
+			m.status = "Move cancelled"
+			return m, s.input.Focus()
+		}
+		return m.startMoveStore()
+	case s.result != "" || s.err != nil:
+		m.moveStore = nil
+		m.mode = modeDisk
+		return m, m.scanDisk()
+	}
+
+	switch msg.String() {
+	case "esc":
+		m.moveStore = nil
+		m.mode = modeDisk
+		return m, nil
+	case "enter":
+		dst := strings.TrimSpace(s.input.Value())
+		if dst == "" {
+			return m, nil
+		}
+		if err := store.CheckMove(s.src, dst); err != nil {
+			m.status = err.Error()
+			return m, nil
+		}
+		s.dst = dst
+		s.confirm = true
+		s.input.Blur()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	s.input, cmd = s.input.Update(msg)
+	return m, cmd
+}
+
+// startMoveStore runs the move in the background: stop Ollama, move the
+// store, point OLLAMA_MODELS at it, start Ollama and check it lists every
+// model, then delete the old copy. A failure puts everything back as it
+// was.
+func (m model) startMoveStore() (tea.Model, tea.Cmd) {
+	s := m.moveStore
+	ctx, cancel := context.WithCancel(context.Background())
+	s.running, s.started, s.cancel = true, time.Now(), cancel
+	s.updates = make(chan tea.Msg, 1)
+	c, name, src, dst, models := m.client, m.serviceName(), s.src, s.dst, s.models
+	go func() {
+		defer close(s.updates)
+		step := func(text string) { s.updates <- moveStepMsg(text) }
+		result, err := moveStore(ctx, c, name, src, dst, models, step, func(done, total int64) {
+			select {
+			case s.updates <- moveProgressMsg{done, total}:
+			default:
+			}
+		})
+		s.updates <- moveDoneMsg{result: result, err: err}
+	}()
+	m.status = "Moving the models to " + dst
+	return m, tea.Batch(listen(s.updates), m.startBusy())
+}
+
+// moveStore does the steps of startMoveStore, reporting each to step.
+func moveStore(ctx context.Context, c *ollama.Client, name, src, dst string, models []string,
+	step func(string), progress func(done, total int64)) (string, error) {
+	background := context.Background()
+	control := func(a service.Action) error {
+		ctx, cancel := context.WithTimeout(background, serviceTimeout)
+		defer cancel()
+		if err := service.Control(ctx, name, a); err != nil {
+			return err
+		}
+		if a == service.Stop {
+			return nil
+		}
+		return waitForAPI(ctx, c)
+	}
+	env := func(value string) error {
+		ctx, cancel := context.WithTimeout(background, apiTimeout)
+		defer cancel()
+		return service.SetEnv(ctx, name, map[string]string{"OLLAMA_MODELS": value})
+	}
+	envCtx, cancel := context.WithTimeout(background, apiTimeout)
+	old, err := service.Env(envCtx, name)
+	cancel()
+	if err != nil {
+		return "", err
+	}
+
+	step("Stopping Ollama")
+	if err := control(service.Stop); err != nil {
+		return "", fmt.Errorf("stopping Ollama: %w", err)
+	}
+	step("Moving the models")
+	copied, err := store.Move(ctx, src, dst, progress)
+	if err != nil {
+		// Move leaves src as it was.
+		if serr := control(service.Start); serr != nil {
+			err = errors.Join(err, fmt.Errorf("starting Ollama again: %w", serr))
+		}
+		return "", fmt.Errorf("moving the models: %w", err)
+	}
+	// undo puts the store and setting back after a later step failed.
+	undo := func(cause error) error {
+		step("Putting the models back")
+		errs := []error{cause}
+		control(service.Stop)
+		if err := env(old["OLLAMA_MODELS"]); err != nil {
+			errs = append(errs, fmt.Errorf("restoring OLLAMA_MODELS: %w", err))
+		}
+		if copied {
+			store.RemoveStore(dst)
+		} else if err := os.Rename(dst, src); err != nil {
+			errs = append(errs, fmt.Errorf("moving the models back: %w", err))
+		}
+		if err := control(service.Start); err != nil {
+			errs = append(errs, fmt.Errorf("starting Ollama again: %w", err))
+		}
+		return errors.Join(errs...)
+	}
+	if copied && runtime.GOOS == "linux" {
+		// The service runs as its own user, which must own the copy.
+		out, err := exec.Command("chown", "-R", "--reference="+src, dst).CombinedOutput()
+		if err != nil {
+			return "", undo(fmt.Errorf("chown: %s", firstNonEmpty(strings.TrimSpace(string(out)), err.Error())))
+		}
+	}
+	step("Setting OLLAMA_MODELS")
+	if err := env(dst); err != nil {
+		return "", undo(fmt.Errorf("setting OLLAMA_MODELS: %w", err))
+	}
+	step("Starting Ollama")
+	if err := control(service.Start); err != nil {
+		return "", undo(fmt.Errorf("starting Ollama: %w", err))
+	}
+	step("Checking the models")
+	listed, err := getModels(c)
+	if err != nil {
+		return "", undo(fmt.Errorf("listing the models: %w", err))
+	}
+	if missing := missingModels(models, listed); len(missing) > 0 {
+		return "", undo(fmt.Errorf("Ollama doesn't list %s from the new location", strings.Join(missing, ", ")))
+	}
+	os.Setenv("OLLAMA_MODELS", dst) // for the disk screen
+	if copied {
+		step("Removing the old copy")
+		if err := store.RemoveStore(src); err != nil {
+			return fmt.Sprintf("Moved %d models to %s, but the old copy in %s is still there: %v", len(models), dst, src, err), nil
+		}
+	}
+	return fmt.Sprintf("Moved %d models to %s", len(models), dst), nil
+}
+
+// missingModels returns the names of want that listed lacks.
+func missingModels(want []string, listed []ollama.Model) []string {
+	have := make(map[string]bool, len(listed))
+	for _, mdl := range listed {
+		have[mdl.Name] = true
+	}
+	var missing []string
+	for _, name := range want {
+		if !have[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+func (m *model) finishMoveStore(msg moveDoneMsg) tea.Cmd {
+	s := m.moveStore
+	s.running = false
+	m.busy = max(m.busy-1, 0)
+	s.result, s.err = msg.result, msg.err
+	m.settings = nil // OLLAMA_MODELS changed under it
+	if msg.err != nil {
+		m.status = "Moving the models failed: " + msg.err.Error()
+		m.logError(m.status)
+		return m.refresh()
+	}
+	m.status = msg.result
+	return m.refresh()
+}
+
+func (m model) moveStoreView() string {
+	s := m.moveStore
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Move Models"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("From: %s (%s in %d models)\n", s.src, formatBytes(uint64(s.size)), len(s.models)))
+	switch {
+	case s.running || s.result != "" || s.err != nil:
+		b.WriteString(fmt.Sprintf("To:   %s\n\n", s.dst))
+	default:
+		b.WriteString(s.input.View())
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render("Pick a folder on a drive with room for the models; it must be empty or not exist yet.\n" +
+			"Ollama is stopped while the models move, OLLAMA_MODELS is pointed at the new folder and\n" +
+			"Ollama is started again. The old copy is deleted once Ollama lists every model from the new one."))
+		b.WriteString("\n")
+	}
+
+	switch {
+	case s.running:
+		line := fmt.Sprintf("%s %s", m.spinner.View(), s.step)
+		if s.step == "Moving the models" && s.total > 0 {
+			line += fmt.Sprintf(": %.0f%% of %s", float64(s.done)/float64(s.total)*100, formatBytes(uint64(s.total)))
+			if secs := time.Since(s.started).Seconds(); secs > 1 {
+				line += fmt.Sprintf(" at %s/s", formatBytes(uint64(float64(s.done)/secs)))
+			}
+		}
+		b.WriteString(line)
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render(helpLine(m.keys.Cancel) + "  Esc: Back (keeps running)"))
+	case s.err != nil:
+		b.WriteString(errorStyle.Render(s.err.Error()))
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render("Any key: Back"))
+	case s.result != "":
+		b.WriteString(loadedStyle.Render(s.result))
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render("Any key: Back"))
+	case s.confirm:
+		b.WriteString(modalStyle.Render(fmt.Sprintf(
+			"Stop Ollama and move %s of models to %s?\nRunning models are unloaded; the move can take a while across drives.\n\ny: Move  any other key: Cancel",
+			formatBytes(uint64(s.size)), s.dst)))
+	default:
+		b.WriteString(helpStyle.Render("Enter: Continue  Esc: Back"))
+	}
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("\nStatus: %s", m.status))
+	return b.String()
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+)
+
+// notesFile keeps what was written about each model, under the data dir.
+const notesFile = "notes.json"
+
+// modelNote is a free-form note on a model and its tags, without the "#".
+type modelNote struct {
+	Text string   `json:"text,omitempty"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+// parseNote reads the note input: words starting with "#" are tags, the
+// rest is the note.
+func parseNote(s string) modelNote {
+	var n modelNote
+	var words []string
+	for _, w := range strings.Fields(s) {
+		tag := strings.ToLower(strings.TrimLeft(w, "#"))
+		switch {
+		case !strings.HasPrefix(w, "#"):
+			words = append(words, w)
+		case tag != "" && !slices.Contains(n.Tags, tag):
+			n.Tags = append(n.Tags, tag)
+		}
+	}
+	n.Text = strings.Join(words, " ")
+	return n
+}
+
+// String is n as typed in the note input.
+func (n modelNote) String() string {
+	s := n.Text
+	for _, t := range n.Tags {
+		s += " #" + t
+	}
+	return strings.TrimSpace(s)
+}
+
+// tagList is the tags of n as shown, with their "#".
+func (n modelNote) tagList() string {
+	tags := make([]string, len(n.Tags))
+	for i, t := range n.Tags {
+		tags[i] = "#" + t
+	}
+	return strings.Join(tags, " ")
+}
+
+// readNotes returns the notes by model name; a missing file has none.
+func readNotes() (map[string]modelNote, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, notesFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]modelNote{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	notes := make(map[string]modelNote)
+	if err := json.Unmarshal(data, &notes); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return notes, nil
+}
+
+// writeNotes replaces the notes file with notes.
+func writeNotes(notes map[string]modelNote) error {
+	data, err := json.MarshalIndent(notes, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir, err := config.DataDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, notesFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// noteMatches reports whether the note on name matches a filter pattern:
+// "#tag" matches a tag starting with it, anything else the note's text.
+func (m model) noteMatches(name, pattern string) bool {
+	n, ok := m.notes[name]
+	if !ok || pattern == "" {
+		return false
+	}
+	pattern = strings.ToLower(pattern)
+	if tag, ok := strings.CutPrefix(pattern, "#"); ok {
+		return slices.ContainsFunc(n.Tags, func(t string) bool { return strings.HasPrefix(t, tag) })
+	}
+	return strings.Contains(strings.ToLower(n.Text), pattern)
+}
+
+// openNoteInput prompts for a model's note and tags, prefilled with the
+// current ones.
+func (m model) openNoteInput(name string) (tea.Model, tea.Cmd) {
+	m.mode = modeNoteInput
+	m.noteModel = name
+	m.input.Reset()
+	m.input.Prompt = fmt.Sprintf("Note on %s: ", name)
+	m.input.Placeholder = "text and #tags (empty = none)"
+	m.input.CharLimit = 500
+	m.input.Width = 60
+	m.input.SetValue(m.notes[name].String())
+	m.input.CursorEnd()
+	return m, m.input.Focus()
+}
+
+func (m model) updateNoteInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = modeList
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		name := m.noteModel
+		note := parseNote(m.input.Value())
+		m.mode = modeList
+		m.input.Blur()
+
+		notes := make(map[string]modelNote, len(m.notes)+1)
+		for k, v := range m.notes {
+			notes[k] = v
+		}
+		if note.String() == "" {
+			delete(notes, name)
+		} else {
+			notes[name] = note
+		}
+		if err := writeNotes(notes); err != nil {
+			m.status = fmt.Sprintf("Could not save notes: %v", err)
+			return m, nil
+		}
+		m.notes = notes
+		m.relist()
+		if note.String() == "" {
+			m.status = "Removed the note on " + name
+		} else {
+			m.status = "Saved the note on " + name
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
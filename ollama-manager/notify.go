@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/notify"
+)
+
+// notifyAfter is how long an operation has to run before its end is
+// announced; quicker ones finish while you're still watching.
+const notifyAfter = 30 * time.Second
+
+var (
+	notifyEvents  = []string{"pull", "bench", "create", "verify", "schedule", "auto_unload", "thermal", "watchdog"}
+	notifyMethods = []string{"desktop", "bell", "off"}
+)
+
+// checkNotify reports the first unknown event or method in the config.
+func checkNotify(cfg *config.Config) error {
+	for event, method := range cfg.Notify {
+		if !slices.Contains(notifyEvents, event) {
+			return fmt.Errorf("notify: unknown event %q (valid: %s)", event, strings.Join(notifyEvents, ", "))
+		}
+		if !slices.Contains(notifyMethods, method) {
+			return fmt.Errorf("notify: %s: unknown method %q (valid: %s)", event, method, strings.Join(notifyMethods, ", "))
+		}
+	}
+	return nil
+}
+
+// notify announces the end of an operation started at started, or of an
+// unattended one when started is zero. Nothing is sent while the terminal
+// has focus, if the terminal reports it, or for operations that were quick.
+// A desktop notification that can't be shown falls back to the bell.
+func (m model) notify(event string, started time.Time, format string, args ...any) tea.Cmd {
+	method := m.cfg.NotifyFor(event)
+	if method == "off" || m.focused || (!started.IsZero() && time.Since(started) < notifyAfter) {
+		return nil
+	}
+	body := fmt.Sprintf(format, args...)
+	return func() tea.Msg {
+		if method == "desktop" {
+			err := notify.Send("Ollama Manager", body)
+			if err == nil {
+				return nil
+			}
+			slog.Debug("notify", "err", err)
+		}
+		notify.Bell()
+		return nil
+	}
+}
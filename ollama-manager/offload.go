@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"ollama-manager/internal/ollama"
+)
+
+// processorText is the table's PROCESSOR cell for a loaded model.
+func (m model) processorText(name string) string {
+	r, ok := m.running[name]
+	if !ok {
+		return ""
+	}
+	return r.Processor()
+}
+
+// gpuLayers estimates how many layers of a partially offloaded model sit on
+// the GPU. Ollama offloads whole layers plus the output layer, so the VRAM
+// share maps roughly onto the layer count.
+func (m model) gpuLayers(r ollama.RunningModel) (onGPU, total int, ok bool) {
+	for _, mdl := range m.models {
+		if mdl.Name != r.Name {
+			continue
+		}
+		layers := m.arch[archKey(mdl)].Layers
+		if layers == 0 {
+			return 0, 0, false
+		}
+		total = layers + 1
+		return int(math.Round(r.GPUFraction() * float64(total))), total, true
+	}
+	return 0, 0, false
+}
+
+// offloadView warns about loaded models that spill onto the CPU, which makes
+// them several times slower.
+func (m model) offloadView() string {
+	var names []string
+	for name, r := range m.running {
+		if r.Offloaded() {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		r := m.running[name]
+		line := fmt.Sprintf("⚠ %s is partially on the CPU: %s", name, r.Processor())
+		if on, total, ok := m.gpuLayers(r); ok {
+			line += fmt.Sprintf(", ~%d/%d layers on GPU", on, total)
+		}
+		b.WriteString(warnStyle.Render(line))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
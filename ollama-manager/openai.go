@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/ollama"
+)
+
+// openaiState is the console for the server's OpenAI-compatible endpoint:
+// a raw chat.completions request, sent as typed, and the raw response.
+type openaiState struct {
+	model   string
+	editor  textarea.Model
+	view    viewport.Model
+	scroll  bool // keys scroll the response instead of editing the request
+	updates chan tea.Msg
+	cancel  context.CancelFunc
+	started time.Time
+	took    time.Duration
+
+	lines  []string // the response as received, line by line
+	reply  strings.Builder
+	finish string // finish_reason of the last choice
+	usage  string
+	status string
+	err    error
+}
+
+// openaiLineMsg is a line of the response: its JSON object, or an event of a
+// streamed one.
+type openaiLineMsg string
+
+// openaiDoneMsg ends a response.
+type openaiDoneMsg struct{ err error }
+
+// completion holds the parts of a chat.completions response or chunk the
+// console assembles.
+type completion struct {
+	Choices []struct {
+		Delta        struct{ Content string } `json:"delta"`
+		Message      struct{ Content string } `json:"message"`
+		FinishReason string                   `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// openaiRequest is the request the console starts with: streamed, with the
+// usage chunk that OpenAI only sends when asked, and the model's saved
+// options under their OpenAI names. num_ctx has none, so it is left out.
+func openaiRequest(name string, o config.GenOptions) string {
+	q, _ := json.Marshal(name)
+	var params strings.Builder
+	if o.Temperature != nil {
+		fmt.Fprintf(&params, "  \"temperature\": %s,\n", formatFloatOpt(o.Temperature))
+	}
+	if o.TopP != nil {
+		fmt.Fprintf(&params, "  \"top_p\": %s,\n", formatFloatOpt(o.TopP))
+	}
+	if o.NumPredict > 0 {
+		fmt.Fprintf(&params, "  \"max_tokens\": %d,\n", o.NumPredict)
+	}
+	if o.Seed != nil {
+		fmt.Fprintf(&params, "  \"seed\": %d,\n", *o.Seed)
+	}
+	return fmt.Sprintf(`{
+  "model": %s,
+  "messages": [
+    {"role": "system", "content": "You are a helpful assistant."},
+    {"role": "user", "content": "Say hello in one sentence."}
+  ],
+%s  "stream": true,
+  "stream_options": {"include_usage": true}
+}`, q, params.String())
+}
+
+func newOpenAIState(name string, opts config.GenOptions, width, height int) *openaiState {
+	editor := textarea.New()
+	editor.ShowLineNumbers = true
+	editor.CharLimit = 0
+	editor.MaxHeight = 0
+	editor.SetValue(openaiRequest(name, opts))
+
+	s := &openaiState{model: name, editor: editor, view: viewport.New(width, 5)}
+	s.resize(width, height)
+	return s
+}
+
+// resize splits the screen between the request and the response, leaving
+// room for the titles and help.
+func (s *openaiState) resize(width, height int) {
+	rest := max(height-8, 10)
+	s.editor.SetWidth(max(width, 40))
+	s.editor.SetHeight(max(rest*2/5, 5))
+	s.view.Width = width
+	s.view.Height = max(rest-rest*2/5, 5)
+	s.render()
+}
+
+func (s *openaiState) sending() bool {
+	return s.updates != nil
+}
+
+func (s *openaiState) stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// send checks the request is JSON and posts it as typed.
+func (s *openaiState) send(client *ollama.Client) tea.Cmd {
+	body := []byte(s.editor.Value())
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		s.status = "The request is not valid JSON: " + jsonErrorAt(body, err)
+		return nil
+	}
+	s.lines, s.finish, s.usage, s.status, s.err = nil, "", "", "", nil
+	s.reply.Reset()
+	s.started = time.Now()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.updates = make(chan tea.Msg, 64)
+	updates := s.updates
+	go func() {
+		defer close(updates)
+		err := client.ChatCompletions(ctx, body, func(line string) {
+			updates <- openaiLineMsg(line)
+		})
+		updates <- openaiDoneMsg{err: err}
+	}()
+	s.render()
+	return listen(updates)
+}
+
+// jsonErrorAt describes a JSON error with the line it is on.
+func jsonErrorAt(body []byte, err error) string {
+	var se *json.SyntaxError
+	if errors.As(err, &se) {
+		return fmt.Sprintf("%v (line %d)", err, bytes.Count(body[:se.Offset], []byte("\n"))+1)
+	}
+	return err.Error()
+}
+
+// add records a line of the response and the reply text it carries.
+func (s *openaiState) add(line string) {
+	s.lines = append(s.lines, line)
+	payload, _ := strings.CutPrefix(line, "data: ")
+	var c completion
+	if json.Unmarshal([]byte(payload), &c) != nil {
+		return
+	}
+	for _, ch := range c.Choices {
+		s.reply.WriteString(ch.Delta.Content)
+		s.reply.WriteString(ch.Message.Content)
+		if ch.FinishReason != "" {
+			s.finish = ch.FinishReason
+		}
+	}
+	if u := c.Usage; u != nil {
+		s.usage = fmt.Sprintf("%d prompt + %d completion tokens", u.PromptTokens, u.CompletionTokens)
+	}
+}
+
+func (s *openaiState) done(msg openaiDoneMsg) {
+	s.updates, s.cancel = nil, nil
+	s.took = time.Since(s.started)
+	s.err = msg.err
+	if errors.Is(msg.err, context.Canceled) {
+		s.err = nil
+		s.status = "Stopped"
+	}
+	s.render()
+}
+
+// render lays out the response: its lines as received, a lone JSON object
+// indented, then the reply they add up to.
+func (s *openaiState) render() {
+	wrap := lipgloss.NewStyle().Width(max(s.view.Width, 20))
+	var b strings.Builder
+	switch {
+	case s.started.IsZero():
+		b.WriteString(helpStyle.Render("Send the request to see the raw response here."))
+	case s.sending():
+		b.WriteString(helpStyle.Render(fmt.Sprintf("Waiting for the response, %d lines so far...", len(s.lines))))
+	default:
+		b.WriteString(helpStyle.Render(fmt.Sprintf("%d lines in %s", len(s.lines), formatLatency(s.took))))
+	}
+	b.WriteString("\n")
+
+	var se *ollama.StatusError
+	if errors.As(s.err, &se) {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("HTTP %d", se.StatusCode)))
+		b.WriteString("\n")
+		b.WriteString(wrap.Render(indentJSON(se.Message)))
+		b.WriteString("\n")
+	} else if s.err != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", s.err)))
+		b.WriteString("\n")
+	}
+	for _, line := range s.lines {
+		if len(s.lines) == 1 {
+			line = indentJSON(line)
+		}
+		b.WriteString(wrap.Render(line))
+		b.WriteString("\n")
+	}
+
+	if s.reply.Len() > 0 || s.finish != "" {
+		b.WriteString("\n")
+		b.WriteString(assistantStyle.Render("Reply"))
+		if s.finish != "" {
+			b.WriteString(helpStyle.Render("  finish_reason: " + s.finish))
+		}
+		b.WriteString("\n")
+		b.WriteString(wrap.Render(s.reply.String()))
+		b.WriteString("\n")
+	}
+	if s.usage != "" {
+		b.WriteString(helpStyle.Render("Usage: " + s.usage))
+		b.WriteString("\n")
+	}
+	s.view.SetContent(b.String())
+	if s.sending() {
+		s.view.GotoBottom()
+	}
+}
+
+// indentJSON indents s if it is JSON, and returns it as is otherwise.
+func indentJSON(s string) string {
+	var b bytes.Buffer
+	if json.Indent(&b, []byte(s), "", "  ") != nil {
+		return s
+	}
+	return b.String()
+}
+
+// openOpenAI shows the console for name, keeping the request and response
+// when it was last open for the same model.
+func (m model) openOpenAI(name string) (tea.Model, tea.Cmd) {
+	if m.openai == nil || m.openai.model != name {
+		if m.openai != nil {
+			m.openai.stop()
+		}
+		m.openai = newOpenAIState(name, m.cfg.ModelOptions[name], m.width, m.height)
+	}
+	m.mode = modeOpenAI
+	m.openai.scroll = false
+	return m, m.openai.editor.Focus()
+}
+
+func (m model) updateOpenAI(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := m.openai
+	switch {
+	case key.Matches(msg, m.keys.Save):
+		if s.sending() {
+			return m, nil
+		}
+		return m, s.send(m.client)
+	case key.Matches(msg, m.keys.ChatStop):
+		s.stop()
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		// A response in flight keeps streaming into the console.
+		s.editor.Blur()
+		m.mode = modeList
+		return m, nil
+	case "tab":
+		s.scroll = !s.scroll
+		if s.scroll {
+			s.editor.Blur()
+			return m, nil
+		}
+		return m, s.editor.Focus()
+	case "pgup", "pgdown":
+		var cmd tea.Cmd
+		s.view, cmd = s.view.Update(msg)
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	if s.scroll {
+		s.view, cmd = s.view.Update(msg)
+	} else {
+		s.editor, cmd = s.editor.Update(msg)
+	}
+	return m, cmd
+}
+
+func (m model) openaiView() string {
+	s := m.openai
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("OpenAI API: " + s.model))
+	b.WriteString(helpStyle.Render("  POST " + m.client.Host() + ollama.ChatCompletionsPath))
+	b.WriteString("\n\n")
+	b.WriteString(s.editor.View())
+	b.WriteString("\n")
+	b.WriteString(chatPaneStyle.Render(s.view.View()))
+	b.WriteString("\n")
+	if s.status != "" {
+		b.WriteString(s.status + "\n")
+	}
+	help := helpLine(relabel(m.keys.Save, "Send"))
+	if s.sending() {
+		help = helpLine(relabel(m.keys.ChatStop, "Stop"))
+	}
+	if s.scroll {
+		help += "  Tab: Edit request  ↑/↓ PgUp/PgDn: Scroll"
+	} else {
+		help += "  Tab: Scroll response  PgUp/PgDn: Scroll"
+	}
+	b.WriteString(helpStyle.Render(help + "  Esc: Back"))
+	return b.String()
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/ollama"
+)
+
+// maxErrors caps the in-memory error log.
+const maxErrors = 50
+
+// errorEntry is one line in the expandable error log.
+type errorEntry struct {
+	at  time.Time
+	msg string
+}
+
+// opDoneMsg reports the outcome of a background model operation.
+type opDoneMsg struct {
+	running string // e.g. "Loading x", reused in the failure message
+	done    string // status text on success
+	err     error
+	load    string // model the operation loaded, if any
+}
+
+// runOp runs fn off the Update loop, showing the spinner with the given
+// status until it reports back as an opDoneMsg.
+func (m *model) runOp(running, done string, fn func() error) tea.Cmd {
+	m.status = running + "..."
+	slog.Info("op", "action", running)
+	op := func() tea.Msg {
+		return opDoneMsg{running: running, done: done, err: fn()}
+	}
+	return tea.Batch(op, m.startBusy())
+}
+
+// startLoad loads name in the background like runOp, showing it as loading
+// in the list until /api/ps reports it.
+func (m *model) startLoad(running, done, name string, opts ollama.LoadOptions) tea.Cmd {
+	c := m.client
+	m.status = running + "..."
+	slog.Info("op", "action", running)
+	m.loading[name] = false
+	m.syncTable()
+	op := func() tea.Msg {
+		return opDoneMsg{running: running, done: done, err: loadModel(c, name, opts), load: name}
+	}
+	return tea.Batch(op, m.startBusy())
+}
+
+// spinning reports whether the spinner is animating: while operations are
+// in flight or models are waiting to show up as loaded.
+func (m model) spinning() bool {
+	return m.busy > 0 || len(m.loading) > 0
+}
+
+// startBusy counts a new operation in flight and starts the spinner if it
+// was idle.
+func (m *model) startBusy() tea.Cmd {
+	idle := !m.spinning()
+	m.busy++
+	if idle {
+		return m.spinner.Tick
+	}
+	return nil
+}
+
+// finishOp records an operation's result and refreshes the list so the view
+// reflects what the server actually did.
+func (m *model) finishOp(msg opDoneMsg) tea.Cmd {
+	m.busy = max(m.busy-1, 0)
+	if msg.err != nil {
+		m.status = fmt.Sprintf("%s failed: %v", msg.running, msg.err)
+		m.logError(m.status)
+		delete(m.loading, msg.load)
+	} else {
+		m.status = msg.done
+		slog.Info("op", "action", msg.running, "result", msg.done)
+		if _, ok := m.loading[msg.load]; ok {
+			m.loading[msg.load] = true
+		}
+	}
+	m.syncTable()
+	return m.refresh()
+}
+
+func (m *model) logError(msg string) {
+	slog.Error(msg)
+	m.errs = append(m.errs, errorEntry{at: time.Now(), msg: msg})
+	if len(m.errs) > maxErrors {
+		m.errs = m.errs[len(m.errs)-maxErrors:]
+	}
+}
+
+// noteRefreshErr surfaces refresh failures without flooding the log: the
+// auto-refresh would otherwise add the same error every few seconds.
+func (m *model) noteRefreshErr(err error) {
+	if err == nil {
+		m.lastRefreshErr = ""
+		return
+	}
+	if msg := err.Error(); msg != m.lastRefreshErr {
+		m.lastRefreshErr = msg
+		m.status = "Refresh failed: " + msg
+		m.logError(m.status)
+	}
+}
+
+func (m model) errorLogView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Errors (%d)", len(m.errs))))
+	b.WriteString("\n")
+	if len(m.errs) == 0 {
+		b.WriteString(helpStyle.Render("  No errors"))
+		b.WriteString("\n")
+		return b.String()
+	}
+	const shown = 10
+	start := max(len(m.errs)-shown, 0)
+	for _, e := range m.errs[start:] {
+		b.WriteString(helpStyle.Render(e.at.Format("15:04:05")))
+		b.WriteString(" ")
+		b.WriteString(errorStyle.Render(e.msg))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// fallbackContext bounds a CLI fallback on its own, since the API call it
+// stands in for may have timed out and spent its context.
+func fallbackContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), apiTimeout)
+}
+
+// getModels lists installed models via the API, falling back to `ollama list`
+// when a local server can't be reached. The CLI fallbacks below are skipped
+// for remote hosts, where they would act on the wrong machine.
+func getModels(c *ollama.Client) ([]ollama.Model, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+	models, err := c.List(ctx)
+	if ollama.IsUnreachable(err) && c.Local() {
+		cliCtx, cliCancel := fallbackContext()
+		defer cliCancel()
+		if cliModels, cliErr := ollama.ListCLI(cliCtx); cliErr == nil {
+			return cliModels, nil
+		}
+	}
+	return models, err
+}
+
+// getRunning lists models in memory via the API, falling back to `ollama ps`
+// when the server can't be reached.
+func getRunning(c *ollama.Client) ([]ollama.RunningModel, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+	running, err := c.Running(ctx)
+	if ollama.IsUnreachable(err) && c.Local() {
+		cliCtx, cliCancel := fallbackContext()
+		defer cliCancel()
+		if cliRunning, cliErr := ollama.RunningCLI(cliCtx); cliErr == nil {
+			return cliRunning, nil
+		}
+	}
+	return running, err
+}
+
+// loadedSet indexes running models by name.
+func loadedSet(running []ollama.RunningModel) map[string]bool {
+	loaded := make(map[string]bool)
+	for _, r := range running {
+		loaded[r.Name] = true
+	}
+	return loaded
+}
+
+// loadModel asks the server to load name and waits until it has. There is
+// no CLI fallback: `ollama run` needs the same server, and started in the
+// background it would report success before the load had even begun.
+func loadModel(c *ollama.Client, name string, opts ollama.LoadOptions) error {
+	return c.Load(context.Background(), name, opts)
+}
+
+// stopModel unloads name, falling back to `ollama stop` when the server
+// can't be reached.
+func stopModel(c *ollama.Client, name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+	err := c.Unload(ctx, name)
+	if ollama.IsUnreachable(err) && c.Local() {
+		cliCtx, cliCancel := fallbackContext()
+		defer cliCancel()
+		if cliErr := ollama.StopCLI(cliCtx, name); cliErr != nil {
+			return errors.Join(err, cliErr)
+		}
+		return nil
+	}
+	return err
+}
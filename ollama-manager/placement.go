@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"ollama-manager/internal/gpu"
+	"ollama-manager/internal/ollama"
+)
+
+// modelPlacement works out how much VRAM each loaded model holds on each
+// GPU. Ollama only reports a model's total, but every model runs in a
+// runner process of its own, and the driver reports a process's VRAM per
+// GPU. Runners are matched to models by the closest total, which pairs
+// them up unless two models are within a few hundred MB of each other.
+// Processes the driver reports no VRAM for, as under Windows WDDM, can't
+// be placed.
+func modelPlacement(running []ollama.RunningModel, procs []gpu.Process) map[string]map[int]uint64 {
+	runners := make(map[int]map[int]uint64) // by PID, then GPU
+	for _, p := range procs {
+		if !isOllama(p) || p.Memory == 0 {
+			continue
+		}
+		if runners[p.PID] == nil {
+			runners[p.PID] = make(map[int]uint64)
+		}
+		runners[p.PID][p.GPU] += p.Memory
+	}
+
+	type pair struct {
+		pid   int
+		model string
+		diff  int64
+	}
+	var pairs []pair
+	for pid, byGPU := range runners {
+		var total int64
+		for _, mem := range byGPU {
+			total += int64(mem)
+		}
+		for _, r := range running {
+			if r.SizeVRAM == 0 {
+				continue // on the CPU
+			}
+			pairs = append(pairs, pair{pid, r.Name, abs(total - r.SizeVRAM)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].diff < pairs[j].diff })
+
+	placed := make(map[string]map[int]uint64)
+	taken := make(map[int]bool)
+	for _, p := range pairs {
+		if taken[p.pid] || placed[p.model] != nil {
+			continue
+		}
+		taken[p.pid] = true
+		placed[p.model] = runners[p.pid]
+	}
+	return placed
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// placementView lists the GPUs each loaded model sits on, from the process
+// list of the GPU tab.
+func (m model) placementView() string {
+	running := m.runningList()
+	if m.procs == nil || m.procs.procs == nil || len(running) == 0 {
+		return ""
+	}
+	placed := modelPlacement(running, m.procs.procs)
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Placement"))
+	b.WriteString("\n\n")
+	for _, r := range running {
+		var where string
+		switch byGPU := placed[r.Name]; {
+		case r.SizeVRAM == 0:
+			where = helpStyle.Render("CPU only")
+		case byGPU == nil:
+			where = helpStyle.Render("n/a (the driver doesn't report its VRAM)")
+		default:
+			var parts []string
+			for _, d := range m.gpus {
+				if mem, ok := byGPU[d.Index]; ok {
+					parts = append(parts, fmt.Sprintf("GPU %d %s", d.Index, formatBytes(mem)))
+				}
+			}
+			where = strings.Join(parts, "  ")
+			if r.SizeVRAM < r.Size {
+				where += warnStyle.Render(fmt.Sprintf("  CPU %s", formatBytes(uint64(r.Size-r.SizeVRAM))))
+			}
+		}
+		b.WriteString(fmt.Sprintf("  %-32s %s\n", r.Name, where))
+	}
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// preset is a curated set of models for one kind of use, pulled together.
+type preset struct {
+	name  string
+	vram  uint64 // the card it is made for; larger ones get the same models
+	about string
+	roles []presetRole
+}
+
+// presetRole is one model of a preset, in sizes ordered from largest to
+// smallest like starters.
+type presetRole struct {
+	role  string
+	sizes []presetModel
+}
+
+type presetModel struct {
+	vram uint64 // smallest VRAM it is chosen for
+	name string
+	size int64 // approximate download, in bytes
+}
+
+// embedder is small enough for every card, so each preset takes it as is.
+var embedder = presetRole{"embeddings", []presetModel{{0, "nomic-embed-text", 274e6}}}
+
+var presets = []preset{
+	{
+		name:  "8GB starter",
+		vram:  8 << 30,
+		about: "A general chat model and an embedding model for a first card.",
+		roles: []presetRole{
+			{"chat", []presetModel{
+				{7 << 30, "qwen3:8b", 5.2e9},
+				{4 << 30, "qwen3:4b", 2.5e9},
+				{0, "qwen3:1.7b", 1.4e9},
+			}},
+			embedder,
+		},
+	},
+	{
+		name:  "16GB coding",
+		vram:  16 << 30,
+		about: "A coding model for chat and edits, a small one for completion, embeddings for code search.",
+		roles: []presetRole{
+			{"coding", []presetModel{
+				{14 << 30, "qwen2.5-coder:14b", 9e9},
+				{7 << 30, "qwen2.5-coder:7b", 4.7e9},
+				{0, "qwen2.5-coder:3b", 1.9e9},
+			}},
+			{"completion", []presetModel{{0, "qwen2.5-coder:1.5b", 986e6}}},
+			embedder,
+		},
+	},
+	{
+		name:  "24GB do-everything",
+		vram:  24 << 30,
+		about: "Chat, coding and vision models as large as the card runs, plus embeddings.",
+		roles: []presetRole{
+			{"chat", []presetModel{
+				{22 << 30, "qwen3:32b", 20e9},
+				{14 << 30, "qwen3:14b", 9.3e9},
+				{7 << 30, "qwen3:8b", 5.2e9},
+				{0, "qwen3:4b", 2.5e9},
+			}},
+			{"coding", []presetModel{
+				{22 << 30, "qwen2.5-coder:32b", 20e9},
+				{14 << 30, "qwen2.5-coder:14b", 9e9},
+				{0, "qwen2.5-coder:7b", 4.7e9},
+			}},
+			{"vision", []presetModel{
+				{22 << 30, "gemma3:27b", 17e9},
+				{11 << 30, "gemma3:12b", 8.1e9},
+				{0, "gemma3:4b", 3.3e9},
+			}},
+			embedder,
+		},
+	},
+}
+
+// expand picks a size of each model of p for a card with vram, no larger
+// than the card p is made for; without a GPU it takes the smallest. Roles
+// that come out as the same model are pulled once.
+func (p preset) expand(vram uint64) []presetModel {
+	vram = min(vram, p.vram)
+	var picked []presetModel
+	seen := make(map[string]bool)
+	for _, r := range p.roles {
+		pm := r.sizes[len(r.sizes)-1]
+		for _, s := range r.sizes {
+			if vram >= s.vram {
+				pm = s
+				break
+			}
+		}
+		if !seen[pm.name] {
+			seen[pm.name] = true
+			picked = append(picked, pm)
+		}
+	}
+	return picked
+}
+
+// presetSummary lists the models of a preset with their total download.
+func presetSummary(models []presetModel) string {
+	names := make([]string, len(models))
+	var total int64
+	for i, pm := range models {
+		names[i] = pm.name
+		total += pm.size
+	}
+	return fmt.Sprintf("%s (~%s)", strings.Join(names, ", "), formatBytes(uint64(total)))
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/proxy"
+)
+
+// proxyListen returns the address the proxy is configured to listen on.
+func proxyListen(cfg *config.Config) string {
+	if cfg.Proxy != nil && cfg.Proxy.Listen != "" {
+		return cfg.Proxy.Listen
+	}
+	return proxy.DefaultListen
+}
+
+// proxyUpstream resolves the server to forward to, allowing a bare port
+// such as ":11434" for this machine.
+func proxyUpstream(s string) (string, error) {
+	if strings.HasPrefix(s, ":") {
+		s = "127.0.0.1" + s
+	}
+	host, _, err := ollama.ParseHost(s)
+	return host, err
+}
+
+func cmdProxy(c *ollama.Client, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	listen, upstream := proxyListen(cfg), c.Host()
+	if cfg.Proxy != nil && cfg.Proxy.Upstream != "" {
+		upstream = cfg.Proxy.Upstream
+	}
+	// Allow the "proxy :11435 -> :11434" form, which shells only pass on
+	// with the arrow quoted.
+	addrs := strings.Fields(strings.ReplaceAll(strings.Join(args, " "), "->", " "))
+	switch len(addrs) {
+	case 2:
+		upstream = addrs[1]
+		fallthrough
+	case 1:
+		listen = addrs[0]
+	case 0:
+	default:
+		return usageError{"usage: proxy [listen [upstream]], e.g. proxy :11435 :11434"}
+	}
+	if upstream, err = proxyUpstream(upstream); err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return serveProxy(ctx, cfg, listen, upstream)
+}
+
+// serveProxy runs the proxy until ctx is done, for the proxy command and
+// the daemon.
+func serveProxy(ctx context.Context, cfg *config.Config, listen, upstream string) error {
+	p, err := proxy.New(upstream)
+	if err != nil {
+		return err
+	}
+	used := openUsageOrWarn()
+	p.Log = func(r proxy.Record) {
+		fmt.Println(formatRecord(r))
+		recordProxied(used, upstream, r)
+	}
+	keys := proxyKeys(cfg)
+	p.SetKeys(keys)
+	p.SetLimits(globalLimits(cfg))
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Forwarding %s to %s\n", ln.Addr(), upstream)
+	if len(keys) == 0 {
+		fmt.Println("No API keys are set, so anyone who can reach the proxy can use the server; add keys in the Traffic tab")
+	} else {
+		fmt.Printf("Requiring an API key (%d configured)\n", len(keys))
+	}
+	if l := globalLimits(cfg); l != (proxy.Limits{}) {
+		fmt.Printf("Limiting all clients to %s\n", l)
+	}
+
+	go watchProxyConfig(ctx, cfg.Path(), p)
+	srv := &http.Server{Handler: p, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// proxyKeys converts the configured API keys for the proxy.
+func proxyKeys(cfg *config.Config) []proxy.Key {
+	var keys []proxy.Key
+	for _, k := range cfg.ProxyKeys() {
+		keys = append(keys, proxy.Key{Name: k.Name, Hash: k.Hash, Models: k.Models, Limits: proxyLimits(k.ProxyLimits)})
+	}
+	return keys
+}
+
+// globalLimits returns the limits the config sets for all clients.
+func globalLimits(cfg *config.Config) proxy.Limits {
+	if cfg.Proxy == nil {
+		return proxy.Limits{}
+	}
+	return proxyLimits(cfg.Proxy.ProxyLimits)
+}
+
+func proxyLimits(l config.ProxyLimits) proxy.Limits {
+	return proxy.Limits{RequestsPerMinute: l.RequestsPerMinute, MaxConcurrent: l.MaxConcurrent}
+}
+
+// watchProxyConfig reloads the API keys and limits whenever the config file
+// changes, so keys created or revoked in the TUI apply to a running proxy.
+func watchProxyConfig(ctx context.Context, path string, p *proxy.Proxy) {
+	var last time.Time
+	if info, err := os.Stat(path); err == nil {
+		last = info.ModTime()
+	}
+	t := time.NewTicker(2 * time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Equal(last) {
+			continue
+		}
+		last = info.ModTime()
+		cfg, err := config.LoadFile(path)
+		if err != nil {
+			fmt.Printf("Keeping the API keys and limits: %v\n", err)
+			continue
+		}
+		keys := proxyKeys(cfg)
+		p.SetKeys(keys)
+		p.SetLimits(globalLimits(cfg))
+		fmt.Printf("Reloaded %d API keys; limits for all clients: %s\n", len(keys), globalLimits(cfg))
+	}
+}
+
+// formatRecord renders a request as the proxy logs it, e.g.
+// "2025-06-01 12:34:56 192.168.1.20 POST /api/chat qwen3:8b 200 4.1s 120+512
+// tokens".
+func formatRecord(r proxy.Record) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s", r.Time.Format("2006-01-02 15:04:05"), r.Addr)
+	if r.Key != "" {
+		fmt.Fprintf(&b, " key %s", r.Key)
+	}
+	fmt.Fprintf(&b, " %s %s", r.Method, r.Path)
+	if r.Model != "" {
+		fmt.Fprintf(&b, " %s", r.Model)
+	}
+	fmt.Fprintf(&b, " %d %s", r.Status, formatLatency(r.Latency))
+	if r.InputTokens+r.OutputTokens > 0 {
+		fmt.Fprintf(&b, " %d+%d tokens", r.InputTokens, r.OutputTokens)
+	}
+	if r.Limited != "" {
+		fmt.Fprintf(&b, " (%s limit)", r.Limited)
+	}
+	return b.String()
+}
+
+// formatLatency rounds a request's duration for display, e.g. "4.1s" or
+// "35ms".
+func formatLatency(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
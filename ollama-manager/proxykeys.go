@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/proxy"
+)
+
+// keysState manages the proxy's API keys on the Traffic tab. The keys live
+// in the config file, which a running proxy reloads when it changes.
+type keysState struct {
+	cursor int
+	// editing is "name" while naming a new key, and "models" or "limits"
+	// while editing the selected key's allowlist or limits.
+	editing string
+	input   textinput.Model
+	confirm bool   // waiting for y to revoke the selected key
+	token   string // a new key's token, shown until the next key press
+}
+
+func newKeysState() keysState {
+	input := textinput.New()
+	input.CharLimit = 256
+	input.Width = 50
+	return keysState{input: input}
+}
+
+// busy reports whether the keys are taking input, so keys that switch tabs
+// don't apply.
+func (s *keysState) busy() bool {
+	return s.editing != "" || s.confirm || s.token != ""
+}
+
+// updateKeys handles the API key actions of the Traffic tab, reporting
+// whether msg was one.
+func (m *model) updateKeys(msg tea.KeyMsg) (tea.Cmd, bool) {
+	s := &m.traffic.keys
+	keys := m.cfg.ProxyKeys()
+	switch {
+	case s.token != "":
+		s.token = ""
+		return nil, true
+	case s.confirm:
+		s.confirm = false
+		if msg.String() != "y" {
+			m.status = "Revoke cancelled"
+			return nil, true
+		}
+		name := keys[s.cursor].Name
+		m.cfg.RemoveProxyKey(name)
+		s.cursor = max(min(s.cursor, len(keys)-2), 0)
+		m.saveKeys("Revoked API key " + name)
+		return nil, true
+	case s.editing != "":
+		switch msg.String() {
+		case "esc":
+			s.editing = ""
+			s.input.Blur()
+		case "enter":
+			m.finishKeyInput(strings.TrimSpace(s.input.Value()))
+		default:
+			var cmd tea.Cmd
+			s.input, cmd = s.input.Update(msg)
+			return cmd, true
+		}
+		return nil, true
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.NewKey):
+		s.editing = "name"
+		s.input.Prompt = "Key name: "
+		s.input.Placeholder = "laptop, agents..."
+		s.input.SetValue("")
+		return s.input.Focus(), true
+	case len(keys) == 0:
+		return nil, false
+	case key.Matches(msg, m.keys.Up):
+		s.cursor = max(s.cursor-1, 0)
+	case key.Matches(msg, m.keys.Down):
+		s.cursor = min(s.cursor+1, len(keys)-1)
+	case msg.String() == "enter":
+		s.editing = "models"
+		s.input.Prompt = "Models: "
+		s.input.Placeholder = "all; or e.g. qwen3, llama3.1:8b, *embed*"
+		s.input.SetValue(strings.Join(keys[s.cursor].Models, ", "))
+		s.input.CursorEnd()
+		return s.input.Focus(), true
+	case key.Matches(msg, m.keys.Limits):
+		k := keys[s.cursor]
+		s.editing = "limits"
+		s.input.Prompt = "Requests per minute, generations at once: "
+		s.input.Placeholder = "e.g. 30 2; 0 for no limit"
+		s.input.SetValue(fmt.Sprintf("%d %d", k.RequestsPerMinute, k.MaxConcurrent))
+		s.input.CursorEnd()
+		return s.input.Focus(), true
+	case key.Matches(msg, m.keys.Delete):
+		s.confirm = true
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+// finishKeyInput creates a key named v, or sets the selected key's models
+// to the comma- or space-separated patterns in v, or its limits.
+func (m *model) finishKeyInput(v string) {
+	s := &m.traffic.keys
+	if s.editing == "name" {
+		if v == "" {
+			m.status = "A key needs a name"
+			return
+		}
+		token, err := proxy.NewToken()
+		if err != nil {
+			m.status = fmt.Sprintf("Could not create a key: %v", err)
+			return
+		}
+		k := config.ProxyKey{Name: v, Hash: proxy.HashToken(token), Hint: proxy.Hint(token), Created: time.Now()}
+		if err := m.cfg.AddProxyKey(k); err != nil {
+			m.status = err.Error()
+			return
+		}
+		s.editing, s.token = "", token
+		s.input.Blur()
+		s.cursor = len(m.cfg.ProxyKeys()) - 1
+		m.saveKeys("Created API key " + v)
+		return
+	}
+
+	if s.editing == "limits" {
+		limits, err := parseKeyLimits(v)
+		if err != nil {
+			m.status = err.Error()
+			return
+		}
+		k := &m.cfg.Proxy.Keys[s.cursor]
+		k.ProxyLimits = limits
+		s.editing = ""
+		s.input.Blur()
+		m.saveKeys("Updated the limits of " + k.Name)
+		return
+	}
+
+	models := strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	for _, p := range models {
+		if _, err := path.Match(p, ""); err != nil {
+			m.status = fmt.Sprintf("Bad model pattern %q", p)
+			return
+		}
+	}
+	k := &m.cfg.Proxy.Keys[s.cursor]
+	k.Models = models
+	s.editing = ""
+	s.input.Blur()
+	m.saveKeys("Updated the models of " + k.Name)
+}
+
+// parseKeyLimits reads "30 2" as 30 requests per minute and 2 generations
+// at once. Missing or zero values mean no limit.
+func parseKeyLimits(v string) (config.ProxyLimits, error) {
+	var l config.ProxyLimits
+	fields := strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(fields) > 2 {
+		return l, fmt.Errorf("enter at most two numbers, e.g. 30 2")
+	}
+	dst := []*int{&l.RequestsPerMinute, &l.MaxConcurrent}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return l, fmt.Errorf("%q is not a limit; enter whole numbers, e.g. 30 2", f)
+		}
+		*dst[i] = n
+	}
+	return l, nil
+}
+
+func (m *model) saveKeys(done string) {
+	if err := m.cfg.Save(); err != nil {
+		m.status = fmt.Sprintf("Could not save config: %v", err)
+		m.logError(m.status)
+		return
+	}
+	m.status = done
+}
+
+// keysView lists the API keys with the prompt or confirmation in progress.
+func (m model) keysView() string {
+	s := m.traffic.keys
+	keys := m.cfg.ProxyKeys()
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("API keys"))
+	b.WriteString("\n")
+	if len(keys) == 0 {
+		b.WriteString(helpStyle.Render("  None; the proxy accepts requests without a key."))
+		b.WriteString("\n")
+	} else {
+		tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  NAME\tTOKEN\tMODELS\tLIMITS\tCREATED")
+		for i, k := range keys {
+			cursor := "  "
+			if i == s.cursor {
+				cursor = "> "
+			}
+			models := "all"
+			if len(k.Models) > 0 {
+				models = strings.Join(k.Models, ", ")
+			}
+			fmt.Fprintf(tw, "%s%s\t%s\t%s\t%s\t%s\n", cursor, k.Name, k.Hint, models, proxyLimits(k.ProxyLimits),
+				formatAge(k.Created))
+		}
+		tw.Flush()
+	}
+	if s.editing != "" {
+		b.WriteString("\n")
+		b.WriteString(s.input.View())
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	switch {
+	case s.token != "":
+		b.WriteString(modalStyle.Render(fmt.Sprintf("New API key %s:\n\n%s\n\n"+
+			"Copy it now; only its hash is saved. Clients send it as\n\"Authorization: Bearer <key>\", the OpenAI API key.\n\nany key: Done",
+			keys[s.cursor].Name, s.token)))
+	case s.confirm:
+		b.WriteString(modalStyle.Render(fmt.Sprintf(
+			"Revoke %s? Clients using it are refused from now on.\n\ny: Revoke  any other key: Cancel", keys[s.cursor].Name)))
+	case s.editing == "name":
+		b.WriteString(helpStyle.Render("Enter: Create  Esc: Cancel"))
+	case s.editing == "models":
+		b.WriteString(helpStyle.Render("Enter: Set (empty = all models; a name without a tag allows every tag)  Esc: Cancel"))
+	case s.editing == "limits":
+		b.WriteString(helpStyle.Render("Enter: Set (0 = no limit; generations are chat, completion and embedding requests)  Esc: Cancel"))
+	default:
+		help := helpLine(m.keys.NewKey)
+		if len(keys) > 0 {
+			help += "  Enter: Models  " + helpLine(m.keys.Limits, relabel(m.keys.Delete, "Revoke"))
+		}
+		if len(m.traffic.snap.Active) > 0 {
+			help += "  " + helpLine(m.keys.Abort)
+		}
+		b.WriteString(helpStyle.Render(help + "  " + helpLine(m.keys.Refresh) + "  Esc: Back"))
+	}
+	return b.String()
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/pullqueue"
+)
+
+// defaultPullWorkers is how many pulls run at once unless configured.
+const defaultPullWorkers = 2
+
+// pullQueueMsg is sent when the pull queue has changed.
+type pullQueueMsg struct{}
+
+// newPullQueue sets up the queue from the config.
+func newPullQueue(cfg *config.Config) (*pullqueue.Queue, error) {
+	limit, err := pullqueue.ParseRate(cfg.PullRateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("pull_rate_limit: %w", err)
+	}
+	return pullqueue.New(orDefault(cfg.PullConcurrency, defaultPullWorkers), limit), nil
+}
+
+// listenQueue waits for the next change of q.
+func listenQueue(q *pullqueue.Queue) tea.Cmd {
+	return func() tea.Msg {
+		<-q.Changed()
+		return pullQueueMsg{}
+	}
+}
+
+// openPullInput prompts for the name of a model to pull.
+func (m model) openPullInput() (tea.Model, tea.Cmd) {
+	m.mode = modePullInput
+	m.input.Reset()
+	m.input.Prompt = "Pull model: "
+	m.input.Placeholder = "llama3.1:8b"
+	m.input.CharLimit = 256
+	m.input.Width = 40
+	return m, m.input.Focus()
+}
+
+// queuePull adds name to the pull queue. Pulls go to the host that was
+// active when they were queued.
+func (m *model) queuePull(name string) {
+	if !m.pulls.Add(m.client, name) {
+		m.status = fmt.Sprintf("Already pulling %s", name)
+		return
+	}
+	m.status = fmt.Sprintf("Pulling %s...", name)
+}
+
+// queuePreset queues the models of p sized for the GPUs, skipping those
+// already installed.
+func (m *model) queuePreset(p preset) {
+	total, _ := m.totalVRAM()
+	var queued []string
+	have := 0
+	for _, pm := range p.expand(total) {
+		if _, ok := findModel(m.models, pm.name); ok {
+			have++
+			continue
+		}
+		if m.pulls.Add(m.client, pm.name) {
+			queued = append(queued, pm.name)
+		}
+	}
+	switch {
+	case len(queued) == 0:
+		m.status = fmt.Sprintf("Everything in %s is installed or already pulling", p.name)
+	case have > 0:
+		m.status = fmt.Sprintf("Pulling %s (%d already installed)", strings.Join(queued, ", "), have)
+	default:
+		m.status = fmt.Sprintf("Pulling %s", strings.Join(queued, ", "))
+	}
+}
+
+// finishPulls reports the pulls that ended since the queue last changed.
+func (m *model) finishPulls() tea.Cmd {
+	cmds := []tea.Cmd{listenQueue(m.pulls)}
+	pulled := false
+	for _, it := range m.pulls.TakeFinished() {
+		cmds = append(cmds, m.noteUpdatePulled(it))
+		switch it.State {
+		case pullqueue.Cancelled:
+			m.status = fmt.Sprintf("Pull of %s cancelled", it.Name)
+			continue
+		case pullqueue.Failed:
+			m.status = fmt.Sprintf("Pull of %s failed: %v", it.Name, it.Err)
+		default:
+			m.status = fmt.Sprintf("Pulled %s", it.Name)
+			pulled = true
+		}
+		cmds = append(cmds, m.notify("pull", it.Started, "%s", m.status), m.alert("pull", "%s", m.status))
+	}
+	if pulled {
+		cmds = append(cmds, m.refresh())
+	}
+	return tea.Batch(cmds...)
+}
+
+// pullsView shows the running pulls under the model list.
+func (m model) pullsView() string {
+	var b strings.Builder
+	queued := 0
+	for _, it := range m.pulls.Items() {
+		switch it.State {
+		case pullqueue.Queued:
+			queued++
+		case pullqueue.Paused:
+			b.WriteString(helpStyle.Render(fmt.Sprintf("Paused %s at %.0f%% of %s  %s",
+				it.Name, it.Progress()*100, formatBytes(uint64(it.Total)), helpLine(m.keys.PullQueue))))
+			b.WriteString("\n")
+		case pullqueue.Pulling, pullqueue.Throttled:
+			b.WriteString(titleStyle.Render("Pulling " + it.Name))
+			b.WriteString(helpStyle.Render(" — " + it.Status))
+			b.WriteString("\n")
+			b.WriteString(fmt.Sprintf("%s  %s / %s  %s/s\n",
+				m.bar.ViewAs(it.Progress()), formatBytes(uint64(it.Done)), formatBytes(uint64(it.Total)),
+				formatBytes(uint64(max(it.Rate, 0)))))
+		}
+	}
+	if queued > 0 {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("%d more queued  %s", queued, helpLine(m.keys.PullQueue))))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// updatePullInput handles keys while the model-name prompt is open.
+func (m model) updatePullInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = modeList
+		m.input.Blur()
+		m.status = "Pull cancelled"
+		return m, nil
+	case "enter":
+		name := strings.TrimSpace(m.input.Value())
+		m.mode = modeList
+		m.input.Blur()
+		if name == "" {
+			return m, nil
+		}
+		m.queuePull(name)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m model) openPullQueue() (tea.Model, tea.Cmd) {
+	if len(m.pulls.Items()) == 0 {
+		m.status = "No pulls queued"
+		return m, nil
+	}
+	m.mode = modePullQueue
+	return m, nil
+}
+
+func (m model) updatePullQueue(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	items := m.pulls.Items()
+	m.queueCursor = min(m.queueCursor, max(len(items)-1, 0))
+	switch s := msg.String(); {
+	case s == "esc", key.Matches(msg, m.keys.Quit, m.keys.PullQueue):
+		m.mode = modeList
+	case key.Matches(msg, m.keys.Up):
+		m.queueCursor = max(m.queueCursor-1, 0)
+	case key.Matches(msg, m.keys.Down):
+		m.queueCursor = min(m.queueCursor+1, max(len(items)-1, 0))
+	case len(items) == 0:
+	case key.Matches(msg, m.keys.MoveUp):
+		m.pulls.Move(items[m.queueCursor].ID, -1)
+		m.queueCursor = max(m.queueCursor-1, 0)
+	case key.Matches(msg, m.keys.MoveDown):
+		m.pulls.Move(items[m.queueCursor].ID, 1)
+		m.queueCursor = min(m.queueCursor+1, len(items)-1)
+	case key.Matches(msg, m.keys.Cancel), s == "ctrl+c":
+		m.pulls.Cancel(items[m.queueCursor].ID)
+	case key.Matches(msg, m.keys.Pause):
+		if it := items[m.queueCursor]; it.State == pullqueue.Paused {
+			m.pulls.Resume(it.ID)
+			m.status = "Resumed " + it.Name
+		} else if !it.State.Finished() {
+			m.pulls.Pause(it.ID)
+			m.status = "Paused " + it.Name
+		}
+	}
+	return m, nil
+}
+
+func (m model) pullQueueView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Pull Queue"))
+	workers, limit := m.pulls.Limits()
+	settings := fmt.Sprintf("  %d at a time", workers)
+	if limit > 0 {
+		settings += fmt.Sprintf(", capped at %s/s", formatBytes(uint64(limit)))
+	}
+	b.WriteString(helpStyle.Render(settings))
+	b.WriteString("\n\n")
+
+	items := m.pulls.Items()
+	if len(items) == 0 {
+		b.WriteString("Nothing queued.\n")
+	}
+	bar := m.bar
+	bar.Width = 24
+	cursor := min(m.queueCursor, max(len(items)-1, 0))
+	for i, it := range items {
+		if i == cursor {
+			b.WriteString(cursorStyle.Render("> "))
+		} else {
+			b.WriteString("  ")
+		}
+		state := it.State.String()
+		switch it.State {
+		case pullqueue.Pulling:
+			state = fmt.Sprintf("%s %s/s", bar.ViewAs(it.Progress()), formatBytes(uint64(max(it.Rate, 0))))
+		case pullqueue.Throttled:
+			state = fmt.Sprintf("%s %s", bar.ViewAs(it.Progress()), helpStyle.Render("waiting for the bandwidth cap"))
+		case pullqueue.Paused:
+			state = fmt.Sprintf("%s %s", bar.ViewAs(it.Progress()), warnStyle.Render("paused"))
+		case pullqueue.Done:
+			state = loadedStyle.Render("✓ done")
+		case pullqueue.Failed:
+			state = errorStyle.Render("✗ " + it.Status)
+		case pullqueue.Queued, pullqueue.Cancelled:
+			state = helpStyle.Render(state)
+		}
+		b.WriteString(fmt.Sprintf("%-36s %s\n", it.Name, state))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render(helpLine(m.keys.Up, m.keys.Down, m.keys.MoveUp, m.keys.MoveDown, m.keys.Pause,
+		relabel(m.keys.Cancel, "Cancel/remove")) + "  Ctrl+C: Cancel  Esc: Back"))
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("\nStatus: %s", m.status))
+	return b.String()
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/vram"
+)
+
+// loadedFile records the models loaded on each server, under the data dir,
+// so the next start can offer them again.
+const loadedFile = "loaded.json"
+
+// loadedRecord is what was last seen loaded on one server.
+type loadedRecord struct {
+	Models []string  `json:"models"`
+	Seen   time.Time `json:"seen"`
+}
+
+// readLoaded returns the records by server URL; a missing file has none.
+func readLoaded() (map[string]loadedRecord, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, loadedFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]loadedRecord{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	records := make(map[string]loadedRecord)
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return records, nil
+}
+
+// writeLoaded records the models loaded on host.
+func writeLoaded(host string, models []string) error {
+	records, err := readLoaded()
+	if err != nil {
+		records = make(map[string]loadedRecord) // start over from a bad file
+	}
+	records[host] = loadedRecord{Models: models, Seen: time.Now()}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir, err := config.DataDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, loadedFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// previousLoaded returns the models loaded on host when the manager last
+// ran, unless restore is off.
+func previousLoaded(cfg *config.Config, host string) []string {
+	if cfg.Restore == "off" {
+		return nil
+	}
+	records, err := readLoaded()
+	if err != nil {
+		slog.Warn("restore", "err", err)
+		return nil
+	}
+	return records[host].Models
+}
+
+// recordLoaded saves the loaded models after a refresh when they changed.
+// Nothing is saved while the previous set is still being offered, so
+// quitting before answering doesn't lose it.
+func (m *model) recordLoaded() {
+	if m.restore != nil && m.mode != modeConfirmRestore {
+		m.restore = nil // left for another tab without answering
+	}
+	if m.restore != nil {
+		return
+	}
+	names := make([]string, 0, len(m.running))
+	for name := range m.running {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	key := m.client.Host() + "\n" + strings.Join(names, "\n")
+	if key == m.savedLoaded {
+		return
+	}
+	m.savedLoaded = key
+	if err := writeLoaded(m.client.Host(), names); err != nil {
+		m.logError("Saving the loaded models: " + err.Error())
+	}
+}
+
+// offerRestore plans loading the previous session's models that aren't
+// loaded now, fitting them into the free VRAM as a warm-up set would be,
+// and asks before running it, or just runs it with restore set to "auto".
+// It acts once, on the first refresh that reached the server.
+func (m *model) offerRestore() tea.Cmd {
+	previous := m.previous
+	m.previous = nil
+	if len(previous) == 0 {
+		return nil
+	}
+	w := config.Warmup{Name: "previous session"}
+	for _, name := range previous {
+		if !m.loaded[name] {
+			w.Models = append(w.Models, config.WarmupModel{Name: name})
+		}
+	}
+	if len(w.Models) == 0 {
+		return nil
+	}
+	free, ok := m.freeVRAM()
+	archOf := func(mdl ollama.Model) vram.Arch { return m.arch[archKey(mdl)] }
+	p := planWarmup(w, m.models, nil, archOf, free, ok, m.loadOptions)
+	if len(p.load) == 0 {
+		for _, s := range p.skipped {
+			m.logError("Not restoring " + s)
+		}
+		return nil
+	}
+	if m.cfg.Restore == "auto" {
+		return m.runRestore(p)
+	}
+	if m.mode != modeList {
+		// Something else is open already; don't interrupt it to ask.
+		m.status = fmt.Sprintf("Not restoring the %d models loaded last session", len(p.load))
+		return nil
+	}
+	m.restore = &p
+	m.mode = modeConfirmRestore
+	return nil
+}
+
+// runRestore loads the planned models in the background.
+func (m *model) runRestore(p warmupPlan) tea.Cmd {
+	c := m.client
+	m.status = fmt.Sprintf("Restoring %d models...", len(p.load))
+	slog.Info("op", "action", "restore", "load", p.load, "skipped", p.skipped)
+	for _, name := range p.load {
+		m.loading[name] = false
+	}
+	m.syncTable()
+	op := func() tea.Msg {
+		return runWarmup(c, p)
+	}
+	return tea.Batch(op, m.startBusy())
+}
+
+// updateRestoreConfirm restores on "y" and leaves the models unloaded on
+// any other key.
+func (m model) updateRestoreConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.restore
+	m.restore = nil
+	m.mode = modeList
+	if msg.String() != "y" {
+		m.status = "Previous session not restored"
+		return m, nil
+	}
+	return m, m.runRestore(*p)
+}
+
+func (m model) restoreView() string {
+	p := m.restore
+	var b strings.Builder
+	b.WriteString(warnStyle.Render(fmt.Sprintf("Restore the previous session? Load %s again", strings.Join(p.load, ", "))))
+	b.WriteString("\n")
+	if len(p.skipped) > 0 {
+		b.WriteString(helpStyle.Render("Skipping " + strings.Join(p.skipped, ", ")))
+		b.WriteString("\n")
+	}
+	b.WriteString(helpStyle.Render("y: Restore  any other key: Skip"))
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/gpu"
+	"ollama-manager/internal/ollama"
+)
+
+// resumed handles waking from sleep, noticed on a tick: the GPU handles are
+// opened again and the server asked afresh, and with reload_on_resume the
+// models loaded before are noted to load again once it answers.
+func (m *model) resumed(slept time.Duration) tea.Cmd {
+	slog.Info("resumed", "slept", slept.Round(time.Second))
+	gpu.Reinit()
+	m.status = fmt.Sprintf("Resumed after %s asleep; refreshing", slept.Round(time.Minute))
+	if m.cfg.ReloadOnResume && len(m.running) > 0 {
+		m.reload = nil
+		for name := range m.running {
+			m.reload = append(m.reload, name)
+		}
+		sort.Strings(m.reload)
+	}
+	return fetchServer(m.client, m.serviceName())
+}
+
+// reloadAfterResume loads whichever of the models noted on resume the
+// server no longer has loaded. It waits for a refresh that reached the
+// server, since it may still be waking up.
+func (m *model) reloadAfterResume() tea.Cmd {
+	if len(m.reload) == 0 {
+		return nil
+	}
+	var missing []string
+	opts := make(map[string]ollama.LoadOptions)
+	for _, name := range m.reload {
+		if _, ok := m.running[name]; !ok {
+			missing = append(missing, name)
+			opts[name] = m.loadOptions(name)
+		}
+	}
+	m.reload = nil
+	if len(missing) == 0 {
+		return nil
+	}
+	return m.startBatchLoad(missing, opts)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/schedule"
+)
+
+// scheduledMsg reports a scheduled action to the TUI.
+type scheduledMsg struct {
+	name string
+	err  error
+}
+
+// scheduleJobs turns the schedule in cfg into jobs acting on c, checking
+// every entry up front so mistakes show at startup rather than at 8:30.
+func scheduleJobs(c *ollama.Client, cfg *config.Config) ([]schedule.Job, error) {
+	var jobs []schedule.Job
+	for i, e := range cfg.Schedule {
+		spec, err := schedule.Parse(e.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("schedule[%d]: %w", i, err)
+		}
+		name, run, err := scheduleAction(c, cfg, e)
+		if err != nil {
+			return nil, fmt.Errorf("schedule[%d]: %w", i, err)
+		}
+		jobs = append(jobs, schedule.Job{Name: name, Spec: spec, Run: run})
+	}
+	return jobs, nil
+}
+
+// scheduleAction describes and builds what an entry does.
+func scheduleAction(c *ollama.Client, cfg *config.Config, e config.ScheduleEntry) (string, func() error, error) {
+	switch e.Action {
+	case "load", "unload":
+		if len(e.Models) == 0 {
+			return "", nil, fmt.Errorf("%s: no models given", e.Action)
+		}
+		models := e.Models
+		run := func() error {
+			var errs []error
+			for _, name := range models {
+				var err error
+				if e.Action == "load" {
+					err = loadModel(c, name, configLoadOptions(cfg, name))
+				} else {
+					err = stopModel(c, name)
+				}
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				}
+			}
+			return errors.Join(errs...)
+		}
+		return e.Action + " " + strings.Join(models, ", "), run, nil
+	case "unload_all":
+		run := func() error {
+			running, err := getRunning(c)
+			if err != nil {
+				return err
+			}
+			var errs []error
+			for _, r := range running {
+				if err := stopModel(c, r.Name); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", r.Name, err))
+				}
+			}
+			return errors.Join(errs...)
+		}
+		return "unload all", run, nil
+	case "warmup":
+		w, ok := cfg.Warmup(e.Warmup)
+		if !ok {
+			return "", nil, fmt.Errorf("warmup: unknown warm-up set %q", e.Warmup)
+		}
+		run := func() error {
+			p, err := planWarmupFor(c, cfg, w)
+			if err != nil {
+				return err
+			}
+			return runWarmup(c, p).err()
+		}
+		return "warm up " + w.Name, run, nil
+	}
+	return "", nil, fmt.Errorf("unknown action %q (valid: load, unload, unload_all, warmup)", e.Action)
+}
+
+// runSchedule runs jobs until ctx is done, logging each outcome before
+// passing it on.
+func runSchedule(ctx context.Context, jobs []schedule.Job, done func(schedule.Job, error)) {
+	schedule.Run(ctx, jobs, func(j schedule.Job, err error) {
+		if err != nil {
+			slog.Error("schedule", "job", j.Name, "err", err)
+		} else {
+			slog.Info("schedule", "job", j.Name)
+		}
+		done(j, err)
+	})
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/service"
+)
+
+const (
+	// serviceTimeout bounds a start, stop or restart including the wait for
+	// the API to answer again, and recreateTimeout recreating a container,
+	// which pulls its image first.
+	serviceTimeout  = 30 * time.Second
+	recreateTimeout = 15 * time.Minute
+
+	// serverLogLines is how much of the server log the panel shows.
+	serverLogLines = 15
+)
+
+// recreate is the server panel's extra action for containers.
+const recreate service.Action = "recreate"
+
+// errRemoteService explains why the server panel has no controls.
+var errRemoteService = errors.New("service control is only available for a local server")
+
+// serverInfo is what the header and server panel know about the daemon: its
+// version from the API and, for a local server, its service state.
+type serverInfo struct {
+	version string
+	status  *service.Status
+	err     error
+	loading bool
+	confirm service.Action // stop, restart or recreate waiting for y
+
+	// showLog shows the tail of the server log, read when it was turned on
+	// or refreshed.
+	showLog bool
+	log     string
+	logErr  error
+}
+
+// serviceVerbs words each action as a command, in progress and finished.
+var serviceVerbs = map[service.Action][3]string{
+	service.Start:   {"Start", "Starting", "Started"},
+	service.Stop:    {"Stop", "Stopping", "Stopped"},
+	service.Restart: {"Restart", "Restarting", "Restarted"},
+	recreate:        {"Recreate", "Recreating", "Recreated"},
+}
+
+// serverMsg carries a fresh serverInfo.
+type serverMsg struct {
+	host    string
+	version string
+	status  *service.Status
+	err     error
+}
+
+// containerMsg carries the service name of a container found serving the
+// local port, or "".
+type containerMsg struct{ name string }
+
+// serverLogMsg carries the tail of the server log.
+type serverLogMsg struct {
+	text string
+	err  error
+}
+
+// serviceDoneMsg reports the outcome of a start, stop or restart.
+type serviceDoneMsg struct {
+	action service.Action
+	err    error
+}
+
+// serviceName is the configured service name, a container found serving
+// the API or the platform default.
+func (m model) serviceName() string {
+	return firstNonEmpty(m.cfg.Service, m.container, service.DefaultName())
+}
+
+// findContainer looks for an Ollama container when the config doesn't name
+// the service, and is nil when it does.
+func (m model) findContainer() tea.Cmd {
+	if m.cfg.Service != "" || m.container != "" {
+		return nil
+	}
+	port := m.localPort()
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		name, _ := service.FindContainer(ctx, port)
+		return containerMsg{name: name}
+	}
+}
+
+// containerSpec is the template the config gives for recreating the
+// container.
+func (m model) containerSpec() service.ContainerSpec {
+	c := m.cfg.Container
+	if c == nil {
+		return service.ContainerSpec{}
+	}
+	return service.ContainerSpec{Image: c.Image, Ports: c.Ports, Volumes: c.Volumes, Env: c.Env, Args: c.Args}
+}
+
+func fetchServerLog(name string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		text, err := service.Log(ctx, name, serverLogLines)
+		return serverLogMsg{text: text, err: err}
+	}
+}
+
+func fetchServer(c *ollama.Client, name string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		msg := serverMsg{host: c.Host(), err: errRemoteService}
+		msg.version, _ = c.Version(ctx)
+		if c.Local() {
+			msg.status, msg.err = service.Query(ctx, name)
+		}
+		return msg
+	}
+}
+
+// controlService runs the action and, unless stopping, waits for the API to
+// come back so the refresh that follows finds the server up.
+func controlService(c *ollama.Client, name string, a service.Action, spec service.ContainerSpec) tea.Cmd {
+	return func() tea.Msg {
+		timeout := serviceTimeout
+		if a == recreate {
+			timeout = recreateTimeout
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		var err error
+		if a == recreate {
+			err = service.Recreate(ctx, name, spec)
+		} else {
+			err = service.Control(ctx, name, a)
+		}
+		if err == nil && a != service.Stop {
+			err = waitForAPI(ctx, c)
+		}
+		return serviceDoneMsg{action: a, err: err}
+	}
+}
+
+func waitForAPI(ctx context.Context, c *ollama.Client) error {
+	for {
+		if _, err := c.Version(ctx); err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return errors.New("the API did not answer in time")
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+func (m *model) finishService(msg serviceDoneMsg) tea.Cmd {
+	m.busy = max(m.busy-1, 0)
+	m.server.loading = false
+	verbs := serviceVerbs[msg.action]
+	if msg.err != nil {
+		m.status = fmt.Sprintf("%s the Ollama service failed: %v", verbs[1], msg.err)
+		m.logError(m.status)
+	} else {
+		m.status = verbs[2] + " the Ollama service"
+	}
+	return tea.Batch(m.refresh(), m.fetchServerPanel())
+}
+
+func (m model) openServer() (tea.Model, tea.Cmd) {
+	m.mode = modeServer
+	m.server.confirm = ""
+	if m.latest == nil {
+		return m, tea.Batch(m.fetchServerPanel(), fetchRelease())
+	}
+	return m, m.fetchServerPanel()
+}
+
+// fetchServerPanel reads everything the server panel shows.
+func (m model) fetchServerPanel() tea.Cmd {
+	cmds := []tea.Cmd{fetchServer(m.client, m.serviceName()), m.fetchWSL(), m.findContainer()}
+	if m.daemonConn != nil {
+		cmds = append(cmds, fetchDaemon(m.daemonConn))
+	}
+	if m.server.showLog && m.client.Local() {
+		cmds = append(cmds, fetchServerLog(m.serviceName()))
+	}
+	return tea.Batch(cmds...)
+}
+
+// managesContainer reports whether the panel controls a container, which
+// can also be recreated.
+func (m model) managesContainer() bool {
+	_, _, ok := service.ContainerName(m.serviceName())
+	return ok && m.server.status != nil
+}
+
+func (m model) updateServer(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := &m.server
+	if s.confirm != "" {
+		a := s.confirm
+		s.confirm = ""
+		if msg.String() != "y" {
+			m.status = "Cancelled"
+			return m, nil
+		}
+		if a == upgrade {
+			return m.startUpgrade()
+		}
+		return m.startService(a)
+	}
+
+	switch {
+	case msg.String() == "esc", key.Matches(msg, m.keys.Quit, m.keys.Server):
+		m.mode = modeList
+	case key.Matches(msg, m.keys.Refresh):
+		return m, tea.Batch(m.fetchServerPanel(), fetchRelease())
+	case key.Matches(msg, m.keys.Logs) && m.client.Local():
+		s.showLog = !s.showLog
+		if s.showLog {
+			s.log, s.logErr = "", nil
+			return m, fetchServerLog(m.serviceName())
+		}
+	case s.loading:
+		// Nothing to control until the action in flight is done.
+	case key.Matches(msg, m.keys.Upgrade) && m.client.Local():
+		if m.latest != nil && !m.outdated() && s.version != "" {
+			m.status = "Ollama " + s.version + " is the latest release"
+			return m, nil
+		}
+		return m.confirmUpgrade()
+	case s.status == nil:
+		// Nothing to control.
+	case key.Matches(msg, m.keys.Run):
+		return m.startService(service.Start)
+	case key.Matches(msg, m.keys.Stop):
+		s.confirm = service.Stop
+	case key.Matches(msg, m.keys.Restart):
+		s.confirm = service.Restart
+	case key.Matches(msg, m.keys.Recreate) && m.managesContainer():
+		s.confirm = recreate
+	}
+	return m, nil
+}
+
+func (m model) startService(a service.Action) (tea.Model, tea.Cmd) {
+	m.server.loading = true
+	m.status = serviceVerbs[a][1] + " the Ollama service..."
+	return m, tea.Batch(controlService(m.client, m.serviceName(), a, m.containerSpec()), m.startBusy())
+}
+
+// serverLabel is the header's "Ollama 0.5.7 · up 3h12m", empty until the
+// version is known.
+func (m model) serverLabel() string {
+	if m.server.version == "" {
+		return ""
+	}
+	label := "Ollama " + m.server.version
+	if m.outdated() {
+		label += " (" + m.latest.Version + " available)"
+	}
+	if up := m.server.status.Uptime(); up > 0 {
+		label += " · up " + formatUptime(up)
+	}
+	return label
+}
+
+// formatUptime renders a duration with its two largest units, e.g. "2d4h".
+func formatUptime(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dd%dh", int(d.Hours()/24), int(d.Hours())%24)
+}
+
+func (m model) serverView() string {
+	s := m.server
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Ollama Server"))
+	b.WriteString("\n\n")
+
+	version := s.version
+	if version == "" {
+		version = "not reachable"
+	}
+	if line := m.releaseLine(); line != "" {
+		version += "  " + line
+	}
+	b.WriteString(labelStyle.Render("Version") + version + "\n")
+	b.WriteString(labelStyle.Render("Host") + m.client.Host() + "\n")
+
+	switch {
+	case errors.Is(s.err, service.ErrNotInstalled):
+		b.WriteString("\n")
+		b.WriteString(warnStyle.Render(fmt.Sprintf("No service named %q was found.", m.serviceName())))
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render(`Set "service" in the config if Ollama is installed under another name, or to docker:NAME for a container.`))
+		b.WriteString("\n")
+	case s.err != nil:
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render(s.err.Error()))
+		b.WriteString("\n")
+	case s.status != nil:
+		st := s.status
+		b.WriteString(labelStyle.Render("Service") + fmt.Sprintf("%s (%s)\n", st.Name, st.Manager))
+		state := st.State.String()
+		if st.PID > 0 {
+			state += fmt.Sprintf(" (pid %d)", st.PID)
+		}
+		switch st.State {
+		case service.Running:
+			state = loadedStyle.Render(state)
+		case service.Failed:
+			state = errorStyle.Render(state)
+		}
+		b.WriteString(labelStyle.Render("State") + state + "\n")
+		if up := st.Uptime(); up > 0 {
+			b.WriteString(labelStyle.Render("Uptime") + formatUptime(up) + "\n")
+		}
+	default:
+		b.WriteString("\nChecking service...\n")
+	}
+
+	if s.showLog {
+		b.WriteString("\n")
+		b.WriteString(titleStyle.Render("Server Log"))
+		b.WriteString("\n\n")
+		switch {
+		case s.logErr != nil:
+			b.WriteString(errorStyle.Render(s.logErr.Error()))
+			b.WriteString("\n")
+		case s.log == "":
+			b.WriteString(helpStyle.Render("Reading the log..."))
+			b.WriteString("\n")
+		default:
+			for _, line := range strings.Split(strings.TrimRight(s.log, "\n"), "\n") {
+				b.WriteString(helpStyle.MaxWidth(max(m.width, 40)).Render(line))
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	if v := m.wslView(); v != "" {
+		b.WriteString("\n")
+		b.WriteString(v)
+	}
+	if m.daemonConn != nil {
+		b.WriteString("\n")
+		b.WriteString(m.daemonView())
+	}
+
+	b.WriteString("\n")
+	switch {
+	case s.confirm == upgrade:
+		b.WriteString(modalStyle.Render(m.upgradePrompt()))
+	case s.confirm == recreate:
+		b.WriteString(modalStyle.Render(
+			"Recreate the Ollama container? Its image is pulled again and the container replaced with one\n" +
+				"from the \"container\" template, with every GPU. Loaded models are unloaded; those in its\n" +
+				"volumes are kept.\n\ny: Confirm  any other key: Cancel"))
+	case s.confirm != "":
+		b.WriteString(modalStyle.Render(fmt.Sprintf(
+			"%s the Ollama service? Loaded models are unloaded and open requests fail.\n\ny: Confirm  any other key: Cancel",
+			serviceVerbs[s.confirm][0])))
+	default:
+		keys := []key.Binding{}
+		if s.status != nil {
+			keys = append(keys, relabel(m.keys.Run, "Start"), relabel(m.keys.Stop, "Stop"), relabel(m.keys.Restart, "Restart"))
+		}
+		if m.managesContainer() {
+			keys = append(keys, m.keys.Recreate)
+		}
+		if m.client.Local() {
+			keys = append(keys, m.keys.Upgrade, relabel(m.keys.Logs, "Server log"))
+		}
+		keys = append(keys, m.keys.Refresh)
+		b.WriteString(helpStyle.Render(helpLine(keys...) + "  Esc: Back"))
+	}
+	b.WriteString("\n")
+	if m.busy > 0 {
+		b.WriteString(fmt.Sprintf("\nStatus: %s %s", m.spinner.View(), m.status))
+	} else {
+		b.WriteString(fmt.Sprintf("\nStatus: %s", m.status))
+	}
+	return b.String()
+}
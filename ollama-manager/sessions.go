@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/session"
+)
+
+// sessionsDir holds the saved chats, under the data dir.
+const sessionsDir = "chats"
+
+// openSessionStore opens the saved chats in the data dir.
+func openSessionStore() (*session.Store, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	return session.Open(filepath.Join(dir, sessionsDir)), nil
+}
+
+// sessionsState is the picker of the saved chats with the open chat's model.
+type sessionsState struct {
+	list    []*session.Session
+	cursor  int
+	confirm bool // waiting for y to delete the selected chat
+}
+
+// saveChat writes the open conversation to disk after each reply.
+func (m *model) saveChat() {
+	c := m.chat
+	if len(c.history) == 0 {
+		return
+	}
+	c.session.Messages = c.history
+	if err := m.sessions.Save(c.session); err != nil {
+		m.logError("Saving chat: " + err.Error())
+	}
+}
+
+func (m model) openSessions() (tea.Model, tea.Cmd) {
+	list, err := m.sessions.List(m.chat.model)
+	if err != nil {
+		m.status = fmt.Sprintf("Could not read saved chats: %v", err)
+		return m, nil
+	}
+	m.chat.input.Blur()
+	m.sessionList = &sessionsState{list: list}
+	m.mode = modeSessions
+	return m, nil
+}
+
+// exportSession writes s as Markdown to the working directory.
+func exportSession(s *session.Session) (string, error) {
+	name := strings.NewReplacer(":", "-", "/", "-", `\`, "-").Replace(s.Model)
+	path, err := filepath.Abs(fmt.Sprintf("chat-%s-%s.md", name, s.ID))
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, []byte(s.Markdown()), 0o644)
+}
+
+func (m model) updateSessions(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p, c := m.sessionList, m.chat
+	if p.confirm {
+		p.confirm = false
+		if msg.String() != "y" {
+			m.status = "Delete cancelled"
+			return m, nil
+		}
+		s := p.list[p.cursor]
+		if err := m.sessions.Delete(s.ID); err != nil {
+			m.status = fmt.Sprintf("Could not delete the chat: %v", err)
+			return m, nil
+		}
+		if s.ID == c.session.ID {
+			// Clear the open chat too, or its next reply would save it again.
+			c.session, c.history, c.lastStat = session.New(c.model), nil, ""
+			c.render()
+		}
+		p.list = append(p.list[:p.cursor], p.list[p.cursor+1:]...)
+		p.cursor = max(min(p.cursor, len(p.list)-1), 0)
+		m.status = "Deleted the chat " + s.Title()
+		return m, nil
+	}
+
+	switch {
+	case msg.String() == "esc", key.Matches(msg, m.keys.Quit):
+		m.mode = modeChat
+		return m, c.input.Focus()
+	case len(p.list) == 0:
+	case key.Matches(msg, m.keys.Up):
+		p.cursor = max(p.cursor-1, 0)
+	case key.Matches(msg, m.keys.Down):
+		p.cursor = min(p.cursor+1, len(p.list)-1)
+	case msg.String() == "enter":
+		if c.streaming() {
+			m.status = "Wait for the reply to finish first"
+			return m, nil
+		}
+		s := p.list[p.cursor]
+		c.session, c.history, c.lastStat, c.err = s, s.Messages, "", nil
+		c.render()
+		m.mode = modeChat
+		m.status = "Resumed the chat " + s.Title()
+		return m, c.input.Focus()
+	case key.Matches(msg, m.keys.Export):
+		path, err := exportSession(p.list[p.cursor])
+		if err != nil {
+			m.status = fmt.Sprintf("Could not export the chat: %v", err)
+			break
+		}
+		m.status = "Exported to " + path
+	case key.Matches(msg, m.keys.Delete):
+		if c.streaming() && p.list[p.cursor].ID == c.session.ID {
+			m.status = "Wait for the reply to finish first"
+			return m, nil
+		}
+		p.confirm = true
+	}
+	return m, nil
+}
+
+func (m model) sessionsView() string {
+	p, c := m.sessionList, m.chat
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Chats with " + c.model))
+	b.WriteString("\n\n")
+	if len(p.list) == 0 {
+		b.WriteString(helpStyle.Render("No saved chats yet. Chats are saved after each reply."))
+		b.WriteString("\n")
+	} else {
+		tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  UPDATED\tMESSAGES\tCHAT")
+		for i, s := range p.list {
+			cursor := "  "
+			if i == p.cursor {
+				cursor = "> "
+			}
+			title := s.Title()
+			if s.ID == c.session.ID {
+				title += helpStyle.Render("  (open)")
+			}
+			fmt.Fprintf(tw, "%s%s\t%d\t%s\n", cursor, formatAge(s.Updated), len(s.Messages), title)
+		}
+		tw.Flush()
+	}
+
+	b.WriteString("\n")
+	if p.confirm {
+		b.WriteString(modalStyle.Render(fmt.Sprintf("Delete the chat %q?\n\ny: Delete  any other key: Cancel", p.list[p.cursor].Title())))
+	} else {
+		help := "Esc: Back"
+		if len(p.list) > 0 {
+			help = "Enter: Resume  " + helpLine(m.keys.Export, m.keys.Delete) + "  " + help
+		}
+		b.WriteString(helpStyle.Render(help))
+	}
+	b.WriteString(fmt.Sprintf("\n\nStatus: %s", m.status))
+	return b.String()
+}
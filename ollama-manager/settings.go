@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/service"
+)
+
+// settingsState is the screen for editing the environment variables the
+// Ollama server starts with.
+type settingsState struct {
+	values  map[string]string // as the service has them
+	edits   map[string]string // unsaved changes; "" removes a variable
+	err     error
+	loading bool
+	cursor  int
+	editing bool
+	input   textinput.Model
+	restart bool // saved; waiting for y to restart the service
+}
+
+// envMsg carries the service environment.
+type envMsg struct {
+	values map[string]string
+	err    error
+}
+
+// envSavedMsg reports the outcome of writing the edits.
+type envSavedMsg struct{ err error }
+
+func readEnv(name string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		values, err := service.Env(ctx, name)
+		return envMsg{values: values, err: err}
+	}
+}
+
+func writeEnv(name string, edits map[string]string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		return envSavedMsg{err: service.SetEnv(ctx, name, edits)}
+	}
+}
+
+func (m model) openSettings() (tea.Model, tea.Cmd) {
+	m.mode = modeSettings
+	if !m.client.Local() {
+		m.settings = &settingsState{err: fmt.Errorf("settings can only be edited for a local server")}
+		return m, nil
+	}
+	if m.settings != nil && len(m.settings.edits) > 0 {
+		return m, nil // keep unsaved edits
+	}
+	input := textinput.New()
+	input.CharLimit = 256
+	input.Width = 40
+	m.settings = &settingsState{edits: make(map[string]string), loading: true, input: input}
+	return m, readEnv(m.serviceName())
+}
+
+// value is the setting's pending or current value.
+func (s *settingsState) value(name string) string {
+	if v, ok := s.edits[name]; ok {
+		return v
+	}
+	return s.values[name]
+}
+
+func (m model) updateSettings(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := m.settings
+	if s.restart {
+		s.restart = false
+		if msg.String() != "y" {
+			m.status = "Saved; restart Ollama to apply"
+			return m, nil
+		}
+		return m.startService(service.Restart)
+	}
+	if s.editing {
+		switch msg.String() {
+		case "esc":
+			s.editing = false
+			s.input.Blur()
+		case "enter":
+			s.editing = false
+			s.input.Blur()
+			name := service.Settings[s.cursor].Name
+			v := strings.TrimSpace(s.input.Value())
+			if v == s.values[name] {
+				delete(s.edits, name)
+			} else {
+				s.edits[name] = v
+			}
+		default:
+			var cmd tea.Cmd
+			s.input, cmd = s.input.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	switch {
+	case msg.String() == "esc", key.Matches(msg, m.keys.Quit, m.keys.Settings):
+		m.mode = modeList
+	case s.values == nil || s.loading:
+		// Nothing to edit yet.
+	case key.Matches(msg, m.keys.Up):
+		s.cursor = max(s.cursor-1, 0)
+	case key.Matches(msg, m.keys.Down):
+		s.cursor = min(s.cursor+1, len(service.Settings)-1)
+	case msg.String() == "enter":
+		name := service.Settings[s.cursor].Name
+		s.editing = true
+		s.input.Prompt = name + "="
+		s.input.SetValue(s.value(name))
+		s.input.CursorEnd()
+		return m, s.input.Focus()
+	case key.Matches(msg, m.keys.Delete):
+		name := service.Settings[s.cursor].Name
+		if s.values[name] == "" {
+			delete(s.edits, name)
+		} else {
+			s.edits[name] = ""
+		}
+	case key.Matches(msg, m.keys.Refresh):
+		s.edits = make(map[string]string)
+		s.loading = true
+		return m, readEnv(m.serviceName())
+	case key.Matches(msg, m.keys.Save):
+		if len(s.edits) == 0 {
+			m.status = "No changes to save"
+			break
+		}
+		s.loading = true
+		m.status = "Saving settings..."
+		return m, writeEnv(m.serviceName(), s.edits)
+	}
+	return m, nil
+}
+
+func (m *model) finishSaveEnv(msg envSavedMsg) tea.Cmd {
+	s := m.settings
+	s.loading = false
+	if msg.err != nil {
+		m.status = fmt.Sprintf("Saving settings failed: %v", msg.err)
+		m.logError(m.status)
+		return nil
+	}
+	for k, v := range s.edits {
+		if v == "" {
+			delete(s.values, k)
+		} else {
+			s.values[k] = v
+		}
+	}
+	s.edits = make(map[string]string)
+	if m.server.status != nil {
+		s.restart = true
+		m.status = "Saved settings"
+		return nil
+	}
+	m.status = "Saved settings; restart Ollama to apply"
+	return nil
+}
+
+func (m model) settingsView() string {
+	s := m.settings
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Ollama Settings"))
+	if loc := service.EnvLocation(m.serviceName()); loc != "" && s.err == nil {
+		b.WriteString(helpStyle.Render("  " + loc))
+	}
+	b.WriteString("\n\n")
+
+	switch {
+	case s.err != nil:
+		b.WriteString(errorStyle.Render(s.err.Error()))
+		b.WriteString("\n")
+	case s.values == nil:
+		b.WriteString("Reading environment...\n")
+	default:
+		for i, st := range service.Settings {
+			cursor := "  "
+			if i == s.cursor {
+				cursor = cursorStyle.Render("> ")
+			}
+			value := s.value(st.Name)
+			if value == "" {
+				value = helpStyle.Render("(default)")
+			}
+			if _, changed := s.edits[st.Name]; changed {
+				value = warnStyle.Render(s.value(st.Name) + " *")
+				if s.value(st.Name) == "" {
+					value = warnStyle.Render("(removed) *")
+				}
+			}
+			b.WriteString(fmt.Sprintf("%s%-26s %s\n", cursor, st.Name, value))
+		}
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render(service.Settings[s.cursor].Help))
+		b.WriteString("\n")
+		if s.editing {
+			b.WriteString("\n")
+			b.WriteString(s.input.View())
+			b.WriteString("\n")
+		}
+		if s.loading {
+			b.WriteString("\nWorking...\n")
+		}
+	}
+
+	b.WriteString("\n")
+	switch {
+	case s.restart:
+		b.WriteString(modalStyle.Render("Restart the Ollama service now to apply the settings?\n\ny: Restart  any other key: Later"))
+	case s.editing:
+		b.WriteString(helpStyle.Render("Enter: Set (empty = default)  Esc: Cancel"))
+	default:
+		b.WriteString(helpStyle.Render("Enter: Edit  " + helpLine(relabel(m.keys.Delete, "Reset to default"),
+			relabel(m.keys.Save, "Save"), relabel(m.keys.Refresh, "Reload")) + "  Esc: Back"))
+	}
+	b.WriteString("\n")
+	if m.busy > 0 {
+		b.WriteString(fmt.Sprintf("\nStatus: %s %s", m.spinner.View(), m.status))
+	} else {
+		b.WriteString(fmt.Sprintf("\nStatus: %s", m.status))
+	}
+	return b.String()
+}
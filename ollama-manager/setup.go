@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"ollama-manager/internal/applog"
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/diag"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/service"
+)
+
+const (
+	// startWait is how long setup waits for a freshly started server to
+	// answer.
+	startWait = 15 * time.Second
+
+	downloadURL = "https://ollama.com/download"
+)
+
+// starter is a first model to pull, sized for the VRAM of the largest GPU.
+type starter struct {
+	vram uint64 // smallest VRAM it is suggested for
+	name string
+	size string // approximate download, for the prompt
+}
+
+// starters is ordered from largest to smallest; the last one also runs
+// acceptably on the CPU.
+var starters = []starter{
+	{22 << 30, "qwen3:32b", "~20 GB"},
+	{14 << 30, "qwen3:14b", "~9 GB"},
+	{7 << 30, "qwen3:8b", "~5 GB"},
+	{4 << 30, "qwen3:4b", "~2.5 GB"},
+	{0, "qwen3:1.7b", "~1.4 GB"},
+}
+
+func starterFor(vram uint64) starter {
+	for _, s := range starters {
+		if vram >= s.vram {
+			return s
+		}
+	}
+	return starters[len(starters)-1]
+}
+
+// prompter asks questions on the terminal.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func newPrompter() prompter {
+	return prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+}
+
+// ask prints question and returns the answer, or def for an empty one.
+func (p prompter) ask(question, def string) string {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	line, _ := p.in.ReadString('\n')
+	if line = strings.TrimSpace(line); line != "" {
+		return line
+	}
+	return def
+}
+
+// confirm asks a yes/no question, returning def for an empty answer.
+func (p prompter) confirm(question string, def bool) bool {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	fmt.Fprintf(p.out, "%s [%s] ", question, hint)
+	line, _ := p.in.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "":
+		return def
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+func cmdSetup(c *ollama.Client, args []string) error {
+	if len(args) > 0 {
+		return usageError{"usage: setup"}
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	return runSetup(newPrompter(), cfg, c.Host())
+}
+
+// firstRun reports whether the config file has never been written.
+func firstRun(cfg *config.Config) bool {
+	_, err := os.Stat(cfg.Path())
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// isTerminal reports whether f is an interactive terminal rather than a
+// pipe or file.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// offerSetup asks whether to run the wizard on the first start of the TUI.
+// Declining writes an empty config so the question isn't asked again.
+func offerSetup(cfg *config.Config, host string) error {
+	p := newPrompter()
+	fmt.Println("Welcome to Ollama Manager. No config file was found at " + cfg.Path() + ".")
+	if !p.confirm("Run the setup wizard?", true) {
+		return cfg.Save()
+	}
+	return runSetup(p, cfg, host)
+}
+
+// runSetup walks through checking the GPU, installing and reaching Ollama,
+// pulling a first model and writing the config file.
+func runSetup(p prompter, cfg *config.Config, host string) error {
+	slog.Info("setup", "host", host)
+
+	fmt.Println("\n[1/4] GPU")
+	vram := checkGPU()
+
+	fmt.Println("\n[2/4] Ollama")
+	host = p.ask("Ollama server", host)
+	c, err := clientFor(config.Profile{Host: host})
+	if err != nil {
+		return err
+	}
+	if c.Local() {
+		installOllama(p)
+	}
+	version, err := reachServer(p, c)
+	if err != nil {
+		fmt.Printf("  ✗ Not reachable at %s: %v\n", c.Host(), err)
+	} else {
+		fmt.Printf("  ✓ Ollama %s at %s\n", version, c.Host())
+	}
+
+	fmt.Println("\n[3/4] Starter model")
+	if err != nil {
+		fmt.Println("  Skipped: the server isn't reachable. Pull a model later with `p` in the manager.")
+	} else {
+		pullStarter(p, c, vram)
+	}
+
+	fmt.Println("\n[4/4] Config")
+	if c.Host() != ollama.DefaultHost {
+		cfg.Host = host
+	}
+	for {
+		ka := p.ask("Keep models loaded for (10m, 1h, -1 = forever; empty = server default)", cfg.KeepAlive)
+		if _, err := ollama.ParseKeepAlive(ka); ka != "" && err != nil {
+			fmt.Printf("  %v\n", err)
+			continue
+		}
+		cfg.KeepAlive = ka
+		break
+	}
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("save config: %w", err)
+	}
+	fmt.Printf("  ✓ Wrote %s\n\nSetup complete.\n", cfg.Path())
+	return nil
+}
+
+// checkGPU reports each NVIDIA GPU with its driver and compute capability
+// against what Ollama needs, returning the VRAM of the largest one.
+func checkGPU() uint64 {
+	var r diag.Report
+	r.CheckSystem()
+	printFindings(r.Findings)
+	var vram uint64
+	for _, g := range r.GPUs {
+		vram = max(vram, g.VRAM)
+	}
+	return vram
+}
+
+// wingetNoUpgrade is the exit code of `winget upgrade` when the installed
+// version is already the latest.
+const wingetNoUpgrade = 0x8A15002B
+
+// wingetCommand runs verb ("install" or "upgrade") on Ollama's winget
+// package, or is nil when winget isn't available.
+func wingetCommand(verb string) []string {
+	if _, err := exec.LookPath("winget"); err != nil {
+		return nil
+	}
+	return []string{"winget", verb, "--id", "Ollama.Ollama", "-e",
+		"--accept-source-agreements", "--accept-package-agreements"}
+}
+
+// installCommand is the official way to install Ollama on this platform and
+// how to show it, or nil where it has to be downloaded by hand.
+func installCommand() (args []string, shown string) {
+	switch runtime.GOOS {
+	case "windows":
+		if args = wingetCommand("install"); args == nil {
+			return nil, ""
+		}
+		return args, strings.Join(args, " ")
+	case "linux":
+		script := "curl -fsSL https://ollama.com/install.sh | sh"
+		return []string{"sh", "-c", script}, script
+	}
+	return nil, ""
+}
+
+// installOllama offers to install the ollama CLI and server when they are
+// missing, and on Windows to upgrade them through winget when they aren't.
+func installOllama(p prompter) {
+	if path := ollama.Binary(); filepath.IsAbs(path) {
+		fmt.Printf("  ✓ Installed at %s\n", path)
+		if runtime.GOOS == "windows" {
+			upgradeOllama(p)
+		}
+		return
+	}
+	fmt.Println("  ✗ The ollama command was not found.")
+	args, shown := installCommand()
+	if args == nil {
+		fmt.Printf("    Download it from %s, then run setup again.\n", downloadURL)
+		return
+	}
+	if !p.confirm("  Install it now with `"+shown+"`?", true) {
+		return
+	}
+	start := time.Now()
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err := cmd.Run()
+	applog.Command(slog.LevelInfo, start, args, err)
+	if err != nil {
+		fmt.Printf("  ✗ Install failed: %v\n    Install it from %s instead.\n", err, downloadURL)
+		return
+	}
+	fmt.Println("  ✓ Installed. A new terminal may be needed before `ollama` is on PATH.")
+}
+
+// upgradeOllama offers to bring a winget install up to date.
+func upgradeOllama(p prompter) {
+	args := wingetCommand("upgrade")
+	if args == nil || !p.confirm("  Upgrade it with winget if a newer version is out?", false) {
+		return
+	}
+	start := time.Now()
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err := cmd.Run()
+	applog.Command(slog.LevelInfo, start, args, err)
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr) && uint32(exitErr.ExitCode()) == wingetNoUpgrade:
+		fmt.Println("  ✓ Already up to date.")
+	case err != nil:
+		fmt.Printf("  ✗ Upgrade failed: %v\n", err)
+	default:
+		fmt.Println("  ✓ Upgraded. Restart the Ollama app or service to run the new version.")
+	}
+}
+
+// reachServer checks the server answers, offering to start the local
+// service when it doesn't.
+func reachServer(p prompter, c *ollama.Client) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	version, err := c.Version(ctx)
+	cancel()
+	if err == nil || !ollama.IsUnreachable(err) || !c.Local() {
+		return version, err
+	}
+
+	name := service.DefaultName()
+	if !p.confirm("  The server isn't running. Start the "+name+" service?", true) {
+		fmt.Println("    Start it with `ollama serve` or the Ollama app.")
+		return "", err
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), apiTimeout)
+	err = service.Control(ctx, name, service.Start)
+	cancel()
+	if err != nil {
+		fmt.Printf("  ✗ %v\n    Start it with `ollama serve` or the Ollama app.\n", err)
+		return "", err
+	}
+	deadline := time.Now().Add(startWait)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		version, err = c.Version(ctx)
+		cancel()
+		if err == nil || time.Now().After(deadline) {
+			return version, err
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// pullStarter offers a model or a preset sized for vram, skipping the
+// question when the server already has models.
+func pullStarter(p prompter, c *ollama.Client, vram uint64) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	models, err := c.List(ctx)
+	cancel()
+	if err == nil && len(models) > 0 {
+		fmt.Printf("  ✓ %d models already installed\n", len(models))
+		return
+	}
+
+	s := starterFor(vram)
+	fit := "for the CPU"
+	if vram > 0 {
+		fit = "for " + formatVRAM(vram) + " of VRAM"
+	}
+	fmt.Printf("  Suggested %s: %s (%s download)\n", fit, s.name, s.size)
+	fmt.Println("  Or a preset, sized for this card:")
+	for i, pr := range presets {
+		fmt.Printf("    %d. %-20s %s\n", i+1, pr.name, presetSummary(pr.expand(vram)))
+	}
+	answer := p.ask("  Model or preset number to pull (\"-\" to skip)", s.name)
+	if answer == "-" {
+		return
+	}
+	names := []string{answer}
+	if i, err := strconv.Atoi(answer); err == nil && i >= 1 && i <= len(presets) {
+		names = nil
+		for _, pm := range presets[i-1].expand(vram) {
+			names = append(names, pm.name)
+		}
+	}
+
+	for _, name := range names {
+		start := time.Now()
+		if err := pullPrinting(c, name); err != nil {
+			fmt.Printf("  ✗ Pull of %s failed: %v\n", name, err)
+			continue
+		}
+		slog.Info("setup", "pulled", name, "took", time.Since(start).Round(time.Second))
+		fmt.Printf("  ✓ Pulled %s\n", name)
+	}
+}
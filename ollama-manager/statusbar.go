@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// statusBar is the last line of the model list: the host, the server's
+// version, how many models are loaded, free VRAM and when the list was last
+// refreshed, then the status message. What isn't known, such as the VRAM
+// of a remote server, is left out. The message moves to a line of its own
+// when both don't fit.
+func (m model) statusBar() string {
+	segs := []string{m.hostLabel()}
+	if label := m.serverLabel(); label != "" {
+		segs = append(segs, label)
+	}
+	segs = append(segs, fmt.Sprintf("%d loaded", len(m.running)))
+	if label := m.vramLabel(); label != "" {
+		segs = append(segs, label)
+	}
+	for i, s := range segs {
+		segs[i] = statusStyle.Render(" " + s + " ")
+	}
+	if !m.refreshed.IsZero() {
+		at := " refreshed " + m.refreshed.Format("15:04:05") + " "
+		if m.lastRefreshErr != "" {
+			segs = append(segs, statusStyle.Foreground(activeTheme.err).Render(at+"(failing) "))
+		} else {
+			segs = append(segs, statusStyle.Render(at))
+		}
+	}
+	bar := strings.Join(segs, statusStyle.Render("│"))
+
+	msg := m.status
+	if m.busy > 0 {
+		msg = m.spinner.View() + " " + msg
+	}
+	if m.width > 0 && lipgloss.Width(bar)+1+lipgloss.Width(msg) > m.width {
+		return bar + "\n" + msg
+	}
+	return bar + " " + msg
+}
+
+// hostLabel names the server the list is of, with its profile when there
+// are several.
+func (m model) hostLabel() string {
+	if len(m.hosts) <= 1 {
+		return m.client.Host()
+	}
+	return m.hosts[m.host].Name + " · " + m.client.Host()
+}
+
+// vramLabel shows the free VRAM, per GPU when there are several since a
+// model has to fit on the cards it lands on rather than in their sum. The
+// GPU tab has the details.
+func (m model) vramLabel() string {
+	if m.gpuErr != nil || len(m.gpus) == 0 {
+		return ""
+	}
+	parts := make([]string, len(m.gpus))
+	for i, d := range m.gpus {
+		parts[i] = fmt.Sprintf("%s / %s", formatVRAM(d.MemoryFree()), formatVRAM(d.MemoryTotal))
+		if len(m.gpus) > 1 {
+			parts[i] = fmt.Sprintf("%d: %s", d.Index, parts[i])
+		}
+	}
+	return "VRAM free " + strings.Join(parts, "  ")
+}
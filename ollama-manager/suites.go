@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/report"
+	"ollama-manager/internal/suite"
+)
+
+// suitesDir holds the benchmark suites, under the config dir, since they
+// are written by hand like the config.
+const suitesDir = "suites"
+
+// suitesState is the list of suites and the last run of one.
+type suitesState struct {
+	list    []*suite.Suite
+	loadErr error // files that failed to load
+	cursor  int
+
+	// run is the suite running, nil when none is; results are the last
+	// run's, one per model.
+	run     *suiteRun
+	results []*suite.Result
+	failed  []string // models the last run couldn't test at all
+}
+
+// suiteRun tracks a suite running in the background.
+type suiteRun struct {
+	suite   string
+	step    string
+	started time.Time
+	updates chan tea.Msg
+	cancel  context.CancelFunc
+}
+
+// suiteStepMsg reports the step a running suite has reached.
+type suiteStepMsg string
+
+// suiteDoneMsg carries the results of a suite run; errs holds the models
+// that couldn't be tested, by name.
+type suiteDoneMsg struct {
+	results []*suite.Result
+	errs    map[string]error
+	err     error
+}
+
+// suitesPath returns the suites directory.
+func suitesPath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, suitesDir), nil
+}
+
+// loadSuites reads every suite in the suites directory.
+func loadSuites() ([]*suite.Suite, error) {
+	dir, err := suitesPath()
+	if err != nil {
+		return nil, err
+	}
+	return suite.LoadDir(dir)
+}
+
+// runSuite runs s against each model in turn. A model that fails to load
+// is skipped; cancelling stops the whole run.
+func runSuite(ctx context.Context, c *ollama.Client, cfg *config.Config, s *suite.Suite, models []string, progress func(string)) ([]*suite.Result, map[string]error, error) {
+	var results []*suite.Result
+	errs := make(map[string]error)
+	for _, name := range models {
+		res, err := suite.Run(ctx, c, name, s, optionsMap(cfg.ModelOptions[name]), progress)
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		if err != nil {
+			errs[name] = err
+			continue
+		}
+		results = append(results, res)
+	}
+	return results, errs, nil
+}
+
+func (m model) openSuites() (tea.Model, tea.Cmd) {
+	m.mode = modeSuites
+	if m.suites == nil {
+		m.suites = &suitesState{}
+	}
+	if m.suites.run == nil {
+		m.suites.list, m.suites.loadErr = loadSuites()
+		m.suites.cursor = min(m.suites.cursor, max(len(m.suites.list)-1, 0))
+	}
+	return m, nil
+}
+
+// startSuite runs the highlighted suite on the selected models, or the one
+// under the cursor.
+func (m model) startSuite() (tea.Model, tea.Cmd) {
+	st := m.suites
+	if st.run != nil || len(st.list) == 0 {
+		return m, nil
+	}
+	models := names(m.targets())
+	if len(models) == 0 {
+		m.status = "No model to run the suite on"
+		return m, nil
+	}
+	s := st.list[st.cursor]
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &suiteRun{suite: s.Name, step: "starting", started: time.Now(), updates: make(chan tea.Msg, 16), cancel: cancel}
+	c, cfg := m.client, m.cfg
+	go func() {
+		defer close(run.updates)
+		results, errs, err := runSuite(ctx, c, cfg, s, models, func(step string) {
+			run.updates <- suiteStepMsg(step)
+		})
+		run.updates <- suiteDoneMsg{results: results, errs: errs, err: err}
+	}()
+	st.run = run
+	m.status = fmt.Sprintf("Running %s on %d models", s.Name, len(models))
+	return m, tea.Batch(listen(run.updates), m.startBusy())
+}
+
+func (m *model) finishSuite(msg suiteDoneMsg) tea.Cmd {
+	st := m.suites
+	name, started := st.run.suite, st.run.started
+	st.run = nil
+	m.busy = max(m.busy-1, 0)
+	if errors.Is(msg.err, context.Canceled) {
+		m.status = "Suite " + name + " cancelled"
+		return nil
+	}
+	st.results, st.failed = msg.results, nil
+	for model, err := range msg.errs {
+		st.failed = append(st.failed, model)
+		m.logError(fmt.Sprintf("Suite %s on %s: %v", name, model, err))
+	}
+	passed, total := 0, 0
+	for _, r := range msg.results {
+		passed += r.Passed()
+		total += len(r.Cases)
+	}
+	m.status = fmt.Sprintf("Suite %s: %d of %d prompts passed across %d models", name, passed, total, len(msg.results))
+	if len(msg.errs) > 0 {
+		m.status += fmt.Sprintf(", %d couldn't be tested (E: show errors)", len(msg.errs))
+	}
+	return tea.Batch(m.refresh(), m.notify("bench", started, "%s", m.status))
+}
+
+func (m model) updateSuites(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	st := m.suites
+	if m.exportAsk {
+		heading, tables, raw := suiteExport(st)
+		return m, m.answerExport(msg.String(), "suite-"+st.results[0].Suite, heading, tables, raw)
+	}
+	switch s := msg.String(); {
+	case s == "esc", key.Matches(msg, m.keys.Quit):
+		m.mode = modeList
+	case key.Matches(msg, m.keys.Up):
+		st.cursor = max(st.cursor-1, 0)
+	case key.Matches(msg, m.keys.Down):
+		st.cursor = min(st.cursor+1, max(len(st.list)-1, 0))
+	case key.Matches(msg, m.keys.Cancel):
+		if st.run != nil {
+			st.run.cancel()
+		}
+	case s == "enter":
+		return m.startSuite()
+	case key.Matches(msg, m.keys.Export):
+		if st.run != nil || len(st.results) == 0 {
+			m.status = "Nothing to export yet"
+			break
+		}
+		m.exportAsk = true
+	}
+	return m, nil
+}
+
+func (m model) suitesView() string {
+	st := m.suites
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Benchmark Suites"))
+	b.WriteString("\n\n")
+
+	dir, _ := suitesPath()
+	if len(st.list) == 0 {
+		b.WriteString(helpStyle.Render("No suites yet. Add YAML files of prompts and expected keywords to " + dir + "."))
+		b.WriteString("\n")
+	}
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	for i, s := range st.list {
+		cursor := "  "
+		if i == st.cursor {
+			cursor = cursorStyle.Render("> ")
+		}
+		fmt.Fprintf(tw, "%s%s\t%d prompts, %d checks\t%s\n", cursor, s.Name, len(s.Prompts), s.Checks(), helpStyle.Render(s.Description))
+	}
+	tw.Flush()
+	if st.loadErr != nil {
+		b.WriteString("\n")
+		b.WriteString(errorStyle.Render(st.loadErr.Error()))
+		b.WriteString("\n")
+	}
+
+	if st.run != nil {
+		b.WriteString(fmt.Sprintf("\n%s %s: %s\n", m.spinner.View(), st.run.suite, st.run.step))
+	} else if len(st.results) > 0 || len(st.failed) > 0 {
+		b.WriteString("\n")
+		b.WriteString(report.Text(suiteReport(st.results, st.failed)))
+		if fails := suiteFailures(st.results); fails != "" {
+			b.WriteString("\n")
+			b.WriteString(warnStyle.Render(fails))
+		}
+	}
+
+	b.WriteString("\n")
+	targets := m.targets()
+	on := "the selected model"
+	if len(targets) > 1 {
+		on = fmt.Sprintf("the %d selected models", len(targets))
+	}
+	help := "Enter: Run on " + on + "  Esc: Back"
+	switch {
+	case st.run != nil:
+		help = helpLine(m.keys.Cancel) + "  Esc: Back (keeps running)"
+	case len(st.results) > 0:
+		help = "Enter: Run on " + on + "  " + helpLine(m.keys.Export) + "  Esc: Back"
+	}
+	if m.exportAsk {
+		b.WriteString(warnStyle.Render(exportPrompt))
+	} else {
+		b.WriteString(helpStyle.Render(helpLine(m.keys.Up, m.keys.Down) + "  " + help))
+	}
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("\nStatus: %s", m.status))
+	return b.String()
+}
+
+// suiteReport summarises a run, one row per model.
+func suiteReport(results []*suite.Result, failed []string) report.Table {
+	t := report.Table{Header: []string{"MODEL", "PASSED", "GEN t/s", "TTFT", "TIME"}}
+	if len(results) > 0 {
+		t.Title = "Suite " + results[0].Suite
+	}
+	for _, r := range results {
+		t.Rows = append(t.Rows, []string{r.Model, fmt.Sprintf("%d/%d", r.Passed(), len(r.Cases)), fmt.Sprintf("%.1f", r.GenTPS()),
+			r.TTFT().Round(time.Millisecond).String(), r.Elapsed.Round(100 * time.Millisecond).String()})
+	}
+	for _, name := range failed {
+		t.Rows = append(t.Rows, []string{name, "-", "-", "-", "not tested"})
+	}
+	return t
+}
+
+// suiteFailures lists each prompt that failed, with why.
+func suiteFailures(results []*suite.Result) string {
+	var b strings.Builder
+	for _, r := range results {
+		for _, c := range r.Cases {
+			switch {
+			case c.Err != "":
+				fmt.Fprintf(&b, "%s · %s: %s\n", r.Model, c.Prompt, c.Err)
+			case !c.Passed:
+				fmt.Fprintf(&b, "%s · %s: missing %s\n", r.Model, c.Prompt, strings.Join(c.Missing, ", "))
+			}
+		}
+	}
+	return b.String()
+}
+
+func cmdSuite(c *ollama.Client, args []string) error {
+	asJSON, rest, err := parseJSONFlag("suite", args)
+	if err != nil {
+		return err
+	}
+	suites, loadErr := loadSuites()
+	if len(rest) == 0 {
+		if loadErr != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", loadErr)
+		}
+		if len(suites) == 0 {
+			dir, _ := suitesPath()
+			fmt.Printf("No suites; add them to %s\n", dir)
+		}
+		for _, s := range suites {
+			fmt.Printf("%-16s %d prompts, %d checks  %s\n", s.Name, len(s.Prompts), s.Checks(), s.Description)
+		}
+		return nil
+	}
+	if len(rest) < 2 {
+		return usageError{"usage: suite [--json] [<suite> <model> [model...]]"}
+	}
+	var s *suite.Suite
+	for _, cand := range suites {
+		if cand.Name == rest[0] {
+			s = cand
+		}
+	}
+	if s == nil {
+		if s, err = suite.Load(rest[0]); err != nil {
+			if loadErr != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", loadErr)
+			}
+			return usageError{fmt.Sprintf("no suite named %q, nor a suite file: %v", rest[0], err)}
+		}
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	results, errs, err := runSuite(context.Background(), c, cfg, s, rest[1:], func(step string) {
+		fmt.Fprintf(os.Stderr, "%s\n", step)
+	})
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		if err := writeJSON(results); err != nil {
+			return err
+		}
+	} else {
+		var failed []string
+		for _, name := range rest[1:] {
+			if err, ok := errs[name]; ok {
+				failed = append(failed, name)
+				fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			}
+		}
+		fmt.Print(report.Text(suiteReport(results, failed)))
+		fmt.Print(suiteFailures(results))
+	}
+	passed, total := 0, 0
+	for _, r := range results {
+		passed += r.Passed()
+		total += len(r.Cases)
+	}
+	if passed < total || len(errs) > 0 {
+		return fmt.Errorf("%d of %d prompts passed, %d models not tested", passed, total, len(errs))
+	}
+	return nil
+}
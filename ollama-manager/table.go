@@ -0,0 +1,337 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+
+	"ollama-manager/internal/ollama"
+)
+
+// sortKey is a column the model table can be ordered by.
+type sortKey int
+
+const (
+	sortName sortKey = iota
+	sortSize
+	sortModified
+	sortLoaded
+	sortFamily
+	sortUsed
+	numSortKeys
+)
+
+func (k sortKey) String() string {
+	return [...]string{"name", "size", "modified", "loaded", "family", "last used"}[k]
+}
+
+// descending reports the natural direction for a key: biggest, newest,
+// loaded and most recently used models first; names and families
+// alphabetically.
+func (k sortKey) descending() bool {
+	return k == sortSize || k == sortModified || k == sortLoaded || k == sortUsed
+}
+
+func newModelTable() table.Model {
+	t := table.New(table.WithHeight(10))
+	t.SetStyles(tableStyles())
+	return t
+}
+
+// sortVisible orders the visible rows by the active sort key, favorites
+// first. While a filter is active the default name order yields to fuzzy
+// match rank.
+func (m *model) sortVisible() {
+	if m.sortBy != sortName || !m.filtering() {
+		m.sortByKey()
+	}
+	sort.SliceStable(m.visible, func(i, j int) bool {
+		return m.cfg.IsFavorite(m.visible[i].Name) && !m.cfg.IsFavorite(m.visible[j].Name)
+	})
+}
+
+func (m *model) sortByKey() {
+	host := m.client.Host()
+	less := func(a, b ollama.Model) bool {
+		switch m.sortBy {
+		case sortSize:
+			return a.Size < b.Size
+		case sortModified:
+			return a.ModifiedAt.Before(b.ModifiedAt)
+		case sortLoaded:
+			return !m.loaded[a.Name] && m.loaded[b.Name]
+		case sortFamily:
+			if a.Details.Family != b.Details.Family {
+				return a.Details.Family < b.Details.Family
+			}
+		case sortUsed:
+			ta, tb := m.usage.Last(host, a.Name), m.usage.Last(host, b.Name)
+			if !ta.Equal(tb) {
+				return ta.Before(tb)
+			}
+		}
+		return a.Name < b.Name
+	}
+	desc := m.sortBy.descending() != m.sortReverse
+	sort.SliceStable(m.visible, func(i, j int) bool {
+		if desc {
+			return less(m.visible[j], m.visible[i])
+		}
+		return less(m.visible[i], m.visible[j])
+	})
+}
+
+// cycleSort advances to the next sort column.
+func (m *model) cycleSort() {
+	m.sortBy = (m.sortBy + 1) % numSortKeys
+	m.sortReverse = false
+	m.relist()
+	m.status = "Sorted by " + m.sortHint()
+}
+
+// relist recomputes the visible rows, keeping the cursor on the same model
+// when it is still visible.
+func (m *model) relist() {
+	name := ""
+	if cur, ok := m.current(); ok {
+		name = cur.Name
+	} else if f, ok := m.currentFamily(); ok {
+		name = f.models[0].Name
+	}
+	m.applyFilter()
+	if i, ok := m.rowOf(name); ok {
+		m.setCursor(i)
+	}
+}
+
+// syncTable pushes the visible models into the table component.
+func (m *model) syncTable() {
+	m.buildRows()
+	names := make([]string, len(m.rows))
+	nameWidth := len("NAME")
+	for i, r := range m.rows {
+		switch {
+		case r.model < 0:
+			names[i] = m.familyRow(r.family)[1]
+		case r.family != nil:
+			names[i] = "  " + m.displayName(m.visible[r.model].Name)
+		default:
+			names[i] = m.displayName(m.visible[r.model].Name)
+		}
+		nameWidth = max(nameWidth, lipgloss.Width(names[i]))
+	}
+	const fixed = 3 + 8 + 8 + 8 + 9 + 10 + 18 + 12 + 15 + 2*10 + 2 // other columns, padding and scrollbar
+	if m.width > 0 {
+		nameWidth = min(nameWidth, max(m.width-fixed, 20))
+	}
+
+	title := func(key sortKey, s string) string {
+		if m.sortBy != key {
+			return s
+		}
+		if m.sortBy.descending() != m.sortReverse {
+			return s + " ▼"
+		}
+		return s + " ▲"
+	}
+	m.table.SetColumns([]table.Column{
+		{Title: "", Width: 3},
+		{Title: title(sortName, "NAME"), Width: nameWidth},
+		{Title: title(sortSize, "SIZE"), Width: 8},
+		{Title: "QUANT", Width: 8},
+		{Title: title(sortFamily, "FAMILY"), Width: 8},
+		{Title: title(sortModified, "MODIFIED"), Width: 9},
+		{Title: title(sortUsed, "LAST USED"), Width: 10},
+		{Title: "EST. VRAM", Width: 18},
+		{Title: title(sortLoaded, "LOADED"), Width: 12},
+		{Title: "PROCESSOR", Width: 15},
+	})
+
+	// Table cells are truncated by width, so the spinner goes in unstyled.
+	spin := m.spinner
+	spin.Style = lipgloss.NewStyle()
+	frame := spin.View()
+
+	rows := make([]table.Row, len(m.rows))
+	for i, r := range m.rows {
+		if r.model < 0 {
+			rows[i] = m.familyRow(r.family)
+			continue
+		}
+		mdl := m.visible[r.model]
+		check := "[ ]"
+		if m.selected[mdl.Name] {
+			check = "[x]"
+		}
+		loaded := ""
+		_, loading := m.loading[mdl.Name]
+		switch {
+		case loading:
+			loaded = frame + "loading…"
+		case m.loaded[mdl.Name]:
+			loaded = "●" + m.expiryLabel(mdl.Name)
+		}
+		size := ""
+		if mdl.Size > 0 {
+			size = formatBytes(uint64(mdl.Size))
+		}
+		rows[i] = table.Row{
+			check,
+			names[i],
+			size,
+			mdl.Details.QuantizationLevel,
+			mdl.Details.Family,
+			formatAge(mdl.ModifiedAt),
+			lastUsedText(m.usage.Last(m.client.Host(), mdl.Name)),
+			m.fitText(mdl),
+			loaded,
+			m.processorText(mdl.Name),
+		}
+	}
+	m.table.SetRows(rows)
+	// The table holds every row; tableView shows the window at listTop, so
+	// the offset is known when mapping mouse clicks to rows.
+	m.table.SetHeight(len(rows) + tableHeaderLines)
+	m.scrollList()
+}
+
+// tableHeaderLines is the height of the table header: titles and a rule.
+const tableHeaderLines = 2
+
+// listTitleLines counts the lines above the table: the tab bar, the title,
+// the filter when shown and a blank line.
+func (m model) listTitleLines() int {
+	if m.mode == modeFilter || m.filtering() {
+		return tabBarLines + 3
+	}
+	return tabBarLines + 2
+}
+
+// listRows is how many model rows fit between the title and the footer.
+// When they don't all fit, a line under them shows the position.
+func (m model) listRows() int {
+	if m.height == 0 {
+		return 18
+	}
+	rows := m.height - m.listTitleLines() - tableHeaderLines - lipgloss.Height(m.listFooter())
+	if len(m.rows) > rows {
+		rows--
+	}
+	return max(rows, 3)
+}
+
+// windowTop is the first row to show: listTop, moved just enough to keep the
+// cursor on screen when the space for rows has shrunk.
+func (m model) windowTop() int {
+	rows := m.listRows()
+	top := m.listTop
+	c := m.table.Cursor()
+	if c < top {
+		top = c
+	}
+	if c >= top+rows {
+		top = c - rows + 1
+	}
+	return max(min(top, len(m.rows)-rows), 0)
+}
+
+// scrollList moves the window of visible rows to follow the cursor.
+func (m *model) scrollList() {
+	m.listTop = m.windowTop()
+}
+
+// moveCursor moves the cursor n rows down, or up for negative n.
+func (m *model) moveCursor(n int) {
+	if n < 0 {
+		m.table.MoveUp(-n)
+	} else {
+		m.table.MoveDown(n)
+	}
+	m.scrollList()
+}
+
+func (m *model) setCursor(i int) {
+	m.table.SetCursor(i)
+	m.scrollList()
+}
+
+// tableView renders the table header and the window of rows, with a
+// scrollbar and position line when the rows don't all fit.
+func (m model) tableView() string {
+	lines := strings.Split(m.table.View(), "\n")
+	if len(lines) <= tableHeaderLines {
+		return strings.Join(lines, "\n")
+	}
+	body := lines[tableHeaderLines:]
+	rows := m.listRows()
+	top := min(m.windowTop(), len(body))
+	end := min(top+rows, len(body))
+	out := append(lines[:tableHeaderLines:tableHeaderLines], body[top:end]...)
+	if len(body) <= rows {
+		return strings.Join(out, "\n")
+	}
+
+	bar := scrollbar(rows, len(body), top)
+	for i := range bar {
+		if i < end-top {
+			out[tableHeaderLines+i] += " " + bar[i]
+		}
+	}
+	out = append(out, helpStyle.Render(fmt.Sprintf("  %d–%d of %d", top+1, end, len(body))))
+	return strings.Join(out, "\n")
+}
+
+// scrollbar draws a track of height rows with a thumb sized and placed by
+// the share of total rows shown from top.
+func scrollbar(rows, total, top int) []string {
+	thumb := max(rows*rows/total, 1)
+	pos := 0
+	if total > rows {
+		pos = top * (rows - thumb) / (total - rows)
+	}
+	bar := make([]string, rows)
+	for i := range bar {
+		if i >= pos && i < pos+thumb {
+			bar[i] = cursorStyle.Render("┃")
+		} else {
+			bar[i] = helpStyle.Render("│")
+		}
+	}
+	return bar
+}
+
+// formatAge renders how long ago t was, in the style of `ollama list`.
+func formatAge(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 14*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	case d < 60*24*time.Hour:
+		return fmt.Sprintf("%dw ago", int(d.Hours()/24/7))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo ago", int(d.Hours()/24/30))
+	}
+	return fmt.Sprintf("%dy ago", int(d.Hours()/24/365))
+}
+
+// sortHint describes the active sort, e.g. "size (desc)".
+func (m model) sortHint() string {
+	dir := "asc"
+	if m.sortBy.descending() != m.sortReverse {
+		dir = "desc"
+	}
+	return fmt.Sprintf("%s (%s)", m.sortBy, dir)
+}
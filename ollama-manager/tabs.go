@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tab is one of the screens listed in the tab bar. Each keeps its own state
+// (procs, server, logs, chat, activity, traffic) while the host and the refresh tick
+// stay shared, so switching tabs doesn't lose a conversation or scroll
+// position.
+type tab int
+
+const (
+	tabModels tab = iota
+	tabGPU
+	tabServer
+	tabLogs
+	tabChat
+	tabActivity
+	tabTraffic
+	numTabs
+)
+
+func (t tab) String() string {
+	return [...]string{"Models", "GPU", "Server", "Logs", "Chat", "Activity", "Traffic"}[t]
+}
+
+// tabBarLines is the height of the tab bar drawn above every tab.
+const tabBarLines = 1
+
+// currentTab maps the mode to its tab. Prompts and dialogs over the model
+// list belong to the Models tab; full screens opened from it, such as
+// details or benchmarks, have no tab bar.
+func (m model) currentTab() (tab, bool) {
+	switch m.mode {
+	case modeList, modeFilter, modePullInput, modeKeepAliveInput, modeCopyInput, modeAliasInput, modeNoteInput,
+		modeImportInput, modeConfirmDelete, modeConfirmRestore, modeSnippet, modeLoadOptions, modeGenOptions:
+		return tabModels, true
+	case modeProcs:
+		return tabGPU, true
+	case modeServer:
+		return tabServer, true
+	case modeLog:
+		return tabLogs, true
+	case modeChat, modeEmbed:
+		return tabChat, true
+	case modeActivity:
+		return tabActivity, true
+	case modeTraffic:
+		return tabTraffic, true
+	}
+	return 0, false
+}
+
+// tabKey reports the tab msg switches to. Keys only switch when the tab
+// isn't waiting for input, and number keys not at all in chat, where they
+// are typed into the message or the texts to embed.
+func (m model) tabKey(msg tea.KeyMsg) (tab, bool) {
+	cur, ok := m.currentTab()
+	if !ok {
+		return 0, false
+	}
+	switch m.mode {
+	case modeList, modeLog, modeChat, modeEmbed, modeActivity:
+	case modeTraffic:
+		if m.traffic.keys.busy() {
+			return 0, false
+		}
+	case modeProcs:
+		if m.procs.kill != nil {
+			return 0, false
+		}
+	case modeServer:
+		if m.server.confirm != "" {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	switch {
+	case key.Matches(msg, m.keys.NextTab):
+		return (cur + 1) % numTabs, true
+	case key.Matches(msg, m.keys.PrevTab):
+		return (cur + numTabs - 1) % numTabs, true
+	}
+	if s := msg.String(); m.mode != modeChat && m.mode != modeEmbed && len(s) == 1 && s[0] >= '1' && s[0] < '1'+byte(numTabs) {
+		return tab(s[0] - '1'), true
+	}
+	return 0, false
+}
+
+// switchTab opens t. Chat resumes the open conversation or embeddings
+// tester, or starts one with the model under the cursor.
+func (m model) switchTab(t tab) (tea.Model, tea.Cmd) {
+	if m.chat != nil {
+		m.chat.input.Blur()
+	}
+	if m.embed != nil {
+		m.embed.texts[m.embed.focus].Blur()
+	}
+	switch t {
+	case tabGPU:
+		return m.openProcs()
+	case tabServer:
+		return m.openServer()
+	case tabLogs:
+		return m.openLogView()
+	case tabActivity:
+		return m.openActivity()
+	case tabTraffic:
+		return m.openTraffic()
+	case tabChat:
+		if m.chat != nil {
+			m.mode = modeChat
+			return m, m.chat.input.Focus()
+		}
+		if m.embed != nil {
+			return m.openEmbed(m.embed.model)
+		}
+		if cur, ok := m.current(); ok {
+			if m.arch[archKey(cur)].Embedding {
+				return m.openEmbed(cur.Name)
+			}
+			return m.openChat(cur.Name)
+		}
+		m.mode = modeList
+		m.status = "No model to chat with"
+		return m, nil
+	}
+	m.mode = modeList
+	return m, nil
+}
+
+// tabLabels are the tab bar entries, e.g. " 1 Models ".
+func tabLabels() []string {
+	labels := make([]string, numTabs)
+	for t := range labels {
+		labels[t] = fmt.Sprintf(" %d %s ", t+1, tab(t))
+	}
+	return labels
+}
+
+func (m model) tabBar() string {
+	cur, _ := m.currentTab()
+	labels := tabLabels()
+	for t, l := range labels {
+		if tab(t) == cur {
+			labels[t] = tabStyle.Render(l)
+		} else {
+			labels[t] = helpStyle.Render(l)
+		}
+	}
+	return strings.Join(labels, helpStyle.Render("│"))
+}
+
+// tabAt finds the tab under column x of the tab bar.
+func tabAt(x int) (tab, bool) {
+	pos := 0
+	for t, l := range tabLabels() {
+		w := lipgloss.Width(l)
+		if x >= pos && x < pos+w {
+			return tab(t), true
+		}
+		pos += w + 1 // the separator
+	}
+	return 0, false
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"ollama-manager/internal/ollama"
+)
+
+// specialToken matches the turn markers templates wrap messages in, such as
+// <|start_header_id|>, <start_of_turn>, [INST] and <<SYS>>.
+var specialToken = regexp.MustCompile(`<\|[^|<>\s]+\|>|</?(?:s|bos|eos|start_of_turn|end_of_turn)>|\[/?(?:INST|SYS)\]|<</?SYS>>`)
+
+// sampleConversation is previewed when the chat is still empty.
+var sampleConversation = []ollama.Message{
+	{Role: "user", Content: "What is the capital of France?"},
+	{Role: "assistant", Content: "Paris."},
+	{Role: "user", Content: "And of Italy?"},
+}
+
+// templateState previews the prompt the server builds from a conversation
+// with the model's template, to see where the system prompt ends up.
+type templateState struct {
+	model   string
+	history []ollama.Message
+	info    *ollama.ShowResponse
+	err     error
+	system  textinput.Model
+	source  bool // show the template itself instead of the rendered prompt
+	view    viewport.Model
+	warning string
+}
+
+// templateMsg carries the model's template and default system prompt.
+type templateMsg struct {
+	model string
+	info  *ollama.ShowResponse
+	err   error
+}
+
+func fetchTemplate(c *ollama.Client, name string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		info, err := c.Show(ctx, name)
+		return templateMsg{model: name, info: info, err: err}
+	}
+}
+
+// templateViewHeight leaves room for the title, system prompt, warning and
+// help.
+func templateViewHeight(height int) int {
+	return max(height-9, 5)
+}
+
+// openTemplate previews the open chat's conversation, or a sample one when
+// it is empty.
+func (m model) openTemplate() (tea.Model, tea.Cmd) {
+	c := m.chat
+	history := c.history
+	if len(history) == 0 {
+		history = sampleConversation
+	}
+	system := textinput.New()
+	system.Prompt = "System: "
+	system.Placeholder = "the model's default"
+	system.CharLimit = 0
+	system.Width = max(m.width-12, 20)
+
+	c.input.Blur()
+	m.template = &templateState{
+		model:   c.model,
+		history: history,
+		system:  system,
+		view:    viewport.New(m.width, templateViewHeight(m.height)),
+	}
+	m.mode = modeTemplate
+	return m, tea.Batch(fetchTemplate(m.client, c.model), m.template.system.Focus())
+}
+
+func (s *templateState) resize(width, height int) {
+	s.view.Width = width
+	s.view.Height = templateViewHeight(height)
+	s.system.Width = max(width-12, 20)
+	s.render()
+}
+
+// render shows the template, or the prompt it builds with the special tokens
+// highlighted, and warns when the system prompt gets lost.
+func (s *templateState) render() {
+	s.warning = ""
+	switch {
+	case s.err != nil:
+		s.view.SetContent(errorStyle.Render(fmt.Sprintf("Could not load the template: %v", s.err)))
+		return
+	case s.info == nil:
+		s.view.SetContent("Loading the template...")
+		return
+	}
+	tmpl := s.info.Template
+	if tmpl == "" {
+		tmpl = "{{ .Prompt }}"
+	}
+	wrap := lipgloss.NewStyle().Width(max(s.view.Width, 20))
+	if s.source {
+		s.view.SetContent(wrap.Render(tmpl))
+		return
+	}
+
+	msgs := s.history
+	system := strings.TrimSpace(s.system.Value())
+	if system != "" {
+		msgs = append([]ollama.Message{{Role: "system", Content: system}}, msgs...)
+	} else {
+		system = s.info.System
+	}
+	prompt, err := ollama.RenderPrompt(tmpl, s.info.System, msgs)
+	if err != nil {
+		s.view.SetContent(errorStyle.Render(fmt.Sprintf("Could not render the template: %v", err)))
+		return
+	}
+	highlighted := specialToken.ReplaceAllStringFunc(prompt, func(tok string) string {
+		return warnStyle.Render(tok)
+	})
+	s.view.SetContent(wrap.Render(highlighted + cursorStyle.Render("▌")))
+
+	switch {
+	case system == "":
+		s.warning = "No system prompt: enter one above to see where it goes, or set SYSTEM in the Modelfile."
+	case !strings.Contains(prompt, system):
+		s.warning = "The system prompt isn't in the rendered prompt: this template drops it, so the model never sees it."
+	}
+}
+
+func (m model) updateTemplate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := m.template
+	switch msg.String() {
+	case "esc":
+		s.system.Blur()
+		m.mode = modeChat
+		return m, m.chat.input.Focus()
+	case "tab":
+		s.source = !s.source
+		s.render()
+		return m, nil
+	case "pgup", "pgdown":
+		var cmd tea.Cmd
+		s.view, cmd = s.view.Update(msg)
+		return m, cmd
+	}
+	var cmd tea.Cmd
+	s.system, cmd = s.system.Update(msg)
+	s.render()
+	return m, cmd
+}
+
+func (m model) templateView() string {
+	s := m.template
+	var b strings.Builder
+	title, hint := "Prompt: ", "  as the server builds it; the reply starts at ▌"
+	if s.source {
+		title, hint = "Template: ", "  from /api/show"
+	}
+	b.WriteString(titleStyle.Render(title + s.model))
+	b.WriteString(helpStyle.Render(hint))
+	b.WriteString("\n\n")
+	b.WriteString(s.system.View())
+	b.WriteString("\n\n")
+	b.WriteString(s.view.View())
+	b.WriteString("\n\n")
+	if s.warning != "" {
+		b.WriteString(warnStyle.Render(s.warning))
+		b.WriteString("\n")
+	}
+	show := "Tab: Show template"
+	if s.source {
+		show = "Tab: Show prompt"
+	}
+	b.WriteString(helpStyle.Render(show + "  PgUp/PgDn: Scroll  Esc: Back to chat"))
+	return b.String()
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// theme is a color palette. Each color carries its own fallbacks for 256-
+// and 16-color terminals, which lipgloss picks from automatically, so a
+// 16-color terminal gets hand-picked colors rather than nearest matches.
+type theme struct {
+	name   string
+	accent lipgloss.TerminalColor // titles, cursor, selected row
+	loaded lipgloss.TerminalColor
+	muted  lipgloss.TerminalColor // help text, borders
+	warn   lipgloss.TerminalColor
+	err    lipgloss.TerminalColor
+	user   lipgloss.TerminalColor // your chat messages
+	bar    [2]string              // progress bar gradient
+
+	// strong marks titles and the cursor row with underline and reverse
+	// video instead of relying on color alone.
+	strong bool
+}
+
+var themes = []theme{
+	{
+		name:   "dark",
+		accent: lipgloss.CompleteColor{TrueColor: "#ff5fd7", ANSI256: "205", ANSI: "13"},
+		loaded: lipgloss.CompleteColor{TrueColor: "#00d787", ANSI256: "42", ANSI: "10"},
+		muted:  lipgloss.CompleteColor{TrueColor: "#626262", ANSI256: "241", ANSI: "8"},
+		warn:   lipgloss.CompleteColor{TrueColor: "#ffaf00", ANSI256: "214", ANSI: "11"},
+		err:    lipgloss.CompleteColor{TrueColor: "#ff0000", ANSI256: "196", ANSI: "9"},
+		user:   lipgloss.CompleteColor{TrueColor: "#00afff", ANSI256: "39", ANSI: "12"},
+		bar:    [2]string{"#5A56E0", "#EE6FF8"},
+	},
+	{
+		name:   "light",
+		accent: lipgloss.CompleteColor{TrueColor: "#af005f", ANSI256: "125", ANSI: "5"},
+		loaded: lipgloss.CompleteColor{TrueColor: "#008700", ANSI256: "28", ANSI: "2"},
+		muted:  lipgloss.CompleteColor{TrueColor: "#767676", ANSI256: "243", ANSI: "8"},
+		warn:   lipgloss.CompleteColor{TrueColor: "#af5f00", ANSI256: "130", ANSI: "3"},
+		err:    lipgloss.CompleteColor{TrueColor: "#d70000", ANSI256: "160", ANSI: "1"},
+		user:   lipgloss.CompleteColor{TrueColor: "#005faf", ANSI256: "25", ANSI: "4"},
+		bar:    [2]string{"#005faf", "#af005f"},
+	},
+	{
+		// Text stays in the terminal's own foreground color, the one with the
+		// most contrast against its background; only states are colored.
+		name:   "high-contrast",
+		accent: lipgloss.NoColor{},
+		loaded: lipgloss.AdaptiveColor{Light: "22", Dark: "46"},
+		muted:  lipgloss.NoColor{},
+		warn:   lipgloss.AdaptiveColor{Light: "94", Dark: "226"},
+		err:    lipgloss.AdaptiveColor{Light: "124", Dark: "196"},
+		user:   lipgloss.NoColor{},
+		bar:    [2]string{"#808080", "#808080"}, // visible on either background
+		strong: true,
+	},
+}
+
+var (
+	titleStyle     lipgloss.Style
+	loadedStyle    lipgloss.Style
+	helpStyle      lipgloss.Style
+	cursorStyle    lipgloss.Style
+	warnStyle      lipgloss.Style
+	errorStyle     lipgloss.Style
+	userStyle      lipgloss.Style
+	assistantStyle lipgloss.Style
+	modalStyle     lipgloss.Style
+	tabStyle       lipgloss.Style
+	statusStyle    lipgloss.Style
+)
+
+// activeTheme is the palette the styles were last built from. main applies
+// the configured one before anything is drawn.
+var activeTheme = themes[0]
+
+// newTheme resolves the theme named in the config and applies the color
+// overrides on top. An empty name or "auto" picks dark or light from the
+// terminal's background.
+func newTheme(name string, colors map[string]string) (theme, error) {
+	var t theme
+	switch name {
+	case "", "auto":
+		t = themes[1]
+		if lipgloss.HasDarkBackground() {
+			t = themes[0]
+		}
+	default:
+		found := false
+		for _, th := range themes {
+			if th.name == name {
+				t, found = th, true
+			}
+		}
+		if !found {
+			return t, fmt.Errorf("theme: unknown theme %q (valid: auto, %s)", name, strings.Join(themeNames(), ", "))
+		}
+	}
+
+	roles := map[string]*lipgloss.TerminalColor{
+		"accent": &t.accent,
+		"loaded": &t.loaded,
+		"muted":  &t.muted,
+		"warn":   &t.warn,
+		"error":  &t.err,
+		"user":   &t.user,
+	}
+	for role, color := range colors {
+		c, ok := roles[role]
+		if !ok {
+			names := make([]string, 0, len(roles))
+			for r := range roles {
+				names = append(names, r)
+			}
+			sort.Strings(names)
+			return t, fmt.Errorf("colors: unknown color %q (valid: %s)", role, strings.Join(names, ", "))
+		}
+		*c = lipgloss.Color(color)
+	}
+	return t, nil
+}
+
+func themeNames() []string {
+	names := make([]string, len(themes))
+	for i, t := range themes {
+		names[i] = t.name
+	}
+	return names
+}
+
+// nextTheme names the built-in theme after the active one, for cycling.
+func nextTheme() string {
+	for i, t := range themes {
+		if t.name == activeTheme.name {
+			return themes[(i+1)%len(themes)].name
+		}
+	}
+	return themes[0].name
+}
+
+// cycleTheme switches to the next theme, keeping the configured color
+// overrides, and remembers the choice in the config file.
+func (m *model) cycleTheme() {
+	t, err := newTheme(nextTheme(), m.cfg.Colors)
+	if err != nil {
+		m.status = err.Error()
+		return
+	}
+	applyTheme(t)
+	m.restyle()
+	m.cfg.Theme = t.name
+	if err := m.cfg.Save(); err != nil {
+		m.status = fmt.Sprintf("Theme %s; could not save config: %v", t.name, err)
+		return
+	}
+	m.status = "Theme " + t.name
+}
+
+// applyTheme rebuilds the shared styles from t. Components owned by the
+// model pick them up in restyle.
+func applyTheme(t theme) {
+	activeTheme = t
+	titleStyle = lipgloss.NewStyle().Bold(true).Underline(t.strong).Foreground(t.accent)
+	loadedStyle = lipgloss.NewStyle().Foreground(t.loaded)
+	helpStyle = lipgloss.NewStyle().Foreground(t.muted)
+	cursorStyle = lipgloss.NewStyle().Bold(t.strong).Foreground(t.accent)
+	warnStyle = lipgloss.NewStyle().Foreground(t.warn)
+	errorStyle = lipgloss.NewStyle().Bold(t.strong).Foreground(t.err)
+	userStyle = lipgloss.NewStyle().Bold(true).Foreground(t.user)
+	assistantStyle = lipgloss.NewStyle().Bold(true).Foreground(t.accent)
+	modalStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.err).
+		Padding(0, 1)
+	tabStyle = lipgloss.NewStyle().Bold(true).Reverse(true).Foreground(t.accent)
+	statusStyle = lipgloss.NewStyle().Reverse(true).Foreground(t.muted)
+}
+
+func tableStyles() table.Styles {
+	st := table.DefaultStyles()
+	st.Header = st.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(activeTheme.muted).
+		BorderBottom(true).
+		Bold(true)
+	st.Selected = st.Selected.
+		Foreground(activeTheme.accent).
+		Reverse(activeTheme.strong).
+		Bold(true)
+	return st
+}
+
+func newProgressBar() progress.Model {
+	return progress.New(progress.WithGradient(activeTheme.bar[0], activeTheme.bar[1]), progress.WithWidth(40))
+}
+
+// restyle updates the components that copied styles when they were made.
+func (m *model) restyle() {
+	m.table.SetStyles(tableStyles())
+	m.spinner.Style = cursorStyle
+	m.bar = newProgressBar()
+	m.syncTable()
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/gpu"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/service"
+	"ollama-manager/internal/webhook"
+)
+
+const (
+	// A GPU has to drop this far below its limits before it can trip them
+	// again, so one hovering at a limit is acted on once.
+	thermalTempReset  = 5   // °C
+	thermalPowerReset = 0.9 // of the limit
+)
+
+var thermalActions = []string{"unload", "reduce_parallel"}
+
+// thermalWatch holds the GPUs to the thermal config's limits.
+type thermalWatch struct {
+	tempC  int
+	powerW int
+	action string
+
+	hot    map[int]bool // by GPU index
+	acting bool         // an action is in flight
+}
+
+// thermalMsg reports a GPU that went past a limit and what was done about
+// it; done is empty when only alerting.
+type thermalMsg struct {
+	breach string
+	done   string
+	err    error
+}
+
+// newThermalWatch checks the thermal config; it returns nil when the
+// feature is off.
+func newThermalWatch(cfg *config.Thermal) (*thermalWatch, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	if cfg.TempC < 0 || cfg.PowerW < 0 || cfg.TempC == 0 && cfg.PowerW == 0 {
+		return nil, fmt.Errorf("thermal: set temp_c, power_w or both")
+	}
+	if cfg.Action != "" && !slices.Contains(thermalActions, cfg.Action) {
+		return nil, fmt.Errorf("thermal: unknown action %q (valid: %s)", cfg.Action, strings.Join(thermalActions, ", "))
+	}
+	return &thermalWatch{tempC: cfg.TempC, powerW: cfg.PowerW, action: cfg.Action, hot: make(map[int]bool)}, nil
+}
+
+// String describes the limits and the action, for the daemon's summary.
+func (w *thermalWatch) String() string {
+	var limits []string
+	if w.tempC > 0 {
+		limits = append(limits, fmt.Sprintf("%d°C", w.tempC))
+	}
+	if w.powerW > 0 {
+		limits = append(limits, fmt.Sprintf("%dW", w.powerW))
+	}
+	s := strings.Join(limits, " or ")
+	if w.action != "" {
+		s += ", then " + w.action
+	}
+	return s
+}
+
+// observe describes the GPUs that have just gone past a limit.
+func (w *thermalWatch) observe(devices []gpu.Device) []string {
+	var breaches []string
+	for _, d := range devices {
+		var over []string
+		if w.tempC > 0 && d.Temperature >= w.tempC {
+			over = append(over, fmt.Sprintf("at %d°C (limit %d°C)", d.Temperature, w.tempC))
+		}
+		if w.powerW > 0 && d.Power >= w.powerW {
+			over = append(over, fmt.Sprintf("drawing %dW (limit %dW)", d.Power, w.powerW))
+		}
+		cool := (w.tempC == 0 || d.Temperature < w.tempC-thermalTempReset) &&
+			(w.powerW == 0 || float64(d.Power) < float64(w.powerW)*thermalPowerReset)
+		switch {
+		case len(over) > 0 && !w.hot[d.Index]:
+			w.hot[d.Index] = true
+			breaches = append(breaches, fmt.Sprintf("GPU %d (%s) is %s", d.Index, d.Name, strings.Join(over, " and ")))
+		case cool:
+			w.hot[d.Index] = false
+		}
+	}
+	return breaches
+}
+
+// throttle takes the configured action to cool the GPU down and describes
+// what it did.
+func throttle(c *ollama.Client, name, action string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), serviceTimeout)
+	defer cancel()
+	switch action {
+	case "unload":
+		running, err := c.Running(ctx)
+		if err != nil {
+			return "", err
+		}
+		if len(running) == 0 {
+			return "no model was loaded", nil
+		}
+		heaviest := slices.MaxFunc(running, func(a, b ollama.RunningModel) int {
+			return cmp.Compare(a.SizeVRAM, b.SizeVRAM)
+		})
+		if err := stopModel(c, heaviest.Name); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("unloaded %s (%s in VRAM)", heaviest.Name, formatBytes(uint64(heaviest.SizeVRAM))), nil
+	case "reduce_parallel":
+		env, err := service.Env(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		// Unset, the server picks the value itself; 1 is the safe choice.
+		n, _ := strconv.Atoi(env["OLLAMA_NUM_PARALLEL"])
+		if n == 1 {
+			return "OLLAMA_NUM_PARALLEL is already 1", nil
+		}
+		next := max(n/2, 1)
+		if err := service.SetEnv(ctx, name, map[string]string{"OLLAMA_NUM_PARALLEL": strconv.Itoa(next)}); err != nil {
+			return "", err
+		}
+		if err := service.Control(ctx, name, service.Restart); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("set OLLAMA_NUM_PARALLEL to %d and restarted Ollama", next), nil
+	}
+	return "", nil
+}
+
+// watchThermal checks the GPUs of a refresh against the limits and, past
+// one, starts the configured action. Only a local server runs on these GPUs.
+func (m *model) watchThermal(devices []gpu.Device) tea.Cmd {
+	w := m.thermal
+	if w == nil || !m.client.Local() {
+		return nil
+	}
+	breaches := w.observe(devices)
+	if len(breaches) == 0 {
+		return nil
+	}
+	breach := strings.Join(breaches, "; ")
+	if w.action == "" || w.acting {
+		return func() tea.Msg { return thermalMsg{breach: breach} }
+	}
+	w.acting = true
+	c, name, action := m.client, m.serviceName(), w.action
+	return func() tea.Msg {
+		done, err := throttle(c, name, action)
+		return thermalMsg{breach: breach, done: done, err: err}
+	}
+}
+
+func (m *model) finishThermal(msg thermalMsg) tea.Cmd {
+	text := msg.breach
+	switch {
+	case msg.err != nil:
+		text += fmt.Sprintf("; %s failed: %v", m.thermal.action, msg.err)
+	case msg.done != "":
+		text += "; " + msg.done
+	}
+	if msg.done != "" || msg.err != nil {
+		m.thermal.acting = false
+	}
+	m.status = text
+	m.logError(text)
+	cmds := []tea.Cmd{m.notify("thermal", time.Time{}, "%s", text), m.alert("thermal", "%s", text)}
+	if msg.done != "" {
+		cmds = append(cmds, m.refresh())
+	}
+	return tea.Batch(cmds...)
+}
+
+// runThermalWatch polls the GPUs until ctx is done, for the daemon.
+func runThermalWatch(ctx context.Context, c *ollama.Client, w *thermalWatch, name string, a *alerts) {
+	ticker := time.NewTicker(idlePoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		devices, err := gpu.Query()
+		if err != nil {
+			continue
+		}
+		breaches := w.observe(devices)
+		if len(breaches) == 0 {
+			continue
+		}
+		text := strings.Join(breaches, "; ")
+		if w.action != "" {
+			done, err := throttle(c, name, w.action)
+			if err != nil {
+				text += fmt.Sprintf("; %s failed: %v", w.action, err)
+			} else {
+				text += "; " + done
+			}
+		}
+		fmt.Printf("%s %s\n", time.Now().Format("2006-01-02 15:04"), text)
+		if a != nil {
+			if err := a.post([]webhook.Event{newEvent(c.Host(), "thermal", text)}); err != nil {
+				fmt.Printf("%s %v\n", time.Now().Format("2006-01-02 15:04"), err)
+			}
+		}
+	}
+}
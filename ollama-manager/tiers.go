@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/store"
+)
+
+// tiersMsg carries where the models of host keep their blobs.
+type tiersMsg struct {
+	host  string
+	tiers map[string]store.Tier
+	err   error
+}
+
+// scanTiers finds which models are archived to the cold store. It only
+// runs with a cold store configured, for the local server.
+func (m model) scanTiers() tea.Cmd {
+	if m.cfg.ColdStore == "" || !m.client.Local() {
+		return nil
+	}
+	host := m.client.Host()
+	return func() tea.Msg {
+		dir, err := store.Dir()
+		if err != nil {
+			return tiersMsg{host: host, err: err}
+		}
+		tiers, err := store.Tiers(dir)
+		return tiersMsg{host: host, tiers: tiers, err: err}
+	}
+}
+
+// finishTiers applies a scan, reporting a failure once rather than on
+// every refresh.
+func (m *model) finishTiers(msg tiersMsg) {
+	if msg.host != m.client.Host() {
+		return
+	}
+	if msg.err != nil {
+		if m.tiers != nil || !m.tiersFailed {
+			m.logError("Cold store: " + msg.err.Error())
+		}
+		m.tiers, m.tiersFailed = nil, true
+		m.syncTable()
+		return
+	}
+	m.tiers, m.tiersFailed = msg.tiers, false
+	m.syncTable()
+}
+
+// tierLabel marks a model archived to the cold store, wholly or in part,
+// for the list.
+func (m model) tierLabel(name string) string {
+	if !m.client.Local() {
+		return ""
+	}
+	switch m.tiers[name] {
+	case store.Cold:
+		return " [cold]"
+	case store.Mixed:
+		return " [part cold]"
+	}
+	return ""
+}
+
+// toggleArchive moves a model's blobs to the cold store, or back from it
+// when any are there. Ollama keeps the blob files of a loaded model open,
+// so it has to be unloaded first.
+func (m *model) toggleArchive(name string) tea.Cmd {
+	cold := m.cfg.ColdStore
+	switch {
+	case cold == "":
+		m.status = "Set cold_store in the config to archive models to another drive"
+		return nil
+	case !m.client.Local():
+		m.status = "Archiving is only available for a local server"
+		return nil
+	case m.loaded[name]:
+		m.status = fmt.Sprintf("Unload %s before moving its files", name)
+		return nil
+	}
+	dir, err := store.Dir()
+	if err != nil {
+		m.status = err.Error()
+		return nil
+	}
+	if m.tiers[name] == store.Hot {
+		return m.runOp("Archiving "+name, "Archived "+name+" to "+cold, func() error {
+			_, err := store.Archive(dir, cold, name)
+			return err
+		})
+	}
+	return m.runOp("Restoring "+name, "Restored "+name+" from the cold store", func() error {
+		_, err := store.Restore(dir, name)
+		return err
+	})
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/proxy"
+)
+
+// trafficRecords is how many of the proxy's latest requests are listed.
+const trafficRecords = 15
+
+// trafficState is the Traffic tab: the stats of `ollama-manager proxy`,
+// which runs as its own process.
+type trafficState struct {
+	snap    proxy.Snapshot
+	err     error
+	loading bool
+	read    time.Time
+
+	// aborting is set while asking which generation to abort.
+	aborting bool
+	abort    textinput.Model
+
+	keys keysState
+}
+
+// trafficMsg carries the stats read from the proxy.
+type trafficMsg struct {
+	snap proxy.Snapshot
+	err  error
+}
+
+// abortedMsg reports the outcome of aborting a generation.
+type abortedMsg struct {
+	id  int64
+	err error
+}
+
+func abortRequest(listen string, id int64) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		return abortedMsg{id: id, err: proxy.RequestAbort(ctx, listen, id)}
+	}
+}
+
+func fetchTraffic(listen string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		snap, err := proxy.Fetch(ctx, listen)
+		return trafficMsg{snap: snap, err: err}
+	}
+}
+
+func (m model) openTraffic() (tea.Model, tea.Cmd) {
+	m.mode = modeTraffic
+	if m.traffic == nil {
+		abort := textinput.New()
+		abort.Prompt = "Abort request #"
+		abort.CharLimit = 12
+		abort.Width = 12
+		m.traffic = &trafficState{keys: newKeysState(), abort: abort}
+	}
+	return m, m.pollTraffic()
+}
+
+// pollTraffic asks the proxy for its stats unless a request is in flight.
+func (m *model) pollTraffic() tea.Cmd {
+	if m.traffic.loading {
+		return nil
+	}
+	m.traffic.loading = true
+	return fetchTraffic(proxyListen(m.cfg))
+}
+
+func (m *model) finishTraffic(msg trafficMsg) {
+	t := m.traffic
+	t.loading, t.err, t.read = false, msg.err, time.Now()
+	if msg.err == nil {
+		t.snap = msg.snap
+	}
+}
+
+func (m model) updateTraffic(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	t := m.traffic
+	if t.aborting {
+		switch msg.String() {
+		case "esc":
+			t.aborting = false
+			t.abort.Blur()
+		case "enter":
+			t.aborting = false
+			t.abort.Blur()
+			id, err := strconv.ParseInt(strings.TrimSpace(t.abort.Value()), 10, 64)
+			if err != nil {
+				m.status = "Enter the # of a request in flight"
+				return m, nil
+			}
+			m.status = fmt.Sprintf("Aborting request #%d...", id)
+			return m, abortRequest(proxyListen(m.cfg), id)
+		default:
+			var cmd tea.Cmd
+			t.abort, cmd = t.abort.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+	if cmd, ok := m.updateKeys(msg); ok {
+		return m, cmd
+	}
+	switch {
+	case msg.String() == "esc", key.Matches(msg, m.keys.Quit):
+		m.mode = modeList
+	case key.Matches(msg, m.keys.Refresh):
+		return m, m.pollTraffic()
+	case key.Matches(msg, m.keys.Abort):
+		if len(t.snap.Active) == 0 {
+			m.status = "No generations in flight"
+			break
+		}
+		// The oldest is the likeliest to be stuck.
+		t.aborting = true
+		t.abort.SetValue(strconv.FormatInt(t.snap.Active[0].ID, 10))
+		t.abort.CursorEnd()
+		return m, t.abort.Focus()
+	}
+	return m, nil
+}
+
+func (m *model) finishAbort(msg abortedMsg) tea.Cmd {
+	if msg.err != nil {
+		m.status = fmt.Sprintf("Aborting request #%d failed: %v", msg.id, msg.err)
+		m.logError(m.status)
+	} else {
+		m.status = fmt.Sprintf("Aborted request #%d", msg.id)
+	}
+	if m.traffic == nil {
+		return nil
+	}
+	return m.pollTraffic()
+}
+
+func (m model) trafficView() string {
+	t := m.traffic
+	listen := proxyListen(m.cfg)
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Traffic"))
+	if t.err == nil && !t.read.IsZero() {
+		b.WriteString(helpStyle.Render(fmt.Sprintf("  proxy on %s → %s, up %s", listen, t.snap.Upstream,
+			formatUptime(time.Since(t.snap.Started)))))
+	}
+	b.WriteString("\n\n")
+
+	switch {
+	case t.read.IsZero():
+		b.WriteString("Asking the proxy for its stats...\n")
+	case t.err != nil:
+		b.WriteString(fmt.Sprintf("No proxy answering on %s.\n\n", listen))
+		b.WriteString(helpStyle.Render("Start one with `ollama-manager proxy` and point clients at it instead of\n" +
+			"Ollama to see who uses the server: requests, tokens and latency per\n" +
+			"client address and API key. Add API keys below to require one."))
+		b.WriteString("\n")
+	case len(t.snap.Callers) == 0 && len(t.snap.Active) == 0:
+		b.WriteString(helpStyle.Render("No requests through the proxy yet."))
+		b.WriteString("\n")
+	default:
+		if len(t.snap.Active) > 0 {
+			b.WriteString(titleStyle.Render("In flight"))
+			b.WriteString("\n")
+			tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "  #\tCLIENT\tKEY\tREQUEST\tMODEL\tRUNNING\tCHUNKS")
+			for _, a := range t.snap.Active {
+				fmt.Fprintf(tw, "  %d\t%s\t%s\t%s %s\t%s\t%s\t%d\n", a.ID, a.Addr, orDash(a.Key), a.Method, a.Path,
+					orDash(a.Model), formatUptime(time.Since(a.Started)), a.Chunks)
+			}
+			tw.Flush()
+			if t.aborting {
+				b.WriteString("\n")
+				b.WriteString(t.abort.View())
+				b.WriteString(helpStyle.Render("  Enter: Abort  Esc: Cancel"))
+				b.WriteString("\n")
+			}
+			b.WriteString("\n")
+		}
+
+		b.WriteString(fmt.Sprintf("Limits for all clients: %s", t.snap.Limits))
+		if hits := t.snap.LimitHits; len(hits) > 0 {
+			b.WriteString(warnStyle.Render(fmt.Sprintf("  hit %d times (rate %d, concurrency %d)",
+				hits[proxy.LimitRate]+hits[proxy.LimitConcurrency], hits[proxy.LimitRate], hits[proxy.LimitConcurrency])))
+		}
+		b.WriteString("\n\n")
+		tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  CLIENT\tKEY\tREQUESTS\tFAILED\tLIMITED\tTOKENS IN\tTOKENS OUT\tAVG LATENCY\tLAST")
+		for _, u := range t.snap.Callers {
+			fmt.Fprintf(tw, "  %s\t%s\t%d\t%d\t%d\t%d\t%d\t%s\t%s\n", u.Addr, orDash(u.Key), u.Requests, u.Errors,
+				u.Limited, u.InputTokens, u.OutputTokens, formatLatency(u.AvgLatency()), formatAgo(u.Last))
+		}
+		tw.Flush()
+
+		b.WriteString("\n")
+		b.WriteString(titleStyle.Render("Recent requests"))
+		b.WriteString("\n")
+		tw = tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		for i, r := range t.snap.Recent {
+			if i == trafficRecords {
+				break
+			}
+			tokens := ""
+			if r.InputTokens+r.OutputTokens > 0 {
+				tokens = fmt.Sprintf("%d+%d", r.InputTokens, r.OutputTokens)
+			}
+			status := fmt.Sprint(r.Status)
+			switch {
+			case r.Aborted:
+				status = warnStyle.Render("aborted")
+			case r.Status >= 400:
+				status = errorStyle.Render(status)
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s %s\t%s\t%s\t%s\t%s\n", r.Time.Format("15:04:05"), r.Addr, r.Method, r.Path,
+				orDash(r.Model), tokens, formatLatency(r.Latency), status)
+		}
+		tw.Flush()
+	}
+
+	b.WriteString("\n")
+	b.WriteString(m.keysView())
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("\nStatus: %s", m.status))
+	return b.String()
+}
+
+// orDash shows an empty cell as "—".
+func orDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/pullqueue"
+	"ollama-manager/internal/registry"
+)
+
+// updatesState is the update check and, once started, the pulling of the
+// updates it found.
+type updatesState struct {
+	checking bool
+	results  []updateResult
+	cursor   int
+
+	// pulling is set while the updates found are in the pull queue;
+	// pending holds those not finished yet.
+	pulling bool
+	pending map[string]bool
+	pulled  []updatePull
+	summary string
+}
+
+// updateResult compares an installed model with its registry.
+type updateResult struct {
+	name   string
+	local  string
+	remote string
+	err    error // registry.ErrNotFound for models that exist only here
+}
+
+func (r updateResult) available() bool {
+	return r.err == nil && r.remote != r.local
+}
+
+// updatesCheckedMsg carries the outcome of an update check.
+type updatesCheckedMsg []updateResult
+
+// updatesPulledMsg reports the pulled updates.
+type updatesPulledMsg []updatePull
+
+// updatePull is the outcome of pulling one update. A pull that left the
+// digest as it was is unchanged: the registry moved back, or the model was
+// updated some other way in the meantime.
+type updatePull struct {
+	name    string
+	changed bool
+	err     error
+}
+
+// checkUpdates asks each model's registry for its current digest.
+func checkUpdates(models []ollama.Model) []updateResult {
+	rc := registry.NewClient()
+	results := make([]updateResult, len(models))
+	names := make([]string, len(models))
+	index := make(map[string]int, len(models))
+	for i, mdl := range models {
+		names[i] = mdl.Name
+		index[mdl.Name] = i
+		results[i] = updateResult{name: mdl.Name, local: mdl.Digest}
+	}
+	var mu sync.Mutex
+	runBatch(names, func(name string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		digest, err := rc.Digest(ctx, name)
+		mu.Lock()
+		results[index[name]].remote, results[index[name]].err = digest, err
+		mu.Unlock()
+		return nil
+	})
+	return results
+}
+
+// markChanged compares the digests of the pulled models with those from
+// before.
+func markChanged(c *ollama.Client, pulls []updatePull, before map[string]string) []updatePull {
+	models, err := getModels(c)
+	if err != nil {
+		return pulls
+	}
+	for i, p := range pulls {
+		if mdl, ok := findModel(models, p.name); ok && p.err == nil {
+			pulls[i].changed = mdl.Digest != before[p.name]
+		}
+	}
+	return pulls
+}
+
+// pullErr is the error of a finished pull, or nil if it succeeded.
+func pullErr(it pullqueue.Item) error {
+	switch it.State {
+	case pullqueue.Done:
+		return nil
+	case pullqueue.Cancelled:
+		return context.Canceled
+	}
+	return it.Err
+}
+
+// summarizePulls counts changed, unchanged and failed updates.
+func summarizePulls(pulls []updatePull) string {
+	changed, unchanged, failed := 0, 0, 0
+	for _, p := range pulls {
+		switch {
+		case p.err != nil:
+			failed++
+		case p.changed:
+			changed++
+		default:
+			unchanged++
+		}
+	}
+	s := fmt.Sprintf("Updated %d models: %d changed, %d unchanged", len(pulls), changed, unchanged)
+	if failed > 0 {
+		s += fmt.Sprintf(", %d failed", failed)
+	}
+	return s
+}
+
+// openUpdates shows the update screen, checking again unless updates are
+// being pulled.
+func (m model) openUpdates() (tea.Model, tea.Cmd) {
+	m.mode = modeUpdates
+	if m.updates != nil && (m.updates.pulling || m.updates.checking) {
+		return m, nil
+	}
+	return m, m.startUpdateCheck()
+}
+
+func (m *model) startUpdateCheck() tea.Cmd {
+	m.updates = &updatesState{checking: true}
+	models := m.models
+	op := func() tea.Msg {
+		return updatesCheckedMsg(checkUpdates(models))
+	}
+	return tea.Batch(op, m.startBusy())
+}
+
+func (m *model) finishUpdateCheck(msg updatesCheckedMsg) {
+	m.busy = max(m.busy-1, 0)
+	u := m.updates
+	u.checking = false
+	u.results = msg
+	u.cursor = min(u.cursor, max(len(u.results)-1, 0))
+	available, current, unknown := 0, 0, 0
+	for _, r := range u.results {
+		switch {
+		case r.available():
+			available++
+		case r.err == nil:
+			current++
+		default:
+			unknown++
+			if !errors.Is(r.err, registry.ErrNotFound) {
+				m.logError(fmt.Sprintf("Update check for %s failed: %v", r.name, r.err))
+			}
+		}
+	}
+	m.status = fmt.Sprintf("%d updates available, %d up to date", available, current)
+	if unknown > 0 {
+		m.status += fmt.Sprintf(", %d not checked", unknown)
+	}
+	m.syncTable()
+}
+
+// hasUpdate reports whether the last check found a newer version of name.
+func (m model) hasUpdate(name string) bool {
+	if m.updates == nil {
+		return false
+	}
+	for _, r := range m.updates.results {
+		if r.name == name {
+			return r.available()
+		}
+	}
+	return false
+}
+
+// startUpdatePulls queues every update found.
+func (m *model) startUpdatePulls() tea.Cmd {
+	u := m.updates
+	u.pending, u.pulled, u.summary = make(map[string]bool), nil, ""
+	for _, r := range u.results {
+		if r.available() {
+			m.pulls.Add(m.client, r.name)
+			u.pending[r.name] = true
+		}
+	}
+	if len(u.pending) == 0 {
+		m.status = "No updates to pull"
+		return nil
+	}
+	u.pulling = true
+	m.status = fmt.Sprintf("Pulling %d updates...", len(u.pending))
+	return nil
+}
+
+// noteUpdatePulled records a finished pull of an update and, once all of
+// them are done, works out which changed anything.
+func (m *model) noteUpdatePulled(it pullqueue.Item) tea.Cmd {
+	u := m.updates
+	if u == nil || !u.pending[it.Name] {
+		return nil
+	}
+	delete(u.pending, it.Name)
+	u.pulled = append(u.pulled, updatePull{name: it.Name, err: pullErr(it)})
+	if len(u.pending) > 0 {
+		return nil
+	}
+	before := make(map[string]string, len(u.results))
+	for _, r := range u.results {
+		before[r.name] = r.local
+	}
+	c, pulls := m.client, u.pulled
+	return func() tea.Msg {
+		return updatesPulledMsg(markChanged(c, pulls, before))
+	}
+}
+
+func (m *model) finishUpdatePulls(msg updatesPulledMsg) {
+	u := m.updates
+	u.pulling = false
+	u.summary = summarizePulls(msg)
+	m.status = u.summary
+	// What was pulled is now the registry's version.
+	for i, r := range u.results {
+		for _, p := range msg {
+			if p.name == r.name && p.err == nil {
+				u.results[i].local = r.remote
+			}
+		}
+	}
+	m.syncTable()
+}
+
+// cancelUpdatePulls takes the updates not pulled yet out of the queue.
+func (m *model) cancelUpdatePulls() {
+	for _, it := range m.pulls.Items() {
+		if m.updates.pending[it.Name] && !it.State.Finished() {
+			m.pulls.Cancel(it.ID)
+		}
+	}
+}
+
+func (m model) updateUpdates(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	u := m.updates
+	switch s := msg.String(); {
+	case s == "esc", key.Matches(msg, m.keys.Quit, m.keys.Updates):
+		// Pulls carry on in the background.
+		m.mode = modeList
+	case key.Matches(msg, m.keys.Up):
+		u.cursor = max(u.cursor-1, 0)
+	case key.Matches(msg, m.keys.Down):
+		u.cursor = min(u.cursor+1, max(len(u.results)-1, 0))
+	case u.pulling && key.Matches(msg, m.keys.Cancel):
+		m.cancelUpdatePulls()
+	case u.pulling || u.checking:
+	case s == "enter":
+		return m, m.startUpdatePulls()
+	case key.Matches(msg, m.keys.Refresh):
+		return m, m.startUpdateCheck()
+	}
+	return m, nil
+}
+
+func (m model) updatesView() string {
+	u := m.updates
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Model Updates"))
+	b.WriteString("\n\n")
+
+	if u.checking {
+		b.WriteString(fmt.Sprintf("%s Checking %d models against their registries...\n", m.spinner.View(), len(m.models)))
+	}
+	queued := make(map[string]pullqueue.Item)
+	for _, it := range m.pulls.Items() {
+		if u.pending[it.Name] {
+			queued[it.Name] = it
+		}
+	}
+	rows := len(u.results)
+	if m.height > 0 {
+		rows = max(m.height-10-tabBarLines, 3)
+	}
+	top := max(0, min(u.cursor-rows/2, len(u.results)-rows))
+	for i := top; i < min(top+rows, len(u.results)); i++ {
+		r := u.results[i]
+		state := helpStyle.Render("up to date")
+		switch {
+		case queued[r.name].State == pullqueue.Pulling:
+			state = fmt.Sprintf("pulling %.0f%%", queued[r.name].Progress()*100)
+		case u.pending[r.name]:
+			state = helpStyle.Render(queued[r.name].State.String())
+		case r.available():
+			state = warnStyle.Render("update available")
+		case errors.Is(r.err, registry.ErrNotFound):
+			state = helpStyle.Render("not in a registry (created here?)")
+		case r.err != nil:
+			state = errorStyle.Render("check failed (E: show errors)")
+		}
+		cursor := "  "
+		if i == u.cursor {
+			cursor = cursorStyle.Render("> ")
+		}
+		b.WriteString(fmt.Sprintf("%s%-40s %s\n", cursor, r.name, state))
+	}
+
+	b.WriteString("\n")
+	switch {
+	case u.pulling:
+		b.WriteString(helpStyle.Render(helpLine(m.keys.Cancel, m.keys.PullQueue) + "  Esc: Back (keeps pulling)"))
+	case u.checking:
+		b.WriteString(helpStyle.Render("Esc: Back"))
+	default:
+		if u.summary != "" {
+			b.WriteString(u.summary + "\n")
+		}
+		b.WriteString(helpStyle.Render("Enter: Pull updates  " + helpLine(relabel(m.keys.Refresh, "Check again")) + "  Esc: Back"))
+	}
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("\nStatus: %s", m.status))
+	return b.String()
+}
+
+func cmdUpdate(c *ollama.Client, args []string) error {
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	pull := fs.Bool("pull", false, "pull the updates found")
+	workers, limit := pullQueueFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	q, err := cliPullQueue(*workers, *limit)
+	if err != nil {
+		return err
+	}
+	models, err := getModels(c)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		var only []ollama.Model
+		for _, name := range fs.Args() {
+			mdl, ok := findModel(models, name)
+			if !ok {
+				return fmt.Errorf("%s is not installed", name)
+			}
+			only = append(only, mdl)
+		}
+		models = only
+	}
+
+	var names []string
+	for _, r := range checkUpdates(models) {
+		switch {
+		case r.available():
+			fmt.Printf("%-40s update available\n", r.name)
+			names = append(names, r.name)
+		case errors.Is(r.err, registry.ErrNotFound):
+			fmt.Printf("%-40s not in a registry\n", r.name)
+		case r.err != nil:
+			fmt.Printf("%-40s check failed: %v\n", r.name, r.err)
+		default:
+			fmt.Printf("%-40s up to date\n", r.name)
+		}
+	}
+	if !*pull || len(names) == 0 {
+		fmt.Printf("%d updates available\n", len(names))
+		return nil
+	}
+
+	before := make(map[string]string, len(models))
+	for _, mdl := range models {
+		before[mdl.Name] = mdl.Digest
+	}
+	var pulls []updatePull
+	for _, it := range runPullQueue(q, c, names) {
+		pulls = append(pulls, updatePull{name: it.Name, err: pullErr(it)})
+	}
+	pulls = markChanged(c, pulls, before)
+	for _, p := range pulls {
+		if p.err != nil {
+			fmt.Printf("%s failed: %v\n", p.name, p.err)
+		}
+	}
+	fmt.Println(summarizePulls(pulls))
+	for _, p := range pulls {
+		if p.err != nil {
+			return errors.New("not every update could be pulled")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/diag"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/release"
+	"ollama-manager/internal/service"
+)
+
+// upgrade is the server panel's action for moving Ollama to the latest
+// release.
+const upgrade service.Action = "upgrade"
+
+// releaseMsg carries the newest Ollama release.
+type releaseMsg struct {
+	rel *release.Release
+	err error
+}
+
+// upgradeDoneMsg reports that the upgrade command finished; before is the
+// version that ran until then.
+type upgradeDoneMsg struct {
+	before string
+	err    error
+}
+
+// upgradeCheckedMsg is the health check after an upgrade: whether the
+// server came back and which version it runs now.
+type upgradeCheckedMsg struct {
+	before, after string
+	err           error
+}
+
+func fetchRelease() tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		rel, err := release.Latest(ctx)
+		return releaseMsg{rel: rel, err: err}
+	}
+}
+
+// outdated reports whether the server runs an older version than the
+// latest release.
+func (m model) outdated() bool {
+	return m.latest != nil && m.server.version != "" && diag.VersionLess(m.server.version, m.latest.Version)
+}
+
+// upgradeCommand is how Ollama is upgraded for the named service on this
+// platform, and how to show it. Containers are recreated from a fresh image
+// instead, so they get no command.
+func upgradeCommand(name string) (args []string, shown string, err error) {
+	switch runtime.GOOS {
+	case "windows":
+		if args = wingetCommand("upgrade"); args != nil {
+			return args, strings.Join(args, " "), nil
+		}
+		return nil, "", fmt.Errorf("winget isn't available; get the new version from %s", downloadURL)
+	case "linux":
+		script := "curl -fsSL https://ollama.com/install.sh | sh"
+		return []string{"sh", "-c", script}, script, nil
+	case "darwin":
+		if _, err := exec.LookPath("brew"); err == nil && name == service.DefaultName() {
+			script := "brew upgrade ollama && brew services restart ollama"
+			return []string{"sh", "-c", script}, script, nil
+		}
+		return nil, "", fmt.Errorf("the Ollama app updates itself; or get the new version from %s", downloadURL)
+	}
+	return nil, "", fmt.Errorf("upgrading Ollama is not supported on %s", runtime.GOOS)
+}
+
+// confirmUpgrade asks before upgrading, saying how it will be done.
+func (m model) confirmUpgrade() (tea.Model, tea.Cmd) {
+	if !m.managesContainer() {
+		if _, _, err := upgradeCommand(m.serviceName()); err != nil {
+			m.status = "Cannot upgrade Ollama: " + err.Error()
+			return m, nil
+		}
+	}
+	m.server.confirm = upgrade
+	return m, nil
+}
+
+// startUpgrade runs the upgrade: a recreated container with the image
+// pulled again, or else the platform's installer in the foreground, since
+// it may ask for a password.
+func (m model) startUpgrade() (tea.Model, tea.Cmd) {
+	before := m.server.version
+	m.server.loading = true
+	m.status = "Upgrading Ollama..."
+	if m.managesContainer() {
+		name, spec := m.serviceName(), m.containerSpec()
+		return m, tea.Batch(func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), recreateTimeout)
+			defer cancel()
+			return upgradeDoneMsg{before: before, err: service.Recreate(ctx, name, spec)}
+		}, m.startBusy())
+	}
+	args, _, err := upgradeCommand(m.serviceName())
+	if err != nil {
+		m.server.loading = false
+		m.status = "Cannot upgrade Ollama: " + err.Error()
+		return m, nil
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	return m, tea.Batch(tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return upgradeDoneMsg{before: before, err: err}
+	}), m.startBusy())
+}
+
+// finishUpgrade checks the server is healthy once the upgrade is done.
+func (m *model) finishUpgrade(msg upgradeDoneMsg) tea.Cmd {
+	if msg.err != nil {
+		m.busy = max(m.busy-1, 0)
+		m.server.loading = false
+		m.status = fmt.Sprintf("Upgrading Ollama failed: %v", msg.err)
+		m.logError(m.status)
+		return fetchServer(m.client, m.serviceName())
+	}
+	m.status = "Upgraded; waiting for the server to answer..."
+	return checkUpgrade(m.client, msg.before)
+}
+
+// checkUpgrade waits for the API and reads the version it now reports.
+func checkUpgrade(c *ollama.Client, before string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), serviceTimeout)
+		defer cancel()
+		if err := waitForAPI(ctx, c); err != nil {
+			return upgradeCheckedMsg{before: before, err: err}
+		}
+		after, err := c.Version(ctx)
+		return upgradeCheckedMsg{before: before, after: after, err: err}
+	}
+}
+
+func (m *model) finishUpgradeCheck(msg upgradeCheckedMsg) tea.Cmd {
+	m.busy = max(m.busy-1, 0)
+	m.server.loading = false
+	switch {
+	case msg.err != nil:
+		m.status = fmt.Sprintf("Ollama did not come back after the upgrade: %v", msg.err)
+		m.logError(m.status)
+	case msg.after == msg.before:
+		m.status = fmt.Sprintf("Ollama still runs %s; restart it to run the new version", msg.after)
+		m.logError(m.status)
+	default:
+		m.status = fmt.Sprintf("Upgraded Ollama from %s to %s", firstNonEmpty(msg.before, "an unknown version"), msg.after)
+	}
+	return tea.Batch(m.refresh(), fetchServer(m.client, m.serviceName()))
+}
+
+// upgradePrompt is the confirmation for an upgrade.
+func (m model) upgradePrompt() string {
+	target := "the latest release"
+	if m.latest != nil {
+		target = m.latest.Version
+	}
+	how := "by recreating its container from a fresh image"
+	if !m.managesContainer() {
+		_, shown, _ := upgradeCommand(m.serviceName())
+		how = "with `" + shown + "`"
+	}
+	return fmt.Sprintf("Upgrade Ollama from %s to %s %s?\nThe server restarts, so loaded models are unloaded and open requests fail.\n\ny: Confirm  any other key: Cancel",
+		firstNonEmpty(m.server.version, "its current version"), target, how)
+}
+
+// releaseLine describes the latest release next to the running version.
+func (m model) releaseLine() string {
+	switch {
+	case m.latestErr != nil:
+		return helpStyle.Render("latest release unknown: " + m.latestErr.Error())
+	case m.latest == nil:
+		return ""
+	case m.outdated():
+		return warnStyle.Render(fmt.Sprintf("%s available", m.latest.Version)) +
+			helpStyle.Render(" · newer models may need it · "+m.latest.URL)
+	case m.server.version != "":
+		return helpStyle.Render("latest")
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"ollama-manager/internal/activity"
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/proxy"
+	"ollama-manager/internal/usage"
+)
+
+const (
+	// usageFile records when each model was last used, under the data dir.
+	usageFile = "usage.json"
+	// defaultUnusedDays is how long a model goes untouched before the
+	// unused view lists it.
+	defaultUnusedDays = 30
+)
+
+func openUsage() (*usage.Log, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	return usage.Open(filepath.Join(dir, usageFile))
+}
+
+// recordUsage notes the loaded models as used now. A model stays loaded for
+// its keep-alive after its last request, so this trails the request by that
+// much; requests through the proxy are recorded exactly.
+func (m *model) recordUsage() {
+	now := time.Now()
+	for name := range m.running {
+		m.usage.Touch(m.client.Host(), name, now)
+	}
+	if err := m.usage.Save(); err != nil {
+		m.logError("Saving model usage: " + err.Error())
+	}
+}
+
+// recordProxied counts a successful proxied inference request towards its
+// model's totals on upstream. The GPU time is what Ollama reports; the
+// OpenAI-compatible API doesn't, so those requests count their duration.
+func recordProxied(log *usage.Log, upstream string, r proxy.Record) {
+	if r.Model == "" || r.Status >= 400 || !activity.IsInference(r.Path) {
+		return
+	}
+	gpuTime := r.GPUTime
+	if gpuTime == 0 {
+		gpuTime = r.Latency
+	}
+	log.Add(upstream, r.Model, r.Time, usage.Totals{
+		Requests:     1,
+		InputTokens:  r.InputTokens,
+		OutputTokens: r.OutputTokens,
+		GPUTime:      gpuTime,
+	})
+	if err := log.Save(); err != nil {
+		slog.Warn("usage", "err", err)
+	}
+}
+
+// unusedDays is how many days without use the unused view asks for.
+func unusedDays(cfg *config.Config) int {
+	if cfg.UnusedDays > 0 {
+		return cfg.UnusedDays
+	}
+	return defaultUnusedDays
+}
+
+// unused reports whether mdl hasn't been used on host for days, counting
+// from its last use, its pull, or when the log began on the server,
+// whichever is latest. Nothing is unused before the log is that old.
+func unused(log *usage.Log, host string, mdl ollama.Model, days int, now time.Time) bool {
+	since := log.Since(host)
+	if since.IsZero() {
+		return false
+	}
+	ref := since
+	for _, t := range []time.Time{log.Last(host, mdl.Name), mdl.ModifiedAt} {
+		if t.After(ref) {
+			ref = t
+		}
+	}
+	return now.Sub(ref) >= time.Duration(days)*24*time.Hour
+}
+
+// lastUsedText is the LAST USED cell: how long ago, or "-" when the model
+// hasn't been seen in use.
+func lastUsedText(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return formatAge(t)
+}
+
+// toggleUnused narrows the list to the models not used for unusedDays, or
+// lists them all again.
+func (m *model) toggleUnused() {
+	m.onlyUnused = !m.onlyUnused
+	m.relist()
+	if !m.onlyUnused {
+		m.status = "Listing all models"
+		return
+	}
+	days := unusedDays(m.cfg)
+	if since := m.usage.Since(m.client.Host()); since.IsZero() || time.Since(since) < time.Duration(days)*24*time.Hour {
+		m.status = fmt.Sprintf("Usage has been recorded for less than %d days, so no model counts as unused yet", days)
+		return
+	}
+	var size int64
+	for _, mdl := range m.visible {
+		size += mdl.Size
+	}
+	m.status = fmt.Sprintf("%d models unused for %d days, %s", len(m.visible), days, formatBytes(uint64(size)))
+}
+
+// openUsageOrWarn opens the usage log for a command, carrying on without
+// one when it can't be read.
+func openUsageOrWarn() *usage.Log {
+	log, err := openUsage()
+	if err != nil {
+		slog.Warn("usage", "err", err)
+		log, _ = usage.Open("")
+	}
+	return log
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/registry"
+	"ollama-manager/internal/store"
+)
+
+// verifyState is a check of one model's blobs on the disk screen.
+type verifyState struct {
+	model   string
+	running bool
+	started time.Time
+	done    int64 // bytes hashed
+	total   int64
+	checks  []store.Check
+	err     error
+	pullErr error // why a repair, which pulls the model again, can't work
+	updates chan tea.Msg
+	cancel  context.CancelFunc
+}
+
+// verifyProgressMsg reports the bytes hashed so far.
+type verifyProgressMsg struct{ done, total int64 }
+
+// verifyDoneMsg carries the finished check.
+type verifyDoneMsg struct {
+	model  string
+	checks []store.Check
+	err    error
+}
+
+// pullableMsg reports whether a damaged model can be pulled again.
+type pullableMsg struct {
+	model string
+	err   error
+}
+
+// repairMsg reports the deletion of a model's corrupt blobs.
+type repairMsg struct {
+	model string
+	count int
+	err   error
+}
+
+// startVerify re-hashes the blobs of the selected model in the background.
+// Progress is sent without blocking, so a slow screen drops updates rather
+// than holding up the hashing.
+func (m model) startVerify() (tea.Model, tea.Cmd) {
+	d := m.disk
+	if d.usage == nil || len(d.usage.Models) == 0 || (d.verify != nil && d.verify.running) {
+		return m, nil
+	}
+	name, dir := d.usage.Models[d.cursor].Name, d.usage.Dir
+	ctx, cancel := context.WithCancel(context.Background())
+	v := &verifyState{model: name, running: true, started: time.Now(), updates: make(chan tea.Msg, 1), cancel: cancel}
+	d.verify = v
+	go func() {
+		defer close(v.updates)
+		checks, err := store.Verify(ctx, dir, name, func(done, total int64) {
+			select {
+			case v.updates <- verifyProgressMsg{done, total}:
+			default:
+			}
+		})
+		v.updates <- verifyDoneMsg{model: name, checks: checks, err: err}
+	}()
+	m.status = "Verifying " + name
+	return m, tea.Batch(listen(v.updates), m.startBusy())
+}
+
+// finishVerify shows the result and offers a repair when layers are bad.
+func (m *model) finishVerify(msg verifyDoneMsg) tea.Cmd {
+	v := m.disk.verify
+	v.running = false
+	m.busy = max(m.busy-1, 0)
+	v.checks, v.err = msg.checks, msg.err
+	switch bad := store.Damaged(msg.checks); {
+	case errors.Is(msg.err, context.Canceled):
+		m.status = "Verification of " + msg.model + " cancelled"
+	case msg.err != nil:
+		m.status = fmt.Sprintf("Verifying %s failed: %v", msg.model, msg.err)
+		m.logError(m.status)
+	case len(bad) > 0:
+		m.status = fmt.Sprintf("%s: %d of %d layers damaged", msg.model, len(bad), len(msg.checks))
+		m.logError(m.status)
+		name := msg.model
+		return tea.Batch(m.notify("verify", v.started, "%s", m.status), func() tea.Msg {
+			return pullableMsg{model: name, err: pullable(name)}
+		})
+	default:
+		m.status = fmt.Sprintf("%s: all %d layers match their digests", msg.model, len(msg.checks))
+		return m.notify("verify", v.started, "%s", m.status)
+	}
+	return nil
+}
+
+// pullable checks that name's registry still has it, since a repair deletes
+// the corrupt layers and pulls them again. A model imported from a GGUF or
+// created from a Modelfile has no registry, and would be lost.
+func pullable(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+	_, err := registry.NewClient().Digest(ctx, name)
+	if errors.Is(err, registry.ErrNotFound) {
+		return fmt.Errorf("%s is not in its registry, probably created locally, so its layers can't be pulled again", name)
+	}
+	if err != nil {
+		return fmt.Errorf("can't tell whether %s can be pulled again: %w", name, err)
+	}
+	return nil
+}
+
+// finishPullable offers the repair of the last check once the model is
+// known to be pullable, and otherwise only reports the damage.
+func (m *model) finishPullable(msg pullableMsg) {
+	v := m.disk.verify
+	if v == nil || v.running || v.model != msg.model {
+		return
+	}
+	v.pullErr = msg.err
+	if msg.err != nil {
+		m.status = fmt.Sprintf("%s: %d layers damaged; not repairing: %v", msg.model, len(store.Damaged(v.checks)), msg.err)
+		m.logError(m.status)
+		return
+	}
+	m.disk.repairAsk = true
+}
+
+// repairBlobs deletes the corrupt blobs of a check, so the pull that
+// follows downloads them again.
+func repairBlobs(name string, checks []store.Check) tea.Cmd {
+	return func() tea.Msg {
+		blobs := store.Corrupt(checks)
+		_, err := store.Prune(blobs)
+		return repairMsg{model: name, count: len(blobs), err: err}
+	}
+}
+
+// finishRepair pulls the model again once its corrupt blobs are gone. The
+// pull fetches only the layers missing from the blob store.
+func (m *model) finishRepair(msg repairMsg) tea.Cmd {
+	if msg.err != nil {
+		m.status = fmt.Sprintf("Deleting the corrupt layers of %s failed: %v", msg.model, msg.err)
+		m.logError(m.status)
+		return nil
+	}
+	m.disk.verify = nil
+	m.queuePull(msg.model)
+	return m.scanDisk()
+}
+
+// verifyView is the disk screen's report of the last check.
+func (m model) verifyView() string {
+	v := m.disk.verify
+	var b strings.Builder
+	switch {
+	case v.running:
+		pct := 0.0
+		if v.total > 0 {
+			pct = float64(v.done) / float64(v.total) * 100
+		}
+		b.WriteString(fmt.Sprintf("%s Verifying %s: %.0f%% of %s", m.spinner.View(), v.model, pct, formatBytes(uint64(v.total))))
+		b.WriteString(helpStyle.Render("  " + helpLine(m.keys.Cancel)))
+		b.WriteString("\n")
+	case errors.Is(v.err, context.Canceled):
+	case v.err != nil:
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Verifying %s: %v", v.model, v.err)))
+		b.WriteString("\n")
+	default:
+		bad := store.Damaged(v.checks)
+		if len(bad) == 0 {
+			b.WriteString(loadedStyle.Render(fmt.Sprintf("%s: all %d layers match their digests", v.model, len(v.checks))))
+			b.WriteString("\n")
+			break
+		}
+		b.WriteString(errorStyle.Render(fmt.Sprintf("%s: %d of %d layers damaged", v.model, len(bad), len(v.checks))))
+		b.WriteString("\n")
+		for _, c := range bad {
+			line := fmt.Sprintf("  %s  %s  %s  %s", c.Digest[:min(len(c.Digest), 19)], c.Kind, formatBytes(uint64(c.Size)), c.Problem)
+			if len(c.Others) > 0 {
+				line += " (also used by " + strings.Join(c.Others, ", ") + ")"
+			}
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+		if v.pullErr != nil {
+			b.WriteString(warnStyle.Render("Not repairable: " + v.pullErr.Error() + ". Restore it from a backup or create it again."))
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// repairPrompt asks before deleting the corrupt layers of the last check.
+func (m model) repairPrompt() string {
+	v := m.disk.verify
+	corrupt := store.Corrupt(v.checks)
+	var size int64
+	for _, bl := range corrupt {
+		size += bl.Size
+	}
+	return modalStyle.Render(fmt.Sprintf(
+		"Delete %d corrupt layers (%s) and pull %s again from %s?\nOnly the missing layers are downloaded; models sharing them need them back too.\n\ny: Repair  any other key: Keep",
+		len(corrupt), formatBytes(uint64(size)), v.model, registryHost(v.model)))
+}
+
+// registryHost is the registry name is pulled from.
+func registryHost(name string) string {
+	host, _, _ := registry.Ref(name)
+	return host
+}
+
+func cmdVerify(c *ollama.Client, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	repair := fs.Bool("repair", false, "delete corrupt layers and pull the damaged models again")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if !c.Local() {
+		return errors.New("verify reads the models directory, so it only works for a local server")
+	}
+	dir, err := store.Dir()
+	if err != nil {
+		return err
+	}
+	names := fs.Args()
+	if len(names) == 0 {
+		u, err := store.Scan(dir)
+		if err != nil {
+			return err
+		}
+		for _, mu := range u.Models {
+			names = append(names, mu.Name)
+		}
+	}
+
+	var damaged []string
+	for _, name := range names {
+		checks, err := store.Verify(context.Background(), dir, name, func(done, total int64) {
+			fmt.Printf("\r  %s %3.0f%% of %s   ", name, float64(done)/float64(max(total, 1))*100, formatBytes(uint64(total)))
+		})
+		fmt.Print("\r\033[K")
+		if err != nil {
+			return err
+		}
+		bad := store.Damaged(checks)
+		if len(bad) == 0 {
+			fmt.Printf("%s: all %d layers match their digests\n", name, len(checks))
+			continue
+		}
+		fmt.Printf("%s: %d of %d layers damaged\n", name, len(bad), len(checks))
+		for _, ch := range bad {
+			fmt.Printf("  %s  %s  %s  %s\n", ch.Digest, ch.Kind, formatBytes(uint64(ch.Size)), ch.Problem)
+		}
+		if !*repair {
+			damaged = append(damaged, name)
+			continue
+		}
+		if err := pullable(name); err != nil {
+			fmt.Printf("  not repairing: %v\n", err)
+			damaged = append(damaged, name)
+			continue
+		}
+		if _, err := store.Prune(store.Corrupt(checks)); err != nil {
+			return fmt.Errorf("deleting the corrupt layers of %s: %w", name, err)
+		}
+		if err := pullPrinting(c, name); err != nil {
+			return fmt.Errorf("pulling %s again: %w", name, err)
+		}
+	}
+	if len(damaged) > 0 {
+		return fmt.Errorf("%d of %d models damaged: %s; run verify --repair to fix them", len(damaged), len(names), strings.Join(damaged, ", "))
+	}
+	return nil
+}
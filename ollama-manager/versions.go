@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/versions"
+)
+
+// openVersions lists the builds of the model named in the editor.
+func (m *model) openVersions() {
+	s := m.modelfile
+	name := strings.TrimSpace(s.name.Value())
+	list := m.modelfiles.Versions(m.client.Host(), name)
+	if len(list) == 0 {
+		s.status = fmt.Sprintf("No versions of %s yet; each build from here adds one", firstNonEmpty(name, "the model"))
+		return
+	}
+	v := &versionsView{model: versions.Name(name)}
+	for i := len(list) - 1; i >= 0; i-- {
+		v.list = append(v.list, list[i])
+	}
+	s.versions = v
+}
+
+func (m model) updateVersions(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := m.modelfile
+	v := s.versions
+	switch {
+	case msg.String() == "esc", key.Matches(msg, m.keys.Versions):
+		s.versions = nil
+	case key.Matches(msg, m.keys.Up):
+		v.cursor = max(v.cursor-1, 0)
+	case key.Matches(msg, m.keys.Down):
+		v.cursor = min(v.cursor+1, len(v.list)-1)
+	case msg.String() == "enter":
+		picked := v.list[v.cursor]
+		s.versions = nil
+		s.editor.SetValue(picked.Text)
+		s.status = fmt.Sprintf("Version %d in the editor; %s builds it", picked.Number, m.keys.Save.Help().Key)
+	case key.Matches(msg, m.keys.Save):
+		// Rolling back rebuilds the model from the version, which then
+		// becomes the newest.
+		picked := v.list[v.cursor]
+		s.versions = nil
+		s.name.SetValue(v.model)
+		s.editor.SetValue(picked.Text)
+		s.note = fmt.Sprintf("rolled back to version %d", picked.Number)
+		return m.startCreate()
+	}
+	return m, nil
+}
+
+func (m model) versionsView() string {
+	v := m.modelfile.versions
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Modelfile versions"))
+	b.WriteString(helpStyle.Render("  " + v.model))
+	b.WriteString("\n\n")
+	for i, ver := range v.list {
+		line := fmt.Sprintf("v%-3d %s", ver.Number, ver.Created.Format("2006-01-02 15:04"))
+		if i == 0 {
+			line += "  (current)"
+		}
+		if ver.Note != "" {
+			line += "  " + ver.Note
+		}
+		if i == v.cursor {
+			b.WriteString(cursorStyle.Render("> " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	picked := v.list[v.cursor]
+	var lines []versions.Line
+	if v.cursor+1 < len(v.list) {
+		prev := v.list[v.cursor+1]
+		b.WriteString(helpStyle.Render(fmt.Sprintf("Changes from version %d:", prev.Number)))
+		lines = versions.Diff(prev.Text, picked.Text)
+		if !versions.Changed(lines) {
+			lines = nil
+			b.WriteString(helpStyle.Render(" none"))
+		}
+	} else {
+		b.WriteString(helpStyle.Render("The oldest version kept:"))
+		lines = versions.Diff(picked.Text, picked.Text)
+	}
+	b.WriteString("\n")
+	room := 20
+	if m.height > 0 {
+		room = max(m.height-len(v.list)-9, 5)
+	}
+	for i, l := range lines {
+		if i == room {
+			b.WriteString(helpStyle.Render(fmt.Sprintf("… %d more lines", len(lines)-room)))
+			b.WriteString("\n")
+			break
+		}
+		text := string(l.Op) + " " + l.Text
+		switch l.Op {
+		case '+':
+			text = loadedStyle.Render(text)
+		case '-':
+			text = errorStyle.Render(text)
+		}
+		b.WriteString(text)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render(helpLine(m.keys.Up, m.keys.Down) + "  Enter: Edit  " +
+		helpLine(relabel(m.keys.Save, "Roll back")) + "  Esc: Back"))
+	b.WriteString("\n")
+	return b.String()
+}
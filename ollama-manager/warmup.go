@@ -0,0 +1,364 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/gpu"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/vram"
+)
+
+// warmupPlan is what activating a warm-up set does.
+type warmupPlan struct {
+	name    string
+	unload  []string
+	load    []string // installed names, in the order of the set
+	opts    map[string]ollama.LoadOptions
+	skipped []string // each with the reason, e.g. "llama3.3:70b (not installed)"
+}
+
+// warmupDoneMsg reports how activating a warm-up set went.
+type warmupDoneMsg struct {
+	plan    warmupPlan
+	stopped []batchResult
+	loaded  []batchResult
+}
+
+// planWarmup works out how to get from the running models to exactly the
+// models of w. With GPU readings (gpuOK), models are admitted in the order
+// listed while their estimate fits the VRAM left once everything else is
+// unloaded; the rest are skipped rather than left to spill onto the CPU.
+func planWarmup(w config.Warmup, installed []ollama.Model, running []ollama.RunningModel,
+	archOf func(ollama.Model) vram.Arch, free uint64, gpuOK bool, defaults func(string) ollama.LoadOptions) warmupPlan {
+	p := warmupPlan{name: w.Name, opts: make(map[string]ollama.LoadOptions)}
+
+	want := make(map[string]bool)
+	for _, wm := range w.Models {
+		if mdl, ok := findModel(installed, wm.Name); ok {
+			want[mdl.Name] = true
+		}
+	}
+	for _, r := range running {
+		if !want[r.Name] {
+			p.unload = append(p.unload, r.Name)
+			free += uint64(r.SizeVRAM)
+		}
+	}
+
+	loaded := loadedSet(running)
+	for _, wm := range w.Models {
+		mdl, ok := findModel(installed, wm.Name)
+		if !ok {
+			p.skipped = append(p.skipped, wm.Name+" (not installed)")
+			continue
+		}
+		if _, dup := p.opts[mdl.Name]; dup {
+			continue
+		}
+		opts := defaults(mdl.Name)
+		if wm.KeepAlive != "" {
+			d, err := ollama.ParseKeepAlive(wm.KeepAlive)
+			if err != nil {
+				p.skipped = append(p.skipped, fmt.Sprintf("%s (%v)", mdl.Name, err))
+				continue
+			}
+			opts.KeepAlive = d
+		}
+		if gpuOK && !loaded[mdl.Name] {
+			arch := archOf(mdl)
+			ctx := arch.Context
+			if ctx == 0 {
+				ctx = vram.DefaultContext
+			}
+			need := vram.ForModel(mdl.Size, arch, ctx).Total()
+			if vram.Check(need, free) == vram.WontFit {
+				p.skipped = append(p.skipped, fmt.Sprintf("%s (needs ~%s, %s free)", mdl.Name, formatBytes(need), formatBytes(free)))
+				continue
+			}
+			free -= need
+		}
+		p.load = append(p.load, mdl.Name)
+		p.opts[mdl.Name] = opts
+	}
+	return p
+}
+
+// findModel looks name up among the installed models, taking a name
+// without a tag to mean ":latest" as Ollama does.
+func findModel(installed []ollama.Model, name string) (ollama.Model, bool) {
+	for _, mdl := range installed {
+		if mdl.Name == name || (!strings.Contains(name, ":") && mdl.Name == name+":latest") {
+			return mdl, true
+		}
+	}
+	return ollama.Model{}, false
+}
+
+// runWarmup unloads, then loads, each step a batch.
+func runWarmup(c *ollama.Client, p warmupPlan) warmupDoneMsg {
+	stopped := runBatch(p.unload, func(name string) error {
+		return stopModel(c, name)
+	})
+	loaded := runBatch(p.load, func(name string) error {
+		return loadModel(c, name, p.opts[name])
+	})
+	return warmupDoneMsg{plan: p, stopped: stopped, loaded: loaded}
+}
+
+func (m model) openWarmups() (tea.Model, tea.Cmd) {
+	if len(m.cfg.Warmups) == 0 {
+		m.status = "No warm-up sets; add them under \"warmups\" in " + m.cfg.Path()
+		return m, nil
+	}
+	m.mode = modeWarmup
+	m.warmupCursor = min(m.warmupCursor, len(m.cfg.Warmups)-1)
+	return m, nil
+}
+
+func (m model) updateWarmups(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch s := msg.String(); {
+	case s == "esc", key.Matches(msg, m.keys.Quit, m.keys.Warmup):
+		m.mode = modeList
+	case key.Matches(msg, m.keys.Up):
+		m.warmupCursor = max(m.warmupCursor-1, 0)
+	case key.Matches(msg, m.keys.Down):
+		m.warmupCursor = min(m.warmupCursor+1, len(m.cfg.Warmups)-1)
+	case s == "enter":
+		m.mode = modeList
+		return m, m.activateWarmup(m.cfg.Warmups[m.warmupCursor])
+	case len(s) == 1 && s[0] >= '1' && s[0] <= '9':
+		if i := int(s[0] - '1'); i < len(m.cfg.Warmups) {
+			m.mode = modeList
+			m.warmupCursor = i
+			return m, m.activateWarmup(m.cfg.Warmups[i])
+		}
+	}
+	return m, nil
+}
+
+// activateWarmup plans w against the latest refresh and runs it in the
+// background.
+func (m *model) activateWarmup(w config.Warmup) tea.Cmd {
+	running := make([]ollama.RunningModel, 0, len(m.running))
+	for _, r := range m.running {
+		running = append(running, r)
+	}
+	free, ok := m.freeVRAM()
+	archOf := func(mdl ollama.Model) vram.Arch { return m.arch[archKey(mdl)] }
+	p := planWarmup(w, m.models, running, archOf, free, ok, m.loadOptions)
+
+	c := m.client
+	m.status = fmt.Sprintf("Activating %s...", w.Name)
+	slog.Info("op", "action", "warm-up", "set", w.Name, "unload", p.unload, "load", p.load)
+	for _, name := range p.load {
+		if !m.loaded[name] {
+			m.loading[name] = false
+		}
+	}
+	m.syncTable()
+	op := func() tea.Msg {
+		return runWarmup(c, p)
+	}
+	return tea.Batch(op, m.startBusy())
+}
+
+// finishWarmup logs each skipped model and failure separately and
+// summarises the rest.
+func (m *model) finishWarmup(msg warmupDoneMsg) tea.Cmd {
+	m.busy = max(m.busy-1, 0)
+	p := msg.plan
+	problems := len(p.skipped)
+	for _, s := range p.skipped {
+		m.logError(fmt.Sprintf("Warm-up %s skipped %s", p.name, s))
+	}
+	for _, r := range msg.stopped {
+		if r.err != nil {
+			problems++
+			m.logError(fmt.Sprintf("Stopping %s failed: %v", r.name, r.err))
+		}
+	}
+	loaded := 0
+	for _, r := range msg.loaded {
+		if r.err != nil {
+			problems++
+			m.logError(fmt.Sprintf("Loading %s failed: %v", r.name, r.err))
+			delete(m.loading, r.name)
+			continue
+		}
+		loaded++
+		if _, ok := m.loading[r.name]; ok {
+			m.loading[r.name] = true
+		}
+	}
+	m.syncTable()
+	m.status = fmt.Sprintf("Activated %s: %d loaded, %d unloaded", p.name, loaded, len(p.unload))
+	if problems > 0 {
+		m.status += fmt.Sprintf(", %d skipped or failed (E: show errors)", problems)
+	}
+	return m.refresh()
+}
+
+// warmupActive reports whether exactly the models of w are loaded.
+func (m model) warmupActive(w config.Warmup) bool {
+	n := 0
+	for _, wm := range w.Models {
+		mdl, ok := findModel(m.models, wm.Name)
+		if !ok || !m.loaded[mdl.Name] {
+			return false
+		}
+		n++
+	}
+	return n > 0 && n == len(m.loaded)
+}
+
+func (m model) warmupsView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Warm-up Sets"))
+	b.WriteString("\n\n")
+
+	for i, w := range m.cfg.Warmups {
+		names := make([]string, len(w.Models))
+		for j, wm := range w.Models {
+			names[j] = wm.Name
+		}
+		line := fmt.Sprintf("%d %-12s %s", i+1, w.Name, helpStyle.Render(strings.Join(names, ", ")))
+		if m.warmupActive(w) {
+			line = loadedStyle.Render("● ") + line
+		} else {
+			line = "  " + line
+		}
+		if i == m.warmupCursor {
+			b.WriteString(cursorStyle.Render("> "))
+		} else {
+			b.WriteString("  ")
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("Activating a set unloads every other model."))
+	b.WriteString("\n\n")
+	b.WriteString(helpStyle.Render(helpLine(m.keys.Up, m.keys.Down) + "  Enter/1-9: Activate  Esc: Back"))
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("\nStatus: %s", m.status))
+	return b.String()
+}
+
+func cmdWarmup(c *ollama.Client, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		if len(cfg.Warmups) == 0 {
+			fmt.Printf("No warm-up sets; add them under \"warmups\" in %s\n", cfg.Path())
+		}
+		for _, w := range cfg.Warmups {
+			names := make([]string, len(w.Models))
+			for i, wm := range w.Models {
+				names[i] = wm.Name
+			}
+			fmt.Printf("%-12s %s\n", w.Name, strings.Join(names, ", "))
+		}
+		return nil
+	}
+	if len(args) > 1 {
+		return usageError{"usage: warmup [set]"}
+	}
+	w, ok := cfg.Warmup(args[0])
+	if !ok {
+		return usageError{fmt.Sprintf("unknown warm-up set %q", args[0])}
+	}
+	p, err := planWarmupFor(c, cfg, w)
+	if err != nil {
+		return err
+	}
+	return reportWarmup(runWarmup(c, p))
+}
+
+// planWarmupFor plans w against what the server reports right now, reading
+// VRAM on this machine when the server runs here.
+func planWarmupFor(c *ollama.Client, cfg *config.Config, w config.Warmup) (warmupPlan, error) {
+	installed, err := getModels(c)
+	if err != nil {
+		return warmupPlan{}, err
+	}
+	running, err := getRunning(c)
+	if err != nil {
+		return warmupPlan{}, err
+	}
+	var free uint64
+	var gpuOK bool
+	if c.Local() {
+		if gpus, err := gpu.Query(); err == nil && len(gpus) > 0 {
+			for _, d := range gpus {
+				free += d.MemoryFree()
+			}
+			gpuOK = true
+		}
+	}
+	archOf := func(mdl ollama.Model) vram.Arch {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		info, err := c.Show(ctx, mdl.Name)
+		if err != nil {
+			return vram.Arch{}
+		}
+		return vram.ArchFromShow(info)
+	}
+	defaults := func(name string) ollama.LoadOptions {
+		return configLoadOptions(cfg, name)
+	}
+	return planWarmup(w, installed, running, archOf, free, gpuOK, defaults), nil
+}
+
+// reportWarmup prints the outcome of a warm-up, failing if any model could
+// not be stopped, loaded or fitted.
+func reportWarmup(msg warmupDoneMsg) error {
+	for _, r := range msg.stopped {
+		if r.err == nil {
+			fmt.Printf("Unloaded %s\n", r.name)
+		}
+	}
+	for _, r := range msg.loaded {
+		if r.err == nil {
+			fmt.Printf("Loaded %s\n", r.name)
+		}
+	}
+	for _, s := range msg.plan.skipped {
+		fmt.Printf("Skipped %s\n", s)
+	}
+	return msg.err()
+}
+
+// err summarises what went wrong, or is nil if every model of the set is
+// loaded and every other one unloaded.
+func (msg warmupDoneMsg) err() error {
+	var failed []string
+	for _, r := range msg.stopped {
+		if r.err != nil {
+			failed = append(failed, fmt.Sprintf("unload %s: %v", r.name, r.err))
+		}
+	}
+	for _, r := range msg.loaded {
+		if r.err != nil {
+			failed = append(failed, fmt.Sprintf("load %s: %v", r.name, r.err))
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "; "))
+	}
+	if len(msg.plan.skipped) > 0 {
+		return fmt.Errorf("%d of the models in %s were skipped", len(msg.plan.skipped), msg.plan.name)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/notify"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/service"
+	"ollama-manager/internal/webhook"
+)
+
+const (
+	defaultWatchInterval = 30 * time.Second
+	defaultWatchTimeout  = 10 * time.Second
+	defaultWatchFailures = 3
+
+	// A restart that doesn't bring the server back for good is followed by
+	// the next only after a backoff, doubling from watchBackoff up to
+	// watchBackoffMax. The server has to stay up for watchSettle before the
+	// backoff starts over.
+	watchBackoff    = time.Minute
+	watchBackoffMax = 30 * time.Minute
+	watchSettle     = 10 * time.Minute
+)
+
+// watchdog restarts a server that stopped answering: one that crashed or
+// was killed for lack of memory, or that hangs, as happens after sleep.
+type watchdog struct {
+	interval time.Duration
+	timeout  time.Duration
+	failures int
+
+	failed   int       // checks failed in a row
+	restarts int       // restarts since the server last settled
+	next     time.Time // no restart before this
+	upSince  time.Time // first good check since the last failure
+}
+
+// newWatchdog parses the watchdog config; it returns nil when the feature
+// is off.
+func newWatchdog(cfg *config.Watchdog) (*watchdog, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	w := &watchdog{interval: defaultWatchInterval, timeout: defaultWatchTimeout, failures: orDefault(cfg.Failures, defaultWatchFailures)}
+	for _, f := range []struct {
+		name, value string
+		into        *time.Duration
+	}{{"interval", cfg.Interval, &w.interval}, {"timeout", cfg.Timeout, &w.timeout}} {
+		if f.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(f.value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("watchdog: %s must be a duration such as 30s, not %q", f.name, f.value)
+		}
+		*f.into = d
+	}
+	return w, nil
+}
+
+// String describes the checks, for the daemon's summary.
+func (w *watchdog) String() string {
+	return fmt.Sprintf("every %s, restarting after %d failed checks", w.interval, w.failures)
+}
+
+// observe records a check and reports whether the service should be
+// restarted now.
+func (w *watchdog) observe(now time.Time, err error) bool {
+	if err == nil {
+		w.failed = 0
+		if w.upSince.IsZero() {
+			w.upSince = now
+		}
+		if w.restarts > 0 && now.Sub(w.upSince) >= watchSettle {
+			w.restarts = 0
+		}
+		return false
+	}
+	w.upSince = time.Time{}
+	w.failed++
+	return w.failed >= w.failures && !now.Before(w.next)
+}
+
+// restarted starts the backoff before the next restart.
+func (w *watchdog) restarted(now time.Time) {
+	w.failed = 0
+	w.restarts++
+	backoff := watchBackoffMax
+	if shift := w.restarts - 1; shift < 16 {
+		backoff = min(watchBackoff<<shift, watchBackoffMax)
+	}
+	w.next = now.Add(backoff)
+}
+
+// runWatchdog checks the server until ctx is done, for the daemon.
+func runWatchdog(ctx context.Context, c *ollama.Client, w *watchdog, name string, cfg *config.Config, a *alerts) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		check, cancel := context.WithTimeout(ctx, w.timeout)
+		_, err := c.Version(check)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		now := time.Now()
+		if !w.observe(now, err) {
+			continue
+		}
+
+		reason := "not answering"
+		if ollama.IsUnreachable(err) {
+			reason = "down"
+		}
+		ctrl, cancel := context.WithTimeout(ctx, serviceTimeout)
+		err = service.Control(ctrl, name, service.Restart)
+		if err == nil {
+			err = waitForAPI(ctrl, c)
+		}
+		cancel()
+		w.restarted(now)
+		text := fmt.Sprintf("Ollama was %s for %d checks in a row; restarted it", reason, w.failures)
+		if err != nil {
+			text = fmt.Sprintf("Ollama was %s for %d checks in a row; restarting it failed: %v", reason, w.failures, err)
+		}
+		if w.restarts > 1 {
+			text += fmt.Sprintf(" (restart %d; the next waits until %s)", w.restarts, w.next.Format("15:04"))
+		}
+		fmt.Printf("%s %s\n", now.Format("2006-01-02 15:04"), text)
+		announce(cfg, "watchdog", text)
+		if a != nil {
+			if err := a.post([]webhook.Event{newEvent(c.Host(), "watchdog", text)}); err != nil {
+				fmt.Printf("%s %v\n", time.Now().Format("2006-01-02 15:04"), err)
+			}
+		}
+	}
+}
+
+// announce notifies of an event outside the TUI, as the daemon does: there
+// is no focus to go by and nothing was started by hand.
+func announce(cfg *config.Config, event, text string) {
+	switch cfg.NotifyFor(event) {
+	case "desktop":
+		err := notify.Send("Ollama Manager", text)
+		if err == nil {
+			return
+		}
+		slog.Debug("notify", "err", err)
+		notify.Bell()
+	case "bell":
+		notify.Bell()
+	}
+}
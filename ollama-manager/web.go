@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/gpu"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/pullqueue"
+	"ollama-manager/internal/web"
+)
+
+// webBackend runs the dashboard's actions the way the TUI does: loads with
+// the configured keep-alive, unloads with the CLI fallback and pulls
+// through a queue with the configured concurrency and rate cap.
+type webBackend struct {
+	c     *ollama.Client
+	cfg   *config.Config
+	pulls *pullqueue.Queue
+}
+
+func (b *webBackend) State(ctx context.Context) (web.State, error) {
+	models, err := getModels(b.c)
+	if err != nil {
+		return web.State{}, err
+	}
+	running, _ := getRunning(b.c)
+	byName := make(map[string]ollama.RunningModel, len(running))
+	for _, r := range running {
+		byName[r.Name] = r
+	}
+	st := web.State{Host: b.c.Host(), Models: make([]web.Model, 0, len(models)), Pulls: []web.Pull{}}
+	for _, m := range models {
+		if b.cfg.IsHidden(m.Name) {
+			continue
+		}
+		wm := web.Model{Name: m.Name, Size: m.Size, ParameterSize: m.Details.ParameterSize, Quantization: m.Details.QuantizationLevel}
+		if r, ok := byName[m.Name]; ok {
+			wm.Loaded, wm.SizeVRAM, wm.ExpiresAt = true, r.SizeVRAM, r.ExpiresAt
+		}
+		st.Models = append(st.Models, wm)
+	}
+	var gpuErr error
+	if b.c.Local() {
+		st.GPUs, gpuErr = gpu.Query()
+	} else {
+		gpuErr = errRemoteGPU
+	}
+	if gpuErr != nil {
+		st.GPUError = gpuErr.Error()
+	}
+	for _, it := range b.pulls.Items() {
+		p := web.Pull{Name: it.Name, State: it.State.String(), Status: it.Status, Progress: it.Progress(), Rate: it.Rate}
+		if it.Err != nil {
+			p.Status = it.Err.Error()
+		}
+		st.Pulls = append(st.Pulls, p)
+	}
+	return st, nil
+}
+
+func (b *webBackend) Load(ctx context.Context, name string) error {
+	return b.c.Load(ctx, name, configLoadOptions(b.cfg, name))
+}
+
+func (b *webBackend) Unload(ctx context.Context, name string) error {
+	return stopModel(b.c, name)
+}
+
+func (b *webBackend) Pull(name string) error {
+	if !b.pulls.Add(b.c, name) {
+		return fmt.Errorf("%s is already being pulled", name)
+	}
+	return nil
+}
+
+func cmdWeb(c *ollama.Client, args []string) error {
+	if len(args) > 1 {
+		return usageError{"usage: web [listen], e.g. web :8080"}
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	listen, token := web.DefaultListen, ""
+	if cfg.Web != nil {
+		listen, token = firstNonEmpty(cfg.Web.Listen, listen), cfg.Web.Token
+	}
+	if len(args) == 1 {
+		listen = args[0]
+	}
+	if token == "" {
+		// A fresh token each run, printed in the link below.
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			return err
+		}
+		token = hex.EncodeToString(buf)
+	}
+	pulls, err := newPullQueue(cfg)
+	if err != nil {
+		return err
+	}
+	b := &webBackend{c: c, cfg: cfg, pulls: pulls}
+
+	fmt.Printf("Managing %s\n", c.Host())
+	fmt.Printf("Open %s/?token=%s\n", webURL(listen), token)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return web.Serve(ctx, listen, web.Handler(b, token))
+}
+
+// webURL is the dashboard's address for a browser, naming this machine
+// when listen leaves the host out.
+func webURL(listen string) string {
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "http://" + listen
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		if host, err = os.Hostname(); err != nil {
+			host = "localhost"
+		}
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/webhook"
+)
+
+const (
+	defaultGPUTempAlert = 85 // °C
+
+	// gpuTempReset is how far a GPU has to cool below the alert before it
+	// can send another, so one hovering at the threshold sends once.
+	gpuTempReset = 5
+)
+
+var hookEvents = []string{"load", "pull", "gpu_temp", "thermal", "server_down", "server_up", "watchdog"}
+
+// webhookFailedMsg reports deliveries that failed.
+type webhookFailedMsg struct{ err error }
+
+// checkWebhooks reports the first webhook with a bad URL, format or event.
+func checkWebhooks(cfg *config.Config) error {
+	for i, h := range cfg.Webhooks {
+		u, err := url.Parse(h.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhooks[%d]: url must be an http:// or https:// address", i)
+		}
+		if h.Format != "" && !slices.Contains(webhook.Formats, h.Format) {
+			return fmt.Errorf("webhooks[%d]: unknown format %q (valid: %s)", i, h.Format, strings.Join(webhook.Formats, ", "))
+		}
+		for _, e := range h.Events {
+			if !slices.Contains(hookEvents, e) {
+				return fmt.Errorf("webhooks[%d]: unknown event %q (valid: %s)", i, e, strings.Join(hookEvents, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// alerts turns refreshes into webhook events. Only changes are sent: a
+// server that stays down is reported once, and models already loaded when
+// watching starts aren't reported at all.
+type alerts struct {
+	hooks   []config.Webhook
+	tempMax int
+
+	down   map[string]bool            // by host
+	loaded map[string]map[string]bool // by host, from the last good refresh
+	hot    map[int]bool               // by GPU index
+
+	// daemon is set when a daemon watches the server; refreshes are left
+	// to it so events aren't sent twice.
+	daemon bool
+}
+
+// newAlerts returns nil when no webhooks are configured.
+func newAlerts(cfg *config.Config) *alerts {
+	if len(cfg.Webhooks) == 0 {
+		return nil
+	}
+	return &alerts{
+		hooks:   cfg.Webhooks,
+		tempMax: orDefault(cfg.GPUTempAlert, defaultGPUTempAlert),
+		down:    make(map[string]bool),
+		loaded:  make(map[string]map[string]bool),
+		hot:     make(map[int]bool),
+	}
+}
+
+// leaveToDaemon stops observing refreshes, which a daemon sends events
+// for. Events only the TUI sees, such as its own pulls, are still sent.
+func (a *alerts) leaveToDaemon() {
+	if a != nil {
+		a.daemon = true
+	}
+}
+
+// observe compares a refresh with the previous one of its host.
+func (a *alerts) observe(msg refreshMsg) []webhook.Event {
+	if a == nil || a.daemon {
+		return nil
+	}
+	var evs []webhook.Event
+	add := func(event, format string, args ...any) {
+		evs = append(evs, newEvent(msg.host, event, fmt.Sprintf(format, args...)))
+	}
+
+	if down := msg.err != nil; down != a.down[msg.host] {
+		a.down[msg.host] = down
+		if down {
+			// Both requests of a refresh fail alike; one reason will do.
+			reason, _, _ := strings.Cut(msg.err.Error(), "\n")
+			add("server_down", "Ollama is unreachable: %s", reason)
+		} else {
+			add("server_up", "Ollama is reachable again")
+		}
+	}
+	if msg.err == nil {
+		prev, seen := a.loaded[msg.host]
+		now := loadedSet(msg.running)
+		for _, r := range msg.running {
+			if seen && !prev[r.Name] {
+				add("load", "Loaded %s (%s in VRAM)", r.Name, formatBytes(uint64(r.SizeVRAM)))
+			}
+		}
+		a.loaded[msg.host] = now
+	}
+	for _, d := range msg.gpus {
+		switch {
+		case d.Temperature >= a.tempMax && !a.hot[d.Index]:
+			a.hot[d.Index] = true
+			add("gpu_temp", "GPU %d (%s) is at %d°C, alert at %d°C", d.Index, d.Name, d.Temperature, a.tempMax)
+		case d.Temperature < a.tempMax-gpuTempReset:
+			a.hot[d.Index] = false
+		}
+	}
+	return evs
+}
+
+func newEvent(host, event, text string) webhook.Event {
+	return webhook.Event{Event: event, Host: host, Text: text, Time: time.Now()}
+}
+
+// post delivers evs to every webhook that wants them.
+func (a *alerts) post(evs []webhook.Event) error {
+	var errs []error
+	for _, ev := range evs {
+		slog.Info("webhook", "event", ev.Event, "text", ev.Text)
+		for i, h := range a.hooks {
+			if !h.Wants(ev.Event) {
+				continue
+			}
+			if err := webhook.Post(context.Background(), h.URL, h.Format, ev); err != nil {
+				errs = append(errs, fmt.Errorf("webhooks[%d] %s: %w", i, ev.Event, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// send posts evs in the background.
+func (a *alerts) send(evs ...webhook.Event) tea.Cmd {
+	if a == nil || len(evs) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		if err := a.post(evs); err != nil {
+			return webhookFailedMsg{err}
+		}
+		return nil
+	}
+}
+
+// alert sends one event about the current host.
+func (m model) alert(event, format string, args ...any) tea.Cmd {
+	return m.alerts.send(newEvent(m.client.Host(), event, fmt.Sprintf(format, args...)))
+}
+
+// runAlerts polls the server for the daemon, sending what the TUI would
+// send from its refreshes.
+func runAlerts(ctx context.Context, c *ollama.Client, a *alerts) {
+	ticker := time.NewTicker(idlePoll)
+	defer ticker.Stop()
+	for {
+		if err := a.post(a.observe(snapshot(c, nil))); err != nil {
+			fmt.Printf("%s %v\n", time.Now().Format("2006-01-02 15:04"), err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
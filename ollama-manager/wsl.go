@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"ollama-manager/internal/config"
+	"ollama-manager/internal/ollama"
+	"ollama-manager/internal/wsl"
+)
+
+// wslProfilePrefix names the session profiles added for WSL servers, e.g.
+// "wsl:Ubuntu".
+const wslProfilePrefix = "wsl:"
+
+// wslMsg carries what wsl.Detect found.
+type wslMsg struct {
+	report *wsl.Report
+	err    error
+}
+
+// fetchWSL looks for native and WSL servers on Windows, and is nil
+// elsewhere.
+func (m model) fetchWSL() tea.Cmd {
+	if runtime.GOOS != "windows" {
+		return nil
+	}
+	port := m.localPort()
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+		defer cancel()
+		r, err := wsl.Detect(ctx, port)
+		return wslMsg{report: r, err: err}
+	}
+}
+
+// finishWSL keeps the report, offers each running WSL server as a host for
+// the session and warns once when a native one runs alongside.
+func (m *model) finishWSL(msg wslMsg) {
+	if msg.err != nil {
+		m.logError(fmt.Sprintf("Could not look for Ollama under WSL: %v", msg.err))
+		return
+	}
+	m.wslTargets = msg.report
+	if msg.report == nil {
+		return
+	}
+	for _, d := range msg.report.Running() {
+		if d.Addr == "" {
+			continue
+		}
+		p := config.Profile{Name: wslProfilePrefix + d.Name, Host: net.JoinHostPort(d.Addr, m.localPort())}
+		if i := m.profileIndex(p.Name); i >= 0 {
+			// The distribution's address changes when WSL restarts.
+			m.hosts[i].Host = p.Host
+		} else {
+			m.hosts = append(m.hosts, p)
+		}
+	}
+	if msg.report.Conflict() && !m.wslWarned {
+		m.wslWarned = true
+		m.status = wslConflict(msg.report)
+		m.logError(m.status)
+	}
+}
+
+// profileIndex returns the index of the host profile named name, or -1.
+func (m model) profileIndex(name string) int {
+	for i, p := range m.hosts {
+		if p.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// sameHost reports whether a profile's host is the base URL given.
+func sameHost(host, base string) bool {
+	h, _, err := ollama.ParseHost(host)
+	return err == nil && h == base
+}
+
+// wslConflict explains who localhost reaches when both kinds of server run.
+func wslConflict(r *wsl.Report) string {
+	var names []string
+	for _, d := range r.Running() {
+		names = append(names, d.Name)
+	}
+	return fmt.Sprintf("Ollama runs both natively and under WSL (%s); localhost reaches only the native one. Stop one of them.",
+		strings.Join(names, ", "))
+}
+
+// wslView lists the servers found on this Windows machine for the server
+// panel, empty when there is nothing under WSL to tell apart.
+func (m model) wslView() string {
+	r := m.wslTargets
+	if r == nil || len(r.Distros) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Windows and WSL"))
+	b.WriteString("\n\n")
+
+	// The managed server is a WSL one reached at its own address, or
+	// whichever holds localhost when the default host is active.
+	managed := ""
+	for _, d := range r.Running() {
+		if i := m.profileIndex(wslProfilePrefix + d.Name); i >= 0 && sameHost(m.hosts[i].Host, m.client.Host()) {
+			managed = d.Name
+		}
+	}
+	if managed == "" && sameHost(m.hosts[0].Host, m.client.Host()) {
+		managed = r.Owner
+		if running := r.Running(); r.Owner == wsl.WSL && len(running) == 1 {
+			managed = running[0].Name
+		}
+	}
+	mark := func(name string) string {
+		if name == managed {
+			return loadedStyle.Render("  ● managed")
+		}
+		return ""
+	}
+
+	native := "not installed"
+	switch {
+	case r.NativePID > 0:
+		native = fmt.Sprintf("running (pid %d)", r.NativePID)
+	case r.Native:
+		native = "stopped"
+	}
+	b.WriteString(labelStyle.Render("Windows") + native + mark(wsl.Windows) + "\n")
+	for _, d := range r.Distros {
+		state := "WSL, installed, not running"
+		if d.Running {
+			state = "WSL, running at " + firstNonEmpty(d.Addr, "an unknown address")
+		}
+		b.WriteString(labelStyle.Render(d.Name) + state + mark(d.Name) + "\n")
+	}
+
+	if r.Conflict() {
+		b.WriteString("\n")
+		b.WriteString(warnStyle.Render(wslConflict(r)))
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render("To manage the WSL one while both run, it must listen on its WSL address: set OLLAMA_HOST=0.0.0.0 in the distribution."))
+		b.WriteString("\n")
+	}
+	if len(r.Running()) > 0 {
+		b.WriteString(helpStyle.Render("Switch between them with " + m.keys.Hosts.Help().Key + "; WSL servers appear as wsl:<distribution>."))
+		b.WriteString("\n")
+	}
+	return b.String()
+}